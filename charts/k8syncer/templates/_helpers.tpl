@@ -4,4 +4,96 @@
 {{- else -}}
 {{ required "$.repository is required" $.repository }}:{{ required "$.tag is required" $.tag }}
 {{- end -}}
-{{- end -}}
\ No newline at end of file
+{{- end -}}
+
+{{- define "podspec" -}}
+{{- if .Values.image.pullSecrets }}
+imagePullSecrets:
+{{- range .Values.image.pullSecrets }}
+- name: {{ . }}
+{{- end }}
+{{- end }}
+{{- if not (or .Values.config.kubeconfig .Values.config.cluster) }}
+serviceAccountName: k8syncer
+{{- end }}
+{{- if .Values.oneShot }}
+restartPolicy: {{ .Values.oneShot.restartPolicy | default "OnFailure" }}
+{{- end }}
+containers:
+- name: k8syncer
+  image: "{{ include "image" .Values.image }}"
+  imagePullPolicy: IfNotPresent
+  command:
+  - /k8syncer
+  - --config=/etc/config/config.yaml
+  {{- if .Values.oneShot }}
+  - --once
+  {{- if .Values.oneShot.checkpoint }}
+  - --once-checkpoint={{ .Release.Namespace }}/k8syncer-checkpoint
+  {{- end }}
+  {{- end }}
+  {{- if or .Values.config.kubeconfig .Values.config.cluster }}
+  - --kubeconfig=/etc/config
+  {{- end }}
+  {{- if .Values.logging }}
+  {{- if .Values.logging.verbosity }}
+  - -v={{ .Values.logging.verbosity }}
+  {{- end }}
+  {{- end }}
+  volumeMounts:
+  - name: config
+    mountPath: /etc/config
+    readOnly: true
+  {{- if .Values.sidecars }}
+  - name: plugin-sockets
+    mountPath: /var/run/k8syncer/plugins
+  {{- end }}
+  resources:
+    requests:
+      cpu: {{ .Values.resources.requests.cpu | default "100m" }}
+      memory: {{ .Values.resources.requests.memory | default "256Mi" }}
+    {{- if .Values.resources.limits }}
+    limits:
+    {{- .Values.resources.limits | toYaml | nindent 6 }}
+    {{- end }}
+{{- if .Values.sidecars }}
+{{- .Values.sidecars | toYaml | nindent 0 }}
+{{- end }}
+volumes:
+{{- if .Values.sidecars }}
+- name: plugin-sockets
+  emptyDir: {}
+{{- end }}
+- name: config
+  projected:
+    sources:
+    - secret:
+        name: k8syncer-config
+    {{- if or .Values.config.kubeconfig .Values.config.cluster }}
+    {{- if .Values.config.kubeconfig }}
+    - secret:
+        name: k8syncer-target
+    {{- else }}
+    - secret:
+        name: k8syncer-target
+        items:
+        - key: host
+          path: host
+        {{- if .Values.config.cluster.caData }}
+        - key: caData
+          path: ca.crt
+        {{- end }}
+    - serviceAccountToken:
+        path: token
+        expirationSeconds: 7200
+        audience: {{ .Values.config.cluster.audience }}
+    {{- if .Values.config.cluster.caConfigMapName }}
+    - configMap:
+        name: {{ .Values.config.cluster.caConfigMapName }}
+        items:
+        - key: ca.crt
+          path: ca.crt
+    {{- end }}
+    {{- end }}
+    {{- end }}
+{{- end -}}
@@ -4,4 +4,7 @@ rbac.authorization.k8s.io/v1
 
 {{- define "deploymentversion" -}}
 apps/v1
-{{- end -}}
\ No newline at end of file
+{{- end -}}
+{{- define "cronjobversion" -}}
+batch/v1
+{{- end -}}
@@ -6,21 +6,44 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/gardener/landscaper/controller-utils/pkg/logging"
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/rest"
 	ctrlrun "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	"github.com/gardener/k8syncer/pkg/admission"
 	"github.com/gardener/k8syncer/pkg/config"
 	"github.com/gardener/k8syncer/pkg/controller"
 	"github.com/gardener/k8syncer/pkg/persist"
+	clusterpersist "github.com/gardener/k8syncer/pkg/persist/cluster"
 	fspersist "github.com/gardener/k8syncer/pkg/persist/filesystem"
 	gitpersist "github.com/gardener/k8syncer/pkg/persist/git"
+	kubepersist "github.com/gardener/k8syncer/pkg/persist/kubernetes"
+	kvpersist "github.com/gardener/k8syncer/pkg/persist/kv"
 	mockpersist "github.com/gardener/k8syncer/pkg/persist/mock"
+	pluginpersist "github.com/gardener/k8syncer/pkg/persist/plugin"
+	remotepersist "github.com/gardener/k8syncer/pkg/persist/remote"
+	wikipersist "github.com/gardener/k8syncer/pkg/persist/wiki"
+	"github.com/gardener/k8syncer/pkg/pruning"
+	"github.com/gardener/k8syncer/pkg/snapshot"
+	"github.com/gardener/k8syncer/pkg/state"
+	"github.com/gardener/k8syncer/pkg/syncerrors"
+	"github.com/gardener/k8syncer/pkg/utils"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
 )
 
 // NewK8SyncerCommand creates a new k8syncer command that runs the git sync controller.
@@ -37,6 +60,13 @@ func NewK8SyncerCommand(ctx context.Context) *cobra.Command {
 				os.Exit(1)
 			}
 			ctx = logging.NewContext(ctx, options.Log)
+			if options.Once {
+				if err := options.runOnce(ctx); err != nil {
+					options.Log.Error(err, "one-shot sync failed")
+					os.Exit(1)
+				}
+				return
+			}
 			if err := options.run(ctx); err != nil {
 				options.Log.Error(err, "unable to run k8syncer controller")
 				os.Exit(1)
@@ -45,6 +75,8 @@ func NewK8SyncerCommand(ctx context.Context) *cobra.Command {
 	}
 
 	options.AddFlags(cmd.Flags())
+	cmd.AddCommand(NewDevCommand(ctx))
+	cmd.AddCommand(NewGenerateCommand())
 
 	return cmd
 }
@@ -53,42 +85,354 @@ func (o *Options) run(ctx context.Context) error {
 	logger := o.Log.WithName("k8syncer")
 	ctx = logging.NewContext(ctx, logger)
 
+	// the error cache is exposed via metrics and a debug endpoint on the metrics server
+	var syncErrorMetricsCfg *config.SyncErrorMetricsConfiguration
+	if o.Config.Metrics != nil {
+		syncErrorMetricsCfg = o.Config.Metrics.SyncErrors
+	}
+	errorCache := syncerrors.NewCache(syncErrorMetricsCfg)
+	if err := metrics.Registry.Register(errorCache.Collector()); err != nil {
+		return fmt.Errorf("unable to register sync error metrics: %w", err)
+	}
+	if err := metrics.Registry.Register(gitpersist.ConflictMetrics()); err != nil {
+		return fmt.Errorf("unable to register git conflict metrics: %w", err)
+	}
+	if err := metrics.Registry.Register(persist.OwnershipConflictMetrics()); err != nil {
+		return fmt.Errorf("unable to register storage ownership conflict metrics: %w", err)
+	}
+	if err := metrics.Registry.Register(persist.WriterIdentityMetrics()); err != nil {
+		return fmt.Errorf("unable to register storage writer identity metrics: %w", err)
+	}
+	for _, col := range state.WriteMetrics() {
+		if err := metrics.Registry.Register(col); err != nil {
+			return fmt.Errorf("unable to register state write metrics: %w", err)
+		}
+	}
+	for _, col := range controller.WatchMetrics() {
+		if err := metrics.Registry.Register(col); err != nil {
+			return fmt.Errorf("unable to register watch metrics: %w", err)
+		}
+	}
+	for _, col := range controller.QuotaMetrics() {
+		if err := metrics.Registry.Register(col); err != nil {
+			return fmt.Errorf("unable to register storage quota metrics: %w", err)
+		}
+	}
+	for _, col := range controller.DeletionMetrics() {
+		if err := metrics.Registry.Register(col); err != nil {
+			return fmt.Errorf("unable to register deletion metrics: %w", err)
+		}
+	}
+
+	// resources of sync configs with a field selector are filtered by the API server
+	byObject, err := controller.CacheByObject(o.Config.SyncConfigs, "")
+	if err != nil {
+		return err
+	}
+
+	// the synced objects and the sync trigger, if configured, are served on the metrics server too
+	synced := controller.NewSyncedObjects()
+	extraHandlers := map[string]http.Handler{
+		syncerrors.DebugEndpointPath:         errorCache,
+		controller.SyncedObjectsEndpointPath: synced,
+	}
+	var trigger *controller.SyncTrigger
+	if o.Config.SyncTrigger != nil {
+		trigger, err = controller.NewSyncTrigger(o.Config.SyncTrigger)
+		if err != nil {
+			return fmt.Errorf("unable to setup sync trigger: %w", err)
+		}
+		extraHandlers[controller.TriggerEndpointPath] = trigger
+	}
+
 	// build manager
 	mOpts := manager.Options{
 		LeaderElection: false,
 		Metrics: server.Options{
-			BindAddress: o.MetricsAddr,
+			BindAddress:   o.MetricsAddr,
+			ExtraHandlers: extraHandlers,
 		},
 		HealthProbeBindAddress: o.ProbeAddr,
+		// the requests of the informers are observed for the watch metrics
+		NewCache: controller.WatchTrackingCache(""),
+		Cache: cache.Options{
+			ByObject: byObject,
+		},
+	}
+	if awCfg := o.Config.AdmissionWebhook; awCfg != nil {
+		mOpts.WebhookServer = webhook.NewServer(webhook.Options{
+			Port:    awCfg.Port,
+			CertDir: awCfg.CertDir,
+		})
 	}
 	mgr, err := ctrlrun.NewManager(o.ClusterConfig, mOpts)
 	if err != nil {
 		return fmt.Errorf("unable to setup manager: %w", err)
 	}
 
-	// initialize persisters for all defined storage definitions
-	persisters := map[string]persist.Persister{}
-	for _, stDef := range o.Config.StorageDefinitions {
-		p, err := initializePersister(ctx, stDef)
+	// sync configs with their own kubeconfig or a shoot watch their resources in a separate cluster
+	// resources of sync configs with the same cluster share the cluster and its cache
+	clusters := map[string]cluster.Cluster{}
+	clients := map[string]client.Client{"": mgr.GetClient()}
+	for clusterKey, restCfg := range o.SyncConfigClusterConfigs {
+		byObject, err := controller.CacheByObject(o.Config.SyncConfigs, clusterKey)
 		if err != nil {
-			return fmt.Errorf("error initializing persister for storage definition '%s': %w", stDef.Name, err)
+			return err
 		}
-		persisters[stDef.Name] = p
+		cl, err := cluster.New(restCfg, func(clOpts *cluster.Options) {
+			clOpts.NewCache = controller.WatchTrackingCache(clusterKey)
+			clOpts.Cache.ByObject = byObject
+		})
+		if err != nil {
+			return fmt.Errorf("unable to setup cluster '%s': %w", clusterKey, err)
+		}
+		if err := mgr.Add(cl); err != nil {
+			return fmt.Errorf("unable to add cluster '%s' to manager: %w", clusterKey, err)
+		}
+		clusters[clusterKey] = cl
+		clients[clusterKey] = cl.GetClient()
+	}
+
+	if err := o.checkPermissions(ctx, clients, true); err != nil {
+		return err
+	}
+
+	// protect the sync state against modifications by other users, if configured
+	if o.Config.AdmissionWebhook != nil {
+		if err := admission.AddStateGuardToManager(ctx, mgr, o.Config.AdmissionWebhook, clients); err != nil {
+			return fmt.Errorf("error adding admission webhook to manager: %w", err)
+		}
+	}
+
+	persisters, err := o.initializePersisters(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the manager's cache is not started yet, so the recorded subPaths are read from the cluster directly
+	if o.Config.SubPathPruning != nil {
+		c, err := client.New(o.ClusterConfig, client.Options{})
+		if err != nil {
+			return fmt.Errorf("unable to create client for subPath pruning: %w", err)
+		}
+		o.pruneSubPaths(ctx, c, persisters)
+	}
+
+	// report conflicts of git storages as events on the affected resources
+	// secrets with the credentials of namespace branches are read directly, so that they are not cached cluster-wide
+	for _, gp := range gitPersisters(persisters) {
+		gp.SetEventRecorder(mgr.GetEventRecorderFor("k8syncer"))
+		gp.SetSecretReader(mgr.GetAPIReader())
+	}
+
+	// serve the webhooks of git storages, if any
+	if err := addWebhookServer(logger, mgr, o.WebhookAddr, persisters); err != nil {
+		return fmt.Errorf("error adding webhook server to manager: %w", err)
+	}
+
+	// pull non-exclusive git storages in the background, if configured
+	if err := addBackgroundPullers(mgr, persisters); err != nil {
+		return fmt.Errorf("error adding background pullers to manager: %w", err)
 	}
 
 	// add one Controller per sync config to the manager
 	for _, syncConfig := range o.Config.SyncConfigs {
-		if err := controller.AddControllerToManager(logger, mgr, o.Config, syncConfig, persisters); err != nil {
+		// clusters contains no entry for sync configs without their own kubeconfig or shoot, which use the manager's cluster
+		if err := controller.AddControllerToManager(ctx, logger, mgr, clusters[syncConfig.ClusterKey()], o.Config, syncConfig, persisters, errorCache, synced, trigger); err != nil {
 			return fmt.Errorf("error adding new controller to manager: %w", err)
 		}
+		if err := snapshot.AddSnapshotterToManager(logger, mgr, o.Config, syncConfig, persisters); err != nil {
+			return fmt.Errorf("error adding snapshotter to manager: %w", err)
+		}
+	}
+	if err := pruning.AddNamespacePrunerToManager(logger, mgr, o.Config, persisters); err != nil {
+		return fmt.Errorf("error adding namespace pruner to manager: %w", err)
+	}
+
+	// the manager's cache is not started yet, so the summary is written to the cluster directly
+	if o.Config.StartupSummary != nil {
+		c, err := client.New(o.ClusterConfig, client.Options{})
+		if err != nil {
+			return fmt.Errorf("unable to create client for startup summary: %w", err)
+		}
+		if err := controller.PublishStartupSummary(ctx, c, o.Config); err != nil {
+			logger.Error(err, "error while publishing startup summary")
+		}
 	}
 
 	logger.Info("Starting controllers")
 	return mgr.Start(ctx)
 }
 
+// runOnce persists all resources of all sync configs once, without starting any controllers.
+// Sync configs are processed one after another, an error for one of them does not prevent the others from being synced.
+func (o *Options) runOnce(ctx context.Context) error {
+	logger := o.Log.WithName("k8syncer")
+	ctx = logging.NewContext(ctx, logger)
+	// stops everything which runs in the background, e.g. the probes of circuit breakers, when the one-shot sync is finished
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// there is no manager whose cache could be used, so resources are read from the cluster directly
+	c, err := client.New(o.ClusterConfig, client.Options{})
+	if err != nil {
+		return fmt.Errorf("unable to create client: %w", err)
+	}
+
+	clients := map[string]client.Client{"": c}
+	for clusterKey, restCfg := range o.SyncConfigClusterConfigs {
+		clients[clusterKey], err = client.New(restCfg, client.Options{})
+		if err != nil {
+			return fmt.Errorf("unable to create client for cluster '%s': %w", clusterKey, err)
+		}
+	}
+
+	if err := o.checkPermissions(ctx, clients, false); err != nil {
+		return err
+	}
+
+	persisters, err := o.initializePersisters(ctx)
+	if err != nil {
+		return err
+	}
+	for _, gp := range gitPersisters(persisters) {
+		gp.SetSecretReader(c)
+	}
+	if o.Config.SubPathPruning != nil {
+		o.pruneSubPaths(ctx, c, persisters)
+	}
+
+	var checkpoints *controller.CheckpointStore
+	if o.OnceCheckpoint != "" {
+		namespace, name, err := o.checkpointConfigMap()
+		if err != nil {
+			return err
+		}
+		checkpoints = controller.NewCheckpointStore(c, namespace, name)
+		logger.Info("Using checkpoints for one-shot sync", constants.Logging.KEY_CHECKPOINT, checkpoints.String())
+	}
+
+	errs := utils.NewErrorList()
+	for _, syncConfig := range o.Config.SyncConfigs {
+		if err := controller.SyncOnce(ctx, logger, o.clusterConfigFor(syncConfig), clients[syncConfig.ClusterKey()], o.Config, syncConfig, persisters, checkpoints); err != nil {
+			errs.Append(fmt.Errorf("error syncing resources for sync config '%s': %w", syncConfig.ID, err))
+		}
+	}
+	if checkpoints != nil {
+		// if all sync configs have been processed completely, the next run should start from the beginning
+		// otherwise, the checkpoints are kept so that a retry can resume the incomplete sync configs
+		completed, err := checkpoints.AllCompleted(ctx, o.Config.SyncConfigs)
+		errs.Append(err)
+		if completed {
+			errs.Append(checkpoints.Clear(ctx))
+		}
+	}
+	if err := errs.Aggregate(); err != nil {
+		return err
+	}
+	logger.Info("One-shot sync finished successfully")
+	return nil
+}
+
+// checkPermissions verifies that K8Syncer has all permissions which are required for the configured sync configs.
+// The clients are mapped by the cluster key of the sync configs, the empty string maps to the default cluster.
+// Returns an error listing all missing permissions, if any.
+func (o *Options) checkPermissions(ctx context.Context, clients map[string]client.Client, watch bool) error {
+	if o.SkipPermissionCheck {
+		return nil
+	}
+	missing := []string{}
+	for _, syncConfig := range o.Config.SyncConfigs {
+		cur, err := controller.CheckPermissions(ctx, o.clusterConfigFor(syncConfig), clients[syncConfig.ClusterKey()], syncConfig, watch)
+		if err != nil {
+			return err
+		}
+		missing = append(missing, cur...)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing permissions, grant them via RBAC or disable this check via --skip-permission-check:\n  - %s", strings.Join(missing, "\n  - "))
+	}
+	return nil
+}
+
+// pruneSubPaths prunes the data of storage references which have been removed from the configuration.
+// Errors are only logged, as they must not prevent the resources from being synced. Failed prunings are retried on the next start.
+func (o *Options) pruneSubPaths(ctx context.Context, c client.Client, persisters map[string]persist.Persister) {
+	logger := logging.FromContextOrDiscard(ctx)
+	spp, err := pruning.NewSubPathPruner(c, o.Config, persisters)
+	if err == nil {
+		err = spp.Run(ctx)
+	}
+	if err != nil {
+		logger.Error(err, "error while pruning subPaths of removed storage references")
+	}
+}
+
+// initializePersisters initializes the persisters for all defined storage definitions.
+func (o *Options) initializePersisters(ctx context.Context) (map[string]persist.Persister, error) {
+	persisters := map[string]persist.Persister{}
+	var identity *persist.WriterIdentity
+	if wiCfg := o.Config.WriterIdentity; wiCfg != nil {
+		hash, err := o.Config.Hash()
+		if err != nil {
+			return nil, fmt.Errorf("error computing writer identity: %w", err)
+		}
+		identity = &persist.WriterIdentity{Instance: wiCfg.InstanceName, ConfigHash: hash}
+	}
+	for _, stDef := range o.Config.StorageDefinitions {
+		p, err := initializePersister(ctx, stDef, o.Config.ClusterName, identity, o.ClusterConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing persister for storage definition '%s': %w", stDef.Name, err)
+		}
+		persisters[stDef.Name] = p
+	}
+	// list the sync configs in the index documents of git storages
+	for _, gp := range gitPersisters(persisters) {
+		gp.SetSyncConfigs(o.Config.SyncConfigs)
+	}
+	return persisters, nil
+}
+
+// addWebhookServer adds a server for the webhook handlers of all git persisters to the manager.
+// If none of the persisters has a webhook configured, no server is added.
+func addWebhookServer(log logging.Logger, mgr manager.Manager, addr string, persisters map[string]persist.Persister) error {
+	mux := http.NewServeMux()
+	paths := []string{}
+	for name, gp := range gitPersisters(persisters) {
+		if h := gp.WebhookHandler(); h != nil {
+			mux.Handle(gitpersist.WebhookPath(name), h)
+			paths = append(paths, gitpersist.WebhookPath(name))
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			_ = srv.Shutdown(shutdownCtx)
+		}()
+		log.Info("Starting webhook server", "address", addr, "paths", paths)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}))
+}
+
 // initializePersister should be called once per storage definition
-func initializePersister(ctx context.Context, stDef *config.StorageDefinition) (persist.Persister, error) {
+// If identity is not nil, all persisted resources are stamped with it.
+// restCfg is used by kubernetes and cluster storages which don't specify their own kubeconfig.
+// ctx defines the lifetime of the persister's background tasks, e.g. the probes of the circuit breaker.
+func initializePersister(ctx context.Context, stDef *config.StorageDefinition, clusterName string, identity *persist.WriterIdentity, restCfg *rest.Config) (persist.Persister, error) {
 	if stDef == nil {
 		return nil, fmt.Errorf("storage definition must not be nil")
 	}
@@ -108,7 +452,7 @@ func initializePersister(ctx context.Context, stDef *config.StorageDefinition) (
 		}
 		p = persist.AddLoggingLayer(fsp, logging.DEBUG)
 	case config.STORAGE_TYPE_GIT:
-		gp, err := gitpersist.New(ctx, stDef)
+		gp, err := gitpersist.New(ctx, stDef, clusterName)
 		if err != nil {
 			return nil, fmt.Errorf("error creating GitPersister: %w", err)
 		}
@@ -118,9 +462,96 @@ func initializePersister(ctx context.Context, stDef *config.StorageDefinition) (
 		if err != nil {
 			return nil, fmt.Errorf("error creating FileSystemPersister: %w", err)
 		}
+	case config.STORAGE_TYPE_WIKI:
+		wp, err := wikipersist.New(stDef)
+		if err != nil {
+			return nil, fmt.Errorf("error creating WikiPersister: %w", err)
+		}
+		p = persist.AddLoggingLayer(wp, logging.DEBUG)
+	case config.STORAGE_TYPE_PLUGIN:
+		pp, err := pluginpersist.New(stDef)
+		if err != nil {
+			return nil, fmt.Errorf("error creating PluginPersister: %w", err)
+		}
+		p = persist.AddLoggingLayer(pp, logging.DEBUG)
+	case config.STORAGE_TYPE_CLUSTER:
+		if kubeconfig := stDef.ClusterConfig.Kubeconfig; kubeconfig != "" {
+			restCfg, err = LoadKubeconfig(kubeconfig)
+			if err != nil {
+				return nil, fmt.Errorf("error loading kubeconfig for cluster storage: %w", err)
+			}
+		}
+		cp, err := clusterpersist.New(stDef, restCfg)
+		if err != nil {
+			return nil, fmt.Errorf("error creating ClusterPersister: %w", err)
+		}
+		p = persist.AddLoggingLayer(cp, logging.DEBUG)
+	case config.STORAGE_TYPE_KUBERNETES:
+		if kubeconfig := stDef.KubernetesConfig.Kubeconfig; kubeconfig != "" {
+			restCfg, err = LoadKubeconfig(kubeconfig)
+			if err != nil {
+				return nil, fmt.Errorf("error loading kubeconfig for kubernetes storage: %w", err)
+			}
+		}
+		kp, err := kubepersist.New(stDef, restCfg)
+		if err != nil {
+			return nil, fmt.Errorf("error creating KubernetesPersister: %w", err)
+		}
+		p = persist.AddLoggingLayer(kp, logging.DEBUG)
+	case config.STORAGE_TYPE_KV:
+		kvp, err := kvpersist.New(stDef)
+		if err != nil {
+			return nil, fmt.Errorf("error creating KVPersister: %w", err)
+		}
+		p = persist.AddLoggingLayer(kvp, logging.DEBUG)
+	case config.STORAGE_TYPE_SFTP, config.STORAGE_TYPE_WEBDAV:
+		fsp, err := remotepersist.New(stDef)
+		if err != nil {
+			return nil, fmt.Errorf("error creating FileSystemPersister for remote storage: %w", err)
+		}
+		p = persist.AddLoggingLayer(fsp, logging.DEBUG)
 	default:
 		// should not happen, as this check is already part of the config validation
 		return nil, fmt.Errorf("unknown storage type '%s'", stDef.Type)
 	}
+	if cb := stDef.CircuitBreaker; cb != nil {
+		// the circuit breaker is placed below the cache, so that cached answers don't depend on the storage's availability
+		p = persist.AddCircuitBreakerLayer(ctx, p, stDef.Name, cb.FailureThreshold, cb.ProbeInterval.Duration)
+	}
+	if identity != nil {
+		// the identity is placed below the cache, so that resources which are skipped by the cache are not fetched for the identity check
+		p = persist.AddWriterIdentityLayer(p, stDef.Name, *identity)
+	}
+	if stDef.Cache != nil {
+		p = persist.AddCachingLayer(p, stDef.Cache.MaxEntries)
+	}
+	p = persist.AddOwnershipLayer(p, stDef.Name, stDef.OwnershipConflictPolicy)
 	return p, nil
 }
+
+// addBackgroundPullers adds a runnable to the manager for each git persister which has background pulls configured.
+func addBackgroundPullers(mgr manager.Manager, persisters map[string]persist.Persister) error {
+	for _, gp := range gitPersisters(persisters) {
+		if !gp.HasBackgroundPull() {
+			continue
+		}
+		if err := mgr.Add(manager.RunnableFunc(gp.StartBackgroundPulls)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gitPersisters returns the git persisters among the given persisters, unwrapping any layers around them.
+func gitPersisters(persisters map[string]persist.Persister) map[string]*gitpersist.GitPersister {
+	res := map[string]*gitpersist.GitPersister{}
+	for name, p := range persisters {
+		for cur := p; cur != nil; cur = cur.InternalPersister() {
+			if gp, ok := cur.(*gitpersist.GitPersister); ok {
+				res[name] = gp
+				break
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,262 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrlrun "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/controller"
+	"github.com/gardener/k8syncer/pkg/utils"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// devStorageName is the name of the storage definition in the generated configuration of the dev command.
+const devStorageName = "dev"
+
+// devRootPathPlaceholder is the rootPath of the filesystem storage in the printed configuration, if no --root-path is given.
+const devRootPathPlaceholder = "/path/to/k8syncer-dev"
+
+// DevOptions describes the options of the dev command.
+type DevOptions struct {
+	MetricsAddr       string
+	ProbeAddr         string
+	ClusterConfigPath string
+	Context           string
+	Group             string
+	Version           string
+	Kind              string
+	Namespace         string
+	Storage           string
+	RootPath          string
+	PrintConfig       bool
+}
+
+func NewDevOptions() *DevOptions {
+	return &DevOptions{}
+}
+
+// NewDevCommand creates the dev command, which runs the controller for a single resource kind with a generated configuration.
+func NewDevCommand(ctx context.Context) *cobra.Command {
+	devOptions := NewDevOptions()
+
+	cmd := &cobra.Command{
+		Use:   "dev",
+		Short: "run k8syncer locally for a single resource kind, without a configuration file",
+		Long: `Runs the k8syncer controller against the cluster of the current kubeconfig context for quick local experiments.
+A minimal configuration is generated, which syncs the resources of the given kind into a filesystem storage
+in a new temporary directory, or into a mock storage which logs all calls. Development logging is enabled by default.
+The generated configuration neither adds finalizers to the resources nor displays their sync state, so the cluster is not modified.`,
+		Example: "  k8syncer dev --group=apps --version=v1 --kind=Deployment --namespace=default",
+
+		PreRun: func(cmd *cobra.Command, args []string) {
+			// verbose logging, unless overwritten explicitly
+			if !cmd.Flags().Changed("dev") {
+				_ = cmd.Flags().Set("dev", "true")
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if devOptions.PrintConfig {
+				data, err := devOptions.printConfig()
+				if err != nil {
+					fmt.Print(err)
+					os.Exit(1)
+				}
+				fmt.Print(string(data))
+				return
+			}
+			options, err := devOptions.Complete()
+			if err != nil {
+				fmt.Print(err)
+				os.Exit(1)
+			}
+			ctx = logging.NewContext(ctx, options.Log)
+			if err := options.run(ctx); err != nil {
+				options.Log.Error(err, "unable to run k8syncer controller")
+				os.Exit(1)
+			}
+		},
+	}
+
+	devOptions.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *DevOptions) AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.MetricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
+	fs.StringVar(&o.ProbeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	fs.StringVar(&o.ClusterConfigPath, "kubeconfig", "", "Path to the kubeconfig file. Defaults to the KUBECONFIG env var or '~/.kube/config'.")
+	fs.StringVar(&o.Context, "context", "", "The kubeconfig context to use. Defaults to the current context.")
+	fs.StringVar(&o.Group, "group", "", "Group of the resource to sync. Leave empty for k8s core api resources.")
+	fs.StringVar(&o.Version, "version", "v1", "Version of the resource to sync.")
+	fs.StringVar(&o.Kind, "kind", "", "Kind of the resource to sync.")
+	fs.StringVar(&o.Namespace, "namespace", "", "Namespace from which the resources are synced. Leave empty for cluster-scoped resources or to sync namespaced resources from all namespaces.")
+	fs.StringVar(&o.Storage, "storage", string(config.STORAGE_TYPE_FILESYSTEM), fmt.Sprintf("Type of the storage into which the resources are synced, either '%s' or '%s'.", config.STORAGE_TYPE_FILESYSTEM, config.STORAGE_TYPE_MOCK))
+	fs.StringVar(&o.RootPath, "root-path", "", "Existing directory into which the resources are synced by the filesystem storage. Defaults to a new temporary directory, which is not removed afterwards.")
+	fs.BoolVar(&o.PrintConfig, "print-config", false, "Print the generated configuration and exit, e.g. to use it as starting point for a configuration file.")
+	logging.InitFlags(fs)
+}
+
+// Complete validates the DevOptions and returns Options for running the controller with the generated configuration.
+// The temporary directory for the filesystem storage is created here, if required.
+func (o *DevOptions) Complete() (*Options, error) {
+	log, err := logging.GetLogger()
+	if err != nil {
+		return nil, err
+	}
+	ctrlrun.SetLogger(log.Logr())
+
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	// load the current kubeconfig context, the same way kubectl does
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = o.ClusterConfigPath
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: o.Context})
+	restCfg, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load kubeconfig: %w", err)
+	}
+	rawCfg, err := clientConfig.RawConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load kubeconfig: %w", err)
+	}
+	contextName := o.Context
+	if contextName == "" {
+		contextName = rawCfg.CurrentContext
+	}
+
+	rootPath := o.RootPath
+	if config.StorageDefinitionType(o.Storage) == config.STORAGE_TYPE_FILESYSTEM && rootPath == "" {
+		rootPath, err = os.MkdirTemp("", "k8syncer-dev-")
+		if err != nil {
+			return nil, fmt.Errorf("unable to create temporary directory for filesystem storage: %w", err)
+		}
+	}
+	cfg, err := o.generateConfig(rootPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Complete(); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(cfg).ToAggregate(); err != nil {
+		return nil, err
+	}
+
+	if rootPath != "" {
+		log.Info("Syncing resources into filesystem storage", constants.Logging.KEY_PATH, rootPath)
+	}
+	log.Info("Starting k8syncer in dev mode", constants.Logging.KEY_CLUSTER, contextName, constants.Logging.KEY_RESOURCE_GROUP, o.Group, constants.Logging.KEY_RESOURCE_VERSION, o.Version, constants.Logging.KEY_RESOURCE_KIND, o.Kind)
+
+	return &Options{
+		MetricsAddr:              o.MetricsAddr,
+		ProbeAddr:                o.ProbeAddr,
+		Log:                      log,
+		Config:                   cfg,
+		ClusterConfig:            controller.WithRateLimit(restCfg, cfg.ClientRateLimit),
+		SyncConfigClusterConfigs: map[string]*rest.Config{},
+	}, nil
+}
+
+// validates the DevOptions
+func (o *DevOptions) validate() error {
+	errs := utils.NewErrorList()
+	if o.Kind == "" {
+		errs.Append(fmt.Errorf("--kind is required"))
+	}
+	if o.Version == "" {
+		errs.Append(fmt.Errorf("--version must not be empty"))
+	}
+	switch config.StorageDefinitionType(o.Storage) {
+	case config.STORAGE_TYPE_FILESYSTEM:
+	case config.STORAGE_TYPE_MOCK:
+		if o.RootPath != "" {
+			errs.Append(fmt.Errorf("--root-path is only supported for storage type '%s'", config.STORAGE_TYPE_FILESYSTEM))
+		}
+	default:
+		errs.Append(fmt.Errorf("invalid value '%s' for --storage, expected '%s' or '%s'", o.Storage, config.STORAGE_TYPE_FILESYSTEM, config.STORAGE_TYPE_MOCK))
+	}
+	return errs.Aggregate()
+}
+
+// printConfig returns the generated configuration for --print-config.
+// As no temporary directory is created, the rootPath of the filesystem storage is a placeholder, unless --root-path is given.
+// The configuration is validated before, so that the printed one can be used as it is, apart from the placeholder.
+func (o *DevOptions) printConfig() ([]byte, error) {
+	rootPath := o.RootPath
+	if config.StorageDefinitionType(o.Storage) == config.STORAGE_TYPE_FILESYSTEM && rootPath == "" {
+		rootPath = devRootPathPlaceholder
+	}
+	cfg, err := o.generateConfig(rootPath)
+	if err != nil {
+		return nil, err
+	}
+	// the minimal configuration is printed, without the defaults
+	completed := cfg.DeepCopy()
+	if err := completed.Complete(); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(completed).ToAggregate(); err != nil {
+		return nil, fmt.Errorf("generated configuration is invalid: %w", err)
+	}
+	return yaml.Marshal(cfg)
+}
+
+// generateConfig returns a minimal configuration which syncs the resources of the configured kind into a single storage.
+// For the filesystem storage, the given root path is used.
+func (o *DevOptions) generateConfig(rootPath string) (*config.K8SyncerConfiguration, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	storage := &config.StorageDefinition{
+		Name: devStorageName,
+		Type: config.StorageDefinitionType(o.Storage),
+	}
+	switch storage.Type {
+	case config.STORAGE_TYPE_FILESYSTEM:
+		storage.FileSystemConfig = &config.FileSystemConfiguration{
+			RootPath: rootPath,
+		}
+	case config.STORAGE_TYPE_MOCK:
+		storage.MockConfig = &config.MockConfiguration{
+			LogPersisterCallsOnInfoLevel: true,
+		}
+	}
+	return &config.K8SyncerConfiguration{
+		SyncConfigs: []*config.SyncConfig{
+			{
+				ID: strings.ToLower(o.Kind),
+				Resource: &config.ResourceSyncConfig{
+					Namespace: o.Namespace,
+					Group:     o.Group,
+					Version:   o.Version,
+					Kind:      o.Kind,
+				},
+				StorageRefs: []*config.StorageReference{
+					{
+						Name: devStorageName,
+					},
+				},
+				// the cluster is not modified, finalizers would block the deletion of resources once the dev command has been stopped
+				Finalize: utils.Ptr(false),
+			},
+		},
+		StorageDefinitions: []*config.StorageDefinition{storage},
+	}, nil
+}
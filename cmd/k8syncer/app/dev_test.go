@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+var _ = Describe("Dev", func() {
+
+	var o *DevOptions
+
+	BeforeEach(func() {
+		o = &DevOptions{
+			Group:     "apps",
+			Version:   "v1",
+			Kind:      "Deployment",
+			Namespace: "default",
+			Storage:   string(config.STORAGE_TYPE_FILESYSTEM),
+		}
+	})
+
+	// expectValid completes and validates the given configuration
+	expectValid := func(cfg *config.K8SyncerConfiguration) {
+		Expect(cfg.Complete()).To(Succeed())
+		Expect(config.Validate(cfg)).To(BeEmpty())
+	}
+
+	It("should generate a valid configuration for both storage types", func() {
+		cfg, err := o.generateConfig("/tmp/k8syncer-dev")
+		Expect(err).ToNot(HaveOccurred())
+		expectValid(cfg)
+		Expect(cfg.SyncConfigs).To(HaveLen(1))
+		Expect(cfg.SyncConfigs[0].ID).To(Equal("deployment"))
+		Expect(cfg.SyncConfigs[0].Resource).To(Equal(&config.ResourceSyncConfig{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "default"}))
+		Expect(*cfg.SyncConfigs[0].Finalize).To(BeFalse())
+		Expect(cfg.StorageDefinitions[0].FileSystemConfig.RootPath).To(Equal("/tmp/k8syncer-dev"))
+
+		o.Storage = string(config.STORAGE_TYPE_MOCK)
+		cfg, err = o.generateConfig("")
+		Expect(err).ToNot(HaveOccurred())
+		expectValid(cfg)
+		Expect(cfg.StorageDefinitions[0].Type).To(Equal(config.STORAGE_TYPE_MOCK))
+		Expect(cfg.StorageDefinitions[0].MockConfig.LogPersisterCallsOnInfoLevel).To(BeTrue())
+	})
+
+	It("should reject a missing --kind and an invalid --storage", func() {
+		o.Kind = ""
+		_, err := o.generateConfig("/tmp/k8syncer-dev")
+		Expect(err).To(MatchError(ContainSubstring("--kind is required")))
+
+		o.Kind = "Deployment"
+		o.Storage = "git"
+		_, err = o.generateConfig("/tmp/k8syncer-dev")
+		Expect(err).To(MatchError(ContainSubstring("invalid value 'git' for --storage")))
+
+		o.Storage = string(config.STORAGE_TYPE_MOCK)
+		o.RootPath = "/tmp/k8syncer-dev"
+		_, err = o.generateConfig("")
+		Expect(err).To(MatchError(ContainSubstring("--root-path is only supported for storage type 'filesystem'")))
+	})
+
+	It("should print a valid configuration", func() {
+		for _, storage := range []config.StorageDefinitionType{config.STORAGE_TYPE_FILESYSTEM, config.STORAGE_TYPE_MOCK} {
+			o.Storage = string(storage)
+			data, err := o.printConfig()
+			Expect(err).ToNot(HaveOccurred())
+			cfg := &config.K8SyncerConfiguration{}
+			Expect(yaml.UnmarshalStrict(data, cfg)).To(Succeed())
+			expectValid(cfg)
+			if storage == config.STORAGE_TYPE_FILESYSTEM {
+				Expect(cfg.StorageDefinitions[0].FileSystemConfig.RootPath).To(Equal(devRootPathPlaceholder))
+			}
+		}
+
+		By("using the given root path")
+		o.Storage = string(config.STORAGE_TYPE_FILESYSTEM)
+		o.RootPath = "/tmp/k8syncer-dev"
+		data, err := o.printConfig()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("rootPath: /tmp/k8syncer-dev"))
+
+		By("rejecting invalid options")
+		o.Kind = ""
+		_, err = o.printConfig()
+		Expect(err).To(MatchError(ContainSubstring("--kind is required")))
+	})
+
+})
@@ -0,0 +1,285 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/controller"
+	"github.com/gardener/k8syncer/pkg/version"
+)
+
+const (
+	// defaultImageRepository is the repository of the k8syncer image, which is used by the generated manifests by default.
+	defaultImageRepository = "europe-docker.pkg.dev/sap-gcp-cp-k8s-stable-hub/cola/k8syncer"
+)
+
+// NewGenerateCommand creates the generate command, which groups the commands generating deployment artifacts from a configuration.
+func NewGenerateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "generate deployment artifacts for a k8syncer configuration",
+	}
+	cmd.AddCommand(NewGenerateRBACCommand())
+	cmd.AddCommand(NewGenerateManifestsCommand())
+	return cmd
+}
+
+// GenerateOptions describes the options which are common to all generate commands.
+type GenerateOptions struct {
+	ConfigPath        string
+	ClusterConfigPath string
+	Context           string
+	Name              string
+}
+
+func (o *GenerateOptions) AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.ConfigPath, "config", "", "Path to the k8syncer configuration file.")
+	fs.StringVar(&o.ClusterConfigPath, "kubeconfig", "", "Path to the kubeconfig of a cluster which knows the synced kinds, used to determine their resources and scopes. If not set, they are guessed.")
+	fs.StringVar(&o.Context, "context", "", "The kubeconfig context to use. Defaults to the current context.")
+	fs.StringVar(&o.Name, "name", "k8syncer", "Name of the generated objects.")
+}
+
+// loadConfig reads, completes, and validates the configuration file.
+// The raw content of the file is returned too, as the loaded configuration has its references to environment variables and files resolved already.
+func (o *GenerateOptions) loadConfig() (*config.K8SyncerConfiguration, []byte, error) {
+	if o.ConfigPath == "" {
+		return nil, nil, fmt.Errorf("--config is required")
+	}
+	raw, err := os.ReadFile(o.ConfigPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read config file: %w", err)
+	}
+	cfg, _, err := config.LoadConfig(o.ConfigPath, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cfg.Complete(); err != nil {
+		return nil, nil, err
+	}
+	if err := config.Validate(cfg).ToAggregate(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, raw, nil
+}
+
+// restMapper returns a RESTMapper for the cluster of the configured kubeconfig.
+// It returns nil if no kubeconfig is configured.
+func (o *GenerateOptions) restMapper() (meta.RESTMapper, error) {
+	if o.ClusterConfigPath == "" {
+		return nil, nil
+	}
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = o.ClusterConfigPath
+	restCfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: o.Context}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load kubeconfig: %w", err)
+	}
+	httpClient, err := rest.HTTPClientFor(restCfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create http client: %w", err)
+	}
+	mapper, err := apiutil.NewDynamicRESTMapper(restCfg, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create rest mapper: %w", err)
+	}
+	return mapper, nil
+}
+
+// GenerateRBACOptions describes the options of the generate rbac command.
+type GenerateRBACOptions struct {
+	GenerateOptions
+	Once           bool
+	OnceCheckpoint string
+}
+
+func NewGenerateRBACOptions() *GenerateRBACOptions {
+	return &GenerateRBACOptions{}
+}
+
+// NewGenerateRBACCommand creates the generate rbac command, which prints the RBAC roles k8syncer requires for a configuration.
+func NewGenerateRBACCommand() *cobra.Command {
+	opts := NewGenerateRBACOptions()
+
+	cmd := &cobra.Command{
+		Use:   "rbac",
+		Short: "print the RBAC roles which k8syncer requires for a configuration",
+		Long: `Prints a ClusterRole and Roles with the minimal permissions which k8syncer requires for the given configuration
+in the cluster specified via '--kubeconfig' when running k8syncer. Permissions which are restricted to a namespace are granted via a Role in that namespace.
+The permissions of sync configs with their own kubeconfig or a shoot have to be granted in their cluster, they are not contained.
+Without '--kubeconfig', the resources of the synced kinds are guessed from the kinds, which might be wrong for irregular plurals.`,
+		Example: "  k8syncer generate rbac --config=config.yaml --kubeconfig=$KUBECONFIG > rbac.yaml",
+
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.run(cmd.OutOrStdout(), cmd.ErrOrStderr()); err != nil {
+				fmt.Fprintln(cmd.ErrOrStderr(), err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *GenerateRBACOptions) AddFlags(fs *flag.FlagSet) {
+	o.GenerateOptions.AddFlags(fs)
+	fs.BoolVar(&o.Once, "once", false, "Generate the permissions for a one-shot sync, which lists the resources instead of watching them.")
+	fs.StringVar(&o.OnceCheckpoint, "once-checkpoint", "", "Reference to the checkpoint ConfigMap of the one-shot sync in the format '<namespace>/<name>', see the flag of the same name of k8syncer. Requires --once.")
+}
+
+func (o *GenerateRBACOptions) run(out, errOut io.Writer) error {
+	cfg, _, err := o.loadConfig()
+	if err != nil {
+		return err
+	}
+	mapper, err := o.restMapper()
+	if err != nil {
+		return err
+	}
+	var checkpoint *client.ObjectKey
+	if o.OnceCheckpoint != "" {
+		if !o.Once {
+			return fmt.Errorf("--once-checkpoint requires --once")
+		}
+		namespace, name, found := strings.Cut(o.OnceCheckpoint, "/")
+		if !found || namespace == "" || name == "" {
+			return fmt.Errorf("invalid value '%s' for --once-checkpoint, expected format '<namespace>/<name>'", o.OnceCheckpoint)
+		}
+		checkpoint = &client.ObjectKey{Namespace: namespace, Name: name}
+	}
+	roles, err := controller.GenerateRBACRoles(cfg, mapper, o.Name, !o.Once, checkpoint)
+	if err != nil {
+		return err
+	}
+	reportSkippedSyncConfigs(errOut, roles)
+	return writeObjects(out, roles.Objects)
+}
+
+// GenerateManifestsOptions describes the options of the generate manifests command.
+type GenerateManifestsOptions struct {
+	GenerateOptions
+	Namespace    string
+	Image        string
+	StorageSize  string
+	StorageClass string
+}
+
+func NewGenerateManifestsOptions() *GenerateManifestsOptions {
+	return &GenerateManifestsOptions{}
+}
+
+// NewGenerateManifestsCommand creates the generate manifests command, which prints the manifests for deploying k8syncer with a configuration.
+func NewGenerateManifestsCommand() *cobra.Command {
+	opts := NewGenerateManifestsOptions()
+
+	cmd := &cobra.Command{
+		Use:   "manifests",
+		Short: "print the manifests for deploying k8syncer with a configuration",
+		Long: `Prints the manifests for deploying k8syncer with the given configuration into the cluster which contains the synced resources:
+a ServiceAccount, the RBAC roles and bindings (see 'k8syncer generate rbac'), a Secret with the configuration file, a PersistentVolumeClaim
+for each filesystem storage which is not kept in memory, and a Deployment which mounts them.
+The configuration file is copied as it is, references to environment variables and files are resolved by k8syncer when it starts.`,
+		Example: "  k8syncer generate manifests --config=config.yaml --namespace=k8syncer | kubectl apply -f -",
+
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.run(cmd.OutOrStdout(), cmd.ErrOrStderr()); err != nil {
+				fmt.Fprintln(cmd.ErrOrStderr(), err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *GenerateManifestsOptions) AddFlags(fs *flag.FlagSet) {
+	o.GenerateOptions.AddFlags(fs)
+	fs.StringVar(&o.Namespace, "namespace", "default", "Namespace into which k8syncer is deployed.")
+	fs.StringVar(&o.Image, "image", fmt.Sprintf("%s:%s", defaultImageRepository, version.Get().GitVersion), "The k8syncer image.")
+	fs.StringVar(&o.StorageSize, "storage-size", "1Gi", "Requested size of the persistent volumes of filesystem storages.")
+	fs.StringVar(&o.StorageClass, "storage-class", "", "Storage class of the persistent volumes of filesystem storages. Defaults to the default storage class of the cluster.")
+}
+
+func (o *GenerateManifestsOptions) run(out, errOut io.Writer) error {
+	cfg, raw, err := o.loadConfig()
+	if err != nil {
+		return err
+	}
+	storageSize, err := resource.ParseQuantity(o.StorageSize)
+	if err != nil {
+		return fmt.Errorf("invalid value '%s' for --storage-size: %w", o.StorageSize, err)
+	}
+	mapper, err := o.restMapper()
+	if err != nil {
+		return err
+	}
+	roles, err := controller.GenerateRBACRoles(cfg, mapper, o.Name, true, nil)
+	if err != nil {
+		return err
+	}
+	reportSkippedSyncConfigs(errOut, roles)
+
+	objs := controller.GenerateManifests(cfg, raw, roles, &controller.ManifestOptions{
+		Name:         o.Name,
+		Namespace:    o.Namespace,
+		Image:        o.Image,
+		StorageSize:  storageSize,
+		StorageClass: o.StorageClass,
+	})
+	return writeObjects(out, objs)
+}
+
+// reportSkippedSyncConfigs prints the sync configs and the runtime permissions which are not contained in the given roles.
+func reportSkippedSyncConfigs(errOut io.Writer, roles *controller.RBACRoles) {
+	if len(roles.SkippedSyncConfigs) > 0 {
+		fmt.Fprintf(errOut, "The resources of the following sync configs are contained in other clusters, their permissions have to be granted there: %s\n", strings.Join(roles.SkippedSyncConfigs, ", "))
+	}
+	if len(roles.RuntimePermissions) > 0 {
+		fmt.Fprintf(errOut, "The following permissions depend on the synced resources and have to be granted additionally:\n- %s\n", strings.Join(roles.RuntimePermissions, "\n- "))
+	}
+}
+
+// writeObjects writes the given objects as a multi-document YAML.
+// Fields which are only set by the API server, like the creation timestamp and the status, are omitted.
+func writeObjects(out io.Writer, objs []client.Object) error {
+	docs := make([]string, 0, len(objs))
+	for _, obj := range objs {
+		kind := obj.GetObjectKind().GroupVersionKind().Kind
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return fmt.Errorf("error converting %s: %w", kind, err)
+		}
+		unstructured.RemoveNestedField(u, "metadata", "creationTimestamp")
+		unstructured.RemoveNestedField(u, "spec", "template", "metadata", "creationTimestamp")
+		unstructured.RemoveNestedField(u, "status")
+		data, err := yaml.Marshal(u)
+		if err != nil {
+			return fmt.Errorf("error marshalling %s: %w", kind, err)
+		}
+		docs = append(docs, string(data))
+	}
+	_, err := fmt.Fprint(out, strings.Join(docs, "---\n"))
+	return err
+}
@@ -5,9 +5,12 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path"
+	"strings"
+	"time"
 
 	flag "github.com/spf13/pflag"
 	"k8s.io/client-go/rest"
@@ -17,18 +20,32 @@ import (
 	ctrlrun "sigs.k8s.io/controller-runtime"
 
 	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/controller"
+	"github.com/gardener/k8syncer/pkg/utils/gardener"
 )
 
+// shootKubeconfigTimeout is the timeout for requesting the initial kubeconfig of a shoot.
+const shootKubeconfigTimeout = 30 * time.Second
+
 // Options describes the options to configure the Landscaper controller.
 type Options struct {
-	MetricsAddr       string
-	ProbeAddr         string
-	ConfigPath        string
-	ClusterConfigPath string
+	MetricsAddr         string
+	ProbeAddr           string
+	WebhookAddr         string
+	ConfigPath          string
+	ClusterConfigPath   string
+	ClusterName         string
+	Once                bool
+	OnceCheckpoint      string
+	ValidateStrict      bool
+	SkipPermissionCheck bool
 
 	Log           logging.Logger
 	Config        *config.K8SyncerConfiguration
 	ClusterConfig *rest.Config
+	// SyncConfigClusterConfigs contains the cluster configurations of all sync configs which specify their own kubeconfig or a shoot,
+	// mapped by their cluster key, see config.SyncConfig.ClusterKey.
+	SyncConfigClusterConfigs map[string]*rest.Config
 }
 
 func NewOptions() *Options {
@@ -38,8 +55,14 @@ func NewOptions() *Options {
 func (o *Options) AddFlags(fs *flag.FlagSet) {
 	fs.StringVar(&o.MetricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	fs.StringVar(&o.ProbeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	fs.StringVar(&o.WebhookAddr, "webhook-bind-address", ":8082", "The address the git webhook endpoints bind to. The server is only started if at least one git storage has a webhook configured.")
 	fs.StringVar(&o.ConfigPath, "config", "", "Specify the path to the configuration file.")
 	fs.StringVar(&o.ClusterConfigPath, "kubeconfig", "", "Path to the kubeconfig file or directory containing either a kubeconfig or host, token, and ca file. Leave empty to use in-cluster config.")
+	fs.StringVar(&o.ClusterName, "cluster-name", "", "Identifier of the watched cluster. Overwrites 'clusterName' from the configuration file, if set.")
+	fs.BoolVar(&o.Once, "once", false, "Persist all resources of all sync configs once and exit instead of running the controllers. Exits with a non-zero code if any resource could not be synced.")
+	fs.StringVar(&o.OnceCheckpoint, "once-checkpoint", "", "Reference to a ConfigMap in the format '<namespace>/<name>' which is used to store the progress of a one-shot sync, so that it can be resumed if it is interrupted. Requires --once.")
+	fs.BoolVar(&o.ValidateStrict, "validate-strict", false, "Fail if the configuration file contains unknown or duplicate fields. Otherwise, such fields are ignored and a warning is logged.")
+	fs.BoolVar(&o.SkipPermissionCheck, "skip-permission-check", false, "Don't verify on startup that K8Syncer has all permissions on the synced resources which it requires for the configured sync configs.")
 	logging.InitFlags(fs)
 }
 
@@ -54,10 +77,18 @@ func (o *Options) Complete() error {
 	ctrlrun.SetLogger(o.Log.Logr())
 
 	// build k8syncer config
-	o.Config, err = config.LoadConfig(o.ConfigPath)
+	var warnings []string
+	o.Config, warnings, err = config.LoadConfig(o.ConfigPath, o.ValidateStrict)
 	if err != nil {
 		return err
 	}
+	for _, w := range warnings {
+		o.Log.Info("Configuration file contains unknown fields which are ignored, use --validate-strict to turn this into an error", "warning", w)
+	}
+
+	if o.ClusterName != "" {
+		o.Config.ClusterName = o.ClusterName
+	}
 
 	err = o.Config.Complete()
 	if err != nil {
@@ -74,15 +105,81 @@ func (o *Options) Complete() error {
 	if err != nil {
 		return fmt.Errorf("unable to load kubeconfig: %w", err)
 	}
+	o.ClusterConfig = controller.WithRateLimit(o.ClusterConfig, o.Config.ClientRateLimit)
+	o.SyncConfigClusterConfigs = map[string]*rest.Config{}
+	for _, syncConfig := range o.Config.SyncConfigs {
+		clusterKey := syncConfig.ClusterKey()
+		if clusterKey == "" {
+			continue
+		}
+		if _, ok := o.SyncConfigClusterConfigs[clusterKey]; ok {
+			continue
+		}
+		var restCfg *rest.Config
+		if syncConfig.Shoot != nil {
+			restCfg, err = o.loadShootConfig(syncConfig.Shoot)
+		} else {
+			restCfg, err = LoadKubeconfig(syncConfig.Kubeconfig)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to load kubeconfig of sync config '%s': %w", syncConfig.ID, err)
+		}
+		o.SyncConfigClusterConfigs[clusterKey] = controller.WithRateLimit(restCfg, o.Config.ClientRateLimit)
+	}
 
 	return nil
 }
 
+// loadShootConfig returns the configuration of the referenced shoot cluster, whose credentials are requested from the garden cluster.
+func (o *Options) loadShootConfig(ref *config.ShootReference) (*rest.Config, error) {
+	gardenCfg := o.ClusterConfig
+	if ref.GardenKubeconfig != "" {
+		var err error
+		gardenCfg, err = LoadKubeconfig(ref.GardenKubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load garden kubeconfig: %w", err)
+		}
+	}
+	sa, err := gardener.NewShootAccess(o.Log, gardenCfg, ref)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shootKubeconfigTimeout)
+	defer cancel()
+	return sa.RESTConfig(ctx)
+}
+
+// clusterConfigFor returns the configuration of the cluster which contains the resources of the given sync config.
+func (o *Options) clusterConfigFor(syncConfig *config.SyncConfig) *rest.Config {
+	clusterKey := syncConfig.ClusterKey()
+	if clusterKey == "" {
+		return o.ClusterConfig
+	}
+	return o.SyncConfigClusterConfigs[clusterKey]
+}
+
 // validates the Options
 func (o *Options) validate() error {
+	if o.OnceCheckpoint != "" {
+		if !o.Once {
+			return fmt.Errorf("--once-checkpoint requires --once")
+		}
+		if _, _, err := o.checkpointConfigMap(); err != nil {
+			return err
+		}
+	}
 	return config.Validate(o.Config).ToAggregate()
 }
 
+// checkpointConfigMap returns namespace and name of the ConfigMap specified via --once-checkpoint.
+func (o *Options) checkpointConfigMap() (string, string, error) {
+	namespace, name, found := strings.Cut(o.OnceCheckpoint, "/")
+	if !found || namespace == "" || name == "" {
+		return "", "", fmt.Errorf("invalid value '%s' for --once-checkpoint, expected format '<namespace>/<name>'", o.OnceCheckpoint)
+	}
+	return namespace, name, nil
+}
+
 // LoadKubeconfig loads a cluster configuration from the given path.
 // If the path points to a single file, this file is expected to contain a kubeconfig which is then loaded.
 // If the path points to a directory which contains a file named "kubeconfig", that file is used.
@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+// writeKubeconfig writes a kubeconfig for the given host into the given file.
+func writeKubeconfig(file, host string) {
+	kcfg := clientcmdapi.NewConfig()
+	kcfg.Clusters["default"] = &clientcmdapi.Cluster{Server: host}
+	kcfg.AuthInfos["default"] = &clientcmdapi.AuthInfo{Token: "token"}
+	kcfg.Contexts["default"] = &clientcmdapi.Context{Cluster: "default", AuthInfo: "default"}
+	kcfg.CurrentContext = "default"
+	Expect(clientcmd.WriteToFile(*kcfg, file)).To(Succeed())
+}
+
+// syncConfig returns a sync config for the given kind, which reads the resources from the cluster of the given kubeconfig.
+func syncConfig(kind, kubeconfig, clusterName string) *config.SyncConfig {
+	return &config.SyncConfig{
+		ID:          kind,
+		Resource:    &config.ResourceSyncConfig{Version: "v1", Kind: kind},
+		StorageRefs: []*config.StorageReference{{Name: "mock"}},
+		Kubeconfig:  kubeconfig,
+		ClusterName: clusterName,
+	}
+}
+
+var _ = Describe("Options", func() {
+
+	var tmpDir string
+
+	BeforeEach(func() {
+		tmpDir = GinkgoT().TempDir()
+	})
+
+	Context("LoadKubeconfig", func() {
+
+		It("should load a kubeconfig file", func() {
+			file := filepath.Join(tmpDir, "kubeconfig.yaml")
+			writeKubeconfig(file, "https://file.example.com")
+			restCfg, err := LoadKubeconfig(file)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(restCfg.Host).To(Equal("https://file.example.com"))
+			Expect(restCfg.BearerToken).To(Equal("token"))
+		})
+
+		It("should load the kubeconfig file from a directory", func() {
+			writeKubeconfig(filepath.Join(tmpDir, "kubeconfig"), "https://dir.example.com")
+			restCfg, err := LoadKubeconfig(tmpDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(restCfg.Host).To(Equal("https://dir.example.com"))
+		})
+
+		It("should load host, token, and CA files from a directory without kubeconfig", func() {
+			Expect(os.WriteFile(filepath.Join(tmpDir, "host"), []byte("https://oidc.example.com"), os.ModePerm)).To(Succeed())
+			restCfg, err := LoadKubeconfig(tmpDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(restCfg.Host).To(Equal("https://oidc.example.com"))
+			Expect(restCfg.BearerTokenFile).To(Equal(filepath.Join(tmpDir, "token")))
+			Expect(restCfg.TLSClientConfig.CAFile).To(Equal(filepath.Join(tmpDir, "ca.crt")))
+
+			By("failing without host file")
+			Expect(os.Remove(filepath.Join(tmpDir, "host"))).To(Succeed())
+			_, err = LoadKubeconfig(tmpDir)
+			Expect(err).To(MatchError(ContainSubstring("error reading host file")))
+		})
+
+		It("should fail for missing paths and invalid kubeconfigs", func() {
+			_, err := LoadKubeconfig(filepath.Join(tmpDir, "missing"))
+			Expect(err).To(HaveOccurred())
+
+			file := filepath.Join(tmpDir, "kubeconfig.yaml")
+			Expect(os.WriteFile(file, []byte("{ invalid"), os.ModePerm)).To(Succeed())
+			_, err = LoadKubeconfig(file)
+			Expect(err).To(HaveOccurred())
+		})
+
+	})
+
+	Context("Complete", func() {
+
+		It("should load the cluster configurations of sync configs with their own kubeconfig", func() {
+			defaultKubeconfig := filepath.Join(tmpDir, "default.yaml")
+			writeKubeconfig(defaultKubeconfig, "https://default.example.com")
+			remoteKubeconfig := filepath.Join(tmpDir, "remote.yaml")
+			writeKubeconfig(remoteKubeconfig, "https://remote.example.com")
+			oidcDir := filepath.Join(tmpDir, "oidc")
+			Expect(os.Mkdir(oidcDir, os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(oidcDir, "host"), []byte("https://oidc.example.com"), os.ModePerm)).To(Succeed())
+
+			cfg := &config.K8SyncerConfiguration{
+				SyncConfigs: []*config.SyncConfig{
+					syncConfig("Default", "", ""),
+					syncConfig("Remote", remoteKubeconfig, "remote"),
+					syncConfig("RemoteToo", remoteKubeconfig, "remote"),
+					syncConfig("OIDC", oidcDir, ""),
+				},
+				StorageDefinitions: []*config.StorageDefinition{{Name: "mock", Type: config.STORAGE_TYPE_MOCK}},
+			}
+			data, err := json.Marshal(cfg)
+			Expect(err).ToNot(HaveOccurred())
+			configPath := filepath.Join(tmpDir, "config.yaml")
+			Expect(os.WriteFile(configPath, data, os.ModePerm)).To(Succeed())
+
+			o := NewOptions()
+			o.ConfigPath = configPath
+			o.ClusterConfigPath = defaultKubeconfig
+			o.ClusterName = "main"
+			Expect(o.Complete()).To(Succeed())
+			Expect(o.Config.ClusterName).To(Equal("main"))
+			Expect(o.ClusterConfig.Host).To(Equal("https://default.example.com"))
+			Expect(o.SyncConfigClusterConfigs).To(HaveLen(2))
+			Expect(o.clusterConfigFor(o.Config.SyncConfigs[0])).To(BeIdenticalTo(o.ClusterConfig))
+			Expect(o.clusterConfigFor(o.Config.SyncConfigs[1]).Host).To(Equal("https://remote.example.com"))
+			Expect(o.clusterConfigFor(o.Config.SyncConfigs[2])).To(BeIdenticalTo(o.clusterConfigFor(o.Config.SyncConfigs[1])))
+			Expect(o.clusterConfigFor(o.Config.SyncConfigs[3]).Host).To(Equal("https://oidc.example.com"))
+
+			By("identifying the clusters by the cluster names of the sync configs")
+			Expect(o.Config.SyncConfigs[0].ClusterNameOrDefault(o.Config.ClusterName)).To(Equal("main"))
+			Expect(o.Config.SyncConfigs[1].ClusterNameOrDefault(o.Config.ClusterName)).To(Equal("remote"))
+			Expect(o.Config.SyncConfigs[3].ClusterNameOrDefault(o.Config.ClusterName)).To(Equal("main"))
+
+			By("failing if the kubeconfig of a sync config cannot be loaded")
+			cfg.SyncConfigs[3].Kubeconfig = filepath.Join(tmpDir, "missing")
+			data, err = json.Marshal(cfg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(configPath, data, os.ModePerm)).To(Succeed())
+			o = NewOptions()
+			o.ConfigPath = configPath
+			o.ClusterConfigPath = defaultKubeconfig
+			Expect(o.Complete()).To(MatchError(ContainSubstring("unable to load kubeconfig of sync config 'OIDC'")))
+		})
+
+	})
+
+})
@@ -0,0 +1,33 @@
+#!/bin/bash
+#
+# SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+#
+# SPDX-License-Identifier: Apache-2.0
+
+set -euo pipefail
+
+PROJECT_ROOT="$(realpath $(dirname $0)/..)"
+if [[ -z ${LOCALBIN:-} ]]; then
+  LOCALBIN="$PROJECT_ROOT/bin"
+fi
+if [[ -z ${PROTOC:-} ]]; then
+  PROTOC="$LOCALBIN/protoc"
+fi
+
+PROTOC_VERSION="$1"
+
+echo "Installing protoc $PROTOC_VERSION ..."
+os="linux"
+if [[ $(uname -o) == "Darwin" ]]; then
+  os="osx"
+fi
+arch="x86_64"
+if [[ $(uname -m) == "arm64" || $(uname -m) == "aarch64" ]]; then
+  arch="aarch_64"
+fi
+tmpdir=$(mktemp -d)
+trap "rm -rf $tmpdir" EXIT
+curl -sfL "https://github.com/protocolbuffers/protobuf/releases/download/v${PROTOC_VERSION}/protoc-${PROTOC_VERSION}-${os}-${arch}.zip" --output "$tmpdir/protoc.zip"
+unzip -q "$tmpdir/protoc.zip" bin/protoc -d "$tmpdir"
+mv "$tmpdir/bin/protoc" "$PROTOC"
+chmod +x "$PROTOC"
@@ -0,0 +1,235 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// config-schema generates a JSON schema for the K8Syncer configuration from the config structs.
+// The descriptions are taken from the doc comments of the struct fields and the allowed values of string types
+// are taken from the typed constants declared for them.
+//
+// Usage: go run ./hack/tools/config-schema <path to pkg/config> <output file>
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const rootType = "K8SyncerConfiguration"
+
+type generator struct {
+	structs     map[string]*ast.StructType
+	stringTypes map[string]bool
+	enums       map[string][]string
+	definitions map[string]any
+}
+
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Fprintln(os.Stderr, "usage: config-schema <path to pkg/config> <output file>")
+		os.Exit(1)
+	}
+	data, err := generate(os.Args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error generating config schema: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(os.Args[2], data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing config schema: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func generate(dir string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{
+		structs:     map[string]*ast.StructType{},
+		stringTypes: map[string]bool{},
+		enums:       map[string][]string{},
+		definitions: map[string]any{},
+	}
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			g.collect(f)
+		}
+	}
+	if _, ok := g.structs[rootType]; !ok {
+		return nil, fmt.Errorf("type %s not found in %s", rootType, dir)
+	}
+	if err := g.define(rootType); err != nil {
+		return nil, err
+	}
+	schema := map[string]any{
+		"$schema":     "http://json-schema.org/draft-07/schema#",
+		"title":       "K8Syncer Configuration",
+		"$ref":        "#/definitions/" + rootType,
+		"definitions": g.definitions,
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// collect records all struct types, string types, and typed string constants of the given file.
+func (g *generator) collect(f *ast.File) {
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				switch t := s.Type.(type) {
+				case *ast.StructType:
+					g.structs[s.Name.Name] = t
+				case *ast.Ident:
+					if t.Name == "string" {
+						g.stringTypes[s.Name.Name] = true
+					}
+				}
+			case *ast.ValueSpec:
+				if gd.Tok != token.CONST {
+					continue
+				}
+				typ, ok := s.Type.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				for _, v := range s.Values {
+					lit, ok := v.(*ast.BasicLit)
+					if !ok || lit.Kind != token.STRING {
+						continue
+					}
+					if value, err := strconv.Unquote(lit.Value); err == nil {
+						g.enums[typ.Name] = append(g.enums[typ.Name], value)
+					}
+				}
+			}
+		}
+	}
+}
+
+// define adds the definition for the struct type with the given name and all struct types referenced by it.
+func (g *generator) define(name string) error {
+	if _, exists := g.definitions[name]; exists {
+		return nil
+	}
+	st, ok := g.structs[name]
+	if !ok {
+		return fmt.Errorf("unknown struct type %s", name)
+	}
+	props := map[string]any{}
+	// the definition is registered before the fields are processed to support recursive types
+	g.definitions[name] = map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties":           props,
+	}
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			return fmt.Errorf("embedded fields are not supported (type %s)", name)
+		}
+		jsonName := field.Names[0].Name
+		if field.Tag != nil {
+			tag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return err
+			}
+			jsonName, _, _ = strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+		}
+		if jsonName == "-" || !field.Names[0].IsExported() {
+			continue
+		}
+		prop, err := g.schemaFor(field.Type)
+		if err != nil {
+			return fmt.Errorf("error generating schema for field %s.%s: %w", name, field.Names[0].Name, err)
+		}
+		if desc := description(field.Doc); desc != "" {
+			prop["description"] = desc
+		}
+		props[jsonName] = prop
+	}
+	return nil
+}
+
+func (g *generator) schemaFor(expr ast.Expr) (map[string]any, error) {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return g.schemaFor(t.X)
+	case *ast.ArrayType:
+		items, err := g.schemaFor(t.Elt)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case *ast.MapType:
+		values, err := g.schemaFor(t.Value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok && pkg.Name == "metav1" && t.Sel.Name == "Duration" {
+			return map[string]any{"type": "string", "format": "duration"}, nil
+		}
+		return nil, fmt.Errorf("unsupported type %T", expr)
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return map[string]any{"type": "string"}, nil
+		case "bool":
+			return map[string]any{"type": "boolean"}, nil
+		case "int", "int32", "int64":
+			return map[string]any{"type": "integer"}, nil
+		case "float32", "float64":
+			return map[string]any{"type": "number"}, nil
+		}
+		if g.stringTypes[t.Name] {
+			res := map[string]any{"type": "string"}
+			if values := g.enums[t.Name]; len(values) > 0 {
+				sorted := append([]string{}, values...)
+				sort.Strings(sorted)
+				res["enum"] = sorted
+			}
+			return res, nil
+		}
+		if _, ok := g.structs[t.Name]; ok {
+			if err := g.define(t.Name); err != nil {
+				return nil, err
+			}
+			return map[string]any{"$ref": "#/definitions/" + t.Name}, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported type %T", expr)
+}
+
+// description returns the text of the given doc comment, without markers like '+optional'.
+func description(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	lines := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(doc.Text()), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "+") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrladmission "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// WebhookPath is the path under which the state guard is served by the webhook server.
+const WebhookPath = "/validate-sync-state"
+
+var _ ctrladmission.Handler = &StateGuard{}
+
+// StateGuard is a validating admission handler which rejects modifications of the state annotations and the finalizer of K8Syncer
+// by anyone but K8Syncer itself and the explicitly allowed users and groups.
+// Only updates are validated, creations and deletions are always allowed.
+// Use NewStateGuard to instantiate it.
+type StateGuard struct {
+	log           logging.Logger
+	allowedUsers  sets.Set[string]
+	allowedGroups sets.Set[string]
+}
+
+// NewStateGuard creates a new StateGuard, which allows the given users and the users and groups from the configuration to modify the protected metadata.
+func NewStateGuard(log logging.Logger, cfg *config.AdmissionWebhookConfiguration, ownUsers ...string) *StateGuard {
+	return &StateGuard{
+		log:           log,
+		allowedUsers:  sets.New(ownUsers...).Insert(cfg.AllowedUsers...),
+		allowedGroups: sets.New(cfg.AllowedGroups...),
+	}
+}
+
+// AddStateGuardToManager determines the identities of K8Syncer in the given clusters and registers the state guard at the webhook server of the manager.
+// The clients have to use the same credentials as the clients which K8Syncer uses to write the state, as their users are allowed to modify it.
+func AddStateGuardToManager(ctx context.Context, mgr manager.Manager, cfg *config.AdmissionWebhookConfiguration, clients map[string]client.Client) error {
+	log := logging.FromContextOrDiscard(ctx).WithName("stateGuard")
+	ownUsers := []string{}
+	for clusterKey, c := range clients {
+		user, err := OwnUsername(ctx, c)
+		if err != nil {
+			if clusterKey == "" {
+				return err
+			}
+			return fmt.Errorf("error for cluster '%s': %w", clusterKey, err)
+		}
+		ownUsers = append(ownUsers, user)
+	}
+	sg := NewStateGuard(log, cfg, ownUsers...)
+	mgr.GetWebhookServer().Register(WebhookPath, &ctrladmission.Webhook{Handler: sg})
+	log.Info("Registered state guard admission webhook", "path", WebhookPath, "ownUsers", ownUsers)
+	return nil
+}
+
+// OwnUsername returns the name of the user which is authenticated by the given client, determined via a SelfSubjectReview.
+func OwnUsername(ctx context.Context, c client.Client) (string, error) {
+	ssr := &authenticationv1.SelfSubjectReview{}
+	if err := c.Create(ctx, ssr); err != nil {
+		return "", fmt.Errorf("unable to determine own user via SelfSubjectReview: %w", err)
+	}
+	if ssr.Status.UserInfo.Username == "" {
+		return "", fmt.Errorf("unable to determine own user via SelfSubjectReview: response doesn't contain a user name")
+	}
+	return ssr.Status.UserInfo.Username, nil
+}
+
+// Handle validates an admission request.
+func (sg *StateGuard) Handle(_ context.Context, req ctrladmission.Request) ctrladmission.Response {
+	if req.Operation != admissionv1.Update {
+		return ctrladmission.Allowed("")
+	}
+	if sg.isAllowed(req.UserInfo) {
+		return ctrladmission.Allowed("")
+	}
+
+	oldObj := &metav1.PartialObjectMetadata{}
+	if err := json.Unmarshal(req.OldObject.Raw, oldObj); err != nil {
+		return ctrladmission.Errored(http.StatusBadRequest, fmt.Errorf("unable to decode old object: %w", err))
+	}
+	newObj := &metav1.PartialObjectMetadata{}
+	if err := json.Unmarshal(req.Object.Raw, newObj); err != nil {
+		return ctrladmission.Errored(http.StatusBadRequest, fmt.Errorf("unable to decode object: %w", err))
+	}
+
+	changes := protectedChanges(oldObj, newObj)
+	if len(changes) == 0 {
+		return ctrladmission.Allowed("")
+	}
+	sg.log.Info("Rejecting modification of sync state", constants.Logging.KEY_RESOURCE_NAMESPACE, req.Namespace, constants.Logging.KEY_RESOURCE_NAME, req.Name, constants.Logging.KEY_RESOURCE_KIND, req.Kind.String(), constants.Logging.KEY_USER, req.UserInfo.Username, "changes", changes)
+	return ctrladmission.Denied(fmt.Sprintf("user '%s' is not allowed to modify %s, which is managed by K8Syncer", req.UserInfo.Username, strings.Join(changes, ", ")))
+}
+
+// isAllowed returns true if the given user is allowed to modify the protected metadata.
+func (sg *StateGuard) isAllowed(user authenticationv1.UserInfo) bool {
+	return sg.allowedUsers.Has(user.Username) || sg.allowedGroups.HasAny(user.Groups...)
+}
+
+// protectedChanges returns a description of each protected annotation or finalizer which differs between the given objects.
+// Annotations are protected if they are state annotations, see utils.IsStateAnnotation.
+func protectedChanges(oldObj, newObj client.Object) []string {
+	res := []string{}
+	oldAnns := oldObj.GetAnnotations()
+	newAnns := newObj.GetAnnotations()
+	keys := sets.New[string]()
+	for k := range oldAnns {
+		if utils.IsStateAnnotation(k) {
+			keys.Insert(k)
+		}
+	}
+	for k := range newAnns {
+		if utils.IsStateAnnotation(k) {
+			keys.Insert(k)
+		}
+	}
+	for _, k := range sets.List(keys) {
+		oldValue, oldExists := oldAnns[k]
+		newValue, newExists := newAnns[k]
+		if oldExists != newExists || oldValue != newValue {
+			res = append(res, fmt.Sprintf("annotation '%s'", k))
+		}
+	}
+	if controllerutil.ContainsFinalizer(oldObj, constants.K8SYNCER_FINALIZER) != controllerutil.ContainsFinalizer(newObj, constants.K8SYNCER_FINALIZER) {
+		res = append(res, fmt.Sprintf("finalizer '%s'", constants.K8SYNCER_FINALIZER))
+	}
+	return res
+}
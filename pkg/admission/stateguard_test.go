@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package admission
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrladmission "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+var _ = Describe("StateGuard", func() {
+
+	var (
+		sg     *StateGuard
+		oldObj *unstructured.Unstructured
+	)
+
+	BeforeEach(func() {
+		sg = NewStateGuard(logging.Discard(), &config.AdmissionWebhookConfiguration{
+			AllowedUsers:  []string{"admin"},
+			AllowedGroups: []string{"system:masters"},
+		}, "system:serviceaccount:k8syncer:k8syncer")
+		oldObj = &unstructured.Unstructured{}
+		oldObj.SetAPIVersion("k8syncer.gardener.cloud/v1")
+		oldObj.SetKind("Dummy")
+		oldObj.SetNamespace("foo")
+		oldObj.SetName("bar")
+		oldObj.SetAnnotations(map[string]string{
+			constants.ANNOTATION_PHASE:                   "Finished",
+			"custom.state.k8syncer.gardener.cloud/phase": "Finished",
+			"example.com/other":                          "foo",
+		})
+		oldObj.SetFinalizers([]string{constants.K8SYNCER_FINALIZER, "example.com/other"})
+	})
+
+	request := func(op admissionv1.Operation, user string, groups []string, oldObj, newObj *unstructured.Unstructured) ctrladmission.Request {
+		req := ctrladmission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: op,
+				Namespace: newObj.GetNamespace(),
+				Name:      newObj.GetName(),
+				UserInfo: authenticationv1.UserInfo{
+					Username: user,
+					Groups:   groups,
+				},
+			},
+		}
+		data, err := json.Marshal(newObj)
+		Expect(err).ToNot(HaveOccurred())
+		req.Object = runtime.RawExtension{Raw: data}
+		if oldObj != nil {
+			data, err := json.Marshal(oldObj)
+			Expect(err).ToNot(HaveOccurred())
+			req.OldObject = runtime.RawExtension{Raw: data}
+		}
+		return req
+	}
+
+	It("should allow modifications of other metadata", func() {
+		newObj := oldObj.DeepCopy()
+		ann := newObj.GetAnnotations()
+		ann["example.com/other"] = "bar"
+		newObj.SetAnnotations(ann)
+		newObj.SetFinalizers([]string{constants.K8SYNCER_FINALIZER})
+		newObj.SetLabels(map[string]string{"foo": "bar"})
+
+		res := sg.Handle(context.Background(), request(admissionv1.Update, "user", nil, oldObj, newObj))
+		Expect(res.Allowed).To(BeTrue())
+	})
+
+	It("should reject modifications of state annotations and the finalizer by other users", func() {
+		newObj := oldObj.DeepCopy()
+		newObj.SetAnnotations(map[string]string{
+			constants.ANNOTATION_PHASE:        "Error",
+			constants.ANNOTATION_CONTENT_HASH: "abc",
+			"example.com/other":               "foo",
+		})
+		newObj.SetFinalizers([]string{"example.com/other"})
+
+		res := sg.Handle(context.Background(), request(admissionv1.Update, "user", []string{"system:authenticated"}, oldObj, newObj))
+		Expect(res.Allowed).To(BeFalse())
+		Expect(res.Result).ToNot(BeNil())
+		Expect(res.Result.Message).To(ContainSubstring("user 'user'"))
+		Expect(res.Result.Message).To(ContainSubstring(constants.ANNOTATION_PHASE))
+		Expect(res.Result.Message).To(ContainSubstring(constants.ANNOTATION_CONTENT_HASH))
+		Expect(res.Result.Message).To(ContainSubstring("custom.state.k8syncer.gardener.cloud/phase"))
+		Expect(res.Result.Message).To(ContainSubstring("finalizer '%s'", constants.K8SYNCER_FINALIZER))
+		Expect(res.Result.Message).ToNot(ContainSubstring("example.com/other"))
+	})
+
+	It("should allow modifications by K8Syncer and the allowed users and groups", func() {
+		newObj := oldObj.DeepCopy()
+		newObj.SetAnnotations(nil)
+		newObj.SetFinalizers(nil)
+
+		for _, user := range []string{"system:serviceaccount:k8syncer:k8syncer", "admin"} {
+			res := sg.Handle(context.Background(), request(admissionv1.Update, user, nil, oldObj, newObj))
+			Expect(res.Allowed).To(BeTrue(), "user: %s", user)
+		}
+		res := sg.Handle(context.Background(), request(admissionv1.Update, "user", []string{"system:authenticated", "system:masters"}, oldObj, newObj))
+		Expect(res.Allowed).To(BeTrue())
+	})
+
+	It("should not validate other operations than updates", func() {
+		res := sg.Handle(context.Background(), request(admissionv1.Create, "user", nil, nil, oldObj))
+		Expect(res.Allowed).To(BeTrue())
+	})
+
+})
@@ -4,17 +4,237 @@
 
 package config
 
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 // K8SyncerConfiguration contains the K8Syncer configuration.
 type K8SyncerConfiguration struct {
 	SyncConfigs        []*SyncConfig        `json:"syncConfigs,omitempty"`
 	StorageDefinitions []*StorageDefinition `json:"storageDefinitions,omitempty"`
+	// ClusterName is an identifier for the cluster from which the resources are synced.
+	// If set, it is added as annotation to all persisted resources and it can be referenced in the subPaths of storage references via '{{ .ClusterName }}'.
+	// This allows distinguishing the resources of multiple clusters which are synced into the same storage.
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
+	// NamespacePruning configures the removal of the data of namespaces which have been deleted in the cluster.
+	// If set, the namespace directories of deleted namespaces are pruned in the storages of all sync configs.
+	// +optional
+	NamespacePruning *NamespacePruningConfiguration `json:"namespacePruning,omitempty"`
+	// ClientRateLimit configures the rate limit of the clients which K8Syncer uses to access the clusters.
+	// It applies to all clusters, sync configs can override it with their own rate limit.
+	// If not set, the defaults of the Kubernetes client library are used.
+	// +optional
+	ClientRateLimit *ClientRateLimitConfiguration `json:"clientRateLimit,omitempty"`
+	// WriterIdentity configures stamping all persisted resources with the identity of the K8Syncer instance which has written them.
+	// If set, overwriting a resource which has been written by another instance or with another configuration is reported,
+	// which helps detecting setups in which multiple instances accidentally write into the same storage.
+	// +optional
+	WriterIdentity *WriterIdentityConfiguration `json:"writerIdentity,omitempty"`
+	// SyncTrigger configures an endpoint on the metrics server which triggers the sync of a specific resource on demand.
+	// If not set, the endpoint is not served.
+	// +optional
+	SyncTrigger *SyncTriggerConfiguration `json:"syncTrigger,omitempty"`
+	// ErrorLogs configures how errors which occur while syncing resources are logged.
+	// +optional
+	ErrorLogs *ErrorLogConfiguration `json:"errorLogs,omitempty"`
+	// AdmissionWebhook configures a validating admission webhook which rejects modifications of the state annotations
+	// and the finalizer of K8Syncer by anyone but K8Syncer itself, so that the sync state cannot be tampered with.
+	// If not set, the webhook is not served.
+	// +optional
+	AdmissionWebhook *AdmissionWebhookConfiguration `json:"admissionWebhook,omitempty"`
+	// Metrics configures the metrics which K8Syncer exposes on the metrics server.
+	// +optional
+	Metrics *MetricsConfiguration `json:"metrics,omitempty"`
+	// SubPathPruning configures the removal of the data below the subPaths of storage references which have been removed from the configuration.
+	// If set, the subPaths of all storage references are recorded in a ConfigMap. On startup, the data below recorded subPaths
+	// which are not referenced anymore is removed from the storages.
+	// +optional
+	SubPathPruning *SubPathPruningConfiguration `json:"subPathPruning,omitempty"`
+	// StartupSummary configures publishing a machine-readable summary of the configured syncs in a ConfigMap on startup,
+	// which allows verifying that the deployed configuration matches the intended one.
+	// The summary of each sync config is logged on startup independently of this setting.
+	// If not set, no ConfigMap is written.
+	// +optional
+	StartupSummary *StartupSummaryConfiguration `json:"startupSummary,omitempty"`
+}
+
+// StartupSummaryConfiguration configures the ConfigMap which contains the startup summary.
+type StartupSummaryConfiguration struct {
+	// ConfigMapName is the name of the ConfigMap which contains the summary.
+	// The ConfigMap is created in the cluster specified via the '--kubeconfig' flag.
+	// Defaults to 'k8syncer-startup-summary'.
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+	// ConfigMapNamespace is the namespace of the ConfigMap.
+	// Defaults to 'default'.
+	// +optional
+	ConfigMapNamespace string `json:"configMapNamespace,omitempty"`
+}
+
+// SubPathPruningConfiguration configures the removal of the data of removed storage references.
+type SubPathPruningConfiguration struct {
+	// ConfigMapName is the name of the ConfigMap in which the subPaths of the storage references are recorded.
+	// The ConfigMap is created in the cluster specified via the '--kubeconfig' flag.
+	// Defaults to 'k8syncer-managed-subpaths'.
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+	// ConfigMapNamespace is the namespace of the ConfigMap.
+	// Defaults to 'default'.
+	// +optional
+	ConfigMapNamespace string `json:"configMapNamespace,omitempty"`
+	// DryRun only logs which subPaths would be pruned, without removing any data.
+	// The subPaths stay recorded, so that they are pruned once DryRun is disabled.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// MetricsConfiguration configures the exposed metrics.
+type MetricsConfiguration struct {
+	// SyncErrors configures the labels of the 'k8syncer_sync_errors' metric.
+	// By default, it has one series per object whose last sync failed, which can result in many series in big clusters.
+	// +optional
+	SyncErrors *SyncErrorMetricsConfiguration `json:"syncErrors,omitempty"`
+}
+
+// SyncErrorMetricsConfiguration configures which labels the sync error metric has and how the objects are aggregated into series.
+type SyncErrorMetricsConfiguration struct {
+	// Labels are the labels of the metric.
+	// Objects which don't differ in any of these labels are aggregated into a single series, see Aggregation.
+	// Defaults to all labels, 'sync_config', 'gvk', 'namespace', and 'name'.
+	// +optional
+	Labels []SyncErrorMetricLabel `json:"labels,omitempty"`
+	// Aggregation specifies how the consecutive failed sync attempts of the objects of a series are aggregated.
+	// Defaults to 'max', which results in the same values as without aggregation if all labels are included.
+	// +optional
+	Aggregation MetricAggregation `json:"aggregation,omitempty"`
+	// MaxNamespaces limits the number of different values of the 'namespace' label.
+	// If the objects whose last sync failed belong to more namespaces, only the namespaces with the most failed objects get their own label value,
+	// the objects of the remaining namespaces are aggregated under the value '_other'.
+	// 0 means that the number of namespaces is not limited.
+	// Must not be set if the labels don't contain 'namespace'.
+	// +optional
+	MaxNamespaces int `json:"maxNamespaces,omitempty"`
+}
+
+type SyncErrorMetricLabel string
+
+const (
+	// SYNC_ERROR_METRIC_LABEL_SYNC_CONFIG is the label containing the ID of the sync config.
+	SYNC_ERROR_METRIC_LABEL_SYNC_CONFIG SyncErrorMetricLabel = "sync_config"
+	// SYNC_ERROR_METRIC_LABEL_GVK is the label containing the GroupVersionKind of the object.
+	SYNC_ERROR_METRIC_LABEL_GVK SyncErrorMetricLabel = "gvk"
+	// SYNC_ERROR_METRIC_LABEL_NAMESPACE is the label containing the namespace of the object.
+	SYNC_ERROR_METRIC_LABEL_NAMESPACE SyncErrorMetricLabel = "namespace"
+	// SYNC_ERROR_METRIC_LABEL_NAME is the label containing the name of the object.
+	SYNC_ERROR_METRIC_LABEL_NAME SyncErrorMetricLabel = "name"
+)
+
+// ALL_SYNC_ERROR_METRIC_LABELS contains all labels of the sync error metric, in the order in which they are exposed.
+var ALL_SYNC_ERROR_METRIC_LABELS = []SyncErrorMetricLabel{SYNC_ERROR_METRIC_LABEL_SYNC_CONFIG, SYNC_ERROR_METRIC_LABEL_GVK, SYNC_ERROR_METRIC_LABEL_NAMESPACE, SYNC_ERROR_METRIC_LABEL_NAME}
+
+type MetricAggregation string
+
+const (
+	// METRIC_AGGREGATION_MAX means that the maximum of the aggregated values is exposed.
+	METRIC_AGGREGATION_MAX MetricAggregation = "max"
+	// METRIC_AGGREGATION_SUM means that the sum of the aggregated values is exposed.
+	METRIC_AGGREGATION_SUM MetricAggregation = "sum"
+	// METRIC_AGGREGATION_COUNT means that the number of aggregated values is exposed.
+	METRIC_AGGREGATION_COUNT MetricAggregation = "count"
+)
+
+// AdmissionWebhookConfiguration configures the webhook server which protects the sync state of the synced resources.
+// The webhook has to be registered at the watched clusters via a ValidatingWebhookConfiguration for the synced resources.
+type AdmissionWebhookConfiguration struct {
+	// Port is the port on which the webhook server listens.
+	// Defaults to 9443.
+	// +optional
+	Port int `json:"port,omitempty"`
+	// CertDir is the directory which contains the serving certificate 'tls.crt' and its key 'tls.key'.
+	// The files are reloaded when they change.
+	CertDir string `json:"certDir"`
+	// AllowedUsers are the names of users which are allowed to modify the protected annotations and the finalizer, in addition to K8Syncer itself.
+	// +optional
+	AllowedUsers []string `json:"allowedUsers,omitempty"`
+	// AllowedGroups are the names of groups whose members are allowed to modify the protected annotations and the finalizer.
+	// +optional
+	AllowedGroups []string `json:"allowedGroups,omitempty"`
+}
+
+// ErrorLogConfiguration configures the deduplication of errors which are logged repeatedly for the same resource.
+type ErrorLogConfiguration struct {
+	// RepeatInterval is the interval in which an identical error is logged at most once per resource.
+	// Further occurrences within the interval are counted and reported by the next log entry for the error.
+	// When the resource has been synced successfully again, a summary of the failed attempts is logged.
+	// Set to 0 to log every occurrence. Defaults to 10m.
+	// +optional
+	RepeatInterval *metav1.Duration `json:"repeatInterval,omitempty"`
+}
+
+// SyncTriggerConfiguration configures the authentication for the sync trigger endpoint.
+// Requests have to send the token as bearer token in the 'Authorization' header.
+// Exactly one of Token and TokenFile must be set.
+type SyncTriggerConfiguration struct {
+	// Token is the token which requests have to send.
+	// +optional
+	Token string `json:"token,omitempty"`
+	// TokenFile is a path to a file containing the token.
+	// +optional
+	TokenFile string `json:"tokenFile,omitempty"`
+}
+
+// WriterIdentityConfiguration configures the identity with which the persisted resources are stamped.
+// The identity consists of the instance name and a hash over the configuration, which is computed automatically.
+type WriterIdentityConfiguration struct {
+	// InstanceName is the name of this K8Syncer instance, e.g. '${POD_NAME}'.
+	// Defaults to the hostname, which is the pod name when running in a pod.
+	// +optional
+	InstanceName string `json:"instanceName,omitempty"`
+}
+
+// ClientRateLimitConfiguration configures the client-side rate limit for requests to the kube-apiserver.
+// Reads which are answered from the informer caches are not affected.
+type ClientRateLimitConfiguration struct {
+	// QPS is the maximum number of requests per second on average.
+	// If 0, the value is inherited.
+	// +optional
+	QPS float32 `json:"qps,omitempty"`
+	// Burst is the maximum number of requests which can be sent at once, exceeding QPS for a short time.
+	// If 0, the value is inherited.
+	// +optional
+	Burst int `json:"burst,omitempty"`
+}
+
+type NamespacePruningConfiguration struct {
+	// Mode specifies what happens to the data of deleted namespaces.
+	// Supported values are
+	//   'delete' - the namespace directory is deleted
+	//   'archive' - the namespace directory is moved below archiveSubPath
+	// Defaults to 'delete'.
+	// +optional
+	Mode NamespacePruningMode `json:"mode,omitempty"`
+	// ArchiveSubPath is the path from the storage's root element to the folder into which the namespace directories are moved.
+	// It is evaluated as a go template, see SubPathTemplateData for the available values, 'Kind' is not set.
+	// Required for mode 'archive'.
+	// +optional
+	ArchiveSubPath string `json:"archiveSubPath,omitempty"`
 }
 
+type NamespacePruningMode string
+
+const (
+	// NAMESPACE_PRUNING_MODE_DELETE means that the data of deleted namespaces is deleted.
+	NAMESPACE_PRUNING_MODE_DELETE NamespacePruningMode = "delete"
+	// NAMESPACE_PRUNING_MODE_ARCHIVE means that the data of deleted namespaces is moved into an archive folder.
+	NAMESPACE_PRUNING_MODE_ARCHIVE NamespacePruningMode = "archive"
+)
+
 type SyncConfig struct {
 	// ID is a unique identifier.
 	// It has no effect except for being included in the logs, so it allows to filter for outputs from a specific watcher,
 	// which is useful if there are multiple sync configs defined which watch the same resource.
-	ID string
+	ID string `json:"id"`
 	// Resource specifies which resource should be synced.
 	Resource *ResourceSyncConfig `json:"resource,omitempty"`
 	// StorageRefs reference the storage definitions.
@@ -27,8 +247,367 @@ type SyncConfig struct {
 	State *StateConfiguration `json:"state,omitempty"`
 	// Finalize specifies whether or not to use a finalizer on the specified resource.
 	// Note that without a finalizer, no sync state will be shown for deletion, as the resource could be gone immediately.
-	// Defaults to true.
+	// Defaults to true, unless readOnlySource is set.
 	Finalize *bool `json:"finalize,omitempty"`
+	// Impersonate specifies a subject which is impersonated when reading the synced resources from the cluster.
+	// This way, only resources which are visible to this subject are persisted.
+	// Writing state and finalizers is still done with the controller's own identity.
+	// +optional
+	Impersonate *ImpersonationConfiguration `json:"impersonate,omitempty"`
+	// Snapshot configures periodic snapshots of all resources which have been persisted for this sync config.
+	// A snapshot bundles all persisted files into a single artifact, which can be used to restore the resources.
+	// +optional
+	Snapshot *SnapshotConfiguration `json:"snapshot,omitempty"`
+	// ChangeDetection specifies how changes of the synced resources are detected.
+	// This affects which updates trigger a sync and which value is stored as last synced generation in the state.
+	// Supported values are
+	//   'generation' - changes of metadata.generation, which is not increased for all resource kinds, e.g. ConfigMaps and Secrets
+	//   'resourceVersion' - changes of metadata.resourceVersion, which changes with every update of the resource
+	//   'contentHash' - changes of a hash over the resource, ignoring its status and fields which are managed by the cluster or by K8Syncer
+	// Defaults to 'generation'.
+	// +optional
+	ChangeDetection ChangeDetectionMode `json:"changeDetection,omitempty"`
+	// AnnotateContentHash specifies whether the SHA256 hash of the persisted form of a resource should be written
+	// into the 'state.k8syncer.gardener.cloud/contentHash' annotation of the resource after it has been synced.
+	// This allows verifying that the archived version matches the resource without accessing the storage.
+	// +optional
+	AnnotateContentHash bool `json:"annotateContentHash,omitempty"`
+	// ReactOn specifies which changes of a resource trigger a sync.
+	// Creations and deletions of resources always trigger a sync.
+	// Defaults to the trigger which matches the change detection mode, together with 'labels' and 'ownerReferences'.
+	// +optional
+	ReactOn []ReactOnTrigger `json:"reactOn,omitempty"`
+	// Debounce is a window during which updates of the same resource are collapsed into a single sync.
+	// The resource is synced once the window since the first of its updates has passed, with the state it has at that time.
+	// This prevents alternating commits for resources which are modified by other controllers several times in rapid succession,
+	// e.g. when labels or owner references are set one after another.
+	// Creations and deletions of resources are always synced immediately.
+	// Disabled if not set.
+	// +optional
+	Debounce *metav1.Duration `json:"debounce,omitempty"`
+	// Kubeconfig is the path to the kubeconfig of the cluster which contains the resources of this sync config.
+	// It has the same format as the '--kubeconfig' flag, so it may also point to a directory.
+	// State and finalizers are written to this cluster too.
+	// Defaults to the cluster specified via the '--kubeconfig' flag.
+	// +optional
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	// Shoot references a Gardener shoot cluster which contains the resources of this sync config.
+	// The kubeconfig of the shoot is requested via the 'shoots/adminkubeconfig' subresource from the garden cluster
+	// and refreshed automatically before it expires. State and finalizers are written to the shoot cluster too.
+	// Mutually exclusive with Kubeconfig.
+	// +optional
+	Shoot *ShootReference `json:"shoot,omitempty"`
+	// ClusterName is an identifier for the cluster which contains the resources of this sync config.
+	// It is used instead of the global ClusterName for the annotation of the persisted resources and in the subPaths of storage references.
+	// Can only be set together with Kubeconfig or Shoot, sync configs for the same cluster must specify the same name.
+	// Defaults to the global ClusterName.
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
+	// PersistOwners specifies whether the chain of owners of a resource should be persisted in an 'owners' sidecar document
+	// next to the resource. The owners are resolved by following the owner references of the resource and its owners.
+	// Only storages of type 'filesystem' and 'git' support sidecar documents, other storages are ignored.
+	// +optional
+	PersistOwners bool `json:"persistOwners,omitempty"`
+	// StateWritePolicy specifies when the state is written to the resource during a sync.
+	// Supported values are
+	//   'always' - the intermediate phases 'Progressing' and 'Deleting' are written before a sync, the final phase afterwards
+	//   'onChangeOnly' - like 'always', but 'Progressing' is only written if the resource has changed since the last sync
+	//   'finalOnly' - only the final phase is written, so each sync causes at most one state write
+	// Errors are always written, independent of the policy.
+	// Defaults to 'always'.
+	// +optional
+	StateWritePolicy StateWritePolicy `json:"stateWritePolicy,omitempty"`
+	// ReadOnlySource specifies that K8Syncer must not write anything to the synced resources or their cluster.
+	// This allows syncing with an identity which is only allowed to get, list, and watch the resources.
+	// Finalizers, state display, and the content hash annotation cannot be used in this mode, so deletions which happen while
+	// K8Syncer is not running are detected by an orphan cleanup on startup instead, which removes all persisted resources
+	// that don't exist in the cluster anymore.
+	// If true, finalize defaults to false.
+	// +optional
+	ReadOnlySource bool `json:"readOnlySource,omitempty"`
+	// PersistCRD specifies whether the CustomResourceDefinition of the synced kind should be persisted too,
+	// so that the archived resources can be restored into a cluster which doesn't know the kind yet.
+	// The CRD is stored in a '_crds' directory below the subPath of each storage reference and kept up-to-date while K8Syncer is running.
+	// Only allowed for resources with a group.
+	// +optional
+	PersistCRD bool `json:"persistCRD,omitempty"`
+	// PersistNamespace specifies whether the Namespace object of a synced namespaced resource should be persisted too,
+	// so that the labels and annotations of the namespace can be restored from the archive.
+	// The Namespace is stored in the namespace directory next to the resources and updated whenever a resource in it is synced.
+	// Only storages of type 'filesystem' and 'git' support this, other storages are ignored.
+	// +optional
+	PersistNamespace bool `json:"persistNamespace,omitempty"`
+	// PersistIncludes specifies whether the resources referenced in the 'k8syncer.gardener.cloud/include' annotation of a synced resource
+	// should be persisted too, even if they are not synced themselves.
+	// The annotation contains a comma-separated list of references in the format '<resource>[.<group>]/<name>', e.g. 'secret/foo,configmap/bar'.
+	// The referenced resources have to be in the same namespace as the synced resource or cluster-scoped.
+	// They are stored below the same subPath as the synced resource and updated whenever it is synced.
+	// +optional
+	PersistIncludes bool `json:"persistIncludes,omitempty"`
+	// Suspend pauses syncing the resources of this sync config, without removing the sync config.
+	// Changes which happen while the sync is suspended are synced after it has been resumed, which requires a restart when this field is changed.
+	// Single resources can be suspended dynamically by setting the 'k8syncer.gardener.cloud/suspend' annotation to 'true' on them.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+	// MaintenanceWindows specify recurring time windows in which syncing is suspended, e.g. during planned maintenance of a storage or cluster upgrades.
+	// Resources which change during a window are synced after the window has ended.
+	// +optional
+	MaintenanceWindows []*MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+	// Transformer configures which fields of the synced resources are persisted.
+	// By default, annotations, finalizers, managed fields, and the status are removed.
+	// +optional
+	Transformer *TransformerConfiguration `json:"transformer,omitempty"`
+	// ContentMode specifies which parts of the synced resources are persisted.
+	// Supported values are
+	//   'full' - the resource is persisted as configured by the transformer
+	//   'metadataOnly' - only apiVersion, kind, and a metadata summary consisting of name, namespace, labels, uid, generation, and the creation and deletion timestamps are persisted
+	// The 'metadataOnly' mode results in an inventory of the resources, without exporting their spec or data. It cannot be combined with a transformer configuration.
+	// It also applies to the resources which are persisted via persistIncludes, persistNamespace, and persistCRD.
+	// Defaults to 'full'.
+	// +optional
+	ContentMode ContentMode `json:"contentMode,omitempty"`
+	// ClientRateLimit configures a dedicated rate limit for the requests of this sync config, e.g. for writing state and finalizers.
+	// If set, the sync config uses its own clients, which don't share the rate limit of the global clients with the other sync configs.
+	// This prevents a large initial sync or resync of this sync config from starving the others in the same cluster, and vice versa.
+	// Values which are not set are inherited from the global client rate limit.
+	// +optional
+	ClientRateLimit *ClientRateLimitConfiguration `json:"clientRateLimit,omitempty"`
+	// UpdateRetry configures how updates of the synced resources are retried if they fail with a conflict,
+	// e.g. because the resource's status is updated by another controller at the same time.
+	// This applies to updates of the state, the finalizer, and the content hash annotation.
+	// +optional
+	UpdateRetry *UpdateRetryConfiguration `json:"updateRetry,omitempty"`
+	// DeletionFailure configures when K8Syncer gives up deleting a resource from the storages which keeps failing, e.g. because of missing permissions.
+	// Without it, a failed deletion is retried until it succeeds, so a finalizer blocks the deletion of the resource forever.
+	// Cannot be combined with readOnlySource.
+	// +optional
+	DeletionFailure *DeletionFailureConfiguration `json:"deletionFailure,omitempty"`
+	// ChangeLog configures an append-only log of the changes which have been persisted for this sync config.
+	// For each resource which is written to or deleted from a storage, a line of JSON is appended to the change log file
+	// below the resource's subPath, which allows analyzing the timeline of changes without mining the git history.
+	// Only storages of type 'filesystem' and 'git' support a change log, other storages are ignored.
+	// +optional
+	ChangeLog *ChangeLogConfiguration `json:"changeLog,omitempty"`
+}
+
+// ChangeLogConfiguration configures the change log of a sync config.
+type ChangeLogConfiguration struct {
+	// FileName is the name of the change log file, which is stored directly below the subPath of each storage reference.
+	// Defaults to '<id>.changelog.ndjson', so that sync configs which share a subPath don't write to the same file.
+	// +optional
+	FileName string `json:"fileName,omitempty"`
+}
+
+// DeletionFailureConfiguration configures how deletions of resources from the storages which fail repeatedly are handled.
+type DeletionFailureConfiguration struct {
+	// MaxAttempts is the number of failed attempts to delete a resource from the storages after which K8Syncer gives up.
+	// It then removes the finalizer and records the data which is left in the storages in the orphaned-storage ConfigMap, so that it can be cleaned up later.
+	// The failed attempts are stored in the same ConfigMap until the deletion succeeds or is given up, so that the count survives restarts.
+	MaxAttempts int `json:"maxAttempts"`
+	// ConfigMapName is the name of the ConfigMap in which the storage data of resources whose deletion has been given up is recorded.
+	// The ConfigMap is created in the cluster of the sync config.
+	// Defaults to 'k8syncer-orphaned-storage'.
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+	// ConfigMapNamespace is the namespace of the orphaned-storage ConfigMap.
+	// Defaults to 'default'.
+	// +optional
+	ConfigMapNamespace string `json:"configMapNamespace,omitempty"`
+}
+
+// UpdateRetryConfiguration configures the retries of conflicting updates of the synced resources.
+type UpdateRetryConfiguration struct {
+	// MaxRetries is the number of times a conflicting update is retried, with the latest version of the resource fetched from the cluster.
+	// Defaults to 1.
+	// +optional
+	MaxRetries *int `json:"maxRetries,omitempty"`
+	// Backoff is the time to wait before the first retry. It is doubled for every further retry, up to one minute.
+	// If not set, updates are retried immediately.
+	// +optional
+	Backoff *metav1.Duration `json:"backoff,omitempty"`
+	// Jitter is the maximum fraction of the backoff which is randomly added to it, e.g. 0.5 for waiting up to 1.5 times the backoff.
+	// This prevents multiple controllers which update the same resource from retrying in lockstep.
+	// Requires backoff to be set.
+	// +optional
+	Jitter float64 `json:"jitter,omitempty"`
+}
+
+// TransformerConfiguration configures the fields which the transformer keeps in addition to its defaults.
+type TransformerConfiguration struct {
+	// KeepAnnotations specifies whether the annotations of the resource should be persisted.
+	// The state annotations written by K8Syncer are removed nevertheless.
+	// +optional
+	KeepAnnotations bool `json:"keepAnnotations,omitempty"`
+	// KeepFinalizers specifies whether the finalizers of the resource should be persisted.
+	// +optional
+	KeepFinalizers bool `json:"keepFinalizers,omitempty"`
+	// KeepStatus specifies whether the status of the resource should be persisted.
+	// Note that the status usually changes more often than the rest of the resource.
+	// +optional
+	KeepStatus bool `json:"keepStatus,omitempty"`
+	// KeepManagedFields specifies whether the managed fields of the resource should be persisted.
+	// +optional
+	KeepManagedFields bool `json:"keepManagedFields,omitempty"`
+	// SecretData specifies how the data of Secrets is persisted.
+	// Supported values are
+	//   'data' - the base64-encoded 'data' is persisted as it is
+	//   'stringData' - values of 'data' which are valid UTF-8 are persisted decoded as 'stringData', the others are kept in 'data'
+	//   'strip' - neither 'data' nor 'stringData' are persisted
+	// Only affects resources of kind 'Secret' in the core group, including Secrets which are persisted via persistIncludes.
+	// Defaults to 'data'.
+	// +optional
+	SecretData SecretDataMode `json:"secretData,omitempty"`
+	// PruneDefaults specifies whether fields which are set to the default value of their schema should be removed,
+	// so that the persisted resources are close to what a user would have written.
+	// The schema is taken from the CRD of the synced kind, or from the OpenAPI v3 document of the cluster for other kinds.
+	// Fields with a null value and objects which are only empty because all of their fields have been removed are removed too.
+	// +optional
+	PruneDefaults bool `json:"pruneDefaults,omitempty"`
+}
+
+type ContentMode string
+
+const (
+	// CONTENT_MODE_FULL means that the synced resources are persisted as configured by the transformer.
+	CONTENT_MODE_FULL ContentMode = "full"
+	// CONTENT_MODE_METADATA_ONLY means that only a summary of the metadata of the synced resources is persisted.
+	CONTENT_MODE_METADATA_ONLY ContentMode = "metadataOnly"
+)
+
+type SecretDataMode string
+
+const (
+	// SECRET_DATA_MODE_DATA means that the data of Secrets is persisted base64-encoded, as it is.
+	SECRET_DATA_MODE_DATA SecretDataMode = "data"
+	// SECRET_DATA_MODE_STRING_DATA means that the data of Secrets is persisted decoded as stringData, if possible.
+	SECRET_DATA_MODE_STRING_DATA SecretDataMode = "stringData"
+	// SECRET_DATA_MODE_STRIP means that the data of Secrets is not persisted.
+	SECRET_DATA_MODE_STRIP SecretDataMode = "strip"
+)
+
+// MaintenanceWindow is a recurring time window.
+type MaintenanceWindow struct {
+	// Days are the weekdays on which the window starts, e.g. 'Mon' or 'Saturday'.
+	// Defaults to every day.
+	// +optional
+	Days []string `json:"days,omitempty"`
+	// Start is the time of day at which the window starts, in the format 'HH:MM'.
+	Start string `json:"start"`
+	// End is the time of day at which the window ends, in the format 'HH:MM'.
+	// If it is not after Start, the window ends on the next day.
+	End string `json:"end"`
+	// TimeZone is the IANA name of the time zone in which Start and End are interpreted, e.g. 'Europe/Berlin'.
+	// Defaults to 'UTC'.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+type StateWritePolicy string
+
+const (
+	// STATE_WRITE_POLICY_ALWAYS means that intermediate and final phases are written for every sync.
+	STATE_WRITE_POLICY_ALWAYS StateWritePolicy = "always"
+	// STATE_WRITE_POLICY_ON_CHANGE_ONLY means that the 'Progressing' phase is only written if the resource has changed since the last sync.
+	STATE_WRITE_POLICY_ON_CHANGE_ONLY StateWritePolicy = "onChangeOnly"
+	// STATE_WRITE_POLICY_FINAL_ONLY means that only final phases are written.
+	STATE_WRITE_POLICY_FINAL_ONLY StateWritePolicy = "finalOnly"
+)
+
+type ReactOnTrigger string
+
+const (
+	// REACT_ON_GENERATION triggers a sync if the resource's generation changes.
+	REACT_ON_GENERATION ReactOnTrigger = "generation"
+	// REACT_ON_LABELS triggers a sync if the resource's labels change.
+	REACT_ON_LABELS ReactOnTrigger = "labels"
+	// REACT_ON_ANNOTATIONS triggers a sync if the resource's annotations change.
+	// Annotations written by K8Syncer itself are ignored.
+	REACT_ON_ANNOTATIONS ReactOnTrigger = "annotations"
+	// REACT_ON_OWNER_REFERENCES triggers a sync if the resource's owner references change.
+	REACT_ON_OWNER_REFERENCES ReactOnTrigger = "ownerReferences"
+	// REACT_ON_RESOURCE_VERSION triggers a sync if the resource's resourceVersion changes, which happens with every update.
+	REACT_ON_RESOURCE_VERSION ReactOnTrigger = "resourceVersion"
+	// REACT_ON_CONTENT_HASH triggers a sync if the content hash of the resource changes, see CHANGE_DETECTION_CONTENT_HASH.
+	REACT_ON_CONTENT_HASH ReactOnTrigger = "contentHash"
+)
+
+type ChangeDetectionMode string
+
+const (
+	// CHANGE_DETECTION_GENERATION means that changes are detected based on the resource's generation.
+	CHANGE_DETECTION_GENERATION ChangeDetectionMode = "generation"
+	// CHANGE_DETECTION_RESOURCE_VERSION means that changes are detected based on the resource's resourceVersion.
+	CHANGE_DETECTION_RESOURCE_VERSION ChangeDetectionMode = "resourceVersion"
+	// CHANGE_DETECTION_CONTENT_HASH means that changes are detected based on a hash over the resource's content.
+	CHANGE_DETECTION_CONTENT_HASH ChangeDetectionMode = "contentHash"
+)
+
+type SnapshotConfiguration struct {
+	// Interval is the interval in which snapshots are created.
+	Interval *metav1.Duration `json:"interval"`
+	// Format is the format of the created snapshot artifacts.
+	// Supported values are
+	//   'yaml' - a single multi-document YAML file
+	//   'tar.gz' - a gzipped tarball containing the persisted files
+	// Defaults to 'yaml'.
+	// +optional
+	Format SnapshotFormat `json:"format,omitempty"`
+	// Source is the name of the storage reference whose persisted resources are bundled.
+	// The referenced storage must be of type 'filesystem', 'git', 'sftp', or 'webdav'.
+	// Defaults to the first storage reference of the sync config.
+	// +optional
+	Source string `json:"source,omitempty"`
+	// Target specifies where the snapshot artifacts are stored.
+	Target *SnapshotTarget `json:"target"`
+}
+
+type SnapshotFormat string
+
+const (
+	SNAPSHOT_FORMAT_YAML  SnapshotFormat = "yaml"
+	SNAPSHOT_FORMAT_TARGZ SnapshotFormat = "tar.gz"
+)
+
+type SnapshotTarget struct {
+	// Name is the name of the storage definition the snapshots are stored in.
+	// The storage must be of type 'filesystem', 'git', 'sftp', or 'webdav'.
+	Name string `json:"name"`
+	// SubPath is the path from the storage's root element to the folder in which the snapshots are stored.
+	// It is evaluated as a go template, see SubPathTemplateData for the available values.
+	// If the target storage is the source storage, it must not point into the source's subPath.
+	// +optional
+	SubPath string `json:"subPath"`
+}
+
+// ShootReference references a Gardener shoot cluster.
+type ShootReference struct {
+	// Namespace is the namespace of the shoot in the garden cluster, which is the namespace of its project.
+	Namespace string `json:"namespace"`
+	// Name is the name of the shoot.
+	Name string `json:"name"`
+	// GardenKubeconfig is the path to the kubeconfig of the garden cluster which contains the shoot.
+	// It has the same format as the '--kubeconfig' flag.
+	// Defaults to the cluster specified via the '--kubeconfig' flag.
+	// +optional
+	GardenKubeconfig string `json:"gardenKubeconfig,omitempty"`
+	// Expiration is the validity of the requested admin kubeconfigs. A new kubeconfig is requested after 80% of it have passed.
+	// Must be at least 10m. Defaults to 1h.
+	// +optional
+	Expiration *metav1.Duration `json:"expiration,omitempty"`
+}
+
+type ImpersonationConfiguration struct {
+	// ServiceAccount is the service account which should be impersonated, in the format '<namespace>/<name>'.
+	// Exactly one of ServiceAccount and User must be set.
+	// +optional
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+	// User is the name of the user which should be impersonated.
+	// Exactly one of ServiceAccount and User must be set.
+	// +optional
+	User string `json:"user,omitempty"`
+	// Groups are the groups which should be impersonated.
+	// +optional
+	Groups []string `json:"groups,omitempty"`
 }
 
 type ResourceSyncConfig struct {
@@ -45,16 +624,88 @@ type ResourceSyncConfig struct {
 	// Kind is the kind of the resource to watch.
 	// Example: 'Deployment', 'Secret'
 	Kind string `json:"kind"`
+	// PersistVersion is the apiversion under which the resources are persisted.
+	// If set and different from Version, each resource is fetched again at this version before it is persisted,
+	// so that the API server converts it. This keeps the archive on a stable schema, even if the watched version changes.
+	// The group and kind are the same as for the watched resource.
+	// Defaults to Version.
+	// +optional
+	PersistVersion string `json:"persistVersion,omitempty"`
+	// FieldSelector restricts the synced resources to the ones matching the given field selector, e.g. 'spec.nodeName=node-1'.
+	// The selector is passed to the API server when listing and watching the resources, so only the supported fields of the kind can be used,
+	// e.g. 'metadata.name' and 'metadata.namespace' for all kinds.
+	// Sync configs which watch the same resource in the same cluster share a cache, so they must have the same field selector.
+	// +optional
+	FieldSelector string `json:"fieldSelector,omitempty"`
+	// Scope is the scope of the resource, either 'Cluster' or 'Namespaced'.
+	// If set, it is verified against the cluster on startup and used to detect conflicting sync configs more precisely:
+	// sync configs for a cluster-scoped resource conflict whenever their storage references overlap,
+	// while sync configs for a namespaced resource only conflict if they also watch overlapping namespaces.
+	// Namespace must not be set for cluster-scoped resources.
+	// All sync configs for the same resource must specify the same scope, if any.
+	// +optional
+	Scope ResourceScope `json:"scope,omitempty"`
 }
 
+type ResourceScope string
+
+const (
+	// RESOURCE_SCOPE_CLUSTER means that the resource is cluster-scoped.
+	RESOURCE_SCOPE_CLUSTER ResourceScope = "Cluster"
+	// RESOURCE_SCOPE_NAMESPACED means that the resource is namespaced.
+	RESOURCE_SCOPE_NAMESPACED ResourceScope = "Namespaced"
+)
+
 type StorageReference struct {
 	// Name is the name of the storage definition this reference refers to.
 	Name string `json:"name"`
 	// SubPath is the path from the storage option's root element to the folder which should be used as root directory for the stored resources.
+	// It is evaluated as a go template, see SubPathTemplateData for the available values.
 	// Leave empty for top-level.
 	SubPath string `json:"subPath"`
+	// FileNaming specifies how the name under which a resource is stored is derived from the resource.
+	// Supported values are
+	//   'name' - the resource's name is used
+	//   'uid' - the resource's UID is used
+	//   'nameAndUid' - the resource's name and UID are used, separated by '_'
+	// The UID-based namings keep the history of resources which are recreated with the same name apart
+	// and also work for resources which are created with 'generateName'.
+	// They require finalize to be enabled for the sync config, as the UID of a resource is not known anymore after it has been deleted.
+	// They are not supported for storage definitions with long names.
+	// Defaults to 'name'.
+	// +optional
+	FileNaming FileNaming `json:"fileNaming,omitempty"`
+	// RecheckInterval causes successfully synced resources to be reconciled again after the given duration.
+	// This verifies that the resource still exists in the storage with the expected content and restores it otherwise,
+	// which catches modifications and deletions in the storage which did not happen via K8Syncer.
+	// If multiple storage references of a sync config specify a recheck interval, the smallest one is used for all of them.
+	// Disabled if not set.
+	// +optional
+	RecheckInterval *metav1.Duration `json:"recheckInterval,omitempty"`
+	// MaxObjects is the maximum number of resources of the sync config which are persisted via this storage reference.
+	// Resources which would exceed it are not persisted and get the error phase instead.
+	// 0 means that the number is not limited.
+	// +optional
+	MaxObjects int `json:"maxObjects,omitempty"`
+	// MaxTotalBytes is the maximum total size in bytes of the resources of the sync config which are persisted via this storage reference,
+	// measured as the size of the transformed resources serialized to YAML.
+	// Resources which would exceed it are not persisted and get the error phase instead, resources which are persisted already can still shrink.
+	// 0 means that the size is not limited.
+	// +optional
+	MaxTotalBytes int64 `json:"maxTotalBytes,omitempty"`
 }
 
+type FileNaming string
+
+const (
+	// FILE_NAMING_NAME means that resources are stored under their name.
+	FILE_NAMING_NAME FileNaming = "name"
+	// FILE_NAMING_UID means that resources are stored under their UID.
+	FILE_NAMING_UID FileNaming = "uid"
+	// FILE_NAMING_NAME_AND_UID means that resources are stored under their name and UID.
+	FILE_NAMING_NAME_AND_UID FileNaming = "nameAndUid"
+)
+
 type StorageDefinition struct {
 	// Name is name for this storage option, used for referencing it.
 	// Must be unique.
@@ -77,6 +728,92 @@ type StorageDefinition struct {
 	// Must only be set when type is 'mock'.
 	// +optional
 	MockConfig *MockConfiguration `json:"mockConfig,omitempty"`
+	// WikiConfig contains the configuration for persisting data as wiki pages or snippets via the API of a git provider.
+	// Must be set when type is 'wiki'.
+	// A FileSystemConfig can be provided to configure the naming and serialization of the resources, as with the filesystem storage.
+	// The data is only staged in an in-memory filesystem, so its InMemory and RootPath fields are ignored.
+	// +optional
+	WikiConfig *WikiConfiguration `json:"wikiConfig,omitempty"`
+	// PluginConfig contains the configuration for persisting data via an external persister plugin.
+	// Must be set when type is 'plugin'.
+	// +optional
+	PluginConfig *PluginConfiguration `json:"pluginConfig,omitempty"`
+	// KubernetesConfig contains the configuration for persisting data in ConfigMaps or Secrets of a cluster.
+	// Must be set when type is 'kubernetes'.
+	// A FileSystemConfig can be provided to configure the naming and serialization of the resources, as with the filesystem storage.
+	// The data is only staged in an in-memory filesystem, so its InMemory and RootPath fields are ignored.
+	// +optional
+	KubernetesConfig *KubernetesConfiguration `json:"kubernetesConfig,omitempty"`
+	// ClusterConfig contains the configuration for replicating resources into a cluster.
+	// Must be set when type is 'cluster'.
+	// +optional
+	ClusterConfig *ClusterStorageConfiguration `json:"clusterConfig,omitempty"`
+	// KVConfig contains the configuration for persisting data in a key-value store.
+	// Must be set when type is 'kv'.
+	// A FileSystemConfig can be provided to configure the serialization of the resources, as with the filesystem storage.
+	// The data is only staged in an in-memory filesystem, so its InMemory and RootPath fields are ignored.
+	// +optional
+	KVConfig *KVConfiguration `json:"kvConfig,omitempty"`
+	// SFTPConfig contains the configuration for persisting data to a directory on an SFTP server.
+	// Must be set when type is 'sftp'.
+	// A FileSystemConfig can be provided to configure the layout, naming, and serialization of the resources, as with the filesystem storage.
+	// The directory is configured in the SFTPConfig, so the InMemory, RootPath, and IO fields of the FileSystemConfig must not be set.
+	// +optional
+	SFTPConfig *SFTPConfiguration `json:"sftpConfig,omitempty"`
+	// WebDAVConfig contains the configuration for persisting data to a collection on a WebDAV server.
+	// Must be set when type is 'webdav'.
+	// A FileSystemConfig can be provided to configure the layout, naming, and serialization of the resources, as with the filesystem storage.
+	// The collection is configured in the WebDAVConfig, so the InMemory, RootPath, and IO fields of the FileSystemConfig must not be set.
+	// +optional
+	WebDAVConfig *WebDAVConfiguration `json:"webdavConfig,omitempty"`
+	// Cache configures a read-through cache in front of the storage, which remembers the digests of the persisted resources.
+	// Persisting a resource which has not changed since it has been persisted last is then answered from the cache, without reading from the storage.
+	// +optional
+	Cache *StorageCacheConfiguration `json:"cache,omitempty"`
+	// OwnershipConflictPolicy specifies what happens if multiple sync configs write the same resource to this storage,
+	// e.g. because they watch overlapping resources and reference the storage with the same subPath.
+	// K8Syncer remembers which sync config has written a resource first and detects writes and deletions by other sync configs.
+	// Supported values are
+	//   'reject' - the conflicting write or deletion fails
+	//   'warn' - the conflict is logged, but the write or deletion is done nevertheless
+	// Conflicts are counted in the 'k8syncer_storage_ownership_conflicts_total' metric in both cases.
+	// Defaults to 'reject'.
+	// +optional
+	OwnershipConflictPolicy OwnershipConflictPolicy `json:"ownershipConflictPolicy,omitempty"`
+	// CircuitBreaker marks the storage as unavailable after a number of consecutive failed operations.
+	// While it is unavailable, operations fail immediately without accessing the storage, until a probe in the background succeeds.
+	// +optional
+	CircuitBreaker *StorageCircuitBreakerConfiguration `json:"circuitBreaker,omitempty"`
+}
+
+type OwnershipConflictPolicy string
+
+const (
+	// OWNERSHIP_CONFLICT_POLICY_REJECT means that writes and deletions of resources which are owned by another sync config fail.
+	OWNERSHIP_CONFLICT_POLICY_REJECT OwnershipConflictPolicy = "reject"
+	// OWNERSHIP_CONFLICT_POLICY_WARN means that writes and deletions of resources which are owned by another sync config are only logged.
+	OWNERSHIP_CONFLICT_POLICY_WARN OwnershipConflictPolicy = "warn"
+)
+
+// StorageCacheConfiguration configures the read-through cache of a storage.
+// For git storages, the cache is flushed after each pull, so it is most effective for exclusive repositories or in combination with background pulls or webhooks.
+type StorageCacheConfiguration struct {
+	// MaxEntries is the maximum number of resources for which the cache holds information.
+	// If it is exceeded, the cache is flushed. 0 means unlimited.
+	// +optional
+	MaxEntries int `json:"maxEntries,omitempty"`
+}
+
+// StorageCircuitBreakerConfiguration configures the circuit breaker of a storage.
+type StorageCircuitBreakerConfiguration struct {
+	// FailureThreshold is the number of consecutive failed operations after which the storage is marked as unavailable.
+	// Defaults to 5.
+	// +optional
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+	// ProbeInterval is the interval in which an unavailable storage is probed in the background.
+	// Defaults to 30s.
+	// +optional
+	ProbeInterval *metav1.Duration `json:"probeInterval,omitempty"`
 }
 
 type StorageDefinitionType string
@@ -88,6 +825,300 @@ const (
 	STORAGE_TYPE_FILESYSTEM StorageDefinitionType = "filesystem"
 	// STORAGE_TYPE_MOCK is for testing purposes
 	STORAGE_TYPE_MOCK StorageDefinitionType = "mock"
+	// STORAGE_TYPE_WIKI is the storage type for wiki pages or snippets of a git provider.
+	STORAGE_TYPE_WIKI StorageDefinitionType = "wiki"
+	// STORAGE_TYPE_PLUGIN is the storage type for external persisters which implement the plugin protocol.
+	STORAGE_TYPE_PLUGIN StorageDefinitionType = "plugin"
+	// STORAGE_TYPE_KUBERNETES is the storage type for ConfigMaps or Secrets in a cluster.
+	STORAGE_TYPE_KUBERNETES StorageDefinitionType = "kubernetes"
+	// STORAGE_TYPE_CLUSTER is the storage type for replicating resources into a cluster.
+	STORAGE_TYPE_CLUSTER StorageDefinitionType = "cluster"
+	// STORAGE_TYPE_KV is the storage type for key-value stores.
+	STORAGE_TYPE_KV StorageDefinitionType = "kv"
+	// STORAGE_TYPE_SFTP is the storage type for a directory on an SFTP server.
+	STORAGE_TYPE_SFTP StorageDefinitionType = "sftp"
+	// STORAGE_TYPE_WEBDAV is the storage type for a collection on a WebDAV server.
+	STORAGE_TYPE_WEBDAV StorageDefinitionType = "webdav"
+)
+
+// KVConfiguration configures storing resources in a key-value store.
+// Each resource is stored under the key '<keyPrefix><subPath>/<gvk>/<namespace>/<name>', with '_cluster' as namespace for cluster-scoped resources.
+// This is meant for consumers which need low-latency programmatic access to the resources.
+type KVConfiguration struct {
+	// Backend is the type of the key-value store.
+	// Valid values are 'etcd' and 'redis'.
+	Backend KVBackend `json:"backend"`
+	// Endpoint is the address of the key-value store.
+	// For etcd, this is the URL of the v3 API, e.g. 'https://etcd.example.com:2379'.
+	// For redis, this is a URL of the format 'redis://<host>:<port>[/<db>]', or 'rediss://...' for TLS.
+	Endpoint string `json:"endpoint"`
+	// KeyPrefix is prepended to all keys, e.g. 'k8syncer/'.
+	// +optional
+	KeyPrefix string `json:"keyPrefix,omitempty"`
+	// TTL is the time after which persisted resources expire, unless they are persisted again.
+	// Persisting an unchanged resource refreshes its TTL, so that only the data of resources which have not been synced for this duration expires.
+	// If not set, the data doesn't expire.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+	// Username is the name of the user which is used for authentication.
+	// +optional
+	Username string `json:"username,omitempty"`
+	// Password is the password which is used for authentication.
+	// Only one of Password and PasswordFile may be set.
+	// +optional
+	Password string `json:"password,omitempty"`
+	// PasswordFile is the path to a file containing the password which is used for authentication.
+	// It is read whenever K8Syncer authenticates, so that a mounted secret can be rotated.
+	// Only one of Password and PasswordFile may be set.
+	// +optional
+	PasswordFile string `json:"passwordFile,omitempty"`
+	// Timeout is the timeout for a single request to the key-value store.
+	// Defaults to 10s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+type KVBackend string
+
+const (
+	// KV_BACKEND_ETCD stores the resources in etcd, using its v3 API.
+	KV_BACKEND_ETCD KVBackend = "etcd"
+	// KV_BACKEND_REDIS stores the resources in redis.
+	KV_BACKEND_REDIS KVBackend = "redis"
+)
+
+// SFTPConfiguration configures storing resources in a directory on an SFTP server.
+// The resources are stored like in a filesystem storage whose root path is the configured directory.
+type SFTPConfiguration struct {
+	// Host is the address of the SSH server, in the format '<host>[:<port>]'.
+	// The port defaults to 22.
+	Host string `json:"host"`
+	// Path is the directory on the server in which the resources are stored.
+	// Relative paths are resolved against the login directory of the user. The directory must exist.
+	// Defaults to the login directory.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Username is the name of the user which is used for authentication.
+	Username string `json:"username"`
+	// Password is the password which is used for authentication.
+	// Only one of Password and PasswordFile may be set.
+	// +optional
+	Password string `json:"password,omitempty"`
+	// PasswordFile is the path to a file containing the password which is used for authentication.
+	// It is read whenever K8Syncer connects, so that a mounted secret can be rotated.
+	// Only one of Password and PasswordFile may be set.
+	// +optional
+	PasswordFile string `json:"passwordFile,omitempty"`
+	// PrivateKey is an unencrypted private key which is used for authentication.
+	// Only one of PrivateKey and PrivateKeyFile may be set.
+	// At least one of them or of Password and PasswordFile must be set.
+	// +optional
+	PrivateKey string `json:"privateKey,omitempty"`
+	// PrivateKeyFile is the path to a file containing an unencrypted private key which is used for authentication.
+	// Only one of PrivateKey and PrivateKeyFile may be set.
+	// +optional
+	PrivateKeyFile string `json:"privateKeyFile,omitempty"`
+	// KnownHostsFile is the path to a file in the known_hosts format of OpenSSH, which contains the host key of the server.
+	// Exactly one of KnownHostsFile and HostKey must be set.
+	// +optional
+	KnownHostsFile string `json:"knownHostsFile,omitempty"`
+	// HostKey is the public host key of the server, in the authorized_keys format of OpenSSH, e.g. 'ssh-ed25519 AAAA...'.
+	// Exactly one of KnownHostsFile and HostKey must be set.
+	// +optional
+	HostKey string `json:"hostKey,omitempty"`
+	// Timeout is the timeout for establishing the connection and for a single request to the server.
+	// Defaults to 30s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// WebDAVConfiguration configures storing resources in a collection on a WebDAV server.
+// The resources are stored like in a filesystem storage whose root path is the configured collection.
+type WebDAVConfiguration struct {
+	// URL is the URL of the WebDAV endpoint, e.g. 'https://files.example.com/remote.php/dav/files/k8syncer'.
+	URL string `json:"url"`
+	// Path is the collection below the URL in which the resources are stored. The collection must exist.
+	// Defaults to the collection of the URL itself.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Username is the name of the user which is used for basic authentication.
+	// +optional
+	Username string `json:"username,omitempty"`
+	// Password is the password which is used for basic authentication.
+	// Only one of Password and PasswordFile may be set.
+	// +optional
+	Password string `json:"password,omitempty"`
+	// PasswordFile is the path to a file containing the password which is used for basic authentication.
+	// It is read for every request, so that a mounted secret can be rotated.
+	// Only one of Password and PasswordFile may be set.
+	// +optional
+	PasswordFile string `json:"passwordFile,omitempty"`
+	// Timeout is the timeout for a single request to the server.
+	// Defaults to 30s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// ClusterStorageConfiguration configures replicating resources into a cluster.
+// The transformed resources are applied to the cluster via server-side apply, under the same name.
+type ClusterStorageConfiguration struct {
+	// Kubeconfig is the path to the kubeconfig of the cluster into which the resources are replicated.
+	// See the '--kubeconfig' flag for the supported formats.
+	// Defaults to the cluster specified via the '--kubeconfig' flag.
+	// +optional
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	// NamespaceMapping maps namespaces of the synced resources to the namespaces into which they are replicated.
+	// Resources in namespaces which are not mapped are replicated into the namespace with the same name.
+	// +optional
+	NamespaceMapping map[string]string `json:"namespaceMapping,omitempty"`
+	// FieldManager is the field manager which is used for applying the resources.
+	// Defaults to 'k8syncer'.
+	// +optional
+	FieldManager string `json:"fieldManager,omitempty"`
+	// ForceConflicts takes over the ownership of fields which are managed by other field managers in the target cluster.
+	// If false, conflicting changes in the target cluster cause the sync of the affected resource to fail.
+	// +optional
+	ForceConflicts bool `json:"forceConflicts,omitempty"`
+}
+
+// KubernetesConfiguration configures storing resources in ConfigMaps or Secrets of a cluster.
+// This is meant for mirroring small sets of resources, e.g. configuration, into another cluster, as each object is limited to 1MiB.
+type KubernetesConfiguration struct {
+	// Kubeconfig is the path to the kubeconfig of the cluster in which the objects are stored.
+	// See the '--kubeconfig' flag for the supported formats.
+	// Defaults to the cluster specified via the '--kubeconfig' flag.
+	// +optional
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	// Namespace is the namespace in which the objects are stored.
+	Namespace string `json:"namespace"`
+	// ObjectType is the type of the objects in which the resources are stored.
+	// Valid values are 'configMap' and 'secret'.
+	// Defaults to 'configMap'.
+	// +optional
+	ObjectType KubernetesObjectType `json:"objectType,omitempty"`
+	// Mode specifies how the resources are distributed across the objects.
+	// Valid values are:
+	//   'objectPerResource' for storing each resource in its own object
+	//   'keyPerResource' for storing the resources which would be in the same directory of a filesystem storage in one object, with one key per resource
+	// Defaults to 'objectPerResource'.
+	// +optional
+	Mode KubernetesStorageMode `json:"mode,omitempty"`
+	// NamePrefix is prepended to the names of all objects.
+	// The name of an object is otherwise derived from the path of the resource's file or directory in a filesystem storage.
+	// Defaults to 'k8syncer-'.
+	// +optional
+	NamePrefix string `json:"namePrefix,omitempty"`
+}
+
+type KubernetesObjectType string
+
+const (
+	// KUBERNETES_OBJECT_TYPE_CONFIGMAP stores the resources in ConfigMaps.
+	KUBERNETES_OBJECT_TYPE_CONFIGMAP KubernetesObjectType = "configMap"
+	// KUBERNETES_OBJECT_TYPE_SECRET stores the resources in Secrets.
+	KUBERNETES_OBJECT_TYPE_SECRET KubernetesObjectType = "secret"
+)
+
+type KubernetesStorageMode string
+
+const (
+	// KUBERNETES_MODE_OBJECT_PER_RESOURCE stores each resource in its own object.
+	KUBERNETES_MODE_OBJECT_PER_RESOURCE KubernetesStorageMode = "objectPerResource"
+	// KUBERNETES_MODE_KEY_PER_RESOURCE stores the resources of a directory in one object, with one key per resource.
+	KUBERNETES_MODE_KEY_PER_RESOURCE KubernetesStorageMode = "keyPerResource"
+)
+
+// PluginConfiguration configures an external persister plugin.
+// A plugin is a gRPC server which implements the service defined in pkg/persist/plugin/persister.proto.
+// The connection is not encrypted, so the plugin should be reachable via a unix socket or localhost only, e.g. as a sidecar container.
+type PluginConfiguration struct {
+	// Address is the address of the plugin's gRPC server.
+	// It is either 'unix://<path>' for a unix socket, with an absolute path, or '<host>:<port>' for a TCP connection.
+	Address string `json:"address"`
+	// Timeout is the timeout for a single call to the plugin.
+	// Defaults to 30s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// TLS configures TLS for the connection to the plugin.
+	// It can only be set for TCP addresses. If not set, the connection is not encrypted.
+	// +optional
+	TLS *PluginTLSConfiguration `json:"tls,omitempty"`
+}
+
+// PluginTLSConfiguration configures TLS for the connection to a plugin.
+type PluginTLSConfiguration struct {
+	// CAFile is the path to a file containing the PEM encoded CA certificates which are used to verify the serving certificate of the plugin.
+	// If not set, the system's root CAs are used.
+	// +optional
+	CAFile string `json:"caFile,omitempty"`
+	// CertFile and KeyFile are the paths to files containing the PEM encoded client certificate and its key,
+	// which K8Syncer uses to authenticate against the plugin.
+	// Either both or none of them must be set.
+	// +optional
+	CertFile string `json:"certFile,omitempty"`
+	// +optional
+	KeyFile string `json:"keyFile,omitempty"`
+	// ServerName is the name which is expected in the serving certificate of the plugin.
+	// Defaults to the host of the address.
+	// +optional
+	ServerName string `json:"serverName,omitempty"`
+}
+
+// WikiConfiguration configures storing resources as wiki pages or snippets.
+// The pages are written via the API of the git provider, one page per resource, without pushing to a repository.
+// This is meant for human-browsable archives of small sets of resources, as each operation results in at least one API call.
+type WikiConfiguration struct {
+	// Provider is the git provider which hosts the wiki or snippets.
+	// Valid values are 'gitea' and 'gitlab'.
+	Provider WikiProvider `json:"provider"`
+	// Mode specifies where the resources are stored.
+	// Valid values are:
+	//   'wiki' for storing each resource as a page of the project's wiki
+	//   'snippets' for storing each resource as a project snippet, which is only supported by GitLab
+	// Defaults to 'wiki'.
+	// +optional
+	Mode WikiMode `json:"mode,omitempty"`
+	// URL is the base URL of the provider instance, e.g. 'https://gitlab.example.com'.
+	URL string `json:"url"`
+	// Project identifies the project which contains the wiki or snippets.
+	// For Gitea, this is '<owner>/<repository>'. For GitLab, this is either the numeric ID or the full path of the project.
+	Project string `json:"project"`
+	// Token is the access token which is used to authenticate against the API.
+	// Only one of Token and TokenFile must be set.
+	// +optional
+	Token string `json:"token,omitempty"`
+	// TokenFile is a path to a file containing the access token.
+	// The file is read for each request, so that the token can be rotated without a restart.
+	// Only one of Token and TokenFile must be set.
+	// +optional
+	TokenFile string `json:"tokenFile,omitempty"`
+	// TitlePrefix is prepended to the titles of all pages or snippets, e.g. 'k8syncer/'.
+	// The title of a resource's page is otherwise the path of its file in a filesystem storage, without the file extension.
+	// +optional
+	TitlePrefix string `json:"titlePrefix,omitempty"`
+	// SnippetVisibility is the visibility of created snippets.
+	// Valid values are 'private', 'internal', and 'public'. Only evaluated for mode 'snippets'.
+	// Defaults to 'private'.
+	// +optional
+	SnippetVisibility string `json:"snippetVisibility,omitempty"`
+}
+
+type WikiProvider string
+
+const (
+	// WIKI_PROVIDER_GITEA is the provider for Gitea (and Forgejo) instances.
+	WIKI_PROVIDER_GITEA WikiProvider = "gitea"
+	// WIKI_PROVIDER_GITLAB is the provider for GitLab instances.
+	WIKI_PROVIDER_GITLAB WikiProvider = "gitlab"
+)
+
+type WikiMode string
+
+const (
+	// WIKI_MODE_WIKI stores the resources as wiki pages.
+	WIKI_MODE_WIKI WikiMode = "wiki"
+	// WIKI_MODE_SNIPPETS stores the resources as project snippets.
+	WIKI_MODE_SNIPPETS WikiMode = "snippets"
 )
 
 // GitConfiguration defines a git repository
@@ -98,6 +1129,12 @@ type GitConfiguration struct {
 	// Defaults to 'master'.
 	// +optional
 	Branch string `json:"branch"`
+	// RemoteName is the name of the git remote which refers to the repository.
+	// If the local repository already exists, e.g. because it has been cloned by an init container,
+	// the remote is created or its URL is updated, if required.
+	// Defaults to 'origin'.
+	// +optional
+	RemoteName string `json:"remoteName,omitempty"`
 	// Exclusive specifies whether the provided repository is exclusively pushed to by the created GitPersister.
 	// If true, the code assumes to be the only source of changes and never pulls from the repo,
 	// except for when initializing and if an error during push occurs.
@@ -111,6 +1148,251 @@ type GitConfiguration struct {
 	// This can be used for setups where there are always two active keys that are rotated by invalidating the primary one and promoting the secondary one to primary.
 	// +optional
 	SecondaryAuth *GitRepoAuth `json:"secondaryAuth,omitempty"`
+	// Webhook configures an endpoint which receives push events from the git provider.
+	// If set, the repository is only pulled when a push event for the configured branch has been received,
+	// instead of before every operation.
+	// Must not be set if Exclusive is true.
+	// +optional
+	Webhook *GitWebhookConfiguration `json:"webhook,omitempty"`
+	// BackgroundPull configures pulling the repository periodically in the background, instead of before every operation.
+	// Operations only pull themselves if the last successful pull is older than the freshness window.
+	// Must not be set if Exclusive or Webhook is set.
+	// +optional
+	BackgroundPull *GitBackgroundPullConfiguration `json:"backgroundPull,omitempty"`
+	// PullMinInterval is the minimum time between two pulls of a branch which are triggered by operations.
+	// Operations don't pull if the last successful pull of the branch is more recent, so that the consecutive operations
+	// of a single reconcile, e.g. checking the existence of a resource, reading it, and persisting it, pull only once.
+	// Must be positive. Must not be set if Exclusive, Webhook, or BackgroundPull is set.
+	// If not set, every operation pulls.
+	// +optional
+	PullMinInterval *metav1.Duration `json:"pullMinInterval,omitempty"`
+	// NamespaceBranches configures storing the resources of each namespace in a separate branch instead of a namespace directory on the configured branch.
+	// The namespace branches are created on demand. Cluster-scoped resources are still stored on the configured branch.
+	// Must not be set if Webhook is set or for the 'argocd' layout.
+	// +optional
+	NamespaceBranches *GitNamespaceBranchesConfiguration `json:"namespaceBranches,omitempty"`
+	// CommitChunkSize is the maximum number of changed files per commit.
+	// If a single operation changes more files, e.g. when pruning a namespace with many resources, the changes are split
+	// into multiple commits, which are pushed one after another. This keeps the memory usage and the size of each push limited.
+	// 0 means that all changes are committed at once.
+	// +optional
+	CommitChunkSize int `json:"commitChunkSize,omitempty"`
+	// Provenance configures adding a provenance document to each commit, which describes the origin of the committed changes.
+	// +optional
+	Provenance *GitProvenanceConfiguration `json:"provenance,omitempty"`
+	// Index configures maintaining an index document in the root of the repository, which describes its contents for humans browsing the repository.
+	// +optional
+	Index *GitIndexConfiguration `json:"index,omitempty"`
+	// GitBackend is the implementation which is used to interact with the repository.
+	// Valid values are:
+	//   'go-git' for the built-in git implementation
+	//   'cli' for the git binary, which has to be available in the PATH
+	// The 'cli' backend supports features which go-git lacks, e.g. git LFS (if git-lfs is installed), and performs better on huge repositories.
+	// It requires a local filesystem, SSH authentication is only supported via an unencrypted privateKeyFile.
+	// Defaults to 'go-git'.
+	// +optional
+	GitBackend GitBackend `json:"gitBackend,omitempty"`
+	// ConflictPolicy specifies how conflicts are handled, which occur if a file has been modified both locally and on the remote
+	// when the local changes are re-applied on top of the remote branch before pushing.
+	// Valid values are:
+	//   'preferCluster' to overwrite the remote changes with the state from the cluster
+	//   'preferRemote' to keep the remote changes and discard the local changes to the file
+	//   'failAndAlert' to keep the remote changes and fail syncing the resource until the conflict has been resolved
+	// Defaults to 'preferCluster'.
+	// +optional
+	ConflictPolicy GitConflictPolicy `json:"conflictPolicy,omitempty"`
+	// CommitTimestamps configures the timestamps of the commits, e.g. to make them reproducible or to record them in UTC.
+	// +optional
+	CommitTimestamps *GitCommitTimestampsConfiguration `json:"commitTimestamps,omitempty"`
+	// CommitGroups configures splitting changes which are committed at once, e.g. when pruning a namespace or when retrying previously failed commits,
+	// into one commit per group, so that changes which belong to different teams or namespaces can be told apart in the history.
+	// +optional
+	CommitGroups *GitCommitGroupsConfiguration `json:"commitGroups,omitempty"`
+	// Bootstrap specifies whether the repository and the branch are created via the API of the git provider if they don't exist,
+	// instead of failing on the first push to a nonexistent repository. This happens once, when the storage is initialized.
+	// Requires Provider to be set.
+	// +optional
+	Bootstrap bool `json:"bootstrap,omitempty"`
+	// Provider configures access to the REST API of the git provider which hosts the repository.
+	// +optional
+	Provider *GitProviderConfiguration `json:"provider,omitempty"`
+}
+
+type GitProviderConfiguration struct {
+	// Type is the git provider which hosts the repository.
+	// Valid values are 'github', 'gitlab', and 'gitea'.
+	Type GitProviderType `json:"type"`
+	// URL is the base URL of the provider instance, e.g. 'https://gitlab.example.com'.
+	// For GitHub, 'https://github.com' refers to the public API, other URLs are treated as GitHub Enterprise Server instances.
+	// Defaults to 'https://' followed by the host of the repository URL.
+	// +optional
+	URL string `json:"url,omitempty"`
+	// Repository identifies the repository at the provider.
+	// For GitHub and Gitea, this is '<owner>/<repository>'. For GitLab, this is the full path of the project.
+	// Defaults to the path of the repository URL without the '.git' suffix.
+	// +optional
+	Repository string `json:"repository,omitempty"`
+	// Token is the access token which is used to authenticate against the API.
+	// Only one of Token and TokenFile must be set.
+	// +optional
+	Token string `json:"token,omitempty"`
+	// TokenFile is a path to a file containing the access token.
+	// Only one of Token and TokenFile must be set.
+	// +optional
+	TokenFile string `json:"tokenFile,omitempty"`
+	// Private specifies whether a repository which is created during bootstrap is private.
+	// Defaults to true.
+	// +optional
+	Private *bool `json:"private,omitempty"`
+	// ProtectBranch specifies whether a branch which is created during bootstrap is protected against force pushes and deletion.
+	// +optional
+	ProtectBranch bool `json:"protectBranch,omitempty"`
+}
+
+type GitProviderType string
+
+const (
+	GIT_PROVIDER_GITHUB GitProviderType = "github"
+	GIT_PROVIDER_GITLAB GitProviderType = "gitlab"
+	// GIT_PROVIDER_GITEA is the provider for Gitea (and Forgejo) instances.
+	GIT_PROVIDER_GITEA GitProviderType = "gitea"
+)
+
+type GitCommitGroupsConfiguration struct {
+	// Template determines the group of each changed file.
+	// It is evaluated as a go template, see CommitGroupTemplateData for the available values, e.g. '{{ .Namespace }}' or '{{ index .Labels "team" }}'.
+	// Leading and trailing whitespace is removed from the result. The name of the group is appended to the commit message, if it isn't empty.
+	Template string `json:"template"`
+}
+
+// GitCommitTimestampsConfiguration configures the author and committer timestamps of the commits created by K8Syncer.
+type GitCommitTimestampsConfiguration struct {
+	// TimeZone is the IANA name of the time zone in which the timestamps are recorded, e.g. 'UTC' or 'Europe/Berlin'.
+	// Defaults to the local time zone of K8Syncer.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+	// Truncate specifies the precision of the timestamps, they are rounded down to a multiple of it, e.g. '1m' for full minutes.
+	// Must be at least one second, which is the precision of git timestamps anyway.
+	// +optional
+	Truncate *metav1.Duration `json:"truncate,omitempty"`
+}
+
+type GitConflictPolicy string
+
+const (
+	// GIT_CONFLICT_POLICY_PREFER_CLUSTER resolves conflicts by overwriting the remote changes with the state from the cluster.
+	GIT_CONFLICT_POLICY_PREFER_CLUSTER GitConflictPolicy = "preferCluster"
+	// GIT_CONFLICT_POLICY_PREFER_REMOTE resolves conflicts by keeping the remote changes.
+	GIT_CONFLICT_POLICY_PREFER_REMOTE GitConflictPolicy = "preferRemote"
+	// GIT_CONFLICT_POLICY_FAIL_AND_ALERT keeps the remote changes and fails syncing the affected resources until the conflict has been resolved.
+	GIT_CONFLICT_POLICY_FAIL_AND_ALERT GitConflictPolicy = "failAndAlert"
+)
+
+type GitBackend string
+
+const (
+	// GIT_BACKEND_GO_GIT is the git backend which uses go-git.
+	GIT_BACKEND_GO_GIT GitBackend = "go-git"
+	// GIT_BACKEND_CLI is the git backend which shells out to the git binary.
+	GIT_BACKEND_CLI GitBackend = "cli"
+)
+
+// GitProvenanceConfiguration configures the provenance document which is added to each commit.
+// The document is stored in the file '.k8syncer-provenance.yaml' in the root of the repository. It contains the K8Syncer version,
+// the name of the source cluster, and the digests of all files which are changed by the commit.
+// If a signing key is configured, the document is signed with it, otherwise it is unsigned.
+// At most one of SigningKey and SigningKeyFile must be set.
+type GitProvenanceConfiguration struct {
+	// SigningKey is a PEM-encoded ed25519 private key in PKCS #8 format, which is used to sign the provenance document.
+	// +optional
+	SigningKey string `json:"signingKey,omitempty"`
+	// SigningKeyFile is a path to a file containing the signing key.
+	// +optional
+	SigningKeyFile string `json:"signingKeyFile,omitempty"`
+}
+
+// GitIndexConfiguration configures the index document which is maintained in the root of the repository.
+// The document is a markdown file, which lists the source cluster, the sync configs writing into the storage together with the paths of their files,
+// and the time at which resources of each kind have been written last. It is updated with every commit.
+// An existing file with the same name is overwritten.
+type GitIndexConfiguration struct {
+	// FileName is the name of the index document in the root of the repository.
+	// Defaults to 'README.md'.
+	// +optional
+	FileName string `json:"fileName,omitempty"`
+}
+
+// GitNamespaceBranchesConfiguration configures the branches which are used for the resources of a namespace.
+// Within a namespace branch, the resources are stored in the same directory structure as on the configured branch.
+// If namespace pruning is configured with mode 'delete', the branch of a deleted namespace is removed from the repository
+// once it doesn't contain any data anymore.
+type GitNamespaceBranchesConfiguration struct {
+	// Prefix is prepended to the namespace name to get the name of the namespace branch.
+	// Defaults to 'namespaces/'.
+	// Example: namespace 'foo' => branch 'namespaces/foo'
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// Namespaces maps namespaces to the credentials which are used for their namespace branch, instead of the storage's auth.
+	// This allows pushing the resources of each tenant with the tenant's own credentials, e.g. a deploy key which only grants access to the tenant's branches.
+	// +optional
+	Namespaces []*GitNamespaceAuthMapping `json:"namespaces,omitempty"`
+	// AuthSecret configures reading the credentials for the branch of each namespace from a Secret in the namespace itself.
+	// It applies to all namespaces which are not listed in Namespaces. If the Secret doesn't exist, the resources of the namespace
+	// cannot be synced, they are never pushed with the storage's auth.
+	// +optional
+	AuthSecret *GitNamespaceAuthSecretConfiguration `json:"authSecret,omitempty"`
+}
+
+// GitNamespaceAuthMapping maps a namespace to the credentials which are used for its namespace branch.
+type GitNamespaceAuthMapping struct {
+	// Namespace is the name of the namespace.
+	Namespace string `json:"namespace"`
+	// Auth contains the auth information needed to push commits to the branch of the namespace.
+	// The storage's secondaryAuth is not used for the branch.
+	Auth *GitRepoAuth `json:"auth"`
+}
+
+// GitNamespaceAuthSecretConfiguration configures reading the credentials of namespace branches from a Secret per namespace.
+// The Secret is read from the cluster specified via the '--kubeconfig' flag.
+// It is expected to contain the keys which correspond to the fields of the auth configuration ('username', 'password', 'privateKey'),
+// depending on the authentication type.
+type GitNamespaceAuthSecretConfiguration struct {
+	// Name is the name of the Secret in each namespace.
+	Name string `json:"name"`
+	// Type is the method used for authentication, see GitRepoAuth.
+	// Defaults to the type of the storage's auth.
+	// +optional
+	Type GitAuthenticationType `json:"type,omitempty"`
+	// RefreshInterval is the interval after which the Secret is read again, so that rotated credentials are picked up.
+	// Defaults to 5 minutes.
+	// +optional
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+}
+
+// GitBackgroundPullConfiguration configures pulling a repository periodically in the background.
+type GitBackgroundPullConfiguration struct {
+	// Interval is the interval in which the repository is pulled.
+	// Defaults to 1 minute.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// FreshnessWindow is the maximum age of the last successful pull for which operations use the local checkout without pulling.
+	// Must not be shorter than the interval. Defaults to twice the interval.
+	// +optional
+	FreshnessWindow *metav1.Duration `json:"freshnessWindow,omitempty"`
+}
+
+// GitWebhookConfiguration configures the verification of incoming push events.
+// GitHub and GitLab push events are supported.
+// For GitHub, the secret is used to verify the signature in the 'X-Hub-Signature-256' header,
+// for GitLab it is compared to the 'X-Gitlab-Token' header.
+// Exactly one of Secret and SecretFile must be set.
+type GitWebhookConfiguration struct {
+	// Secret is the secret token configured for the webhook at the git provider.
+	// +optional
+	Secret string `json:"secret,omitempty"`
+	// SecretFile is a path to a file containing the secret token.
+	// +optional
+	SecretFile string `json:"secretFile,omitempty"`
 }
 
 // GitRepoAuth represents different possibilities to authenticate against a git repository
@@ -122,6 +1404,10 @@ type GitConfiguration struct {
 //	Auth via SSH
 //	  either 'privateKey' or 'privateKeyFile' has to be set
 //	  'password' has to be set if the specified private key contains an encrypted PEM block
+//	Password from a secret manager
+//	  'passwordFrom' can be set instead of 'password' for any of the above
+//	Credentials from Vault
+//	  'vault' has to be set, the credentials fields must not be set
 type GitRepoAuth struct {
 	// Type is the method used for authentication.
 	// Valid values are:
@@ -134,9 +1420,15 @@ type GitRepoAuth struct {
 	// +optional
 	Username string `json:"username"`
 	// Password is either the password for username/password or the access token.
-	// It is required for both cases and optional for authentication via SSH.
+	// It is required for both cases and optional for authentication via SSH, where it is used as passphrase for the private key.
+	// Instead of specifying it inline, it can be fetched from a secret manager via PasswordFrom.
 	// +optional
 	Password string `json:"password"`
+	// PasswordFrom configures fetching the password from an external secret manager at runtime.
+	// The value is fetched again after the refresh interval, so that a rotated password is picked up without a restart.
+	// Must not be set together with Password or Vault.
+	// +optional
+	PasswordFrom *SecretValueSource `json:"passwordFrom,omitempty"`
 	// PrivateKey is the private key for authentication via SSH.
 	// This field is for providing the key inline, for a file path use PrivateKeyFile instead.
 	// Only one of PrivateKey and PrivateKeyFile must be set for authentication via SSH and none must be set for other auth methods.
@@ -147,6 +1439,105 @@ type GitRepoAuth struct {
 	// Only one of PrivateKey and PrivateKeyFile must be set for authentication via SSH and none must be set for other auth methods.
 	// +optional
 	PrivateKeyFile string `json:"privateKeyFile"`
+	// Vault configures fetching the credentials from HashiCorp Vault at runtime, instead of specifying them in the configuration.
+	// The secret in Vault is expected to contain the keys which correspond to the fields above ('username', 'password', 'privateKey'),
+	// depending on the authentication type.
+	// If set, none of the credentials fields above must be set.
+	// +optional
+	Vault *VaultConfiguration `json:"vault,omitempty"`
+}
+
+// VaultConfiguration specifies how to fetch credentials from HashiCorp Vault.
+// Authentication against Vault happens via the Kubernetes auth method, the credentials are read from a KV secrets engine.
+type VaultConfiguration struct {
+	// Address is the address of the Vault server.
+	// Example: 'https://vault.example.com:8200'
+	Address string `json:"address"`
+	// Role is the Vault role which is used to log in via the Kubernetes auth method.
+	Role string `json:"role"`
+	// Namespace is the Vault Enterprise namespace in which the auth method and the secrets engine are mounted.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// CACert is the PEM-encoded CA certificate bundle which is used to verify the certificate of the Vault server.
+	// Defaults to the system's trusted CAs.
+	// +optional
+	CACert string `json:"caCert,omitempty"`
+	// CACertFile is the path to a file containing the PEM-encoded CA certificate bundle, see CACert.
+	// Must not be set together with CACert.
+	// +optional
+	CACertFile string `json:"caCertFile,omitempty"`
+	// AuthMountPath is the path at which the Kubernetes auth method is mounted.
+	// Defaults to 'kubernetes'.
+	// +optional
+	AuthMountPath string `json:"authMountPath,omitempty"`
+	// ServiceAccountTokenFile is the path to the file containing the service account token which is used to log in.
+	// Defaults to '/var/run/secrets/kubernetes.io/serviceaccount/token'.
+	// +optional
+	ServiceAccountTokenFile string `json:"serviceAccountTokenFile,omitempty"`
+	// Engine is the path at which the KV secrets engine is mounted.
+	// Defaults to 'secret'.
+	// +optional
+	Engine string `json:"engine,omitempty"`
+	// EngineVersion is the version of the KV secrets engine, either 1 or 2.
+	// Defaults to 2.
+	// +optional
+	EngineVersion int `json:"engineVersion,omitempty"`
+	// Path is the path of the secret containing the credentials, relative to the secrets engine's mount path.
+	Path string `json:"path"`
+	// RefreshInterval specifies after which time the credentials are fetched from Vault again.
+	// Defaults to 5 minutes.
+	// +optional
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+}
+
+// SecretValueSource specifies a single value which is fetched from an external secret manager.
+// Exactly one of the sources must be set.
+type SecretValueSource struct {
+	// AWSSecretsManager fetches the value from AWS Secrets Manager.
+	// +optional
+	AWSSecretsManager *AWSSecretsManagerSource `json:"awsSecretsManager,omitempty"`
+	// GCPSecretManager fetches the value from Google Cloud Secret Manager.
+	// +optional
+	GCPSecretManager *GCPSecretManagerSource `json:"gcpSecretManager,omitempty"`
+	// RefreshInterval specifies after which time the value is fetched again.
+	// Defaults to 5 minutes.
+	// +optional
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+}
+
+// AWSSecretsManagerSource references a secret in AWS Secrets Manager.
+// The AWS credentials are resolved by the default credential chain of the AWS SDK, e.g. from 'AWS_ACCESS_KEY_ID' and 'AWS_SECRET_ACCESS_KEY',
+// or from a web identity token via 'AWS_ROLE_ARN' and 'AWS_WEB_IDENTITY_TOKEN_FILE', as injected by EKS for IAM roles for service accounts.
+type AWSSecretsManagerSource struct {
+	// Region is the AWS region of the secret.
+	Region string `json:"region"`
+	// SecretID is the name or ARN of the secret.
+	SecretID string `json:"secretId"`
+	// Key is the key of the value within the secret, if the secret string is a JSON object.
+	// If empty, the whole secret string is used.
+	// +optional
+	Key string `json:"key,omitempty"`
+	// Endpoint overwrites the Secrets Manager endpoint.
+	// If empty, the AWS SDK resolves the endpoint of the region.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// GCPSecretManagerSource references a secret version in Google Cloud Secret Manager.
+// The access token is obtained via the application default credentials, e.g. from the GCE metadata server, which is also provided by GKE workload identity.
+type GCPSecretManagerSource struct {
+	// Project is the ID of the project containing the secret.
+	Project string `json:"project"`
+	// Secret is the name of the secret.
+	Secret string `json:"secret"`
+	// Version is the version of the secret.
+	// Defaults to 'latest'.
+	// +optional
+	Version string `json:"version,omitempty"`
+	// Endpoint overwrites the Secret Manager endpoint.
+	// Defaults to 'https://secretmanager.googleapis.com'.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
 }
 
 type GitAuthenticationType string
@@ -180,6 +1571,179 @@ type FileSystemConfiguration struct {
 	// InMemory makes the FileSystemPersister use an in-memory filesystem, if set to true.
 	// Defaults to false for type 'filesystem' and to true for type 'git'.
 	InMemory *bool `json:"inMemory,omitempty"`
+	// Layout specifies the directory structure which is used for the persisted resources.
+	// Supported values are
+	//   'default' - namespace directories with prefix and '<gvk><separator><name>' file names
+	//   'argocd' - 'applications/<namespace>/<kind>-<name>' file names, plus an Argo CD app-of-apps index
+	// Defaults to 'default'.
+	// +optional
+	Layout FileSystemLayout `json:"layout,omitempty"`
+	// ArgoCD contains the configuration for the Argo CD applications which are generated for the 'argocd' layout.
+	// Ignored for other layouts.
+	// +optional
+	ArgoCD *ArgoCDLayoutConfiguration `json:"argocd,omitempty"`
+	// KindOverrides overrides the file naming for specific kinds, so that they can be stored differently without requiring a separate storage definition.
+	// The keys are GVK strings in the format which is used in the file names, '<kind>.<version>.<group>' with the kind in lower case,
+	// e.g. 'configmap.v1' or 'deployment.v1.apps'.
+	// Only the file extension can be overridden for the 'argocd' layout.
+	// +optional
+	KindOverrides map[string]*FileNamingOverride `json:"kindOverrides,omitempty"`
+	// Serialization configures how resources are serialized into files.
+	// If not set, resources are serialized as-is, with the fields of each map sorted alphabetically.
+	// +optional
+	Serialization *SerializationConfiguration `json:"serialization,omitempty"`
+	// OnCorruptData specifies how data in the storage is handled which cannot be parsed, e.g. because the file has been corrupted.
+	// Valid values are:
+	//   'overwrite' to treat the data as missing and overwrite it with the next update of the resource
+	//   'error' to fail all operations on the resource, until the data has been repaired manually
+	//   'quarantine' like 'overwrite', but the corrupt data is moved below '.quarantine' in the root path first
+	// Defaults to 'overwrite'.
+	// +optional
+	OnCorruptData CorruptDataPolicy `json:"onCorruptData,omitempty"`
+	// IO configures retries and locking for the operations on the filesystem, which helps if the root path is on network storage, e.g. NFS or SMB.
+	// Must not be set if InMemory is true.
+	// +optional
+	IO *FileSystemIOConfiguration `json:"io,omitempty"`
+	// LongNames configures the shortening of file names which would exceed the maximum length, e.g. for resources with very long names.
+	// If not set, file names are never shortened, so persisting such resources fails on most filesystems.
+	// +optional
+	LongNames *LongNamesConfiguration `json:"longNames,omitempty"`
+	// ListDocuments makes the persister maintain an additional document of kind 'List' per kind and namespace directory,
+	// which contains all persisted resources of this kind in this directory, so that they can be applied with a single file.
+	// Not supported for the 'argocd' layout.
+	// +optional
+	ListDocuments bool `json:"listDocuments,omitempty"`
+	// FrontMatter makes the persister prepend a YAML comment header with sync metadata to each resource file,
+	// e.g. the sync time and the resource version of the persisted resource.
+	// +optional
+	FrontMatter bool `json:"frontMatter,omitempty"`
+}
+
+// LongNamesConfiguration configures how resources are stored whose file name would exceed the maximum length.
+// Only the part of the file name which is derived from the resource name is shortened, deterministically.
+type LongNamesConfiguration struct {
+	// MaxLength is the maximum length of a file name in bytes.
+	// Defaults to 255, which is the limit of most filesystems.
+	// +optional
+	MaxLength int `json:"maxLength,omitempty"`
+	// Strategy determines how the name is shortened.
+	// Valid values are:
+	//   'truncate' to keep the beginning of the name, followed by '~' and a short hash of the full name
+	//   'hash' to replace the name with '~' and a hash of the full name
+	// Defaults to 'truncate'.
+	// +optional
+	Strategy LongNameStrategy `json:"strategy,omitempty"`
+}
+
+type LongNameStrategy string
+
+const (
+	// LONG_NAME_STRATEGY_TRUNCATE keeps as much of the name as possible, followed by a short hash.
+	LONG_NAME_STRATEGY_TRUNCATE LongNameStrategy = "truncate"
+	// LONG_NAME_STRATEGY_HASH replaces the name with a hash.
+	LONG_NAME_STRATEGY_HASH LongNameStrategy = "hash"
+)
+
+// FileSystemIOConfiguration configures how K8Syncer works with a filesystem on the host.
+type FileSystemIOConfiguration struct {
+	// Retries is the number of times a filesystem operation is retried if it fails with a transient error,
+	// which network filesystems report e.g. with EBUSY or ESTALE.
+	// 0 means that operations are not retried.
+	// +optional
+	Retries int `json:"retries,omitempty"`
+	// RetryInterval is the time to wait before the first retry, it is doubled for each further retry.
+	// Defaults to 100ms.
+	// +optional
+	RetryInterval *metav1.Duration `json:"retryInterval,omitempty"`
+	// Lock makes K8Syncer hold an advisory lock (flock) on each file while reading or writing it,
+	// so that other processes which respect these locks never see partially written files.
+	// Only supported on unix-like operating systems.
+	// +optional
+	Lock bool `json:"lock,omitempty"`
+}
+
+type CorruptDataPolicy string
+
+const (
+	// CORRUPT_DATA_POLICY_OVERWRITE overwrites corrupt data with the current resource.
+	CORRUPT_DATA_POLICY_OVERWRITE CorruptDataPolicy = "overwrite"
+	// CORRUPT_DATA_POLICY_ERROR returns an error for operations on corrupt data.
+	CORRUPT_DATA_POLICY_ERROR CorruptDataPolicy = "error"
+	// CORRUPT_DATA_POLICY_QUARANTINE moves corrupt data to a quarantine directory before overwriting it with the current resource.
+	CORRUPT_DATA_POLICY_QUARANTINE CorruptDataPolicy = "quarantine"
+)
+
+// SerializationConfiguration configures how resources are serialized into files.
+type SerializationConfiguration struct {
+	// Canonical enables the normalization of values which have multiple equivalent representations, so that semantically equal
+	// resources always result in byte-identical files.
+	// Resource quantities are converted into their canonical form (e.g. '1000m' => '1', '1024Mi' => '1Gi')
+	// and timestamps are converted to UTC with the fractional seconds trimmed.
+	// +optional
+	Canonical bool `json:"canonical,omitempty"`
+	// FieldOrder contains field names which are written before all other fields of a map, in the given order.
+	// It applies to the maps at all nesting levels, the remaining fields are sorted alphabetically.
+	// Example: ['apiVersion', 'kind', 'metadata', 'name', 'namespace', 'spec']
+	// +optional
+	FieldOrder []string `json:"fieldOrder,omitempty"`
+	// TopLevelFieldOrder contains field names which are written before all other top-level fields of a resource, in the given order.
+	// If set, it replaces FieldOrder for the top-level fields, the nested maps are still ordered according to FieldOrder.
+	// Example: ['apiVersion', 'kind', 'metadata', 'spec', 'data', 'status']
+	// +optional
+	TopLevelFieldOrder []string `json:"topLevelFieldOrder,omitempty"`
+}
+
+// FileNamingOverride overrides the file naming of a filesystem configuration for a specific kind.
+// Fields which are not set are taken from the filesystem configuration.
+type FileNamingOverride struct {
+	// NamespacePrefix is the prefix used for the namespace folders which contain resources of this kind.
+	// +optional
+	NamespacePrefix *string `json:"namespacePrefix,omitempty"`
+	// GVKNameSeparator is the separator between the GroupVersionKind and the resource name used in the filename.
+	// +optional
+	GVKNameSeparator *string `json:"gvrNameSeparator,omitempty"`
+	// FileExtension is the file extension used for the files of this kind.
+	// May be specified with or without preceding '.'
+	// +optional
+	FileExtension *string `json:"fileExtension,omitempty"`
+}
+
+type FileSystemLayout string
+
+const (
+	// FILESYSTEM_LAYOUT_DEFAULT is the default directory structure.
+	FILESYSTEM_LAYOUT_DEFAULT FileSystemLayout = "default"
+	// FILESYSTEM_LAYOUT_ARGOCD is a directory structure which can be deployed via Argo CD.
+	FILESYSTEM_LAYOUT_ARGOCD FileSystemLayout = "argocd"
+)
+
+// ArgoCDLayoutConfiguration contains the values required to generate the Argo CD applications for the 'argocd' layout.
+type ArgoCDLayoutConfiguration struct {
+	// RepoURL is the URL of the repository which Argo CD should deploy the resources from.
+	// Defaults to the git URL for storages of type 'git', required otherwise.
+	// +optional
+	RepoURL string `json:"repoURL,omitempty"`
+	// TargetRevision is the revision which Argo CD should deploy.
+	// Defaults to the git branch for storages of type 'git' and to 'HEAD' otherwise.
+	// +optional
+	TargetRevision string `json:"targetRevision,omitempty"`
+	// RootApplicationName is the name of the generated root application.
+	// It is also used as prefix for the names of the generated per-namespace applications.
+	// Defaults to 'k8syncer'.
+	// +optional
+	RootApplicationName string `json:"rootApplicationName,omitempty"`
+	// Namespace is the namespace in which the generated applications are deployed, usually the namespace Argo CD is running in.
+	// Defaults to 'argocd'.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Project is the Argo CD project of the generated applications.
+	// Defaults to 'default'.
+	// +optional
+	Project string `json:"project,omitempty"`
+	// DestinationServer is the API server URL of the cluster which the resources should be deployed to.
+	// Defaults to 'https://kubernetes.default.svc'.
+	// +optional
+	DestinationServer string `json:"destinationServer,omitempty"`
 }
 
 type MockConfiguration struct {
@@ -187,14 +1751,54 @@ type MockConfiguration struct {
 	// They are always logged, but usually on Debug verbosity.
 	// If set to true, this is switched to Info for this MockPersister.
 	LogPersisterCallsOnInfoLevel bool `json:"logPersisterCallsOnInfoLevel"`
+	// Faults contains failures and latencies which are injected into the Persister function calls.
+	// This allows testing how K8Syncer reacts to an unreliable storage.
+	// +optional
+	Faults []*MockFault `json:"faults,omitempty"`
 }
 
+// MockFault describes failures and latencies which are injected into the matching calls of a MockPersister.
+type MockFault struct {
+	// Operations are the Persister functions the fault applies to.
+	// Supported values are 'exists', 'get', 'persist', and 'delete'.
+	// Applies to all functions if empty.
+	// +optional
+	Operations []MockOperation `json:"operations,omitempty"`
+	// FailOnCalls contains the numbers of the matching calls which fail, starting with 1 for the first matching call.
+	// +optional
+	FailOnCalls []int `json:"failOnCalls,omitempty"`
+	// FailureRate is the percentage of matching calls which fail randomly, between 0 and 100.
+	// +optional
+	FailureRate int `json:"failureRate,omitempty"`
+	// Seed is the seed for the random failures, which allows reproducing a sequence of failures.
+	// Defaults to a random seed.
+	// +optional
+	Seed *int64 `json:"seed,omitempty"`
+	// Latency is added to each matching call before it is executed.
+	// +optional
+	Latency *metav1.Duration `json:"latency,omitempty"`
+	// Message is the error message of the injected failures.
+	// Defaults to 'injected fault'.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type MockOperation string
+
+const (
+	MOCK_OPERATION_EXISTS  MockOperation = "exists"
+	MOCK_OPERATION_GET     MockOperation = "get"
+	MOCK_OPERATION_PERSIST MockOperation = "persist"
+	MOCK_OPERATION_DELETE  MockOperation = "delete"
+)
+
 type StateConfiguration struct {
 	// Type is the type of state display which should be used.
 	// Supported values are
 	//   'none' for no state display
 	//   'status' for writing it into the resource's status
 	//   'annotation' for writing it on the resource as annotations
+	//   'configmap' for writing it into a ConfigMap per namespace, without modifying the resource
 	Type StateType `json:"type"`
 	// Verbosity defines what is displayed as state.
 	// Supported values are
@@ -206,6 +1810,58 @@ type StateConfiguration struct {
 	// It has to be set for type 'status'.
 	// +optional
 	StatusStateConfig *StatusStateConfiguration `json:"statusConfig,omitempty"`
+	// ConfigMapStateConfig is the configuration for storing the state in a ConfigMap.
+	// It is only evaluated for type 'configmap' and defaulted if not set.
+	// +optional
+	ConfigMapStateConfig *ConfigMapStateConfiguration `json:"configMapConfig,omitempty"`
+	// AnnotationStateConfig is the configuration for storing the state in annotations.
+	// It is only evaluated for type 'annotation', the default annotation keys are used if not set.
+	// +optional
+	AnnotationStateConfig *AnnotationStateConfiguration `json:"annotationConfig,omitempty"`
+	// FailurePolicy specifies what happens if the state cannot be written, e.g. because a webhook rejects the update.
+	// Supported values are
+	//   'fail' - the reconcile fails and is retried, even if the resource has been persisted successfully
+	//   'warn' - the error is logged and the reconcile continues
+	//   'ignore' - the error is only logged on debug level and the reconcile continues
+	// Failed state writes are counted in the state write metrics for all policies.
+	// Defaults to 'fail'.
+	// +optional
+	FailurePolicy StateFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+type AnnotationStateConfiguration struct {
+	// Prefix is the prefix of the annotation keys, which are '<prefix>/lastSyncedGeneration', '<prefix>/phase', and '<prefix>/detail'.
+	// It has to be 'state.k8syncer.gardener.cloud' or a subdomain of it, e.g. 'instance-a.state.k8syncer.gardener.cloud',
+	// so that the annotations are recognized as state annotations by all K8Syncer instances.
+	// Defaults to 'state.k8syncer.gardener.cloud'.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// LastSyncedGenerationKey is the key of the annotation which contains the last synced generation.
+	// It has to have the same kind of prefix as Prefix.
+	// Defaults to '<prefix>/lastSyncedGeneration'.
+	// +optional
+	LastSyncedGenerationKey string `json:"lastSyncedGenerationKey,omitempty"`
+	// PhaseKey is the key of the annotation which contains the phase.
+	// It has to have the same kind of prefix as Prefix.
+	// Defaults to '<prefix>/phase'.
+	// +optional
+	PhaseKey string `json:"phaseKey,omitempty"`
+	// DetailKey is the key of the annotation which contains details about errors.
+	// It has to have the same kind of prefix as Prefix.
+	// Defaults to '<prefix>/detail'.
+	// +optional
+	DetailKey string `json:"detailKey,omitempty"`
+}
+
+type ConfigMapStateConfiguration struct {
+	// Name is the name of the ConfigMap which contains the state of all synced resources of a namespace.
+	// Defaults to 'k8syncer-state'.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// ClusterScopedNamespace is the namespace of the ConfigMap which contains the state of cluster-scoped resources.
+	// Defaults to 'default'.
+	// +optional
+	ClusterScopedNamespace string `json:"clusterScopedNamespace,omitempty"`
 }
 
 type StatusStateConfiguration struct {
@@ -232,6 +1888,19 @@ const (
 	STATE_TYPE_STATUS StateType = "status"
 	// STATE_TYPE_ANNOTATION configures state display via annotations on the resource.
 	STATE_TYPE_ANNOTATION StateType = "annotation"
+	// STATE_TYPE_CONFIGMAP configures state display via a ConfigMap per namespace.
+	STATE_TYPE_CONFIGMAP StateType = "configmap"
+)
+
+type StateFailurePolicy string
+
+const (
+	// STATE_FAILURE_POLICY_FAIL means that failed state writes cause the reconcile to fail.
+	STATE_FAILURE_POLICY_FAIL StateFailurePolicy = "fail"
+	// STATE_FAILURE_POLICY_WARN means that failed state writes are logged and otherwise ignored.
+	STATE_FAILURE_POLICY_WARN StateFailurePolicy = "warn"
+	// STATE_FAILURE_POLICY_IGNORE means that failed state writes are only logged on debug level.
+	STATE_FAILURE_POLICY_IGNORE StateFailurePolicy = "ignore"
 )
 
 type StateVerbosity string
@@ -19,6 +19,118 @@ func (in *K8SyncerConfiguration) DeepCopy() *K8SyncerConfiguration {
 	return &K8SyncerConfiguration{
 		SyncConfigs:        deepCopySlice[*SyncConfig](in.SyncConfigs),
 		StorageDefinitions: deepCopySlice[*StorageDefinition](in.StorageDefinitions),
+		ClusterName:        in.ClusterName,
+		NamespacePruning:   in.NamespacePruning.DeepCopy(),
+		ClientRateLimit:    in.ClientRateLimit.DeepCopy(),
+		WriterIdentity:     in.WriterIdentity.DeepCopy(),
+		SyncTrigger:        in.SyncTrigger.DeepCopy(),
+		ErrorLogs:          in.ErrorLogs.DeepCopy(),
+		AdmissionWebhook:   in.AdmissionWebhook.DeepCopy(),
+		Metrics:            in.Metrics.DeepCopy(),
+		SubPathPruning:     in.SubPathPruning.DeepCopy(),
+		StartupSummary:     in.StartupSummary.DeepCopy(),
+	}
+}
+
+func (in *StartupSummaryConfiguration) DeepCopy() *StartupSummaryConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &StartupSummaryConfiguration{
+		ConfigMapName:      in.ConfigMapName,
+		ConfigMapNamespace: in.ConfigMapNamespace,
+	}
+}
+
+func (in *SubPathPruningConfiguration) DeepCopy() *SubPathPruningConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &SubPathPruningConfiguration{
+		ConfigMapName:      in.ConfigMapName,
+		ConfigMapNamespace: in.ConfigMapNamespace,
+		DryRun:             in.DryRun,
+	}
+}
+
+func (in *MetricsConfiguration) DeepCopy() *MetricsConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &MetricsConfiguration{
+		SyncErrors: in.SyncErrors.DeepCopy(),
+	}
+}
+
+func (in *SyncErrorMetricsConfiguration) DeepCopy() *SyncErrorMetricsConfiguration {
+	if in == nil {
+		return nil
+	}
+	res := &SyncErrorMetricsConfiguration{
+		Aggregation:   in.Aggregation,
+		MaxNamespaces: in.MaxNamespaces,
+	}
+	if in.Labels != nil {
+		res.Labels = make([]SyncErrorMetricLabel, len(in.Labels))
+		copy(res.Labels, in.Labels)
+	}
+	return res
+}
+
+func (in *AdmissionWebhookConfiguration) DeepCopy() *AdmissionWebhookConfiguration {
+	if in == nil {
+		return nil
+	}
+	res := &AdmissionWebhookConfiguration{
+		Port:    in.Port,
+		CertDir: in.CertDir,
+	}
+	if in.AllowedUsers != nil {
+		res.AllowedUsers = make([]string, len(in.AllowedUsers))
+		copy(res.AllowedUsers, in.AllowedUsers)
+	}
+	if in.AllowedGroups != nil {
+		res.AllowedGroups = make([]string, len(in.AllowedGroups))
+		copy(res.AllowedGroups, in.AllowedGroups)
+	}
+	return res
+}
+
+func (in *ErrorLogConfiguration) DeepCopy() *ErrorLogConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &ErrorLogConfiguration{
+		RepeatInterval: in.RepeatInterval.DeepCopy(),
+	}
+}
+
+func (in *SyncTriggerConfiguration) DeepCopy() *SyncTriggerConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &SyncTriggerConfiguration{
+		Token:     in.Token,
+		TokenFile: in.TokenFile,
+	}
+}
+
+func (in *WriterIdentityConfiguration) DeepCopy() *WriterIdentityConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &WriterIdentityConfiguration{
+		InstanceName: in.InstanceName,
+	}
+}
+
+func (in *NamespacePruningConfiguration) DeepCopy() *NamespacePruningConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &NamespacePruningConfiguration{
+		Mode:           in.Mode,
+		ArchiveSubPath: in.ArchiveSubPath,
 	}
 }
 
@@ -26,13 +138,167 @@ func (in *SyncConfig) DeepCopy() *SyncConfig {
 	if in == nil {
 		return nil
 	}
-	return &SyncConfig{
-		ID:          in.ID,
-		Resource:    in.Resource.DeepCopy(),
-		StorageRefs: deepCopySlice[*StorageReference](in.StorageRefs),
-		State:       in.State.DeepCopy(),
-		Finalize:    deepCopyBool(in.Finalize),
+	res := &SyncConfig{
+		ID:                  in.ID,
+		Resource:            in.Resource.DeepCopy(),
+		StorageRefs:         deepCopySlice[*StorageReference](in.StorageRefs),
+		State:               in.State.DeepCopy(),
+		Finalize:            deepCopyBool(in.Finalize),
+		Impersonate:         in.Impersonate.DeepCopy(),
+		Snapshot:            in.Snapshot.DeepCopy(),
+		ChangeDetection:     in.ChangeDetection,
+		AnnotateContentHash: in.AnnotateContentHash,
+		Kubeconfig:          in.Kubeconfig,
+		Shoot:               in.Shoot.DeepCopy(),
+		ClusterName:         in.ClusterName,
+		PersistOwners:       in.PersistOwners,
+		StateWritePolicy:    in.StateWritePolicy,
+		ReadOnlySource:      in.ReadOnlySource,
+		PersistCRD:          in.PersistCRD,
+		PersistIncludes:     in.PersistIncludes,
+		PersistNamespace:    in.PersistNamespace,
+		Suspend:             in.Suspend,
+		Transformer:         in.Transformer.DeepCopy(),
+		ContentMode:         in.ContentMode,
+		ClientRateLimit:     in.ClientRateLimit.DeepCopy(),
+		UpdateRetry:         in.UpdateRetry.DeepCopy(),
+		DeletionFailure:     in.DeletionFailure.DeepCopy(),
+		ChangeLog:           in.ChangeLog.DeepCopy(),
+	}
+	if in.ReactOn != nil {
+		res.ReactOn = make([]ReactOnTrigger, len(in.ReactOn))
+		copy(res.ReactOn, in.ReactOn)
+	}
+	if in.Debounce != nil {
+		res.Debounce = in.Debounce.DeepCopy()
 	}
+	if in.MaintenanceWindows != nil {
+		res.MaintenanceWindows = deepCopySlice[*MaintenanceWindow](in.MaintenanceWindows)
+	}
+	return res
+}
+
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	res := &MaintenanceWindow{
+		Start:    in.Start,
+		End:      in.End,
+		TimeZone: in.TimeZone,
+	}
+	if in.Days != nil {
+		res.Days = make([]string, len(in.Days))
+		copy(res.Days, in.Days)
+	}
+	return res
+}
+
+func (in *ClientRateLimitConfiguration) DeepCopy() *ClientRateLimitConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &ClientRateLimitConfiguration{
+		QPS:   in.QPS,
+		Burst: in.Burst,
+	}
+}
+
+func (in *UpdateRetryConfiguration) DeepCopy() *UpdateRetryConfiguration {
+	if in == nil {
+		return nil
+	}
+	res := &UpdateRetryConfiguration{
+		Jitter: in.Jitter,
+	}
+	if in.MaxRetries != nil {
+		res.MaxRetries = utils.Ptr(*in.MaxRetries)
+	}
+	if in.Backoff != nil {
+		res.Backoff = in.Backoff.DeepCopy()
+	}
+	return res
+}
+
+func (in *DeletionFailureConfiguration) DeepCopy() *DeletionFailureConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &DeletionFailureConfiguration{
+		MaxAttempts:        in.MaxAttempts,
+		ConfigMapName:      in.ConfigMapName,
+		ConfigMapNamespace: in.ConfigMapNamespace,
+	}
+}
+
+func (in *ChangeLogConfiguration) DeepCopy() *ChangeLogConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &ChangeLogConfiguration{
+		FileName: in.FileName,
+	}
+}
+
+func (in *TransformerConfiguration) DeepCopy() *TransformerConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &TransformerConfiguration{
+		KeepAnnotations:   in.KeepAnnotations,
+		KeepFinalizers:    in.KeepFinalizers,
+		KeepStatus:        in.KeepStatus,
+		KeepManagedFields: in.KeepManagedFields,
+		SecretData:        in.SecretData,
+		PruneDefaults:     in.PruneDefaults,
+	}
+}
+
+func (in *SnapshotConfiguration) DeepCopy() *SnapshotConfiguration {
+	if in == nil {
+		return nil
+	}
+	res := &SnapshotConfiguration{
+		Format: in.Format,
+		Source: in.Source,
+	}
+	if in.Interval != nil {
+		res.Interval = in.Interval.DeepCopy()
+	}
+	if in.Target != nil {
+		res.Target = &SnapshotTarget{
+			Name:    in.Target.Name,
+			SubPath: in.Target.SubPath,
+		}
+	}
+	return res
+}
+
+func (in *ShootReference) DeepCopy() *ShootReference {
+	if in == nil {
+		return nil
+	}
+	return &ShootReference{
+		Namespace:        in.Namespace,
+		Name:             in.Name,
+		GardenKubeconfig: in.GardenKubeconfig,
+		Expiration:       in.Expiration.DeepCopy(),
+	}
+}
+
+func (in *ImpersonationConfiguration) DeepCopy() *ImpersonationConfiguration {
+	if in == nil {
+		return nil
+	}
+	res := &ImpersonationConfiguration{
+		ServiceAccount: in.ServiceAccount,
+		User:           in.User,
+	}
+	if in.Groups != nil {
+		res.Groups = make([]string, len(in.Groups))
+		copy(res.Groups, in.Groups)
+	}
+	return res
 }
 
 func (in *ResourceSyncConfig) DeepCopy() *ResourceSyncConfig {
@@ -40,10 +306,13 @@ func (in *ResourceSyncConfig) DeepCopy() *ResourceSyncConfig {
 		return nil
 	}
 	return &ResourceSyncConfig{
-		Namespace: in.Namespace,
-		Group:     in.Group,
-		Version:   in.Version,
-		Kind:      in.Kind,
+		Namespace:      in.Namespace,
+		Group:          in.Group,
+		Version:        in.Version,
+		Kind:           in.Kind,
+		PersistVersion: in.PersistVersion,
+		FieldSelector:  in.FieldSelector,
+		Scope:          in.Scope,
 	}
 }
 
@@ -51,10 +320,17 @@ func (in *StorageReference) DeepCopy() *StorageReference {
 	if in == nil {
 		return nil
 	}
-	return &StorageReference{
-		Name:    in.Name,
-		SubPath: in.SubPath,
+	res := &StorageReference{
+		Name:          in.Name,
+		SubPath:       in.SubPath,
+		FileNaming:    in.FileNaming,
+		MaxObjects:    in.MaxObjects,
+		MaxTotalBytes: in.MaxTotalBytes,
+	}
+	if in.RecheckInterval != nil {
+		res.RecheckInterval = in.RecheckInterval.DeepCopy()
 	}
+	return res
 }
 
 func (in *StorageDefinition) DeepCopy() *StorageDefinition {
@@ -62,11 +338,157 @@ func (in *StorageDefinition) DeepCopy() *StorageDefinition {
 		return nil
 	}
 	return &StorageDefinition{
-		Name:             in.Name,
-		Type:             in.Type,
-		GitConfig:        in.GitConfig.DeepCopy(),
-		FileSystemConfig: in.FileSystemConfig.DeepCopy(),
-		MockConfig:       in.MockConfig.DeepCopy(),
+		Name:                    in.Name,
+		Type:                    in.Type,
+		GitConfig:               in.GitConfig.DeepCopy(),
+		FileSystemConfig:        in.FileSystemConfig.DeepCopy(),
+		MockConfig:              in.MockConfig.DeepCopy(),
+		WikiConfig:              in.WikiConfig.DeepCopy(),
+		PluginConfig:            in.PluginConfig.DeepCopy(),
+		KubernetesConfig:        in.KubernetesConfig.DeepCopy(),
+		ClusterConfig:           in.ClusterConfig.DeepCopy(),
+		KVConfig:                in.KVConfig.DeepCopy(),
+		SFTPConfig:              in.SFTPConfig.DeepCopy(),
+		WebDAVConfig:            in.WebDAVConfig.DeepCopy(),
+		Cache:                   in.Cache.DeepCopy(),
+		OwnershipConflictPolicy: in.OwnershipConflictPolicy,
+		CircuitBreaker:          in.CircuitBreaker.DeepCopy(),
+	}
+}
+
+func (in *StorageCircuitBreakerConfiguration) DeepCopy() *StorageCircuitBreakerConfiguration {
+	if in == nil {
+		return nil
+	}
+	res := &StorageCircuitBreakerConfiguration{
+		FailureThreshold: in.FailureThreshold,
+	}
+	if in.ProbeInterval != nil {
+		res.ProbeInterval = in.ProbeInterval.DeepCopy()
+	}
+	return res
+}
+
+func (in *WikiConfiguration) DeepCopy() *WikiConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &WikiConfiguration{
+		Provider:          in.Provider,
+		Mode:              in.Mode,
+		URL:               in.URL,
+		Project:           in.Project,
+		Token:             in.Token,
+		TokenFile:         in.TokenFile,
+		TitlePrefix:       in.TitlePrefix,
+		SnippetVisibility: in.SnippetVisibility,
+	}
+}
+
+func (in *KVConfiguration) DeepCopy() *KVConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &KVConfiguration{
+		Backend:      in.Backend,
+		Endpoint:     in.Endpoint,
+		KeyPrefix:    in.KeyPrefix,
+		TTL:          in.TTL.DeepCopy(),
+		Username:     in.Username,
+		Password:     in.Password,
+		PasswordFile: in.PasswordFile,
+		Timeout:      in.Timeout.DeepCopy(),
+	}
+}
+
+func (in *SFTPConfiguration) DeepCopy() *SFTPConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &SFTPConfiguration{
+		Host:           in.Host,
+		Path:           in.Path,
+		Username:       in.Username,
+		Password:       in.Password,
+		PasswordFile:   in.PasswordFile,
+		PrivateKey:     in.PrivateKey,
+		PrivateKeyFile: in.PrivateKeyFile,
+		KnownHostsFile: in.KnownHostsFile,
+		HostKey:        in.HostKey,
+		Timeout:        in.Timeout.DeepCopy(),
+	}
+}
+
+func (in *WebDAVConfiguration) DeepCopy() *WebDAVConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &WebDAVConfiguration{
+		URL:          in.URL,
+		Path:         in.Path,
+		Username:     in.Username,
+		Password:     in.Password,
+		PasswordFile: in.PasswordFile,
+		Timeout:      in.Timeout.DeepCopy(),
+	}
+}
+
+func (in *ClusterStorageConfiguration) DeepCopy() *ClusterStorageConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := &ClusterStorageConfiguration{
+		Kubeconfig:     in.Kubeconfig,
+		FieldManager:   in.FieldManager,
+		ForceConflicts: in.ForceConflicts,
+	}
+	if in.NamespaceMapping != nil {
+		out.NamespaceMapping = make(map[string]string, len(in.NamespaceMapping))
+		for k, v := range in.NamespaceMapping {
+			out.NamespaceMapping[k] = v
+		}
+	}
+	return out
+}
+
+func (in *KubernetesConfiguration) DeepCopy() *KubernetesConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &KubernetesConfiguration{
+		Kubeconfig: in.Kubeconfig,
+		Namespace:  in.Namespace,
+		ObjectType: in.ObjectType,
+		Mode:       in.Mode,
+		NamePrefix: in.NamePrefix,
+	}
+}
+
+func (in *PluginConfiguration) DeepCopy() *PluginConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &PluginConfiguration{
+		Address: in.Address,
+		Timeout: in.Timeout.DeepCopy(),
+		TLS:     in.TLS.DeepCopy(),
+	}
+}
+
+func (in *PluginTLSConfiguration) DeepCopy() *PluginTLSConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	return &out
+}
+
+func (in *StorageCacheConfiguration) DeepCopy() *StorageCacheConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &StorageCacheConfiguration{
+		MaxEntries: in.MaxEntries,
 	}
 }
 
@@ -75,13 +497,145 @@ func (in *GitConfiguration) DeepCopy() *GitConfiguration {
 		return nil
 	}
 	return &GitConfiguration{
-		URL:       in.URL,
-		Branch:    in.Branch,
-		Exclusive: in.Exclusive,
+		URL:               in.URL,
+		Branch:            in.Branch,
+		RemoteName:        in.RemoteName,
+		Exclusive:         in.Exclusive,
+		Auth:              in.Auth.DeepCopy(),
+		SecondaryAuth:     in.SecondaryAuth.DeepCopy(),
+		Webhook:           in.Webhook.DeepCopy(),
+		BackgroundPull:    in.BackgroundPull.DeepCopy(),
+		PullMinInterval:   in.PullMinInterval.DeepCopy(),
+		NamespaceBranches: in.NamespaceBranches.DeepCopy(),
+		CommitChunkSize:   in.CommitChunkSize,
+		Provenance:        in.Provenance.DeepCopy(),
+		Index:             in.Index.DeepCopy(),
+		GitBackend:        in.GitBackend,
+		ConflictPolicy:    in.ConflictPolicy,
+		CommitTimestamps:  in.CommitTimestamps.DeepCopy(),
+		CommitGroups:      in.CommitGroups.DeepCopy(),
+		Bootstrap:         in.Bootstrap,
+		Provider:          in.Provider.DeepCopy(),
+	}
+}
+
+func (in *GitProviderConfiguration) DeepCopy() *GitProviderConfiguration {
+	if in == nil {
+		return nil
+	}
+	res := &GitProviderConfiguration{
+		Type:          in.Type,
+		URL:           in.URL,
+		Repository:    in.Repository,
+		Token:         in.Token,
+		TokenFile:     in.TokenFile,
+		ProtectBranch: in.ProtectBranch,
+	}
+	if in.Private != nil {
+		res.Private = utils.Ptr(*in.Private)
+	}
+	return res
+}
+
+func (in *GitCommitGroupsConfiguration) DeepCopy() *GitCommitGroupsConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &GitCommitGroupsConfiguration{
+		Template: in.Template,
+	}
+}
+
+func (in *GitCommitTimestampsConfiguration) DeepCopy() *GitCommitTimestampsConfiguration {
+	if in == nil {
+		return nil
+	}
+	res := &GitCommitTimestampsConfiguration{
+		TimeZone: in.TimeZone,
+	}
+	if in.Truncate != nil {
+		res.Truncate = in.Truncate.DeepCopy()
+	}
+	return res
+}
+
+func (in *GitProvenanceConfiguration) DeepCopy() *GitProvenanceConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &GitProvenanceConfiguration{
+		SigningKey:     in.SigningKey,
+		SigningKeyFile: in.SigningKeyFile,
+	}
+}
+
+func (in *GitIndexConfiguration) DeepCopy() *GitIndexConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &GitIndexConfiguration{
+		FileName: in.FileName,
+	}
+}
+
+func (in *GitNamespaceBranchesConfiguration) DeepCopy() *GitNamespaceBranchesConfiguration {
+	if in == nil {
+		return nil
+	}
+	res := &GitNamespaceBranchesConfiguration{
+		Prefix:     in.Prefix,
+		AuthSecret: in.AuthSecret.DeepCopy(),
+	}
+	if in.Namespaces != nil {
+		res.Namespaces = deepCopySlice[*GitNamespaceAuthMapping](in.Namespaces)
+	}
+	return res
+}
+
+func (in *GitNamespaceAuthMapping) DeepCopy() *GitNamespaceAuthMapping {
+	if in == nil {
+		return nil
+	}
+	return &GitNamespaceAuthMapping{
+		Namespace: in.Namespace,
 		Auth:      in.Auth.DeepCopy(),
 	}
 }
 
+func (in *GitNamespaceAuthSecretConfiguration) DeepCopy() *GitNamespaceAuthSecretConfiguration {
+	if in == nil {
+		return nil
+	}
+	res := &GitNamespaceAuthSecretConfiguration{
+		Name: in.Name,
+		Type: in.Type,
+	}
+	if in.RefreshInterval != nil {
+		res.RefreshInterval = in.RefreshInterval.DeepCopy()
+	}
+	return res
+}
+
+func (in *GitBackgroundPullConfiguration) DeepCopy() *GitBackgroundPullConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &GitBackgroundPullConfiguration{
+		Interval:        in.Interval.DeepCopy(),
+		FreshnessWindow: in.FreshnessWindow.DeepCopy(),
+	}
+}
+
+func (in *GitWebhookConfiguration) DeepCopy() *GitWebhookConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &GitWebhookConfiguration{
+		Secret:     in.Secret,
+		SecretFile: in.SecretFile,
+	}
+}
+
 func (in *GitRepoAuth) DeepCopy() *GitRepoAuth {
 	if in == nil {
 		return nil
@@ -90,11 +644,73 @@ func (in *GitRepoAuth) DeepCopy() *GitRepoAuth {
 		Type:           in.Type,
 		Username:       in.Username,
 		Password:       in.Password,
+		PasswordFrom:   in.PasswordFrom.DeepCopy(),
 		PrivateKey:     in.PrivateKey,
 		PrivateKeyFile: in.PrivateKeyFile,
+		Vault:          in.Vault.DeepCopy(),
 	}
 }
 
+func (in *SecretValueSource) DeepCopy() *SecretValueSource {
+	if in == nil {
+		return nil
+	}
+	res := &SecretValueSource{
+		AWSSecretsManager: in.AWSSecretsManager.DeepCopy(),
+		GCPSecretManager:  in.GCPSecretManager.DeepCopy(),
+	}
+	if in.RefreshInterval != nil {
+		res.RefreshInterval = in.RefreshInterval.DeepCopy()
+	}
+	return res
+}
+
+func (in *AWSSecretsManagerSource) DeepCopy() *AWSSecretsManagerSource {
+	if in == nil {
+		return nil
+	}
+	return &AWSSecretsManagerSource{
+		Region:   in.Region,
+		SecretID: in.SecretID,
+		Key:      in.Key,
+		Endpoint: in.Endpoint,
+	}
+}
+
+func (in *GCPSecretManagerSource) DeepCopy() *GCPSecretManagerSource {
+	if in == nil {
+		return nil
+	}
+	return &GCPSecretManagerSource{
+		Project:  in.Project,
+		Secret:   in.Secret,
+		Version:  in.Version,
+		Endpoint: in.Endpoint,
+	}
+}
+
+func (in *VaultConfiguration) DeepCopy() *VaultConfiguration {
+	if in == nil {
+		return nil
+	}
+	res := &VaultConfiguration{
+		Address:                 in.Address,
+		Role:                    in.Role,
+		Namespace:               in.Namespace,
+		CACert:                  in.CACert,
+		CACertFile:              in.CACertFile,
+		AuthMountPath:           in.AuthMountPath,
+		ServiceAccountTokenFile: in.ServiceAccountTokenFile,
+		Engine:                  in.Engine,
+		EngineVersion:           in.EngineVersion,
+		Path:                    in.Path,
+	}
+	if in.RefreshInterval != nil {
+		res.RefreshInterval = in.RefreshInterval.DeepCopy()
+	}
+	return res
+}
+
 func (in *FileSystemConfiguration) DeepCopy() *FileSystemConfiguration {
 	if in == nil {
 		return nil
@@ -105,6 +721,79 @@ func (in *FileSystemConfiguration) DeepCopy() *FileSystemConfiguration {
 		FileExtension:    in.FileExtension,
 		RootPath:         in.RootPath,
 		InMemory:         deepCopyBool(in.InMemory),
+		Layout:           in.Layout,
+		ArgoCD:           in.ArgoCD.DeepCopy(),
+		KindOverrides:    deepCopyMap(in.KindOverrides),
+		Serialization:    in.Serialization.DeepCopy(),
+		OnCorruptData:    in.OnCorruptData,
+		IO:               in.IO.DeepCopy(),
+		LongNames:        in.LongNames.DeepCopy(),
+		ListDocuments:    in.ListDocuments,
+		FrontMatter:      in.FrontMatter,
+	}
+}
+
+func (in *LongNamesConfiguration) DeepCopy() *LongNamesConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &LongNamesConfiguration{
+		MaxLength: in.MaxLength,
+		Strategy:  in.Strategy,
+	}
+}
+
+func (in *FileSystemIOConfiguration) DeepCopy() *FileSystemIOConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &FileSystemIOConfiguration{
+		Retries:       in.Retries,
+		RetryInterval: in.RetryInterval.DeepCopy(),
+		Lock:          in.Lock,
+	}
+}
+
+func (in *SerializationConfiguration) DeepCopy() *SerializationConfiguration {
+	if in == nil {
+		return nil
+	}
+	res := &SerializationConfiguration{
+		Canonical: in.Canonical,
+	}
+	if in.FieldOrder != nil {
+		res.FieldOrder = make([]string, len(in.FieldOrder))
+		copy(res.FieldOrder, in.FieldOrder)
+	}
+	if in.TopLevelFieldOrder != nil {
+		res.TopLevelFieldOrder = make([]string, len(in.TopLevelFieldOrder))
+		copy(res.TopLevelFieldOrder, in.TopLevelFieldOrder)
+	}
+	return res
+}
+
+func (in *FileNamingOverride) DeepCopy() *FileNamingOverride {
+	if in == nil {
+		return nil
+	}
+	return &FileNamingOverride{
+		NamespacePrefix:  in.NamespacePrefix,
+		GVKNameSeparator: in.GVKNameSeparator,
+		FileExtension:    in.FileExtension,
+	}
+}
+
+func (in *ArgoCDLayoutConfiguration) DeepCopy() *ArgoCDLayoutConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &ArgoCDLayoutConfiguration{
+		RepoURL:             in.RepoURL,
+		TargetRevision:      in.TargetRevision,
+		RootApplicationName: in.RootApplicationName,
+		Namespace:           in.Namespace,
+		Project:             in.Project,
+		DestinationServer:   in.DestinationServer,
 	}
 }
 
@@ -112,9 +801,36 @@ func (in *MockConfiguration) DeepCopy() *MockConfiguration {
 	if in == nil {
 		return nil
 	}
-	return &MockConfiguration{
+	res := &MockConfiguration{
 		LogPersisterCallsOnInfoLevel: in.LogPersisterCallsOnInfoLevel,
 	}
+	if in.Faults != nil {
+		res.Faults = deepCopySlice[*MockFault](in.Faults)
+	}
+	return res
+}
+
+func (in *MockFault) DeepCopy() *MockFault {
+	if in == nil {
+		return nil
+	}
+	res := &MockFault{
+		FailureRate: in.FailureRate,
+		Latency:     in.Latency.DeepCopy(),
+		Message:     in.Message,
+	}
+	if in.Operations != nil {
+		res.Operations = make([]MockOperation, len(in.Operations))
+		copy(res.Operations, in.Operations)
+	}
+	if in.FailOnCalls != nil {
+		res.FailOnCalls = make([]int, len(in.FailOnCalls))
+		copy(res.FailOnCalls, in.FailOnCalls)
+	}
+	if in.Seed != nil {
+		res.Seed = utils.Ptr(*in.Seed)
+	}
+	return res
 }
 
 func (in *StateConfiguration) DeepCopy() *StateConfiguration {
@@ -122,9 +838,34 @@ func (in *StateConfiguration) DeepCopy() *StateConfiguration {
 		return nil
 	}
 	return &StateConfiguration{
-		Type:              in.Type,
-		Verbosity:         in.Verbosity,
-		StatusStateConfig: in.StatusStateConfig.DeepCopy(),
+		Type:                  in.Type,
+		Verbosity:             in.Verbosity,
+		StatusStateConfig:     in.StatusStateConfig.DeepCopy(),
+		ConfigMapStateConfig:  in.ConfigMapStateConfig.DeepCopy(),
+		AnnotationStateConfig: in.AnnotationStateConfig.DeepCopy(),
+		FailurePolicy:         in.FailurePolicy,
+	}
+}
+
+func (in *AnnotationStateConfiguration) DeepCopy() *AnnotationStateConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &AnnotationStateConfiguration{
+		Prefix:                  in.Prefix,
+		LastSyncedGenerationKey: in.LastSyncedGenerationKey,
+		PhaseKey:                in.PhaseKey,
+		DetailKey:               in.DetailKey,
+	}
+}
+
+func (in *ConfigMapStateConfiguration) DeepCopy() *ConfigMapStateConfiguration {
+	if in == nil {
+		return nil
+	}
+	return &ConfigMapStateConfiguration{
+		Name:                   in.Name,
+		ClusterScopedNamespace: in.ClusterScopedNamespace,
 	}
 }
 
@@ -147,6 +888,17 @@ func deepCopySlice[T DeepCopyAble[T]](list []T) []T {
 	return res
 }
 
+func deepCopyMap[K comparable, T DeepCopyAble[T]](in map[K]T) map[K]T {
+	if in == nil {
+		return nil
+	}
+	res := make(map[K]T, len(in))
+	for k, v := range in {
+		res[k] = v.DeepCopy()
+	}
+	return res
+}
+
 func deepCopyBool(in *bool) *bool {
 	if in == nil {
 		return nil
@@ -5,13 +5,25 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"reflect"
+	"regexp"
 	"strings"
+	"text/template"
+	"time"
 
-	"k8s.io/apimachinery/pkg/util/yaml"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
 
 	"github.com/gardener/k8syncer/pkg/utils"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
 )
 
 // Complete performs some completion tasks as setting defaults and transforming values into the expected format.
@@ -19,11 +31,176 @@ func (cfg *K8SyncerConfiguration) Complete() error {
 	for _, sc := range cfg.SyncConfigs {
 		// default finalizer config
 		if sc.Finalize == nil {
-			sc.Finalize = utils.Ptr(true)
+			// a read-only source must not be modified, so finalizers cannot be used
+			sc.Finalize = utils.Ptr(!sc.ReadOnlySource)
+		}
+		// default change detection
+		if sc.ChangeDetection == "" {
+			sc.ChangeDetection = CHANGE_DETECTION_GENERATION
+		}
+		// default state write policy
+		if sc.StateWritePolicy == "" {
+			sc.StateWritePolicy = STATE_WRITE_POLICY_ALWAYS
+		}
+		// default triggers, depending on the change detection mode
+		if sc.ReactOn == nil {
+			sc.ReactOn = []ReactOnTrigger{ReactOnTrigger(sc.ChangeDetection), REACT_ON_LABELS, REACT_ON_OWNER_REFERENCES}
+		}
+		// default content mode
+		if sc.ContentMode == "" {
+			sc.ContentMode = CONTENT_MODE_FULL
+		}
+		// default secret data mode
+		if sc.Transformer != nil && sc.Transformer.SecretData == "" {
+			sc.Transformer.SecretData = SECRET_DATA_MODE_DATA
+		}
+		// default update retries
+		if sc.UpdateRetry != nil && sc.UpdateRetry.MaxRetries == nil {
+			sc.UpdateRetry.MaxRetries = utils.Ptr(1)
+		}
+		// default orphaned-storage configmap
+		if sc.DeletionFailure != nil {
+			if sc.DeletionFailure.ConfigMapName == "" {
+				sc.DeletionFailure.ConfigMapName = "k8syncer-orphaned-storage"
+			}
+			if sc.DeletionFailure.ConfigMapNamespace == "" {
+				sc.DeletionFailure.ConfigMapNamespace = "default"
+			}
+		}
+		// default change log file
+		if sc.ChangeLog != nil && sc.ChangeLog.FileName == "" {
+			sc.ChangeLog.FileName = fmt.Sprintf("%s.changelog.ndjson", sc.ID)
+		}
+		// default file naming
+		for _, sr := range sc.StorageRefs {
+			if sr != nil && sr.FileNaming == "" {
+				sr.FileNaming = FILE_NAMING_NAME
+			}
+		}
+		// default state failure policy
+		if sc.State != nil && sc.State.FailurePolicy == "" {
+			sc.State.FailurePolicy = STATE_FAILURE_POLICY_FAIL
+		}
+		// default configmap state config
+		if sc.State != nil && sc.State.Type == STATE_TYPE_CONFIGMAP {
+			if sc.State.ConfigMapStateConfig == nil {
+				sc.State.ConfigMapStateConfig = &ConfigMapStateConfiguration{}
+			}
+			if sc.State.ConfigMapStateConfig.Name == "" {
+				sc.State.ConfigMapStateConfig.Name = "k8syncer-state"
+			}
+			if sc.State.ConfigMapStateConfig.ClusterScopedNamespace == "" {
+				sc.State.ConfigMapStateConfig.ClusterScopedNamespace = "default"
+			}
+		}
+		// default annotation state config
+		if sc.State != nil && sc.State.Type == STATE_TYPE_ANNOTATION && sc.State.AnnotationStateConfig != nil {
+			asCfg := sc.State.AnnotationStateConfig
+			if asCfg.Prefix == "" {
+				asCfg.Prefix = constants.STATE_ANNOTATION_DOMAIN
+			}
+			if asCfg.LastSyncedGenerationKey == "" {
+				asCfg.LastSyncedGenerationKey = asCfg.Prefix + "/lastSyncedGeneration"
+			}
+			if asCfg.PhaseKey == "" {
+				asCfg.PhaseKey = asCfg.Prefix + "/phase"
+			}
+			if asCfg.DetailKey == "" {
+				asCfg.DetailKey = asCfg.Prefix + "/detail"
+			}
+		}
+		// default snapshot config
+		if sc.Snapshot != nil {
+			if sc.Snapshot.Format == "" {
+				sc.Snapshot.Format = SNAPSHOT_FORMAT_YAML
+			}
+			if sc.Snapshot.Source == "" && len(sc.StorageRefs) > 0 && sc.StorageRefs[0] != nil {
+				sc.Snapshot.Source = sc.StorageRefs[0].Name
+			}
+		}
+		// default shoot kubeconfig expiration
+		if sc.Shoot != nil && sc.Shoot.Expiration == nil {
+			sc.Shoot.Expiration = &metav1.Duration{Duration: time.Hour}
+		}
+		// default maintenance windows
+		for _, mw := range sc.MaintenanceWindows {
+			if mw != nil && mw.TimeZone == "" {
+				mw.TimeZone = "UTC"
+			}
+		}
+	}
+
+	// default namespace pruning mode
+	if cfg.NamespacePruning != nil && cfg.NamespacePruning.Mode == "" {
+		cfg.NamespacePruning.Mode = NAMESPACE_PRUNING_MODE_DELETE
+	}
+
+	// default error log deduplication
+	if cfg.ErrorLogs == nil {
+		cfg.ErrorLogs = &ErrorLogConfiguration{}
+	}
+	if cfg.ErrorLogs.RepeatInterval == nil {
+		cfg.ErrorLogs.RepeatInterval = &metav1.Duration{Duration: 10 * time.Minute}
+	}
+
+	// default admission webhook port
+	if cfg.AdmissionWebhook != nil && cfg.AdmissionWebhook.Port == 0 {
+		cfg.AdmissionWebhook.Port = 9443
+	}
+
+	// default sync error metric labels and aggregation
+	if cfg.Metrics != nil && cfg.Metrics.SyncErrors != nil {
+		se := cfg.Metrics.SyncErrors
+		if len(se.Labels) == 0 {
+			se.Labels = make([]SyncErrorMetricLabel, len(ALL_SYNC_ERROR_METRIC_LABELS))
+			copy(se.Labels, ALL_SYNC_ERROR_METRIC_LABELS)
+		}
+		if se.Aggregation == "" {
+			se.Aggregation = METRIC_AGGREGATION_MAX
 		}
 	}
 
+	// default subPath pruning configmap
+	if cfg.SubPathPruning != nil {
+		if cfg.SubPathPruning.ConfigMapName == "" {
+			cfg.SubPathPruning.ConfigMapName = "k8syncer-managed-subpaths"
+		}
+		if cfg.SubPathPruning.ConfigMapNamespace == "" {
+			cfg.SubPathPruning.ConfigMapNamespace = "default"
+		}
+	}
+	if cfg.StartupSummary != nil {
+		if cfg.StartupSummary.ConfigMapName == "" {
+			cfg.StartupSummary.ConfigMapName = "k8syncer-startup-summary"
+		}
+		if cfg.StartupSummary.ConfigMapNamespace == "" {
+			cfg.StartupSummary.ConfigMapNamespace = "default"
+		}
+	}
+
+	// default writer instance name
+	if cfg.WriterIdentity != nil && cfg.WriterIdentity.InstanceName == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("unable to determine hostname as default for writerIdentity.instanceName: %w", err)
+		}
+		cfg.WriterIdentity.InstanceName = hostname
+	}
+
 	for _, sd := range cfg.StorageDefinitions {
+		// default ownership conflict policy
+		if sd.OwnershipConflictPolicy == "" {
+			sd.OwnershipConflictPolicy = OWNERSHIP_CONFLICT_POLICY_REJECT
+		}
+		// default circuit breaker
+		if sd.CircuitBreaker != nil {
+			if sd.CircuitBreaker.FailureThreshold == 0 {
+				sd.CircuitBreaker.FailureThreshold = 5
+			}
+			if sd.CircuitBreaker.ProbeInterval == nil {
+				sd.CircuitBreaker.ProbeInterval = &metav1.Duration{Duration: 30 * time.Second}
+			}
+		}
 		switch sd.Type {
 		case STORAGE_TYPE_GIT:
 			// transform git auth types to lowercase
@@ -32,11 +209,66 @@ func (cfg *K8SyncerConfiguration) Complete() error {
 				if sd.GitConfig.Branch == "" {
 					sd.GitConfig.Branch = "master"
 				}
-				if sd.GitConfig.Auth != nil {
-					sd.GitConfig.Auth.Type = GitAuthenticationType(strings.ToLower(string(sd.GitConfig.Auth.Type)))
+				// default remote name
+				if sd.GitConfig.RemoteName == "" {
+					sd.GitConfig.RemoteName = "origin"
+				}
+				// default git backend
+				if sd.GitConfig.GitBackend == "" {
+					sd.GitConfig.GitBackend = GIT_BACKEND_GO_GIT
+				}
+				// default conflict policy
+				if sd.GitConfig.ConflictPolicy == "" {
+					sd.GitConfig.ConflictPolicy = GIT_CONFLICT_POLICY_PREFER_CLUSTER
+				}
+				if sd.GitConfig.BackgroundPull != nil {
+					sd.GitConfig.BackgroundPull.complete()
+				}
+				// default namespace branch prefix
+				if sd.GitConfig.NamespaceBranches != nil && sd.GitConfig.NamespaceBranches.Prefix == "" {
+					sd.GitConfig.NamespaceBranches.Prefix = "namespaces/"
+				}
+				// default namespace auth secret
+				if sd.GitConfig.NamespaceBranches != nil && sd.GitConfig.NamespaceBranches.AuthSecret != nil {
+					as := sd.GitConfig.NamespaceBranches.AuthSecret
+					if as.Type == "" && sd.GitConfig.Auth != nil {
+						as.Type = sd.GitConfig.Auth.Type
+					}
+					as.Type = GitAuthenticationType(strings.ToLower(string(as.Type)))
+					if as.RefreshInterval == nil {
+						as.RefreshInterval = &metav1.Duration{Duration: 5 * time.Minute}
+					}
+				}
+				// default index file name
+				if sd.GitConfig.Index != nil && sd.GitConfig.Index.FileName == "" {
+					sd.GitConfig.Index.FileName = "README.md"
+				}
+				if sd.GitConfig.Provider != nil {
+					sd.GitConfig.Provider.complete(sd.GitConfig.URL)
+				}
+				auths := []*GitRepoAuth{sd.GitConfig.Auth, sd.GitConfig.SecondaryAuth}
+				if nb := sd.GitConfig.NamespaceBranches; nb != nil {
+					for _, m := range nb.Namespaces {
+						if m != nil {
+							auths = append(auths, m.Auth)
+						}
+					}
+				}
+				for _, auth := range auths {
+					if auth == nil {
+						continue
+					}
+					auth.Type = GitAuthenticationType(strings.ToLower(string(auth.Type)))
+					if auth.Vault != nil {
+						auth.Vault.complete()
+						continue
+					}
+					if auth.PasswordFrom != nil {
+						auth.PasswordFrom.complete()
+					}
 					// set arbitrary username for access token
-					if sd.GitConfig.Auth.Type == GIT_AUTH_USERNAME_PASSWORD && sd.GitConfig.Auth.Username == "" {
-						sd.GitConfig.Auth.Username = "anonymous"
+					if auth.Type == GIT_AUTH_USERNAME_PASSWORD && auth.Username == "" {
+						auth.Username = "anonymous"
 					}
 				}
 			}
@@ -45,11 +277,23 @@ func (cfg *K8SyncerConfiguration) Complete() error {
 				sd.FileSystemConfig = &FileSystemConfiguration{}
 			}
 			if sd.FileSystemConfig.InMemory == nil {
-				sd.FileSystemConfig.InMemory = utils.Ptr(true)
+				// the git binary can only work on the local filesystem
+				sd.FileSystemConfig.InMemory = utils.Ptr(sd.GitConfig == nil || sd.GitConfig.GitBackend != GIT_BACKEND_CLI)
 			}
 			if *sd.FileSystemConfig.InMemory && sd.FileSystemConfig.RootPath == "" {
 				sd.FileSystemConfig.RootPath = "/data"
 			}
+			if sd.GitConfig != nil {
+				sd.FileSystemConfig.completeLayout(sd.GitConfig.URL, sd.GitConfig.Branch)
+			} else {
+				sd.FileSystemConfig.completeLayout("", "")
+			}
+			if sd.FileSystemConfig.IO != nil {
+				sd.FileSystemConfig.IO.complete()
+			}
+			if sd.FileSystemConfig.LongNames != nil {
+				sd.FileSystemConfig.LongNames.complete()
+			}
 		case STORAGE_TYPE_FILESYSTEM:
 			// default filesystemconfig
 			// has to be specified for this type, so only default single missing values
@@ -60,31 +304,428 @@ func (cfg *K8SyncerConfiguration) Complete() error {
 				if *sd.FileSystemConfig.InMemory && sd.FileSystemConfig.RootPath == "" {
 					sd.FileSystemConfig.RootPath = "/data"
 				}
+				sd.FileSystemConfig.completeLayout("", "")
+				if sd.FileSystemConfig.IO != nil {
+					sd.FileSystemConfig.IO.complete()
+				}
+				if sd.FileSystemConfig.LongNames != nil {
+					sd.FileSystemConfig.LongNames.complete()
+				}
 			}
 		case STORAGE_TYPE_MOCK:
 			// default mockconfig
 			if sd.MockConfig == nil {
 				sd.MockConfig = &MockConfiguration{}
 			}
+		case STORAGE_TYPE_WIKI:
+			if sd.WikiConfig != nil {
+				if sd.WikiConfig.Mode == "" {
+					sd.WikiConfig.Mode = WIKI_MODE_WIKI
+				}
+				if sd.WikiConfig.SnippetVisibility == "" {
+					sd.WikiConfig.SnippetVisibility = "private"
+				}
+			}
+			// the filesystem config only determines the naming, the data is staged in memory
+			if sd.FileSystemConfig == nil {
+				sd.FileSystemConfig = &FileSystemConfiguration{}
+			}
+			sd.FileSystemConfig.InMemory = utils.Ptr(true)
+			sd.FileSystemConfig.RootPath = "/data"
+			sd.FileSystemConfig.completeLayout("", "")
+		case STORAGE_TYPE_PLUGIN:
+			if sd.PluginConfig != nil && sd.PluginConfig.Timeout == nil {
+				sd.PluginConfig.Timeout = &metav1.Duration{Duration: 30 * time.Second}
+			}
+		case STORAGE_TYPE_CLUSTER:
+			if sd.ClusterConfig != nil && sd.ClusterConfig.FieldManager == "" {
+				sd.ClusterConfig.FieldManager = "k8syncer"
+			}
+		case STORAGE_TYPE_KV:
+			if sd.KVConfig != nil && sd.KVConfig.Timeout == nil {
+				sd.KVConfig.Timeout = &metav1.Duration{Duration: 10 * time.Second}
+			}
+			// the filesystem config only determines the serialization, the data is staged in memory
+			if sd.FileSystemConfig == nil {
+				sd.FileSystemConfig = &FileSystemConfiguration{}
+			}
+			sd.FileSystemConfig.InMemory = utils.Ptr(true)
+			sd.FileSystemConfig.RootPath = "/data"
+			sd.FileSystemConfig.completeLayout("", "")
+		case STORAGE_TYPE_SFTP, STORAGE_TYPE_WEBDAV:
+			if sd.SFTPConfig != nil && sd.SFTPConfig.Timeout == nil {
+				sd.SFTPConfig.Timeout = &metav1.Duration{Duration: 30 * time.Second}
+			}
+			if sd.WebDAVConfig != nil && sd.WebDAVConfig.Timeout == nil {
+				sd.WebDAVConfig.Timeout = &metav1.Duration{Duration: 30 * time.Second}
+			}
+			// the root path is determined by the remote directory
+			if sd.FileSystemConfig == nil {
+				sd.FileSystemConfig = &FileSystemConfiguration{}
+			}
+			if sd.FileSystemConfig.InMemory == nil {
+				sd.FileSystemConfig.InMemory = utils.Ptr(false)
+			}
+			sd.FileSystemConfig.completeLayout("", "")
+			if sd.FileSystemConfig.LongNames != nil {
+				sd.FileSystemConfig.LongNames.complete()
+			}
+		case STORAGE_TYPE_KUBERNETES:
+			if sd.KubernetesConfig != nil {
+				if sd.KubernetesConfig.ObjectType == "" {
+					sd.KubernetesConfig.ObjectType = KUBERNETES_OBJECT_TYPE_CONFIGMAP
+				}
+				if sd.KubernetesConfig.Mode == "" {
+					sd.KubernetesConfig.Mode = KUBERNETES_MODE_OBJECT_PER_RESOURCE
+				}
+				if sd.KubernetesConfig.NamePrefix == "" {
+					sd.KubernetesConfig.NamePrefix = "k8syncer-"
+				}
+			}
+			// the filesystem config only determines the naming, the data is staged in memory
+			if sd.FileSystemConfig == nil {
+				sd.FileSystemConfig = &FileSystemConfiguration{}
+			}
+			sd.FileSystemConfig.InMemory = utils.Ptr(true)
+			sd.FileSystemConfig.RootPath = "/data"
+			sd.FileSystemConfig.completeLayout("", "")
 		}
 	}
 	return nil
 }
 
-// LoadConfig reads the configuration file from a given path and parses the data into a K8SyncerConfiguration
-func LoadConfig(path string) (*K8SyncerConfiguration, error) {
+// completeLayout defaults the layout and, for the 'argocd' layout, its configuration.
+// repoURL and revision are used as defaults for the generated applications, if not empty.
+func (fsc *FileSystemConfiguration) completeLayout(repoURL, revision string) {
+	if fsc.Layout == "" {
+		fsc.Layout = FILESYSTEM_LAYOUT_DEFAULT
+	}
+	if fsc.Layout != FILESYSTEM_LAYOUT_ARGOCD {
+		return
+	}
+	if fsc.ArgoCD == nil {
+		fsc.ArgoCD = &ArgoCDLayoutConfiguration{}
+	}
+	if fsc.ArgoCD.RepoURL == "" {
+		fsc.ArgoCD.RepoURL = repoURL
+	}
+	if fsc.ArgoCD.TargetRevision == "" {
+		fsc.ArgoCD.TargetRevision = revision
+		if revision == "" {
+			fsc.ArgoCD.TargetRevision = "HEAD"
+		}
+	}
+	if fsc.ArgoCD.RootApplicationName == "" {
+		fsc.ArgoCD.RootApplicationName = "k8syncer"
+	}
+	if fsc.ArgoCD.Namespace == "" {
+		fsc.ArgoCD.Namespace = "argocd"
+	}
+	if fsc.ArgoCD.Project == "" {
+		fsc.ArgoCD.Project = "default"
+	}
+	if fsc.ArgoCD.DestinationServer == "" {
+		fsc.ArgoCD.DestinationServer = "https://kubernetes.default.svc"
+	}
+}
+
+// complete sets the defaults for the filesystem IO configuration.
+func (io *FileSystemIOConfiguration) complete() {
+	if io.RetryInterval == nil {
+		io.RetryInterval = &metav1.Duration{Duration: 100 * time.Millisecond}
+	}
+}
+
+// complete sets the defaults for the long names configuration.
+func (ln *LongNamesConfiguration) complete() {
+	if ln.MaxLength == 0 {
+		ln.MaxLength = 255
+	}
+	if ln.Strategy == "" {
+		ln.Strategy = LONG_NAME_STRATEGY_TRUNCATE
+	}
+	ln.Strategy = LongNameStrategy(strings.ToLower(string(ln.Strategy)))
+}
+
+// complete sets the defaults for the background pull configuration.
+func (bp *GitBackgroundPullConfiguration) complete() {
+	if bp.Interval == nil {
+		bp.Interval = &metav1.Duration{Duration: time.Minute}
+	}
+	if bp.FreshnessWindow == nil {
+		bp.FreshnessWindow = &metav1.Duration{Duration: 2 * bp.Interval.Duration}
+	}
+}
+
+// complete sets the defaults for the git provider configuration, based on the given repository URL.
+func (pc *GitProviderConfiguration) complete(repoURL string) {
+	pc.Type = GitProviderType(strings.ToLower(string(pc.Type)))
+	host, repoPath := SplitRepositoryURL(repoURL)
+	if pc.URL == "" && host != "" {
+		pc.URL = "https://" + host
+	}
+	if pc.Repository == "" {
+		pc.Repository = repoPath
+	}
+	if pc.Private == nil {
+		pc.Private = utils.Ptr(true)
+	}
+}
+
+// SplitRepositoryURL returns the host and the path of the given git repository URL, without the '.git' suffix.
+// Besides URLs with a scheme, the scp-like syntax 'user@host:path' is supported. Both values are empty if the URL can't be parsed.
+func SplitRepositoryURL(repoURL string) (string, string) {
+	var host, repoPath string
+	if u, err := url.Parse(repoURL); err == nil && u.Scheme != "" && u.Host != "" {
+		host, repoPath = u.Hostname(), u.Path
+	} else if user, rest, found := strings.Cut(repoURL, "@"); found && !strings.Contains(user, "/") {
+		host, repoPath, _ = strings.Cut(rest, ":")
+	}
+	repoPath = strings.TrimSuffix(strings.Trim(repoPath, "/"), ".git")
+	if host == "" || repoPath == "" {
+		return "", ""
+	}
+	return host, repoPath
+}
+
+// complete sets the defaults for the secret value source.
+func (svs *SecretValueSource) complete() {
+	if svs.GCPSecretManager != nil {
+		if svs.GCPSecretManager.Version == "" {
+			svs.GCPSecretManager.Version = "latest"
+		}
+		if svs.GCPSecretManager.Endpoint == "" {
+			svs.GCPSecretManager.Endpoint = "https://secretmanager.googleapis.com"
+		}
+	}
+	if svs.RefreshInterval == nil {
+		svs.RefreshInterval = &metav1.Duration{Duration: 5 * time.Minute}
+	}
+}
+
+// complete sets the defaults for the vault configuration.
+func (vc *VaultConfiguration) complete() {
+	if vc.AuthMountPath == "" {
+		vc.AuthMountPath = "kubernetes"
+	}
+	if vc.ServiceAccountTokenFile == "" {
+		vc.ServiceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	}
+	if vc.Engine == "" {
+		vc.Engine = "secret"
+	}
+	if vc.EngineVersion == 0 {
+		vc.EngineVersion = 2
+	}
+	if vc.RefreshInterval == nil {
+		vc.RefreshInterval = &metav1.Duration{Duration: 5 * time.Minute}
+	}
+}
+
+// Hash returns a short hash over the configuration, which is part of the writer identity.
+// The writer identity configuration itself is excluded, so that instances which only differ in their instance name have the same hash.
+// The sync trigger, admission webhook, metrics, subPath pruning, and startup summary configurations are excluded too, as they don't influence what is written.
+func (cfg *K8SyncerConfiguration) Hash() (string, error) {
+	tmp := *cfg
+	tmp.WriterIdentity = nil
+	tmp.SyncTrigger = nil
+	tmp.ErrorLogs = nil
+	tmp.AdmissionWebhook = nil
+	tmp.Metrics = nil
+	tmp.SubPathPruning = nil
+	tmp.StartupSummary = nil
+	data, err := json.Marshal(&tmp)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling configuration: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12], nil
+}
+
+// LoadConfig reads the configuration file from a given path and parses the data into a K8SyncerConfiguration.
+// The file may contain YAML or JSON.
+// If strict is true, unknown or duplicate fields cause an error. Otherwise, they are ignored
+// and the returned warnings describe what would have caused strict decoding to fail.
+// Environment variable and file references in string values are resolved, see substituteValue.
+func LoadConfig(path string, strict bool) (*K8SyncerConfiguration, []string, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("unable to read config file: %w", err)
+		return nil, nil, fmt.Errorf("unable to read config file: %w", err)
 	}
 
+	var warnings []string
 	cfg := &K8SyncerConfiguration{}
-	err = yaml.Unmarshal(data, cfg)
+	strictErr := yaml.UnmarshalStrict(data, cfg)
+	if strictErr != nil {
+		if strict {
+			return nil, nil, fmt.Errorf("unable to parse config file: %w", strictErr)
+		}
+		cfg = &K8SyncerConfiguration{}
+		err = yaml.Unmarshal(data, cfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to parse config file: %w", err)
+		}
+		warnings = append(warnings, strictErr.Error())
+	}
+
+	if err := substituteValues(reflect.ValueOf(cfg), field.NewPath("")); err != nil {
+		return nil, nil, fmt.Errorf("unable to resolve references in config file: %w", err)
+	}
+
+	return cfg, warnings, nil
+}
+
+// envVarReference matches '${VAR}' references to environment variables, as well as escaped '$${VAR}' references.
+var envVarReference = regexp.MustCompile(`\$?\$\{([a-zA-Z_][a-zA-Z0-9_]*)\}`)
+
+// fileReferencePrefix marks string values of credential fields which are replaced by the content of the referenced file.
+const fileReferencePrefix = "file://"
+
+// fileReferenceFields contains the json names of the credential fields which may contain file references.
+// Other fields, e.g. git URLs, may legitimately start with 'file://' and are therefore never replaced by file contents.
+var fileReferenceFields = sets.New("password", "privateKey", "token", "secret", "signingKey")
+
+// substituteValues replaces references in all string values reachable from the given value.
+// See substituteValue for the supported references.
+func substituteValues(v reflect.Value, fldPath *field.Path) error {
+	return substituteValuesRec(v, fldPath, false)
+}
+
+func substituteValuesRec(v reflect.Value, fldPath *field.Path, resolveFiles bool) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return substituteValuesRec(v.Elem(), fldPath, resolveFiles)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				name = t.Field(i).Name
+			}
+			if err := substituteValuesRec(v.Field(i), fldPath.Child(name), fileReferenceFields.Has(name)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := substituteValuesRec(v.Index(i), fldPath.Index(i), resolveFiles); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			// map values are not addressable, so the value is copied, resolved, and written back
+			elem := reflect.New(iter.Value().Type()).Elem()
+			elem.Set(iter.Value())
+			if err := substituteValuesRec(elem, fldPath.Key(fmt.Sprint(iter.Key().Interface())), resolveFiles); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	case reflect.String:
+		res, err := substituteValue(v.String(), resolveFiles)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fldPath.String(), err)
+		}
+		v.SetString(res)
+	}
+	return nil
+}
+
+// substituteValue resolves the references in the given string.
+// All '${VAR}' references are replaced by the value of the environment variable 'VAR', which must be set.
+// '$${VAR}' escapes a reference, it is replaced by the literal '${VAR}'.
+// Afterwards, if resolveFiles is true and the value starts with 'file://', the whole value is replaced by the content
+// of the referenced file, without trailing line breaks. E.g. 'file:///etc/secret/password' is replaced by the content of '/etc/secret/password'.
+func substituteValue(value string, resolveFiles bool) (string, error) {
+	var err error
+	res := envVarReference.ReplaceAllStringFunc(value, func(ref string) string {
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
+		}
+		name := envVarReference.FindStringSubmatch(ref)[1]
+		envValue, ok := os.LookupEnv(name)
+		if !ok && err == nil {
+			err = fmt.Errorf("environment variable '%s' is not set", name)
+		}
+		return envValue
+	})
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse config file: %w", err)
+		return "", err
+	}
+	if !resolveFiles {
+		return res, nil
+	}
+	if path, ok := strings.CutPrefix(res, fileReferencePrefix); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("unable to read referenced file: %w", err)
+		}
+		res = strings.TrimRight(string(data), "\r\n")
+	}
+	return res, nil
+}
+
+// ClusterKey identifies the cluster which contains the resources of this sync config.
+// It is the kubeconfig path, 'shoot:<namespace>/<name>' for shoot clusters, and the empty string for the default cluster.
+func (sc *SyncConfig) ClusterKey() string {
+	if sc.Shoot != nil {
+		return sc.Shoot.ClusterKey()
+	}
+	return sc.Kubeconfig
+}
+
+// ClusterNameOrDefault returns the cluster name of this sync config, or the given global cluster name if it is not set.
+func (sc *SyncConfig) ClusterNameOrDefault(global string) string {
+	if sc.ClusterName != "" {
+		return sc.ClusterName
+	}
+	return global
+}
+
+// ClusterKey returns 'shoot:<namespace>/<name>', which identifies the shoot cluster.
+func (sr *ShootReference) ClusterKey() string {
+	return fmt.Sprintf("shoot:%s/%s", sr.Namespace, sr.Name)
+}
+
+// UserName returns the name of the user which should be impersonated.
+// For service accounts, this is 'system:serviceaccount:<namespace>:<name>'.
+func (ic *ImpersonationConfiguration) UserName() string {
+	if ic.ServiceAccount != "" {
+		ns, name, _ := strings.Cut(ic.ServiceAccount, "/")
+		return fmt.Sprintf("system:serviceaccount:%s:%s", ns, name)
 	}
+	return ic.User
+}
+
+// UsesUID returns true if the file naming requires the resource's UID.
+func (fn FileNaming) UsesUID() bool {
+	return fn == FILE_NAMING_UID || fn == FILE_NAMING_NAME_AND_UID
+}
 
-	return cfg, nil
+// StorageName returns the name under which a resource with the given name and UID is stored.
+// Returns an error if a value which is required for the file naming is empty.
+func (fn FileNaming) StorageName(name, uid string) (string, error) {
+	if fn.UsesUID() && uid == "" {
+		return "", fmt.Errorf("file naming '%s' requires the resource's UID, which is not known", string(fn))
+	}
+	if fn != FILE_NAMING_UID && name == "" {
+		return "", fmt.Errorf("file naming '%s' requires the resource's name, which is empty", string(fn))
+	}
+	switch fn {
+	case FILE_NAMING_UID:
+		return uid, nil
+	case FILE_NAMING_NAME_AND_UID:
+		return fmt.Sprintf("%s_%s", name, uid), nil
+	}
+	return name, nil
 }
 
 // IncludesPhase returns true if the verbosity includes the phase.
@@ -107,3 +748,159 @@ func (sv StateVerbosity) IncludesDetail() bool {
 	}
 	return true
 }
+
+// SubPathTemplateData contains the values which can be referenced in the subPath of a storage reference.
+type SubPathTemplateData struct {
+	// ClusterName is the cluster name of the sync config, which defaults to the one from the K8Syncer configuration.
+	ClusterName string
+	// Namespace is the namespace of the persisted resource.
+	// It is empty for cluster-scoped resources.
+	Namespace string
+	// Kind is the kind of the persisted resource, as specified in the sync config.
+	Kind string
+}
+
+// ParseSubPathTemplate parses the given subPath into a template.
+// Returns nil if the subPath does not contain any template actions, so it can be used as-is.
+func ParseSubPathTemplate(subPath string) (*template.Template, error) {
+	if !strings.Contains(subPath, "{{") {
+		return nil, nil
+	}
+	return template.New("subPath").Option("missingkey=error").Parse(subPath)
+}
+
+// RenderSubPath renders the given subPath template with the given data.
+// If the template is nil, the raw subPath is returned.
+func RenderSubPath(tmpl *template.Template, subPath string, data *SubPathTemplateData) (string, error) {
+	if tmpl == nil {
+		return subPath, nil
+	}
+	sb := &strings.Builder{}
+	if err := tmpl.Execute(sb, data); err != nil {
+		return "", fmt.Errorf("error rendering subPath template '%s': %w", subPath, err)
+	}
+	return sb.String(), nil
+}
+
+// CommitGroupTemplateData contains the values which can be referenced in the template of a git commit groups configuration.
+// Apart from Path, the values are taken from the resource stored in the changed file, they are empty if the file doesn't contain a resource.
+// For deleted files, the content of the file before the deletion is used.
+type CommitGroupTemplateData struct {
+	// Path is the path of the changed file, relative to the repository root.
+	Path string
+	// Name is the name of the resource.
+	Name string
+	// Namespace is the namespace of the resource.
+	// It is empty for cluster-scoped resources.
+	Namespace string
+	// Kind is the kind of the resource.
+	Kind string
+	// Labels contains the labels of the resource.
+	Labels map[string]string
+	// Annotations contains the annotations of the resource.
+	Annotations map[string]string
+}
+
+// ParseCommitGroupTemplate parses the given template of a git commit groups configuration.
+// Missing map keys, e.g. labels which are not set, are evaluated to the empty string.
+func ParseCommitGroupTemplate(tmpl string) (*template.Template, error) {
+	return template.New("commitGroups").Option("missingkey=zero").Parse(tmpl)
+}
+
+// RenderCommitGroup renders the given commit group template with the given data.
+func RenderCommitGroup(tmpl *template.Template, data *CommitGroupTemplateData) (string, error) {
+	sb := &strings.Builder{}
+	if err := tmpl.Execute(sb, data); err != nil {
+		return "", fmt.Errorf("error rendering commit group template: %w", err)
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// ParseWeekday parses the english name of a weekday or its three-letter abbreviation, ignoring the case.
+func ParseWeekday(day string) (time.Weekday, error) {
+	lower := strings.ToLower(day)
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		name := strings.ToLower(wd.String())
+		if lower == name || lower == name[:3] {
+			return wd, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("unknown weekday '%s'", day)
+}
+
+// parseTimeOfDay parses a time of day in the format 'HH:MM' and returns the hour and minute.
+func parseTimeOfDay(value string) (int, int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time of day '%s', expected format is 'HH:MM'", value)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// ActiveUntil returns the end of the occurrence of the maintenance window which contains the given time.
+// The second return value is false if the given time is not within the window.
+// Invalid windows, which are prevented by the config validation, are never active.
+func (mw *MaintenanceWindow) ActiveUntil(now time.Time) (time.Time, bool) {
+	loc, err := time.LoadLocation(mw.TimeZone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	startHour, startMinute, err := parseTimeOfDay(mw.Start)
+	if err != nil {
+		return time.Time{}, false
+	}
+	endHour, endMinute, err := parseTimeOfDay(mw.End)
+	if err != nil {
+		return time.Time{}, false
+	}
+	days := map[time.Weekday]bool{}
+	for _, day := range mw.Days {
+		wd, err := ParseWeekday(day)
+		if err != nil {
+			return time.Time{}, false
+		}
+		days[wd] = true
+	}
+	now = now.In(loc)
+	// windows which end on the next day might have started yesterday
+	for _, offset := range []int{0, -1} {
+		day := now.AddDate(0, 0, offset)
+		if len(days) > 0 && !days[day.Weekday()] {
+			continue
+		}
+		start := time.Date(day.Year(), day.Month(), day.Day(), startHour, startMinute, 0, 0, loc)
+		end := time.Date(day.Year(), day.Month(), day.Day(), endHour, endMinute, 0, 0, loc)
+		if !end.After(start) {
+			end = end.AddDate(0, 0, 1)
+		}
+		if !now.Before(start) && now.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ActiveMaintenanceWindowEnd returns the end of the maintenance windows of the sync config which contain the given time.
+// If multiple windows are active, the latest end is returned. The second return value is false if no window is active.
+func (sc *SyncConfig) ActiveMaintenanceWindowEnd(now time.Time) (time.Time, bool) {
+	var res time.Time
+	active := false
+	for _, mw := range sc.MaintenanceWindows {
+		if end, ok := mw.ActiveUntil(now); ok {
+			active = true
+			if end.After(res) {
+				res = end
+			}
+		}
+	}
+	return res, active
+}
+
+// SuspendedUntil returns whether syncing is suspended for the sync config at the given time, either via Suspend or by a maintenance window.
+// The returned time is the end of the active maintenance windows, it is zero if the sync config is suspended until further notice.
+func (sc *SyncConfig) SuspendedUntil(now time.Time) (time.Time, bool) {
+	if sc.Suspend {
+		return time.Time{}, true
+	}
+	return sc.ActiveMaintenanceWindowEnd(now)
+}
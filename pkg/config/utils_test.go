@@ -0,0 +1,246 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LoadConfig", func() {
+
+	var tmpDir string
+
+	BeforeEach(func() {
+		tmpDir = GinkgoT().TempDir()
+	})
+
+	writeConfig := func(data string) string {
+		path := filepath.Join(tmpDir, "config.yaml")
+		Expect(os.WriteFile(path, []byte(data), 0600)).To(Succeed())
+		return path
+	}
+
+	const validConfig = `
+clusterName: test
+syncConfigs:
+- id: dummy
+  resource:
+    version: v1
+    kind: Dummy
+  storageRefs:
+  - name: myStorage
+storageDefinitions:
+- name: myStorage
+  type: mock
+`
+
+	It("should load a valid config in strict mode without warnings", func() {
+		cfg, warnings, err := LoadConfig(writeConfig(validConfig), true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(warnings).To(BeEmpty())
+		Expect(cfg.ClusterName).To(Equal("test"))
+		Expect(cfg.SyncConfigs).To(HaveLen(1))
+		Expect(cfg.StorageDefinitions).To(HaveLen(1))
+	})
+
+	It("should load JSON configs", func() {
+		cfg, warnings, err := LoadConfig(writeConfig(`{"clusterName": "test", "syncConfigs": [{"id": "dummy"}]}`), true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(warnings).To(BeEmpty())
+		Expect(cfg.ClusterName).To(Equal("test"))
+		Expect(cfg.SyncConfigs).To(HaveLen(1))
+	})
+
+	It("should reject unknown fields in strict mode", func() {
+		_, _, err := LoadConfig(writeConfig(validConfig+"  filesystemConfg: {}\n"), true)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("filesystemConfg"))
+	})
+
+	It("should ignore unknown fields and return a warning in lenient mode", func() {
+		cfg, warnings, err := LoadConfig(writeConfig(validConfig+"  filesystemConfg: {}\n"), false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(warnings).To(ConsistOf(ContainSubstring("filesystemConfg")))
+		Expect(cfg.StorageDefinitions).To(HaveLen(1))
+		Expect(cfg.StorageDefinitions[0].FileSystemConfig).To(BeNil())
+	})
+
+	Context("Substitution", func() {
+
+		const gitConfig = `
+storageDefinitions:
+- name: myStorage
+  type: git
+  gitConfig:
+    url: "https://github.com/${TEST_GIT_ORG}/repo.git"
+    branch: master
+    auth:
+      type: username_password
+      username: "$${NOT_SUBSTITUTED}"
+      password: "%s"
+`
+
+		It("should replace environment variable references", func() {
+			GinkgoT().Setenv("TEST_GIT_ORG", "myorg")
+			GinkgoT().Setenv("TEST_GIT_PASSWORD", "secret")
+			cfg, _, err := LoadConfig(writeConfig(fmt.Sprintf(gitConfig, "${TEST_GIT_PASSWORD}")), true)
+			Expect(err).ToNot(HaveOccurred())
+			gitCfg := cfg.StorageDefinitions[0].GitConfig
+			Expect(gitCfg.URL).To(Equal("https://github.com/myorg/repo.git"))
+			Expect(gitCfg.Auth.Username).To(Equal("${NOT_SUBSTITUTED}"))
+			Expect(gitCfg.Auth.Password).To(Equal("secret"))
+		})
+
+		It("should replace file references with the file content", func() {
+			GinkgoT().Setenv("TEST_GIT_ORG", "myorg")
+			GinkgoT().Setenv("TEST_SECRET_DIR", tmpDir)
+			Expect(os.WriteFile(filepath.Join(tmpDir, "password"), []byte("secret\n"), 0600)).To(Succeed())
+			cfg, _, err := LoadConfig(writeConfig(fmt.Sprintf(gitConfig, "file://${TEST_SECRET_DIR}/password")), true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cfg.StorageDefinitions[0].GitConfig.Auth.Password).To(Equal("secret"))
+		})
+
+		It("should fail for unset environment variables and missing files", func() {
+			GinkgoT().Setenv("TEST_GIT_ORG", "myorg")
+			_, _, err := LoadConfig(writeConfig(fmt.Sprintf(gitConfig, "${TEST_UNSET_VARIABLE}")), true)
+			Expect(err).To(MatchError(ContainSubstring("storageDefinitions[0].gitConfig.auth.password: environment variable 'TEST_UNSET_VARIABLE' is not set")))
+
+			_, _, err = LoadConfig(writeConfig(fmt.Sprintf(gitConfig, "file://"+filepath.Join(tmpDir, "missing"))), true)
+			Expect(err).To(MatchError(ContainSubstring("storageDefinitions[0].gitConfig.auth.password: unable to read referenced file")))
+		})
+
+		It("should not replace file URLs in non-credential fields", func() {
+			repoDir := filepath.Join(tmpDir, "repo.git")
+			Expect(os.Mkdir(repoDir, 0700)).To(Succeed())
+			cfg, _, err := LoadConfig(writeConfig(`
+storageDefinitions:
+- name: myStorage
+  type: git
+  gitConfig:
+    url: "file://`+repoDir+`"
+    branch: master
+`), true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cfg.StorageDefinitions[0].GitConfig.URL).To(Equal("file://" + repoDir))
+		})
+
+		It("should replace references in map values", func() {
+			GinkgoT().Setenv("TEST_TARGET_NAMESPACE", "target")
+			GinkgoT().Setenv("TEST_FILE_EXTENSION", "json")
+			cfg, _, err := LoadConfig(writeConfig(`
+storageDefinitions:
+- name: myCluster
+  type: cluster
+  clusterConfig:
+    namespaceMapping:
+      source: "${TEST_TARGET_NAMESPACE}"
+- name: myFilesystem
+  type: filesystem
+  filesystemConfig:
+    kindOverrides:
+      configmap.v1:
+        fileExtension: "${TEST_FILE_EXTENSION}"
+`), true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cfg.StorageDefinitions[0].ClusterConfig.NamespaceMapping).To(HaveKeyWithValue("source", "target"))
+			Expect(cfg.StorageDefinitions[1].FileSystemConfig.KindOverrides).To(HaveKey("configmap.v1"))
+			Expect(*cfg.StorageDefinitions[1].FileSystemConfig.KindOverrides["configmap.v1"].FileExtension).To(Equal("json"))
+
+			_, _, err = LoadConfig(writeConfig(`
+storageDefinitions:
+- name: myCluster
+  type: cluster
+  clusterConfig:
+    namespaceMapping:
+      source: "${TEST_UNSET_VARIABLE}"
+`), true)
+			Expect(err).To(MatchError(ContainSubstring("storageDefinitions[0].clusterConfig.namespaceMapping[source]: environment variable 'TEST_UNSET_VARIABLE' is not set")))
+		})
+
+	})
+
+})
+
+var _ = Describe("MaintenanceWindow", func() {
+
+	It("should contain times between start and end", func() {
+		mw := &MaintenanceWindow{Start: "02:00", End: "04:30", TimeZone: "UTC"}
+		end, ok := mw.ActiveUntil(time.Date(2024, 3, 5, 3, 0, 0, 0, time.UTC))
+		Expect(ok).To(BeTrue())
+		Expect(end).To(BeTemporally("==", time.Date(2024, 3, 5, 4, 30, 0, 0, time.UTC)))
+		_, ok = mw.ActiveUntil(time.Date(2024, 3, 5, 4, 30, 0, 0, time.UTC))
+		Expect(ok).To(BeFalse())
+		_, ok = mw.ActiveUntil(time.Date(2024, 3, 5, 1, 59, 0, 0, time.UTC))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should end on the next day if the end is before the start", func() {
+		// 2024-03-08 is a Friday
+		mw := &MaintenanceWindow{Days: []string{"fri"}, Start: "22:00", End: "02:00", TimeZone: "UTC"}
+		end, ok := mw.ActiveUntil(time.Date(2024, 3, 9, 1, 0, 0, 0, time.UTC))
+		Expect(ok).To(BeTrue())
+		Expect(end).To(BeTemporally("==", time.Date(2024, 3, 9, 2, 0, 0, 0, time.UTC)))
+		_, ok = mw.ActiveUntil(time.Date(2024, 3, 9, 23, 0, 0, 0, time.UTC))
+		Expect(ok).To(BeFalse(), "the window must only start on fridays")
+		_, ok = mw.ActiveUntil(time.Date(2024, 3, 8, 23, 0, 0, 0, time.UTC))
+		Expect(ok).To(BeTrue())
+	})
+
+	It("should interpret start and end in the configured time zone", func() {
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		Expect(err).ToNot(HaveOccurred())
+		mw := &MaintenanceWindow{Days: []string{"Saturday", "Sun"}, Start: "01:00", End: "03:00", TimeZone: "Europe/Berlin"}
+		// 2024-03-09 is a Saturday, 01:00 in Berlin is 00:00 UTC
+		end, ok := mw.ActiveUntil(time.Date(2024, 3, 9, 0, 30, 0, 0, time.UTC))
+		Expect(ok).To(BeTrue())
+		Expect(end).To(BeTemporally("==", time.Date(2024, 3, 9, 3, 0, 0, 0, berlin)))
+		_, ok = mw.ActiveUntil(time.Date(2024, 3, 9, 2, 30, 0, 0, time.UTC))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should return the latest end of all active windows of a sync config", func() {
+		sc := &SyncConfig{
+			MaintenanceWindows: []*MaintenanceWindow{
+				{Start: "02:00", End: "04:00", TimeZone: "UTC"},
+				{Start: "03:00", End: "05:00", TimeZone: "UTC"},
+			},
+		}
+		end, ok := sc.SuspendedUntil(time.Date(2024, 3, 5, 3, 30, 0, 0, time.UTC))
+		Expect(ok).To(BeTrue())
+		Expect(end).To(BeTemporally("==", time.Date(2024, 3, 5, 5, 0, 0, 0, time.UTC)))
+		_, ok = sc.SuspendedUntil(time.Date(2024, 3, 5, 6, 0, 0, 0, time.UTC))
+		Expect(ok).To(BeFalse())
+
+		sc.Suspend = true
+		end, ok = sc.SuspendedUntil(time.Date(2024, 3, 5, 6, 0, 0, 0, time.UTC))
+		Expect(ok).To(BeTrue())
+		Expect(end.IsZero()).To(BeTrue())
+	})
+
+})
+
+var _ = Describe("SplitRepositoryURL", func() {
+
+	It("should return host and path of repository URLs", func() {
+		for repoURL, expected := range map[string][]string{
+			"https://github.com/gardener/k8syncer.git":        {"github.com", "gardener/k8syncer"},
+			"https://gitlab.example.com/group/sub/project/":   {"gitlab.example.com", "group/sub/project"},
+			"ssh://git@gitea.example.com:2222/owner/repo.git": {"gitea.example.com", "owner/repo"},
+			"git@github.com:gardener/k8syncer.git":            {"github.com", "gardener/k8syncer"},
+			"/tmp/remote":                                     {"", ""},
+			"https://github.com":                              {"", ""},
+		} {
+			host, repoPath := SplitRepositoryURL(repoURL)
+			Expect([]string{host, repoPath}).To(Equal(expected), "repository URL %s", repoURL)
+		}
+	})
+
+})
@@ -6,12 +6,23 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/gardener/k8syncer/pkg/utils"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
 )
 
 // only letters, digits, and '-' and '_'
@@ -19,13 +30,29 @@ import (
 // '-' and '_' must always be followed by a letter or digit
 var nameRegex = regexp.MustCompile("^[a-zA-Z]([-_]?[a-zA-Z0-9])*$")
 
+// gvkStringRegex matches GVK strings as they are used in file names, see utils.GVKToString.
+var gvkStringRegex = regexp.MustCompile(`^[a-z0-9]+\.[a-z0-9]+(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+// invalidBranchNameChars contains the characters which are not allowed in git branch names.
+const invalidBranchNameChars = " \t\n~^:?*[\\"
+
+// watchedResource identifies a resource in one of the clusters, see SyncConfig.ClusterKey.
+type watchedResource struct {
+	cluster string
+	gvk     schema.GroupVersionKind
+}
+
 type validator struct {
 	storageDefs           map[string]*StorageDefinition
 	sharedHostFsBasePaths sets.Set[string]
+	subPathTemplateData   *SubPathTemplateData
+	clusterName           string
 }
 
 func newValidator() *validator {
 	return &validator{
+		// subPathTemplateData contains the static values which are used to render templated subPaths of storage references
+		subPathTemplateData: &SubPathTemplateData{},
 		// storageDefs contains a mapping from name to the storage definition
 		// this is helpful for validating the storage references in the sync configs
 		storageDefs: map[string]*StorageDefinition{},
@@ -44,8 +71,222 @@ func Validate(cfg *K8SyncerConfiguration) field.ErrorList {
 	}
 
 	v := newValidator()
+	v.clusterName = cfg.ClusterName
+	v.subPathTemplateData.ClusterName = cfg.ClusterName
 	allErrs = append(allErrs, v.validateStorageDefinitions(cfg.StorageDefinitions, field.NewPath("storageDefinitions"))...)
 	allErrs = append(allErrs, v.validateSyncConfigs(cfg.SyncConfigs, field.NewPath("syncConfigs"))...)
+	allErrs = append(allErrs, v.validateNamespacePruningConfiguration(cfg.NamespacePruning, field.NewPath("namespacePruning"))...)
+	allErrs = append(allErrs, validateClientRateLimitConfiguration(cfg.ClientRateLimit, field.NewPath("clientRateLimit"))...)
+	allErrs = append(allErrs, validateWriterIdentityConfiguration(cfg.WriterIdentity, field.NewPath("writerIdentity"))...)
+	allErrs = append(allErrs, validateSyncTriggerConfiguration(cfg.SyncTrigger, field.NewPath("syncTrigger"))...)
+	allErrs = append(allErrs, validateErrorLogConfiguration(cfg.ErrorLogs, field.NewPath("errorLogs"))...)
+	allErrs = append(allErrs, validateAdmissionWebhookConfiguration(cfg.AdmissionWebhook, field.NewPath("admissionWebhook"))...)
+	allErrs = append(allErrs, validateMetricsConfiguration(cfg.Metrics, field.NewPath("metrics"))...)
+	allErrs = append(allErrs, validateSubPathPruningConfiguration(cfg.SubPathPruning, field.NewPath("subPathPruning"))...)
+	allErrs = append(allErrs, validateStartupSummaryConfiguration(cfg.StartupSummary, field.NewPath("startupSummary"))...)
+
+	return allErrs
+}
+
+func validateWriterIdentityConfiguration(wiCfg *WriterIdentityConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if wiCfg == nil {
+		return allErrs
+	}
+
+	if wiCfg.InstanceName == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("instanceName"), "instanceName must not be empty"))
+	}
+
+	return allErrs
+}
+
+func validateSyncTriggerConfiguration(stCfg *SyncTriggerConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if stCfg == nil {
+		return allErrs
+	}
+
+	if (stCfg.Token == "") == (stCfg.TokenFile == "") {
+		allErrs = append(allErrs, field.Invalid(fldPath, "<redacted>", "exactly one of 'token' and 'tokenFile' must be set"))
+	}
+
+	return allErrs
+}
+
+func validateErrorLogConfiguration(elCfg *ErrorLogConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if elCfg == nil {
+		return allErrs
+	}
+
+	if elCfg.RepeatInterval == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("repeatInterval"), "repeat interval is required, but it should have been defaulted, check coding"))
+	} else if elCfg.RepeatInterval.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("repeatInterval"), elCfg.RepeatInterval.Duration.String(), "repeat interval must not be negative"))
+	}
+
+	return allErrs
+}
+
+func validateAdmissionWebhookConfiguration(awCfg *AdmissionWebhookConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if awCfg == nil {
+		return allErrs
+	}
+
+	if awCfg.Port == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("port"), "port is required, but it should have been defaulted, check coding"))
+	} else if awCfg.Port < 0 || awCfg.Port > 65535 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("port"), awCfg.Port, "port must be between 1 and 65535"))
+	}
+	if awCfg.CertDir == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("certDir"), "certDir must not be empty"))
+	}
+	for idx, user := range awCfg.AllowedUsers {
+		if user == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("allowedUsers").Index(idx), user, "user name must not be empty"))
+		}
+	}
+	for idx, group := range awCfg.AllowedGroups {
+		if group == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("allowedGroups").Index(idx), group, "group name must not be empty"))
+		}
+	}
+
+	return allErrs
+}
+
+func validateMetricsConfiguration(mCfg *MetricsConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if mCfg == nil {
+		return allErrs
+	}
+
+	if se := mCfg.SyncErrors; se != nil {
+		sePath := fldPath.Child("syncErrors")
+		if len(se.Labels) == 0 {
+			allErrs = append(allErrs, field.Required(sePath.Child("labels"), "labels are required, but they should have been defaulted, check coding"))
+		}
+		labels := sets.New[SyncErrorMetricLabel]()
+		for idx, l := range se.Labels {
+			curPath := sePath.Child("labels").Index(idx)
+			switch l {
+			case SYNC_ERROR_METRIC_LABEL_SYNC_CONFIG, SYNC_ERROR_METRIC_LABEL_GVK, SYNC_ERROR_METRIC_LABEL_NAMESPACE, SYNC_ERROR_METRIC_LABEL_NAME:
+			default:
+				allErrs = append(allErrs, field.NotSupported(curPath, string(l), []string{string(SYNC_ERROR_METRIC_LABEL_SYNC_CONFIG), string(SYNC_ERROR_METRIC_LABEL_GVK), string(SYNC_ERROR_METRIC_LABEL_NAMESPACE), string(SYNC_ERROR_METRIC_LABEL_NAME)}))
+				continue
+			}
+			if labels.Has(l) {
+				allErrs = append(allErrs, field.Duplicate(curPath, string(l)))
+			}
+			labels.Insert(l)
+		}
+		switch se.Aggregation {
+		case METRIC_AGGREGATION_MAX, METRIC_AGGREGATION_SUM, METRIC_AGGREGATION_COUNT:
+		case "":
+			allErrs = append(allErrs, field.Required(sePath.Child("aggregation"), "aggregation is required, but it should have been defaulted, check coding"))
+		default:
+			allErrs = append(allErrs, field.NotSupported(sePath.Child("aggregation"), string(se.Aggregation), []string{string(METRIC_AGGREGATION_MAX), string(METRIC_AGGREGATION_SUM), string(METRIC_AGGREGATION_COUNT)}))
+		}
+		if se.MaxNamespaces < 0 {
+			allErrs = append(allErrs, field.Invalid(sePath.Child("maxNamespaces"), se.MaxNamespaces, "maxNamespaces must not be negative"))
+		} else if se.MaxNamespaces > 0 && !labels.Has(SYNC_ERROR_METRIC_LABEL_NAMESPACE) {
+			allErrs = append(allErrs, field.Forbidden(sePath.Child("maxNamespaces"), fmt.Sprintf("maxNamespaces must not be set if the labels don't contain '%s'", string(SYNC_ERROR_METRIC_LABEL_NAMESPACE))))
+		}
+	}
+
+	return allErrs
+}
+
+func validateSubPathPruningConfiguration(sppCfg *SubPathPruningConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if sppCfg == nil {
+		return allErrs
+	}
+
+	if sppCfg.ConfigMapName == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("configMapName"), "name is required, but it should have been defaulted, check coding"))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(sppCfg.ConfigMapName) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("configMapName"), sppCfg.ConfigMapName, msg))
+		}
+	}
+	if sppCfg.ConfigMapNamespace == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("configMapNamespace"), "namespace is required, but it should have been defaulted, check coding"))
+	} else {
+		for _, msg := range validation.IsDNS1123Label(sppCfg.ConfigMapNamespace) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("configMapNamespace"), sppCfg.ConfigMapNamespace, msg))
+		}
+	}
+
+	return allErrs
+}
+
+func validateStartupSummaryConfiguration(ssCfg *StartupSummaryConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if ssCfg == nil {
+		return allErrs
+	}
+
+	if ssCfg.ConfigMapName == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("configMapName"), "name is required, but it should have been defaulted, check coding"))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(ssCfg.ConfigMapName) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("configMapName"), ssCfg.ConfigMapName, msg))
+		}
+	}
+	if ssCfg.ConfigMapNamespace == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("configMapNamespace"), "namespace is required, but it should have been defaulted, check coding"))
+	} else {
+		for _, msg := range validation.IsDNS1123Label(ssCfg.ConfigMapNamespace) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("configMapNamespace"), ssCfg.ConfigMapNamespace, msg))
+		}
+	}
+
+	return allErrs
+}
+
+func validateClientRateLimitConfiguration(rlCfg *ClientRateLimitConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if rlCfg == nil {
+		return allErrs
+	}
+
+	if rlCfg.QPS < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("qps"), rlCfg.QPS, "qps must not be negative"))
+	}
+	if rlCfg.Burst < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("burst"), rlCfg.Burst, "burst must not be negative"))
+	}
+
+	return allErrs
+}
+
+func (v *validator) validateNamespacePruningConfiguration(npCfg *NamespacePruningConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if npCfg == nil {
+		return allErrs
+	}
+
+	switch npCfg.Mode {
+	case NAMESPACE_PRUNING_MODE_DELETE:
+	case NAMESPACE_PRUNING_MODE_ARCHIVE:
+		if npCfg.ArchiveSubPath == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("archiveSubPath"), fmt.Sprintf("archive subPath is required for mode '%s'", string(npCfg.Mode))))
+		}
+	case "":
+		allErrs = append(allErrs, field.Required(fldPath.Child("mode"), "mode is required, but it should have been defaulted, check coding"))
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("mode"), string(npCfg.Mode), []string{string(NAMESPACE_PRUNING_MODE_DELETE), string(NAMESPACE_PRUNING_MODE_ARCHIVE)}))
+	}
+	// the validator's template data contains the values of the last validated sync config, reset them
+	v.subPathTemplateData.ClusterName = v.clusterName
+	v.subPathTemplateData.Namespace = ""
+	v.subPathTemplateData.Kind = ""
+	if _, err := v.renderSubPath(npCfg.ArchiveSubPath); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("archiveSubPath"), npCfg.ArchiveSubPath, fmt.Sprintf("invalid subPath template: %s", err.Error())))
+	}
 
 	return allErrs
 }
@@ -59,12 +300,47 @@ func (v *validator) validateSyncConfigs(syncConfigs []*SyncConfig, fldPath *fiel
 	}
 
 	// if there are multiple sync configs configured for the same resource, the same namespace and the same storages, this could cause write conflicts
-	// to detect these configurations, synced resource GroupVersionKinds are mapped to namespaces (in which they are watched) and these are mapped to storage references
-	avoidSyncConflicts := map[schema.GroupVersionKind]map[string]sets.Set[string]{}
+	// to detect these configurations, synced resource GroupVersionKinds are mapped to namespaces (in which they are watched) and these are mapped to storage references,
+	// which are mapped to the ID of the sync config referencing them
+	// cluster-scoped resources and namespaced resources which are watched in all namespaces are mapped to the empty namespace
+	avoidSyncConflicts := map[schema.GroupVersionKind]map[string]map[string]string{}
+	// the first sync config which specifies the scope of a resource, the scope is the same for all versions of the resource
+	resourceScopes := map[schema.GroupKind]*SyncConfig{}
+	for _, sc := range syncConfigs {
+		if sc.Resource == nil || sc.Resource.Scope == "" {
+			continue
+		}
+		gk := schema.GroupKind{Group: sc.Resource.Group, Kind: sc.Resource.Kind}
+		if _, ok := resourceScopes[gk]; !ok {
+			resourceScopes[gk] = sc
+		}
+	}
+	// sync configs which watch the same resource in the same cluster share an informer, which can only have one field selector
+	fieldSelectors := map[watchedResource]string{}
 	syncConfigIDs := sets.New[string]()
+	// sync configs for the same shoot share its cluster configuration
+	shootRefs := map[string]*ShootReference{}
+	// sync configs for the same cluster must identify it by the same name
+	clusterNames := map[string]string{}
 	for idx, sc := range syncConfigs {
 		curPath := fldPath.Index(idx)
 
+		if clusterKey := sc.ClusterKey(); clusterKey != "" {
+			if existing, ok := clusterNames[clusterKey]; ok && existing != sc.ClusterName {
+				allErrs = append(allErrs, field.Invalid(curPath.Child("clusterName"), sc.ClusterName, fmt.Sprintf("sync configs for the same cluster must specify the same cluster name, another one specifies '%s'", existing)))
+			} else if !ok {
+				clusterNames[clusterKey] = sc.ClusterName
+			}
+		}
+
+		if sc.Shoot != nil {
+			if existing, ok := shootRefs[sc.Shoot.ClusterKey()]; ok && !reflect.DeepEqual(existing, sc.Shoot) {
+				allErrs = append(allErrs, field.Invalid(curPath.Child("shoot"), sc.Shoot.ClusterKey(), "sync configs which reference the same shoot share its cluster configuration and must reference it identically"))
+			} else if !ok {
+				shootRefs[sc.Shoot.ClusterKey()] = sc.Shoot
+			}
+		}
+
 		// validate that IDs are unique
 		if syncConfigIDs.Has(sc.ID) {
 			allErrs = append(allErrs, field.Duplicate(curPath.Child("id"), sc.ID))
@@ -81,39 +357,38 @@ func (v *validator) validateSyncConfigs(syncConfigs []*SyncConfig, fldPath *fiel
 				srNames = append(srNames, elem.Name)
 			}
 			gvk := schema.GroupVersionKind{Group: sc.Resource.Group, Version: sc.Resource.Version, Kind: sc.Resource.Kind}
-			syncedNamespaces, ok := avoidSyncConflicts[gvk]
-			if ok {
-				allNs, allOk := syncedNamespaces[""]
-				var ns sets.Set[string]
-				nsOk := false
-				if sc.Resource.Namespace != "" {
-					ns, nsOk = syncedNamespaces[sc.Resource.Namespace]
-				}
-				if allOk {
-					// there is another sync defined for the same resource across all namespaces => storage references must be disjunct
-					if allNs.HasAny(srNames...) {
-						allErrs = append(allErrs, field.Forbidden(curPath, "conflicting sync (same resource, all namespaces) with overlapping storage references found"))
-					}
+			var scope ResourceScope
+			if scopeConfig, ok := resourceScopes[gvk.GroupKind()]; ok {
+				scope = scopeConfig.Resource.Scope
+				if sc.Resource.Scope != "" && sc.Resource.Scope != scope {
+					allErrs = append(allErrs, field.Invalid(curPath.Child("resource", "scope"), sc.Resource.Scope, fmt.Sprintf("all sync configs for the same resource must specify the same scope, but sync config '%s' specifies '%s'", scopeConfig.ID, scope)))
 				}
-				if nsOk {
-					// there is another sync defined for the same resource in the same namespace => storage references must be disjunct
-					for _, sr := range sc.StorageRefs {
-						if sr == nil {
-							continue
-						}
-						if ns.Has(sr.Name) {
-							allErrs = append(allErrs, field.Forbidden(curPath, "conflicting sync (same resource, same namespace) with overlapping storage references found"))
-							break
-						}
-					}
+			}
+			namespace := sc.Resource.Namespace
+			if scope == RESOURCE_SCOPE_CLUSTER {
+				namespace = ""
+			}
+			if _, ok := avoidSyncConflicts[gvk]; !ok {
+				avoidSyncConflicts[gvk] = map[string]map[string]string{}
+			}
+			for _, conflict := range syncConflicts(avoidSyncConflicts[gvk], namespace, scope, srNames) {
+				allErrs = append(allErrs, field.Forbidden(curPath, conflict))
+			}
+			if _, ok := avoidSyncConflicts[gvk][namespace]; !ok {
+				avoidSyncConflicts[gvk][namespace] = map[string]string{}
+			}
+			for _, srName := range srNames {
+				if _, ok := avoidSyncConflicts[gvk][namespace][srName]; !ok {
+					avoidSyncConflicts[gvk][namespace][srName] = sc.ID
 				}
-			} else {
-				avoidSyncConflicts[gvk] = map[string]sets.Set[string]{}
 			}
-			if _, ok := avoidSyncConflicts[gvk][sc.Resource.Namespace]; !ok {
-				avoidSyncConflicts[gvk][sc.Resource.Namespace] = sets.New[string]()
+
+			wr := watchedResource{cluster: sc.ClusterKey(), gvk: gvk}
+			if selector, ok := fieldSelectors[wr]; ok && selector != sc.Resource.FieldSelector {
+				allErrs = append(allErrs, field.Invalid(curPath.Child("resource", "fieldSelector"), sc.Resource.FieldSelector, fmt.Sprintf("sync configs which watch the same resource in the same cluster share a cache and must have the same field selector, but another one uses '%s'", selector)))
+			} else if !ok {
+				fieldSelectors[wr] = sc.Resource.FieldSelector
 			}
-			avoidSyncConflicts[gvk][sc.Resource.Namespace].Insert(srNames...)
 		}
 
 		// validate syncConfig
@@ -160,15 +435,444 @@ func (v *validator) validateStorageDefinition(sd *StorageDefinition, fldPath *fi
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), sd.Name, fmt.Sprintf("name must match regex %s", nameRegex.String())))
 	}
 
+	if sd.Cache != nil && sd.Cache.MaxEntries < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("cache", "maxEntries"), sd.Cache.MaxEntries, "maximum number of cache entries must not be negative"))
+	}
+
+	if cb := sd.CircuitBreaker; cb != nil {
+		cbPath := fldPath.Child("circuitBreaker")
+		if cb.FailureThreshold <= 0 {
+			allErrs = append(allErrs, field.Invalid(cbPath.Child("failureThreshold"), cb.FailureThreshold, "failure threshold must be greater than 0"))
+		}
+		if cb.ProbeInterval == nil {
+			allErrs = append(allErrs, field.Required(cbPath.Child("probeInterval"), "probe interval is required, but it should have been defaulted, check coding"))
+		} else if cb.ProbeInterval.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(cbPath.Child("probeInterval"), cb.ProbeInterval.Duration.String(), "probe interval must be greater than 0"))
+		}
+	}
+
+	switch sd.OwnershipConflictPolicy {
+	case "", OWNERSHIP_CONFLICT_POLICY_REJECT, OWNERSHIP_CONFLICT_POLICY_WARN:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("ownershipConflictPolicy"), string(sd.OwnershipConflictPolicy), []string{string(OWNERSHIP_CONFLICT_POLICY_REJECT), string(OWNERSHIP_CONFLICT_POLICY_WARN)}))
+	}
+
 	switch sd.Type {
 	case STORAGE_TYPE_FILESYSTEM:
 		allErrs = append(allErrs, v.validateFileSystemConfig(sd.FileSystemConfig, fldPath.Child("filesystemConfig"))...)
 	case STORAGE_TYPE_GIT:
 		allErrs = append(allErrs, v.validateGitRepoConfig(sd.GitConfig, fldPath.Child("gitConfig"), gitRepoURLs)...)
+		if sd.GitConfig != nil && sd.GitConfig.GitBackend == GIT_BACKEND_CLI {
+			allErrs = append(allErrs, v.validateGitCLIBackend(sd, fldPath)...)
+		}
+		if sd.FileSystemConfig != nil {
+			allErrs = append(allErrs, v.validateFileSystemLayout(sd.FileSystemConfig, fldPath.Child("filesystemConfig"))...)
+			allErrs = append(allErrs, v.validateCorruptDataPolicy(sd.FileSystemConfig.OnCorruptData, fldPath.Child("filesystemConfig", "onCorruptData"))...)
+			allErrs = append(allErrs, v.validateFileSystemIO(sd.FileSystemConfig, fldPath.Child("filesystemConfig", "io"))...)
+			allErrs = append(allErrs, v.validateLongNames(sd.FileSystemConfig.LongNames, fldPath.Child("filesystemConfig", "longNames"))...)
+			if sd.GitConfig != nil && sd.GitConfig.NamespaceBranches != nil && sd.FileSystemConfig.Layout == FILESYSTEM_LAYOUT_ARGOCD {
+				allErrs = append(allErrs, field.Forbidden(fldPath.Child("gitConfig", "namespaceBranches"), fmt.Sprintf("namespace branches are not supported for layout '%s'", string(FILESYSTEM_LAYOUT_ARGOCD))))
+			}
+		}
 	case STORAGE_TYPE_MOCK:
-		// nothing to do
+		if sd.MockConfig != nil {
+			allErrs = append(allErrs, v.validateMockConfig(sd.MockConfig, fldPath.Child("mockConfig"))...)
+		}
+	case STORAGE_TYPE_WIKI:
+		allErrs = append(allErrs, v.validateWikiConfig(sd.WikiConfig, fldPath.Child("wikiConfig"))...)
+		if sd.FileSystemConfig != nil {
+			allErrs = append(allErrs, v.validateStagingFileSystemConfig(sd.FileSystemConfig, sd.Type, fldPath.Child("filesystemConfig"))...)
+		}
+	case STORAGE_TYPE_PLUGIN:
+		allErrs = append(allErrs, v.validatePluginConfig(sd.PluginConfig, fldPath.Child("pluginConfig"))...)
+	case STORAGE_TYPE_KUBERNETES:
+		allErrs = append(allErrs, v.validateKubernetesConfig(sd.KubernetesConfig, fldPath.Child("kubernetesConfig"))...)
+		if sd.FileSystemConfig != nil {
+			allErrs = append(allErrs, v.validateStagingFileSystemConfig(sd.FileSystemConfig, sd.Type, fldPath.Child("filesystemConfig"))...)
+		}
+	case STORAGE_TYPE_CLUSTER:
+		allErrs = append(allErrs, validateClusterStorageConfig(sd.ClusterConfig, fldPath.Child("clusterConfig"))...)
+		if sd.FileSystemConfig != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("filesystemConfig"), fmt.Sprintf("filesystem config is not evaluated for storage type '%s'", string(sd.Type))))
+		}
+	case STORAGE_TYPE_KV:
+		allErrs = append(allErrs, validateKVConfig(sd.KVConfig, fldPath.Child("kvConfig"))...)
+		if sd.FileSystemConfig != nil {
+			allErrs = append(allErrs, v.validateStagingFileSystemConfig(sd.FileSystemConfig, sd.Type, fldPath.Child("filesystemConfig"))...)
+		}
+	case STORAGE_TYPE_SFTP:
+		allErrs = append(allErrs, validateSFTPConfig(sd.SFTPConfig, fldPath.Child("sftpConfig"))...)
+		if sd.FileSystemConfig != nil {
+			allErrs = append(allErrs, v.validateRemoteFileSystemConfig(sd.FileSystemConfig, sd.Type, fldPath.Child("filesystemConfig"))...)
+		}
+	case STORAGE_TYPE_WEBDAV:
+		allErrs = append(allErrs, validateWebDAVConfig(sd.WebDAVConfig, fldPath.Child("webdavConfig"))...)
+		if sd.FileSystemConfig != nil {
+			allErrs = append(allErrs, v.validateRemoteFileSystemConfig(sd.FileSystemConfig, sd.Type, fldPath.Child("filesystemConfig"))...)
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("type"), sd.Type, []string{string(STORAGE_TYPE_FILESYSTEM), string(STORAGE_TYPE_GIT), string(STORAGE_TYPE_WIKI), string(STORAGE_TYPE_PLUGIN), string(STORAGE_TYPE_KUBERNETES), string(STORAGE_TYPE_CLUSTER), string(STORAGE_TYPE_KV), string(STORAGE_TYPE_SFTP), string(STORAGE_TYPE_WEBDAV)}))
+	}
+
+	return allErrs
+}
+
+func (v *validator) validateWikiConfig(wikiCfg *WikiConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if wikiCfg == nil {
+		allErrs = append(allErrs, field.Required(fldPath, "wiki config must not be empty"))
+		return allErrs
+	}
+
+	switch wikiCfg.Provider {
+	case WIKI_PROVIDER_GITEA, WIKI_PROVIDER_GITLAB:
+	case "":
+		allErrs = append(allErrs, field.Required(fldPath.Child("provider"), "provider must not be empty"))
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("provider"), string(wikiCfg.Provider), []string{string(WIKI_PROVIDER_GITEA), string(WIKI_PROVIDER_GITLAB)}))
+	}
+
+	switch wikiCfg.Mode {
+	case "", WIKI_MODE_WIKI:
+	case WIKI_MODE_SNIPPETS:
+		if wikiCfg.Provider == WIKI_PROVIDER_GITEA {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("mode"), fmt.Sprintf("mode '%s' is not supported by provider '%s'", string(WIKI_MODE_SNIPPETS), string(WIKI_PROVIDER_GITEA))))
+		}
+		switch wikiCfg.SnippetVisibility {
+		case "", "private", "internal", "public":
+		default:
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("snippetVisibility"), wikiCfg.SnippetVisibility, []string{"private", "internal", "public"}))
+		}
 	default:
-		allErrs = append(allErrs, field.NotSupported(fldPath.Child("type"), sd.Type, []string{string(STORAGE_TYPE_FILESYSTEM), string(STORAGE_TYPE_GIT)}))
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("mode"), string(wikiCfg.Mode), []string{string(WIKI_MODE_WIKI), string(WIKI_MODE_SNIPPETS)}))
+	}
+
+	if wikiCfg.URL == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("url"), "url must not be empty"))
+	} else if u, err := url.Parse(wikiCfg.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("url"), wikiCfg.URL, "url must be an absolute http or https URL"))
+	}
+
+	if wikiCfg.Project == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("project"), "project must not be empty"))
+	} else if wikiCfg.Provider == WIKI_PROVIDER_GITEA && len(strings.Split(wikiCfg.Project, "/")) != 2 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("project"), wikiCfg.Project, "project must have the format '<owner>/<repository>' for provider 'gitea'"))
+	}
+
+	if wikiCfg.Token == "" && wikiCfg.TokenFile == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("token"), "one of token and tokenFile must be set"))
+	} else if wikiCfg.Token != "" && wikiCfg.TokenFile != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("tokenFile"), "only one of token and tokenFile must be set"))
+	}
+
+	return allErrs
+}
+
+func (v *validator) validatePluginConfig(pluginCfg *PluginConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if pluginCfg == nil {
+		allErrs = append(allErrs, field.Required(fldPath, "plugin config must not be empty"))
+		return allErrs
+	}
+
+	if pluginCfg.Address == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("address"), "address must not be empty"))
+	} else if socket, ok := strings.CutPrefix(pluginCfg.Address, "unix://"); ok {
+		if !filepath.IsAbs(socket) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("address"), pluginCfg.Address, "path of unix socket must be absolute"))
+		}
+		if pluginCfg.TLS != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("tls"), "tls must not be set for unix sockets"))
+		}
+	} else if host, port, err := net.SplitHostPort(pluginCfg.Address); err != nil || host == "" || port == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("address"), pluginCfg.Address, "address must have the format 'unix://<path>' or '<host>:<port>'"))
+	}
+
+	if pluginCfg.Timeout != nil && pluginCfg.Timeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("timeout"), pluginCfg.Timeout.Duration.String(), "timeout must be positive"))
+	}
+
+	if tlsCfg := pluginCfg.TLS; tlsCfg != nil && (tlsCfg.CertFile == "") != (tlsCfg.KeyFile == "") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("tls"), fmt.Sprintf("certFile: '%s', keyFile: '%s'", tlsCfg.CertFile, tlsCfg.KeyFile), "either both or none of certFile and keyFile must be set"))
+	}
+
+	return allErrs
+}
+
+func (v *validator) validateKubernetesConfig(kubeCfg *KubernetesConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if kubeCfg == nil {
+		allErrs = append(allErrs, field.Required(fldPath, "kubernetes config must not be empty"))
+		return allErrs
+	}
+
+	if kubeCfg.Namespace == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("namespace"), "namespace must not be empty"))
+	} else {
+		for _, msg := range validation.IsDNS1123Label(kubeCfg.Namespace) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("namespace"), kubeCfg.Namespace, msg))
+		}
+	}
+
+	switch kubeCfg.ObjectType {
+	case "", KUBERNETES_OBJECT_TYPE_CONFIGMAP, KUBERNETES_OBJECT_TYPE_SECRET:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("objectType"), string(kubeCfg.ObjectType), []string{string(KUBERNETES_OBJECT_TYPE_CONFIGMAP), string(KUBERNETES_OBJECT_TYPE_SECRET)}))
+	}
+
+	switch kubeCfg.Mode {
+	case "", KUBERNETES_MODE_OBJECT_PER_RESOURCE, KUBERNETES_MODE_KEY_PER_RESOURCE:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("mode"), string(kubeCfg.Mode), []string{string(KUBERNETES_MODE_OBJECT_PER_RESOURCE), string(KUBERNETES_MODE_KEY_PER_RESOURCE)}))
+	}
+
+	if kubeCfg.NamePrefix != "" {
+		// the prefix has to be valid on its own, the rest of the name is generated
+		for _, msg := range validation.IsDNS1123Subdomain(strings.TrimRight(kubeCfg.NamePrefix, "-.") + "x") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("namePrefix"), kubeCfg.NamePrefix, msg))
+		}
+		if len(kubeCfg.NamePrefix) > 100 {
+			allErrs = append(allErrs, field.TooLong(fldPath.Child("namePrefix"), kubeCfg.NamePrefix, 100))
+		}
+	}
+
+	return allErrs
+}
+
+func validateKVConfig(kvCfg *KVConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if kvCfg == nil {
+		allErrs = append(allErrs, field.Required(fldPath, "kv config must not be empty"))
+		return allErrs
+	}
+
+	schemes := []string{}
+	switch kvCfg.Backend {
+	case KV_BACKEND_ETCD:
+		schemes = []string{"http", "https"}
+	case KV_BACKEND_REDIS:
+		schemes = []string{"redis", "rediss"}
+	case "":
+		allErrs = append(allErrs, field.Required(fldPath.Child("backend"), "backend must not be empty"))
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("backend"), string(kvCfg.Backend), []string{string(KV_BACKEND_ETCD), string(KV_BACKEND_REDIS)}))
+	}
+
+	if kvCfg.Endpoint == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("endpoint"), "endpoint must not be empty"))
+	} else if len(schemes) > 0 {
+		u, err := url.Parse(kvCfg.Endpoint)
+		if err != nil || !sets.New(schemes...).Has(u.Scheme) || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("endpoint"), kvCfg.Endpoint, fmt.Sprintf("endpoint must be an absolute URL with one of the schemes [%s] for backend '%s'", strings.Join(schemes, ", "), string(kvCfg.Backend))))
+		} else if kvCfg.Backend == KV_BACKEND_REDIS && strings.Trim(u.Path, "/") != "" {
+			if db, err := strconv.Atoi(strings.Trim(u.Path, "/")); err != nil || db < 0 {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("endpoint"), kvCfg.Endpoint, "the path of the endpoint must be the number of the database"))
+			}
+		}
+	}
+
+	if kvCfg.TTL != nil && kvCfg.TTL.Duration < time.Second {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("ttl"), kvCfg.TTL.Duration.String(), "ttl must be at least 1s"))
+	}
+
+	if kvCfg.Password != "" && kvCfg.PasswordFile != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("passwordFile"), "only one of password and passwordFile must be set"))
+	} else if kvCfg.Username != "" && kvCfg.Password == "" && kvCfg.PasswordFile == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("password"), "one of password and passwordFile must be set if a username is specified"))
+	}
+
+	if kvCfg.Timeout != nil && kvCfg.Timeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("timeout"), kvCfg.Timeout.Duration.String(), "timeout must be positive"))
+	}
+
+	return allErrs
+}
+
+func validateSFTPConfig(sftpCfg *SFTPConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if sftpCfg == nil {
+		allErrs = append(allErrs, field.Required(fldPath, "sftp config must not be empty"))
+		return allErrs
+	}
+
+	if sftpCfg.Host == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("host"), "host must not be empty"))
+	} else if u, err := url.Parse("ssh://" + sftpCfg.Host); err != nil || u.Host != sftpCfg.Host || u.Hostname() == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("host"), sftpCfg.Host, "host must have the format '<host>[:<port>]'"))
+	}
+	if sftpCfg.Username == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("username"), "username must not be empty"))
+	}
+
+	if sftpCfg.Password != "" && sftpCfg.PasswordFile != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("passwordFile"), "only one of password and passwordFile must be set"))
+	}
+	if sftpCfg.PrivateKey != "" && sftpCfg.PrivateKeyFile != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("privateKeyFile"), "only one of privateKey and privateKeyFile must be set"))
+	}
+	if sftpCfg.Password == "" && sftpCfg.PasswordFile == "" && sftpCfg.PrivateKey == "" && sftpCfg.PrivateKeyFile == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("password"), "one of password, passwordFile, privateKey, and privateKeyFile must be set"))
+	}
+
+	if sftpCfg.KnownHostsFile != "" && sftpCfg.HostKey != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("hostKey"), "only one of knownHostsFile and hostKey must be set"))
+	} else if sftpCfg.KnownHostsFile == "" && sftpCfg.HostKey == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("knownHostsFile"), "one of knownHostsFile and hostKey must be set to verify the identity of the server"))
+	}
+
+	if sftpCfg.Timeout != nil && sftpCfg.Timeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("timeout"), sftpCfg.Timeout.Duration.String(), "timeout must be positive"))
+	}
+
+	return allErrs
+}
+
+func validateWebDAVConfig(webdavCfg *WebDAVConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if webdavCfg == nil {
+		allErrs = append(allErrs, field.Required(fldPath, "webdav config must not be empty"))
+		return allErrs
+	}
+
+	if webdavCfg.URL == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("url"), "url must not be empty"))
+	} else if u, err := url.Parse(webdavCfg.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("url"), webdavCfg.URL, "url must be an absolute URL with scheme 'http' or 'https'"))
+	}
+
+	hasPassword := webdavCfg.Password != "" || webdavCfg.PasswordFile != ""
+	if webdavCfg.Password != "" && webdavCfg.PasswordFile != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("passwordFile"), "only one of password and passwordFile must be set"))
+	}
+	if webdavCfg.Username != "" && !hasPassword {
+		allErrs = append(allErrs, field.Required(fldPath.Child("password"), "one of password and passwordFile must be set if a username is specified"))
+	} else if webdavCfg.Username == "" && hasPassword {
+		allErrs = append(allErrs, field.Required(fldPath.Child("username"), "username must be set if a password is specified"))
+	}
+
+	if webdavCfg.Timeout != nil && webdavCfg.Timeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("timeout"), webdavCfg.Timeout.Duration.String(), "timeout must be positive"))
+	}
+
+	return allErrs
+}
+
+func validateClusterStorageConfig(clusterCfg *ClusterStorageConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if clusterCfg == nil {
+		allErrs = append(allErrs, field.Required(fldPath, "cluster config must not be empty"))
+		return allErrs
+	}
+
+	nmPath := fldPath.Child("namespaceMapping")
+	for _, source := range sets.List(sets.KeySet(clusterCfg.NamespaceMapping)) {
+		for _, msg := range validation.IsDNS1123Label(source) {
+			allErrs = append(allErrs, field.Invalid(nmPath, source, msg))
+		}
+		target := clusterCfg.NamespaceMapping[source]
+		for _, msg := range validation.IsDNS1123Label(target) {
+			allErrs = append(allErrs, field.Invalid(nmPath.Key(source), target, msg))
+		}
+	}
+
+	if len(clusterCfg.FieldManager) > 128 {
+		allErrs = append(allErrs, field.TooLong(fldPath.Child("fieldManager"), clusterCfg.FieldManager, 128))
+	}
+
+	return allErrs
+}
+
+// validateStagingFileSystemConfig validates the parts of the filesystem configuration which are evaluated for storages
+// of the given type which only stage their data in an in-memory filesystem, e.g. wiki storages.
+func (v *validator) validateStagingFileSystemConfig(fsConfig *FileSystemConfiguration, stType StorageDefinitionType, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if fsConfig.Layout != "" && fsConfig.Layout != FILESYSTEM_LAYOUT_DEFAULT {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("layout"), fmt.Sprintf("only layout '%s' is supported for storage type '%s'", string(FILESYSTEM_LAYOUT_DEFAULT), string(stType))))
+	}
+	allErrs = append(allErrs, v.validateKindOverrides(fsConfig, fldPath.Child("kindOverrides"))...)
+	if fsConfig.Serialization != nil {
+		allErrs = append(allErrs, v.validateSerialization(fsConfig.Serialization, fldPath.Child("serialization"))...)
+	}
+	if fsConfig.OnCorruptData == CORRUPT_DATA_POLICY_QUARANTINE {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("onCorruptData"), fmt.Sprintf("policy '%s' is not supported for storage type '%s'", string(CORRUPT_DATA_POLICY_QUARANTINE), string(stType))))
+	} else {
+		allErrs = append(allErrs, v.validateCorruptDataPolicy(fsConfig.OnCorruptData, fldPath.Child("onCorruptData"))...)
+	}
+	if fsConfig.IO != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("io"), fmt.Sprintf("io configuration is not supported for storage type '%s', as its data is staged in memory", string(stType))))
+	}
+	if fsConfig.LongNames != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("longNames"), fmt.Sprintf("shortening long names is not supported for storage type '%s'", string(stType))))
+	}
+	if fsConfig.ListDocuments {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("listDocuments"), fmt.Sprintf("list documents are not supported for storage type '%s'", string(stType))))
+	}
+
+	return allErrs
+}
+
+// validateRemoteFileSystemConfig validates the filesystem configuration of storages of the given type,
+// which persist their data to a remote directory, e.g. sftp storages.
+func (v *validator) validateRemoteFileSystemConfig(fsConfig *FileSystemConfiguration, stType StorageDefinitionType, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if fsConfig.RootPath != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("rootPath"), fmt.Sprintf("the root path is determined by the remote directory for storage type '%s'", string(stType))))
+	}
+	if fsConfig.InMemory != nil && *fsConfig.InMemory {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("inMemory"), fmt.Sprintf("in-memory filesystems are not supported for storage type '%s'", string(stType))))
+	}
+	if fsConfig.IO != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("io"), fmt.Sprintf("io configuration is not supported for storage type '%s'", string(stType))))
+	}
+	allErrs = append(allErrs, v.validateFileSystemLayout(fsConfig, fldPath)...)
+	allErrs = append(allErrs, v.validateKindOverrides(fsConfig, fldPath.Child("kindOverrides"))...)
+	if fsConfig.Serialization != nil {
+		allErrs = append(allErrs, v.validateSerialization(fsConfig.Serialization, fldPath.Child("serialization"))...)
+	}
+	allErrs = append(allErrs, v.validateCorruptDataPolicy(fsConfig.OnCorruptData, fldPath.Child("onCorruptData"))...)
+	allErrs = append(allErrs, v.validateLongNames(fsConfig.LongNames, fldPath.Child("longNames"))...)
+
+	return allErrs
+}
+
+func (v *validator) validateMockConfig(mockCfg *MockConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	supportedOperations := sets.New[string](string(MOCK_OPERATION_EXISTS), string(MOCK_OPERATION_GET), string(MOCK_OPERATION_PERSIST), string(MOCK_OPERATION_DELETE))
+	for idx, fault := range mockCfg.Faults {
+		curPath := fldPath.Child("faults").Index(idx)
+		if fault == nil {
+			allErrs = append(allErrs, field.Required(curPath, "fault must not be empty"))
+			continue
+		}
+		for opIdx, op := range fault.Operations {
+			if !supportedOperations.Has(string(op)) {
+				allErrs = append(allErrs, field.NotSupported(curPath.Child("operations").Index(opIdx), string(op), sets.List(supportedOperations)))
+			}
+		}
+		for callIdx, call := range fault.FailOnCalls {
+			if call < 1 {
+				allErrs = append(allErrs, field.Invalid(curPath.Child("failOnCalls").Index(callIdx), call, "call numbers start with 1"))
+			}
+		}
+		if fault.FailureRate < 0 || fault.FailureRate > 100 {
+			allErrs = append(allErrs, field.Invalid(curPath.Child("failureRate"), fault.FailureRate, "failure rate must be a percentage between 0 and 100"))
+		}
+		if fault.Latency != nil && fault.Latency.Duration < 0 {
+			allErrs = append(allErrs, field.Invalid(curPath.Child("latency"), fault.Latency.Duration.String(), "latency must not be negative"))
+		}
+		if len(fault.FailOnCalls) == 0 && fault.FailureRate == 0 && (fault.Latency == nil || fault.Latency.Duration == 0) {
+			allErrs = append(allErrs, field.Required(curPath, "fault must specify failOnCalls, a failureRate, or a latency"))
+		}
 	}
 
 	return allErrs
@@ -189,13 +893,270 @@ func (v *validator) validateSyncConfig(syncConfig *SyncConfig, fldPath *field.Pa
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("id"), syncConfig.ID, fmt.Sprintf("ID must match regex %s", nameRegex.String())))
 	}
 
-	allErrs = append(allErrs, v.validateStorageReferences(syncConfig.StorageRefs, fldPath.Child("storageRefs"))...)
+	if syncConfig.ClusterName != "" && syncConfig.ClusterKey() == "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("clusterName"), "clusterName can only be set together with kubeconfig or shoot, the default cluster is identified by the global clusterName"))
+	}
+
+	// templated subPaths are rendered with the values which are known for all resources of this sync config
+	v.subPathTemplateData.ClusterName = syncConfig.ClusterNameOrDefault(v.clusterName)
+	v.subPathTemplateData.Namespace = ""
+	v.subPathTemplateData.Kind = ""
+	if syncConfig.Resource != nil {
+		v.subPathTemplateData.Namespace = syncConfig.Resource.Namespace
+		v.subPathTemplateData.Kind = syncConfig.Resource.Kind
+	}
+	allErrs = append(allErrs, v.validateStorageReferences(syncConfig.StorageRefs, syncConfig.Finalize, fldPath.Child("storageRefs"))...)
+	allErrs = append(allErrs, v.validateClusterStorageReferences(syncConfig, fldPath.Child("storageRefs"))...)
 	allErrs = append(allErrs, v.validateResourceSyncConfig(syncConfig.Resource, fldPath.Child("resource"))...)
 	allErrs = append(allErrs, v.validateStateConfiguration(syncConfig.State, fldPath.Child("state"))...)
+	allErrs = append(allErrs, v.validateImpersonationConfiguration(syncConfig.Impersonate, fldPath.Child("impersonate"))...)
+	if syncConfig.Shoot != nil && syncConfig.Kubeconfig != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("shoot"), "shoot and kubeconfig are mutually exclusive"))
+	}
+	allErrs = append(allErrs, validateShootReference(syncConfig.Shoot, fldPath.Child("shoot"))...)
+	allErrs = append(allErrs, v.validateSnapshotConfiguration(syncConfig.Snapshot, syncConfig.StorageRefs, fldPath.Child("snapshot"))...)
+	allErrs = append(allErrs, validateClientRateLimitConfiguration(syncConfig.ClientRateLimit, fldPath.Child("clientRateLimit"))...)
+	allErrs = append(allErrs, validateTransformerConfiguration(syncConfig.Transformer, fldPath.Child("transformer"))...)
+	allErrs = append(allErrs, validateUpdateRetryConfiguration(syncConfig.UpdateRetry, fldPath.Child("updateRetry"))...)
+	allErrs = append(allErrs, validateDeletionFailureConfiguration(syncConfig.DeletionFailure, fldPath.Child("deletionFailure"))...)
+	allErrs = append(allErrs, validateChangeLogConfiguration(syncConfig.ChangeLog, fldPath.Child("changeLog"))...)
+	if syncConfig.Debounce != nil && syncConfig.Debounce.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("debounce"), syncConfig.Debounce.Duration.String(), "debounce must be positive"))
+	}
 
 	if syncConfig.Finalize == nil {
 		allErrs = append(allErrs, field.Required(fldPath.Child("finalize"), "finalize is required, but it should have been defaulted, check coding"))
 	}
+	allErrs = append(allErrs, v.validateChangeDetection(syncConfig.ChangeDetection, syncConfig.State, fldPath.Child("changeDetection"))...)
+	if syncConfig.AnnotateContentHash && syncConfig.ChangeDetection == CHANGE_DETECTION_RESOURCE_VERSION {
+		// writing the annotation changes the resourceVersion, which would cause an endless loop
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("annotateContentHash"), fmt.Sprintf("content hash annotation cannot be combined with change detection '%s'", string(CHANGE_DETECTION_RESOURCE_VERSION))))
+	}
+	allErrs = append(allErrs, v.validateReactOn(syncConfig, fldPath.Child("reactOn"))...)
+	if syncConfig.ReadOnlySource {
+		allErrs = append(allErrs, v.validateReadOnlySource(syncConfig, fldPath)...)
+	}
+	if syncConfig.PersistCRD && syncConfig.Resource != nil && syncConfig.Resource.Group == "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("persistCRD"), "resources of the core group are not defined by a CustomResourceDefinition"))
+	}
+	switch syncConfig.StateWritePolicy {
+	case STATE_WRITE_POLICY_ALWAYS, STATE_WRITE_POLICY_ON_CHANGE_ONLY, STATE_WRITE_POLICY_FINAL_ONLY:
+	case "":
+		allErrs = append(allErrs, field.Required(fldPath.Child("stateWritePolicy"), "state write policy is required, but it should have been defaulted, check coding"))
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("stateWritePolicy"), string(syncConfig.StateWritePolicy), []string{string(STATE_WRITE_POLICY_ALWAYS), string(STATE_WRITE_POLICY_ON_CHANGE_ONLY), string(STATE_WRITE_POLICY_FINAL_ONLY)}))
+	}
+	switch syncConfig.ContentMode {
+	case CONTENT_MODE_FULL:
+	case CONTENT_MODE_METADATA_ONLY:
+		if syncConfig.Transformer != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("transformer"), fmt.Sprintf("transformer configuration cannot be combined with content mode '%s', which persists only the metadata summary", string(CONTENT_MODE_METADATA_ONLY))))
+		}
+	case "":
+		allErrs = append(allErrs, field.Required(fldPath.Child("contentMode"), "content mode is required, but it should have been defaulted, check coding"))
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("contentMode"), string(syncConfig.ContentMode), []string{string(CONTENT_MODE_FULL), string(CONTENT_MODE_METADATA_ONLY)}))
+	}
+	for idx, mw := range syncConfig.MaintenanceWindows {
+		allErrs = append(allErrs, v.validateMaintenanceWindow(mw, fldPath.Child("maintenanceWindows").Index(idx))...)
+	}
+
+	return allErrs
+}
+
+func validateTransformerConfiguration(tCfg *TransformerConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if tCfg == nil {
+		return allErrs
+	}
+
+	switch tCfg.SecretData {
+	case SECRET_DATA_MODE_DATA, SECRET_DATA_MODE_STRING_DATA, SECRET_DATA_MODE_STRIP:
+	case "":
+		allErrs = append(allErrs, field.Required(fldPath.Child("secretData"), "secret data mode is required, but it should have been defaulted, check coding"))
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("secretData"), string(tCfg.SecretData), []string{string(SECRET_DATA_MODE_DATA), string(SECRET_DATA_MODE_STRING_DATA), string(SECRET_DATA_MODE_STRIP)}))
+	}
+
+	return allErrs
+}
+
+func validateChangeLogConfiguration(clCfg *ChangeLogConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if clCfg == nil {
+		return allErrs
+	}
+
+	if clCfg.FileName == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("fileName"), "file name is required, but it should have been defaulted, check coding"))
+	} else if clCfg.FileName == "." || clCfg.FileName == ".." || strings.ContainsAny(clCfg.FileName, `/\`) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("fileName"), clCfg.FileName, "file name must not be a path"))
+	}
+
+	return allErrs
+}
+
+func validateDeletionFailureConfiguration(dfCfg *DeletionFailureConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if dfCfg == nil {
+		return allErrs
+	}
+
+	if dfCfg.MaxAttempts <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxAttempts"), dfCfg.MaxAttempts, "max attempts must be greater than 0"))
+	}
+	if dfCfg.ConfigMapName == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("configMapName"), "name is required, but it should have been defaulted, check coding"))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(dfCfg.ConfigMapName) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("configMapName"), dfCfg.ConfigMapName, msg))
+		}
+	}
+	if dfCfg.ConfigMapNamespace == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("configMapNamespace"), "namespace is required, but it should have been defaulted, check coding"))
+	} else {
+		for _, msg := range validation.IsDNS1123Label(dfCfg.ConfigMapNamespace) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("configMapNamespace"), dfCfg.ConfigMapNamespace, msg))
+		}
+	}
+
+	return allErrs
+}
+
+func validateUpdateRetryConfiguration(urCfg *UpdateRetryConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if urCfg == nil {
+		return allErrs
+	}
+
+	if urCfg.MaxRetries == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("maxRetries"), "max retries are required, but they should have been defaulted, check coding"))
+	} else if *urCfg.MaxRetries < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxRetries"), *urCfg.MaxRetries, "max retries must not be negative"))
+	}
+	if urCfg.Backoff != nil && urCfg.Backoff.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("backoff"), urCfg.Backoff.Duration.String(), "backoff must be greater than 0"))
+	}
+	if urCfg.Jitter < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("jitter"), urCfg.Jitter, "jitter must not be negative"))
+	} else if urCfg.Jitter > 0 && urCfg.Backoff == nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("jitter"), "jitter requires backoff to be set"))
+	}
+
+	return allErrs
+}
+
+func (v *validator) validateMaintenanceWindow(mw *MaintenanceWindow, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if mw == nil {
+		allErrs = append(allErrs, field.Required(fldPath, "maintenance window must not be empty"))
+		return allErrs
+	}
+
+	for idx, day := range mw.Days {
+		if _, err := ParseWeekday(day); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("days").Index(idx), day, "must be the name of a weekday or its three-letter abbreviation"))
+		}
+	}
+	if mw.Start == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("start"), "start must not be empty"))
+	} else if _, _, err := parseTimeOfDay(mw.Start); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("start"), mw.Start, err.Error()))
+	}
+	if mw.End == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("end"), "end must not be empty"))
+	} else if _, _, err := parseTimeOfDay(mw.End); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("end"), mw.End, err.Error()))
+	}
+	if _, err := time.LoadLocation(mw.TimeZone); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("timeZone"), mw.TimeZone, fmt.Sprintf("unknown time zone: %s", err.Error())))
+	}
+
+	return allErrs
+}
+
+// validateReadOnlySource verifies that nothing is configured which would require writing to the synced resources.
+func (v *validator) validateReadOnlySource(syncConfig *SyncConfig, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if syncConfig.Finalize != nil && *syncConfig.Finalize {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("finalize"), "finalizers cannot be used for a read-only source"))
+	}
+	if syncConfig.State != nil && syncConfig.State.Type != STATE_TYPE_NONE {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("state"), "state cannot be displayed for a read-only source"))
+	}
+	if syncConfig.AnnotateContentHash {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("annotateContentHash"), "content hash annotation cannot be written for a read-only source"))
+	}
+	if syncConfig.DeletionFailure != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("deletionFailure"), "orphaned storage data cannot be recorded for a read-only source"))
+	}
+
+	return allErrs
+}
+
+func (v *validator) validateChangeDetection(mode ChangeDetectionMode, sdCfg *StateConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	switch mode {
+	case CHANGE_DETECTION_GENERATION, CHANGE_DETECTION_CONTENT_HASH:
+	case CHANGE_DETECTION_RESOURCE_VERSION:
+		// writing the state into the resource changes its resourceVersion, which would cause an endless loop
+		if sdCfg != nil && (sdCfg.Type == STATE_TYPE_ANNOTATION || sdCfg.Type == STATE_TYPE_STATUS) {
+			allErrs = append(allErrs, field.Forbidden(fldPath, fmt.Sprintf("change detection '%s' cannot be combined with state type '%s', as writing the state changes the resourceVersion", string(mode), string(sdCfg.Type))))
+		}
+	case "":
+		allErrs = append(allErrs, field.Required(fldPath, "change detection is required, but it should have been defaulted, check coding"))
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath, string(mode), []string{string(CHANGE_DETECTION_GENERATION), string(CHANGE_DETECTION_RESOURCE_VERSION), string(CHANGE_DETECTION_CONTENT_HASH)}))
+	}
+
+	return allErrs
+}
+
+func (v *validator) validateReactOn(syncConfig *SyncConfig, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	triggers := syncConfig.ReactOn
+
+	if triggers == nil {
+		allErrs = append(allErrs, field.Required(fldPath, "reactOn is required, but it should have been defaulted, check coding"))
+		return allErrs
+	}
+	if len(triggers) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath, "at least one trigger is required"))
+		return allErrs
+	}
+	seen := map[ReactOnTrigger]bool{}
+	for idx, t := range triggers {
+		curPath := fldPath.Index(idx)
+		if seen[t] {
+			allErrs = append(allErrs, field.Duplicate(curPath, string(t)))
+			continue
+		}
+		seen[t] = true
+		switch t {
+		case REACT_ON_GENERATION, REACT_ON_LABELS, REACT_ON_ANNOTATIONS, REACT_ON_OWNER_REFERENCES, REACT_ON_CONTENT_HASH:
+		case REACT_ON_RESOURCE_VERSION:
+			if syncConfig.ChangeDetection == CHANGE_DETECTION_RESOURCE_VERSION {
+				// conflicts are already reported for the change detection mode
+				continue
+			}
+			// writing the state or the content hash into the resource changes its resourceVersion, which would cause an endless loop
+			if sdCfg := syncConfig.State; sdCfg != nil && (sdCfg.Type == STATE_TYPE_ANNOTATION || sdCfg.Type == STATE_TYPE_STATUS) {
+				allErrs = append(allErrs, field.Forbidden(curPath, fmt.Sprintf("trigger '%s' cannot be combined with state type '%s', as writing the state changes the resourceVersion", string(t), string(sdCfg.Type))))
+			}
+			if syncConfig.AnnotateContentHash {
+				allErrs = append(allErrs, field.Forbidden(curPath, fmt.Sprintf("trigger '%s' cannot be combined with the content hash annotation, as writing the annotation changes the resourceVersion", string(t))))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(curPath, string(t), []string{string(REACT_ON_GENERATION), string(REACT_ON_LABELS), string(REACT_ON_ANNOTATIONS), string(REACT_ON_OWNER_REFERENCES), string(REACT_ON_RESOURCE_VERSION), string(REACT_ON_CONTENT_HASH)}))
+		}
+	}
 
 	return allErrs
 }
@@ -214,11 +1175,158 @@ func (v *validator) validateFileSystemConfig(fsConfig *FileSystemConfiguration,
 	if fsConfig.InMemory == nil {
 		allErrs = append(allErrs, field.Required(fldPath.Child("inMemory"), "inMemory is required, but it should have been defaulted, check coding"))
 	}
+	allErrs = append(allErrs, v.validateFileSystemLayout(fsConfig, fldPath)...)
+	allErrs = append(allErrs, v.validateKindOverrides(fsConfig, fldPath.Child("kindOverrides"))...)
+	if fsConfig.Serialization != nil {
+		allErrs = append(allErrs, v.validateSerialization(fsConfig.Serialization, fldPath.Child("serialization"))...)
+	}
+	allErrs = append(allErrs, v.validateCorruptDataPolicy(fsConfig.OnCorruptData, fldPath.Child("onCorruptData"))...)
+	allErrs = append(allErrs, v.validateFileSystemIO(fsConfig, fldPath.Child("io"))...)
+	allErrs = append(allErrs, v.validateLongNames(fsConfig.LongNames, fldPath.Child("longNames"))...)
+
+	return allErrs
+}
+
+// minLongNameMaxLength is the smallest supported maximum file name length.
+// Shorter limits would not leave enough room for the hash next to the GVK and the file extension.
+const minLongNameMaxLength = 64
+
+// validateLongNames validates the long names configuration, if any.
+func (v *validator) validateLongNames(lnConfig *LongNamesConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if lnConfig == nil {
+		return allErrs
+	}
+	if lnConfig.MaxLength < minLongNameMaxLength {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxLength"), lnConfig.MaxLength, fmt.Sprintf("maxLength must be at least %d", minLongNameMaxLength)))
+	}
+	switch lnConfig.Strategy {
+	case LONG_NAME_STRATEGY_TRUNCATE, LONG_NAME_STRATEGY_HASH:
+	case "":
+		allErrs = append(allErrs, field.Required(fldPath.Child("strategy"), "strategy is required, but it should have been defaulted, check coding"))
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("strategy"), string(lnConfig.Strategy), []string{string(LONG_NAME_STRATEGY_TRUNCATE), string(LONG_NAME_STRATEGY_HASH)}))
+	}
+
+	return allErrs
+}
+
+// validateFileSystemIO validates the IO configuration of the given filesystem configuration, if any.
+func (v *validator) validateFileSystemIO(fsConfig *FileSystemConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	io := fsConfig.IO
+	if io == nil {
+		return allErrs
+	}
+	if fsConfig.InMemory != nil && *fsConfig.InMemory {
+		allErrs = append(allErrs, field.Forbidden(fldPath, "io configuration is not supported for in-memory filesystems"))
+	}
+	if io.Retries < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("retries"), io.Retries, "retries must not be negative"))
+	}
+	if io.RetryInterval == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("retryInterval"), "retryInterval is required, but it should have been defaulted, check coding"))
+	} else if io.RetryInterval.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("retryInterval"), io.RetryInterval.Duration.String(), "retryInterval must be positive"))
+	}
+
+	return allErrs
+}
+
+func (v *validator) validateSerialization(serCfg *SerializationConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, validateFieldOrder(serCfg.FieldOrder, fldPath.Child("fieldOrder"))...)
+	allErrs = append(allErrs, validateFieldOrder(serCfg.TopLevelFieldOrder, fldPath.Child("topLevelFieldOrder"))...)
+
+	return allErrs
+}
+
+// validateFieldOrder verifies that the given field order contains neither empty nor duplicate field names.
+func validateFieldOrder(fieldOrder []string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	seen := sets.New[string]()
+	for idx, fieldName := range fieldOrder {
+		curPath := fldPath.Index(idx)
+		if fieldName == "" {
+			allErrs = append(allErrs, field.Required(curPath, "field name must not be empty"))
+			continue
+		}
+		if seen.Has(fieldName) {
+			allErrs = append(allErrs, field.Duplicate(curPath, fieldName))
+			continue
+		}
+		seen.Insert(fieldName)
+	}
+
+	return allErrs
+}
+
+func (v *validator) validateKindOverrides(fsConfig *FileSystemConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for _, key := range sets.List(sets.KeySet(fsConfig.KindOverrides)) {
+		curPath := fldPath.Key(key)
+		if !gvkStringRegex.MatchString(key) {
+			allErrs = append(allErrs, field.Invalid(curPath, key, "key must be a GVK string in the format '<kind>.<version>.<group>' with the kind in lower case, e.g. 'deployment.v1.apps'"))
+		}
+		override := fsConfig.KindOverrides[key]
+		if override == nil {
+			allErrs = append(allErrs, field.Required(curPath, "override must not be empty"))
+			continue
+		}
+		if fsConfig.Layout == FILESYSTEM_LAYOUT_ARGOCD {
+			// the 'argocd' layout uses neither the namespace prefix nor the separator
+			if override.NamespacePrefix != nil {
+				allErrs = append(allErrs, field.Forbidden(curPath.Child("namespacePrefix"), fmt.Sprintf("namespace prefix cannot be overridden for layout '%s'", string(FILESYSTEM_LAYOUT_ARGOCD))))
+			}
+			if override.GVKNameSeparator != nil {
+				allErrs = append(allErrs, field.Forbidden(curPath.Child("gvrNameSeparator"), fmt.Sprintf("separator cannot be overridden for layout '%s'", string(FILESYSTEM_LAYOUT_ARGOCD))))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+func (v *validator) validateCorruptDataPolicy(policy CorruptDataPolicy, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	switch policy {
+	case "", CORRUPT_DATA_POLICY_OVERWRITE, CORRUPT_DATA_POLICY_ERROR, CORRUPT_DATA_POLICY_QUARANTINE:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath, string(policy), []string{string(CORRUPT_DATA_POLICY_OVERWRITE), string(CORRUPT_DATA_POLICY_ERROR), string(CORRUPT_DATA_POLICY_QUARANTINE)}))
+	}
+
+	return allErrs
+}
+
+func (v *validator) validateFileSystemLayout(fsConfig *FileSystemConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	switch fsConfig.Layout {
+	case "", FILESYSTEM_LAYOUT_DEFAULT:
+	case FILESYSTEM_LAYOUT_ARGOCD:
+		if fsConfig.ArgoCD == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("argocd"), "argocd is required, but it should have been defaulted, check coding"))
+		} else if fsConfig.ArgoCD.RepoURL == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("argocd", "repoURL"), "repoURL is required for the 'argocd' layout, unless the storage is of type 'git'"))
+		}
+		if fsConfig.ListDocuments {
+			// Argo CD would deploy the resources twice, as it applies all files in the namespace directories
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("listDocuments"), fmt.Sprintf("list documents are not supported for layout '%s'", string(FILESYSTEM_LAYOUT_ARGOCD))))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("layout"), string(fsConfig.Layout), []string{string(FILESYSTEM_LAYOUT_DEFAULT), string(FILESYSTEM_LAYOUT_ARGOCD)}))
+	}
 
 	return allErrs
 }
 
-func (v *validator) validateStorageReferences(refs []*StorageReference, fldPath *field.Path) field.ErrorList {
+func (v *validator) validateStorageReferences(refs []*StorageReference, finalize *bool, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 
 	if len(refs) == 0 {
@@ -237,11 +1345,48 @@ func (v *validator) validateStorageReferences(refs []*StorageReference, fldPath
 			allErrs = append(allErrs, field.Required(curPath.Child("name"), "storage reference name must not be empty"))
 		}
 
+		switch ref.FileNaming {
+		case "", FILE_NAMING_NAME:
+		case FILE_NAMING_UID, FILE_NAMING_NAME_AND_UID:
+			if finalize != nil && !*finalize {
+				allErrs = append(allErrs, field.Forbidden(curPath.Child("fileNaming"), fmt.Sprintf("file naming '%s' requires finalize to be enabled", string(ref.FileNaming))))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(curPath.Child("fileNaming"), string(ref.FileNaming), []string{string(FILE_NAMING_NAME), string(FILE_NAMING_UID), string(FILE_NAMING_NAME_AND_UID)}))
+		}
+
+		if ref.RecheckInterval != nil && ref.RecheckInterval.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(curPath.Child("recheckInterval"), ref.RecheckInterval.Duration.String(), "recheckInterval must be positive"))
+		}
+
+		if ref.MaxObjects < 0 {
+			allErrs = append(allErrs, field.Invalid(curPath.Child("maxObjects"), ref.MaxObjects, "maxObjects must not be negative"))
+		}
+		if ref.MaxTotalBytes < 0 {
+			allErrs = append(allErrs, field.Invalid(curPath.Child("maxTotalBytes"), ref.MaxTotalBytes, "maxTotalBytes must not be negative"))
+		}
+
+		// validate that the subPath is a valid template
+		subPath := ref.SubPath
+		tmpl, err := ParseSubPathTemplate(ref.SubPath)
+		if err == nil {
+			subPath, err = RenderSubPath(tmpl, ref.SubPath, v.subPathTemplateData)
+		}
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(curPath.Child("subPath"), ref.SubPath, fmt.Sprintf("invalid subPath template: %s", err.Error())))
+			continue
+		}
+
 		// validate that only existing storage definitions are referenced and that base paths on shared filesystems are not nested
 		sd, ok := v.storageDefs[ref.Name]
 		if ok {
+			if ref.FileNaming.UsesUID() && sd.FileSystemConfig != nil && sd.FileSystemConfig.LongNames != nil {
+				// files with shortened names are verified by comparing the contained resource's name with the storage name,
+				// which isn't the resource's name for the UID-based namings
+				allErrs = append(allErrs, field.Forbidden(curPath.Child("fileNaming"), fmt.Sprintf("file naming '%s' is not supported for storage definitions with longNames", string(ref.FileNaming))))
+			}
 			if sd.FileSystemConfig != nil && sd.Type != STORAGE_TYPE_MOCK {
-				basePath := filepath.Join(sd.FileSystemConfig.RootPath, ref.SubPath)
+				basePath := filepath.Join(sd.FileSystemConfig.RootPath, subPath)
 				if basePath == "" {
 					basePath = "/"
 				}
@@ -266,6 +1411,39 @@ func (v *validator) validateStorageReferences(refs []*StorageReference, fldPath
 	return allErrs
 }
 
+// validateClusterStorageReferences validates the references of the given sync config to cluster storages.
+// Resources are replicated under their own name and namespace, so subPaths and file namings don't apply.
+// If the resources would be replicated into their own cluster, they must be mapped to another namespace, to not overwrite themselves.
+func (v *validator) validateClusterStorageReferences(syncConfig *SyncConfig, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for idx, ref := range syncConfig.StorageRefs {
+		if ref == nil {
+			continue
+		}
+		sd, ok := v.storageDefs[ref.Name]
+		if !ok || sd.Type != STORAGE_TYPE_CLUSTER || sd.ClusterConfig == nil {
+			continue
+		}
+		curPath := fldPath.Index(idx)
+		if ref.SubPath != "" {
+			allErrs = append(allErrs, field.Forbidden(curPath.Child("subPath"), fmt.Sprintf("subPath is not supported for storage type '%s'", string(sd.Type))))
+		}
+		if ref.FileNaming != "" && ref.FileNaming != FILE_NAMING_NAME {
+			allErrs = append(allErrs, field.Forbidden(curPath.Child("fileNaming"), fmt.Sprintf("resources are always replicated under their own name for storage type '%s'", string(sd.Type))))
+		}
+		if sd.ClusterConfig.Kubeconfig != "" || syncConfig.ClusterKey() != "" || syncConfig.Resource == nil {
+			continue
+		}
+		namespace := syncConfig.Resource.Namespace
+		if target, ok := sd.ClusterConfig.NamespaceMapping[namespace]; namespace == "" || !ok || target == namespace {
+			allErrs = append(allErrs, field.Forbidden(curPath, fmt.Sprintf("storage '%s' replicates into the cluster of the synced resources, which requires the sync config to be restricted to a namespace which is mapped to another namespace", ref.Name)))
+		}
+	}
+
+	return allErrs
+}
+
 func (v *validator) validateGitRepoConfig(repoConfig *GitConfiguration, fldPath *field.Path, gitRepoURLs sets.Set[string]) field.ErrorList {
 	allErrs := field.ErrorList{}
 
@@ -283,11 +1461,233 @@ func (v *validator) validateGitRepoConfig(repoConfig *GitConfiguration, fldPath
 		gitRepoURLs.Insert(repoConfig.URL)
 	}
 
+	if strings.ContainsAny(repoConfig.RemoteName, "/ \t\n") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("remoteName"), repoConfig.RemoteName, "remote name must not contain '/' or whitespace"))
+	}
+
 	allErrs = append(allErrs, v.validateGitRepoAuth(repoConfig.Auth, fldPath.Child("auth"))...)
 	if repoConfig.SecondaryAuth != nil {
 		allErrs = append(allErrs, v.validateGitRepoAuth(repoConfig.SecondaryAuth, fldPath.Child("secondaryAuth"))...)
 	}
 
+	if repoConfig.Webhook != nil {
+		if repoConfig.Exclusive {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("webhook"), "webhooks are not supported for exclusive repositories, as these are never pulled"))
+		}
+		if (repoConfig.Webhook.Secret == "") == (repoConfig.Webhook.SecretFile == "") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("webhook"), "<redacted>", "exactly one of 'secret' and 'secretFile' must be set"))
+		}
+	}
+
+	if bp := repoConfig.BackgroundPull; bp != nil {
+		bpPath := fldPath.Child("backgroundPull")
+		if repoConfig.Exclusive {
+			allErrs = append(allErrs, field.Forbidden(bpPath, "background pulls are not supported for exclusive repositories, as these are never pulled"))
+		}
+		if repoConfig.Webhook != nil {
+			allErrs = append(allErrs, field.Forbidden(bpPath, "background pulls cannot be combined with webhooks"))
+		}
+		if bp.Interval == nil {
+			allErrs = append(allErrs, field.Required(bpPath.Child("interval"), "interval is required, but it should have been defaulted, check coding"))
+		} else if bp.Interval.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(bpPath.Child("interval"), bp.Interval.Duration.String(), "interval must be positive"))
+		}
+		if bp.FreshnessWindow == nil {
+			allErrs = append(allErrs, field.Required(bpPath.Child("freshnessWindow"), "freshnessWindow is required, but it should have been defaulted, check coding"))
+		} else if bp.Interval != nil && bp.FreshnessWindow.Duration < bp.Interval.Duration {
+			allErrs = append(allErrs, field.Invalid(bpPath.Child("freshnessWindow"), bp.FreshnessWindow.Duration.String(), "freshnessWindow must not be shorter than the interval"))
+		}
+	}
+
+	if pmi := repoConfig.PullMinInterval; pmi != nil {
+		pmiPath := fldPath.Child("pullMinInterval")
+		if repoConfig.Exclusive {
+			allErrs = append(allErrs, field.Forbidden(pmiPath, "a minimum pull interval is not supported for exclusive repositories, as these are never pulled"))
+		}
+		if repoConfig.Webhook != nil {
+			allErrs = append(allErrs, field.Forbidden(pmiPath, "a minimum pull interval cannot be combined with webhooks, which only pull after push events"))
+		}
+		if repoConfig.BackgroundPull != nil {
+			allErrs = append(allErrs, field.Forbidden(pmiPath, "a minimum pull interval cannot be combined with background pulls, use their freshness window instead"))
+		}
+		if pmi.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(pmiPath, pmi.Duration.String(), "pullMinInterval must be positive"))
+		}
+	}
+
+	if repoConfig.NamespaceBranches != nil {
+		nbPath := fldPath.Child("namespaceBranches")
+		if repoConfig.Webhook != nil {
+			allErrs = append(allErrs, field.Forbidden(nbPath, "namespace branches are not supported in combination with webhooks"))
+		}
+		prefix := repoConfig.NamespaceBranches.Prefix
+		if strings.ContainsAny(prefix, invalidBranchNameChars) || strings.Contains(prefix, "..") || strings.HasPrefix(prefix, "/") || strings.HasPrefix(prefix, "-") {
+			allErrs = append(allErrs, field.Invalid(nbPath.Child("prefix"), prefix, fmt.Sprintf("prefix must not start with '/' or '-' and must not contain '..', whitespace, or any of '%s'", strings.TrimSpace(invalidBranchNameChars))))
+		}
+		namespaces := sets.New[string]()
+		for idx, m := range repoConfig.NamespaceBranches.Namespaces {
+			mPath := nbPath.Child("namespaces").Index(idx)
+			if m == nil {
+				allErrs = append(allErrs, field.Required(mPath, "namespace auth mapping must not be empty"))
+				continue
+			}
+			if m.Namespace == "" {
+				allErrs = append(allErrs, field.Required(mPath.Child("namespace"), "namespace must not be empty"))
+			} else if namespaces.Has(m.Namespace) {
+				allErrs = append(allErrs, field.Duplicate(mPath.Child("namespace"), m.Namespace))
+			}
+			namespaces.Insert(m.Namespace)
+			allErrs = append(allErrs, v.validateGitRepoAuth(m.Auth, mPath.Child("auth"))...)
+		}
+		if as := repoConfig.NamespaceBranches.AuthSecret; as != nil {
+			asPath := nbPath.Child("authSecret")
+			if as.Name == "" {
+				allErrs = append(allErrs, field.Required(asPath.Child("name"), "secret name must not be empty"))
+			} else {
+				for _, msg := range validation.IsDNS1123Subdomain(as.Name) {
+					allErrs = append(allErrs, field.Invalid(asPath.Child("name"), as.Name, msg))
+				}
+			}
+			switch as.Type {
+			case GIT_AUTH_USERNAME_PASSWORD, GIT_AUTH_SSH:
+			case "":
+				allErrs = append(allErrs, field.Required(asPath.Child("type"), "git authentication type is required, but it should have been defaulted, check coding"))
+			default:
+				allErrs = append(allErrs, field.NotSupported(asPath.Child("type"), string(as.Type), []string{string(GIT_AUTH_USERNAME_PASSWORD), string(GIT_AUTH_SSH)}))
+			}
+			if as.RefreshInterval == nil {
+				allErrs = append(allErrs, field.Required(asPath.Child("refreshInterval"), "refresh interval is required, but it should have been defaulted, check coding"))
+			} else if as.RefreshInterval.Duration <= 0 {
+				allErrs = append(allErrs, field.Invalid(asPath.Child("refreshInterval"), as.RefreshInterval.Duration.String(), "refresh interval must be positive"))
+			}
+		}
+	}
+
+	switch repoConfig.GitBackend {
+	case "", GIT_BACKEND_GO_GIT, GIT_BACKEND_CLI:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("gitBackend"), string(repoConfig.GitBackend), []string{string(GIT_BACKEND_GO_GIT), string(GIT_BACKEND_CLI)}))
+	}
+
+	switch repoConfig.ConflictPolicy {
+	case "", GIT_CONFLICT_POLICY_PREFER_CLUSTER, GIT_CONFLICT_POLICY_PREFER_REMOTE, GIT_CONFLICT_POLICY_FAIL_AND_ALERT:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("conflictPolicy"), string(repoConfig.ConflictPolicy), []string{string(GIT_CONFLICT_POLICY_PREFER_CLUSTER), string(GIT_CONFLICT_POLICY_PREFER_REMOTE), string(GIT_CONFLICT_POLICY_FAIL_AND_ALERT)}))
+	}
+
+	if repoConfig.CommitChunkSize < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("commitChunkSize"), repoConfig.CommitChunkSize, "commit chunk size must not be negative"))
+	}
+
+	if ct := repoConfig.CommitTimestamps; ct != nil {
+		ctPath := fldPath.Child("commitTimestamps")
+		if ct.TimeZone != "" {
+			if _, err := time.LoadLocation(ct.TimeZone); err != nil {
+				allErrs = append(allErrs, field.Invalid(ctPath.Child("timeZone"), ct.TimeZone, fmt.Sprintf("unknown time zone: %s", err.Error())))
+			}
+		}
+		if ct.Truncate != nil && ct.Truncate.Duration < time.Second {
+			allErrs = append(allErrs, field.Invalid(ctPath.Child("truncate"), ct.Truncate.Duration.String(), "truncate must be at least one second"))
+		}
+	}
+
+	if cg := repoConfig.CommitGroups; cg != nil {
+		tmplPath := fldPath.Child("commitGroups", "template")
+		if cg.Template == "" {
+			allErrs = append(allErrs, field.Required(tmplPath, "template is required"))
+		} else if tmpl, err := ParseCommitGroupTemplate(cg.Template); err != nil {
+			allErrs = append(allErrs, field.Invalid(tmplPath, cg.Template, fmt.Sprintf("invalid template: %s", err.Error())))
+		} else if _, err := RenderCommitGroup(tmpl, &CommitGroupTemplateData{}); err != nil {
+			// catches references to unknown values
+			allErrs = append(allErrs, field.Invalid(tmplPath, cg.Template, err.Error()))
+		}
+	}
+
+	if repoConfig.Bootstrap && repoConfig.Provider == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("provider"), "provider is required for bootstrapping the repository"))
+	}
+	if pc := repoConfig.Provider; pc != nil {
+		pcPath := fldPath.Child("provider")
+		switch pc.Type {
+		case GIT_PROVIDER_GITHUB, GIT_PROVIDER_GITLAB, GIT_PROVIDER_GITEA:
+		default:
+			allErrs = append(allErrs, field.NotSupported(pcPath.Child("type"), string(pc.Type), []string{string(GIT_PROVIDER_GITHUB), string(GIT_PROVIDER_GITLAB), string(GIT_PROVIDER_GITEA)}))
+		}
+		if pc.URL == "" {
+			allErrs = append(allErrs, field.Required(pcPath.Child("url"), "url is required if it can't be derived from the repository URL"))
+		} else if u, err := url.Parse(pc.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(pcPath.Child("url"), pc.URL, "url must be an absolute http or https URL"))
+		}
+		if pc.Repository == "" {
+			allErrs = append(allErrs, field.Required(pcPath.Child("repository"), "repository is required if it can't be derived from the repository URL"))
+		} else if pc.Type != GIT_PROVIDER_GITLAB && strings.Count(pc.Repository, "/") != 1 {
+			allErrs = append(allErrs, field.Invalid(pcPath.Child("repository"), pc.Repository, "repository must have the format '<owner>/<repository>'"))
+		}
+		if pc.Token == "" && pc.TokenFile == "" {
+			allErrs = append(allErrs, field.Required(pcPath.Child("token"), "one of token and tokenFile must be set"))
+		} else if pc.Token != "" && pc.TokenFile != "" {
+			allErrs = append(allErrs, field.Forbidden(pcPath.Child("tokenFile"), "only one of token and tokenFile must be set"))
+		}
+	}
+
+	if repoConfig.Provenance != nil && repoConfig.Provenance.SigningKey != "" && repoConfig.Provenance.SigningKeyFile != "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("provenance"), "<redacted>", "at most one of 'signingKey' and 'signingKeyFile' must be set"))
+	}
+
+	if repoConfig.Index != nil {
+		fileName := repoConfig.Index.FileName
+		if fileName == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("index", "fileName"), "fileName is required, but it should have been defaulted, check coding"))
+		} else if strings.ContainsAny(fileName, "/\\") || fileName == "." || fileName == ".." || fileName == ".git" || fileName == ".k8syncer-provenance.yaml" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("index", "fileName"), fileName, "fileName must be the name of a file in the root of the repository and must not be reserved by git or K8Syncer"))
+		}
+	}
+
+	return allErrs
+}
+
+// validateGitCLIBackend validates the restrictions of the 'cli' git backend.
+// The git binary works on the local filesystem and receives the SSH key as a file, so in-memory filesystems and
+// SSH credentials which are not available as a key file are not supported.
+func (v *validator) validateGitCLIBackend(sd *StorageDefinition, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if sd.FileSystemConfig != nil {
+		if sd.FileSystemConfig.InMemory != nil && *sd.FileSystemConfig.InMemory {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("filesystemConfig", "inMemory"), fmt.Sprintf("in-memory filesystems are not supported by git backend '%s'", string(GIT_BACKEND_CLI))))
+		} else if sd.FileSystemConfig.RootPath == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("filesystemConfig", "rootPath"), fmt.Sprintf("root path must not be empty for git backend '%s'", string(GIT_BACKEND_CLI))))
+		}
+	}
+
+	gitPath := fldPath.Child("gitConfig")
+	auths := map[*field.Path]*GitRepoAuth{gitPath.Child("auth"): sd.GitConfig.Auth, gitPath.Child("secondaryAuth"): sd.GitConfig.SecondaryAuth}
+	if nb := sd.GitConfig.NamespaceBranches; nb != nil {
+		nbPath := gitPath.Child("namespaceBranches")
+		for idx, m := range nb.Namespaces {
+			if m != nil {
+				auths[nbPath.Child("namespaces").Index(idx).Child("auth")] = m.Auth
+			}
+		}
+		if nb.AuthSecret != nil && nb.AuthSecret.Type == GIT_AUTH_SSH {
+			allErrs = append(allErrs, field.Forbidden(nbPath.Child("authSecret"), fmt.Sprintf("reading SSH keys from secrets is not supported by git backend '%s'", string(GIT_BACKEND_CLI))))
+		}
+	}
+	for authPath, auth := range auths {
+		if auth == nil || auth.Type != GIT_AUTH_SSH {
+			continue
+		}
+		if auth.Vault != nil {
+			allErrs = append(allErrs, field.Forbidden(authPath.Child("vault"), fmt.Sprintf("fetching SSH credentials from vault is not supported by git backend '%s'", string(GIT_BACKEND_CLI))))
+		}
+		if auth.PrivateKey != "" {
+			allErrs = append(allErrs, field.Forbidden(authPath.Child("privateKey"), fmt.Sprintf("inline SSH keys are not supported by git backend '%s', use 'privateKeyFile' instead", string(GIT_BACKEND_CLI))))
+		}
+		if auth.Password != "" || auth.PasswordFrom != nil {
+			allErrs = append(allErrs, field.Forbidden(authPath.Child("password"), fmt.Sprintf("encrypted SSH keys are not supported by git backend '%s'", string(GIT_BACKEND_CLI))))
+		}
+	}
+
 	return allErrs
 }
 
@@ -304,10 +1704,66 @@ func (v *validator) validateResourceSyncConfig(resourceSyncConfig *ResourceSyncC
 	if resourceSyncConfig.Version == "" {
 		allErrs = append(allErrs, field.Required(fldPath.Child("version"), "resource version must not be empty"))
 	}
+	if resourceSyncConfig.PersistVersion != "" {
+		for _, msg := range validation.IsDNS1035Label(resourceSyncConfig.PersistVersion) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("persistVersion"), resourceSyncConfig.PersistVersion, msg))
+		}
+	}
+	if resourceSyncConfig.FieldSelector != "" {
+		if _, err := fields.ParseSelector(resourceSyncConfig.FieldSelector); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("fieldSelector"), resourceSyncConfig.FieldSelector, err.Error()))
+		}
+	}
+	switch resourceSyncConfig.Scope {
+	case "", RESOURCE_SCOPE_NAMESPACED:
+	case RESOURCE_SCOPE_CLUSTER:
+		if resourceSyncConfig.Namespace != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("namespace"), "must not be set for cluster-scoped resources"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("scope"), string(resourceSyncConfig.Scope), []string{string(RESOURCE_SCOPE_CLUSTER), string(RESOURCE_SCOPE_NAMESPACED)}))
+	}
 
 	return allErrs
 }
 
+// syncConflicts returns a description for each namespace in which the resource is already synced into one of the given storage references.
+// synced maps the namespaces in which the resource is watched to the storage references, which are mapped to the ID of the sync config referencing them.
+// namespace is the namespace in which the resource is watched, empty for cluster-scoped resources and for all namespaces.
+// scope is the configured scope of the resource, empty if it is unknown.
+func syncConflicts(synced map[string]map[string]string, namespace string, scope ResourceScope, srNames []string) []string {
+	res := []string{}
+	for _, ns := range sets.List(sets.KeySet(synced)) {
+		var reason string
+		switch {
+		case scope == RESOURCE_SCOPE_CLUSTER:
+			reason = "same cluster-scoped resource"
+		case ns == "" && namespace == "" && scope == RESOURCE_SCOPE_NAMESPACED:
+			reason = "same resource, all namespaces"
+		case ns == "" && namespace == "":
+			reason = "same resource, all namespaces or cluster-scoped"
+		case ns == namespace:
+			reason = fmt.Sprintf("same resource, same namespace '%s'", ns)
+		case ns == "":
+			reason = fmt.Sprintf("same resource, all namespaces and namespace '%s'", namespace)
+		case namespace == "":
+			reason = fmt.Sprintf("same resource, namespace '%s' and all namespaces", ns)
+		default:
+			continue
+		}
+		overlapping := map[string][]string{}
+		for _, srName := range sets.List(sets.New(srNames...)) {
+			if id, ok := synced[ns][srName]; ok {
+				overlapping[id] = append(overlapping[id], srName)
+			}
+		}
+		for _, id := range sets.List(sets.KeySet(overlapping)) {
+			res = append(res, fmt.Sprintf("conflicting sync (%s) with overlapping storage references found: sync config '%s' also syncs into [%s]", reason, id, strings.Join(overlapping[id], ", ")))
+		}
+	}
+	return res
+}
+
 func (v *validator) validateStateConfiguration(sdCfg *StateConfiguration, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	if sdCfg == nil || sdCfg.Type == STATE_TYPE_NONE {
@@ -322,18 +1778,225 @@ func (v *validator) validateStateConfiguration(sdCfg *StateConfiguration, fldPat
 		allErrs = append(allErrs, field.NotSupported(fldPath.Child("verbosity"), string(sdCfg.Verbosity), []string{string(STATE_VERBOSITY_GENERATION), string(STATE_VERBOSITY_PHASE), string(STATE_VERBOSITY_DETAIL)}))
 	}
 
+	switch sdCfg.FailurePolicy {
+	case "", STATE_FAILURE_POLICY_FAIL, STATE_FAILURE_POLICY_WARN, STATE_FAILURE_POLICY_IGNORE:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("failurePolicy"), string(sdCfg.FailurePolicy), []string{string(STATE_FAILURE_POLICY_FAIL), string(STATE_FAILURE_POLICY_WARN), string(STATE_FAILURE_POLICY_IGNORE)}))
+	}
+
 	switch sdCfg.Type {
 	case STATE_TYPE_NONE:
 	case STATE_TYPE_ANNOTATION:
+		allErrs = append(allErrs, v.validateAnnotationStateConfiguration(sdCfg.AnnotationStateConfig, fldPath.Child("annotationConfig"))...)
 	case STATE_TYPE_STATUS:
 		allErrs = append(allErrs, v.validateStatusStateConfiguration(sdCfg.StatusStateConfig, sdCfg.Verbosity, fldPath.Child("statusConfig"))...)
+	case STATE_TYPE_CONFIGMAP:
+		allErrs = append(allErrs, v.validateConfigMapStateConfiguration(sdCfg.ConfigMapStateConfig, fldPath.Child("configMapConfig"))...)
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("type"), string(sdCfg.Type), []string{string(STATE_TYPE_NONE), string(STATE_TYPE_ANNOTATION), string(STATE_TYPE_STATUS), string(STATE_TYPE_CONFIGMAP)}))
+	}
+
+	return allErrs
+}
+
+func (v *validator) validateAnnotationStateConfiguration(asCfg *AnnotationStateConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if asCfg == nil {
+		// the default annotation keys are used
+		return allErrs
+	}
+
+	if asCfg.Prefix == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("prefix"), "prefix is required, but it should have been defaulted, check coding"))
+	} else if !utils.IsStateAnnotation(asCfg.Prefix + "/") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("prefix"), asCfg.Prefix, fmt.Sprintf("prefix must be '%s' or a subdomain of it", constants.STATE_ANNOTATION_DOMAIN)))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(asCfg.Prefix) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("prefix"), asCfg.Prefix, msg))
+		}
+	}
+	keys := sets.New[string]()
+	for _, k := range []struct {
+		name  string
+		value string
+	}{
+		{"lastSyncedGenerationKey", asCfg.LastSyncedGenerationKey},
+		{"phaseKey", asCfg.PhaseKey},
+		{"detailKey", asCfg.DetailKey},
+	} {
+		curPath := fldPath.Child(k.name)
+		if k.value == "" {
+			allErrs = append(allErrs, field.Required(curPath, "annotation key is required, but it should have been defaulted, check coding"))
+			continue
+		}
+		if !utils.IsStateAnnotation(k.value) {
+			allErrs = append(allErrs, field.Invalid(curPath, k.value, fmt.Sprintf("annotation key must have the prefix '%s' or a subdomain of it", constants.STATE_ANNOTATION_DOMAIN)))
+			continue
+		}
+		for _, msg := range validation.IsQualifiedName(k.value) {
+			allErrs = append(allErrs, field.Invalid(curPath, k.value, msg))
+		}
+		if k.value == constants.ANNOTATION_CONTENT_HASH {
+			allErrs = append(allErrs, field.Invalid(curPath, k.value, "annotation key is reserved for the content hash"))
+		}
+		if keys.Has(k.value) {
+			allErrs = append(allErrs, field.Duplicate(curPath, k.value))
+		}
+		keys.Insert(k.value)
+	}
+
+	return allErrs
+}
+
+func (v *validator) validateConfigMapStateConfiguration(cmCfg *ConfigMapStateConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if cmCfg == nil {
+		allErrs = append(allErrs, field.Required(fldPath, "configmap state configuration is required, but it should have been defaulted, check coding"))
+		return allErrs
+	}
+
+	if cmCfg.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), "name is required, but it should have been defaulted, check coding"))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(cmCfg.Name) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), cmCfg.Name, msg))
+		}
+	}
+	if cmCfg.ClusterScopedNamespace == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("clusterScopedNamespace"), "namespace is required, but it should have been defaulted, check coding"))
+	} else {
+		for _, msg := range validation.IsDNS1123Label(cmCfg.ClusterScopedNamespace) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("clusterScopedNamespace"), cmCfg.ClusterScopedNamespace, msg))
+		}
+	}
+
+	return allErrs
+}
+
+// minShootKubeconfigExpiration is the shortest validity of admin kubeconfigs which is accepted by Gardener.
+const minShootKubeconfigExpiration = 10 * time.Minute
+
+func validateShootReference(shootRef *ShootReference, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if shootRef == nil {
+		return allErrs
+	}
+
+	if shootRef.Namespace == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("namespace"), "shoot namespace must not be empty"))
+	}
+	if shootRef.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), "shoot name must not be empty"))
+	}
+	if shootRef.Expiration == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("expiration"), "expiration is required, but it should have been defaulted, check coding"))
+	} else if shootRef.Expiration.Duration < minShootKubeconfigExpiration {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("expiration"), shootRef.Expiration.Duration.String(), fmt.Sprintf("expiration must be at least %s", minShootKubeconfigExpiration.String())))
+	}
+
+	return allErrs
+}
+
+func (v *validator) validateImpersonationConfiguration(impCfg *ImpersonationConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if impCfg == nil {
+		return allErrs
+	}
+
+	if (impCfg.ServiceAccount == "") == (impCfg.User == "") {
+		allErrs = append(allErrs, field.Invalid(fldPath, impCfg, "exactly one of 'serviceAccount' and 'user' must be set"))
+	}
+	if impCfg.ServiceAccount != "" {
+		if ns, name, ok := strings.Cut(impCfg.ServiceAccount, "/"); !ok || ns == "" || name == "" || strings.Contains(name, "/") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("serviceAccount"), impCfg.ServiceAccount, "service account must be specified in the format '<namespace>/<name>'"))
+		}
+	}
+
+	return allErrs
+}
+
+func (v *validator) validateSnapshotConfiguration(snapCfg *SnapshotConfiguration, refs []*StorageReference, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if snapCfg == nil {
+		return allErrs
+	}
+
+	if snapCfg.Interval == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("interval"), "snapshot interval must not be empty"))
+	} else if snapCfg.Interval.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("interval"), snapCfg.Interval.Duration.String(), "interval must be positive"))
+	}
+
+	switch snapCfg.Format {
+	case SNAPSHOT_FORMAT_YAML, SNAPSHOT_FORMAT_TARGZ:
+	case "":
+		allErrs = append(allErrs, field.Required(fldPath.Child("format"), "format is required, but it should have been defaulted, check coding"))
 	default:
-		allErrs = append(allErrs, field.NotSupported(fldPath.Child("type"), string(sdCfg.Type), []string{string(STATE_TYPE_NONE), string(STATE_TYPE_ANNOTATION), string(STATE_TYPE_STATUS)}))
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("format"), string(snapCfg.Format), []string{string(SNAPSHOT_FORMAT_YAML), string(SNAPSHOT_FORMAT_TARGZ)}))
+	}
+
+	// the source has to be one of the sync config's storage references
+	var source *StorageReference
+	for _, ref := range refs {
+		if ref != nil && ref.Name == snapCfg.Source {
+			source = ref
+			break
+		}
+	}
+	if snapCfg.Source == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("source"), "source is required, but it should have been defaulted, check coding"))
+	} else if source == nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("source"), snapCfg.Source, "source must be the name of one of the sync config's storage references"))
+	} else if sd, ok := v.storageDefs[source.Name]; ok && !supportsSnapshots(sd) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("source"), snapCfg.Source, fmt.Sprintf("snapshots are not supported for storages of type '%s'", string(sd.Type))))
+	}
+
+	if snapCfg.Target == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("target"), "snapshot target must not be empty"))
+		return allErrs
+	}
+	tgtPath := fldPath.Child("target")
+	if snapCfg.Target.Name == "" {
+		allErrs = append(allErrs, field.Required(tgtPath.Child("name"), "target storage name must not be empty"))
+	} else if sd, ok := v.storageDefs[snapCfg.Target.Name]; !ok {
+		allErrs = append(allErrs, field.Invalid(tgtPath.Child("name"), snapCfg.Target.Name, "storage definition with this name does not exist"))
+	} else if !supportsSnapshots(sd) {
+		allErrs = append(allErrs, field.Invalid(tgtPath.Child("name"), snapCfg.Target.Name, fmt.Sprintf("snapshots cannot be stored in storages of type '%s'", string(sd.Type))))
+	}
+	tgtSubPath, err := v.renderSubPath(snapCfg.Target.SubPath)
+	if err != nil {
+		allErrs = append(allErrs, field.Invalid(tgtPath.Child("subPath"), snapCfg.Target.SubPath, fmt.Sprintf("invalid subPath template: %s", err.Error())))
+	} else if source != nil && source.Name == snapCfg.Target.Name {
+		// snapshots must not be stored within the tree which is snapshotted
+		if srcSubPath, err := v.renderSubPath(source.SubPath); err == nil {
+			rel, err := filepath.Rel(filepath.Join("/", srcSubPath), filepath.Join("/", tgtSubPath))
+			if err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+				allErrs = append(allErrs, field.Forbidden(tgtPath.Child("subPath"), "snapshots must not be stored below the subPath of the source storage reference"))
+			}
+		}
 	}
 
 	return allErrs
 }
 
+// supportsSnapshots returns whether snapshots can be read from and written to storages of the given definition's type.
+func supportsSnapshots(sd *StorageDefinition) bool {
+	switch sd.Type {
+	case STORAGE_TYPE_FILESYSTEM, STORAGE_TYPE_GIT, STORAGE_TYPE_SFTP, STORAGE_TYPE_WEBDAV:
+		return true
+	}
+	return false
+}
+
+// renderSubPath parses and renders the given subPath template with the validator's template data.
+func (v *validator) renderSubPath(subPath string) (string, error) {
+	tmpl, err := ParseSubPathTemplate(subPath)
+	if err != nil {
+		return "", err
+	}
+	return RenderSubPath(tmpl, subPath, v.subPathTemplateData)
+}
+
 func (v *validator) validateStatusStateConfiguration(ssCfg *StatusStateConfiguration, verbosity StateVerbosity, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	if ssCfg == nil {
@@ -372,6 +2035,26 @@ func (v *validator) validateGitRepoAuth(auth *GitRepoAuth, fldPath *field.Path)
 		return allErrs
 	}
 
+	if auth.Vault != nil {
+		switch auth.Type {
+		case GIT_AUTH_USERNAME_PASSWORD, GIT_AUTH_SSH:
+		default:
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("type"), string(auth.Type), []string{string(GIT_AUTH_USERNAME_PASSWORD), string(GIT_AUTH_SSH)}))
+		}
+		if auth.Username != "" || auth.Password != "" || auth.PasswordFrom != nil || auth.PrivateKey != "" || auth.PrivateKeyFile != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath, "credentials must not be specified if they are fetched from vault"))
+		}
+		allErrs = append(allErrs, v.validateVaultConfiguration(auth.Vault, fldPath.Child("vault"))...)
+		return allErrs
+	}
+
+	if auth.PasswordFrom != nil {
+		if auth.Password != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("password"), "password must not be set if it is fetched via 'passwordFrom'"))
+		}
+		allErrs = append(allErrs, v.validateSecretValueSource(auth.PasswordFrom, fldPath.Child("passwordFrom"))...)
+	}
+
 	switch auth.Type {
 	case GIT_AUTH_USERNAME_PASSWORD:
 		allErrs = append(allErrs, v.validateGitRepoAuthForUserPass(auth, fldPath)...)
@@ -390,7 +2073,7 @@ func (v *validator) validateGitRepoAuthForUserPass(auth *GitRepoAuth, fldPath *f
 	if auth.Username == "" {
 		allErrs = append(allErrs, field.Required(fldPath.Child("username"), "username is required for the chosen authentication type"))
 	}
-	if auth.Password == "" {
+	if auth.Password == "" && auth.PasswordFrom == nil {
 		allErrs = append(allErrs, field.Required(fldPath.Child("password"), "password is required for the chosen authentication type"))
 	}
 
@@ -414,8 +2097,81 @@ func (v *validator) validateGitRepoAuthForSSH(auth *GitRepoAuth, fldPath *field.
 	if auth.Username != "" {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("username"), auth.Username, "username must not be set for the chosen authentication type"))
 	}
-	if auth.Password != "" {
-		allErrs = append(allErrs, field.Invalid(fldPath.Child("password"), auth.Password, "password must not be set for the chosen authentication type"))
+
+	return allErrs
+}
+
+func (v *validator) validateSecretValueSource(svs *SecretValueSource, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if (svs.AWSSecretsManager == nil) == (svs.GCPSecretManager == nil) {
+		allErrs = append(allErrs, field.Invalid(fldPath, svs, "exactly one of 'awsSecretsManager' and 'gcpSecretManager' must be set"))
+	}
+
+	if aws := svs.AWSSecretsManager; aws != nil {
+		awsPath := fldPath.Child("awsSecretsManager")
+		if aws.Region == "" {
+			allErrs = append(allErrs, field.Required(awsPath.Child("region"), "region must not be empty"))
+		}
+		if aws.SecretID == "" {
+			allErrs = append(allErrs, field.Required(awsPath.Child("secretId"), "secretId must not be empty"))
+		}
+		if aws.Endpoint != "" {
+			if u, err := url.Parse(aws.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				allErrs = append(allErrs, field.Invalid(awsPath.Child("endpoint"), aws.Endpoint, "endpoint must be an absolute http or https URL"))
+			}
+		}
+	}
+
+	if gcp := svs.GCPSecretManager; gcp != nil {
+		gcpPath := fldPath.Child("gcpSecretManager")
+		if gcp.Project == "" {
+			allErrs = append(allErrs, field.Required(gcpPath.Child("project"), "project must not be empty"))
+		}
+		if gcp.Secret == "" {
+			allErrs = append(allErrs, field.Required(gcpPath.Child("secret"), "secret must not be empty"))
+		}
+		if gcp.Version == "" {
+			allErrs = append(allErrs, field.Required(gcpPath.Child("version"), "version is required, but it should have been defaulted, check coding"))
+		}
+		if gcp.Endpoint == "" {
+			allErrs = append(allErrs, field.Required(gcpPath.Child("endpoint"), "endpoint is required, but it should have been defaulted, check coding"))
+		} else if u, err := url.Parse(gcp.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(gcpPath.Child("endpoint"), gcp.Endpoint, "endpoint must be an absolute http or https URL"))
+		}
+	}
+
+	if svs.RefreshInterval == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("refreshInterval"), "refreshInterval is required, but it should have been defaulted, check coding"))
+	} else if svs.RefreshInterval.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("refreshInterval"), svs.RefreshInterval.Duration.String(), "refreshInterval must be positive"))
+	}
+
+	return allErrs
+}
+
+func (v *validator) validateVaultConfiguration(vaultCfg *VaultConfiguration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if vaultCfg.Address == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("address"), "vault address must not be empty"))
+	}
+	if vaultCfg.Role == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("role"), "vault role must not be empty"))
+	}
+	if vaultCfg.Path == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("path"), "vault secret path must not be empty"))
+	}
+	if vaultCfg.CACert != "" && vaultCfg.CACertFile != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("caCertFile"), "caCertFile must not be set together with caCert"))
+	}
+	if vaultCfg.EngineVersion != 1 && vaultCfg.EngineVersion != 2 {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("engineVersion"), vaultCfg.EngineVersion, []string{"1", "2"}))
+	}
+	if vaultCfg.RefreshInterval == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("refreshInterval"), "refreshInterval is required, but it should have been defaulted, check coding"))
+	} else if vaultCfg.RefreshInterval.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("refreshInterval"), vaultCfg.RefreshInterval.Duration.String(), "refreshInterval must be positive"))
 	}
 
 	return allErrs
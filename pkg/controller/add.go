@@ -5,35 +5,95 @@
 package controller
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"reflect"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/gardener/landscaper/controller-utils/pkg/logging"
 	"github.com/go-logr/logr"
 
 	"github.com/gardener/k8syncer/pkg/config"
 	"github.com/gardener/k8syncer/pkg/persist"
+	"github.com/gardener/k8syncer/pkg/state"
+	"github.com/gardener/k8syncer/pkg/syncerrors"
+	"github.com/gardener/k8syncer/pkg/utils"
 	"github.com/gardener/k8syncer/pkg/utils/constants"
 )
 
 // AddControllerToManager register the installation Controller in a manager.
-func AddControllerToManager(baseLogger logging.Logger, mgr manager.Manager, cfg *config.K8SyncerConfiguration, syncConfig *config.SyncConfig, persisters map[string]persist.Persister) error {
+// The resources are watched in the given cluster, which has to be added to the manager already.
+// If cl is nil, the manager's cluster is used.
+// If errorCache is not nil, the controller records the last error per reconciled object in it.
+// If synced is not nil, the controller keeps track of the objects it has synced successfully in it.
+// If trigger is not nil, the controller also reconciles the resources which are triggered via it.
+func AddControllerToManager(ctx context.Context, baseLogger logging.Logger, mgr manager.Manager, cl cluster.Cluster, cfg *config.K8SyncerConfiguration, syncConfig *config.SyncConfig, persisters map[string]persist.Persister, errorCache *syncerrors.Cache, synced *SyncedObjects, trigger *SyncTrigger) error {
 	log := baseLogger.WithName(syncConfig.ID).WithValues(constants.Logging.KEY_ID, syncConfig.ID, constants.Logging.KEY_RESOURCE_GROUP, syncConfig.Resource.Group, constants.Logging.KEY_RESOURCE_VERSION, syncConfig.Resource.Version, constants.Logging.KEY_RESOURCE_KIND, syncConfig.Resource.Kind)
-	c, err := NewController(mgr.GetClient(), cfg, syncConfig, persisters)
+	remote := cl != nil
+	if !remote {
+		cl = mgr
+	}
+	restCfg := cl.GetConfig()
+	cli := cl.GetClient()
+	// owners are fetched without the cache, as it would otherwise start watching all owner kinds
+	var apiReader client.Reader = cl.GetAPIReader()
+	var err error
+	if syncConfig.ClientRateLimit != nil {
+		// the sync config gets its own clients with a dedicated rate limit, reads are still answered from the shared cache
+		restCfg = WithRateLimit(restCfg, syncConfig.ClientRateLimit)
+		opts := client.Options{Scheme: cl.GetScheme(), Mapper: cl.GetRESTMapper()}
+		apiReader, err = client.New(restCfg, opts)
+		if err != nil {
+			return fmt.Errorf("error creating rate limited client for sync config '%s': %w", syncConfig.ID, err)
+		}
+		opts.Cache = &client.CacheOptions{Reader: cl.GetCache()}
+		cli, err = client.New(restCfg, opts)
+		if err != nil {
+			return fmt.Errorf("error creating rate limited client for sync config '%s': %w", syncConfig.ID, err)
+		}
+	}
+	c, err := NewController(cli, cfg, syncConfig, persisters)
 	if err != nil {
 		return err
 	}
+	c.ErrorCache = errorCache
+	c.SyncedObjects = synced
+	if cfg.ErrorLogs != nil && cfg.ErrorLogs.RepeatInterval != nil {
+		c.ErrorLogs = syncerrors.NewLogDeduplicator(cfg.ErrorLogs.RepeatInterval.Duration)
+	}
+	c.OwnerReader = apiReader
+	if syncConfig.Transformer != nil && syncConfig.Transformer.PruneDefaults {
+		// the schema is fetched without the cache, as it would otherwise start watching all CRDs
+		if err := c.EnableDefaultsPruning(logging.NewContext(ctx, log), apiReader, restCfg, cl.GetRESTMapper()); err != nil {
+			return fmt.Errorf("error fetching schema for pruning defaulted fields in sync config '%s': %w", syncConfig.ID, err)
+		}
+	}
 	logFields := []interface{}{}
+	if remote {
+		logFields = append(logFields, constants.Logging.KEY_CLUSTER, cl.GetConfig().Host)
+	}
+	if syncConfig.Impersonate != nil {
+		c.ReadClient, err = newImpersonatedClient(restCfg, client.Options{Scheme: cl.GetScheme(), Mapper: cl.GetRESTMapper()}, syncConfig.Impersonate)
+		if err != nil {
+			return fmt.Errorf("error creating impersonated client for sync config '%s': %w", syncConfig.ID, err)
+		}
+		c.OwnerReader = c.ReadClient
+		logFields = append(logFields, constants.Logging.KEY_IMPERSONATED_USER, syncConfig.Impersonate.UserName())
+	}
 	if c.SyncConfig.Resource.Namespace != "" {
 		logFields = append(logFields, constants.Logging.KEY_WATCHED_NAMESPACE, c.SyncConfig.Resource.Namespace)
 	}
@@ -45,7 +105,11 @@ func AddControllerToManager(baseLogger logging.Logger, mgr manager.Manager, cfg
 		scNames = append(scNames, fmt.Sprintf("%s<%s>", sc.Name(), string(sc.Type)))
 	}
 	logFields = append(logFields, constants.Logging.KEY_CONFIGURED_STORAGES, fmt.Sprintf("[%s]", strings.Join(scNames, ", ")))
+	if syncConfig.ReadOnlySource {
+		logFields = append(logFields, constants.Logging.KEY_READ_ONLY_SOURCE, true)
+	}
 	log.Info("sync configured", logFields...)
+	log.Info("sync summary", constants.Logging.KEY_SUMMARY, SummarizeSyncConfig(cfg, syncConfig))
 
 	u := &unstructured.Unstructured{}
 	u.SetGroupVersionKind(schema.GroupVersionKind{
@@ -54,28 +118,199 @@ func AddControllerToManager(baseLogger logging.Logger, mgr manager.Manager, cfg
 		Kind:    syncConfig.Resource.Kind,
 	})
 
-	// only react if generation, labels, or ownerReferences changed
-	preds := predicate.Or(
-		predicate.GenerationChangedPredicate{},
-		predicate.LabelChangedPredicate{},
-		OwnerReferencesChangedPredicate{},
-	)
+	preds, _ := eventFilter(syncConfig)
+
+	if err := VerifyResourceScope(cl.GetRESTMapper(), syncConfig); err != nil {
+		return err
+	}
+	if err := registerWatchedResource(cl.GetRESTMapper(), syncConfig.ClusterKey(), c.GVK, syncConfig.ID); err != nil {
+		return fmt.Errorf("error determining watched resource for sync config '%s': %w", syncConfig.ID, err)
+	}
+
+	var eventHandler handler.EventHandler = &handler.EnqueueRequestForObject{}
+	if syncConfig.Debounce != nil {
+		// updates in rapid succession are collapsed into a single sync
+		eventHandler = DebouncingEventHandler{EventHandler: eventHandler, Window: syncConfig.Debounce.Duration}
+	}
+
+	// the predicates only look at the metadata, but the resources have to be watched as a whole anyway, because they are persisted,
+	// so a metadata-only watch for the predicates would just add a second informer
+	bldr := builder.ControllerManagedBy(mgr).
+		Named(strings.ToLower(syncConfig.ID)).
+		WithEventFilter(preds).
+		WithLogConstructor(c.LogConstructor(log))
+	if remote || syncConfig.Debounce != nil {
+		// resources which are not contained in the manager's cluster have to be watched via the other cluster's cache
+		// 'For' doesn't allow a custom event handler, so debounced resources are watched explicitly too
+		bldr = bldr.WatchesRawSource(source.Kind(cl.GetCache(), u), eventHandler)
+	} else {
+		bldr = bldr.For(u)
+	}
+	if trigger != nil {
+		// triggered resources pass the event filter, as none of the predicates rejects generic events apart from the namespace one
+		bldr = bldr.WatchesRawSource(trigger.source(syncConfig, c.GVK), &handler.EnqueueRequestForObject{})
+	}
+	if err := bldr.Complete(c); err != nil {
+		return err
+	}
+	if syncConfig.PersistCRD {
+		if err := c.addCRDControllerToManager(log, mgr, cl, remote); err != nil {
+			return fmt.Errorf("error adding CRD controller to manager: %w", err)
+		}
+	}
+	if syncConfig.ReadOnlySource {
+		// without finalizers, deletions which happened while K8Syncer was not running have to be detected on startup
+		// the runnable is started after the caches have been synced
+		if err := mgr.Add(c.orphanCleanup(log)); err != nil {
+			return fmt.Errorf("error adding orphan cleanup to manager: %w", err)
+		}
+	}
+	return nil
+}
+
+// addCRDControllerToManager adds a controller to the manager which keeps the CRD of the controller's kind up-to-date in the storages.
+func (c *Controller) addCRDControllerToManager(log logging.Logger, mgr manager.Manager, cl cluster.Cluster, remote bool) error {
+	crdName, err := CRDName(cl.GetRESTMapper(), c.GVK)
+	if err != nil {
+		return err
+	}
+	log = log.WithName("crd")
+	log.Info("CRD sync configured", constants.Logging.KEY_RESOURCE_NAME, crdName)
+
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(CRDGVK)
+	bldr := builder.ControllerManagedBy(mgr).
+		// sync config IDs must not contain dots, so the name cannot conflict with the one of another sync config's controller
+		Named(strings.ToLower(c.SyncConfig.ID) + ".crd").
+		WithEventFilter(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			return obj.GetName() == crdName
+		})).
+		WithLogConstructor(func(r *reconcile.Request) logr.Logger { return log.Logr() })
+	if remote {
+		bldr = bldr.WatchesRawSource(source.Kind(cl.GetCache(), crd), &handler.EnqueueRequestForObject{})
+	} else {
+		bldr = bldr.For(crd)
+	}
+	return bldr.Complete(&crdReconciler{
+		Controller: c,
+		crdName:    crdName,
+	})
+}
+
+// newImpersonatedClient returns a client which impersonates the configured subject.
+// The client does not use the manager's cache, as the cache's view is not restricted by the impersonated subject's permissions.
+func newImpersonatedClient(baseCfg *rest.Config, opts client.Options, impCfg *config.ImpersonationConfiguration) (client.Client, error) {
+	restCfg := rest.CopyConfig(baseCfg)
+	restCfg.Impersonate = rest.ImpersonationConfig{
+		UserName: impCfg.UserName(),
+		Groups:   impCfg.Groups,
+	}
+	return client.New(restCfg, opts)
+}
+
+// eventFilter returns the predicate which filters the events of the resources of the given sync config,
+// together with a description of the predicates it consists of, in the format '<name>' or '<name>=<value>'.
+func eventFilter(syncConfig *config.SyncConfig) (predicate.Predicate, []string) {
+	names := []string{}
+	for _, t := range syncConfig.ReactOn {
+		names = append(names, fmt.Sprintf("reactOn=%s", string(t)))
+	}
+	names = append(names, "suspendAnnotationChanged")
+	// only react if the resource changed according to the configured triggers
+	preds := predicate.Or(reactOnPredicate(syncConfig.ReactOn), SuspendAnnotationChangedPredicate{})
 	if syncConfig.Finalize != nil && *syncConfig.Finalize {
 		// to remove finalizers, we have to get notified for deletion timestamps
 		preds = predicate.Or(preds, DeletionTimestampChangedPredicate{})
+		names = append(names, "deletionTimestampChanged")
 	}
 	if syncConfig.Resource.Namespace != "" {
 		preds = predicate.And(preds, predicate.NewPredicateFuncs(func(obj client.Object) bool {
 			return obj.GetNamespace() != "" && obj.GetNamespace() == syncConfig.Resource.Namespace
 		}))
+		names = append(names, fmt.Sprintf("namespace=%s", syncConfig.Resource.Namespace))
+	}
+	if syncConfig.Resource.Scope != "" {
+		preds = predicate.And(preds, scopePredicate(syncConfig.Resource.Scope))
+		names = append(names, fmt.Sprintf("scope=%s", string(syncConfig.Resource.Scope)))
 	}
 
-	return builder.ControllerManagedBy(mgr).
-		For(u).
-		Named(strings.ToLower(syncConfig.ID)).
-		WithEventFilter(preds).
-		WithLogConstructor(func(r *reconcile.Request) logr.Logger { return log.Logr() }).
-		Complete(c)
+	// events with outdated versions of the resource must not overwrite newer ones in the storages
+	preds = predicate.And(StaleEventPredicate{SyncConfigID: syncConfig.ID}, preds)
+	names = append(names, "staleEvent")
+	return preds, names
+}
+
+// reactOnPredicate returns a predicate which reacts to changes of the resource for any of the given triggers.
+// Unknown triggers are ignored, they are prevented by the config validation.
+func reactOnPredicate(triggers []config.ReactOnTrigger) predicate.Predicate {
+	preds := make([]predicate.Predicate, 0, len(triggers))
+	for _, t := range triggers {
+		switch t {
+		case config.REACT_ON_GENERATION:
+			preds = append(preds, predicate.GenerationChangedPredicate{})
+		case config.REACT_ON_LABELS:
+			preds = append(preds, predicate.LabelChangedPredicate{})
+		case config.REACT_ON_ANNOTATIONS:
+			preds = append(preds, AnnotationsChangedPredicate{})
+		case config.REACT_ON_OWNER_REFERENCES:
+			preds = append(preds, OwnerReferencesChangedPredicate{})
+		case config.REACT_ON_RESOURCE_VERSION:
+			preds = append(preds, predicate.ResourceVersionChangedPredicate{})
+		case config.REACT_ON_CONTENT_HASH:
+			preds = append(preds, ContentHashChangedPredicate{})
+		}
+	}
+	return predicate.Or(preds...)
+}
+
+// AnnotationsChangedPredicate reacts to changes of the annotations.
+// Annotations written by K8Syncer itself are ignored, as writing the state would otherwise trigger another sync.
+type AnnotationsChangedPredicate struct {
+	predicate.Funcs
+}
+
+func (AnnotationsChangedPredicate) Update(e event.UpdateEvent) bool {
+	if e.ObjectOld == nil {
+		return false
+	}
+	if e.ObjectNew == nil {
+		return false
+	}
+	return !reflect.DeepEqual(foreignAnnotations(e.ObjectOld), foreignAnnotations(e.ObjectNew))
+}
+
+// foreignAnnotations returns the annotations of the given object, without the ones written by K8Syncer.
+func foreignAnnotations(obj client.Object) map[string]string {
+	res := map[string]string{}
+	for k, v := range obj.GetAnnotations() {
+		if !utils.IsStateAnnotation(k) {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// ContentHashChangedPredicate reacts to changes of the content hash, see state.ContentHash.
+type ContentHashChangedPredicate struct {
+	predicate.Funcs
+}
+
+func (ContentHashChangedPredicate) Update(e event.UpdateEvent) bool {
+	if e.ObjectOld == nil {
+		return false
+	}
+	if e.ObjectNew == nil {
+		return false
+	}
+	oldHash, err := state.ContentHash(e.ObjectOld)
+	if err != nil {
+		return true
+	}
+	newHash, err := state.ContentHash(e.ObjectNew)
+	if err != nil {
+		return true
+	}
+	return !bytes.Equal(oldHash, newHash)
 }
 
 // OwnerReferencesChangedPredicate reacts to changes of the owner references.
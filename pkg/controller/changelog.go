@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/gardener/k8syncer/pkg/persist"
+	"github.com/gardener/k8syncer/pkg/utils"
+)
+
+const (
+	// CHANGE_LOG_OPERATION_PERSIST is the operation of change log entries for resources which have been written to a storage.
+	CHANGE_LOG_OPERATION_PERSIST = "persist"
+	// CHANGE_LOG_OPERATION_DELETE is the operation of change log entries for resources which have been deleted from a storage.
+	CHANGE_LOG_OPERATION_DELETE = "delete"
+)
+
+// ChangeLogEntry is a single line of the change log of a sync config.
+type ChangeLogEntry struct {
+	Time       time.Time `json:"time"`
+	Operation  string    `json:"operation"`
+	GVK        string    `json:"gvk"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Name       string    `json:"name"`
+	Generation int64     `json:"generation,omitempty"`
+	// Digest is the SHA256 hash of the persisted data, prefixed with 'sha256:'. It is empty for deletions.
+	Digest string `json:"digest,omitempty"`
+}
+
+// appendChangeLog appends an entry for the given operation on the given resource to the change log below the given subPath.
+// The transformed resource is used to compute the digest, it is nil for deletions.
+// Failing to write the change log doesn't fail the sync, as the resource has already been changed in the storage,
+// so errors are only logged and the entry is lost.
+func (c *Controller) appendChangeLog(ctx context.Context, storage *StorageConfiguration, operation string, obj, transformed *unstructured.Unstructured, subPath string) {
+	if c.SyncConfig.ChangeLog == nil {
+		return
+	}
+	log := logging.FromContextOrDiscard(ctx)
+	aa, ok := persist.FindArtifactAppender(storage.Persister)
+	if !ok {
+		log.Debug("Storage does not support appending to files, change is not logged")
+		return
+	}
+	entry := &ChangeLogEntry{
+		Time:       time.Now().UTC(),
+		Operation:  operation,
+		GVK:        utils.GVKToString(c.persistGVK(), true),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+		Generation: obj.GetGeneration(),
+	}
+	if transformed != nil {
+		hash, err := contentHash(transformed)
+		if err != nil {
+			log.Error(err, "error computing digest for change log")
+			return
+		}
+		entry.Digest = "sha256:" + hash
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Error(err, "error marshalling change log entry")
+		return
+	}
+	if err := aa.AppendArtifact(ctx, append(data, '\n'), c.SyncConfig.ChangeLog.FileName, subPath); err != nil {
+		log.Error(err, "error appending to change log")
+	}
+}
@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+// Checkpoint describes the progress of a one-shot sync for a single sync config.
+type Checkpoint struct {
+	// Continue is the continue token for listing the next page of resources.
+	Continue string `json:"continue,omitempty"`
+	// LastProcessed is the key ('<namespace>/<name>') of the last resource which has been processed.
+	// It is used to skip already processed resources if the continue token has expired.
+	LastProcessed string `json:"lastProcessed,omitempty"`
+	// Completed is true if all resources of the sync config have been processed.
+	Completed bool `json:"completed,omitempty"`
+	// Failed contains the keys of the processed resources which could not be synced.
+	// They are retried when resuming, even if the checkpoint is completed.
+	Failed []string `json:"failed,omitempty"`
+}
+
+// CheckpointStore stores the checkpoints of a one-shot sync in a ConfigMap, with one key per sync config.
+// This allows a restarted one-shot sync to resume where it left off.
+type CheckpointStore struct {
+	client client.Client
+	key    types.NamespacedName
+}
+
+func NewCheckpointStore(c client.Client, namespace, name string) *CheckpointStore {
+	return &CheckpointStore{
+		client: c,
+		key: types.NamespacedName{
+			Namespace: namespace,
+			Name:      name,
+		},
+	}
+}
+
+// String returns the key of the ConfigMap which contains the checkpoints.
+func (cs *CheckpointStore) String() string {
+	return cs.key.String()
+}
+
+// Load returns the checkpoint for the sync config with the given ID.
+// Returns nil if there is no checkpoint for it.
+func (cs *CheckpointStore) Load(ctx context.Context, syncConfigID string) (*Checkpoint, error) {
+	cm := &corev1.ConfigMap{}
+	if err := cs.client.Get(ctx, cs.key, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching checkpoint configmap: %w", err)
+	}
+	raw, exists := cm.Data[syncConfigID]
+	if !exists {
+		return nil, nil
+	}
+	cp := &Checkpoint{}
+	if err := json.Unmarshal([]byte(raw), cp); err != nil {
+		return nil, fmt.Errorf("error parsing checkpoint under key '%s' in configmap: %w", syncConfigID, err)
+	}
+	return cp, nil
+}
+
+// Save stores the checkpoint for the sync config with the given ID, creating the ConfigMap if it doesn't exist.
+func (cs *CheckpointStore) Save(ctx context.Context, syncConfigID string, cp *Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}, func() error {
+		cm := &corev1.ConfigMap{}
+		if err := cs.client.Get(ctx, cs.key, cm); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return fmt.Errorf("error fetching checkpoint configmap: %w", err)
+			}
+			cm = &corev1.ConfigMap{}
+			cm.SetName(cs.key.Name)
+			cm.SetNamespace(cs.key.Namespace)
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[syncConfigID] = string(data)
+		if cm.ResourceVersion == "" {
+			return cs.client.Create(ctx, cm)
+		}
+		return cs.client.Update(ctx, cm)
+	})
+}
+
+// AllCompleted returns true if the checkpoints of all given sync configs are marked as completed and contain no failed resources.
+func (cs *CheckpointStore) AllCompleted(ctx context.Context, syncConfigs []*config.SyncConfig) (bool, error) {
+	for _, sc := range syncConfigs {
+		cp, err := cs.Load(ctx, sc.ID)
+		if err != nil {
+			return false, err
+		}
+		if cp == nil || !cp.Completed || len(cp.Failed) > 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Clear removes all checkpoints by deleting the ConfigMap.
+func (cs *CheckpointStore) Clear(ctx context.Context) error {
+	cm := &corev1.ConfigMap{}
+	cm.SetName(cs.key.Name)
+	cm.SetNamespace(cs.key.Namespace)
+	if err := cs.client.Delete(ctx, cm); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("error deleting checkpoint configmap: %w", err)
+	}
+	return nil
+}
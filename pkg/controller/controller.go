@@ -7,10 +7,15 @@ package controller
 import (
 	"context"
 	"fmt"
+	"text/template"
+	"time"
 
 	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/go-logr/logr"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -20,19 +25,49 @@ import (
 	"github.com/gardener/k8syncer/pkg/persist"
 	"github.com/gardener/k8syncer/pkg/persist/transformers"
 	"github.com/gardener/k8syncer/pkg/state"
+	"github.com/gardener/k8syncer/pkg/syncerrors"
 	"github.com/gardener/k8syncer/pkg/utils"
 	"github.com/gardener/k8syncer/pkg/utils/constants"
 )
 
-var basicTransformer = transformers.NewBasic() // will probably be configurable somehow in the future
+var basicTransformer = transformers.NewBasic()
 
 type Controller struct {
-	Client         client.Client
+	Client client.Client
+	// ReadClient is used to fetch the reconciled resources from the cluster.
+	// It differs from Client if an impersonation is configured for the sync config.
+	// If nil, Client is used.
+	ReadClient client.Client
+	// OwnerReader is used to fetch the owners of the reconciled resources, if the owners should be persisted.
+	// If nil, the ReadClient is used.
+	OwnerReader    client.Reader
 	Config         *config.K8SyncerConfiguration
 	SyncConfig     *config.SyncConfig
 	StorageConfigs []*StorageConfiguration
 	GVK            schema.GroupVersionKind
-	StateDisplay   state.StateDisplay
+	// PersistGVK is the GroupVersionKind under which the resources are persisted.
+	// It only differs from GVK in the version, if a persist version is configured.
+	// If empty, GVK is used.
+	PersistGVK   schema.GroupVersionKind
+	StateDisplay state.StateDisplay
+	// FieldSelector restricts the reconciled resources to the ones matching it, resources which don't match are treated as deleted.
+	// If nil, all resources are reconciled.
+	FieldSelector fields.Selector
+	// ErrorCache is used to keep track of the last error per reconciled object.
+	// If nil, errors are not recorded.
+	ErrorCache *syncerrors.Cache
+	// ErrorLogs deduplicates the errors which are logged repeatedly for the same object, see LogConstructor.
+	// If nil, all errors are logged.
+	ErrorLogs *syncerrors.LogDeduplicator
+	// SyncedObjects is used to keep track of the objects which have been synced successfully.
+	// If nil, the synced objects are not tracked.
+	SyncedObjects *SyncedObjects
+
+	// deletionFailures counts the failed deletions from the storages, if the sync config gives up on them.
+	// It is nil if failed deletions are retried forever.
+	deletionFailures *deletionFailureTracker
+	// uids remembers the UIDs of the reconciled resources, to verify the persisted data before deleting it.
+	uids *uidTracker
 }
 
 // StorageConfiguration is a helper struct to bundle a storage reference with its definition.
@@ -41,6 +76,13 @@ type StorageConfiguration struct {
 	*config.StorageDefinition
 	Persister   persist.Persister
 	Transformer persist.Transformer
+
+	// subPathTemplate is the parsed subPath of the storage reference.
+	// It is nil if the subPath does not contain any template actions.
+	subPathTemplate *template.Template
+	// quota tracks the persisted resources for the quota of the storage reference.
+	// It is nil if the storage reference doesn't configure a quota.
+	quota *quotaTracker
 }
 
 func (sc *StorageConfiguration) Name() string {
@@ -48,12 +90,23 @@ func (sc *StorageConfiguration) Name() string {
 	return sc.StorageReference.Name
 }
 
+// ResolveName returns the name under which the given object is stored, depending on the configured file naming.
+func (sc *StorageConfiguration) ResolveName(obj *unstructured.Unstructured) (string, error) {
+	return sc.FileNaming.StorageName(obj.GetName(), string(obj.GetUID()))
+}
+
+// ResolveSubPath returns the subPath of the storage reference with all template actions resolved.
+func (sc *StorageConfiguration) ResolveSubPath(data *config.SubPathTemplateData) (string, error) {
+	return config.RenderSubPath(sc.subPathTemplate, sc.SubPath, data)
+}
+
 func NewController(client client.Client, cfg *config.K8SyncerConfiguration, syncConfig *config.SyncConfig, persisters map[string]persist.Persister) (*Controller, error) {
 	ctrl := &Controller{
 		Client:     client,
 		Config:     cfg,
 		SyncConfig: syncConfig,
 	}
+	ctrl.uids = newUIDTracker()
 
 	// set GVK
 	ctrl.GVK = schema.GroupVersionKind{
@@ -61,13 +114,31 @@ func NewController(client client.Client, cfg *config.K8SyncerConfiguration, sync
 		Version: syncConfig.Resource.Version,
 		Kind:    syncConfig.Resource.Kind,
 	}
+	ctrl.PersistGVK = ctrl.GVK
+	ctrl.deletionFailures = newDeletionFailureTracker(client, syncConfig.ID, ctrl.GVK, syncConfig.DeletionFailure)
+	if syncConfig.Resource.PersistVersion != "" {
+		ctrl.PersistGVK.Version = syncConfig.Resource.PersistVersion
+	}
+	if syncConfig.Resource.FieldSelector != "" {
+		sel, err := fields.ParseSelector(syncConfig.Resource.FieldSelector)
+		if err != nil {
+			// should not happen, as this check is already part of the config validation
+			return nil, fmt.Errorf("invalid field selector in sync configuration with id %s: %w", syncConfig.ID, err)
+		}
+		ctrl.FieldSelector = sel
+	}
 
 	// configure state display, if any
 	if syncConfig.State != nil && syncConfig.State.Type != config.STATE_TYPE_NONE {
 		sdCfg := syncConfig.State
 		switch sdCfg.Type {
 		case config.STATE_TYPE_ANNOTATION:
-			ctrl.StateDisplay = state.NewAnnotationStateDisplay(state.StateVerbosity(sdCfg.Verbosity))
+			// without annotation state config, the default annotation keys are used
+			var generationKey, phaseKey, detailKey string
+			if asCfg := sdCfg.AnnotationStateConfig; asCfg != nil {
+				generationKey, phaseKey, detailKey = asCfg.LastSyncedGenerationKey, asCfg.PhaseKey, asCfg.DetailKey
+			}
+			ctrl.StateDisplay = state.NewAnnotationStateDisplay(generationKey, phaseKey, detailKey, state.StateVerbosity(sdCfg.Verbosity))
 		case config.STATE_TYPE_STATUS:
 			stCfg := sdCfg.StatusStateConfig
 			if stCfg == nil {
@@ -75,12 +146,40 @@ func NewController(client client.Client, cfg *config.K8SyncerConfiguration, sync
 				return nil, fmt.Errorf("missing state configuration for state type '%s' in sync configuration with id %s", string(syncConfig.State.Type), syncConfig.ID)
 			}
 			ctrl.StateDisplay = state.NewStatusStateDisplay(stCfg.GenerationPath, stCfg.PhasePath, stCfg.DetailPath, state.StateVerbosity(sdCfg.Verbosity))
+		case config.STATE_TYPE_CONFIGMAP:
+			cmCfg := sdCfg.ConfigMapStateConfig
+			if cmCfg == nil {
+				// should be prevented by defaulting
+				return nil, fmt.Errorf("missing state configuration for state type '%s' in sync configuration with id %s", string(syncConfig.State.Type), syncConfig.ID)
+			}
+			ctrl.StateDisplay = state.NewConfigMapStateDisplay(client, cmCfg.Name, cmCfg.ClusterScopedNamespace, state.StateVerbosity(sdCfg.Verbosity))
 		default:
 			// should not happen, as this check is already part of the config validation
 			return nil, fmt.Errorf("unknown state type '%s' in sync configuration with id %s", string(syncConfig.State.Type), syncConfig.ID)
 		}
 	}
 
+	// build transformer
+	var transformer persist.Transformer = basicTransformer
+	clusterName := syncConfig.ClusterNameOrDefault(cfg.ClusterName)
+	if clusterName != "" || syncConfig.Transformer != nil || syncConfig.ContentMode == config.CONTENT_MODE_METADATA_ONLY {
+		t := transformers.NewBasic()
+		if clusterName != "" {
+			t.InjectedAnnotations = map[string]string{
+				constants.ANNOTATION_CLUSTER_NAME: clusterName,
+			}
+		}
+		if tCfg := syncConfig.Transformer; tCfg != nil {
+			t.KeepAnnotations = tCfg.KeepAnnotations
+			t.KeepFinalizers = tCfg.KeepFinalizers
+			t.KeepStatus = tCfg.KeepStatus
+			t.KeepManagedFields = tCfg.KeepManagedFields
+			t.SecretData = tCfg.SecretData
+		}
+		t.MetadataOnly = syncConfig.ContentMode == config.CONTENT_MODE_METADATA_ONLY
+		transformer = t
+	}
+
 	// build storage configurations
 	ctrl.StorageConfigs = make([]*StorageConfiguration, len(syncConfig.StorageRefs))
 	for idx, stRef := range syncConfig.StorageRefs {
@@ -89,7 +188,19 @@ func NewController(client client.Client, cfg *config.K8SyncerConfiguration, sync
 		for _, stDef := range cfg.StorageDefinitions {
 			if stDef.Name == stRef.Name {
 				found = true
-				stCfg = &StorageConfiguration{stRef, stDef, persisters[stDef.Name], basicTransformer}
+				tmpl, err := config.ParseSubPathTemplate(stRef.SubPath)
+				if err != nil {
+					// should not happen, as this check is already part of the config validation
+					return nil, fmt.Errorf("invalid subPath template in storage reference at index %d in sync configuration with id %s: %w", idx, syncConfig.ID, err)
+				}
+				stCfg = &StorageConfiguration{
+					StorageReference:  stRef,
+					StorageDefinition: stDef,
+					Persister:         persisters[stDef.Name],
+					Transformer:       transformer,
+					subPathTemplate:   tmpl,
+					quota:             newQuotaTracker(syncConfig.ID, stRef),
+				}
 				break
 			}
 		}
@@ -115,18 +226,69 @@ func (c *Controller) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	obj.SetName(req.Name)
 	obj.SetNamespace(req.Namespace)
 	obj.SetGroupVersionKind(c.GVK)
-	err := c.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+	res, err := c.reconcile(ctx, obj)
+	c.ErrorCache.Record(c.SyncConfig.ID, c.GVK, req.Namespace, req.Name, err)
+	c.ErrorLogs.Done(log, req.Namespace, req.Name, err)
+	return res, err
+}
+
+// LogConstructor returns a function which returns the logger for reconciling the requested object,
+// based on the given logger. If the controller deduplicates error logs, the logger is deduplicating too.
+func (c *Controller) LogConstructor(log logging.Logger) func(*reconcile.Request) logr.Logger {
+	return func(req *reconcile.Request) logr.Logger {
+		if req == nil {
+			return log.Logr()
+		}
+		return c.ErrorLogs.Logger(log, req.Namespace, req.Name).Logr()
+	}
+}
+
+func (c *Controller) reconcile(ctx context.Context, obj *unstructured.Unstructured) (reconcile.Result, error) {
+	log := logging.FromContextOrDiscard(ctx)
+	if res, suspended := c.checkSuspension(ctx); suspended {
+		return res, nil
+	}
+	// the whole resource is fetched, although only the metadata is needed if it turns out to be deleted:
+	// the cache contains the whole resources anyway and the impersonated client would need a second request for existing ones
+	err := c.readClient().Get(ctx, client.ObjectKeyFromObject(obj), obj)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			return reconcile.Result{}, c.handleDelete(ctx, obj)
 		}
+		if apierrors.IsForbidden(err) && c.SyncConfig.Impersonate != nil {
+			// the resource is not visible for the impersonated subject, treat it as if it didn't exist
+			log.Debug("Resource is not visible for the impersonated subject")
+			return reconcile.Result{}, c.handleDelete(ctx, obj)
+		}
 		return reconcile.Result{}, fmt.Errorf("error fetching resource from cluster: %w", err)
 	}
-
+	c.uids.observe(client.ObjectKeyFromObject(obj), obj.GetUID())
+	if !c.matchesFieldSelector(obj) {
+		// the cache only contains matching resources, but uncached reads, e.g. for impersonation, can return resources which don't match anymore
+		log.Debug("Resource doesn't match the field selector")
+		return reconcile.Result{}, c.handleDelete(ctx, obj)
+	}
 	if del := obj.GetDeletionTimestamp(); del != nil && !del.IsZero() {
+		// deletions are handled for suspended resources too, otherwise the finalizer would block the deletion
 		return reconcile.Result{}, c.handleDelete(ctx, obj)
 	}
-	return reconcile.Result{}, c.handleCreateOrUpdate(ctx, obj)
+	if isSuspended(obj) {
+		// the resource is synced when the annotation is removed, see SuspendAnnotationChangedPredicate
+		log.Info("Resource is suspended via annotation, skipping reconcile")
+		return reconcile.Result{}, nil
+	}
+	if err := c.handleCreateOrUpdate(ctx, obj); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// requeue the resource to verify that it still exists in the storages, if configured
+	// persisting the resource again restores it in the storage in case it has been modified or removed
+	res := reconcile.Result{}
+	if recheck := c.recheckInterval(); recheck > 0 {
+		log.Debug("Scheduling recheck of the persisted resource", constants.Logging.KEY_RECHECK_INTERVAL, recheck.String())
+		res.RequeueAfter = recheck
+	}
+	return res, nil
 }
 
 func (c *Controller) handleCreateOrUpdate(ctx context.Context, obj *unstructured.Unstructured) error {
@@ -138,7 +300,7 @@ func (c *Controller) handleCreateOrUpdate(ctx context.Context, obj *unstructured
 		err := c.updateWithRetry(ctx, obj, func(obj *unstructured.Unstructured) (sets.Set[string], error) {
 			utils.AddFinalizer(obj)
 			return sets.New[string]("metadata"), nil
-		}, retryLimit)
+		}, "")
 		if err != nil {
 			errMsg := "error adding finalizer"
 			log.Error(err, errMsg)
@@ -150,18 +312,103 @@ func (c *Controller) handleCreateOrUpdate(ctx context.Context, obj *unstructured
 	}
 
 	// if state display with phase is configured, update phase to progressing
-	err := c.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_PROGRESSING, state.STATE_FIELD_DETAIL, "")
+	var err error
+	if c.writeIntermediateState(obj) {
+		err = c.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_PROGRESSING, state.STATE_FIELD_DETAIL, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	var ownersDoc []byte
+	if c.SyncConfig.PersistOwners {
+		ownersDoc, err = c.ownersDocument(ctx, obj)
+		if err != nil {
+			errMsg := "error resolving owners"
+			log.Error(err, errMsg)
+			errs := utils.NewErrorList(fmt.Errorf("%s: %w", errMsg, err))
+			err2 := c.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_ERROR, state.STATE_FIELD_DETAIL, errs.Aggregate().Error())
+			errs.Append(err2)
+			return errs.Aggregate()
+		}
+	}
+
+	var namespace *unstructured.Unstructured
+	if c.SyncConfig.PersistNamespace && obj.GetNamespace() != "" {
+		namespace, err = c.fetchNamespace(ctx, obj.GetNamespace())
+		if err != nil {
+			errMsg := "error fetching namespace"
+			log.Error(err, errMsg)
+			errs := utils.NewErrorList(fmt.Errorf("%s: %w", errMsg, err))
+			err2 := c.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_ERROR, state.STATE_FIELD_DETAIL, errs.Aggregate().Error())
+			errs.Append(err2)
+			return errs.Aggregate()
+		}
+	}
+
+	var includes []*unstructured.Unstructured
+	if c.SyncConfig.PersistIncludes {
+		includes, err = c.fetchIncludes(ctx, obj)
+		if err != nil {
+			errMsg := "error fetching included resources"
+			log.Error(err, errMsg)
+			errs := utils.NewErrorList(fmt.Errorf("%s: %w", errMsg, err))
+			err2 := c.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_ERROR, state.STATE_FIELD_DETAIL, errs.Aggregate().Error())
+			errs.Append(err2)
+			return errs.Aggregate()
+		}
+	}
+
+	toPersist, err := c.convertForPersistence(ctx, obj)
 	if err != nil {
-		return err
+		errMsg := "error converting resource to persist version"
+		log.Error(err, errMsg)
+		errs := utils.NewErrorList(fmt.Errorf("%s: %w", errMsg, err))
+		err2 := c.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_ERROR, state.STATE_FIELD_DETAIL, errs.Aggregate().Error())
+		errs.Append(err2)
+		return errs.Aggregate()
 	}
 
+	var transformed *unstructured.Unstructured
+	syncedStorages := make([]SyncedStorageEntry, 0, len(c.StorageConfigs))
 	for _, storage := range c.StorageConfigs {
 		curLog := log.WithValues(constants.Logging.KEY_RESOURCE_STORAGE_ID, storage.Name())
 		curCtx := logging.NewContext(ctx, curLog)
 
+		subPath, err := storage.ResolveSubPath(c.subPathTemplateData(obj))
+		if err != nil {
+			errMsg := "error while resolving subPath"
+			curLog.Error(err, errMsg)
+			errs := utils.NewErrorList(fmt.Errorf("[%s] %s: %w", storage.Name(), errMsg, err))
+			err2 := c.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_ERROR, state.STATE_FIELD_DETAIL, errs.Aggregate().Error())
+			errs.Append(err2)
+			return errs.Aggregate()
+		}
+
+		name, err := storage.ResolveName(obj)
+		if err != nil {
+			errMsg := "error while determining storage name"
+			curLog.Error(err, errMsg)
+			errs := utils.NewErrorList(fmt.Errorf("[%s] %s: %w", storage.Name(), errMsg, err))
+			err2 := c.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_ERROR, state.STATE_FIELD_DETAIL, errs.Aggregate().Error())
+			errs.Append(err2)
+			return errs.Aggregate()
+		}
+
+		undoQuota, err := c.reserveQuota(storage, toPersist, name, subPath)
+		if err != nil {
+			errMsg := "error while checking quota"
+			curLog.Error(err, errMsg)
+			errs := utils.NewErrorList(fmt.Errorf("[%s] %s: %w", storage.Name(), errMsg, err))
+			err2 := c.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_ERROR, state.STATE_FIELD_DETAIL, errs.Aggregate().Error())
+			errs.Append(err2)
+			return errs.Aggregate()
+		}
+
 		// persist changes
-		_, changed, err := storage.Persister.Persist(curCtx, obj, storage.Transformer, storage.SubPath)
+		persisted, changed, err := storage.Persister.Persist(persist.WithOwner(curCtx, c.SyncConfig.ID), toPersist, storage.Transformer, name, subPath)
 		if err != nil {
+			undoQuota()
 			errMsg := "error while persisting resource"
 			curLog.Error(err, errMsg)
 			errs := utils.NewErrorList(fmt.Errorf("[%s] %s: %w", storage.Name(), errMsg, err))
@@ -173,14 +420,82 @@ func (c *Controller) handleCreateOrUpdate(ctx context.Context, obj *unstructured
 		// if corresponding resource exists in storage
 		if !changed {
 			curLog.Debug("No relevant fields have changed, resource has not been updated in storage")
+		} else {
+			c.appendChangeLog(curCtx, storage, CHANGE_LOG_OPERATION_PERSIST, obj, persisted, subPath)
+		}
+
+		if c.SyncConfig.PersistOwners {
+			if err := c.persistOwners(curCtx, storage, ownersDoc, name, obj.GetNamespace(), subPath); err != nil {
+				errMsg := "error while persisting owners"
+				curLog.Error(err, errMsg)
+				errs := utils.NewErrorList(fmt.Errorf("[%s] %s: %w", storage.Name(), errMsg, err))
+				err2 := c.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_ERROR, state.STATE_FIELD_DETAIL, errs.Aggregate().Error())
+				errs.Append(err2)
+				return errs.Aggregate()
+			}
+		}
+		if namespace != nil {
+			if err := c.persistNamespace(curCtx, storage, namespace, subPath); err != nil {
+				errMsg := "error while persisting namespace"
+				curLog.Error(err, errMsg)
+				errs := utils.NewErrorList(fmt.Errorf("[%s] %s: %w", storage.Name(), errMsg, err))
+				err2 := c.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_ERROR, state.STATE_FIELD_DETAIL, errs.Aggregate().Error())
+				errs.Append(err2)
+				return errs.Aggregate()
+			}
+		}
+		if len(includes) > 0 {
+			if err := c.persistIncludes(curCtx, storage, includes, subPath); err != nil {
+				errMsg := "error while persisting included resources"
+				curLog.Error(err, errMsg)
+				errs := utils.NewErrorList(fmt.Errorf("[%s] %s: %w", storage.Name(), errMsg, err))
+				err2 := c.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_ERROR, state.STATE_FIELD_DETAIL, errs.Aggregate().Error())
+				errs.Append(err2)
+				return errs.Aggregate()
+			}
+		}
+		if transformed == nil {
+			transformed = persisted
+		}
+		syncedStorages = append(syncedStorages, syncedStorageEntry(storage, persisted, name, subPath))
+	}
+
+	if c.SyncConfig.AnnotateContentHash && transformed != nil {
+		if err := c.updateContentHashOnResource(ctx, obj, transformed); err != nil {
+			errMsg := "error writing content hash annotation"
+			log.Error(err, errMsg)
+			errs := utils.NewErrorList(fmt.Errorf("%s: %w", errMsg, err))
+			err2 := c.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_ERROR, state.STATE_FIELD_DETAIL, errs.Aggregate().Error())
+			errs.Append(err2)
+			return errs.Aggregate()
 		}
 	}
 
-	err = c.updateStateOnResource(ctx, obj, state.STATE_FIELD_LAST_SYNCED_GENERATION, obj.GetGeneration(), state.STATE_FIELD_PHASE, state.PHASE_FINISHED, state.STATE_FIELD_DETAIL, "")
+	observed, err := state.ObservedVersion(obj, c.SyncConfig.ChangeDetection)
+	if err != nil {
+		errMsg := "error determining observed version"
+		log.Error(err, errMsg)
+		errs := utils.NewErrorList(fmt.Errorf("%s: %w", errMsg, err))
+		err2 := c.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_ERROR, state.STATE_FIELD_DETAIL, errs.Aggregate().Error())
+		errs.Append(err2)
+		return errs.Aggregate()
+	}
+	err = c.updateStateOnResource(ctx, obj, state.STATE_FIELD_LAST_SYNCED_GENERATION, observed, state.STATE_FIELD_PHASE, state.PHASE_FINISHED, state.STATE_FIELD_DETAIL, "")
 	if err != nil {
 		return err
 	}
 
+	c.SyncedObjects.Record(SyncedObjectEntry{
+		SyncConfigID:         c.SyncConfig.ID,
+		GVK:                  utils.GVKToString(c.GVK, true),
+		Namespace:            obj.GetNamespace(),
+		Name:                 obj.GetName(),
+		UID:                  string(obj.GetUID()),
+		LastSyncedGeneration: observed,
+		LastSynced:           time.Now(),
+		Storages:             syncedStorages,
+	})
+
 	return nil
 }
 
@@ -190,7 +505,7 @@ func (c *Controller) handleDelete(ctx context.Context, obj *unstructured.Unstruc
 
 	hasFinalizer := utils.HasFinalizer(obj)
 
-	if hasFinalizer {
+	if hasFinalizer && c.SyncConfig.StateWritePolicy != config.STATE_WRITE_POLICY_FINAL_ONLY {
 		// only update state if there is a finalizer on the resource, otherwise it could be gone before the state can be written
 		err := c.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_DELETING, state.STATE_FIELD_DETAIL, "")
 		if err != nil {
@@ -198,34 +513,53 @@ func (c *Controller) handleDelete(ctx context.Context, obj *unstructured.Unstruc
 		}
 	}
 
+	// the storages are handled independently, so that an error in one of them doesn't prevent the deletion from the others
+	errs := utils.NewErrorList()
+	storageErrs := map[string]string{}
 	for _, storage := range c.StorageConfigs {
 		curLog := log.WithValues(constants.Logging.KEY_RESOURCE_STORAGE_ID, storage.Name())
-		curCtx := logging.NewContext(ctx, curLog)
-		exists, err := storage.Persister.Exists(curCtx, obj.GetName(), obj.GetNamespace(), c.GVK, storage.SubPath)
-		if err != nil {
-			errMsg := "error while checking for data existence"
-			curLog.Error(err, errMsg)
-			errs := utils.NewErrorList(fmt.Errorf("%s: %w", errMsg, err))
+		if err := c.deleteFromStorage(logging.NewContext(ctx, curLog), storage, obj); err != nil {
+			curLog.Error(err, "error while deleting resource from storage")
+			errs.Append(fmt.Errorf("[%s] %w", storage.Name(), err))
+			storageErrs[storage.Name()] = err.Error()
+		}
+	}
+	if errs.Aggregate() != nil {
+		givenUp, err := c.giveUpDeletion(ctx, obj, storageErrs)
+		errs.Append(err)
+		if !givenUp || err != nil {
 			if hasFinalizer {
 				err2 := c.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_ERROR_DELETING, state.STATE_FIELD_DETAIL, errs.Aggregate().Error())
 				errs.Append(err2)
 			}
 			return errs.Aggregate()
 		}
-		if exists {
-			err = storage.Persister.Delete(curCtx, obj.GetName(), obj.GetNamespace(), c.GVK, storage.SubPath)
-			if err != nil {
-				errMsg := "error while deleting data"
-				curLog.Error(err, errMsg)
-				errs := utils.NewErrorList(fmt.Errorf("%s: %w", errMsg, err))
-				if hasFinalizer {
-					err2 := c.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_ERROR_DELETING, state.STATE_FIELD_DETAIL, errs.Aggregate().Error())
-					errs.Append(err2)
-				}
-				return errs.Aggregate()
+	} else {
+		if err := c.deletionFailures.forget(ctx, client.ObjectKeyFromObject(obj)); err != nil {
+			// the deletion is done nevertheless, a stale count only lets a later deletion of a resource with the same name give up earlier
+			log.Error(err, "error resetting failed deletion attempts")
+		}
+		c.uids.forget(client.ObjectKeyFromObject(obj))
+	}
+	// given up deletions are recorded as orphaned storage data, so the object counts as not synced anymore in both cases
+	c.SyncedObjects.Forget(c.SyncConfig.ID, c.GVK, obj.GetNamespace(), obj.GetName())
+
+	// remove state which is stored outside of the resource
+	if sr, ok := c.StateDisplay.(state.StateRemover); ok {
+		err := sr.Remove(obj)
+		if err != nil {
+			state.RecordWriteFailure(c.StateDisplay.Type())
+			err = c.handleStateFailure(ctx, err)
+		}
+		if err != nil {
+			errMsg := "error removing state"
+			log.Error(err, errMsg)
+			errs := utils.NewErrorList(fmt.Errorf("%s: %w", errMsg, err))
+			if hasFinalizer {
+				err2 := c.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_ERROR_DELETING, state.STATE_FIELD_DETAIL, errs.Aggregate().Error())
+				errs.Append(err2)
 			}
-		} else {
-			curLog.Debug("No data found for current resource")
+			return errs.Aggregate()
 		}
 	}
 
@@ -234,7 +568,7 @@ func (c *Controller) handleDelete(ctx context.Context, obj *unstructured.Unstruc
 		err := c.updateWithRetry(ctx, obj, func(obj *unstructured.Unstructured) (sets.Set[string], error) {
 			utils.RemoveFinalizer(obj)
 			return sets.New[string]("metadata"), nil
-		}, retryLimit)
+		}, "")
 		if err != nil {
 			errMsg := "error removing finalizer"
 			log.Error(err, errMsg)
@@ -247,3 +581,108 @@ func (c *Controller) handleDelete(ctx context.Context, obj *unstructured.Unstruc
 
 	return nil
 }
+
+// giveUpDeletion counts the failed deletion of the given object from the storages and returns true if the deletion should be given up,
+// because it has failed too often. In this case, the storages which still contain data of the object are recorded in the orphaned-storage ConfigMap.
+// The given map contains the errors of the failed deletion by storage name.
+func (c *Controller) giveUpDeletion(ctx context.Context, obj *unstructured.Unstructured, storageErrs map[string]string) (bool, error) {
+	log := logging.FromContextOrDiscard(ctx)
+	key := client.ObjectKeyFromObject(obj)
+	attempts, giveUp, err := c.deletionFailures.failed(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("error counting failed deletion: %w", err)
+	}
+	if !giveUp {
+		return false, nil
+	}
+	entry := &OrphanedStorageEntry{
+		SyncConfigID: c.SyncConfig.ID,
+		APIVersion:   c.GVK.GroupVersion().String(),
+		Kind:         c.GVK.Kind,
+		Namespace:    obj.GetNamespace(),
+		Name:         obj.GetName(),
+		Storages:     storageErrs,
+		Attempts:     attempts,
+		Time:         metav1.NewTime(c.deletionFailures.now().UTC()),
+	}
+	if err := c.deletionFailures.record(ctx, entry); err != nil {
+		return false, fmt.Errorf("error recording orphaned storage data: %w", err)
+	}
+	log.Info("Giving up deleting resource from storages, the remaining data has been recorded for later cleanup", constants.Logging.KEY_FAILED_COUNT, attempts,
+		constants.Logging.KEY_CONFIGMAP, c.deletionFailures.key.String())
+	abandonedDeletionCounter.WithLabelValues(c.SyncConfig.ID).Inc()
+	return true, nil
+}
+
+// persistedDataBelongsTo returns false if the persisted data of the given resource has a different UID than the resource itself or,
+// if the resource is already gone, its last known UID.
+// It returns true if any of the UIDs is unknown, e.g. because the transformer doesn't persist it.
+func (c *Controller) persistedDataBelongsTo(ctx context.Context, storage *StorageConfiguration, obj *unstructured.Unstructured, name, subPath string) (bool, error) {
+	uid := obj.GetUID()
+	if uid == "" {
+		uid = c.uids.get(client.ObjectKeyFromObject(obj))
+	}
+	if uid == "" {
+		return true, nil
+	}
+	persisted, err := storage.Persister.Get(ctx, name, obj.GetNamespace(), c.persistGVK(), subPath)
+	if err != nil {
+		return false, fmt.Errorf("error while reading persisted data for UID verification: %w", err)
+	}
+	if persisted == nil || persisted.GetUID() == "" || persisted.GetUID() == uid {
+		return true, nil
+	}
+	logging.FromContextOrDiscard(ctx).Info("Persisted data belongs to another resource with the same name, skipping deletion from storage", constants.Logging.KEY_UID, string(uid), constants.Logging.KEY_PERSISTED_UID, string(persisted.GetUID()))
+	return false, nil
+}
+
+// deleteFromStorage removes the given resource, and its owners document if configured, from the given storage.
+// It is not an error if the resource doesn't exist in the storage.
+func (c *Controller) deleteFromStorage(ctx context.Context, storage *StorageConfiguration, obj *unstructured.Unstructured) error {
+	log := logging.FromContextOrDiscard(ctx)
+	subPath, err := storage.ResolveSubPath(c.subPathTemplateData(obj))
+	if err != nil {
+		return fmt.Errorf("error while resolving subPath: %w", err)
+	}
+	if storage.FileNaming.UsesUID() && obj.GetUID() == "" {
+		// the resource is already gone and its UID cannot be determined anymore
+		// this should only happen if the finalizer has been removed by someone else
+		log.Info("Unable to determine the storage name of the deleted resource, because its UID is unknown, skipping deletion from storage", constants.Logging.KEY_FILE_NAMING, string(storage.FileNaming))
+		return nil
+	}
+	name, err := storage.ResolveName(obj)
+	if err != nil {
+		return fmt.Errorf("error while determining storage name: %w", err)
+	}
+	if !storage.FileNaming.UsesUID() {
+		// the resource might have been recreated with the same name and persisted already, e.g. if the deletion is handled late
+		belongs, err := c.persistedDataBelongsTo(ctx, storage, obj, name, subPath)
+		if err != nil {
+			return err
+		}
+		if !belongs {
+			return nil
+		}
+	}
+	if c.SyncConfig.PersistOwners {
+		// the sidecar document is removed first, so that the namespace directory is empty after the resource has been deleted
+		if err := c.persistOwners(ctx, storage, nil, name, obj.GetNamespace(), subPath); err != nil {
+			return fmt.Errorf("error while deleting owners: %w", err)
+		}
+	}
+	exists, err := storage.Persister.Exists(ctx, name, obj.GetNamespace(), c.persistGVK(), subPath)
+	if err != nil {
+		return fmt.Errorf("error while checking for data existence: %w", err)
+	}
+	if !exists {
+		log.Debug("No data found for current resource")
+	} else if err := storage.Persister.Delete(persist.WithOwner(ctx, c.SyncConfig.ID), name, obj.GetNamespace(), c.persistGVK(), subPath); err != nil {
+		return fmt.Errorf("error while deleting data: %w", err)
+	} else {
+		c.appendChangeLog(ctx, storage, CHANGE_LOG_OPERATION_DELETE, obj, nil, subPath)
+	}
+	if storage.quota != nil {
+		storage.quota.release(quotaKey(name, obj.GetNamespace(), subPath))
+	}
+	return nil
+}
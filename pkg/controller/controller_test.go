@@ -6,18 +6,47 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"time"
 
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+	fspersist "github.com/gardener/k8syncer/pkg/persist/filesystem"
 	mockpersist "github.com/gardener/k8syncer/pkg/persist/mock"
+	"github.com/gardener/k8syncer/pkg/persist/transformers"
+	"github.com/gardener/k8syncer/pkg/state"
 	"github.com/gardener/k8syncer/pkg/utils"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
 	testutils "github.com/gardener/k8syncer/test/utils"
 )
 
@@ -115,12 +144,12 @@ var _ = Describe("Controller Tests", func() {
 		Expect(err).ToNot(HaveOccurred())
 
 		By("persisting a new resource")
-		mockPersister.ExpectCall(mockpersist.MockedPersistCall(obj, basicTransformer, testStorageRef.SubPath), mockpersist.MockedPersistReturn(transformed, true, nil))
+		mockPersister.ExpectCall(mockpersist.MockedPersistCall(obj, basicTransformer, obj.GetName(), testStorageRef.SubPath), mockpersist.MockedPersistReturn(transformed, true, nil))
 		_, err = ctrl.Reconcile(ctx, testutils.ReconcileRequestFromObject(obj))
 		Expect(err).ToNot(HaveOccurred())
 
 		By("should notice unchanged resources")
-		mockPersister.ExpectCall(mockpersist.MockedPersistCall(obj, basicTransformer, testStorageRef.SubPath), mockpersist.MockedPersistReturn(transformed, false, nil))
+		mockPersister.ExpectCall(mockpersist.MockedPersistCall(obj, basicTransformer, obj.GetName(), testStorageRef.SubPath), mockpersist.MockedPersistReturn(transformed, false, nil))
 		_, err = ctrl.Reconcile(ctx, testutils.ReconcileRequestFromObject(obj))
 		Expect(err).ToNot(HaveOccurred())
 
@@ -132,9 +161,1324 @@ var _ = Describe("Controller Tests", func() {
 		Expect(testenv.Client.Patch(ctx, obj, client.MergeFrom(old))).To(Succeed())
 		transformed, err = basicTransformer.Transform(obj)
 		Expect(err).ToNot(HaveOccurred())
-		mockPersister.ExpectCall(mockpersist.MockedPersistCall(obj, basicTransformer, testStorageRef.SubPath), mockpersist.MockedPersistReturn(transformed, true, nil))
+		mockPersister.ExpectCall(mockpersist.MockedPersistCall(obj, basicTransformer, obj.GetName(), testStorageRef.SubPath), mockpersist.MockedPersistReturn(transformed, true, nil))
 		_, err = ctrl.Reconcile(ctx, testutils.ReconcileRequestFromObject(obj))
 		Expect(err).ToNot(HaveOccurred())
 	})
 
+	It("should summarize the sync configs and publish the startup summary", func() {
+		syncConfig := ctrl.SyncConfig.DeepCopy()
+		syncConfig.Resource.Namespace = namespace.GetName()
+		syncConfig.ReactOn = []config.ReactOnTrigger{config.REACT_ON_GENERATION}
+		syncConfig.State = &config.StateConfiguration{Type: config.STATE_TYPE_ANNOTATION, Verbosity: config.STATE_VERBOSITY_PHASE}
+		cfg := &config.K8SyncerConfiguration{
+			SyncConfigs:        []*config.SyncConfig{syncConfig},
+			StorageDefinitions: []*config.StorageDefinition{ctrl.StorageConfigs[0].StorageDefinition},
+			StartupSummary:     &config.StartupSummaryConfiguration{ConfigMapName: "summary", ConfigMapNamespace: namespace.GetName()},
+		}
+
+		summary := SummarizeSyncConfig(cfg, syncConfig)
+		Expect(summary).To(Equal(SyncSummary{
+			SyncConfigID:   "dummyWatcher",
+			GVK:            "dummy.v1.k8syncer.gardener.cloud",
+			PersistVersion: "v1",
+			Namespace:      namespace.GetName(),
+			Storages:       []StorageSummary{{Name: testStorageRef.Name, Type: string(config.STORAGE_TYPE_MOCK), SubPath: testStorageRef.SubPath}},
+			StateDisplay:   &StateDisplaySummary{Type: string(config.STATE_TYPE_ANNOTATION), Verbosity: string(config.STATE_VERBOSITY_PHASE)},
+			Predicates:     []string{"reactOn=generation", "suspendAnnotationChanged", "deletionTimestampChanged", fmt.Sprintf("namespace=%s", namespace.GetName()), "staleEvent"},
+			Finalize:       true,
+		}))
+
+		Expect(PublishStartupSummary(ctx, testenv.Client, cfg)).To(Succeed())
+		cm := &corev1.ConfigMap{}
+		Expect(testenv.Client.Get(ctx, client.ObjectKey{Namespace: namespace.GetName(), Name: "summary"}, cm)).To(Succeed())
+		Expect(cm.Data).To(HaveLen(1))
+		published := SyncSummary{}
+		Expect(json.Unmarshal([]byte(cm.Data["dummyWatcher"]), &published)).To(Succeed())
+		Expect(published).To(Equal(summary))
+
+		By("removing the summaries of removed sync configs")
+		renamed := syncConfig.DeepCopy()
+		renamed.ID = "renamed"
+		cfg.SyncConfigs = []*config.SyncConfig{renamed}
+		Expect(PublishStartupSummary(ctx, testenv.Client, cfg)).To(Succeed())
+		Expect(testenv.Client.Get(ctx, client.ObjectKeyFromObject(cm), cm)).To(Succeed())
+		Expect(cm.Data).To(HaveKey("renamed"))
+		Expect(cm.Data).To(HaveLen(1))
+	})
+
+	It("should store and clear checkpoints", func() {
+		checkpoints := NewCheckpointStore(testenv.Client, namespace.GetName(), "checkpoints")
+
+		cp, err := checkpoints.Load(ctx, ctrl.SyncConfig.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cp).To(BeNil())
+
+		By("saving an incomplete checkpoint")
+		Expect(checkpoints.Save(ctx, ctrl.SyncConfig.ID, &Checkpoint{Continue: "foo", LastProcessed: "bar/baz"})).To(Succeed())
+		cp, err = checkpoints.Load(ctx, ctrl.SyncConfig.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cp).To(Equal(&Checkpoint{Continue: "foo", LastProcessed: "bar/baz"}))
+		completed, err := checkpoints.AllCompleted(ctx, []*config.SyncConfig{ctrl.SyncConfig})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(completed).To(BeFalse())
+
+		By("completing the checkpoint")
+		Expect(checkpoints.Save(ctx, ctrl.SyncConfig.ID, &Checkpoint{LastProcessed: "bar/foo", Completed: true})).To(Succeed())
+		completed, err = checkpoints.AllCompleted(ctx, []*config.SyncConfig{ctrl.SyncConfig})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(completed).To(BeTrue())
+
+		By("clearing the checkpoints")
+		Expect(checkpoints.Clear(ctx)).To(Succeed())
+		cp, err = checkpoints.Load(ctx, ctrl.SyncConfig.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cp).To(BeNil())
+		Expect(checkpoints.Clear(ctx)).To(Succeed())
+	})
+
+	It("should retry resources which could not be synced when resuming from a checkpoint", func() {
+		pers, err := mockpersist.New(&config.MockConfiguration{
+			Faults: []*config.MockFault{
+				{
+					Operations:  []config.MockOperation{config.MOCK_OPERATION_PERSIST},
+					FailOnCalls: []int{2},
+				},
+			},
+		}, false)
+		Expect(err).ToNot(HaveOccurred())
+		ctrl.StorageConfigs[0].Persister = pers
+		ctrl.SyncConfig.Resource.Namespace = namespace.GetName()
+		ctrl.SyncConfig.Finalize = utils.Ptr(false)
+		checkpoints := NewCheckpointStore(testenv.Client, namespace.GetName(), "checkpoints")
+
+		for _, name := range []string{"resume-a", "resume-b", "resume-c"} {
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(testGVK)
+			obj.SetName(name)
+			obj.SetNamespace(namespace.GetName())
+			Expect(testenv.Client.Create(ctx, obj)).To(Succeed())
+		}
+		failedKey := client.ObjectKey{Namespace: namespace.GetName(), Name: "resume-b"}.String()
+
+		By("failing to sync one of the resources")
+		err = ctrl.SyncAll(ctx, checkpoints)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(failedKey))
+		cp, err := checkpoints.Load(ctx, ctrl.SyncConfig.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cp.Completed).To(BeTrue())
+		Expect(cp.Failed).To(ConsistOf(failedKey))
+		exists, err := pers.Exists(ctx, "resume-b", namespace.GetName(), testGVK, testStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+		completed, err := checkpoints.AllCompleted(ctx, []*config.SyncConfig{ctrl.SyncConfig})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(completed).To(BeFalse(), "checkpoints with failed resources must be kept for a retry")
+
+		By("retrying the failed resource when resuming")
+		Expect(ctrl.SyncAll(ctx, checkpoints)).To(Succeed())
+		cp, err = checkpoints.Load(ctx, ctrl.SyncConfig.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cp.Failed).To(BeEmpty())
+		exists, err = pers.Exists(ctx, "resume-b", namespace.GetName(), testGVK, testStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+		completed, err = checkpoints.AllCompleted(ctx, []*config.SyncConfig{ctrl.SyncConfig})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(completed).To(BeTrue())
+	})
+
+	It("should sync all resources once, page by page, without adding finalizers", func() {
+		oldPageSize := listPageSize
+		listPageSize = 2
+		DeferCleanup(func() { listPageSize = oldPageSize })
+
+		pers, err := mockpersist.New(nil, false)
+		Expect(err).ToNot(HaveOccurred())
+		syncConfig := ctrl.SyncConfig.DeepCopy()
+		syncConfig.Resource.Namespace = namespace.GetName()
+		cfg := &config.K8SyncerConfiguration{
+			SyncConfigs:        []*config.SyncConfig{syncConfig},
+			StorageDefinitions: []*config.StorageDefinition{ctrl.StorageConfigs[0].StorageDefinition},
+		}
+
+		names := []string{"once-a", "once-b", "once-c", "once-d", "once-e"}
+		for _, name := range names {
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(testGVK)
+			obj.SetName(name)
+			obj.SetNamespace(namespace.GetName())
+			Expect(testenv.Client.Create(ctx, obj)).To(Succeed())
+		}
+
+		Expect(SyncOnce(ctx, logging.Discard(), testenv.Env.Config, testenv.Client, cfg, syncConfig, map[string]persist.Persister{testStorageRef.Name: pers}, nil)).To(Succeed())
+		for _, name := range names {
+			exists, err := pers.Exists(ctx, name, namespace.GetName(), testGVK, testStorageRef.SubPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue(), "resource %s has not been synced", name)
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(testGVK)
+			Expect(testenv.Client.Get(ctx, client.ObjectKey{Namespace: namespace.GetName(), Name: name}, obj)).To(Succeed())
+			Expect(utils.HasFinalizer(obj)).To(BeFalse(), "one-shot syncs must not add finalizers")
+		}
+	})
+
+	It("should aggregate the errors of all resources which could not be synced during a one-shot sync", func() {
+		pers, err := mockpersist.New(&config.MockConfiguration{
+			Faults: []*config.MockFault{
+				{
+					Operations:  []config.MockOperation{config.MOCK_OPERATION_PERSIST},
+					FailOnCalls: []int{1, 3},
+				},
+			},
+		}, false)
+		Expect(err).ToNot(HaveOccurred())
+		syncConfig := ctrl.SyncConfig.DeepCopy()
+		syncConfig.Resource.Namespace = namespace.GetName()
+		cfg := &config.K8SyncerConfiguration{
+			SyncConfigs:        []*config.SyncConfig{syncConfig},
+			StorageDefinitions: []*config.StorageDefinition{ctrl.StorageConfigs[0].StorageDefinition},
+		}
+
+		for _, name := range []string{"faulty-a", "faulty-b", "faulty-c"} {
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(testGVK)
+			obj.SetName(name)
+			obj.SetNamespace(namespace.GetName())
+			Expect(testenv.Client.Create(ctx, obj)).To(Succeed())
+		}
+
+		err = SyncOnce(ctx, logging.Discard(), testenv.Env.Config, testenv.Client, cfg, syncConfig, map[string]persist.Persister{testStorageRef.Name: pers}, nil)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(client.ObjectKey{Namespace: namespace.GetName(), Name: "faulty-a"}.String()))
+		Expect(err.Error()).To(ContainSubstring(client.ObjectKey{Namespace: namespace.GetName(), Name: "faulty-c"}.String()))
+		Expect(err.Error()).ToNot(ContainSubstring(client.ObjectKey{Namespace: namespace.GetName(), Name: "faulty-b"}.String()))
+		Expect(err.Error()).To(HavePrefix("multiple errors occurred:"))
+		Expect(err.Error()).To(ContainSubstring("injected fault"))
+		exists, err := pers.Exists(ctx, "faulty-b", namespace.GetName(), testGVK, testStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue(), "errors of single resources must not abort the sync")
+	})
+
+	It("should watch the cluster of a sync config with its own kubeconfig and identify it by the sync config's cluster name", func() {
+		pers, err := mockpersist.New(nil, false)
+		Expect(err).ToNot(HaveOccurred())
+		syncConfig := ctrl.SyncConfig.DeepCopy()
+		syncConfig.ID = "remoteWatcher"
+		syncConfig.Resource.Namespace = namespace.GetName()
+		syncConfig.Kubeconfig = "/etc/clusters/remote"
+		syncConfig.ClusterName = "remote"
+		syncConfig.StorageRefs = []*config.StorageReference{{Name: testStorageRef.Name, SubPath: "{{ .ClusterName }}"}}
+		cfg := &config.K8SyncerConfiguration{
+			ClusterName:        "main",
+			SyncConfigs:        []*config.SyncConfig{syncConfig},
+			StorageDefinitions: []*config.StorageDefinition{ctrl.StorageConfigs[0].StorageDefinition},
+		}
+
+		By("falling back to the global cluster name")
+		defaultSyncConfig := syncConfig.DeepCopy()
+		defaultSyncConfig.ClusterName = ""
+		c, err := NewController(testenv.Client, cfg, defaultSyncConfig, map[string]persist.Persister{testStorageRef.Name: pers})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.subPathTemplateData(&unstructured.Unstructured{}).ClusterName).To(Equal("main"))
+		Expect(c.StorageConfigs[0].Transformer).To(BeAssignableToTypeOf(&transformers.Basic{}))
+		Expect(c.StorageConfigs[0].Transformer.(*transformers.Basic).InjectedAnnotations).To(HaveKeyWithValue(constants.ANNOTATION_CLUSTER_NAME, "main"))
+
+		By("using the cluster name of the sync config")
+		c, err = NewController(testenv.Client, cfg, syncConfig, map[string]persist.Persister{testStorageRef.Name: pers})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.subPathTemplateData(&unstructured.Unstructured{}).ClusterName).To(Equal("remote"))
+		Expect(c.StorageConfigs[0].Transformer.(*transformers.Basic).InjectedAnnotations).To(HaveKeyWithValue(constants.ANNOTATION_CLUSTER_NAME, "remote"))
+
+		By("watching the resources in the other cluster")
+		// the test environment's API server serves as the other cluster, which is watched via its own cache
+		mgr, err := manager.New(testenv.Env.Config, manager.Options{
+			Metrics:                metricsserver.Options{BindAddress: "0"},
+			HealthProbeBindAddress: "0",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		remoteCluster, err := cluster.New(testenv.Env.Config)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mgr.Add(remoteCluster)).To(Succeed())
+		Expect(AddControllerToManager(ctx, logging.Discard(), mgr, remoteCluster, cfg, syncConfig, map[string]persist.Persister{testStorageRef.Name: pers}, nil, nil, nil)).To(Succeed())
+		mgrCtx, cancel := context.WithCancel(ctx)
+		stopped := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(stopped)
+			Expect(mgr.Start(mgrCtx)).To(Succeed())
+		}()
+
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(testGVK)
+		obj.SetName("remote")
+		obj.SetNamespace(namespace.GetName())
+		Expect(testenv.Client.Create(ctx, obj)).To(Succeed())
+		Eventually(func(g Gomega) {
+			persisted, err := pers.Get(ctx, obj.GetName(), obj.GetNamespace(), testGVK, "remote")
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(persisted).ToNot(BeNil())
+			g.Expect(persisted.GetAnnotations()).To(HaveKeyWithValue(constants.ANNOTATION_CLUSTER_NAME, "remote"))
+		}).WithTimeout(10 * time.Second).Should(Succeed())
+
+		// stop the controller before the namespace is cleaned up
+		cancel()
+		Eventually(stopped).WithTimeout(10 * time.Second).Should(BeClosed())
+	})
+
+	It("should not report missing permissions if all permissions are granted", func() {
+		syncConfig := ctrl.SyncConfig.DeepCopy()
+		syncConfig.Resource.Namespace = namespace.GetName()
+		syncConfig.State = &config.StateConfiguration{
+			Type: config.STATE_TYPE_STATUS,
+		}
+
+		missing, err := CheckPermissions(ctx, testenv.Env.Config, testenv.Client, syncConfig, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(missing).To(BeEmpty())
+	})
+
+	It("should generate the RBAC roles for the required permissions", func() {
+		cfg := &config.K8SyncerConfiguration{
+			SyncConfigs: []*config.SyncConfig{
+				{
+					ID:              "deployments",
+					Resource:        &config.ResourceSyncConfig{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "foo"},
+					Finalize:        utils.Ptr(false),
+					State:           &config.StateConfiguration{Type: config.STATE_TYPE_STATUS},
+					PersistIncludes: true,
+				},
+				{
+					ID:               "policies",
+					Resource:         &config.ResourceSyncConfig{Group: "k8syncer.gardener.cloud", Version: "v1", Kind: "Policy"},
+					Finalize:         utils.Ptr(true),
+					PersistNamespace: true,
+					PersistOwners:    true,
+					Impersonate:      &config.ImpersonationConfiguration{User: "reader", Groups: []string{"readers"}},
+				},
+				{
+					ID:         "remote",
+					Resource:   &config.ResourceSyncConfig{Version: "v1", Kind: "Secret"},
+					Kubeconfig: "/etc/clusters/remote",
+				},
+			},
+			SubPathPruning: &config.SubPathPruningConfiguration{ConfigMapName: "subpaths", ConfigMapNamespace: "k8syncer"},
+		}
+
+		roles, err := GenerateRBACRoles(cfg, nil, "k8syncer", true, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(roles.SkippedSyncConfigs).To(ConsistOf("remote"))
+		Expect(roles.Objects).To(HaveLen(3))
+		Expect(roles.RuntimePermissions).To(ConsistOf(
+			ContainSubstring("sync config 'deployments': 'get' on the resources which are referenced in the"),
+			ContainSubstring("sync config 'policies': 'get' on the kinds of the owners"),
+		))
+
+		cr, ok := roles.Objects[0].(*rbacv1.ClusterRole)
+		Expect(ok).To(BeTrue())
+		Expect(cr.GetName()).To(Equal("k8syncer"))
+		Expect(cr.Rules).To(Equal([]rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"groups"}, ResourceNames: []string{"readers"}, Verbs: []string{"impersonate"}},
+			{APIGroups: []string{""}, Resources: []string{"namespaces"}, Verbs: []string{"get"}},
+			{APIGroups: []string{""}, Resources: []string{"users"}, ResourceNames: []string{"reader"}, Verbs: []string{"impersonate"}},
+			{APIGroups: []string{"authorization.k8s.io"}, Resources: []string{"selfsubjectaccessreviews"}, Verbs: []string{"create"}},
+			{APIGroups: []string{"k8syncer.gardener.cloud"}, Resources: []string{"policies"}, Verbs: []string{"get", "list", "update", "watch"}},
+		}))
+
+		role, ok := roles.Objects[1].(*rbacv1.Role)
+		Expect(ok).To(BeTrue())
+		Expect(role.GetNamespace()).To(Equal("foo"))
+		Expect(role.Rules).To(Equal([]rbacv1.PolicyRule{
+			{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get", "list", "watch"}},
+			{APIGroups: []string{"apps"}, Resources: []string{"deployments/status"}, Verbs: []string{"update"}},
+		}))
+
+		role, ok = roles.Objects[2].(*rbacv1.Role)
+		Expect(ok).To(BeTrue())
+		Expect(role.GetNamespace()).To(Equal("k8syncer"))
+		Expect(role.Rules).To(Equal([]rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"create", "get", "update"}},
+		}))
+
+		By("listing instead of watching for one-shot syncs")
+		roles, err = GenerateRBACRoles(cfg, nil, "k8syncer", false, &client.ObjectKey{Namespace: "k8syncer", Name: "checkpoint"})
+		Expect(err).ToNot(HaveOccurred())
+		role, ok = roles.Objects[1].(*rbacv1.Role)
+		Expect(ok).To(BeTrue())
+		Expect(role.Rules[0].Verbs).To(Equal([]string{"get", "list"}))
+
+		By("granting the permissions for the checkpoint of one-shot syncs")
+		role, ok = roles.Objects[2].(*rbacv1.Role)
+		Expect(ok).To(BeTrue())
+		Expect(role.GetNamespace()).To(Equal("k8syncer"))
+		Expect(role.Rules).To(Equal([]rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"create", "get", "update"}},
+			{APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{"checkpoint"}, Verbs: []string{"delete", "get", "update"}},
+		}))
+	})
+
+	It("should generate the manifests for deploying K8Syncer", func() {
+		cfg := &config.K8SyncerConfiguration{
+			SyncConfigs: []*config.SyncConfig{
+				{
+					ID:       "deployments",
+					Resource: &config.ResourceSyncConfig{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "foo"},
+					Finalize: utils.Ptr(false),
+					State:    &config.StateConfiguration{Type: config.STATE_TYPE_STATUS},
+				},
+				{
+					ID:       "policies",
+					Resource: &config.ResourceSyncConfig{Group: "k8syncer.gardener.cloud", Version: "v1", Kind: "Policy"},
+					Finalize: utils.Ptr(true),
+				},
+			},
+			StorageDefinitions: []*config.StorageDefinition{
+				{Name: "my_archive", Type: config.STORAGE_TYPE_FILESYSTEM, FileSystemConfig: &config.FileSystemConfiguration{RootPath: "/data", InMemory: utils.Ptr(false)}},
+				{Name: "shared", Type: config.STORAGE_TYPE_FILESYSTEM, FileSystemConfig: &config.FileSystemConfiguration{RootPath: "/data", InMemory: utils.Ptr(false)}},
+				{Name: "memory", Type: config.STORAGE_TYPE_FILESYSTEM, FileSystemConfig: &config.FileSystemConfiguration{RootPath: "/memory", InMemory: utils.Ptr(true)}},
+				{Name: "mock", Type: config.STORAGE_TYPE_MOCK},
+			},
+		}
+		rawConfig := []byte("storageDefinitions: []\n")
+		opts := &ManifestOptions{
+			Name:         "k8syncer",
+			Namespace:    "k8syncer-system",
+			Image:        "k8syncer:v1.0.0",
+			StorageSize:  resource.MustParse("5Gi"),
+			StorageClass: "fast",
+		}
+		roles, err := GenerateRBACRoles(cfg, nil, opts.Name, true, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		objs := GenerateManifests(cfg, rawConfig, roles, opts)
+		kinds := []string{}
+		for _, obj := range objs {
+			kinds = append(kinds, obj.GetObjectKind().GroupVersionKind().Kind)
+		}
+		Expect(kinds).To(Equal([]string{"ServiceAccount", "ClusterRole", "ClusterRoleBinding", "Role", "RoleBinding", "Secret", "PersistentVolumeClaim", "Deployment"}))
+
+		By("binding the roles to the service account")
+		subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "k8syncer", Namespace: "k8syncer-system"}}
+		crb, ok := objs[2].(*rbacv1.ClusterRoleBinding)
+		Expect(ok).To(BeTrue())
+		Expect(crb.Subjects).To(Equal(subjects))
+		Expect(crb.RoleRef).To(Equal(rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "k8syncer"}))
+		rb, ok := objs[4].(*rbacv1.RoleBinding)
+		Expect(ok).To(BeTrue())
+		Expect(rb.GetNamespace()).To(Equal("foo"))
+		Expect(rb.Subjects).To(Equal(subjects))
+		Expect(rb.RoleRef).To(Equal(rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "k8syncer"}))
+
+		By("storing the configuration in a secret")
+		secret, ok := objs[5].(*corev1.Secret)
+		Expect(ok).To(BeTrue())
+		Expect(secret.GetName()).To(Equal("k8syncer-config"))
+		Expect(secret.GetNamespace()).To(Equal("k8syncer-system"))
+		Expect(secret.StringData).To(Equal(map[string]string{"config.yaml": string(rawConfig)}))
+
+		By("creating a single volume for the filesystem storages which share a root path")
+		pvc, ok := objs[6].(*corev1.PersistentVolumeClaim)
+		Expect(ok).To(BeTrue())
+		Expect(pvc.GetName()).To(Equal("k8syncer-storage-my-archive"))
+		Expect(pvc.Spec.StorageClassName).To(Equal(utils.Ptr("fast")))
+		Expect(pvc.Spec.Resources.Requests).To(HaveKeyWithValue(corev1.ResourceStorage, resource.MustParse("5Gi")))
+
+		deploy, ok := objs[7].(*appsv1.Deployment)
+		Expect(ok).To(BeTrue())
+		Expect(deploy.Spec.Strategy.Type).To(Equal(appsv1.RecreateDeploymentStrategyType))
+		podSpec := deploy.Spec.Template.Spec
+		Expect(podSpec.ServiceAccountName).To(Equal("k8syncer"))
+		Expect(podSpec.Volumes).To(Equal([]corev1.Volume{
+			{Name: "config", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "k8syncer-config"}}},
+			{Name: "storage-my-archive", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "k8syncer-storage-my-archive"}}},
+		}))
+		Expect(podSpec.Containers).To(HaveLen(1))
+		Expect(podSpec.Containers[0].Image).To(Equal("k8syncer:v1.0.0"))
+		Expect(podSpec.Containers[0].Command).To(ContainElement("--config=/etc/config/config.yaml"))
+		Expect(podSpec.Containers[0].VolumeMounts).To(Equal([]corev1.VolumeMount{
+			{Name: "config", MountPath: "/etc/config", ReadOnly: true},
+			{Name: "storage-my-archive", MountPath: "/data"},
+		}))
+		Expect(deploy.Spec.Template.GetAnnotations()).To(HaveKey("checksum/k8syncer-config"))
+
+		By("using the default strategy without volumes")
+		cfg.StorageDefinitions = cfg.StorageDefinitions[2:]
+		objs = GenerateManifests(cfg, rawConfig, roles, opts)
+		deploy, ok = objs[len(objs)-1].(*appsv1.Deployment)
+		Expect(ok).To(BeTrue())
+		Expect(deploy.Spec.Strategy.Type).To(BeEmpty())
+		Expect(deploy.Spec.Template.Spec.Volumes).To(HaveLen(1))
+	})
+
+	It("should verify the configured scope of the synced resource", func() {
+		syncConfig := ctrl.SyncConfig.DeepCopy()
+		Expect(VerifyResourceScope(testenv.Client.RESTMapper(), syncConfig)).To(Succeed())
+		syncConfig.Resource.Scope = config.RESOURCE_SCOPE_NAMESPACED
+		Expect(VerifyResourceScope(testenv.Client.RESTMapper(), syncConfig)).To(Succeed())
+		syncConfig.Resource.Scope = config.RESOURCE_SCOPE_CLUSTER
+		Expect(VerifyResourceScope(testenv.Client.RESTMapper(), syncConfig)).To(MatchError(ContainSubstring("the cluster reports scope 'Namespaced'")))
+
+		syncConfig.Resource = &config.ResourceSyncConfig{Version: "v1", Kind: "Namespace", Scope: config.RESOURCE_SCOPE_CLUSTER}
+		Expect(VerifyResourceScope(testenv.Client.RESTMapper(), syncConfig)).To(Succeed())
+
+		By("only accepting objects which match the scope")
+		obj := &unstructured.Unstructured{}
+		obj.SetName("foo")
+		Expect(scopePredicate(config.RESOURCE_SCOPE_CLUSTER).Generic(event.GenericEvent{Object: obj})).To(BeTrue())
+		Expect(scopePredicate(config.RESOURCE_SCOPE_NAMESPACED).Generic(event.GenericEvent{Object: obj})).To(BeFalse())
+		obj.SetNamespace("bar")
+		Expect(scopePredicate(config.RESOURCE_SCOPE_CLUSTER).Generic(event.GenericEvent{Object: obj})).To(BeFalse())
+		Expect(scopePredicate(config.RESOURCE_SCOPE_NAMESPACED).Generic(event.GenericEvent{Object: obj})).To(BeTrue())
+		Expect(scopePredicate("").Generic(event.GenericEvent{Object: obj})).To(BeTrue())
+	})
+
+	It("should only write intermediate states as allowed by the state write policy", func() {
+		ctrl.StateDisplay = state.NewAnnotationStateDisplay("", "", "", state.STATE_VERBOSITY_PHASE)
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(testGVK)
+		obj.SetGeneration(2)
+		obj.SetAnnotations(map[string]string{
+			constants.ANNOTATION_LAST_SYNCED_GENERATION: "1",
+			constants.ANNOTATION_PHASE:                  string(state.PHASE_FINISHED),
+		})
+
+		ctrl.SyncConfig.StateWritePolicy = config.STATE_WRITE_POLICY_ALWAYS
+		Expect(ctrl.writeIntermediateState(obj)).To(BeTrue())
+		ctrl.SyncConfig.StateWritePolicy = config.STATE_WRITE_POLICY_FINAL_ONLY
+		Expect(ctrl.writeIntermediateState(obj)).To(BeFalse())
+		ctrl.SyncConfig.StateWritePolicy = config.STATE_WRITE_POLICY_ON_CHANGE_ONLY
+		Expect(ctrl.writeIntermediateState(obj)).To(BeTrue())
+		obj.SetGeneration(1)
+		Expect(ctrl.writeIntermediateState(obj)).To(BeFalse())
+	})
+
+	It("should compute the retries and backoffs of conflicting updates from the sync config", func() {
+		Expect(ctrl.maxUpdateRetries()).To(Equal(retryLimit))
+		Expect(ctrl.updateRetryBackoff(1)).To(BeZero())
+
+		ctrl.SyncConfig.UpdateRetry = &config.UpdateRetryConfiguration{
+			MaxRetries: utils.Ptr(5),
+			Backoff:    &metav1.Duration{Duration: 100 * time.Millisecond},
+		}
+		Expect(ctrl.maxUpdateRetries()).To(Equal(5))
+		Expect(ctrl.updateRetryBackoff(1)).To(Equal(100 * time.Millisecond))
+		Expect(ctrl.updateRetryBackoff(3)).To(Equal(400 * time.Millisecond))
+		Expect(ctrl.updateRetryBackoff(20)).To(Equal(maxUpdateBackoff))
+
+		ctrl.SyncConfig.UpdateRetry.Jitter = 0.5
+		for i := 0; i < 10; i++ {
+			backoff := ctrl.updateRetryBackoff(2)
+			Expect(backoff).To(BeNumerically(">=", 200*time.Millisecond))
+			Expect(backoff).To(BeNumerically("<", 300*time.Millisecond))
+		}
+
+		cancelledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		Expect(ctrl.waitForUpdateRetry(cancelledCtx, 1)).To(MatchError(context.Canceled))
+	})
+
+	It("should only reconcile resources matching the field selector", func() {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(testGVK)
+		obj.SetName("field-selector")
+		obj.SetNamespace(namespace.GetName())
+		Expect(ctrl.matchesFieldSelector(obj)).To(BeTrue())
+
+		ctrl.FieldSelector = fields.ParseSelectorOrDie("metadata.name=field-selector")
+		Expect(ctrl.matchesFieldSelector(obj)).To(BeTrue())
+		ctrl.FieldSelector = fields.ParseSelectorOrDie("metadata.name!=field-selector")
+		Expect(ctrl.matchesFieldSelector(obj)).To(BeFalse())
+		ctrl.FieldSelector = fields.ParseSelectorOrDie("spec.nodeName=node-1")
+		Expect(ctrl.matchesFieldSelector(obj)).To(BeFalse())
+		Expect(unstructured.SetNestedField(obj.Object, "node-1", "spec", "nodeName")).To(Succeed())
+		Expect(ctrl.matchesFieldSelector(obj)).To(BeTrue())
+
+		byObject, err := CacheByObject([]*config.SyncConfig{ctrl.SyncConfig}, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(byObject).To(BeNil())
+		sc := ctrl.SyncConfig.DeepCopy()
+		sc.Resource.FieldSelector = "metadata.name=field-selector"
+		byObject, err = CacheByObject([]*config.SyncConfig{sc}, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(byObject).To(HaveLen(1))
+		byObject, err = CacheByObject([]*config.SyncConfig{sc}, "other")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(byObject).To(BeNil())
+	})
+
+	It("should reject resources which would exceed the quota of a storage reference", func() {
+		storage := ctrl.StorageConfigs[0]
+		newObj := func(name string, size int) *unstructured.Unstructured {
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(testGVK)
+			obj.SetName(name)
+			obj.SetNamespace(namespace.GetName())
+			Expect(unstructured.SetNestedField(obj.Object, string(make([]byte, size)), "spec", "data")).To(Succeed())
+			return obj
+		}
+
+		// without a quota, nothing is tracked
+		_, err := ctrl.reserveQuota(storage, newObj("quota-a", 10), "quota-a", testStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		storage.quota = newQuotaTracker(ctrl.SyncConfig.ID, &config.StorageReference{Name: testStorageRef.Name, MaxObjects: 2, MaxTotalBytes: 1000})
+		labels := []string{ctrl.SyncConfig.ID, testStorageRef.Name}
+		_, err = ctrl.reserveQuota(storage, newObj("quota-a", 10), "quota-a", testStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		undo, err := ctrl.reserveQuota(storage, newObj("quota-b", 10), "quota-b", testStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(testutil.ToFloat64(quotaObjectsGauge.WithLabelValues(labels...))).To(Equal(float64(2)))
+
+		By("rejecting new resources beyond maxObjects")
+		_, err = ctrl.reserveQuota(storage, newObj("quota-c", 10), "quota-c", testStorageRef.SubPath)
+		var quotaErr *QuotaExceededError
+		Expect(errors.As(err, &quotaErr)).To(BeTrue())
+		Expect(quotaErr.Quota).To(Equal("maxObjects"))
+		Expect(testutil.ToFloat64(quotaExceededGauge.WithLabelValues(labels...))).To(Equal(float64(1)))
+		Expect(testutil.ToFloat64(quotaRejectionCounter.WithLabelValues(labels...))).To(Equal(float64(1)))
+
+		By("allowing new resources after a reservation has been reverted or a resource has been deleted")
+		undo()
+		_, err = ctrl.reserveQuota(storage, newObj("quota-c", 10), "quota-c", testStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(testutil.ToFloat64(quotaExceededGauge.WithLabelValues(labels...))).To(Equal(float64(0)))
+		storage.quota.release(quotaKey("quota-c", namespace.GetName(), testStorageRef.SubPath))
+		Expect(testutil.ToFloat64(quotaObjectsGauge.WithLabelValues(labels...))).To(Equal(float64(1)))
+
+		By("rejecting growing resources beyond maxTotalBytes")
+		_, err = ctrl.reserveQuota(storage, newObj("quota-a", 2000), "quota-a", testStorageRef.SubPath)
+		Expect(errors.As(err, &quotaErr)).To(BeTrue())
+		Expect(quotaErr.Quota).To(Equal("maxTotalBytes"))
+		_, err = ctrl.reserveQuota(storage, newObj("quota-a", 5), "quota-a", testStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should apply the state failure policy to failed state writes", func() {
+		stateErr := errors.New("state write rejected")
+		Expect(ctrl.handleStateFailure(ctx, stateErr)).To(MatchError(stateErr))
+
+		ctrl.SyncConfig.State = &config.StateConfiguration{
+			Type:          config.STATE_TYPE_ANNOTATION,
+			Verbosity:     config.STATE_VERBOSITY_PHASE,
+			FailurePolicy: config.STATE_FAILURE_POLICY_FAIL,
+		}
+		Expect(ctrl.handleStateFailure(ctx, stateErr)).To(MatchError(stateErr))
+		ctrl.SyncConfig.State.FailurePolicy = config.STATE_FAILURE_POLICY_WARN
+		Expect(ctrl.handleStateFailure(ctx, stateErr)).To(Succeed())
+		ctrl.SyncConfig.State.FailurePolicy = config.STATE_FAILURE_POLICY_IGNORE
+		Expect(ctrl.handleStateFailure(ctx, stateErr)).To(Succeed())
+	})
+
+	It("should count state writes, retried conflicts, and permanent failures in the state write metrics", func() {
+		// conflicts is the number of updates which fail with a conflict before the next one succeeds
+		conflicts := 0
+		// failure, if not nil, is returned for all updates
+		var failure error
+		inject := func(obj client.Object) error {
+			if failure != nil {
+				return failure
+			}
+			if conflicts > 0 {
+				conflicts--
+				return apierrors.NewConflict(schema.GroupResource{Resource: "dummies"}, obj.GetName(), errors.New("injected conflict"))
+			}
+			return nil
+		}
+		cl, err := client.NewWithWatch(testenv.Env.Config, client.Options{})
+		Expect(err).ToNot(HaveOccurred())
+		ctrl.Client = interceptor.NewClient(cl, interceptor.Funcs{
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				if err := inject(obj); err != nil {
+					return err
+				}
+				return c.Update(ctx, obj, opts...)
+			},
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				if err := inject(obj); err != nil {
+					return err
+				}
+				return c.SubResource(subResourceName).Update(ctx, obj, opts...)
+			},
+		})
+		ctrl.SyncConfig.UpdateRetry = &config.UpdateRetryConfiguration{MaxRetries: utils.Ptr(2)}
+
+		for _, stateType := range []config.StateType{config.STATE_TYPE_STATUS, config.STATE_TYPE_CONFIGMAP} {
+			By(fmt.Sprintf("using the %s state display", stateType))
+			conflicts = 0
+			failure = nil
+			ctrl.SyncConfig.State = &config.StateConfiguration{Type: stateType, Verbosity: config.STATE_VERBOSITY_PHASE}
+			if stateType == config.STATE_TYPE_STATUS {
+				ctrl.StateDisplay = state.NewStatusStateDisplay("syncStatus.lastSyncedGeneration", "syncStatus.phase", "syncStatus.detail", state.STATE_VERBOSITY_PHASE)
+			} else {
+				ctrl.StateDisplay = state.NewConfigMapStateDisplay(ctrl.Client, "k8syncer-state", "", state.STATE_VERBOSITY_PHASE)
+			}
+			displayType := ctrl.StateDisplay.Type()
+			// metrics returns the current values of the write, conflict, and failure counters of the state display, in the order of state.WriteMetrics
+			metrics := func() [3]float64 {
+				res := [3]float64{}
+				for i, c := range state.WriteMetrics() {
+					res[i] = testutil.ToFloat64(c.(*prometheus.CounterVec).WithLabelValues(displayType))
+				}
+				return res
+			}
+
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(testGVK)
+			obj.SetName("metrics-" + string(stateType))
+			obj.SetNamespace(namespace.GetName())
+			Expect(testenv.Client.Create(ctx, obj)).To(Succeed())
+
+			By("counting a write")
+			before := metrics()
+			Expect(ctrl.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_PROGRESSING)).To(Succeed())
+			Expect(metrics()).To(Equal([3]float64{before[0] + 1, before[1], before[2]}))
+
+			By("counting a retried conflict")
+			before = metrics()
+			conflicts = 1
+			Expect(ctrl.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_FINISHED)).To(Succeed())
+			Expect(metrics()).To(Equal([3]float64{before[0] + 2, before[1] + 1, before[2]}))
+			read, serr := ctrl.StateDisplay.Read(obj)
+			Expect(serr).ToNot(HaveOccurred())
+			Expect(read.Phase).To(Equal(state.PHASE_FINISHED))
+
+			By("counting a permanent failure")
+			before = metrics()
+			failure = apierrors.NewInternalError(errors.New("injected failure"))
+			Expect(ctrl.updateStateOnResource(ctx, obj, state.STATE_FIELD_PHASE, state.PHASE_ERROR)).To(MatchError(ContainSubstring("injected failure")))
+			Expect(metrics()).To(Equal([3]float64{before[0] + 1, before[1], before[2] + 1}))
+		}
+	})
+
+	It("should remove orphaned resources from the storages", func() {
+		fsp, err := fspersist.NewForMemory(&config.FileSystemConfiguration{RootPath: "/data"})
+		Expect(err).ToNot(HaveOccurred())
+		ctrl.SyncConfig.Finalize = utils.Ptr(false)
+		ctrl.SyncConfig.ReadOnlySource = true
+		ctrl.StorageConfigs[0].Persister = fsp
+
+		existing := &unstructured.Unstructured{}
+		existing.SetGroupVersionKind(testGVK)
+		existing.SetName("orphans-existing")
+		existing.SetNamespace(namespace.GetName())
+		Expect(testenv.Client.Create(ctx, existing)).To(Succeed())
+		orphan := existing.DeepCopy()
+		orphan.SetName("orphans-deleted")
+		other := &unstructured.Unstructured{}
+		other.SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"})
+		other.SetName("orphans-deleted")
+		other.SetNamespace(namespace.GetName())
+		for _, obj := range []*unstructured.Unstructured{existing, orphan, other} {
+			_, _, err := fsp.Persist(ctx, obj, basicTransformer, obj.GetName(), testStorageRef.SubPath)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Expect(ctrl.CleanupOrphans(ctx)).To(Succeed())
+		exists, err := fsp.Exists(ctx, existing.GetName(), existing.GetNamespace(), testGVK, testStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+		exists, err = fsp.Exists(ctx, orphan.GetName(), orphan.GetNamespace(), testGVK, testStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+		// resources of other kinds belong to other sync configs and must not be touched
+		exists, err = fsp.Exists(ctx, other.GetName(), other.GetNamespace(), other.GroupVersionKind(), testStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+	})
+
+	It("should set the error phase if the storage fails and recover with the next reconcile", func() {
+		pers, err := mockpersist.New(&config.MockConfiguration{
+			Faults: []*config.MockFault{
+				{
+					Operations:  []config.MockOperation{config.MOCK_OPERATION_PERSIST},
+					FailOnCalls: []int{1},
+					Message:     "storage unavailable",
+				},
+			},
+		}, false)
+		Expect(err).ToNot(HaveOccurred())
+		ctrl.StorageConfigs[0].Persister = pers
+		ctrl.StateDisplay = state.NewAnnotationStateDisplay("", "", "", state.STATE_VERBOSITY_PHASE)
+
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(testGVK)
+		obj.SetName("persist-faulty")
+		obj.SetNamespace(namespace.GetName())
+		Expect(testenv.Client.Create(ctx, obj)).To(Succeed())
+
+		By("failing the first persist call")
+		_, err = ctrl.Reconcile(ctx, testutils.ReconcileRequestFromObject(obj))
+		Expect(err).To(HaveOccurred())
+		var faultErr *mockpersist.InjectedFaultError
+		Expect(errors.As(err, &faultErr)).To(BeTrue())
+		Expect(faultErr.Call).To(Equal(1))
+		Expect(testenv.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+		Expect(obj.GetAnnotations()).To(HaveKeyWithValue(constants.ANNOTATION_PHASE, string(state.PHASE_ERROR)))
+
+		By("succeeding with the next reconcile")
+		_, err = ctrl.Reconcile(ctx, testutils.ReconcileRequestFromObject(obj))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(testenv.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+		Expect(obj.GetAnnotations()).To(HaveKeyWithValue(constants.ANNOTATION_PHASE, string(state.PHASE_FINISHED)))
+	})
+
+	It("should delete resources from all storages, independently of the other storages", func() {
+		faulty, err := mockpersist.New(&config.MockConfiguration{
+			Faults: []*config.MockFault{
+				{
+					Operations:  []config.MockOperation{config.MOCK_OPERATION_EXISTS},
+					FailOnCalls: []int{1},
+					Message:     "storage unavailable",
+				},
+			},
+		}, false)
+		Expect(err).ToNot(HaveOccurred())
+		fsp, err := fspersist.NewForMemory(&config.FileSystemConfiguration{RootPath: "/data"})
+		Expect(err).ToNot(HaveOccurred())
+		fsStorageRef := &config.StorageReference{Name: "fsStorage", SubPath: testStorageRef.SubPath, FileNaming: config.FILE_NAMING_NAME}
+		ctrl.StorageConfigs[0].Persister = faulty
+		ctrl.StorageConfigs = append(ctrl.StorageConfigs, &StorageConfiguration{
+			StorageReference: fsStorageRef,
+			StorageDefinition: &config.StorageDefinition{
+				Name: fsStorageRef.Name,
+				Type: config.STORAGE_TYPE_FILESYSTEM,
+			},
+			Persister:   fsp,
+			Transformer: basicTransformer,
+		})
+		ctrl.SyncConfig.Finalize = utils.Ptr(false)
+
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(testGVK)
+		obj.SetName("delete-multi")
+		obj.SetNamespace(namespace.GetName())
+		_, _, err = fsp.Persist(ctx, obj, basicTransformer, obj.GetName(), fsStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		By("deleting the resource from the second storage although the first one fails")
+		err = ctrl.handleDelete(ctx, obj)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("[%s]", testStorageRef.Name))
+		exists, err := fsp.Exists(ctx, obj.GetName(), obj.GetNamespace(), testGVK, fsStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+
+		By("deleting the resource from the second storage if it doesn't exist in the first one")
+		_, _, err = fsp.Persist(ctx, obj, basicTransformer, obj.GetName(), fsStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ctrl.handleDelete(ctx, obj)).To(Succeed())
+		exists, err = fsp.Exists(ctx, obj.GetName(), obj.GetNamespace(), testGVK, fsStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+	})
+
+	It("should not delete persisted data which belongs to a recreated resource with the same name", func() {
+		fsp, err := fspersist.NewForMemory(&config.FileSystemConfiguration{RootPath: "/data"})
+		Expect(err).ToNot(HaveOccurred())
+		fsStorageRef := &config.StorageReference{Name: "fsStorage", SubPath: testStorageRef.SubPath, FileNaming: config.FILE_NAMING_NAME}
+		ctrl.StorageConfigs = []*StorageConfiguration{{
+			StorageReference: fsStorageRef,
+			StorageDefinition: &config.StorageDefinition{
+				Name: fsStorageRef.Name,
+				Type: config.STORAGE_TYPE_FILESYSTEM,
+			},
+			Persister:   fsp,
+			Transformer: basicTransformer,
+		}}
+		ctrl.SyncConfig.Finalize = utils.Ptr(false)
+
+		recreated := &unstructured.Unstructured{}
+		recreated.SetGroupVersionKind(testGVK)
+		recreated.SetName("delete-recreated")
+		recreated.SetNamespace(namespace.GetName())
+		recreated.SetUID("new-uid")
+		_, _, err = fsp.Persist(ctx, recreated, basicTransformer, recreated.GetName(), fsStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		// the deleted resource is only known by name, as in a reconcile for a resource which doesn't exist anymore
+		deleted := &unstructured.Unstructured{}
+		deleted.SetGroupVersionKind(testGVK)
+		deleted.SetName(recreated.GetName())
+		deleted.SetNamespace(recreated.GetNamespace())
+
+		By("keeping the data if the last known UID differs")
+		ctrl.uids.observe(client.ObjectKeyFromObject(deleted), "old-uid")
+		Expect(ctrl.handleDelete(ctx, deleted)).To(Succeed())
+		exists, err := fsp.Exists(ctx, recreated.GetName(), recreated.GetNamespace(), testGVK, fsStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+
+		By("deleting the data if the last known UID matches")
+		ctrl.uids.observe(client.ObjectKeyFromObject(deleted), recreated.GetUID())
+		Expect(ctrl.handleDelete(ctx, deleted)).To(Succeed())
+		exists, err = fsp.Exists(ctx, recreated.GetName(), recreated.GetNamespace(), testGVK, fsStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+		Expect(ctrl.uids.get(client.ObjectKeyFromObject(deleted))).To(BeEmpty())
+
+		By("deleting the data if no UID is known")
+		_, _, err = fsp.Persist(ctx, recreated, basicTransformer, recreated.GetName(), fsStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ctrl.handleDelete(ctx, deleted)).To(Succeed())
+		exists, err = fsp.Exists(ctx, recreated.GetName(), recreated.GetNamespace(), testGVK, fsStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+	})
+
+	It("should give up deletions which fail repeatedly and record the orphaned storage data", func() {
+		faulty, err := mockpersist.New(&config.MockConfiguration{
+			Faults: []*config.MockFault{
+				{
+					Operations:  []config.MockOperation{config.MOCK_OPERATION_EXISTS},
+					FailOnCalls: []int{1, 2},
+					Message:     "permission denied",
+				},
+			},
+		}, false)
+		Expect(err).ToNot(HaveOccurred())
+		ctrl.StorageConfigs[0].Persister = faulty
+		ctrl.SyncConfig.Finalize = utils.Ptr(false)
+		dfCfg := &config.DeletionFailureConfiguration{
+			MaxAttempts:        2,
+			ConfigMapName:      "k8syncer-orphaned-storage",
+			ConfigMapNamespace: namespace.GetName(),
+		}
+		ctrl.deletionFailures = newDeletionFailureTracker(testenv.Client, ctrl.SyncConfig.ID, ctrl.GVK, dfCfg)
+
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(testGVK)
+		obj.SetName("delete-orphaned")
+		obj.SetNamespace(namespace.GetName())
+
+		By("retrying the deletion until the maximum number of attempts is reached")
+		Expect(ctrl.handleDelete(ctx, obj)).ToNot(Succeed())
+		cm := &corev1.ConfigMap{}
+		cmKey := client.ObjectKey{Namespace: namespace.GetName(), Name: "k8syncer-orphaned-storage"}
+		Expect(testenv.Client.Get(ctx, cmKey, cm)).To(Succeed())
+		Expect(cm.Data).To(HaveLen(1))
+		for key, data := range cm.Data {
+			Expect(key).To(HaveSuffix(attemptsKeySuffix))
+			fd := &failedDeletion{}
+			Expect(json.Unmarshal([]byte(data), fd)).To(Succeed())
+			Expect(fd.Name).To(Equal(obj.GetName()))
+			Expect(fd.Attempts).To(Equal(1))
+		}
+
+		By("giving up the deletion and recording the storage data, also after a restart")
+		ctrl.deletionFailures = newDeletionFailureTracker(testenv.Client, ctrl.SyncConfig.ID, ctrl.GVK, dfCfg)
+		Expect(ctrl.handleDelete(ctx, obj)).To(Succeed())
+		Expect(testenv.Client.Get(ctx, cmKey, cm)).To(Succeed())
+		Expect(cm.Data).To(HaveLen(1))
+		for _, data := range cm.Data {
+			entry := &OrphanedStorageEntry{}
+			Expect(json.Unmarshal([]byte(data), entry)).To(Succeed())
+			Expect(entry.SyncConfigID).To(Equal(ctrl.SyncConfig.ID))
+			Expect(entry.Name).To(Equal(obj.GetName()))
+			Expect(entry.Namespace).To(Equal(obj.GetNamespace()))
+			Expect(entry.Attempts).To(Equal(2))
+			Expect(entry.Storages).To(HaveKeyWithValue(testStorageRef.Name, ContainSubstring("permission denied")))
+		}
+		Expect(testenv.Client.Delete(ctx, cm)).To(Succeed())
+	})
+
+	It("should persist the CRD of the synced kind", func() {
+		fsp, err := fspersist.NewForMemory(&config.FileSystemConfiguration{RootPath: "/data"})
+		Expect(err).ToNot(HaveOccurred())
+		ctrl.SyncConfig.PersistCRD = true
+		ctrl.StorageConfigs[0].Persister = fsp
+
+		crdName, err := CRDName(testenv.Client.RESTMapper(), testGVK)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(crdName).To(Equal("dummies.k8syncer.gardener.cloud"))
+
+		Expect(ctrl.SyncCRD(ctx, testenv.Client, crdName)).To(Succeed())
+		crdSubPath := path.Join(testStorageRef.SubPath, crdDirectory)
+		persisted, err := fsp.Get(ctx, crdName, "", CRDGVK, crdSubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persisted.GetName()).To(Equal(crdName))
+		Expect(persisted.Object).To(HaveKey("spec"))
+		Expect(persisted.Object).ToNot(HaveKey("status"))
+
+		By("removing CRDs which don't exist anymore")
+		Expect(ctrl.SyncCRD(ctx, testenv.Client, "missing.k8syncer.gardener.cloud")).To(Succeed())
+		_, _, err = fsp.Persist(ctx, persisted, basicTransformer, "missing.k8syncer.gardener.cloud", crdSubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ctrl.SyncCRD(ctx, testenv.Client, "missing.k8syncer.gardener.cloud")).To(Succeed())
+		exists, err := fsp.Exists(ctx, "missing.k8syncer.gardener.cloud", "", CRDGVK, crdSubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+	})
+
+	It("should persist resources referenced in the include annotation", func() {
+		fsp, err := fspersist.NewForMemory(&config.FileSystemConfiguration{RootPath: "/data"})
+		Expect(err).ToNot(HaveOccurred())
+		ctrl.SyncConfig.PersistIncludes = true
+		ctrl.StorageConfigs[0].Persister = fsp
+
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "included",
+				Namespace: namespace.GetName(),
+			},
+			Data: map[string]string{
+				"foo": "bar",
+			},
+		}
+		Expect(testenv.Client.Create(ctx, cm)).To(Succeed())
+
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(testGVK)
+		obj.SetName("includes")
+		obj.SetNamespace(namespace.GetName())
+		obj.SetAnnotations(map[string]string{
+			constants.ANNOTATION_INCLUDE: "configmap/included, secret/missing",
+		})
+		Expect(testenv.Client.Create(ctx, obj)).To(Succeed())
+
+		_, err = ctrl.Reconcile(ctx, testutils.ReconcileRequestFromObject(obj))
+		Expect(err).ToNot(HaveOccurred())
+		persisted, err := fsp.Get(ctx, cm.Name, cm.Namespace, corev1.SchemeGroupVersion.WithKind("ConfigMap"), testStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persisted).ToNot(BeNil())
+		Expect(persisted.Object).To(HaveKeyWithValue("data", HaveKeyWithValue("foo", "bar")))
+		exists, err := fsp.Exists(ctx, "missing", cm.Namespace, corev1.SchemeGroupVersion.WithKind("Secret"), testStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+
+		By("failing for invalid references")
+		old := obj.DeepCopy()
+		obj.SetAnnotations(map[string]string{
+			constants.ANNOTATION_INCLUDE: "configmap",
+		})
+		Expect(testenv.Client.Patch(ctx, obj, client.MergeFrom(old))).To(Succeed())
+		_, err = ctrl.Reconcile(ctx, testutils.ReconcileRequestFromObject(obj))
+		Expect(err).To(HaveOccurred())
+		Expect(testenv.Client.Delete(ctx, cm)).To(Succeed())
+	})
+
+	It("should not sync suspended resources until they have been resumed", func() {
+		fsp, err := fspersist.NewForMemory(&config.FileSystemConfiguration{RootPath: "/data"})
+		Expect(err).ToNot(HaveOccurred())
+		ctrl.StorageConfigs[0].Persister = fsp
+
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(testGVK)
+		obj.SetName("suspended")
+		obj.SetNamespace(namespace.GetName())
+		obj.SetAnnotations(map[string]string{
+			constants.ANNOTATION_SUSPEND: "true",
+		})
+		Expect(testenv.Client.Create(ctx, obj)).To(Succeed())
+
+		By("skipping resources which are suspended via annotation")
+		res, err := ctrl.Reconcile(ctx, testutils.ReconcileRequestFromObject(obj))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.RequeueAfter).To(BeZero())
+		exists, err := fsp.Exists(ctx, obj.GetName(), obj.GetNamespace(), testGVK, testStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+
+		By("postponing the sync during a maintenance window")
+		old := obj.DeepCopy()
+		obj.SetAnnotations(nil)
+		Expect(testenv.Client.Patch(ctx, obj, client.MergeFrom(old))).To(Succeed())
+		now := time.Now().UTC()
+		ctrl.SyncConfig.MaintenanceWindows = []*config.MaintenanceWindow{
+			{
+				Start:    now.Add(-time.Hour).Format("15:04"),
+				End:      now.Add(time.Hour).Format("15:04"),
+				TimeZone: "UTC",
+			},
+		}
+		res, err = ctrl.Reconcile(ctx, testutils.ReconcileRequestFromObject(obj))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.RequeueAfter).To(And(BeNumerically(">", 0), BeNumerically("<=", time.Hour)))
+		exists, err = fsp.Exists(ctx, obj.GetName(), obj.GetNamespace(), testGVK, testStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+
+		By("syncing the resource after the maintenance window")
+		ctrl.SyncConfig.MaintenanceWindows = nil
+		_, err = ctrl.Reconcile(ctx, testutils.ReconcileRequestFromObject(obj))
+		Expect(err).ToNot(HaveOccurred())
+		exists, err = fsp.Exists(ctx, obj.GetName(), obj.GetNamespace(), testGVK, testStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+	})
+
+	It("should handle the deletion of suspended resources", func() {
+		fsp, err := fspersist.NewForMemory(&config.FileSystemConfiguration{RootPath: "/data"})
+		Expect(err).ToNot(HaveOccurred())
+		ctrl.StorageConfigs[0].Persister = fsp
+
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(testGVK)
+		obj.SetName("suspended-deletion")
+		obj.SetNamespace(namespace.GetName())
+		Expect(testenv.Client.Create(ctx, obj)).To(Succeed())
+
+		By("syncing the resource")
+		_, err = ctrl.Reconcile(ctx, testutils.ReconcileRequestFromObject(obj))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(testenv.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+		Expect(utils.HasFinalizer(obj)).To(BeTrue())
+		exists, err := fsp.Exists(ctx, obj.GetName(), obj.GetNamespace(), testGVK, testStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+
+		By("deleting the suspended resource")
+		old := obj.DeepCopy()
+		obj.SetAnnotations(map[string]string{
+			constants.ANNOTATION_SUSPEND: "true",
+		})
+		Expect(testenv.Client.Patch(ctx, obj, client.MergeFrom(old))).To(Succeed())
+		Expect(testenv.Client.Delete(ctx, obj)).To(Succeed())
+		_, err = ctrl.Reconcile(ctx, testutils.ReconcileRequestFromObject(obj))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(apierrors.IsNotFound(testenv.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj))).To(BeTrue())
+		exists, err = fsp.Exists(ctx, obj.GetName(), obj.GetNamespace(), testGVK, testStorageRef.SubPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+	})
+
+	It("should count watch restarts and informer resyncs and drop stale events", func() {
+		gvr := schema.GroupVersionResource{Group: testGVK.Group, Version: testGVK.Version, Resource: "dummies"}
+		watches.register(watchedResource{cluster: "test", gvr: gvr}, "watchTest")
+		now := time.Now()
+		observe := func(rawURL string, at time.Time) {
+			u, err := url.Parse(rawURL)
+			Expect(err).ToNot(HaveOccurred())
+			watches.observe("test", u, at)
+		}
+		collection := "https://cluster/apis/k8syncer.gardener.cloud/v1/namespaces/test/dummies"
+
+		By("ignoring the initial list and watch")
+		observe(collection+"?limit=500&resourceVersion=0", now)
+		observe(collection+"?resourceVersion=1&timeoutSeconds=300&watch=true", now)
+		Expect(testutil.ToFloat64(watchRestartCounter.WithLabelValues("watchTest"))).To(BeZero())
+		Expect(testutil.ToFloat64(informerResyncCounter.WithLabelValues("watchTest"))).To(BeZero())
+
+		By("ignoring requests for single resources")
+		observe(collection+"/foo", now)
+		Expect(testutil.ToFloat64(informerResyncCounter.WithLabelValues("watchTest"))).To(BeZero())
+
+		By("ignoring watches which are restarted after their timeout")
+		now = now.Add(301 * time.Second)
+		observe(collection+"?resourceVersion=2&timeoutSeconds=300&watch=true", now)
+		Expect(testutil.ToFloat64(watchRestartCounter.WithLabelValues("watchTest"))).To(BeZero())
+
+		By("counting watches which are restarted early and lists after the initial one")
+		now = now.Add(time.Second)
+		observe(collection+"?resourceVersion=3&timeoutSeconds=300&watch=true", now)
+		observe(collection+"?limit=500&resourceVersion=3", now)
+		Expect(testutil.ToFloat64(watchRestartCounter.WithLabelValues("watchTest"))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(informerResyncCounter.WithLabelValues("watchTest"))).To(Equal(1.0))
+
+		By("dropping update events with an older resourceVersion")
+		oldObj := &unstructured.Unstructured{}
+		oldObj.SetResourceVersion("5")
+		newObj := oldObj.DeepCopy()
+		newObj.SetResourceVersion("4")
+		pred := StaleEventPredicate{SyncConfigID: "watchTest"}
+		Expect(pred.Update(event.UpdateEvent{ObjectOld: oldObj, ObjectNew: newObj})).To(BeFalse())
+		Expect(pred.Update(event.UpdateEvent{ObjectOld: newObj, ObjectNew: oldObj})).To(BeTrue())
+		Expect(pred.Update(event.UpdateEvent{ObjectOld: oldObj, ObjectNew: oldObj})).To(BeTrue())
+		Expect(testutil.ToFloat64(staleEventCounter.WithLabelValues("watchTest"))).To(Equal(1.0))
+	})
+
+	It("should collapse updates within the debounce window into a single request", func() {
+		q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+		defer q.ShutDown()
+		h := DebouncingEventHandler{EventHandler: &handler.EnqueueRequestForObject{}, Window: 200 * time.Millisecond}
+		obj := &unstructured.Unstructured{}
+		obj.SetNamespace("test")
+		obj.SetName("foo")
+
+		By("delaying updates")
+		h.Update(ctx, event.UpdateEvent{ObjectOld: obj, ObjectNew: obj}, q)
+		h.Update(ctx, event.UpdateEvent{ObjectOld: obj, ObjectNew: obj}, q)
+		Expect(q.Len()).To(BeZero())
+		Eventually(q.Len).Should(Equal(1))
+		Consistently(q.Len, 300*time.Millisecond).Should(Equal(1))
+		item, _ := q.Get()
+		Expect(item).To(Equal(testutils.ReconcileRequestFromObject(obj)))
+		q.Done(item)
+
+		By("enqueueing creations immediately")
+		h.Create(ctx, event.CreateEvent{Object: obj}, q)
+		Expect(q.Len()).To(Equal(1))
+	})
+
+	It("should enqueue triggered resources for the sync configs which watch them", func() {
+		trigger, err := NewSyncTrigger(&config.SyncTriggerConfiguration{Token: "s3cr3t"})
+		Expect(err).ToNot(HaveOccurred())
+		sources := map[string]*source.Channel{}
+		for _, sc := range []*config.SyncConfig{
+			{ID: "all", Resource: &config.ResourceSyncConfig{}},
+			{ID: "test", Resource: &config.ResourceSyncConfig{Namespace: "test"}},
+		} {
+			sources[sc.ID] = trigger.source(sc, testGVK).(*source.Channel)
+		}
+		Expect(reactOnPredicate([]config.ReactOnTrigger{config.REACT_ON_GENERATION}).Generic(event.GenericEvent{})).To(BeTrue())
+
+		request := func(method, token, query string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, TriggerEndpointPath+"?"+query, nil)
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			rec := httptest.NewRecorder()
+			trigger.ServeHTTP(rec, req)
+			return rec
+		}
+		query := url.Values{
+			"group":     {testGVK.Group},
+			"version":   {testGVK.Version},
+			"kind":      {testGVK.Kind},
+			"namespace": {"test"},
+			"name":      {"foo"},
+		}
+
+		By("rejecting unauthenticated and incomplete requests")
+		Expect(request(http.MethodGet, "s3cr3t", query.Encode()).Code).To(Equal(http.StatusMethodNotAllowed))
+		Expect(request(http.MethodPost, "", query.Encode()).Code).To(Equal(http.StatusUnauthorized))
+		Expect(request(http.MethodPost, "wrong", query.Encode()).Code).To(Equal(http.StatusUnauthorized))
+		Expect(request(http.MethodPost, "s3cr3t", "kind=Dummy&name=foo").Code).To(Equal(http.StatusBadRequest))
+
+		By("enqueueing the resource for all sync configs which watch it")
+		rec := request(http.MethodPost, "s3cr3t", query.Encode())
+		Expect(rec.Code).To(Equal(http.StatusAccepted))
+		Expect(rec.Body.String()).To(MatchJSON(`{"syncConfigIDs": ["all", "test"]}`))
+		for _, id := range []string{"all", "test"} {
+			Expect(sources[id].Source).To(Receive(WithTransform(func(e event.GenericEvent) client.ObjectKey {
+				return client.ObjectKeyFromObject(e.Object)
+			}, Equal(client.ObjectKey{Namespace: "test", Name: "foo"}))))
+		}
+
+		By("considering the watched namespace and the requested sync config")
+		query.Set("namespace", "other")
+		Expect(request(http.MethodPost, "s3cr3t", query.Encode()).Body.String()).To(MatchJSON(`{"syncConfigIDs": ["all"]}`))
+		query.Set("syncConfig", "test")
+		Expect(request(http.MethodPost, "s3cr3t", query.Encode()).Code).To(Equal(http.StatusNotFound))
+		query.Set("kind", "Other")
+		query.Del("syncConfig")
+		Expect(request(http.MethodPost, "s3cr3t", query.Encode()).Code).To(Equal(http.StatusNotFound))
+
+		By("rejecting triggers if the queue is full")
+		query.Set("kind", testGVK.Kind)
+		for i := 1; i < triggerQueueSize; i++ {
+			Expect(request(http.MethodPost, "s3cr3t", query.Encode()).Code).To(Equal(http.StatusAccepted))
+		}
+		Expect(request(http.MethodPost, "s3cr3t", query.Encode()).Code).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("should keep track of the synced objects", func() {
+		fsp, err := fspersist.NewForMemory(&config.FileSystemConfiguration{RootPath: "/data"})
+		Expect(err).ToNot(HaveOccurred())
+		fsStorageRef := &config.StorageReference{Name: "fsStorage", SubPath: testStorageRef.SubPath, FileNaming: config.FILE_NAMING_NAME}
+		ctrl.StorageConfigs = []*StorageConfiguration{{
+			StorageReference: fsStorageRef,
+			StorageDefinition: &config.StorageDefinition{
+				Name: fsStorageRef.Name,
+				Type: config.STORAGE_TYPE_FILESYSTEM,
+			},
+			Persister:   fsp,
+			Transformer: basicTransformer,
+		}}
+		ctrl.SyncConfig.Finalize = utils.Ptr(false)
+		ctrl.SyncedObjects = NewSyncedObjects()
+
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(testGVK)
+		obj.SetName("synced")
+		obj.SetNamespace(namespace.GetName())
+		obj.SetUID("synced-uid")
+		obj.SetGeneration(3)
+		other := obj.DeepCopy()
+		other.SetName("other")
+		other.SetNamespace("other")
+
+		request := func(query string) []SyncedObjectEntry {
+			rec := httptest.NewRecorder()
+			ctrl.SyncedObjects.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, SyncedObjectsEndpointPath+"?"+query, nil))
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			entries := []SyncedObjectEntry{}
+			Expect(json.Unmarshal(rec.Body.Bytes(), &entries)).To(Succeed())
+			return entries
+		}
+
+		By("recording the successfully synced objects")
+		Expect(ctrl.handleCreateOrUpdate(ctx, obj)).To(Succeed())
+		Expect(ctrl.handleCreateOrUpdate(ctx, other)).To(Succeed())
+		Expect(request("")).To(HaveLen(2))
+		entries := request("namespace=" + obj.GetNamespace())
+		Expect(entries).To(HaveLen(1))
+		entry := entries[0]
+		Expect(entry.SyncConfigID).To(Equal(ctrl.SyncConfig.ID))
+		Expect(entry.GVK).To(Equal(utils.GVKToString(testGVK, true)))
+		Expect(entry.Namespace).To(Equal(obj.GetNamespace()))
+		Expect(entry.Name).To(Equal(obj.GetName()))
+		Expect(entry.UID).To(Equal(string(obj.GetUID())))
+		Expect(entry.LastSyncedGeneration).To(BeEquivalentTo(3))
+		Expect(entry.Storages).To(ConsistOf(SatisfyAll(
+			HaveField("Storage", fsStorageRef.Name),
+			HaveField("SubPath", fsStorageRef.SubPath),
+			HaveField("Name", obj.GetName()),
+			HaveField("Digest", HavePrefix("sha256:")),
+		)))
+		digest := entry.Storages[0].Digest
+
+		By("filtering by sync config")
+		Expect(request("syncConfig=" + ctrl.SyncConfig.ID)).To(HaveLen(2))
+		Expect(request("syncConfig=unknown")).To(BeEmpty())
+
+		By("updating the digest when the persisted data changes")
+		obj.SetLabels(map[string]string{"foo": "bar"})
+		Expect(ctrl.handleCreateOrUpdate(ctx, obj)).To(Succeed())
+		entries = request("namespace=" + obj.GetNamespace())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Storages[0].Digest).ToNot(Equal(digest))
+
+		By("removing deleted objects")
+		Expect(ctrl.handleDelete(ctx, obj)).To(Succeed())
+		Expect(request("")).To(ConsistOf(HaveField("Name", other.GetName())))
+	})
+
+	It("should only fetch the metadata of resources whose existence is checked via the API server", func() {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(testGVK)
+		obj.SetName("orphan")
+		obj.SetNamespace(namespace.GetName())
+
+		By("fetching the whole resource from the cache")
+		cached := &recordingClient{}
+		ctrl.Client = cached
+		exists, err := ctrl.existsInCluster(ctx, obj)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+		Expect(cached.fetched).To(ConsistOf(BeAssignableToTypeOf(&unstructured.Unstructured{})))
+
+		By("fetching only the metadata via the impersonated client")
+		impersonated := &recordingClient{}
+		ctrl.ReadClient = impersonated
+		exists, err = ctrl.existsInCluster(ctx, obj)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+		Expect(impersonated.fetched).To(ConsistOf(SatisfyAll(
+			BeAssignableToTypeOf(&metav1.PartialObjectMetadata{}),
+			WithTransform(func(o client.Object) schema.GroupVersionKind { return o.GetObjectKind().GroupVersionKind() }, Equal(testGVK)),
+		)))
+		Expect(cached.fetched).To(HaveLen(1))
+	})
+
 })
+
+// recordingClient is a client which records the objects it is asked to fetch and reports all of them as not found.
+type recordingClient struct {
+	client.Client
+	fetched []client.Object
+}
+
+func (rc *recordingClient) Get(_ context.Context, key client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	rc.fetched = append(rc.fetched, obj)
+	return apierrors.NewNotFound(schema.GroupResource{}, key.Name)
+}
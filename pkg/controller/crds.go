@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/gardener/k8syncer/pkg/utils"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// crdDirectory is the directory below the subPath of a storage reference which contains the persisted CRD.
+const crdDirectory = "_crds"
+
+// CRDGVK is the GroupVersionKind of CustomResourceDefinitions.
+var CRDGVK = schema.GroupVersionKind{
+	Group:   "apiextensions.k8s.io",
+	Version: "v1",
+	Kind:    "CustomResourceDefinition",
+}
+
+// CRDName returns the name of the CustomResourceDefinition which defines the given kind.
+func CRDName(mapper meta.RESTMapper, gvk schema.GroupVersionKind) (string, error) {
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return "", fmt.Errorf("unable to determine resource for '%s': %w", gvk.String(), err)
+	}
+	return fmt.Sprintf("%s.%s", mapping.Resource.Resource, mapping.Resource.Group), nil
+}
+
+// crdReconciler persists the CRD of the controller's kind into the controller's storages, see SyncCRD.
+type crdReconciler struct {
+	*Controller
+	crdName string
+}
+
+func (r *crdReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := logging.FromContextOrDiscard(ctx).WithValues(constants.Logging.KEY_RESOURCE_NAME, req.Name)
+	ctx = logging.NewContext(ctx, log)
+	if req.Name != r.crdName {
+		// should be prevented by the predicate
+		return reconcile.Result{}, nil
+	}
+	if res, suspended := r.checkSuspension(ctx); suspended {
+		return res, nil
+	}
+	return reconcile.Result{}, r.SyncCRD(ctx, r.Client, r.crdName)
+}
+
+// SyncCRD fetches the CRD with the given name and persists it into all storages of the controller.
+// If the CRD doesn't exist, it is removed from the storages.
+func (c *Controller) SyncCRD(ctx context.Context, reader client.Reader, crdName string) error {
+	log := logging.FromContextOrDiscard(ctx)
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(CRDGVK)
+	if err := reader.Get(ctx, client.ObjectKey{Name: crdName}, crd); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("error fetching CRD '%s' from cluster: %w", crdName, err)
+		}
+		log.Info("CRD does not exist, removing it from storages")
+		return c.deleteCRD(ctx, crdName)
+	}
+
+	log.Info("Persisting CRD")
+	errs := utils.NewErrorList()
+	for _, storage := range c.StorageConfigs {
+		curLog := log.WithValues(constants.Logging.KEY_RESOURCE_STORAGE_ID, storage.Name())
+		subPath, err := c.crdSubPath(storage)
+		if err != nil {
+			errs.Append(fmt.Errorf("[%s] error while resolving subPath: %w", storage.Name(), err))
+			continue
+		}
+		_, changed, err := storage.Persister.Persist(logging.NewContext(ctx, curLog), crd, storage.Transformer, crd.GetName(), subPath)
+		if err != nil {
+			errs.Append(fmt.Errorf("[%s] error while persisting CRD: %w", storage.Name(), err))
+			continue
+		}
+		if !changed {
+			curLog.Debug("No relevant fields have changed, CRD has not been updated in storage")
+		}
+	}
+	return errs.Aggregate()
+}
+
+// deleteCRD removes the CRD with the given name from all storages of the controller.
+func (c *Controller) deleteCRD(ctx context.Context, crdName string) error {
+	log := logging.FromContextOrDiscard(ctx)
+	errs := utils.NewErrorList()
+	for _, storage := range c.StorageConfigs {
+		curCtx := logging.NewContext(ctx, log.WithValues(constants.Logging.KEY_RESOURCE_STORAGE_ID, storage.Name()))
+		subPath, err := c.crdSubPath(storage)
+		if err != nil {
+			errs.Append(fmt.Errorf("[%s] error while resolving subPath: %w", storage.Name(), err))
+			continue
+		}
+		exists, err := storage.Persister.Exists(curCtx, crdName, "", CRDGVK, subPath)
+		if err != nil {
+			errs.Append(fmt.Errorf("[%s] error while checking for CRD existence: %w", storage.Name(), err))
+			continue
+		}
+		if !exists {
+			continue
+		}
+		if err := storage.Persister.Delete(curCtx, crdName, "", CRDGVK, subPath); err != nil {
+			errs.Append(fmt.Errorf("[%s] error while deleting CRD: %w", storage.Name(), err))
+		}
+	}
+	return errs.Aggregate()
+}
+
+// crdSubPath returns the subPath the CRD is persisted at in the given storage.
+// As CRDs are cluster-scoped, the subPath of the storage reference is resolved without a namespace.
+func (c *Controller) crdSubPath(storage *StorageConfiguration) (string, error) {
+	subPath, err := storage.ResolveSubPath(c.subPathTemplateData(&unstructured.Unstructured{}))
+	if err != nil {
+		return "", err
+	}
+	return path.Join(subPath, crdDirectory), nil
+}
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+var _ handler.EventHandler = DebouncingEventHandler{}
+
+// DebouncingEventHandler wraps an event handler and delays the requests which it enqueues for update events by the given window.
+// As the workqueue keeps the earliest time at which a delayed request becomes ready, all updates of an object within the window
+// after its first update result in a single reconcile.
+// Other events are passed to the wrapped handler unchanged, so creations and deletions are reconciled immediately.
+type DebouncingEventHandler struct {
+	handler.EventHandler
+	Window time.Duration
+}
+
+func (h DebouncingEventHandler) Update(ctx context.Context, e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	h.EventHandler.Update(ctx, e, &delayingQueue{RateLimitingInterface: q, delay: h.Window})
+}
+
+// delayingQueue is a workqueue which delays all added items by the given duration.
+type delayingQueue struct {
+	workqueue.RateLimitingInterface
+	delay time.Duration
+}
+
+func (q *delayingQueue) Add(item interface{}) {
+	q.RateLimitingInterface.AddAfter(item, q.delay)
+}
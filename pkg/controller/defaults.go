@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/k8syncer/pkg/persist/transformers"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// EnableDefaultsPruning wraps the transformers of all storages of the controller with a transformer which removes defaulted fields, see transformers.DefaultsPruner.
+// The schema of the persisted kind is taken from its CRD, if there is one, and from the OpenAPI v3 document of the cluster otherwise.
+// It is fetched once, changes of the schema are picked up after a restart.
+// If no schema can be found for the kind, only null values are pruned.
+func (c *Controller) EnableDefaultsPruning(ctx context.Context, reader client.Reader, restCfg *rest.Config, mapper meta.RESTMapper) error {
+	log := logging.FromContextOrDiscard(ctx)
+	gvk := c.persistGVK()
+	s, definitions, err := fetchCRDSchema(ctx, reader, mapper, gvk)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		s, definitions, err = fetchOpenAPISchema(restCfg, gvk)
+		if err != nil {
+			return err
+		}
+	}
+	if s == nil {
+		log.Info("No schema found for the synced kind, only null values are pruned from the persisted resources")
+	} else {
+		log.Debug("Pruning defaulted fields from the persisted resources", constants.Logging.KEY_RESOURCE_VERSION, gvk.Version)
+	}
+	for _, storage := range c.StorageConfigs {
+		storage.Transformer = transformers.NewDefaultsPruner(storage.Transformer, gvk, s, definitions)
+	}
+	return nil
+}
+
+// fetchCRDSchema returns the openAPIV3Schema of the given version from the CRD which defines the given kind.
+// The returned schema is nil if the kind is not defined by a CRD.
+func fetchCRDSchema(ctx context.Context, reader client.Reader, mapper meta.RESTMapper, gvk schema.GroupVersionKind) (map[string]interface{}, map[string]interface{}, error) {
+	if gvk.Group == "" {
+		return nil, nil, nil
+	}
+	crdName, err := CRDName(mapper, gvk)
+	if err != nil {
+		return nil, nil, err
+	}
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(CRDGVK)
+	if err := reader.Get(ctx, client.ObjectKey{Name: crdName}, crd); err != nil {
+		if apierrors.IsNotFound(err) {
+			// the kind is served by the core api server or an aggregated one
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("error fetching CRD '%s' from cluster: %w", crdName, err)
+	}
+	versions, _, err := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid versions in CRD '%s': %w", crdName, err)
+	}
+	for _, v := range versions {
+		version, ok := v.(map[string]interface{})
+		if !ok || version["name"] != gvk.Version {
+			continue
+		}
+		s, _, err := unstructured.NestedMap(version, "schema", "openAPIV3Schema")
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid schema of version '%s' in CRD '%s': %w", gvk.Version, crdName, err)
+		}
+		// CRD schemas are structural, they don't contain references
+		return s, nil, nil
+	}
+	return nil, nil, fmt.Errorf("CRD '%s' does not serve version '%s'", crdName, gvk.Version)
+}
+
+// fetchOpenAPISchema returns the schema of the given kind from the OpenAPI v3 document of its group version, together with the schemas it references.
+// The returned schema is nil if the document doesn't contain the kind.
+func fetchOpenAPISchema(restCfg *rest.Config, gvk schema.GroupVersionKind) (map[string]interface{}, map[string]interface{}, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(restCfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating discovery client: %w", err)
+	}
+	paths, err := dc.OpenAPIV3().Paths()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching OpenAPI v3 paths: %w", err)
+	}
+	path := fmt.Sprintf("apis/%s/%s", gvk.Group, gvk.Version)
+	if gvk.Group == "" {
+		path = fmt.Sprintf("api/%s", gvk.Version)
+	}
+	gv, ok := paths[path]
+	if !ok {
+		return nil, nil, nil
+	}
+	data, err := gv.Schema(runtime.ContentTypeJSON)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching OpenAPI v3 document for '%s': %w", path, err)
+	}
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("error parsing OpenAPI v3 document for '%s': %w", path, err)
+	}
+	definitions, _, err := unstructured.NestedMap(doc, "components", "schemas")
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid schemas in OpenAPI v3 document for '%s': %w", path, err)
+	}
+	for _, d := range definitions {
+		s, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		gvks, _ := s["x-kubernetes-group-version-kind"].([]interface{})
+		for _, e := range gvks {
+			entry, ok := e.(map[string]interface{})
+			if ok && entry["group"] == gvk.Group && entry["version"] == gvk.Version && entry["kind"] == gvk.Kind {
+				return s, definitions, nil
+			}
+		}
+	}
+	return nil, nil, nil
+}
@@ -0,0 +1,256 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+var abandonedDeletionCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "k8syncer",
+	Name:      "abandoned_deletions_total",
+	Help:      "Number of resources whose deletion from the storages has been given up after repeated failures, by sync config.",
+}, []string{"sync_config"})
+
+// DeletionMetrics returns the prometheus collectors for the metrics about failed deletions.
+// They have to be registered at a prometheus registry in order to be exposed.
+func DeletionMetrics() []prometheus.Collector {
+	return []prometheus.Collector{abandonedDeletionCounter}
+}
+
+// OrphanedStorageEntry describes the storage data of a resource which has been left behind, because its deletion has been given up.
+// The entries are stored in the orphaned-storage ConfigMap, with one key per resource and sync config.
+type OrphanedStorageEntry struct {
+	SyncConfigID string `json:"syncConfigID"`
+	APIVersion   string `json:"apiVersion"`
+	Kind         string `json:"kind"`
+	Namespace    string `json:"namespace,omitempty"`
+	Name         string `json:"name"`
+	// Storages maps the names of the storages from which the resource could not be deleted to the last error.
+	Storages map[string]string `json:"storages"`
+	// Attempts is the number of failed attempts to delete the resource.
+	Attempts int `json:"attempts"`
+	// Time is the time at which the deletion has been given up.
+	Time metav1.Time `json:"time"`
+}
+
+// deletionFailureTracker counts the failed deletions of resources from the storages and records the storage data of resources
+// whose deletion has been given up in a ConfigMap.
+// The failed attempts of deletions which haven't been given up yet are stored in the same ConfigMap, so that the count survives restarts.
+// Use newDeletionFailureTracker to instantiate it.
+type deletionFailureTracker struct {
+	client       client.Client
+	key          types.NamespacedName
+	syncConfigID string
+	gvk          schema.GroupVersionKind
+	maxAttempts  int
+	// now returns the current time, can be overwritten for testing purposes.
+	now func() time.Time
+
+	// lock guards attempts and serializes the updates of the ConfigMap.
+	lock sync.Mutex
+	// attempts contains the failed attempts of this sync config, as they are stored in the ConfigMap.
+	// It is nil until the ConfigMap has been read.
+	attempts map[types.NamespacedName]int
+}
+
+// failedDeletion is the value of the ConfigMap keys which contain the failed attempts of a deletion that hasn't been given up yet.
+type failedDeletion struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Attempts  int    `json:"attempts"`
+}
+
+// attemptsKeySuffix is appended to the ConfigMap keys which contain the failed attempts of a deletion.
+const attemptsKeySuffix = ".attempts"
+
+// newDeletionFailureTracker returns a deletionFailureTracker for the given sync config and resource, which writes the ConfigMap via the given client.
+// It returns nil if no configuration is given, as failed deletions are retried forever then.
+func newDeletionFailureTracker(c client.Client, syncConfigID string, gvk schema.GroupVersionKind, dfCfg *config.DeletionFailureConfiguration) *deletionFailureTracker {
+	if dfCfg == nil {
+		return nil
+	}
+	return &deletionFailureTracker{
+		client: c,
+		key: types.NamespacedName{
+			Namespace: dfCfg.ConfigMapNamespace,
+			Name:      dfCfg.ConfigMapName,
+		},
+		syncConfigID: syncConfigID,
+		gvk:          gvk,
+		maxAttempts:  dfCfg.MaxAttempts,
+		now:          time.Now,
+	}
+}
+
+// failed counts a failed deletion of the specified resource.
+// It returns the number of failed attempts and whether the deletion should be given up.
+// The count is stored in the ConfigMap, unless the deletion should be given up, as record removes it then anyway.
+// A nil tracker never gives up.
+func (t *deletionFailureTracker) failed(ctx context.Context, key types.NamespacedName) (int, bool, error) {
+	if t == nil {
+		return 0, false, nil
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if err := t.load(ctx); err != nil {
+		return 0, false, err
+	}
+	attempts := t.attempts[key] + 1
+	if attempts < t.maxAttempts {
+		data, err := json.Marshal(&failedDeletion{
+			Namespace: key.Namespace,
+			Name:      key.Name,
+			Attempts:  attempts,
+		})
+		if err != nil {
+			return 0, false, err
+		}
+		if err := t.update(ctx, func(cmData map[string]string) {
+			cmData[t.attemptsKey(key)] = string(data)
+		}); err != nil {
+			return 0, false, fmt.Errorf("error storing failed deletion attempts: %w", err)
+		}
+	}
+	t.attempts[key] = attempts
+	return attempts, attempts >= t.maxAttempts, nil
+}
+
+// forget resets the failed attempts of the specified resource.
+// The ConfigMap is only updated if there have been failed attempts.
+func (t *deletionFailureTracker) forget(ctx context.Context, key types.NamespacedName) error {
+	if t == nil {
+		return nil
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if err := t.load(ctx); err != nil {
+		return err
+	}
+	if _, ok := t.attempts[key]; !ok {
+		return nil
+	}
+	if err := t.update(ctx, func(cmData map[string]string) {
+		delete(cmData, t.attemptsKey(key))
+	}); err != nil {
+		return fmt.Errorf("error removing failed deletion attempts: %w", err)
+	}
+	delete(t.attempts, key)
+	return nil
+}
+
+// record writes the given entry into the orphaned-storage ConfigMap, creating the ConfigMap if it doesn't exist.
+// An existing entry for the same resource and sync config is overwritten. The failed attempts of the resource are removed.
+func (t *deletionFailureTracker) record(ctx context.Context, entry *OrphanedStorageEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	key := types.NamespacedName{Namespace: entry.Namespace, Name: entry.Name}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	err = t.update(ctx, func(cmData map[string]string) {
+		cmData[orphanedStorageKey(entry)] = string(data)
+		delete(cmData, t.attemptsKey(key))
+	})
+	if err != nil {
+		return err
+	}
+	delete(t.attempts, key)
+	return nil
+}
+
+// load reads the failed attempts of this sync config from the ConfigMap, if this hasn't happened yet.
+// Keys which cannot be parsed are ignored. The lock has to be held by the caller.
+func (t *deletionFailureTracker) load(ctx context.Context) error {
+	if t.attempts != nil {
+		return nil
+	}
+	cm := &corev1.ConfigMap{}
+	if err := t.client.Get(ctx, t.key, cm); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("error fetching orphaned-storage configmap: %w", err)
+		}
+	}
+	attempts := map[types.NamespacedName]int{}
+	for k, v := range cm.Data {
+		// sync config IDs don't contain dots, so the prefix can't match the keys of other sync configs
+		if !strings.HasPrefix(k, t.syncConfigID+".") || !strings.HasSuffix(k, attemptsKeySuffix) {
+			continue
+		}
+		fd := &failedDeletion{}
+		if err := json.Unmarshal([]byte(v), fd); err != nil {
+			continue
+		}
+		attempts[types.NamespacedName{Namespace: fd.Namespace, Name: fd.Name}] = fd.Attempts
+	}
+	t.attempts = attempts
+	return nil
+}
+
+// update applies the given modification to the data of the ConfigMap, creating the ConfigMap if it doesn't exist.
+// Conflicts are retried.
+func (t *deletionFailureTracker) update(ctx context.Context, modify func(cmData map[string]string)) error {
+	return retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}, func() error {
+		cm := &corev1.ConfigMap{}
+		if err := t.client.Get(ctx, t.key, cm); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return fmt.Errorf("error fetching orphaned-storage configmap: %w", err)
+			}
+			cm = &corev1.ConfigMap{}
+			cm.SetName(t.key.Name)
+			cm.SetNamespace(t.key.Namespace)
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		modify(cm.Data)
+		if cm.ResourceVersion == "" {
+			if len(cm.Data) == 0 {
+				// nothing to store
+				return nil
+			}
+			return t.client.Create(ctx, cm)
+		}
+		return t.client.Update(ctx, cm)
+	})
+}
+
+// attemptsKey returns the ConfigMap key for the failed attempts of the specified resource.
+func (t *deletionFailureTracker) attemptsKey(key types.NamespacedName) string {
+	return resourceDataKey(t.syncConfigID, t.gvk.GroupVersion().String(), t.gvk.Kind, key.Namespace, key.Name) + attemptsKeySuffix
+}
+
+// orphanedStorageKey returns the ConfigMap key for the given entry.
+func orphanedStorageKey(entry *OrphanedStorageEntry) string {
+	return resourceDataKey(entry.SyncConfigID, entry.APIVersion, entry.Kind, entry.Namespace, entry.Name)
+}
+
+// resourceDataKey returns the ConfigMap key prefix for the data about the specified resource.
+// Resource names may contain characters which are not allowed in ConfigMap keys, so a hash of the resource is used.
+func resourceDataKey(syncConfigID, apiVersion, kind, namespace, name string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%s/%s", apiVersion, kind, namespace, name)))
+	return fmt.Sprintf("%s.%s", syncConfigID, hex.EncodeToString(sum[:])[:16])
+}
@@ -6,22 +6,95 @@ package controller
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"time"
 
 	"github.com/gardener/landscaper/controller-utils/pkg/logging"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist/filesystem"
 	"github.com/gardener/k8syncer/pkg/state"
 	"github.com/gardener/k8syncer/pkg/utils/constants"
 )
 
 const (
+	// retryLimit is the number of retries of conflicting updates, if the sync config doesn't configure update retries.
 	retryLimit = 1
+	// maxUpdateBackoff is the upper limit for the exponential backoff between retries of conflicting updates.
+	maxUpdateBackoff = time.Minute
 )
 
+// readClient returns the client which should be used for reading the reconciled resources.
+func (c *Controller) readClient() client.Client {
+	if c.ReadClient != nil {
+		return c.ReadClient
+	}
+	return c.Client
+}
+
+// convertForPersistence returns the given object in the version it should be persisted in.
+// If a persist version is configured, the object is fetched again at that version, so that the API server converts it.
+// Otherwise, the object is returned unchanged.
+func (c *Controller) convertForPersistence(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	gvk := c.persistGVK()
+	if gvk == obj.GroupVersionKind() {
+		return obj, nil
+	}
+	res := &unstructured.Unstructured{}
+	res.SetGroupVersionKind(gvk)
+	if err := c.readClient().Get(ctx, client.ObjectKeyFromObject(obj), res); err != nil {
+		return nil, fmt.Errorf("error fetching resource as '%s': %w", gvk.GroupVersion().String(), err)
+	}
+	return res, nil
+}
+
+// persistGVK returns the GroupVersionKind under which the resources are persisted.
+func (c *Controller) persistGVK() schema.GroupVersionKind {
+	if c.PersistGVK.Empty() {
+		return c.GVK
+	}
+	return c.PersistGVK
+}
+
+// subPathTemplateData returns the data which is used to resolve templated storage reference subPaths for the given object.
+func (c *Controller) subPathTemplateData(obj *unstructured.Unstructured) *config.SubPathTemplateData {
+	res := &config.SubPathTemplateData{
+		Namespace: obj.GetNamespace(),
+		Kind:      c.GVK.Kind,
+	}
+	if c.Config != nil {
+		res.ClusterName = c.Config.ClusterName
+	}
+	if c.SyncConfig != nil {
+		res.ClusterName = c.SyncConfig.ClusterNameOrDefault(res.ClusterName)
+	}
+	return res
+}
+
+// recheckInterval returns the interval after which successfully synced resources should be reconciled again.
+// If multiple storages specify a recheck interval, the smallest one is returned.
+// 0 means that no recheck is configured.
+func (c *Controller) recheckInterval() time.Duration {
+	var res time.Duration
+	for _, storage := range c.StorageConfigs {
+		if storage.StorageReference == nil || storage.RecheckInterval == nil {
+			continue
+		}
+		if res == 0 || storage.RecheckInterval.Duration < res {
+			res = storage.RecheckInterval.Duration
+		}
+	}
+	return res
+}
+
 // updateStateOnResource sets given state fields on the resource and updates it, with retrying in case of a conflict.
 // State fields and their values are expected as key-value-pairs, similar to how the logger does it.
 //
@@ -63,13 +136,86 @@ func (c *Controller) updateStateOnResource(ctx context.Context, obj *unstructure
 	logFields = append(logFields, constants.Logging.KEY_STATE_DISPLAY, c.StateDisplay.Type(), constants.Logging.KEY_STATE_VERBOSITY, string(c.StateDisplay.Verbosity()))
 	log.Debug("Updating resource state", logFields...)
 
-	return c.updateWithRetry(ctx, obj, func(obj *unstructured.Unstructured) (sets.Set[string], error) {
+	err := c.updateWithRetry(ctx, obj, func(obj *unstructured.Unstructured) (sets.Set[string], error) {
 		changedFields, err := c.StateDisplay.Write(obj, s, fieldsToUpdate.UnsortedList()...)
 		if err != nil {
 			return changedFields, fmt.Errorf("error writing state for object (using state type '%s'): %w", string(c.SyncConfig.State.Type), err)
 		}
 		return changedFields, nil
-	}, retryLimit)
+	}, c.StateDisplay.Type())
+	if err != nil {
+		state.RecordWriteFailure(c.StateDisplay.Type())
+		return c.handleStateFailure(ctx, err)
+	}
+	return nil
+}
+
+// handleStateFailure applies the configured state failure policy to the given error from writing or removing the state.
+// It returns the error if the reconcile should fail and logs it otherwise.
+func (c *Controller) handleStateFailure(ctx context.Context, err error) error {
+	policy := config.STATE_FAILURE_POLICY_FAIL
+	if c.SyncConfig != nil && c.SyncConfig.State != nil && c.SyncConfig.State.FailurePolicy != "" {
+		policy = c.SyncConfig.State.FailurePolicy
+	}
+	log := logging.FromContextOrDiscard(ctx)
+	switch policy {
+	case config.STATE_FAILURE_POLICY_WARN:
+		log.Error(err, "Unable to update state, continuing as configured by the state failure policy")
+		return nil
+	case config.STATE_FAILURE_POLICY_IGNORE:
+		log.Debug("Unable to update state, ignoring it as configured by the state failure policy", constants.Logging.KEY_ERROR, err.Error())
+		return nil
+	}
+	return err
+}
+
+// writeIntermediateState returns whether the intermediate 'Progressing' phase should be written for the given object before syncing it,
+// depending on the configured state write policy.
+// For 'onChangeOnly', it is only written if the object has changed since it has been synced last.
+func (c *Controller) writeIntermediateState(obj *unstructured.Unstructured) bool {
+	switch c.SyncConfig.StateWritePolicy {
+	case config.STATE_WRITE_POLICY_FINAL_ONLY:
+		return false
+	case config.STATE_WRITE_POLICY_ON_CHANGE_ONLY:
+		if c.StateDisplay == nil {
+			return false
+		}
+		synced, err := state.IsSyncedWithChangeDetection(obj, c.StateDisplay, nil, c.SyncConfig.ChangeDetection)
+		// if the state cannot be read, it is overwritten anyway
+		return err != nil || !synced
+	}
+	return true
+}
+
+// updateContentHashOnResource writes the SHA256 hash of the persisted form of the given transformed resource into the content hash annotation of the resource.
+// The resource is only updated if the annotation doesn't already contain the hash.
+func (c *Controller) updateContentHashOnResource(ctx context.Context, obj, transformed *unstructured.Unstructured) error {
+	hexHash, err := contentHash(transformed)
+	if err != nil {
+		return err
+	}
+	return c.updateWithRetry(ctx, obj, func(obj *unstructured.Unstructured) (sets.Set[string], error) {
+		ann := obj.GetAnnotations()
+		if ann[constants.ANNOTATION_CONTENT_HASH] == hexHash {
+			return nil, nil
+		}
+		if ann == nil {
+			ann = map[string]string{}
+		}
+		ann[constants.ANNOTATION_CONTENT_HASH] = hexHash
+		obj.SetAnnotations(ann)
+		return sets.New[string]("metadata"), nil
+	}, "")
+}
+
+// contentHash returns the hex-encoded SHA256 hash of the persisted form of the given transformed resource.
+func contentHash(transformed *unstructured.Unstructured) (string, error) {
+	data, err := filesystem.ConvertToPersistence(transformed, nil)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
 }
 
 // updateWithRetry takes an idempotent(!) change function and applies it to the object.
@@ -78,13 +224,18 @@ func (c *Controller) updateStateOnResource(ctx context.Context, obj *unstructure
 //	The function only differentiates between 'status' and anything else at the moment.
 //	If this list is nil or empty, the resource is not updated in the cluster.
 //
-// Applying the update will be retried for up to maxRetries times, but only for conflict errors.
+// Applying the update will be retried as configured in the sync config's update retry configuration, but only for conflict errors.
 // All other errors cause the function to abort and return an error.
-func (c *Controller) updateWithRetry(ctx context.Context, obj *unstructured.Unstructured, changeFunc func(obj *unstructured.Unstructured) (sets.Set[string], error), maxRetries int) error {
+// If the change function writes state, stateType has to be the type of the state display, the updates and retried conflicts are then counted in the state write metrics.
+func (c *Controller) updateWithRetry(ctx context.Context, obj *unstructured.Unstructured, changeFunc func(obj *unstructured.Unstructured) (sets.Set[string], error), stateType string) error {
+	maxRetries := c.maxUpdateRetries()
 	success := false
 	for tries := 0; !success; tries++ {
 		if tries > 0 {
 			// this is not the first try
+			if err := c.waitForUpdateRetry(ctx, tries); err != nil {
+				return fmt.Errorf("error waiting for update retry: %w", err)
+			}
 			// fetch object from cluster, as client.Update does not update object in case of error
 			_ = c.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj)
 			// ignore error, as failing to get the object will likely result in failing to update the object which will be returned if it happens too often
@@ -100,12 +251,14 @@ func (c *Controller) updateWithRetry(ctx context.Context, obj *unstructured.Unst
 		}
 		if changedFields.Has("status") {
 			// update status subresource
+			recordStateWrite(stateType)
 			err := c.Client.Status().Update(ctx, obj)
 			if err != nil {
 				if !apierrors.IsConflict(err) || tries >= maxRetries {
 					// only retry update conflicts
 					return fmt.Errorf("error updating object: %w", err)
 				}
+				recordStateConflict(stateType)
 				success = false
 				continue
 			}
@@ -115,12 +268,14 @@ func (c *Controller) updateWithRetry(ctx context.Context, obj *unstructured.Unst
 		if len(changedFields) > 0 {
 			// something else except for status has changed
 			// update resource
+			recordStateWrite(stateType)
 			err := c.Client.Update(ctx, obj)
 			if err != nil {
 				if !apierrors.IsConflict(err) || tries >= maxRetries {
 					// only retry update conflicts
 					return fmt.Errorf("error updating object: %w", err)
 				}
+				recordStateConflict(stateType)
 				success = false
 				continue
 			}
@@ -130,3 +285,62 @@ func (c *Controller) updateWithRetry(ctx context.Context, obj *unstructured.Unst
 	return nil
 
 }
+
+// maxUpdateRetries returns how often conflicting updates of the reconciled resources are retried.
+func (c *Controller) maxUpdateRetries() int {
+	if c.SyncConfig == nil || c.SyncConfig.UpdateRetry == nil || c.SyncConfig.UpdateRetry.MaxRetries == nil {
+		return retryLimit
+	}
+	return *c.SyncConfig.UpdateRetry.MaxRetries
+}
+
+// updateRetryBackoff returns the time to wait before the given retry of a conflicting update, starting at 1.
+// The configured backoff is doubled for every retry after the first one, limited by maxUpdateBackoff, and the configured jitter is added afterwards.
+func (c *Controller) updateRetryBackoff(retry int) time.Duration {
+	if c.SyncConfig == nil || c.SyncConfig.UpdateRetry == nil || c.SyncConfig.UpdateRetry.Backoff == nil {
+		return 0
+	}
+	urCfg := c.SyncConfig.UpdateRetry
+	backoff := urCfg.Backoff.Duration
+	for i := 1; i < retry && backoff < maxUpdateBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxUpdateBackoff {
+		backoff = maxUpdateBackoff
+	}
+	if urCfg.Jitter > 0 {
+		backoff = wait.Jitter(backoff, urCfg.Jitter)
+	}
+	return backoff
+}
+
+// waitForUpdateRetry waits for the backoff of the given retry of a conflicting update.
+// It returns an error if the context is done before.
+func (c *Controller) waitForUpdateRetry(ctx context.Context, retry int) error {
+	backoff := c.updateRetryBackoff(retry)
+	if backoff <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// recordStateWrite counts a state write for the state write metrics, if stateType is not empty.
+func recordStateWrite(stateType string) {
+	if stateType != "" {
+		state.RecordWriteAttempt(stateType)
+	}
+}
+
+// recordStateConflict counts a retried state write conflict for the state write metrics, if stateType is not empty.
+func recordStateConflict(stateType string) {
+	if stateType != "" {
+		state.RecordWriteConflict(stateType)
+	}
+}
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/k8syncer/pkg/utils"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// includeReference is a single entry of the include annotation.
+type includeReference struct {
+	Resource schema.GroupResource
+	Name     string
+}
+
+func (ir includeReference) String() string {
+	return fmt.Sprintf("%s/%s", ir.Resource.String(), ir.Name)
+}
+
+// parseIncludes parses the value of the include annotation.
+// The value is a comma-separated list of references in the format '<resource>[.<group>]/<name>'.
+func parseIncludes(value string) ([]includeReference, error) {
+	res := []includeReference{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		resource, name, ok := strings.Cut(entry, "/")
+		if !ok || resource == "" || name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid reference '%s', expected format is '<resource>[.<group>]/<name>'", entry)
+		}
+		res = append(res, includeReference{
+			Resource: schema.ParseGroupResource(resource),
+			Name:     name,
+		})
+	}
+	return res, nil
+}
+
+// fetchIncludes fetches the resources which are referenced in the include annotation of the given object.
+// Namespaced resources are expected in the namespace of the given object. References to resources which don't exist are skipped.
+func (c *Controller) fetchIncludes(ctx context.Context, obj *unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	value, ok := obj.GetAnnotations()[constants.ANNOTATION_INCLUDE]
+	if !ok {
+		return nil, nil
+	}
+	refs, err := parseIncludes(value)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing annotation '%s': %w", constants.ANNOTATION_INCLUDE, err)
+	}
+	log := logging.FromContextOrDiscard(ctx)
+	mapper := c.Client.RESTMapper()
+	res := make([]*unstructured.Unstructured, 0, len(refs))
+	errs := utils.NewErrorList()
+	for _, ref := range refs {
+		gvk, err := mapper.KindFor(ref.Resource.WithVersion(""))
+		if err != nil {
+			errs.Append(fmt.Errorf("unable to determine kind of included resource '%s': %w", ref.String(), err))
+			continue
+		}
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			errs.Append(fmt.Errorf("unable to determine scope of included resource '%s': %w", ref.String(), err))
+			continue
+		}
+		key := client.ObjectKey{Name: ref.Name}
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			if obj.GetNamespace() == "" {
+				errs.Append(fmt.Errorf("included resource '%s' is namespaced, but the including resource is cluster-scoped", ref.String()))
+				continue
+			}
+			key.Namespace = obj.GetNamespace()
+		}
+		inc := &unstructured.Unstructured{}
+		inc.SetGroupVersionKind(gvk)
+		if err := c.readClient().Get(ctx, key, inc); err != nil {
+			if apierrors.IsNotFound(err) {
+				log.Info("Included resource not found, it is not persisted", constants.Logging.KEY_INCLUDED_RESOURCE, ref.String())
+				continue
+			}
+			errs.Append(fmt.Errorf("error fetching included resource '%s': %w", ref.String(), err))
+			continue
+		}
+		res = append(res, inc)
+	}
+	return res, errs.Aggregate()
+}
+
+// persistIncludes stores the given included resources in the given storage, next to the resource which includes them.
+func (c *Controller) persistIncludes(ctx context.Context, storage *StorageConfiguration, includes []*unstructured.Unstructured, subPath string) error {
+	log := logging.FromContextOrDiscard(ctx)
+	errs := utils.NewErrorList()
+	for _, inc := range includes {
+		ref := fmt.Sprintf("%s/%s", inc.GroupVersionKind().GroupKind().String(), inc.GetName())
+		name, err := storage.ResolveName(inc)
+		if err != nil {
+			errs.Append(fmt.Errorf("error determining storage name of included resource '%s': %w", ref, err))
+			continue
+		}
+		_, changed, err := storage.Persister.Persist(ctx, inc, storage.Transformer, name, subPath)
+		if err != nil {
+			errs.Append(fmt.Errorf("error persisting included resource '%s': %w", ref, err))
+			continue
+		}
+		if !changed {
+			log.Debug("No relevant fields have changed, included resource has not been updated in storage", constants.Logging.KEY_INCLUDED_RESOURCE, ref)
+		}
+	}
+	return errs.Aggregate()
+}
@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils"
+)
+
+// manifestConfigDir is the directory into which the configuration is mounted by the generated manifests.
+const manifestConfigDir = "/etc/config"
+
+// ManifestOptions contains the parameters of the manifests which are generated by GenerateManifests.
+type ManifestOptions struct {
+	// Name is the name of the generated objects.
+	Name string
+	// Namespace is the namespace into which K8Syncer is deployed.
+	Namespace string
+	// Image is the K8Syncer image.
+	Image string
+	// StorageSize is the requested size of the persistent volumes of filesystem storages.
+	StorageSize resource.Quantity
+	// StorageClass is the storage class of the persistent volumes of filesystem storages.
+	// The default storage class of the cluster is used if it is empty.
+	StorageClass string
+}
+
+// GenerateManifests returns the manifests for deploying K8Syncer with the given configuration into the cluster which contains the synced resources:
+// a ServiceAccount, the given roles together with bindings for the ServiceAccount, a Secret containing the raw configuration file,
+// a PersistentVolumeClaim for each filesystem storage which is not kept in memory, and a Deployment which mounts them.
+// The raw configuration file is used as it is, as the given configuration has its references to environment variables and files resolved already.
+func GenerateManifests(cfg *config.K8SyncerConfiguration, rawConfig []byte, roles *RBACRoles, opts *ManifestOptions) []client.Object {
+	labels := map[string]string{
+		"app":  "k8syncer",
+		"role": opts.Name,
+	}
+	objs := []client.Object{}
+
+	sa := &corev1.ServiceAccount{}
+	sa.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ServiceAccount"))
+	sa.SetName(opts.Name)
+	sa.SetNamespace(opts.Namespace)
+	sa.SetLabels(labels)
+	objs = append(objs, sa)
+
+	// each role is bound to the service account
+	subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: sa.GetName(), Namespace: sa.GetNamespace()}}
+	for _, role := range roles.Objects {
+		objs = append(objs, role)
+		if _, ok := role.(*rbacv1.ClusterRole); ok {
+			crb := &rbacv1.ClusterRoleBinding{
+				Subjects: subjects,
+				RoleRef:  rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: role.GetName()},
+			}
+			crb.SetGroupVersionKind(rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"))
+			crb.SetName(role.GetName())
+			objs = append(objs, crb)
+			continue
+		}
+		rb := &rbacv1.RoleBinding{
+			Subjects: subjects,
+			RoleRef:  rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: role.GetName()},
+		}
+		rb.SetGroupVersionKind(rbacv1.SchemeGroupVersion.WithKind("RoleBinding"))
+		rb.SetName(role.GetName())
+		rb.SetNamespace(role.GetNamespace())
+		objs = append(objs, rb)
+	}
+
+	// the configuration may contain credentials, so it is stored in a secret, like in the helm chart
+	secret := &corev1.Secret{
+		StringData: map[string]string{
+			"config.yaml": string(rawConfig),
+		},
+	}
+	secret.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+	secret.SetName(fmt.Sprintf("%s-config", opts.Name))
+	secret.SetNamespace(opts.Namespace)
+	secret.SetLabels(labels)
+	objs = append(objs, secret)
+
+	volumes := []corev1.Volume{
+		{
+			Name: "config",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: secret.GetName()},
+			},
+		},
+	}
+	mounts := []corev1.VolumeMount{
+		{Name: "config", MountPath: manifestConfigDir, ReadOnly: true},
+	}
+	// filesystem storages which share a root path share a volume
+	mountedPaths := map[string]bool{}
+	for _, stDef := range cfg.StorageDefinitions {
+		if stDef.Type != config.STORAGE_TYPE_FILESYSTEM || stDef.FileSystemConfig == nil || *stDef.FileSystemConfig.InMemory || mountedPaths[stDef.FileSystemConfig.RootPath] {
+			continue
+		}
+		mountedPaths[stDef.FileSystemConfig.RootPath] = true
+		volName := fmt.Sprintf("storage-%s", strings.ReplaceAll(strings.ToLower(stDef.Name), "_", "-"))
+		pvc := &corev1.PersistentVolumeClaim{
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: opts.StorageSize},
+				},
+			},
+		}
+		if opts.StorageClass != "" {
+			pvc.Spec.StorageClassName = utils.Ptr(opts.StorageClass)
+		}
+		pvc.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("PersistentVolumeClaim"))
+		pvc.SetName(fmt.Sprintf("%s-%s", opts.Name, volName))
+		pvc.SetNamespace(opts.Namespace)
+		pvc.SetLabels(labels)
+		objs = append(objs, pvc)
+		volumes = append(volumes, corev1.Volume{
+			Name: volName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.GetName()},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{Name: volName, MountPath: stDef.FileSystemConfig.RootPath})
+	}
+
+	// the pod is restarted when the configuration changes
+	configHash := sha256.Sum256(rawConfig)
+	deploy := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Replicas: utils.Ptr[int32](1),
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					ServiceAccountName: sa.GetName(),
+					Containers: []corev1.Container{
+						{
+							Name:            "k8syncer",
+							Image:           opts.Image,
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Command: []string{
+								"/k8syncer",
+								fmt.Sprintf("--config=%s/config.yaml", manifestConfigDir),
+							},
+							VolumeMounts: mounts,
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("100m"),
+									corev1.ResourceMemory: resource.MustParse("256Mi"),
+								},
+							},
+						},
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+	deploy.Spec.Template.SetLabels(labels)
+	deploy.Spec.Template.SetAnnotations(map[string]string{
+		"checksum/k8syncer-config": hex.EncodeToString(configHash[:]),
+	})
+	if len(mountedPaths) > 0 {
+		// the volumes can only be mounted by a single pod at a time
+		deploy.Spec.Strategy.Type = appsv1.RecreateDeploymentStrategyType
+	}
+	deploy.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind("Deployment"))
+	deploy.SetName(opts.Name)
+	deploy.SetNamespace(opts.Namespace)
+	deploy.SetLabels(labels)
+	objs = append(objs, deploy)
+
+	return objs
+}
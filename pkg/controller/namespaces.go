@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/k8syncer/pkg/persist"
+)
+
+// NamespaceGVK is the GroupVersionKind of Namespaces.
+var NamespaceGVK = schema.GroupVersionKind{
+	Version: "v1",
+	Kind:    "Namespace",
+}
+
+// fetchNamespace fetches the Namespace object of the given namespace from the cluster.
+// The namespace is fetched with K8Syncer's own identity, independent of a configured impersonation.
+func (c *Controller) fetchNamespace(ctx context.Context, namespace string) (*unstructured.Unstructured, error) {
+	ns := &unstructured.Unstructured{}
+	ns.SetGroupVersionKind(NamespaceGVK)
+	if err := c.Client.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return nil, fmt.Errorf("error fetching namespace '%s' from cluster: %w", namespace, err)
+	}
+	return ns, nil
+}
+
+// persistNamespace stores the given Namespace object next to the resources of the namespace in the given storage.
+// Storages which don't support persisting namespaces are ignored.
+func (c *Controller) persistNamespace(ctx context.Context, storage *StorageConfiguration, ns *unstructured.Unstructured, subPath string) error {
+	log := logging.FromContextOrDiscard(ctx)
+	nmp, ok := persist.FindNamespaceMetadataPersister(storage.Persister)
+	if !ok {
+		log.Debug("Storage does not support persisting namespaces, namespace is not persisted")
+		return nil
+	}
+	changed, err := nmp.PersistNamespaceMetadata(ctx, ns, storage.Transformer, subPath)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		log.Debug("No relevant fields have changed, namespace has not been updated in storage")
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+	"github.com/gardener/k8syncer/pkg/utils"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// listPageSize is the maximum number of objects fetched per list call during a one-shot sync.
+// It is a variable, so that it can be reduced in tests.
+var listPageSize int64 = 500
+
+// SyncOnce lists all resources of the given sync config and persists each of them once.
+// In contrast to AddControllerToManager, no watch is established and no finalizers are added,
+// as there is no running controller which could remove them again.
+// The returned error aggregates the errors of all resources which could not be synced.
+// If checkpoints is not nil, it is used to store and resume the progress, see SyncAll.
+func SyncOnce(ctx context.Context, baseLogger logging.Logger, restCfg *rest.Config, c client.Client, cfg *config.K8SyncerConfiguration, syncConfig *config.SyncConfig, persisters map[string]persist.Persister, checkpoints *CheckpointStore) error {
+	log := baseLogger.WithName(syncConfig.ID).WithValues(constants.Logging.KEY_ID, syncConfig.ID, constants.Logging.KEY_RESOURCE_GROUP, syncConfig.Resource.Group, constants.Logging.KEY_RESOURCE_VERSION, syncConfig.Resource.Version, constants.Logging.KEY_RESOURCE_KIND, syncConfig.Resource.Kind)
+	ctx = logging.NewContext(ctx, log)
+
+	syncConfig = syncConfig.DeepCopy()
+	if syncConfig.Finalize != nil && *syncConfig.Finalize {
+		log.Info("Finalizers are not added during a one-shot sync, existing finalizers are removed from deleted resources")
+		finalize := false
+		syncConfig.Finalize = &finalize
+	}
+	if syncConfig.ClientRateLimit != nil {
+		var err error
+		restCfg = WithRateLimit(restCfg, syncConfig.ClientRateLimit)
+		c, err = client.New(restCfg, client.Options{Scheme: c.Scheme(), Mapper: c.RESTMapper()})
+		if err != nil {
+			return fmt.Errorf("error creating rate limited client for sync config '%s': %w", syncConfig.ID, err)
+		}
+	}
+	if err := VerifyResourceScope(c.RESTMapper(), syncConfig); err != nil {
+		return err
+	}
+	ctrl, err := NewController(c, cfg, syncConfig, persisters)
+	if err != nil {
+		return err
+	}
+	if syncConfig.Impersonate != nil {
+		ctrl.ReadClient, err = newImpersonatedClient(restCfg, client.Options{Scheme: c.Scheme(), Mapper: c.RESTMapper()}, syncConfig.Impersonate)
+		if err != nil {
+			return fmt.Errorf("error creating impersonated client for sync config '%s': %w", syncConfig.ID, err)
+		}
+	}
+	if syncConfig.Transformer != nil && syncConfig.Transformer.PruneDefaults {
+		if err := ctrl.EnableDefaultsPruning(ctx, c, restCfg, c.RESTMapper()); err != nil {
+			return fmt.Errorf("error fetching schema for pruning defaulted fields in sync config '%s': %w", syncConfig.ID, err)
+		}
+	}
+	if until, suspended := syncConfig.SuspendedUntil(time.Now()); suspended {
+		// the resources are synced by the next run after the suspension has ended
+		logFields := []interface{}{}
+		if !until.IsZero() {
+			logFields = append(logFields, constants.Logging.KEY_RESUME_AT, until.Format(time.RFC3339))
+		}
+		log.Info("Sync config is suspended, skipping one-shot sync", logFields...)
+		return nil
+	}
+	errs := utils.NewErrorList(ctrl.SyncAll(ctx, checkpoints))
+	if syncConfig.PersistCRD {
+		crdName, err := CRDName(c.RESTMapper(), ctrl.GVK)
+		if err == nil {
+			err = ctrl.SyncCRD(logging.NewContext(ctx, log.WithValues(constants.Logging.KEY_RESOURCE_NAME, crdName)), c, crdName)
+		}
+		if err != nil {
+			errs.Append(fmt.Errorf("error syncing CRD: %w", err))
+		}
+	}
+	return errs.Aggregate()
+}
+
+// SyncAll lists all resources matching the sync config and reconciles each of them once.
+// Errors of single resources don't abort the sync, they are aggregated and returned at the end.
+// If checkpoints is not nil, the progress is stored after each listed page and a previously stored progress is resumed.
+func (c *Controller) SyncAll(ctx context.Context, checkpoints *CheckpointStore) error {
+	log := logging.FromContextOrDiscard(ctx)
+
+	cp := &Checkpoint{}
+	if checkpoints != nil {
+		loaded, err := checkpoints.Load(ctx, c.SyncConfig.ID)
+		if err != nil {
+			return fmt.Errorf("error loading checkpoint: %w", err)
+		}
+		if loaded != nil {
+			cp = loaded
+		}
+		if cp.Completed && len(cp.Failed) == 0 {
+			log.Info("Resources have already been synced according to the checkpoint, skipping sync config")
+			return nil
+		}
+	}
+	if cp.LastProcessed != "" {
+		log.Info("Resuming one-shot sync from checkpoint", constants.Logging.KEY_LAST_PROCESSED, cp.LastProcessed, constants.Logging.KEY_FAILED_COUNT, len(cp.Failed))
+	} else {
+		log.Info("Starting one-shot sync")
+	}
+
+	errs := utils.NewErrorList()
+	synced := 0
+	if len(cp.Failed) > 0 {
+		// resources which could not be synced by a previous run are retried first
+		synced = c.retryFailed(ctx, cp, errs)
+		if checkpoints != nil {
+			if err := checkpoints.Save(ctx, c.SyncConfig.ID, cp); err != nil {
+				log.Error(err, "error saving checkpoint")
+				errs.Append(fmt.Errorf("error saving checkpoint: %w", err))
+			}
+		}
+		if cp.Completed {
+			log.Info("Finished retrying failed resources from checkpoint", constants.Logging.KEY_SYNCED_COUNT, synced, constants.Logging.KEY_FAILED_COUNT, len(cp.Failed))
+			return errs.Aggregate()
+		}
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(c.GVK.GroupVersion().WithKind(c.GVK.Kind + "List"))
+	opts := []client.ListOption{client.Limit(listPageSize)}
+	if c.SyncConfig.Resource.Namespace != "" {
+		opts = append(opts, client.InNamespace(c.SyncConfig.Resource.Namespace))
+	}
+	if c.FieldSelector != nil {
+		opts = append(opts, client.MatchingFieldsSelector{Selector: c.FieldSelector})
+	}
+
+	for {
+		curOpts := opts
+		if cp.Continue != "" {
+			curOpts = append(curOpts, client.Continue(cp.Continue))
+		}
+		if err := c.readClient().List(ctx, list, curOpts...); err != nil {
+			if apierrors.IsResourceExpired(err) && cp.Continue != "" {
+				// the checkpoint is too old, list again from the beginning
+				// resources are listed ordered by their key, so already processed ones can be recognized
+				log.Info("Continue token from checkpoint has expired, restarting list and skipping already processed resources")
+				cp.Continue = ""
+				continue
+			}
+			errs.Append(fmt.Errorf("error listing resources: %w", err))
+			return errs.Aggregate()
+		}
+		for i := range list.Items {
+			obj := &list.Items[i]
+			obj.SetGroupVersionKind(c.GVK)
+			key := client.ObjectKeyFromObject(obj).String()
+			if cp.LastProcessed != "" && key <= cp.LastProcessed {
+				continue
+			}
+			curLog := log.WithValues(constants.Logging.KEY_RESOURCE_NAME, obj.GetName(), constants.Logging.KEY_RESOURCE_NAMESPACE, obj.GetNamespace())
+			if _, err := c.reconcile(logging.NewContext(ctx, curLog), obj); err != nil {
+				curLog.Error(err, "error syncing resource")
+				errs.Append(fmt.Errorf("error syncing resource '%s': %w", key, err))
+				cp.Failed = append(cp.Failed, key)
+			} else {
+				synced++
+			}
+			cp.LastProcessed = key
+		}
+		cp.Continue = list.GetContinue()
+		cp.Completed = cp.Continue == ""
+		if checkpoints != nil {
+			if err := checkpoints.Save(ctx, c.SyncConfig.ID, cp); err != nil {
+				// the sync can still continue, only resuming it would not be possible
+				log.Error(err, "error saving checkpoint")
+				errs.Append(fmt.Errorf("error saving checkpoint: %w", err))
+			}
+		}
+		if cp.Completed {
+			break
+		}
+	}
+
+	log.Info("Finished one-shot sync", constants.Logging.KEY_SYNCED_COUNT, synced, constants.Logging.KEY_FAILED_COUNT, len(errs.Errs))
+	return errs.Aggregate()
+}
+
+// retryFailed syncs the resources which are marked as failed in the given checkpoint again.
+// Resources which have been synced successfully or which don't exist anymore are removed from the checkpoint.
+// Returns the number of successfully synced resources, errors are appended to the given list.
+func (c *Controller) retryFailed(ctx context.Context, cp *Checkpoint, errs *utils.ErrorList) int {
+	log := logging.FromContextOrDiscard(ctx)
+	synced := 0
+	failed := []string{}
+	for _, key := range cp.Failed {
+		// keys have the format '<namespace>/<name>', the namespace is empty for cluster-scoped resources
+		namespace, name, _ := strings.Cut(key, "/")
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(c.GVK)
+		if err := c.readClient().Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			errs.Append(fmt.Errorf("error fetching resource '%s': %w", key, err))
+			failed = append(failed, key)
+			continue
+		}
+		curLog := log.WithValues(constants.Logging.KEY_RESOURCE_NAME, obj.GetName(), constants.Logging.KEY_RESOURCE_NAMESPACE, obj.GetNamespace())
+		if _, err := c.reconcile(logging.NewContext(ctx, curLog), obj); err != nil {
+			curLog.Error(err, "error syncing resource")
+			errs.Append(fmt.Errorf("error syncing resource '%s': %w", key, err))
+			failed = append(failed, key)
+			continue
+		}
+		synced++
+	}
+	cp.Failed = failed
+	return synced
+}
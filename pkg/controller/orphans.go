@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/k8syncer/pkg/persist"
+	"github.com/gardener/k8syncer/pkg/utils"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// orphanCleanup returns a runnable which removes orphaned resources from the storages once, see CleanupOrphans.
+func (c *Controller) orphanCleanup(log logging.Logger) manager.RunnableFunc {
+	return func(ctx context.Context) error {
+		ctx = logging.NewContext(ctx, log)
+		if !c.waitForResumption(ctx) {
+			log.Info("Sync config is suspended, skipping orphan cleanup")
+			return nil
+		}
+		if err := c.CleanupOrphans(ctx); err != nil {
+			// orphans don't affect the sync of existing resources, so the manager is not stopped
+			log.Error(err, "error cleaning up orphaned resources")
+		}
+		return nil
+	}
+}
+
+// CleanupOrphans removes all resources of the sync config from the storages which don't exist in the cluster anymore.
+// This is required for sync configs without finalizers, which would otherwise miss deletions that happen while K8Syncer is not running.
+// Only storages which can read all persisted data at once are cleaned up. Storages whose subPath depends on the namespace of the
+// resources are skipped, unless the sync config is restricted to a single namespace, as the namespaces of the persisted resources are unknown.
+func (c *Controller) CleanupOrphans(ctx context.Context) error {
+	log := logging.FromContextOrDiscard(ctx)
+	log.Info("Starting orphan cleanup")
+
+	orphans := []*unstructured.Unstructured{}
+	seen := sets.New[client.ObjectKey]()
+	errs := utils.NewErrorList()
+	for _, storage := range c.StorageConfigs {
+		curLog := log.WithValues(constants.Logging.KEY_RESOURCE_STORAGE_ID, storage.Name())
+		curCtx := logging.NewContext(ctx, curLog)
+		objs, err := c.persistedResources(curCtx, storage)
+		if err != nil {
+			errs.Append(fmt.Errorf("[%s] error reading persisted resources: %w", storage.Name(), err))
+			continue
+		}
+		for _, obj := range objs {
+			key := client.ObjectKeyFromObject(obj)
+			if seen.Has(key) {
+				continue
+			}
+			seen.Insert(key)
+			exists, err := c.existsInCluster(ctx, obj)
+			if err != nil {
+				errs.Append(fmt.Errorf("error fetching resource '%s' from cluster: %w", key.String(), err))
+				continue
+			}
+			if !exists {
+				orphans = append(orphans, obj)
+			}
+		}
+	}
+
+	// the deletion removes the resource from all storages, not only from the one it has been found in
+	for _, obj := range orphans {
+		curLog := log.WithValues(constants.Logging.KEY_RESOURCE_NAME, obj.GetName(), constants.Logging.KEY_RESOURCE_NAMESPACE, obj.GetNamespace())
+		curLog.Info("Removing orphaned resource from storages")
+		if err := c.handleDelete(logging.NewContext(ctx, curLog), obj); err != nil {
+			errs.Append(fmt.Errorf("error removing orphaned resource '%s': %w", client.ObjectKeyFromObject(obj).String(), err))
+		}
+	}
+
+	log.Info("Finished orphan cleanup", constants.Logging.KEY_DELETED_COUNT, len(orphans))
+	return errs.Aggregate()
+}
+
+// persistedResources returns all resources of the sync config's kind which are persisted in the given storage.
+// The returned objects only contain the type information, name, and namespace.
+func (c *Controller) persistedResources(ctx context.Context, storage *StorageConfiguration) ([]*unstructured.Unstructured, error) {
+	log := logging.FromContextOrDiscard(ctx)
+	tr, ok := persist.FindTreeReader(storage.Persister)
+	if !ok {
+		log.Info("Storage does not support reading all persisted resources, skipping orphan cleanup")
+		return nil, nil
+	}
+	scope := &unstructured.Unstructured{}
+	scope.SetNamespace(c.SyncConfig.Resource.Namespace)
+	tmplData := c.subPathTemplateData(scope)
+	subPath, err := storage.ResolveSubPath(tmplData)
+	if err != nil {
+		return nil, err
+	}
+	if tmplData.Namespace == "" {
+		// resolve the subPath with a different namespace to find out whether it depends on the namespace
+		tmplData.Namespace = "k8syncer-orphan-cleanup"
+		nsSubPath, err := storage.ResolveSubPath(tmplData)
+		if err != nil {
+			return nil, err
+		}
+		if nsSubPath != subPath {
+			log.Info("SubPath of storage depends on the namespace, skipping orphan cleanup")
+			return nil, nil
+		}
+	}
+
+	files, err := tr.ReadTree(ctx, subPath)
+	if err != nil {
+		return nil, err
+	}
+	res := []*unstructured.Unstructured{}
+	for path, data := range files {
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(data, &obj.Object); err != nil || obj.Object == nil {
+			log.Debug("Unable to parse persisted file, ignoring it", constants.Logging.KEY_PATH, path)
+			continue
+		}
+		if obj.GroupVersionKind().GroupKind() != c.GVK.GroupKind() || obj.GetName() == "" {
+			// the storage might contain resources of other sync configs
+			continue
+		}
+		if ns := c.SyncConfig.Resource.Namespace; ns != "" && obj.GetNamespace() != ns {
+			continue
+		}
+		ref := &unstructured.Unstructured{}
+		ref.SetGroupVersionKind(c.GVK)
+		ref.SetName(obj.GetName())
+		ref.SetNamespace(obj.GetNamespace())
+		res = append(res, ref)
+	}
+	return res, nil
+}
+
+// existsInCluster returns whether the given resource exists in the cluster.
+// As during reconciliation, resources which are not visible for the impersonated subject are treated as if they didn't exist.
+// If the resource is fetched from the API server, only its metadata is requested.
+func (c *Controller) existsInCluster(ctx context.Context, obj *unstructured.Unstructured) (bool, error) {
+	var cur client.Object
+	if c.ReadClient != nil {
+		// the impersonated client doesn't use the cache, so fetching the whole resource would transfer its content for nothing
+		pom := &metav1.PartialObjectMetadata{}
+		pom.SetGroupVersionKind(c.GVK)
+		cur = pom
+	} else {
+		// the cache contains the whole resources already, reading only the metadata from it would start another informer
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(c.GVK)
+		cur = u
+	}
+	err := c.readClient().Get(ctx, client.ObjectKeyFromObject(obj), cur)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		if apierrors.IsForbidden(err) && c.SyncConfig.Impersonate != nil {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
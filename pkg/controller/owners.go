@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/k8syncer/pkg/persist"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+const (
+	// ownersSidecarKind is the kind of the sidecar document which contains the owners of a resource.
+	ownersSidecarKind = "owners"
+	// maxOwnerDepth is the maximum length of the owner chain which is resolved.
+	maxOwnerDepth = 10
+)
+
+// OwnersDocument is the content of the 'owners' sidecar document of a resource.
+type OwnersDocument struct {
+	Owners []*OwnerInfo `json:"owners"`
+}
+
+// OwnerInfo describes an owner of a resource, together with its own owners.
+type OwnerInfo struct {
+	APIVersion string    `json:"apiVersion"`
+	Kind       string    `json:"kind"`
+	Name       string    `json:"name"`
+	UID        types.UID `json:"uid"`
+	Controller bool      `json:"controller,omitempty"`
+	// Unresolved contains the reason why the owners of this owner could not be resolved, if any.
+	Unresolved string       `json:"unresolved,omitempty"`
+	Owners     []*OwnerInfo `json:"owners,omitempty"`
+}
+
+// ownerReader returns the reader which should be used for fetching the owners of the reconciled resources.
+func (c *Controller) ownerReader() client.Reader {
+	if c.OwnerReader != nil {
+		return c.OwnerReader
+	}
+	return c.readClient()
+}
+
+// ownersDocument resolves the owner chain of the given object and returns its serialized form.
+// Returns nil if the object doesn't have any owners.
+func (c *Controller) ownersDocument(ctx context.Context, obj *unstructured.Unstructured) ([]byte, error) {
+	if len(obj.GetOwnerReferences()) == 0 {
+		return nil, nil
+	}
+	doc := &OwnersDocument{
+		Owners: c.resolveOwners(ctx, obj.GetOwnerReferences(), obj.GetNamespace(), sets.New[types.UID](obj.GetUID()), 1),
+	}
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling owners document: %w", err)
+	}
+	return data, nil
+}
+
+// resolveOwners converts the given owner references and recursively resolves the owners of the referenced objects.
+// Owners which cannot be fetched are still contained in the result, with the reason in 'unresolved'.
+// The visited UIDs are used to detect cycles.
+func (c *Controller) resolveOwners(ctx context.Context, refs []metav1.OwnerReference, namespace string, visited sets.Set[types.UID], depth int) []*OwnerInfo {
+	log := logging.FromContextOrDiscard(ctx)
+	res := make([]*OwnerInfo, 0, len(refs))
+	for _, ref := range refs {
+		oi := &OwnerInfo{
+			APIVersion: ref.APIVersion,
+			Kind:       ref.Kind,
+			Name:       ref.Name,
+			UID:        ref.UID,
+			Controller: ref.Controller != nil && *ref.Controller,
+		}
+		res = append(res, oi)
+		if visited.Has(ref.UID) {
+			oi.Unresolved = "cycle in owner references"
+			continue
+		}
+		if depth >= maxOwnerDepth {
+			oi.Unresolved = fmt.Sprintf("owner chain exceeds maximum depth of %d", maxOwnerDepth)
+			continue
+		}
+		owner, err := c.fetchOwner(ctx, ref, namespace)
+		if err != nil {
+			log.Debug("Unable to fetch owner", constants.Logging.KEY_OWNER, fmt.Sprintf("%s %s", ref.Kind, ref.Name), constants.Logging.KEY_ERROR, err.Error())
+			oi.Unresolved = err.Error()
+			continue
+		}
+		if owner.GetUID() != ref.UID {
+			// the owner has been deleted and recreated with the same name
+			oi.Unresolved = "owner with referenced uid does not exist"
+			continue
+		}
+		if len(owner.GetOwnerReferences()) > 0 {
+			oi.Owners = c.resolveOwners(ctx, owner.GetOwnerReferences(), owner.GetNamespace(), visited.Clone().Insert(ref.UID), depth+1)
+		}
+	}
+	return res
+}
+
+// fetchOwner fetches the object referenced by the given owner reference.
+// Owners have to be in the same namespace as the owned object or cluster-scoped.
+func (c *Controller) fetchOwner(ctx context.Context, ref metav1.OwnerReference, namespace string) (*unstructured.Unstructured, error) {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid apiVersion: %w", err)
+	}
+	gvk := gv.WithKind(ref.Kind)
+	owner := &unstructured.Unstructured{}
+	owner.SetGroupVersionKind(gvk)
+	key := client.ObjectKey{Name: ref.Name, Namespace: namespace}
+	if mapper := c.Client.RESTMapper(); mapper != nil {
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, fmt.Errorf("unknown owner kind: %w", err)
+		}
+		if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+			key.Namespace = ""
+		}
+	}
+	if err := c.ownerReader().Get(ctx, key, owner); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("owner does not exist")
+		}
+		return nil, err
+	}
+	return owner, nil
+}
+
+// persistOwners stores the given owners document as sidecar document next to the resource in the given storage.
+// If the document is nil, an existing sidecar document is removed instead.
+// Storages which don't support sidecar documents are ignored.
+func (c *Controller) persistOwners(ctx context.Context, storage *StorageConfiguration, doc []byte, name, namespace, subPath string) error {
+	sp, ok := persist.FindSidecarPersister(storage.Persister)
+	if !ok {
+		logging.FromContextOrDiscard(ctx).Debug("Storage does not support sidecar documents, owners are not persisted")
+		return nil
+	}
+	if doc == nil {
+		return sp.DeleteSidecar(ctx, ownersSidecarKind, name, namespace, c.persistGVK(), subPath)
+	}
+	_, err := sp.PersistSidecar(ctx, doc, ownersSidecarKind, name, namespace, c.persistGVK(), subPath)
+	return err
+}
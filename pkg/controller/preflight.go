@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// configMapsResource is the resource of ConfigMaps, which are used to store the state and other records.
+var configMapsResource = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+// permissionCheck describes a single permission which is required for a sync config.
+type permissionCheck struct {
+	// client is used to create the SelfSubjectAccessReview, its identity is the subject whose permissions are checked.
+	client client.Client
+	// subject describes the identity of the client for error messages.
+	subject     string
+	verb        string
+	resource    schema.GroupVersionResource
+	subresource string
+	namespace   string
+	// resourceName restricts the permission to the resource with the given name.
+	// It is only used for generating RBAC rules, the checked permissions are never restricted to a name.
+	resourceName string
+}
+
+// CheckPermissions verifies via SelfSubjectAccessReviews that K8Syncer has all permissions on the resources of the given sync config
+// which it requires for syncing them. If watch is false, the resources are expected to be listed instead of watched, as during a one-shot sync.
+// If an impersonation is configured, the read permissions are checked for the impersonated subject in addition.
+// Returns a description for each missing permission. The error is only set if the check itself failed.
+func CheckPermissions(ctx context.Context, restCfg *rest.Config, c client.Client, syncConfig *config.SyncConfig, watch bool) ([]string, error) {
+	log := logging.FromContextOrDiscard(ctx).WithValues(constants.Logging.KEY_ID, syncConfig.ID)
+
+	gvk := schema.GroupVersionKind{
+		Group:   syncConfig.Resource.Group,
+		Version: syncConfig.Resource.Version,
+		Kind:    syncConfig.Resource.Kind,
+	}
+	mapping, err := c.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine resource for '%s' of sync config '%s', is it known to the cluster?: %w", gvk.String(), syncConfig.ID, err)
+	}
+
+	checks := requiredPermissions(syncConfig, mapping.Resource, mapping.Scope.Name() == meta.RESTScopeNameNamespace, watch)
+	for i := range checks {
+		checks[i].client = c
+		checks[i].subject = "K8Syncer"
+	}
+	if syncConfig.Impersonate != nil {
+		impClient, err := newImpersonatedClient(restCfg, client.Options{Scheme: c.Scheme(), Mapper: c.RESTMapper()}, syncConfig.Impersonate)
+		if err != nil {
+			return nil, fmt.Errorf("error creating impersonated client for sync config '%s': %w", syncConfig.ID, err)
+		}
+		// the impersonated subject is only used for fetching the resources, the watch is established by K8Syncer itself
+		verbs := []string{"get"}
+		if !watch {
+			verbs = append(verbs, "list")
+		}
+		for _, verb := range verbs {
+			checks = append(checks, permissionCheck{client: impClient, subject: fmt.Sprintf("impersonated user '%s'", syncConfig.Impersonate.UserName()), verb: verb, resource: mapping.Resource, namespace: syncConfig.Resource.Namespace})
+		}
+	}
+
+	missing := []string{}
+	for _, check := range checks {
+		allowed, err := check.run(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error checking permissions for sync config '%s': %w", syncConfig.ID, err)
+		}
+		log.Debug("Checked permission", constants.Logging.KEY_PERMISSION, check.String(), constants.Logging.KEY_ALLOWED, allowed)
+		if !allowed {
+			missing = append(missing, fmt.Sprintf("[%s] %s is not allowed to %s", syncConfig.ID, check.subject, check.String()))
+		}
+	}
+	return missing, nil
+}
+
+// requiredPermissions returns the permissions which K8Syncer itself requires for syncing the resources of the given sync config,
+// which are of the given resource. namespaced specifies whether the resource is namespaced.
+// The permissions of an impersonated subject are not contained. The client and subject of the returned checks are not set.
+func requiredPermissions(syncConfig *config.SyncConfig, resource schema.GroupVersionResource, namespaced, watch bool) []permissionCheck {
+	namespace := syncConfig.Resource.Namespace
+	crds := schema.GroupVersionResource{Group: CRDGVK.Group, Version: CRDGVK.Version, Resource: "customresourcedefinitions"}
+
+	readVerbs := []string{"get", "list", "watch"}
+	if !watch {
+		readVerbs = []string{"get", "list"}
+	}
+	checks := []permissionCheck{}
+	for _, verb := range readVerbs {
+		checks = append(checks, permissionCheck{verb: verb, resource: resource, namespace: namespace})
+	}
+	if syncConfig.PersistCRD {
+		for _, verb := range readVerbs {
+			checks = append(checks, permissionCheck{verb: verb, resource: crds})
+		}
+	}
+	if !syncConfig.PersistCRD && syncConfig.Transformer != nil && syncConfig.Transformer.PruneDefaults && resource.Group != "" {
+		// the schema for pruning defaulted fields is read from the CRD, if the kind is defined by one
+		checks = append(checks, permissionCheck{verb: "get", resource: crds})
+	}
+	if syncConfig.PersistNamespace && namespaced {
+		checks = append(checks, permissionCheck{verb: "get", resource: schema.GroupVersionResource{Version: NamespaceGVK.Version, Resource: "namespaces"}})
+	}
+
+	// finalizers, annotations, and the status are written by K8Syncer via update calls
+	stateType := config.STATE_TYPE_NONE
+	if syncConfig.State != nil {
+		stateType = syncConfig.State.Type
+	}
+	if (syncConfig.Finalize != nil && *syncConfig.Finalize) || syncConfig.AnnotateContentHash || stateType == config.STATE_TYPE_ANNOTATION {
+		checks = append(checks, permissionCheck{verb: "update", resource: resource, namespace: namespace})
+	}
+	if stateType == config.STATE_TYPE_STATUS {
+		checks = append(checks, permissionCheck{verb: "update", resource: resource, subresource: "status", namespace: namespace})
+	}
+	if stateType == config.STATE_TYPE_CONFIGMAP {
+		for _, verb := range []string{"get", "create", "update"} {
+			checks = append(checks, permissionCheck{verb: verb, resource: configMapsResource, namespace: namespace})
+		}
+	}
+	if dfCfg := syncConfig.DeletionFailure; dfCfg != nil {
+		for _, verb := range []string{"get", "create", "update"} {
+			checks = append(checks, permissionCheck{verb: verb, resource: configMapsResource, namespace: dfCfg.ConfigMapNamespace})
+		}
+	}
+	return checks
+}
+
+// run creates a SelfSubjectAccessReview for the permission and returns whether it is granted.
+func (pc permissionCheck) run(ctx context.Context) (bool, error) {
+	ssar := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   pc.namespace,
+				Verb:        pc.verb,
+				Group:       pc.resource.Group,
+				Version:     pc.resource.Version,
+				Resource:    pc.resource.Resource,
+				Subresource: pc.subresource,
+			},
+		},
+	}
+	if err := pc.client.Create(ctx, ssar); err != nil {
+		return false, err
+	}
+	return ssar.Status.Allowed, nil
+}
+
+// String describes the checked permission, e.g. "'update' 'dummies.k8syncer.gardener.cloud/status' in namespace 'foo'".
+func (pc permissionCheck) String() string {
+	resource := pc.resource.GroupResource().String()
+	if pc.subresource != "" {
+		resource = fmt.Sprintf("%s/%s", resource, pc.subresource)
+	}
+	scope := "in all namespaces"
+	if pc.namespace != "" {
+		scope = fmt.Sprintf("in namespace '%s'", pc.namespace)
+	}
+	return fmt.Sprintf("'%s' '%s' %s", pc.verb, resource, scope)
+}
@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+var (
+	quotaObjectsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "k8syncer",
+		Name:      "storage_quota_objects",
+		Help:      "Number of resources which have been persisted via a storage reference with a quota, by sync config and storage.",
+	}, []string{"sync_config", "storage"})
+	quotaBytesGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "k8syncer",
+		Name:      "storage_quota_bytes",
+		Help:      "Total size in bytes of the resources which have been persisted via a storage reference with a quota, by sync config and storage.",
+	}, []string{"sync_config", "storage"})
+	quotaExceededGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "k8syncer",
+		Name:      "storage_quota_exceeded",
+		Help:      "1 if resources have been rejected because the quota of a storage reference is exceeded, 0 otherwise, by sync config and storage.",
+	}, []string{"sync_config", "storage"})
+	quotaRejectionCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "k8syncer",
+		Name:      "storage_quota_rejections_total",
+		Help:      "Number of times a resource has not been persisted because it would have exceeded the quota of a storage reference, by sync config and storage.",
+	}, []string{"sync_config", "storage"})
+)
+
+// QuotaMetrics returns the prometheus collectors for the quota metrics of all storage references.
+// They have to be registered at a prometheus registry in order to be exposed.
+func QuotaMetrics() []prometheus.Collector {
+	return []prometheus.Collector{quotaObjectsGauge, quotaBytesGauge, quotaExceededGauge, quotaRejectionCounter}
+}
+
+// QuotaExceededError is returned if persisting a resource would exceed the quota of a storage reference.
+type QuotaExceededError struct {
+	Storage string
+	// Quota is the name of the exceeded quota setting.
+	Quota string
+	Limit int64
+	// Requested is the value the quota setting would have after persisting the resource.
+	Requested int64
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s quota of storage reference '%s' exceeded: persisting the resource would result in %d, but the limit is %d", e.Quota, e.Storage, e.Requested, e.Limit)
+}
+
+// quotaTracker keeps track of the number and the total size of the resources which have been persisted via a storage reference.
+// It only knows the resources which have been synced since K8Syncer has been started, which are all existing resources
+// once the initial reconciliation is done.
+type quotaTracker struct {
+	syncConfig    string
+	storage       string
+	maxObjects    int
+	maxTotalBytes int64
+
+	lock sync.Mutex
+	// sizes contains the size of each persisted resource, by storage key
+	sizes map[string]int64
+	total int64
+}
+
+// newQuotaTracker returns a quotaTracker for the given storage reference, or nil if the reference doesn't configure a quota.
+func newQuotaTracker(syncConfigID string, ref *config.StorageReference) *quotaTracker {
+	if ref.MaxObjects <= 0 && ref.MaxTotalBytes <= 0 {
+		return nil
+	}
+	return &quotaTracker{
+		syncConfig:    syncConfigID,
+		storage:       ref.Name,
+		maxObjects:    ref.MaxObjects,
+		maxTotalBytes: ref.MaxTotalBytes,
+		sizes:         map[string]int64{},
+	}
+}
+
+// reserve records the resource with the given key and size, if the quota allows it.
+// The returned function reverts the reservation, it has to be called if the resource could not be persisted.
+func (qt *quotaTracker) reserve(key string, size int64) (func(), error) {
+	qt.lock.Lock()
+	defer qt.lock.Unlock()
+	oldSize, exists := qt.sizes[key]
+	if !exists && qt.maxObjects > 0 && len(qt.sizes)+1 > qt.maxObjects {
+		return nil, qt.reject("maxObjects", int64(qt.maxObjects), int64(len(qt.sizes)+1))
+	}
+	if total := qt.total - oldSize + size; qt.maxTotalBytes > 0 && size > oldSize && total > qt.maxTotalBytes {
+		return nil, qt.reject("maxTotalBytes", qt.maxTotalBytes, total)
+	}
+	qt.set(key, size)
+	if !exists {
+		quotaExceededGauge.WithLabelValues(qt.syncConfig, qt.storage).Set(0)
+	}
+	return func() {
+		qt.lock.Lock()
+		defer qt.lock.Unlock()
+		if exists {
+			qt.set(key, oldSize)
+		} else {
+			qt.remove(key)
+		}
+	}, nil
+}
+
+// release removes the resource with the given key, after it has been deleted from the storage.
+func (qt *quotaTracker) release(key string) {
+	qt.lock.Lock()
+	defer qt.lock.Unlock()
+	if _, exists := qt.sizes[key]; !exists {
+		return
+	}
+	qt.remove(key)
+	// the freed capacity might be sufficient for the rejected resources
+	quotaExceededGauge.WithLabelValues(qt.syncConfig, qt.storage).Set(0)
+}
+
+// reject records a rejected resource and returns the corresponding error.
+// It has to be called with the lock held.
+func (qt *quotaTracker) reject(quota string, limit, requested int64) error {
+	quotaRejectionCounter.WithLabelValues(qt.syncConfig, qt.storage).Inc()
+	quotaExceededGauge.WithLabelValues(qt.syncConfig, qt.storage).Set(1)
+	return &QuotaExceededError{
+		Storage:   qt.storage,
+		Quota:     quota,
+		Limit:     limit,
+		Requested: requested,
+	}
+}
+
+// set has to be called with the lock held.
+func (qt *quotaTracker) set(key string, size int64) {
+	qt.total += size - qt.sizes[key]
+	qt.sizes[key] = size
+	qt.updateMetrics()
+}
+
+// remove has to be called with the lock held.
+func (qt *quotaTracker) remove(key string) {
+	qt.total -= qt.sizes[key]
+	delete(qt.sizes, key)
+	qt.updateMetrics()
+}
+
+// updateMetrics has to be called with the lock held.
+func (qt *quotaTracker) updateMetrics() {
+	quotaObjectsGauge.WithLabelValues(qt.syncConfig, qt.storage).Set(float64(len(qt.sizes)))
+	quotaBytesGauge.WithLabelValues(qt.syncConfig, qt.storage).Set(float64(qt.total))
+}
+
+// quotaKey returns the key under which a persisted resource is tracked for the quota of a storage reference.
+func quotaKey(name, namespace, subPath string) string {
+	return path.Join(subPath, namespace, name)
+}
+
+// reserveQuota checks whether persisting the given resource via the given storage reference stays within its quota and records it if so.
+// The size of the resource is determined by transforming and serializing it, which approximates the size in the storage.
+// The returned function reverts the reservation and must be called if persisting the resource fails.
+// If the storage reference has no quota, nothing is checked and the returned function does nothing.
+func (c *Controller) reserveQuota(storage *StorageConfiguration, obj *unstructured.Unstructured, name, subPath string) (func(), error) {
+	if storage.quota == nil {
+		return func() {}, nil
+	}
+	transformed, err := storage.Transformer.Transform(obj)
+	if err != nil {
+		return nil, fmt.Errorf("error transforming resource to determine its size: %w", err)
+	}
+	data, err := yaml.Marshal(transformed.Object)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing resource to determine its size: %w", err)
+	}
+	return storage.quota.reserve(quotaKey(name, obj.GetNamespace(), subPath), int64(len(data)))
+}
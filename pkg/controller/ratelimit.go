@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"k8s.io/client-go/rest"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+// WithRateLimit returns a copy of the given rest config which uses the given client rate limit.
+// Values which are not set in the rate limit are kept from the given config.
+// If the rate limit is nil, the given config is returned unchanged.
+func WithRateLimit(restCfg *rest.Config, rlCfg *config.ClientRateLimitConfiguration) *rest.Config {
+	if rlCfg == nil {
+		return restCfg
+	}
+	res := rest.CopyConfig(restCfg)
+	// a rate limiter takes precedence over QPS and burst, and it must not be shared with the original config
+	res.RateLimiter = nil
+	if rlCfg.QPS != 0 {
+		res.QPS = rlCfg.QPS
+	}
+	if rlCfg.Burst != 0 {
+		res.Burst = rlCfg.Burst
+	}
+	return res
+}
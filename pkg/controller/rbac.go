@@ -0,0 +1,263 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// RBACRoles contains the RBAC roles which grant the permissions K8Syncer requires for a configuration.
+type RBACRoles struct {
+	// Objects contains a ClusterRole for the permissions which are not restricted to a namespace, if any,
+	// followed by a Role per namespace, ordered by namespace.
+	Objects []client.Object
+	// SkippedSyncConfigs contains the IDs of the sync configs whose resources are contained in another cluster,
+	// as their permissions have to be granted there.
+	SkippedSyncConfigs []string
+	// RuntimePermissions describes the permissions which are required for the configuration, but can't be contained in the roles,
+	// as the resources they apply to are only known at runtime.
+	RuntimePermissions []string
+}
+
+// GenerateRBACRoles returns the RBAC roles which grant K8Syncer the permissions it requires for the given configuration
+// in the cluster specified via the '--kubeconfig' flag. All roles have the given name.
+// If mapper is nil, the resources of the synced kinds are guessed from the kinds, and all kinds are assumed to be namespaced,
+// unless their sync config specifies a scope.
+// If watch is false, the roles grant the permissions which are required for a one-shot sync instead.
+// If checkpoint is not nil, they also grant the permissions for the checkpoint ConfigMap of a one-shot sync, see '--once-checkpoint'.
+func GenerateRBACRoles(cfg *config.K8SyncerConfiguration, mapper meta.RESTMapper, name string, watch bool, checkpoint *client.ObjectKey) (*RBACRoles, error) {
+	res := &RBACRoles{}
+	perms := []permissionCheck{
+		// the permissions are verified on startup, unless the check is disabled
+		{verb: "create", resource: schema.GroupVersionResource{Group: "authorization.k8s.io", Version: "v1", Resource: "selfsubjectaccessreviews"}},
+	}
+
+	for _, syncConfig := range cfg.SyncConfigs {
+		if sr := syncConfig.Shoot; sr != nil && sr.GardenKubeconfig == "" {
+			// the admin kubeconfig of the shoot is requested from the cluster specified via '--kubeconfig'
+			perms = append(perms, permissionCheck{verb: "create", resource: schema.GroupVersionResource{Group: "core.gardener.cloud", Version: "v1beta1", Resource: "shoots"}, subresource: "adminkubeconfig", namespace: sr.Namespace, resourceName: sr.Name})
+		}
+		if syncConfig.ClusterKey() != "" {
+			res.SkippedSyncConfigs = append(res.SkippedSyncConfigs, syncConfig.ID)
+			continue
+		}
+		gvk := schema.GroupVersionKind{
+			Group:   syncConfig.Resource.Group,
+			Version: syncConfig.Resource.Version,
+			Kind:    syncConfig.Resource.Kind,
+		}
+		resource, _ := meta.UnsafeGuessKindToResource(gvk)
+		namespaced := syncConfig.Resource.Scope != config.RESOURCE_SCOPE_CLUSTER
+		if mapper != nil {
+			mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+			if err != nil {
+				return nil, fmt.Errorf("unable to determine resource for '%s' of sync config '%s', is it known to the cluster?: %w", gvk.String(), syncConfig.ID, err)
+			}
+			resource = mapping.Resource
+			namespaced = mapping.Scope.Name() == meta.RESTScopeNameNamespace
+		}
+		perms = append(perms, requiredPermissions(syncConfig, resource, namespaced, watch)...)
+		perms = append(perms, replicationPermissions(cfg, syncConfig, resource, namespaced)...)
+		if ic := syncConfig.Impersonate; ic != nil {
+			perms = append(perms, impersonationPermissions(ic)...)
+		}
+		// the referenced resources are read dynamically, their kinds depend on the synced resources
+		if syncConfig.PersistIncludes {
+			res.RuntimePermissions = append(res.RuntimePermissions, fmt.Sprintf("sync config '%s': 'get' on the resources which are referenced in the '%s' annotation of the synced resources", syncConfig.ID, constants.ANNOTATION_INCLUDE))
+		}
+		if syncConfig.PersistOwners {
+			res.RuntimePermissions = append(res.RuntimePermissions, fmt.Sprintf("sync config '%s': 'get' on the kinds of the owners of the synced resources and of their owners", syncConfig.ID))
+		}
+	}
+
+	if cfg.NamespacePruning != nil {
+		for _, verb := range []string{"get", "list", "watch"} {
+			perms = append(perms, permissionCheck{verb: verb, resource: schema.GroupVersionResource{Version: NamespaceGVK.Version, Resource: "namespaces"}})
+		}
+	}
+	if sppCfg := cfg.SubPathPruning; sppCfg != nil {
+		for _, verb := range []string{"get", "create", "update"} {
+			perms = append(perms, permissionCheck{verb: verb, resource: configMapsResource, namespace: sppCfg.ConfigMapNamespace})
+		}
+	}
+	if checkpoint != nil && !watch {
+		// the checkpoint ConfigMap is created if it doesn't exist, which can't be restricted to its name
+		perms = append(perms, permissionCheck{verb: "create", resource: configMapsResource, namespace: checkpoint.Namespace})
+		for _, verb := range []string{"get", "update", "delete"} {
+			perms = append(perms, permissionCheck{verb: verb, resource: configMapsResource, namespace: checkpoint.Namespace, resourceName: checkpoint.Name})
+		}
+	}
+	if ssCfg := cfg.StartupSummary; ssCfg != nil && watch {
+		for _, verb := range []string{"get", "create", "update"} {
+			perms = append(perms, permissionCheck{verb: verb, resource: configMapsResource, namespace: ssCfg.ConfigMapNamespace})
+		}
+	}
+	if cfg.AdmissionWebhook != nil {
+		// the own identity is determined on startup, so that K8Syncer is allowed to modify the protected metadata
+		perms = append(perms, permissionCheck{verb: "create", resource: schema.GroupVersionResource{Group: "authentication.k8s.io", Version: "v1", Resource: "selfsubjectreviews"}})
+	}
+	for _, stDef := range cfg.StorageDefinitions {
+		if kc := stDef.KubernetesConfig; stDef.Type == config.STORAGE_TYPE_KUBERNETES && kc != nil && kc.Kubeconfig == "" {
+			// the objects are stored in the cluster specified via '--kubeconfig'
+			resource := configMapsResource
+			if kc.ObjectType == config.KUBERNETES_OBJECT_TYPE_SECRET {
+				resource = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+			}
+			for _, verb := range []string{"get", "create", "update", "delete"} {
+				perms = append(perms, permissionCheck{verb: verb, resource: resource, namespace: kc.Namespace})
+			}
+		}
+		if stDef.Type != config.STORAGE_TYPE_GIT || stDef.GitConfig == nil {
+			continue
+		}
+		if watch {
+			// conflicts are reported as events on the affected resources
+			for _, verb := range []string{"create", "patch"} {
+				perms = append(perms, permissionCheck{verb: verb, resource: schema.GroupVersionResource{Version: "v1", Resource: "events"}})
+			}
+		}
+		if nb := stDef.GitConfig.NamespaceBranches; nb != nil && nb.AuthSecret != nil {
+			// the secret is read from each namespace
+			perms = append(perms, permissionCheck{verb: "get", resource: schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, resourceName: nb.AuthSecret.Name})
+		}
+	}
+
+	byNamespace := map[string][]permissionCheck{}
+	for _, perm := range perms {
+		byNamespace[perm.namespace] = append(byNamespace[perm.namespace], perm)
+	}
+	namespaces := sets.List(sets.KeySet(byNamespace))
+	for _, namespace := range namespaces {
+		rules := policyRules(byNamespace[namespace])
+		if namespace == "" {
+			cr := &rbacv1.ClusterRole{Rules: rules}
+			cr.SetGroupVersionKind(rbacv1.SchemeGroupVersion.WithKind("ClusterRole"))
+			cr.SetName(name)
+			res.Objects = append(res.Objects, cr)
+			continue
+		}
+		role := &rbacv1.Role{Rules: rules}
+		role.SetGroupVersionKind(rbacv1.SchemeGroupVersion.WithKind("Role"))
+		role.SetName(name)
+		role.SetNamespace(namespace)
+		res.Objects = append(res.Objects, role)
+	}
+	return res, nil
+}
+
+// replicationPermissions returns the permissions which are required for replicating the resources of the given sync config
+// into cluster storages which replicate into the cluster specified via the '--kubeconfig' flag.
+func replicationPermissions(cfg *config.K8SyncerConfiguration, syncConfig *config.SyncConfig, resource schema.GroupVersionResource, namespaced bool) []permissionCheck {
+	res := []permissionCheck{}
+	for _, sr := range syncConfig.StorageRefs {
+		for _, stDef := range cfg.StorageDefinitions {
+			if stDef.Name != sr.Name || stDef.Type != config.STORAGE_TYPE_CLUSTER || stDef.ClusterConfig == nil || stDef.ClusterConfig.Kubeconfig != "" {
+				continue
+			}
+			namespace := ""
+			if namespaced && syncConfig.Resource.Namespace != "" {
+				namespace = syncConfig.Resource.Namespace
+				if target, ok := stDef.ClusterConfig.NamespaceMapping[namespace]; ok {
+					namespace = target
+				}
+			}
+			// applying a resource which doesn't exist yet requires the permission to create it
+			for _, verb := range []string{"get", "create", "patch", "delete"} {
+				res = append(res, permissionCheck{verb: verb, resource: resource, namespace: namespace})
+			}
+		}
+	}
+	return res
+}
+
+// impersonationPermissions returns the permissions which are required for impersonating the configured subject.
+func impersonationPermissions(ic *config.ImpersonationConfiguration) []permissionCheck {
+	res := []permissionCheck{}
+	if ic.ServiceAccount != "" {
+		namespace, name, _ := strings.Cut(ic.ServiceAccount, "/")
+		res = append(res, permissionCheck{verb: "impersonate", resource: schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}, namespace: namespace, resourceName: name})
+	} else {
+		res = append(res, permissionCheck{verb: "impersonate", resource: schema.GroupVersionResource{Version: "v1", Resource: "users"}, resourceName: ic.User})
+	}
+	for _, group := range ic.Groups {
+		res = append(res, permissionCheck{verb: "impersonate", resource: schema.GroupVersionResource{Version: "v1", Resource: "groups"}, resourceName: group})
+	}
+	return res
+}
+
+// policyRules combines the given permissions into RBAC rules.
+// Permissions on the same resource are combined into one rule, as long as they have the same verbs or are not restricted to names.
+// The rules are sorted by group and resource.
+func policyRules(perms []permissionCheck) []rbacv1.PolicyRule {
+	type ruleKey struct {
+		group    string
+		resource string
+	}
+	// verbs of the permissions which are not restricted to names
+	verbs := map[ruleKey]sets.Set[string]{}
+	// verbs of the permissions which are restricted to names, by name
+	namedVerbs := map[ruleKey]map[string]sets.Set[string]{}
+	for _, perm := range perms {
+		key := ruleKey{group: perm.resource.Group, resource: perm.resource.Resource}
+		if perm.subresource != "" {
+			key.resource = fmt.Sprintf("%s/%s", key.resource, perm.subresource)
+		}
+		if perm.resourceName == "" {
+			if _, ok := verbs[key]; !ok {
+				verbs[key] = sets.New[string]()
+			}
+			verbs[key].Insert(perm.verb)
+			continue
+		}
+		if _, ok := namedVerbs[key]; !ok {
+			namedVerbs[key] = map[string]sets.Set[string]{}
+		}
+		if _, ok := namedVerbs[key][perm.resourceName]; !ok {
+			namedVerbs[key][perm.resourceName] = sets.New[string]()
+		}
+		namedVerbs[key][perm.resourceName].Insert(perm.verb)
+	}
+
+	rules := []rbacv1.PolicyRule{}
+	for key, vs := range verbs {
+		rules = append(rules, rbacv1.PolicyRule{APIGroups: []string{key.group}, Resources: []string{key.resource}, Verbs: sets.List(vs)})
+	}
+	for key, byName := range namedVerbs {
+		// names with the same verbs share a rule, names whose verbs are all granted without restriction don't need one
+		namesByVerbs := map[string][]string{}
+		for name, vs := range byName {
+			if unrestricted, ok := verbs[key]; ok && unrestricted.IsSuperset(vs) {
+				continue
+			}
+			vsKey := strings.Join(sets.List(vs), ",")
+			namesByVerbs[vsKey] = append(namesByVerbs[vsKey], name)
+		}
+		for vsKey, names := range namesByVerbs {
+			sort.Strings(names)
+			rules = append(rules, rbacv1.PolicyRule{APIGroups: []string{key.group}, Resources: []string{key.resource}, ResourceNames: names, Verbs: strings.Split(vsKey, ",")})
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].APIGroups[0] != rules[j].APIGroups[0] {
+			return rules[i].APIGroups[0] < rules[j].APIGroups[0]
+		}
+		if rules[i].Resources[0] != rules[j].Resources[0] {
+			return rules[i].Resources[0] < rules[j].Resources[0]
+		}
+		return strings.Join(rules[i].ResourceNames, ",") < strings.Join(rules[j].ResourceNames, ",")
+	})
+	return rules
+}
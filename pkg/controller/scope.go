@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+// VerifyResourceScope returns an error if the scope which is configured for the resource of the given sync config
+// doesn't match the scope of the resource in the cluster. Sync configs without a configured scope are not verified.
+func VerifyResourceScope(mapper meta.RESTMapper, syncConfig *config.SyncConfig) error {
+	scope := syncConfig.Resource.Scope
+	if scope == "" {
+		return nil
+	}
+	gvk := schema.GroupVersionKind{
+		Group:   syncConfig.Resource.Group,
+		Version: syncConfig.Resource.Version,
+		Kind:    syncConfig.Resource.Kind,
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return fmt.Errorf("unable to determine resource for '%s' of sync config '%s', is it known to the cluster?: %w", gvk.String(), syncConfig.ID, err)
+	}
+	actual := config.RESOURCE_SCOPE_NAMESPACED
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		actual = config.RESOURCE_SCOPE_CLUSTER
+	}
+	if actual != scope {
+		return fmt.Errorf("sync config '%s' specifies scope '%s' for '%s', but the cluster reports scope '%s'", syncConfig.ID, string(scope), gvk.String(), string(actual))
+	}
+	return nil
+}
+
+// scopePredicate returns a predicate which only accepts objects matching the given scope:
+// objects without a namespace for cluster-scoped resources and objects with a namespace for namespaced ones.
+// If scope is empty, all objects are accepted.
+func scopePredicate(scope config.ResourceScope) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		switch scope {
+		case config.RESOURCE_SCOPE_CLUSTER:
+			return obj.GetNamespace() == ""
+		case config.RESOURCE_SCOPE_NAMESPACED:
+			return obj.GetNamespace() != ""
+		}
+		return true
+	})
+}
@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+// CacheByObject returns the per-kind cache options for the sync configs which watch the cluster with the given key, see config.SyncConfig.ClusterKey.
+// The resources of sync configs with a field selector are only listed and watched if they match it, so the API server filters them.
+// The empty key stands for the default cluster. The returned map is nil if none of the sync configs has a field selector.
+func CacheByObject(syncConfigs []*config.SyncConfig, clusterKey string) (map[client.Object]cache.ByObject, error) {
+	var res map[client.Object]cache.ByObject
+	for _, syncConfig := range syncConfigs {
+		if syncConfig.ClusterKey() != clusterKey || syncConfig.Resource == nil || syncConfig.Resource.FieldSelector == "" {
+			continue
+		}
+		sel, err := fields.ParseSelector(syncConfig.Resource.FieldSelector)
+		if err != nil {
+			// should not happen, as this check is already part of the config validation
+			return nil, fmt.Errorf("invalid field selector in sync configuration with id %s: %w", syncConfig.ID, err)
+		}
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(schema.GroupVersionKind{
+			Group:   syncConfig.Resource.Group,
+			Version: syncConfig.Resource.Version,
+			Kind:    syncConfig.Resource.Kind,
+		})
+		if res == nil {
+			res = map[client.Object]cache.ByObject{}
+		}
+		// the cache options are looked up by the kind of the object, sync configs for the same kind have the same selector
+		res[u] = cache.ByObject{Field: sel}
+	}
+	return res, nil
+}
+
+// matchesFieldSelector returns whether the given object matches the field selector of the sync config.
+// Fields which don't exist in the object are treated as empty strings.
+func (c *Controller) matchesFieldSelector(obj *unstructured.Unstructured) bool {
+	if c.FieldSelector == nil || c.FieldSelector.Empty() {
+		return true
+	}
+	set := fields.Set{}
+	for _, req := range c.FieldSelector.Requirements() {
+		val, found, err := unstructured.NestedFieldNoCopy(obj.Object, strings.Split(req.Field, ".")...)
+		if err != nil || !found || val == nil {
+			set[req.Field] = ""
+			continue
+		}
+		set[req.Field] = fmt.Sprint(val)
+	}
+	return c.FieldSelector.Matches(set)
+}
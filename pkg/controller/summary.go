@@ -0,0 +1,163 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils"
+)
+
+// SyncSummary is a machine-readable summary of a sync config, as it is in effect after defaulting.
+type SyncSummary struct {
+	// SyncConfigID is the ID of the sync config.
+	SyncConfigID string `json:"syncConfigID"`
+	// GVK is the GroupVersionKind of the synced resource, in the format '<kind>.<version>.<group>' (lowercase kind).
+	GVK string `json:"gvk"`
+	// PersistVersion is the version under which the resources are persisted.
+	PersistVersion string `json:"persistVersion"`
+	// Cluster identifies the cluster which contains the resources, empty for the cluster specified via the '--kubeconfig' flag.
+	// See config.SyncConfig.ClusterKey for the format.
+	Cluster string `json:"cluster,omitempty"`
+	// Namespace is the namespace in which the resources are watched, empty for all namespaces and cluster-scoped resources.
+	Namespace string `json:"namespace,omitempty"`
+	// Scope is the configured scope of the resource, if any.
+	Scope string `json:"scope,omitempty"`
+	// FieldSelector is the field selector which restricts the synced resources, if any.
+	FieldSelector string `json:"fieldSelector,omitempty"`
+	// Storages describes the storage references of the sync config.
+	Storages []StorageSummary `json:"storages"`
+	// StateDisplay describes how the sync state is displayed, it is nil if the state is not displayed.
+	StateDisplay *StateDisplaySummary `json:"stateDisplay,omitempty"`
+	// Predicates describes the predicates which filter the events of the resources, in the format '<name>' or '<name>=<value>'.
+	Predicates []string `json:"predicates"`
+	// Finalize is true if finalizers are added to the synced resources.
+	Finalize bool `json:"finalize"`
+	// ReadOnlySource is true if nothing is written to the synced resources or their cluster.
+	ReadOnlySource bool `json:"readOnlySource,omitempty"`
+	// Suspended is true if the sync config is suspended via config.
+	Suspended bool `json:"suspended,omitempty"`
+	// ImpersonatedUser is the name of the user which is impersonated for reading the resources, if any.
+	ImpersonatedUser string `json:"impersonatedUser,omitempty"`
+}
+
+// StorageSummary is a machine-readable summary of a storage reference.
+type StorageSummary struct {
+	// Name is the name of the referenced storage.
+	Name string `json:"name"`
+	// Type is the type of the referenced storage.
+	Type string `json:"type"`
+	// SubPath is the unresolved subPath of the storage reference.
+	SubPath string `json:"subPath,omitempty"`
+	// FileNaming is the file naming of the storage reference.
+	FileNaming string `json:"fileNaming,omitempty"`
+}
+
+// StateDisplaySummary is a machine-readable summary of a state display.
+type StateDisplaySummary struct {
+	// Type is the type of the state display.
+	Type string `json:"type"`
+	// Verbosity is the verbosity of the state display.
+	Verbosity string `json:"verbosity"`
+}
+
+// SummarizeSyncConfig returns the summary of the given sync config, which has to be part of the given configuration.
+// The configuration is expected to be completed and validated.
+func SummarizeSyncConfig(cfg *config.K8SyncerConfiguration, syncConfig *config.SyncConfig) SyncSummary {
+	gvk := schema.GroupVersionKind{
+		Group:   syncConfig.Resource.Group,
+		Version: syncConfig.Resource.Version,
+		Kind:    syncConfig.Resource.Kind,
+	}
+	_, predicates := eventFilter(syncConfig)
+	res := SyncSummary{
+		SyncConfigID:   syncConfig.ID,
+		GVK:            utils.GVKToString(gvk, true),
+		PersistVersion: syncConfig.Resource.Version,
+		Cluster:        syncConfig.ClusterKey(),
+		Namespace:      syncConfig.Resource.Namespace,
+		Scope:          string(syncConfig.Resource.Scope),
+		FieldSelector:  syncConfig.Resource.FieldSelector,
+		Storages:       make([]StorageSummary, 0, len(syncConfig.StorageRefs)),
+		Predicates:     predicates,
+		Finalize:       syncConfig.Finalize != nil && *syncConfig.Finalize,
+		ReadOnlySource: syncConfig.ReadOnlySource,
+		Suspended:      syncConfig.Suspend,
+	}
+	if syncConfig.Resource.PersistVersion != "" {
+		res.PersistVersion = syncConfig.Resource.PersistVersion
+	}
+	for _, sr := range syncConfig.StorageRefs {
+		ss := StorageSummary{
+			Name:       sr.Name,
+			SubPath:    sr.SubPath,
+			FileNaming: string(sr.FileNaming),
+		}
+		for _, stDef := range cfg.StorageDefinitions {
+			if stDef.Name == sr.Name {
+				ss.Type = string(stDef.Type)
+				break
+			}
+		}
+		res.Storages = append(res.Storages, ss)
+	}
+	if st := syncConfig.State; st != nil && st.Type != config.STATE_TYPE_NONE {
+		res.StateDisplay = &StateDisplaySummary{
+			Type:      string(st.Type),
+			Verbosity: string(st.Verbosity),
+		}
+	}
+	if syncConfig.Impersonate != nil {
+		res.ImpersonatedUser = syncConfig.Impersonate.UserName()
+	}
+	return res
+}
+
+// PublishStartupSummary writes the summaries of all sync configs into the ConfigMap specified by the startup summary configuration,
+// creating the ConfigMap if it doesn't exist. The ConfigMap contains one key per sync config, with the summary as JSON as value.
+// Keys of sync configs which are not configured anymore are removed. Does nothing if the startup summary is not configured.
+func PublishStartupSummary(ctx context.Context, c client.Client, cfg *config.K8SyncerConfiguration) error {
+	ssCfg := cfg.StartupSummary
+	if ssCfg == nil {
+		return nil
+	}
+	data := map[string]string{}
+	for _, syncConfig := range cfg.SyncConfigs {
+		raw, err := json.Marshal(SummarizeSyncConfig(cfg, syncConfig))
+		if err != nil {
+			return fmt.Errorf("error marshalling summary of sync config '%s': %w", syncConfig.ID, err)
+		}
+		data[syncConfig.ID] = string(raw)
+	}
+	key := types.NamespacedName{Namespace: ssCfg.ConfigMapNamespace, Name: ssCfg.ConfigMapName}
+	return retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}, func() error {
+		cm := &corev1.ConfigMap{}
+		if err := c.Get(ctx, key, cm); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return fmt.Errorf("error fetching configmap '%s': %w", key.String(), err)
+			}
+			cm = &corev1.ConfigMap{}
+			cm.SetName(key.Name)
+			cm.SetNamespace(key.Namespace)
+		}
+		cm.Data = data
+		if cm.ResourceVersion == "" {
+			return c.Create(ctx, cm)
+		}
+		return c.Update(ctx, cm)
+	})
+}
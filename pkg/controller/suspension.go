@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// checkSuspension returns true if the sync config is currently suspended, together with the result of the skipped reconcile.
+// During a maintenance window, the reconcile is requeued for the end of the window, so that changes which happen during
+// the window are synced afterwards. If the sync config is suspended via config, the resources are synced when K8Syncer
+// is restarted without the suspension.
+func (c *Controller) checkSuspension(ctx context.Context) (reconcile.Result, bool) {
+	log := logging.FromContextOrDiscard(ctx)
+	until, suspended := c.SyncConfig.SuspendedUntil(time.Now())
+	if !suspended {
+		return reconcile.Result{}, false
+	}
+	if until.IsZero() {
+		log.Info("Sync config is suspended, skipping reconcile")
+		return reconcile.Result{}, true
+	}
+	log.Info("Sync config is suspended by a maintenance window, postponing reconcile", constants.Logging.KEY_RESUME_AT, until.Format(time.RFC3339))
+	return reconcile.Result{RequeueAfter: time.Until(until)}, true
+}
+
+// waitForResumption blocks while the sync config is suspended by a maintenance window.
+// It returns false if the sync config is suspended until further notice or the context has been cancelled.
+func (c *Controller) waitForResumption(ctx context.Context) bool {
+	log := logging.FromContextOrDiscard(ctx)
+	for {
+		until, suspended := c.SyncConfig.SuspendedUntil(time.Now())
+		if !suspended {
+			return true
+		}
+		if until.IsZero() {
+			return false
+		}
+		log.Info("Sync config is suspended by a maintenance window, waiting", constants.Logging.KEY_RESUME_AT, until.Format(time.RFC3339))
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(time.Until(until)):
+		}
+	}
+}
+
+// isSuspended returns true if syncing the given resource has been suspended via the suspend annotation.
+func isSuspended(obj client.Object) bool {
+	return obj.GetAnnotations()[constants.ANNOTATION_SUSPEND] == "true"
+}
+
+// SuspendAnnotationChangedPredicate reacts to changes of the suspend annotation, independently of the configured triggers.
+// This way, resources are synced as soon as they are resumed.
+type SuspendAnnotationChangedPredicate struct {
+	predicate.Funcs
+}
+
+func (SuspendAnnotationChangedPredicate) Update(e event.UpdateEvent) bool {
+	if e.ObjectOld == nil {
+		return false
+	}
+	if e.ObjectNew == nil {
+		return false
+	}
+	return isSuspended(e.ObjectOld) != isSuspended(e.ObjectNew)
+}
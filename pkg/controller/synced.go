@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/utils"
+)
+
+// SyncedObjectsEndpointPath is the path under which the synced objects are served, if registered at the metrics server.
+const SyncedObjectsEndpointPath = "/debug/synced-objects"
+
+// SyncedObjectEntry describes an object which has been synced successfully.
+type SyncedObjectEntry struct {
+	// SyncConfigID is the ID of the sync config which synced the object.
+	SyncConfigID string `json:"syncConfigID"`
+	// GVK is the GroupVersionKind of the object, in the format '<kind>.<version>.<group>' (lowercase kind).
+	GVK string `json:"gvk"`
+	// Namespace is the namespace of the object, empty for cluster-scoped objects.
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the object.
+	Name string `json:"name"`
+	// UID is the UID of the object.
+	UID string `json:"uid,omitempty"`
+	// LastSyncedGeneration is the version of the object which has been synced last, depending on the change detection of the sync config.
+	// It is the same value which is written as last synced generation into the state.
+	LastSyncedGeneration int64 `json:"lastSyncedGeneration"`
+	// LastSynced is the time of the last successful sync.
+	LastSynced time.Time `json:"lastSynced"`
+	// Storages describes the persisted data of the object per storage reference.
+	Storages []SyncedStorageEntry `json:"storages"`
+}
+
+// SyncedStorageEntry describes the persisted data of a synced object in one storage.
+type SyncedStorageEntry struct {
+	// Storage is the name of the storage.
+	Storage string `json:"storage"`
+	// SubPath is the resolved subPath under which the object is persisted.
+	SubPath string `json:"subPath,omitempty"`
+	// Name is the name under which the object is persisted, depending on the file naming.
+	Name string `json:"name"`
+	// Digest is the SHA-256 digest of the JSON representation of the transformed object which has been persisted, in the format 'sha256:<hex>'.
+	Digest string `json:"digest,omitempty"`
+}
+
+// SyncedObjects keeps track of the objects which the controllers believe to be synced.
+// An object is added when it has been synced successfully and removed when its data has been deleted from the storages.
+// As the entries are only kept in memory, objects are listed only after they have been reconciled since the start of K8Syncer.
+// All methods are safe for concurrent use and can be called on a nil SyncedObjects, which does nothing.
+// Use NewSyncedObjects to instantiate it.
+type SyncedObjects struct {
+	lock    sync.RWMutex
+	entries map[syncedObjectKey]*SyncedObjectEntry
+}
+
+type syncedObjectKey struct {
+	syncConfigID string
+	gvk          string
+	namespace    string
+	name         string
+}
+
+// NewSyncedObjects creates a new, empty SyncedObjects.
+func NewSyncedObjects() *SyncedObjects {
+	return &SyncedObjects{
+		entries: map[syncedObjectKey]*SyncedObjectEntry{},
+	}
+}
+
+// Record stores the given entry, replacing any previous entry for the same object.
+func (so *SyncedObjects) Record(entry SyncedObjectEntry) {
+	if so == nil {
+		return
+	}
+	key := syncedObjectKey{syncConfigID: entry.SyncConfigID, gvk: entry.GVK, namespace: entry.Namespace, name: entry.Name}
+	so.lock.Lock()
+	defer so.lock.Unlock()
+	so.entries[key] = &entry
+}
+
+// Forget removes the entry for the specified object, if any.
+func (so *SyncedObjects) Forget(syncConfigID string, gvk schema.GroupVersionKind, namespace, name string) {
+	if so == nil {
+		return
+	}
+	key := syncedObjectKey{syncConfigID: syncConfigID, gvk: utils.GVKToString(gvk, true), namespace: namespace, name: name}
+	so.lock.Lock()
+	defer so.lock.Unlock()
+	delete(so.entries, key)
+}
+
+// List returns copies of the entries, sorted by sync config ID, GVK, namespace, and name.
+// If syncConfigID is not empty, only the entries of the sync config with this ID are returned.
+func (so *SyncedObjects) List(syncConfigID string) []SyncedObjectEntry {
+	if so == nil {
+		return nil
+	}
+	so.lock.RLock()
+	res := make([]SyncedObjectEntry, 0, len(so.entries))
+	for _, e := range so.entries {
+		if syncConfigID == "" || e.SyncConfigID == syncConfigID {
+			res = append(res, *e)
+		}
+	}
+	so.lock.RUnlock()
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.SyncConfigID != b.SyncConfigID {
+			return a.SyncConfigID < b.SyncConfigID
+		}
+		if a.GVK != b.GVK {
+			return a.GVK < b.GVK
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return res
+}
+
+// ServeHTTP returns the list of entries as JSON.
+// The optional query parameters 'syncConfig' and 'namespace' restrict the list to the entries of the sync config with the given ID
+// and to the objects in the given namespace, respectively.
+func (so *SyncedObjects) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	entries := so.List(q.Get("syncConfig"))
+	if q.Has("namespace") {
+		filtered := make([]SyncedObjectEntry, 0, len(entries))
+		for _, e := range entries {
+			if e.Namespace == q.Get("namespace") {
+				filtered = append(filtered, e)
+			}
+		}
+		entries = filtered
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// syncedStorageEntry returns the entry describing the given persisted data.
+// The digest is omitted if it cannot be computed.
+func syncedStorageEntry(storage *StorageConfiguration, persisted *unstructured.Unstructured, name, subPath string) SyncedStorageEntry {
+	res := SyncedStorageEntry{
+		Storage: storage.Name(),
+		SubPath: subPath,
+		Name:    name,
+	}
+	if persisted != nil {
+		if data, err := json.Marshal(persisted.Object); err == nil {
+			digest := sha256.Sum256(data)
+			res.Digest = fmt.Sprintf("sha256:%s", hex.EncodeToString(digest[:]))
+		}
+	}
+	return res
+}
@@ -0,0 +1,163 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+const (
+	// TriggerEndpointPath is the path under which the sync trigger is served, if registered at the metrics server.
+	TriggerEndpointPath = "/debug/sync"
+
+	// triggerQueueSize is the number of triggered syncs per sync config which can be pending before further requests are rejected.
+	triggerQueueSize = 100
+)
+
+// SyncTrigger enqueues the reconciliation of specific resources on demand, e.g. after a storage has been modified manually.
+// It is served as an HTTP endpoint, see ServeHTTP.
+// Use NewSyncTrigger to instantiate it.
+type SyncTrigger struct {
+	lock    sync.RWMutex
+	targets []*triggerTarget
+	token   []byte
+}
+
+// triggerTarget is the controller of a sync config, which reconciles the resources given to its channel.
+type triggerTarget struct {
+	syncConfigID     string
+	gvk              schema.GroupVersionKind
+	watchedNamespace string
+	events           chan event.GenericEvent
+}
+
+// TriggerResponse is returned by the sync trigger endpoint.
+type TriggerResponse struct {
+	// SyncConfigIDs are the IDs of the sync configs which reconcile the resource.
+	SyncConfigIDs []string `json:"syncConfigIDs"`
+}
+
+// NewSyncTrigger creates a new SyncTrigger, which authenticates requests with the configured token.
+func NewSyncTrigger(cfg *config.SyncTriggerConfiguration) (*SyncTrigger, error) {
+	token := []byte(cfg.Token)
+	if cfg.TokenFile != "" {
+		data, err := os.ReadFile(cfg.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading sync trigger token file: %w", err)
+		}
+		token = bytes.TrimSpace(data)
+	}
+	if len(token) == 0 {
+		return nil, fmt.Errorf("sync trigger token must not be empty")
+	}
+	return &SyncTrigger{
+		token: token,
+	}, nil
+}
+
+// source registers the controller of the given sync config and returns the source from which it receives the triggered resources.
+func (st *SyncTrigger) source(syncConfig *config.SyncConfig, gvk schema.GroupVersionKind) source.Source {
+	t := &triggerTarget{
+		syncConfigID:     syncConfig.ID,
+		gvk:              gvk,
+		watchedNamespace: syncConfig.Resource.Namespace,
+		events:           make(chan event.GenericEvent, triggerQueueSize),
+	}
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	st.targets = append(st.targets, t)
+	return &source.Channel{Source: t.events}
+}
+
+// Trigger enqueues the reconciliation of the specified resource for all sync configs which watch it.
+// If syncConfigID is not empty, only the sync config with this ID is considered.
+// It returns the IDs of the sync configs for which the reconciliation has been enqueued.
+// An error is returned if the queue of one of them is full, the resource is enqueued for the others anyway.
+func (st *SyncTrigger) Trigger(gvk schema.GroupVersionKind, namespace, name, syncConfigID string) ([]string, error) {
+	st.lock.RLock()
+	defer st.lock.RUnlock()
+	triggered := []string{}
+	full := []string{}
+	for _, t := range st.targets {
+		if t.gvk != gvk || (syncConfigID != "" && t.syncConfigID != syncConfigID) {
+			continue
+		}
+		if t.watchedNamespace != "" && t.watchedNamespace != namespace {
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		select {
+		case t.events <- event.GenericEvent{Object: obj}:
+			triggered = append(triggered, t.syncConfigID)
+		default:
+			full = append(full, t.syncConfigID)
+		}
+	}
+	if len(full) > 0 {
+		return triggered, fmt.Errorf("too many pending triggers for sync configs [%s]", strings.Join(full, ", "))
+	}
+	return triggered, nil
+}
+
+// ServeHTTP triggers the sync of the resource specified via the query parameters
+// 'group' (empty for the core group), 'version', 'kind', 'namespace' (empty for cluster-scoped resources), and 'name'.
+// The optional parameter 'syncConfig' restricts the sync to the sync config with the given ID.
+// Only POST requests with the configured token as bearer token are accepted.
+func (st *SyncTrigger) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST requests are supported", http.StatusMethodNotAllowed)
+		return
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), st.token) != 1 {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+	q := r.URL.Query()
+	gvk := schema.GroupVersionKind{
+		Group:   q.Get("group"),
+		Version: q.Get("version"),
+		Kind:    q.Get("kind"),
+	}
+	name := q.Get("name")
+	if gvk.Version == "" || gvk.Kind == "" || name == "" {
+		http.Error(w, "query parameters 'version', 'kind', and 'name' are required", http.StatusBadRequest)
+		return
+	}
+	triggered, err := st.Trigger(gvk, q.Get("namespace"), name, q.Get("syncConfig"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if len(triggered) == 0 {
+		http.Error(w, "no sync config watches the specified resource", http.StatusNotFound)
+		return
+	}
+	data, err := json.Marshal(&TriggerResponse{SyncConfigIDs: triggered})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write(data)
+}
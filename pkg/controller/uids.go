@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// uidTracker remembers the last known UID of each reconciled resource.
+// After a resource has been deleted, its UID cannot be fetched from the cluster anymore, but is required to verify
+// that the persisted data belongs to the deleted resource and not to a newly created one with the same name.
+// The UIDs are only kept in memory, so they are unknown for resources which have been deleted while K8Syncer was not running.
+// Use newUIDTracker to instantiate it.
+type uidTracker struct {
+	lock sync.Mutex
+	uids map[types.NamespacedName]types.UID
+}
+
+func newUIDTracker() *uidTracker {
+	return &uidTracker{
+		uids: map[types.NamespacedName]types.UID{},
+	}
+}
+
+// observe records the given UID for the specified resource.
+// Empty UIDs are ignored.
+func (t *uidTracker) observe(key types.NamespacedName, uid types.UID) {
+	if uid == "" {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.uids[key] = uid
+}
+
+// get returns the last known UID of the specified resource.
+// It returns an empty UID if none has been recorded.
+func (t *uidTracker) get(key types.NamespacedName) types.UID {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.uids[key]
+}
+
+// forget removes the recorded UID of the specified resource.
+func (t *uidTracker) forget(key types.NamespacedName) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.uids, key)
+}
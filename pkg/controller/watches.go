@@ -0,0 +1,223 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+var (
+	watchRestartCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "k8syncer",
+		Name:      "watch_restarts_total",
+		Help:      "Number of watches on the watched resources which have been re-established before the API server would have ended them regularly, by sync config.",
+	}, []string{"sync_config"})
+	informerResyncCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "k8syncer",
+		Name:      "informer_resyncs_total",
+		Help:      "Number of times the informer of the watched resources had to list them again after the initial list, by sync config.",
+	}, []string{"sync_config"})
+	staleEventCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "k8syncer",
+		Name:      "stale_events_dropped_total",
+		Help:      "Number of update events which have been dropped because they contained an older version of a resource than the one known before, by sync config.",
+	}, []string{"sync_config"})
+)
+
+// WatchMetrics returns the prometheus collectors for the watch metrics of all sync configs.
+// They have to be registered at a prometheus registry in order to be exposed.
+func WatchMetrics() []prometheus.Collector {
+	return []prometheus.Collector{watchRestartCounter, informerResyncCounter, staleEventCounter}
+}
+
+// watches keeps track of the list and watch requests of all caches which have been created by WatchTrackingCache.
+var watches = &watchTracker{
+	syncConfigs:    map[watchedResource]sets.Set[string]{},
+	watchDeadlines: map[watchKey]time.Time{},
+	listed:         sets.New[watchKey](),
+}
+
+// WatchTrackingCache returns a function which creates caches for the cluster with the given key, see config.SyncConfig.ClusterKey.
+// The requests of the caches' informers are observed to count watch restarts and informer resyncs of the sync configs which use the cluster.
+// The empty key stands for the default cluster.
+func WatchTrackingCache(clusterKey string) cache.NewCacheFunc {
+	return func(restCfg *rest.Config, opts cache.Options) (cache.Cache, error) {
+		restCfg = rest.CopyConfig(restCfg)
+		restCfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return &watchTrackingRoundTripper{
+				delegate: rt,
+				cluster:  clusterKey,
+			}
+		})
+		return cache.New(restCfg, opts)
+	}
+}
+
+// registerWatchedResource attributes list and watch requests for the resource of the given kind in the given cluster to the given sync config.
+func registerWatchedResource(mapper meta.RESTMapper, clusterKey string, gvk schema.GroupVersionKind, syncConfigID string) error {
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return err
+	}
+	watches.register(watchedResource{cluster: clusterKey, gvr: mapping.Resource}, syncConfigID)
+	return nil
+}
+
+// watchedResource identifies a resource in one of the clusters.
+type watchedResource struct {
+	cluster string
+	gvr     schema.GroupVersionResource
+}
+
+// watchKey identifies the list and watch requests of one informer.
+// Caches which are restricted to namespaces have one informer per namespace.
+type watchKey struct {
+	watchedResource
+	namespace string
+}
+
+// watchTracker counts watch restarts and informer resyncs for the sync configs which watch the affected resources.
+type watchTracker struct {
+	lock sync.Mutex
+	// syncConfigs maps resources to the IDs of the sync configs which watch them.
+	syncConfigs map[watchedResource]sets.Set[string]
+	// watchDeadlines contains the time at which the API server ends the latest watch of an informer.
+	watchDeadlines map[watchKey]time.Time
+	// listed contains the informers which have listed their resources at least once.
+	listed sets.Set[watchKey]
+}
+
+func (wt *watchTracker) register(res watchedResource, syncConfigID string) {
+	wt.lock.Lock()
+	defer wt.lock.Unlock()
+	if _, ok := wt.syncConfigs[res]; !ok {
+		wt.syncConfigs[res] = sets.New[string]()
+	}
+	wt.syncConfigs[res].Insert(syncConfigID)
+}
+
+// observe records a GET request to the given URL at the given time, which has been sent by an informer of a cache for the given cluster.
+// Requests which are neither lists nor watches of a resource collection are ignored.
+func (wt *watchTracker) observe(cluster string, u *url.URL, now time.Time) {
+	gvr, namespace, ok := parseCollectionPath(u.Path)
+	if !ok {
+		return
+	}
+	key := watchKey{watchedResource: watchedResource{cluster: cluster, gvr: gvr}, namespace: namespace}
+	query := u.Query()
+
+	wt.lock.Lock()
+	defer wt.lock.Unlock()
+	var counter *prometheus.CounterVec
+	if watch := query.Get("watch"); watch == "true" || watch == "1" {
+		// the informers restart their watches after the timeout which they requested regularly
+		// if a watch is started before the previous one timed out, the previous one has been ended unexpectedly
+		if deadline, ok := wt.watchDeadlines[key]; ok && now.Before(deadline) {
+			counter = watchRestartCounter
+		}
+		deadline := now.Add(100 * 365 * 24 * time.Hour)
+		if timeout, err := strconv.Atoi(query.Get("timeoutSeconds")); err == nil && timeout > 0 {
+			deadline = now.Add(time.Duration(timeout) * time.Second)
+		}
+		wt.watchDeadlines[key] = deadline
+	} else {
+		// a list after the initial one means that the watch could not be resumed and events in between might have been missed
+		if wt.listed.Has(key) {
+			counter = informerResyncCounter
+		}
+		wt.listed.Insert(key)
+	}
+	if counter == nil {
+		return
+	}
+	for id := range wt.syncConfigs[key.watchedResource] {
+		counter.WithLabelValues(id).Inc()
+	}
+}
+
+// parseCollectionPath returns the resource and namespace of a request path which refers to a collection of resources,
+// e.g. '/apis/apps/v1/namespaces/default/deployments'.
+// The namespace is empty for requests across all namespaces or for cluster-scoped resources.
+// The last return value is false if the path doesn't refer to a resource collection.
+func parseCollectionPath(path string) (schema.GroupVersionResource, string, bool) {
+	gvr := schema.GroupVersionResource{}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(segments) >= 2 && segments[0] == "api":
+		gvr.Version = segments[1]
+		segments = segments[2:]
+	case len(segments) >= 3 && segments[0] == "apis":
+		gvr.Group = segments[1]
+		gvr.Version = segments[2]
+		segments = segments[3:]
+	default:
+		return gvr, "", false
+	}
+	namespace := ""
+	if len(segments) == 3 && segments[0] == "namespaces" {
+		namespace = segments[1]
+		segments = segments[2:]
+	}
+	if len(segments) != 1 || segments[0] == "" {
+		return gvr, "", false
+	}
+	gvr.Resource = segments[0]
+	return gvr, namespace, true
+}
+
+// watchTrackingRoundTripper reports the requests of a cache's informers to the watch tracker.
+type watchTrackingRoundTripper struct {
+	delegate http.RoundTripper
+	cluster  string
+}
+
+func (rt *watchTrackingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodGet {
+		watches.observe(rt.cluster, req.URL, time.Now())
+	}
+	return rt.delegate.RoundTrip(req)
+}
+
+// StaleEventPredicate drops update events which contain an older resourceVersion of the resource than the previous one.
+// This can happen if an informer lists the resources again from an API server whose cache is behind the one of the last watch.
+// Syncing such an event would overwrite the newer version in the storages, so it is dropped and counted in the watch metrics of the sync config.
+// ResourceVersions which are not integers cannot be compared and are never dropped.
+type StaleEventPredicate struct {
+	predicate.Funcs
+	SyncConfigID string
+}
+
+func (p StaleEventPredicate) Update(e event.UpdateEvent) bool {
+	if e.ObjectOld == nil || e.ObjectNew == nil {
+		return true
+	}
+	oldRV, err := strconv.ParseInt(e.ObjectOld.GetResourceVersion(), 10, 64)
+	if err != nil {
+		return true
+	}
+	newRV, err := strconv.ParseInt(e.ObjectNew.GetResourceVersion(), 10, 64)
+	if err != nil {
+		return true
+	}
+	if newRV < oldRV {
+		staleEventCounter.WithLabelValues(p.SyncConfigID).Inc()
+		return false
+	}
+	return true
+}
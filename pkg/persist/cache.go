@@ -0,0 +1,212 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package persist
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var _ Persister = &cachingPersister{}
+
+// ChangeNotifier is implemented by persisters whose data can change without a call to their Persister methods,
+// e.g. because changes are pulled from a remote repository or because a namespace has been pruned.
+type ChangeNotifier interface {
+	// OnChange registers a function which is called whenever the persisted data might have changed outside of the Persister methods.
+	OnChange(func())
+}
+
+// ChangeListeners implements ChangeNotifier, it is meant to be embedded into persisters.
+// The zero value is ready to use.
+type ChangeListeners struct {
+	lock      sync.Mutex
+	listeners []func()
+}
+
+func (cl *ChangeListeners) OnChange(fn func()) {
+	cl.lock.Lock()
+	defer cl.lock.Unlock()
+	cl.listeners = append(cl.listeners, fn)
+}
+
+// NotifyChange calls all registered functions.
+func (cl *ChangeListeners) NotifyChange() {
+	cl.lock.Lock()
+	listeners := cl.listeners
+	cl.lock.Unlock()
+	for _, fn := range listeners {
+		fn()
+	}
+}
+
+// cacheKey identifies the data of a resource in the storage.
+type cacheKey struct {
+	name      string
+	namespace string
+	gvk       schema.GroupVersionKind
+	subPath   string
+}
+
+// cacheEntry is what is known about the data of a resource in the storage.
+type cacheEntry struct {
+	exists bool
+	// digest is the digest of the transformed resource which has been persisted last.
+	// It is only set if the resource has been persisted via the cache.
+	digest *[sha256.Size]byte
+}
+
+// cachingPersister is a wrapper for a Persister which remembers which resources exist in the storage and the digests of the persisted ones.
+// Persisting a resource whose digest matches the cached one returns early, without reading from the storage.
+type cachingPersister struct {
+	Persister
+	// maxEntries is the number of entries after which the cache is flushed, 0 means unlimited.
+	maxEntries int
+
+	lock    sync.Mutex
+	entries map[cacheKey]cacheEntry
+	// generation is increased with every flush, so that results of calls which overlap with a flush are not cached.
+	generation uint64
+}
+
+// AddCachingLayer wraps the given Persister with a read-through cache.
+// The cache is updated by all calls going through it and flushed whenever any ChangeNotifier in the chain of internal Persisters reports a change.
+// Changes done directly on the storage, by other means than the Persister methods, are not detected.
+// If maxEntries is greater than 0, the cache is flushed whenever it would exceed this number of entries.
+func AddCachingLayer(p Persister, maxEntries int) Persister {
+	res := &cachingPersister{
+		Persister:  p,
+		maxEntries: maxEntries,
+		entries:    map[cacheKey]cacheEntry{},
+	}
+	for cur := p; cur != nil; cur = cur.InternalPersister() {
+		if cn, ok := cur.(ChangeNotifier); ok {
+			cn.OnChange(res.flush)
+		}
+	}
+	return res
+}
+
+func (cp *cachingPersister) Exists(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (bool, error) {
+	key := cacheKey{name: name, namespace: namespace, gvk: gvk, subPath: subPath}
+	entry, gen, ok := cp.lookup(key)
+	if ok {
+		return entry.exists, nil
+	}
+	exists, err := cp.Persister.Exists(ctx, name, namespace, gvk, subPath)
+	if err != nil {
+		return exists, err
+	}
+	cp.store(key, cacheEntry{exists: exists}, gen)
+	return exists, nil
+}
+
+func (cp *cachingPersister) Get(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (*unstructured.Unstructured, error) {
+	key := cacheKey{name: name, namespace: namespace, gvk: gvk, subPath: subPath}
+	entry, gen, ok := cp.lookup(key)
+	res, err := cp.Persister.Get(ctx, name, namespace, gvk, subPath)
+	if err != nil {
+		cp.remove(key)
+		return res, err
+	}
+	if !ok || !entry.exists || res == nil {
+		// the digest of the persisted resource is only known if it has been persisted via the cache
+		entry = cacheEntry{exists: res != nil}
+	}
+	cp.store(key, entry, gen)
+	return res, nil
+}
+
+func (cp *cachingPersister) Persist(ctx context.Context, resource *unstructured.Unstructured, t Transformer, name, subPath string) (*unstructured.Unstructured, bool, error) {
+	key := cacheKey{name: name, namespace: resource.GetNamespace(), gvk: resource.GroupVersionKind(), subPath: subPath}
+	transformed, err := t.Transform(resource)
+	if err != nil {
+		return nil, false, err
+	}
+	digest, err := resourceDigest(transformed)
+	if err != nil {
+		return nil, false, err
+	}
+	entry, gen, ok := cp.lookup(key)
+	if ok && entry.exists && entry.digest != nil && *entry.digest == *digest {
+		logging.FromContextOrDiscard(ctx).Debug("Resource has not changed since it has been persisted last, skipping storage")
+		return transformed, false, nil
+	}
+	persisted, changed, err := cp.Persister.Persist(ctx, resource, t, name, subPath)
+	if err != nil {
+		cp.remove(key)
+		return persisted, changed, err
+	}
+	cp.store(key, cacheEntry{exists: true, digest: digest}, gen)
+	return persisted, changed, nil
+}
+
+func (cp *cachingPersister) Delete(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) error {
+	key := cacheKey{name: name, namespace: namespace, gvk: gvk, subPath: subPath}
+	_, gen, _ := cp.lookup(key)
+	if err := cp.Persister.Delete(ctx, name, namespace, gvk, subPath); err != nil {
+		cp.remove(key)
+		return err
+	}
+	cp.store(key, cacheEntry{exists: false}, gen)
+	return nil
+}
+
+func (cp *cachingPersister) InternalPersister() Persister {
+	return cp.Persister
+}
+
+// lookup returns the cache entry for the given key and the current generation of the cache.
+// The last return value is false if there is no entry for the key.
+func (cp *cachingPersister) lookup(key cacheKey) (cacheEntry, uint64, bool) {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	entry, ok := cp.entries[key]
+	return entry, cp.generation, ok
+}
+
+// store sets the cache entry for the given key, unless the cache has been flushed since the given generation.
+func (cp *cachingPersister) store(key cacheKey, entry cacheEntry, generation uint64) {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	if generation != cp.generation {
+		return
+	}
+	if _, ok := cp.entries[key]; !ok && cp.maxEntries > 0 && len(cp.entries) >= cp.maxEntries {
+		cp.entries = map[cacheKey]cacheEntry{}
+	}
+	cp.entries[key] = entry
+}
+
+// remove removes the cache entry for the given key.
+func (cp *cachingPersister) remove(key cacheKey) {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	delete(cp.entries, key)
+}
+
+// flush removes all entries from the cache.
+func (cp *cachingPersister) flush() {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	cp.entries = map[cacheKey]cacheEntry{}
+	cp.generation++
+}
+
+// resourceDigest returns the SHA-256 digest of the JSON representation of the given resource.
+func resourceDigest(obj *unstructured.Unstructured) (*[sha256.Size]byte, error) {
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, fmt.Errorf("error computing resource digest: %w", err)
+	}
+	digest := sha256.Sum256(data)
+	return &digest, nil
+}
@@ -0,0 +1,201 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package persist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+var _ Persister = &circuitBreakerPersister{}
+
+// probeGVK is the kind of the resource whose existence is checked to probe storages which don't implement HealthChecker.
+var probeGVK = schema.GroupVersionKind{Group: constants.K8SYNCER_GROUP, Version: "v1", Kind: "Probe"}
+
+// StorageUnavailableError is returned for all calls to a storage which has been marked as unavailable by its circuit breaker.
+type StorageUnavailableError struct {
+	Storage       string
+	Failures      int
+	ProbeInterval time.Duration
+	// Cause is the error of the last failed operation before the storage has been marked as unavailable.
+	Cause error
+}
+
+func (e *StorageUnavailableError) Error() string {
+	return fmt.Sprintf("storage '%s' is unavailable after %d consecutive failed operations and is probed every %s, last error: %s", e.Storage, e.Failures, e.ProbeInterval.String(), e.Cause.Error())
+}
+
+func (e *StorageUnavailableError) Unwrap() error {
+	return e.Cause
+}
+
+// ResourceError is implemented by errors which are caused by the stored data of a single resource, e.g. because it is corrupt,
+// and not by the storage itself. They don't open the circuit, as they persist no matter how available the storage is.
+type ResourceError interface {
+	error
+	// ResourceError is a marker method without any effect.
+	ResourceError()
+}
+
+// circuitBreakerPersister is a wrapper for a Persister which stops calling it after a number of consecutive failures.
+// While the circuit is open, all calls fail with a StorageUnavailableError, until a probe in the background succeeds.
+type circuitBreakerPersister struct {
+	Persister
+	storage       string
+	threshold     int
+	probeInterval time.Duration
+	// stop is closed when the probes in the background have to be stopped, e.g. because the manager shuts down.
+	stop <-chan struct{}
+
+	lock     sync.Mutex
+	failures int
+	lastErr  error
+	open     bool
+}
+
+// AddCircuitBreakerLayer wraps the given Persister with a circuit breaker, which opens after threshold consecutive failed calls.
+// Once it is open, the storage is probed in the given interval, via the outermost HealthChecker in the chain of internal Persisters if there is one
+// or by checking for the existence of a resource otherwise. The circuit is closed again as soon as a probe succeeds.
+// Only the methods of the Persister interface are guarded, calls via other interfaces found in the chain of internal Persisters are not.
+// Errors caused by cancelled contexts and ResourceErrors are not counted as failures.
+// The given context defines the lifetime of the circuit breaker, probes in the background are stopped when it is done.
+func AddCircuitBreakerLayer(ctx context.Context, p Persister, storage string, threshold int, probeInterval time.Duration) Persister {
+	res := &circuitBreakerPersister{
+		Persister:     p,
+		storage:       storage,
+		threshold:     threshold,
+		probeInterval: probeInterval,
+		stop:          ctx.Done(),
+	}
+	return res
+}
+
+func (cb *circuitBreakerPersister) Exists(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (bool, error) {
+	if err := cb.check(); err != nil {
+		return false, err
+	}
+	exists, err := cb.Persister.Exists(ctx, name, namespace, gvk, subPath)
+	cb.record(ctx, err)
+	return exists, err
+}
+
+func (cb *circuitBreakerPersister) Get(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (*unstructured.Unstructured, error) {
+	if err := cb.check(); err != nil {
+		return nil, err
+	}
+	res, err := cb.Persister.Get(ctx, name, namespace, gvk, subPath)
+	cb.record(ctx, err)
+	return res, err
+}
+
+func (cb *circuitBreakerPersister) Persist(ctx context.Context, resource *unstructured.Unstructured, t Transformer, name, subPath string) (*unstructured.Unstructured, bool, error) {
+	if err := cb.check(); err != nil {
+		return nil, false, err
+	}
+	persisted, changed, err := cb.Persister.Persist(ctx, resource, t, name, subPath)
+	cb.record(ctx, err)
+	return persisted, changed, err
+}
+
+func (cb *circuitBreakerPersister) Delete(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) error {
+	if err := cb.check(); err != nil {
+		return err
+	}
+	err := cb.Persister.Delete(ctx, name, namespace, gvk, subPath)
+	cb.record(ctx, err)
+	return err
+}
+
+func (cb *circuitBreakerPersister) InternalPersister() Persister {
+	return cb.Persister
+}
+
+// check returns a StorageUnavailableError if the circuit is open.
+func (cb *circuitBreakerPersister) check() error {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	if !cb.open {
+		return nil
+	}
+	return &StorageUnavailableError{
+		Storage:       cb.storage,
+		Failures:      cb.failures,
+		ProbeInterval: cb.probeInterval,
+		Cause:         cb.lastErr,
+	}
+}
+
+// record updates the consecutive failures with the result of a call and opens the circuit if the threshold is reached.
+// A successful call closes the circuit, as it proves that the storage is available.
+func (cb *circuitBreakerPersister) record(ctx context.Context, err error) {
+	var resErr ResourceError
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.As(err, &resErr) {
+		return
+	}
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	if err == nil {
+		cb.failures = 0
+		cb.lastErr = nil
+		cb.open = false
+		return
+	}
+	cb.failures++
+	cb.lastErr = err
+	if cb.open || cb.failures < cb.threshold {
+		return
+	}
+	cb.open = true
+	logging.FromContextOrDiscard(ctx).Error(err, "Storage failed repeatedly, marking it as unavailable", constants.Logging.KEY_RESOURCE_STORAGE, cb.storage)
+	// the probe must not be stopped when the context of the failed call is done, only when the circuit breaker's lifetime ends
+	go cb.probeUntilAvailable(context.WithoutCancel(ctx))
+}
+
+// probeUntilAvailable probes the storage in the configured interval until a probe succeeds, the circuit has been closed otherwise,
+// or the lifetime of the circuit breaker ends.
+func (cb *circuitBreakerPersister) probeUntilAvailable(ctx context.Context) {
+	log := logging.FromContextOrDiscard(ctx)
+	ticker := time.NewTicker(cb.probeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-cb.stop:
+			return
+		case <-ticker.C:
+		}
+		if cb.check() == nil {
+			return
+		}
+		if err := cb.probe(ctx); err != nil {
+			log.Debug("Storage is still unavailable", constants.Logging.KEY_RESOURCE_STORAGE, cb.storage, constants.Logging.KEY_ERROR, err.Error())
+			continue
+		}
+		cb.lock.Lock()
+		cb.failures = 0
+		cb.lastErr = nil
+		cb.open = false
+		cb.lock.Unlock()
+		log.Info("Storage is available again", constants.Logging.KEY_RESOURCE_STORAGE, cb.storage)
+		return
+	}
+}
+
+// probe checks whether the storage is available.
+func (cb *circuitBreakerPersister) probe(ctx context.Context) error {
+	if hc, ok := FindHealthChecker(cb.Persister); ok {
+		return hc.CheckHealth(ctx)
+	}
+	_, err := cb.Persister.Exists(ctx, "k8syncer-probe", "", probeGVK, "")
+	return err
+}
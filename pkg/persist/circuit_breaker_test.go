@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package persist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/utils/git"
+)
+
+// failingPersister returns err for all calls.
+type failingPersister struct {
+	err   error
+	calls int
+}
+
+var _ Persister = &failingPersister{}
+
+func (p *failingPersister) Exists(_ context.Context, _, _ string, _ schema.GroupVersionKind, _ string) (bool, error) {
+	p.calls++
+	return false, p.err
+}
+
+func (p *failingPersister) Get(_ context.Context, _, _ string, _ schema.GroupVersionKind, _ string) (*unstructured.Unstructured, error) {
+	p.calls++
+	return nil, p.err
+}
+
+func (p *failingPersister) Persist(_ context.Context, resource *unstructured.Unstructured, _ Transformer, _, _ string) (*unstructured.Unstructured, bool, error) {
+	p.calls++
+	return resource, false, p.err
+}
+
+func (p *failingPersister) Delete(_ context.Context, _, _ string, _ schema.GroupVersionKind, _ string) error {
+	p.calls++
+	return p.err
+}
+
+func (p *failingPersister) InternalPersister() Persister {
+	return nil
+}
+
+// corruptResourceError is a ResourceError like the ones of the filesystem persister, which can't be imported here.
+type corruptResourceError struct{}
+
+func (e *corruptResourceError) Error() string {
+	return "corrupt data"
+}
+
+func (e *corruptResourceError) ResourceError() {}
+
+var _ = Describe("Circuit Breaker", func() {
+
+	const threshold = 3
+	ctx := context.Background()
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+
+	It("should open the circuit after consecutive storage failures", func() {
+		fp := &failingPersister{err: errors.New("connection refused")}
+		cb := AddCircuitBreakerLayer(ctx, fp, "myStorage", threshold, time.Hour)
+
+		for i := 0; i < threshold; i++ {
+			_, err := cb.Get(ctx, "foo", "bar", gvk, "")
+			Expect(err).To(MatchError("connection refused"))
+		}
+		_, err := cb.Get(ctx, "foo", "bar", gvk, "")
+		unavailableErr := &StorageUnavailableError{}
+		Expect(errors.As(err, &unavailableErr)).To(BeTrue())
+		Expect(unavailableErr.Storage).To(Equal("myStorage"))
+		Expect(fp.calls).To(Equal(threshold))
+	})
+
+	It("should not open the circuit for persistent errors of single resources", func() {
+		for _, resErr := range []error{
+			&corruptResourceError{},
+			fmt.Errorf("unresolved conflict: %w", &git.ConflictError{Paths: []string{"ns_bar/configmap.v1_foo.yaml"}}),
+		} {
+			fp := &failingPersister{err: resErr}
+			cb := AddCircuitBreakerLayer(ctx, fp, "myStorage", threshold, time.Hour)
+
+			for i := 0; i < 2*threshold; i++ {
+				_, err := cb.Get(ctx, "foo", "bar", gvk, "")
+				Expect(err).To(MatchError(resErr))
+				Expect(cb.Delete(ctx, "foo", "bar", gvk, "")).To(MatchError(resErr))
+			}
+			Expect(fp.calls).To(Equal(4*threshold), "error: %s", resErr.Error())
+		}
+	})
+
+	It("should stop probing the storage when its lifetime ends", func() {
+		lifetimeCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		fp := &failingPersister{err: errors.New("connection refused")}
+		cb := AddCircuitBreakerLayer(lifetimeCtx, fp, "myStorage", threshold, time.Millisecond)
+
+		for i := 0; i < threshold; i++ {
+			_, err := cb.Get(ctx, "foo", "bar", gvk, "")
+			Expect(err).To(MatchError("connection refused"))
+		}
+		Consistently(func() int { return fp.calls }, 50*time.Millisecond, 5*time.Millisecond).Should(Equal(threshold))
+	})
+
+	It("should not count errors of cancelled contexts", func() {
+		fp := &failingPersister{err: context.Canceled}
+		cb := AddCircuitBreakerLayer(ctx, fp, "myStorage", threshold, time.Hour)
+
+		for i := 0; i < 2*threshold; i++ {
+			_, err := cb.Exists(ctx, "foo", "bar", gvk, "")
+			Expect(err).To(MatchError(context.Canceled))
+		}
+		Expect(fp.calls).To(Equal(2 * threshold))
+	})
+
+})
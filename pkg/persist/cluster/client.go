@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// targetClient reads and writes the replicated resources in the target cluster.
+type targetClient interface {
+	// get returns the object of the given kind with the given name and namespace.
+	// If the object doesn't exist, it returns (nil, nil).
+	get(ctx context.Context, gvk schema.GroupVersionKind, namespace, name string) (*unstructured.Unstructured, error)
+	// apply applies the given object via server-side apply with the given field manager and returns the resulting object.
+	// If force is true, conflicts with other field managers are resolved by taking over the ownership of the conflicting fields.
+	apply(ctx context.Context, obj *unstructured.Unstructured, fieldManager string, force bool) (*unstructured.Unstructured, error)
+	// delete deletes the given object, if it still has the same UID.
+	// It does not return an error if the object doesn't exist.
+	delete(ctx context.Context, obj *unstructured.Unstructured) error
+}
+
+// clusterClient is a targetClient which uses a controller-runtime client.
+type clusterClient struct {
+	client client.Client
+}
+
+var _ targetClient = &clusterClient{}
+
+func (c *clusterClient) get(ctx context.Context, gvk schema.GroupVersionKind, namespace, name string) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching replicated resource: %w", err)
+	}
+	return obj, nil
+}
+
+func (c *clusterClient) apply(ctx context.Context, obj *unstructured.Unstructured, fieldManager string, force bool) (*unstructured.Unstructured, error) {
+	res := obj.DeepCopy()
+	opts := []client.PatchOption{client.FieldOwner(fieldManager)}
+	if force {
+		opts = append(opts, client.ForceOwnership)
+	}
+	if err := c.client.Patch(ctx, res, client.Apply, opts...); err != nil {
+		return nil, fmt.Errorf("error applying replicated resource: %w", err)
+	}
+	return res, nil
+}
+
+func (c *clusterClient) delete(ctx context.Context, obj *unstructured.Unstructured) error {
+	uid := obj.GetUID()
+	if err := c.client.Delete(ctx, obj, client.Preconditions{UID: &uid}); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("error deleting replicated resource: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+var _ persist.Persister = &ClusterPersister{}
+
+// targetMetadataFields are the metadata fields which are removed from the transformed resources before they are applied,
+// because they are managed by the target cluster or refer to objects which only exist in the source cluster.
+var targetMetadataFields = []string{
+	"uid",
+	"resourceVersion",
+	"generation",
+	"creationTimestamp",
+	"deletionTimestamp",
+	"deletionGracePeriodSeconds",
+	"managedFields",
+	"ownerReferences",
+	"finalizers",
+	"generateName",
+	"selfLink",
+}
+
+// ClusterPersister replicates resources into a cluster.
+// The transformed resources are applied via server-side apply under their own name, in the namespace their namespace is mapped to.
+// Replicated resources are labeled with the name of the storage and only those are returned or deleted.
+// The UID of the source resource is stored in an annotation and restored when the resource is read, so that the persisted data
+// can be matched to the source resource. The subPath is ignored.
+type ClusterPersister struct {
+	client        targetClient
+	storageName   string
+	namespaceMap  map[string]string
+	fieldManager  string
+	forceConflict bool
+}
+
+// New creates a new ClusterPersister from the given storage definition.
+// The resources are replicated into the cluster specified by the given rest config.
+// The storage definition is expected to be completed and validated.
+func New(stDef *config.StorageDefinition, restCfg *rest.Config) (*ClusterPersister, error) {
+	if stDef.ClusterConfig == nil {
+		return nil, fmt.Errorf("cluster config must not be nil")
+	}
+	c, err := client.New(restCfg, client.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("error creating client for cluster storage '%s': %w", stDef.Name, err)
+	}
+	return newWithClient(stDef, &clusterClient{client: c}), nil
+}
+
+// newWithClient creates a new ClusterPersister which replicates the resources via the given client.
+func newWithClient(stDef *config.StorageDefinition, c targetClient) *ClusterPersister {
+	return &ClusterPersister{
+		client:        c,
+		storageName:   stDef.Name,
+		namespaceMap:  stDef.ClusterConfig.NamespaceMapping,
+		fieldManager:  stDef.ClusterConfig.FieldManager,
+		forceConflict: stDef.ClusterConfig.ForceConflicts,
+	}
+}
+
+func (p *ClusterPersister) Exists(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, _ string) (bool, error) {
+	obj, err := p.get(ctx, name, namespace, gvk)
+	if err != nil {
+		return false, err
+	}
+	return obj != nil, nil
+}
+
+func (p *ClusterPersister) Get(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, _ string) (*unstructured.Unstructured, error) {
+	obj, err := p.get(ctx, name, namespace, gvk)
+	if err != nil || obj == nil {
+		return nil, err
+	}
+	// return the resource as it looks from the source cluster
+	obj.SetNamespace(namespace)
+	obj.SetUID(types.UID(obj.GetAnnotations()[constants.ANNOTATION_SOURCE_UID]))
+	return obj, nil
+}
+
+func (p *ClusterPersister) Persist(ctx context.Context, resource *unstructured.Unstructured, t persist.Transformer, name, _ string) (*unstructured.Unstructured, bool, error) {
+	transformed, err := t.Transform(resource)
+	if err != nil {
+		return nil, false, fmt.Errorf("error transforming resource: %w", err)
+	}
+	gvk := transformed.GroupVersionKind()
+	existing, err := p.get(ctx, name, transformed.GetNamespace(), gvk)
+	if err != nil {
+		return nil, false, err
+	}
+	target, err := p.toTarget(transformed, name)
+	if err != nil {
+		return nil, false, err
+	}
+	logging.FromContextOrDiscard(ctx).Debug("Applying resource", constants.Logging.KEY_RESOURCE_NAME, target.GetName(), constants.Logging.KEY_RESOURCE_NAMESPACE, target.GetNamespace())
+	applied, err := p.client.apply(ctx, target, p.fieldManager, p.forceConflict)
+	if err != nil {
+		return nil, false, err
+	}
+	// applying an unchanged resource doesn't modify it, so its resourceVersion stays the same
+	changed := existing == nil || applied.GetResourceVersion() != existing.GetResourceVersion()
+	return transformed, changed, nil
+}
+
+func (p *ClusterPersister) Delete(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, _ string) error {
+	obj, err := p.get(ctx, name, namespace, gvk)
+	if err != nil || obj == nil {
+		return err
+	}
+	logging.FromContextOrDiscard(ctx).Debug("Deleting resource", constants.Logging.KEY_RESOURCE_NAME, obj.GetName(), constants.Logging.KEY_RESOURCE_NAMESPACE, obj.GetNamespace())
+	return p.client.delete(ctx, obj)
+}
+
+func (p *ClusterPersister) InternalPersister() persist.Persister {
+	return nil
+}
+
+// targetNamespace returns the namespace into which resources of the given namespace are replicated.
+func (p *ClusterPersister) targetNamespace(namespace string) string {
+	if target, ok := p.namespaceMap[namespace]; ok && namespace != "" {
+		return target
+	}
+	return namespace
+}
+
+// get returns the replicated version of the specified resource.
+// It returns (nil, nil) if it doesn't exist or the object in the target cluster doesn't belong to the storage.
+func (p *ClusterPersister) get(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind) (*unstructured.Unstructured, error) {
+	obj, err := p.client.get(ctx, gvk, p.targetNamespace(namespace), name)
+	if err != nil || obj == nil {
+		return nil, err
+	}
+	if obj.GetLabels()[constants.LABEL_STORAGE] != p.storageName {
+		return nil, nil
+	}
+	return obj, nil
+}
+
+// toTarget returns the object which is applied to the target cluster for the given transformed resource.
+func (p *ClusterPersister) toTarget(transformed *unstructured.Unstructured, name string) (*unstructured.Unstructured, error) {
+	res := transformed.DeepCopy()
+	for _, f := range targetMetadataFields {
+		unstructured.RemoveNestedField(res.Object, "metadata", f)
+	}
+	res.SetName(name)
+	res.SetNamespace(p.targetNamespace(transformed.GetNamespace()))
+	labels := res.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[constants.LABEL_STORAGE] = p.storageName
+	res.SetLabels(labels)
+	if uid := transformed.GetUID(); uid != "" {
+		ann := res.GetAnnotations()
+		if ann == nil {
+			ann = map[string]string{}
+		}
+		ann[constants.ANNOTATION_SOURCE_UID] = string(uid)
+		res.SetAnnotations(ann)
+	}
+	if res.GetName() == "" {
+		return nil, fmt.Errorf("unable to replicate resource without name")
+	}
+	return res, nil
+}
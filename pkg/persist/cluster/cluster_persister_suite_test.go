@@ -0,0 +1,205 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist/transformers"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cluster Persister Test Suite")
+}
+
+var _ = Describe("Cluster Persister Tests", func() {
+
+	var (
+		dummy            *unstructured.Unstructured
+		basicTransformer = transformers.NewBasic()
+		ctx              context.Context
+		target           *memCluster
+	)
+
+	BeforeEach(func() {
+		dummy = &unstructured.Unstructured{}
+		dummy.SetName("foo")
+		dummy.SetNamespace("bar")
+		dummy.SetUID("source-uid")
+		dummy.SetResourceVersion("42")
+		dummy.SetFinalizers([]string{constants.K8SYNCER_FINALIZER})
+		dummy.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "v1", Kind: "ConfigMap", Name: "owner", UID: "owner-uid"}})
+		dummy.SetGroupVersionKind(schema.GroupVersionKind{
+			Group:   "k8syncer.gardener.cloud",
+			Version: "v1",
+			Kind:    "Dummy",
+		})
+		Expect(unstructured.SetNestedField(dummy.Object, "value", "spec", "value")).To(Succeed())
+
+		ctx = logging.NewContext(context.Background(), logging.Discard())
+		target = &memCluster{objects: map[string]*unstructured.Unstructured{}}
+	})
+
+	newPersister := func(clusterCfg *config.ClusterStorageConfiguration) *ClusterPersister {
+		cfg := &config.K8SyncerConfiguration{
+			StorageDefinitions: []*config.StorageDefinition{
+				{
+					Name:          "replica",
+					Type:          config.STORAGE_TYPE_CLUSTER,
+					ClusterConfig: clusterCfg,
+				},
+			},
+		}
+		Expect(cfg.Complete()).To(Succeed())
+		return newWithClient(cfg.StorageDefinitions[0], target)
+	}
+
+	It("should apply the resources into the mapped namespaces", func() {
+		p := newPersister(&config.ClusterStorageConfiguration{
+			NamespaceMapping: map[string]string{"bar": "mirror"},
+		})
+		gvk := dummy.GroupVersionKind()
+
+		By("replicating a new resource")
+		_, changed, err := p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		Expect(target.objects).To(HaveLen(1))
+		applied := target.objects["mirror/foo"]
+		Expect(applied).ToNot(BeNil())
+		Expect(target.fieldManagers).To(ConsistOf("k8syncer"))
+		Expect(applied.GetLabels()).To(HaveKeyWithValue(constants.LABEL_STORAGE, "replica"))
+		Expect(applied.GetAnnotations()).To(HaveKeyWithValue(constants.ANNOTATION_SOURCE_UID, "source-uid"))
+		Expect(applied.GetUID()).ToNot(Equal(types.UID("source-uid")))
+		Expect(applied.GetOwnerReferences()).To(BeEmpty())
+		Expect(applied.GetFinalizers()).To(BeEmpty())
+
+		By("reading the replicated resource as it looks from the source cluster")
+		exists, err := p.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), gvk, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+		res, err := p.Get(ctx, dummy.GetName(), dummy.GetNamespace(), gvk, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).ToNot(BeNil())
+		Expect(res.GetNamespace()).To(Equal("bar"))
+		Expect(res.GetUID()).To(Equal(types.UID("source-uid")))
+
+		By("replicating an unchanged resource")
+		_, changed, err = p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeFalse())
+
+		By("replicating a changed resource")
+		modified := dummy.DeepCopy()
+		Expect(unstructured.SetNestedField(modified.Object, "modified", "spec", "value")).To(Succeed())
+		_, changed, err = p.Persist(ctx, modified, basicTransformer, dummy.GetName(), "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		value, _, err := unstructured.NestedString(target.objects["mirror/foo"].Object, "spec", "value")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value).To(Equal("modified"))
+
+		By("deleting the replicated resource")
+		Expect(p.Delete(ctx, dummy.GetName(), dummy.GetNamespace(), gvk, "")).To(Succeed())
+		Expect(target.objects).To(BeEmpty())
+		res, err = p.Get(ctx, dummy.GetName(), dummy.GetNamespace(), gvk, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).To(BeNil())
+		Expect(p.Delete(ctx, dummy.GetName(), dummy.GetNamespace(), gvk, "")).To(Succeed())
+	})
+
+	It("should keep the namespace of unmapped and cluster-scoped resources", func() {
+		p := newPersister(&config.ClusterStorageConfiguration{
+			NamespaceMapping: map[string]string{"other": "mirror"},
+			FieldManager:     "replicator",
+			ForceConflicts:   true,
+		})
+
+		_, _, err := p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.objects).To(HaveKey("bar/foo"))
+
+		clusterScoped := dummy.DeepCopy()
+		clusterScoped.SetNamespace("")
+		_, _, err = p.Persist(ctx, clusterScoped, basicTransformer, clusterScoped.GetName(), "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.objects).To(HaveKey("/foo"))
+		Expect(target.fieldManagers).To(ConsistOf("replicator!", "replicator!"))
+	})
+
+	It("should ignore resources which don't belong to the storage", func() {
+		p := newPersister(&config.ClusterStorageConfiguration{})
+		foreign := dummy.DeepCopy()
+		foreign.SetLabels(map[string]string{constants.LABEL_STORAGE: "other"})
+		target.objects["bar/foo"] = foreign
+
+		exists, err := p.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+		Expect(p.Delete(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "")).To(Succeed())
+		Expect(target.objects).To(HaveKey("bar/foo"))
+	})
+
+})
+
+// memCluster is an in-memory targetClient, which imitates the behavior of server-side apply for the fields used by the tests.
+type memCluster struct {
+	objects map[string]*unstructured.Unstructured
+	// fieldManagers contains the field manager of each apply call, suffixed with '!' if conflicts were forced.
+	fieldManagers []string
+	uidCounter    int
+}
+
+var _ targetClient = &memCluster{}
+
+func (c *memCluster) get(_ context.Context, _ schema.GroupVersionKind, namespace, name string) (*unstructured.Unstructured, error) {
+	obj, ok := c.objects[namespace+"/"+name]
+	if !ok {
+		return nil, nil
+	}
+	return obj.DeepCopy(), nil
+}
+
+func (c *memCluster) apply(_ context.Context, obj *unstructured.Unstructured, fieldManager string, force bool) (*unstructured.Unstructured, error) {
+	if force {
+		fieldManager += "!"
+	}
+	c.fieldManagers = append(c.fieldManagers, fieldManager)
+	key := obj.GetNamespace() + "/" + obj.GetName()
+	res := obj.DeepCopy()
+	existing, ok := c.objects[key]
+	if ok {
+		res.SetUID(existing.GetUID())
+		res.SetResourceVersion(existing.GetResourceVersion())
+		if reflect.DeepEqual(res.Object, existing.Object) {
+			return existing.DeepCopy(), nil
+		}
+	} else {
+		c.uidCounter++
+		res.SetUID(types.UID(fmt.Sprintf("target-uid-%d", c.uidCounter)))
+	}
+	res.SetResourceVersion(fmt.Sprint(c.uidCounter*1000 + len(c.fieldManagers)))
+	c.objects[key] = res
+	return res.DeepCopy(), nil
+}
+
+func (c *memCluster) delete(_ context.Context, obj *unstructured.Unstructured) error {
+	delete(c.objects, obj.GetNamespace()+"/"+obj.GetName())
+	return nil
+}
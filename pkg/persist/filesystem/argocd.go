@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// argoCDApplicationsDir is the directory which contains the persisted resources, grouped by namespace, for the 'argocd' layout.
+	argoCDApplicationsDir = "applications"
+	// argoCDIndexDir is the directory which contains one Argo CD application per namespace directory.
+	argoCDIndexDir = "apps"
+	// argoCDClusterScopedDir is the directory which contains cluster-scoped resources.
+	// The leading '_' avoids conflicts with namespaces, as it is not allowed in namespace names.
+	argoCDClusterScopedDir = "_cluster"
+)
+
+// argoCDNamespaceDir returns the name of the directory for resources in the given namespace.
+func argoCDNamespaceDir(namespace string) string {
+	if namespace == "" {
+		return argoCDClusterScopedDir
+	}
+	return namespace
+}
+
+// updateArgoCDIndex makes sure that the root application and the application for the given namespace exist below the given subPath.
+// The root application points to the index directory, which contains the namespace applications (app-of-apps pattern),
+// so applying the root application to a cluster running Argo CD restores all resources.
+func (p *FileSystemPersister) updateArgoCDIndex(ctx context.Context, namespace, subPath string) error {
+	rootName := p.ArgoCD.RootApplicationName
+	indexPath := repoRelativePath(vfs.Join(p.Fs, subPath, argoCDIndexDir))
+	root := p.argoCDApplication(rootName, indexPath, "")
+	if err := p.persistIfChanged(ctx, root, p.joinRoot(subPath, p.withFileExtension(rootName))); err != nil {
+		return err
+	}
+
+	nsDir := argoCDNamespaceDir(namespace)
+	appName := fmt.Sprintf("%s-cluster", rootName)
+	if namespace != "" {
+		appName = fmt.Sprintf("%s-ns-%s", rootName, namespace)
+	}
+	app := p.argoCDApplication(appName, repoRelativePath(vfs.Join(p.Fs, subPath, argoCDApplicationsDir, nsDir)), namespace)
+	return p.persistIfChanged(ctx, app, p.joinRoot(subPath, argoCDIndexDir, p.withFileExtension(nsDir)))
+}
+
+// argoCDApplication returns the manifest of an Argo CD application which deploys the manifests from the given path.
+func (p *FileSystemPersister) argoCDApplication(name, path, destinationNamespace string) map[string]any {
+	destination := map[string]any{
+		"server": p.ArgoCD.DestinationServer,
+	}
+	if destinationNamespace != "" {
+		destination["namespace"] = destinationNamespace
+	}
+	return map[string]any{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Application",
+		"metadata": map[string]any{
+			"name":      name,
+			"namespace": p.ArgoCD.Namespace,
+		},
+		"spec": map[string]any{
+			"project": p.ArgoCD.Project,
+			"source": map[string]any{
+				"repoURL":        p.ArgoCD.RepoURL,
+				"targetRevision": p.ArgoCD.TargetRevision,
+				"path":           path,
+			},
+			"destination": destination,
+		},
+	}
+}
+
+// persistIfChanged writes the given manifest to the given path, unless the file already has the same content.
+func (p *FileSystemPersister) persistIfChanged(ctx context.Context, manifest map[string]any, filepath string) error {
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("error while marshalling argocd application to yaml: %w", err)
+	}
+	existingData, err := p.getRaw(ctx, filepath)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(data, existingData) {
+		return nil
+	}
+	return p.persistRaw(ctx, data, filepath)
+}
+
+// repoRelativePath converts the given path into the format expected by Argo CD.
+func repoRelativePath(path string) string {
+	res := strings.Trim(path, "/")
+	if res == "" {
+		return "."
+	}
+	return res
+}
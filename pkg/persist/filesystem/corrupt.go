@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// quarantineDir is the directory below the root path which contains quarantined data.
+// As it is hidden, it is ignored when the tree of the storage is read.
+const quarantineDir = ".quarantine"
+
+var _ persist.ResourceError = &CorruptDataError{}
+
+// CorruptDataError is returned if data in the storage cannot be parsed and the corrupt data policy is 'error'.
+type CorruptDataError struct {
+	// Path is the path of the file containing the corrupt data.
+	Path string
+	Err  error
+}
+
+func (e *CorruptDataError) Error() string {
+	return fmt.Sprintf("corrupt data in file '%s', the file needs to be repaired or removed manually: %v", e.Path, e.Err)
+}
+
+func (e *CorruptDataError) Unwrap() error {
+	return e.Err
+}
+
+func (e *CorruptDataError) ResourceError() {}
+
+// handleCorruptData checks whether the given data, which has been read from the given file, can be parsed.
+// If it can't, the configured corrupt data policy is applied. An error is returned if the file must not be overwritten.
+func (p *FileSystemPersister) handleCorruptData(ctx context.Context, data []byte, filepath string) error {
+	_, parseErr := ConvertFromPersistence(data)
+	if parseErr == nil {
+		return nil
+	}
+	log := logging.FromContextOrDiscard(ctx).WithValues(constants.Logging.KEY_PATH, filepath)
+	switch p.OnCorruptData {
+	case config.CORRUPT_DATA_POLICY_ERROR:
+		return &CorruptDataError{Path: filepath, Err: parseErr}
+	case config.CORRUPT_DATA_POLICY_QUARANTINE:
+		quarantinePath, err := p.quarantine(filepath)
+		if err != nil {
+			return fmt.Errorf("error moving corrupt file '%s' to quarantine: %w", filepath, err)
+		}
+		log.Error(parseErr, "Unable to parse data from storage, corrupt file has been moved to quarantine", constants.Logging.KEY_QUARANTINE_PATH, quarantinePath)
+	default:
+		log.Error(parseErr, "Unable to parse data from storage, overwriting it")
+	}
+	return nil
+}
+
+// quarantine moves the given file below the quarantine directory and returns its new path.
+// The path of the file relative to the root path is kept and a timestamp is appended to the filename.
+func (p *FileSystemPersister) quarantine(filepath string) (string, error) {
+	relPath := strings.TrimPrefix(strings.TrimPrefix(filepath, p.RootPath), string(vfs.PathSeparatorChar))
+	quarantinePath := vfs.Join(p.Fs, p.RootPath, quarantineDir, fmt.Sprintf("%s.%s", relPath, time.Now().UTC().Format(archiveTimestampFormat)))
+	if err := p.Fs.MkdirAll(vfs.Dir(p.Fs, quarantinePath), os.ModeDir|os.ModePerm); err != nil {
+		return "", err
+	}
+	if err := p.Fs.Rename(filepath, quarantinePath); err != nil {
+		return "", err
+	}
+	return quarantinePath, nil
+}
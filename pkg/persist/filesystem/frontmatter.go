@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+	"github.com/gardener/k8syncer/pkg/version"
+)
+
+// frontMatterPrefix is the prefix of each line of the front matter.
+// As the lines are YAML comments, the front matter is ignored when the file is parsed.
+const frontMatterPrefix = "# k8syncer."
+
+// frontMatter returns the comment header with the sync metadata for the given resource.
+// The resource version and generation are taken from the resource as it has been read from the cluster,
+// the source cluster from the annotation which is injected by the transformer, if a cluster name is configured.
+func frontMatter(resource, transformed *unstructured.Unstructured, now time.Time) []byte {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "%ssyncTime: %s\n", frontMatterPrefix, now.UTC().Format(time.RFC3339))
+	if cluster := transformed.GetAnnotations()[constants.ANNOTATION_CLUSTER_NAME]; cluster != "" {
+		fmt.Fprintf(buf, "%ssourceCluster: %s\n", frontMatterPrefix, cluster)
+	}
+	fmt.Fprintf(buf, "%sversion: %s\n", frontMatterPrefix, version.Get().GitVersion)
+	if rv := resource.GetResourceVersion(); rv != "" {
+		fmt.Fprintf(buf, "%sresourceVersion: %s\n", frontMatterPrefix, rv)
+	}
+	if generation := resource.GetGeneration(); generation != 0 {
+		fmt.Fprintf(buf, "%sgeneration: %d\n", frontMatterPrefix, generation)
+	}
+	return buf.Bytes()
+}
+
+// stripFrontMatter returns the given file content without its front matter.
+// The returned bool is false if the content doesn't start with a front matter.
+func stripFrontMatter(data []byte) ([]byte, bool) {
+	found := false
+	for bytes.HasPrefix(data, []byte(frontMatterPrefix)) {
+		found = true
+		idx := bytes.IndexByte(data, '\n')
+		if idx < 0 {
+			return nil, true
+		}
+		data = data[idx+1:]
+	}
+	return data, found
+}
+
+// unchanged returns true if the existing file content matches the newly serialized resource.
+// With front matter, only the content after it is compared, so that the file is not rewritten if only the sync metadata changed.
+func (p *FileSystemPersister) unchanged(newData, existingData []byte) bool {
+	if !p.FrontMatter {
+		return bytes.Equal(newData, existingData)
+	}
+	body, found := stripFrontMatter(existingData)
+	return found && bytes.Equal(newData, body)
+}
@@ -5,11 +5,11 @@
 package filesystem
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gardener/landscaper/controller-utils/pkg/logging"
 
@@ -23,11 +23,12 @@ import (
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/yaml"
 )
 
 var _ persist.Persister = &FileSystemPersister{}
-var _ persist.LoggerInjectable = &FileSystemPersister{}
+var _ persist.ChangeNotifier = &FileSystemPersister{}
 
 // FileSystemPersister persists data by writing it to a given file system.
 type FileSystemPersister struct {
@@ -41,16 +42,46 @@ type FileSystemPersister struct {
 	FileExtension string
 	// RootPath is used as a root path.
 	RootPath string
-
-	injectedLogger *logging.Logger
-}
-
-func (p *FileSystemPersister) InjectLogger(il *logging.Logger) {
-	p.injectedLogger = il
+	// Layout is the directory structure used for the persisted resources.
+	Layout config.FileSystemLayout
+	// ArgoCD contains the configuration for the generated Argo CD applications, if Layout is 'argocd'.
+	ArgoCD *config.ArgoCDLayoutConfiguration
+	// KindOverrides contains overrides for NamespacePrefix, GVKNameSeparator, and FileExtension, mapped by GVK string.
+	KindOverrides map[string]*config.FileNamingOverride
+	// OnCorruptData specifies how data which cannot be parsed is handled.
+	OnCorruptData config.CorruptDataPolicy
+	// LongNames configures the shortening of file names which would be too long.
+	// If nil, file names are not shortened.
+	LongNames *config.LongNamesConfiguration
+	// ListDocuments is true if a document of kind 'List' containing all resources of a kind is maintained per directory.
+	ListDocuments bool
+	// FrontMatter is true if a comment header with sync metadata is prepended to each resource file.
+	FrontMatter bool
+
+	// lockFiles is true if files are locked while they are read or written.
+	lockFiles bool
+	// serializer is used to serialize resources, if a serialization is configured.
+	// If nil, ConvertToPersistence is used.
+	serializer *serializer
+	// ChangeListeners are notified when a namespace has been pruned.
+	persist.ChangeListeners
 }
 
 // New returns a new FileSystemPersister
+// If an IO configuration is given, the filesystem is wrapped to retry operations which fail with transient errors.
 func New(fs vfs.FileSystem, cfg *config.FileSystemConfiguration, createRootPath bool) (*FileSystemPersister, error) {
+	if cfg.IO != nil {
+		if cfg.IO.Lock && !fileLockingSupported {
+			return nil, fmt.Errorf("file locking is not supported on this operating system")
+		}
+		if cfg.IO.Retries > 0 {
+			interval := 100 * time.Millisecond
+			if cfg.IO.RetryInterval != nil {
+				interval = cfg.IO.RetryInterval.Duration
+			}
+			fs = withRetries(fs, cfg.IO.Retries, interval)
+		}
+	}
 	// check if root path exists
 	rootPathExists, err := vfs.DirExists(fs, cfg.RootPath)
 	if err != nil {
@@ -66,13 +97,21 @@ func New(fs vfs.FileSystem, cfg *config.FileSystemConfiguration, createRootPath
 			return nil, fmt.Errorf("specified root path '%s' does not exist or is not a directory", cfg.RootPath)
 		}
 	}
+	// resolve relative paths and symbolic links, so that all paths below the root path can be checked against it
+	rootPath, err := vfs.Canonical(fs, cfg.RootPath, true)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve root path '%s': %w", cfg.RootPath, err)
+	}
 
 	fsp := &FileSystemPersister{
 		Fs:               fs,
 		NamespacePrefix:  "ns_",
 		GVKNameSeparator: "_",
 		FileExtension:    "yaml",
-		RootPath:         cfg.RootPath,
+		RootPath:         rootPath,
+		Layout:           config.FILESYSTEM_LAYOUT_DEFAULT,
+		OnCorruptData:    config.CORRUPT_DATA_POLICY_OVERWRITE,
+		lockFiles:        cfg.IO != nil && cfg.IO.Lock,
 	}
 
 	if cfg.NamespacePrefix != nil {
@@ -84,8 +123,31 @@ func New(fs vfs.FileSystem, cfg *config.FileSystemConfiguration, createRootPath
 	if cfg.FileExtension != nil {
 		fsp.FileExtension = *cfg.FileExtension
 	}
+	if cfg.Layout != "" {
+		fsp.Layout = cfg.Layout
+	}
+	if cfg.OnCorruptData != "" {
+		fsp.OnCorruptData = cfg.OnCorruptData
+	}
+	fsp.LongNames = cfg.LongNames.DeepCopy()
+	fsp.ListDocuments = cfg.ListDocuments
+	fsp.FrontMatter = cfg.FrontMatter
+	if fsp.Layout == config.FILESYSTEM_LAYOUT_ARGOCD {
+		if cfg.ArgoCD == nil {
+			// should not happen, as this is defaulted when completing the configuration
+			return nil, fmt.Errorf("argocd configuration is required for layout '%s'", string(fsp.Layout))
+		}
+		fsp.ArgoCD = cfg.ArgoCD.DeepCopy()
+	}
 
-	fsp.injectedLogger = &persist.StaticDiscardLogger
+	if len(cfg.KindOverrides) > 0 {
+		fsp.KindOverrides = make(map[string]*config.FileNamingOverride, len(cfg.KindOverrides))
+		for gvkString, override := range cfg.KindOverrides {
+			fsp.KindOverrides[gvkString] = override.DeepCopy()
+		}
+	}
+
+	fsp.serializer = newSerializer(cfg.Serialization)
 
 	return fsp, nil
 }
@@ -101,8 +163,15 @@ func NewForMemory(cfg *config.FileSystemConfiguration) (*FileSystemPersister, er
 }
 
 func (p *FileSystemPersister) Exists(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (bool, error) {
-	filepath, _ := p.GetResourceFilepath(name, namespace, gvk, subPath, true)
-	return vfs.FileExists(p.Fs, filepath)
+	filepath, _, shortened := p.GetResourceFilepath(ctx, name, namespace, gvk, subPath, true)
+	if !shortened {
+		return vfs.FileExists(p.Fs, filepath)
+	}
+	data, err := p.getRaw(ctx, filepath)
+	if err != nil {
+		return false, err
+	}
+	return data != nil, CheckNameCollision(filepath, data, name, namespace)
 }
 
 func (p *FileSystemPersister) getRaw(ctx context.Context, filepath string) ([]byte, error) {
@@ -113,20 +182,40 @@ func (p *FileSystemPersister) getRaw(ctx context.Context, filepath string) ([]by
 	if !exists {
 		return nil, nil
 	}
-	return vfs.ReadFile(p.Fs, filepath)
+	return p.readFile(filepath)
 }
 
 func (p *FileSystemPersister) Get(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (*unstructured.Unstructured, error) {
-	filepath, _ := p.GetResourceFilepath(name, namespace, gvk, subPath, true)
+	filepath, _, shortened := p.GetResourceFilepath(ctx, name, namespace, gvk, subPath, true)
 	data, err := p.getRaw(ctx, filepath)
 	if err != nil {
 		return nil, err
 	}
-	return ConvertFromPersistence(data)
+	if data == nil {
+		return nil, nil
+	}
+	if shortened {
+		if err := CheckNameCollision(filepath, data, name, namespace); err != nil {
+			return nil, err
+		}
+	}
+	res, err := ConvertFromPersistence(data)
+	if err != nil {
+		if p.OnCorruptData == config.CORRUPT_DATA_POLICY_ERROR {
+			return nil, &CorruptDataError{Path: filepath, Err: err}
+		}
+		// the corrupt data is handled when the resource is persisted the next time
+		logging.FromContextOrDiscard(ctx).Error(err, "Unable to parse data from storage, treating it as missing", constants.Logging.KEY_PATH, filepath)
+		return nil, nil
+	}
+	return res, nil
 }
 
 func (p *FileSystemPersister) persistRaw(ctx context.Context, data []byte, filepath string) error {
 	dirpath := vfs.Dir(p.Fs, filepath)
+	if err := p.ensureWithinRoot(dirpath); err != nil {
+		return err
+	}
 	parentDirExists, err := vfs.DirExists(p.Fs, dirpath)
 	if err != nil {
 		return err
@@ -140,33 +229,91 @@ func (p *FileSystemPersister) persistRaw(ctx context.Context, data []byte, filep
 		}
 	}
 
-	return vfs.WriteFile(p.Fs, filepath, data, os.ModePerm)
+	return p.writeFile(filepath, data)
 }
 
-func (p *FileSystemPersister) Persist(ctx context.Context, resource *unstructured.Unstructured, t persist.Transformer, subPath string) (*unstructured.Unstructured, bool, error) {
-	filepath, _ := p.GetResourceFilepath(resource.GetName(), resource.GetNamespace(), resource.GroupVersionKind(), subPath, true)
+func (p *FileSystemPersister) Persist(ctx context.Context, resource *unstructured.Unstructured, t persist.Transformer, name, subPath string) (*unstructured.Unstructured, bool, error) {
+	filepath, _, shortened := p.GetResourceFilepath(ctx, name, resource.GetNamespace(), resource.GroupVersionKind(), subPath, true)
 	existingData, err := p.getRaw(ctx, filepath)
 	if err != nil {
 		return nil, false, err
 	}
+	if shortened {
+		if err := CheckNameCollision(filepath, existingData, name, resource.GetNamespace()); err != nil {
+			return nil, false, err
+		}
+	}
 	transformed, err := t.Transform(resource)
 	if err != nil {
 		return nil, false, err
 	}
-	newData, err := ConvertToPersistence(transformed, nil)
+	newData, err := p.convertToPersistence(transformed, nil)
 	if err != nil {
 		return nil, false, err
 	}
-	if bytes.Equal(newData, existingData) {
+	if p.unchanged(newData, existingData) {
 		return transformed, false, nil
 	}
+	if p.FrontMatter {
+		newData = append(frontMatter(resource, transformed, time.Now()), newData...)
+	}
+	if existingData != nil {
+		if err := p.handleCorruptData(ctx, existingData, filepath); err != nil {
+			return nil, false, err
+		}
+	}
 	err = p.persistRaw(ctx, newData, filepath)
-	return transformed, true, err
+	if err != nil {
+		return transformed, true, err
+	}
+	if p.ListDocuments {
+		if err := p.updateListDocument(ctx, vfs.Dir(p.Fs, filepath), resource.GroupVersionKind()); err != nil {
+			return transformed, true, fmt.Errorf("error updating list document: %w", err)
+		}
+	}
+	if p.Layout == config.FILESYSTEM_LAYOUT_ARGOCD {
+		if err := p.updateArgoCDIndex(ctx, resource.GetNamespace(), subPath); err != nil {
+			return transformed, true, fmt.Errorf("error updating argocd application index: %w", err)
+		}
+	}
+	return transformed, true, nil
+}
+
+// Differs returns true if persisting the given resource would change the data in the storage.
+func (p *FileSystemPersister) Differs(ctx context.Context, resource *unstructured.Unstructured, t persist.Transformer, name, subPath string) (bool, error) {
+	filepath, _, shortened := p.GetResourceFilepath(ctx, name, resource.GetNamespace(), resource.GroupVersionKind(), subPath, true)
+	existingData, err := p.getRaw(ctx, filepath)
+	if err != nil {
+		return false, err
+	}
+	if shortened {
+		if err := CheckNameCollision(filepath, existingData, name, resource.GetNamespace()); err != nil {
+			return false, err
+		}
+	}
+	newData, err := p.convertToPersistence(resource, t)
+	if err != nil {
+		return false, err
+	}
+	return !p.unchanged(newData, existingData), nil
 }
 
 func (p *FileSystemPersister) Delete(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) error {
-	filepath, nsdir := p.GetResourceFilepath(name, namespace, gvk, subPath, true)
+	filepath, nsdir, shortened := p.GetResourceFilepath(ctx, name, namespace, gvk, subPath, true)
+	if shortened {
+		// the file of another resource with the same shortened name must not be removed
+		data, err := p.getRaw(ctx, filepath)
+		if err != nil {
+			return err
+		}
+		if err := CheckNameCollision(filepath, data, name, namespace); err != nil {
+			return err
+		}
+	}
 	dirpath := vfs.Dir(p.Fs, filepath)
+	if err := p.ensureWithinRoot(dirpath); err != nil {
+		return err
+	}
 	parentDirExists, err := vfs.DirExists(p.Fs, dirpath)
 	if err != nil {
 		return err
@@ -184,18 +331,31 @@ func (p *FileSystemPersister) Delete(ctx context.Context, name, namespace string
 			return err
 		}
 	}
+	if p.ListDocuments && parentDirExists {
+		// this removes the list document if the resource was the last one of its kind, so that it doesn't keep the namespace dir
+		if err := p.updateListDocument(ctx, dirpath, gvk); err != nil {
+			return fmt.Errorf("error updating list document: %w", err)
+		}
+	}
 	if parentDirExists && parentDirIsNamespaceDir {
-		// check if namespace dir is now empty
+		// check if namespace dir is now empty, apart from the namespace's own metadata
 		contents, err := vfs.ReadDir(p.Fs, dirpath)
 		if err != nil {
 			return err
 		}
-		if len(contents) == 0 {
+		if len(contents) == 0 || p.containsOnlyNamespaceMetadata(ctx, contents, namespace, subPath) {
 			// namespace dir is empty, remove it
 			err := p.Fs.RemoveAll(dirpath)
 			if err != nil {
 				return err
 			}
+			if p.Layout == config.FILESYSTEM_LAYOUT_ARGOCD {
+				// remove the application for the namespace
+				appPath := p.joinRoot(subPath, argoCDIndexDir, p.withFileExtension(nsdir))
+				if err := p.Fs.Remove(appPath); err != nil && !vfs.IsErrNotExist(err) {
+					return fmt.Errorf("error removing argocd application for namespace directory '%s': %w", nsdir, err)
+				}
+			}
 		}
 	}
 	return nil
@@ -208,23 +368,93 @@ func (p *FileSystemPersister) InternalPersister() persist.Persister {
 // GetResourceFilepath returns the filepath under which the specified resource is stored and the namespace dir, if any.
 // The returned namespace dir is already part of the path returned as first argument.
 // If includeRootPath is false, the returned path is relative to the directory at p.RootPath. Otherwise, p.RootPath is contained in the returned path.
-func (p *FileSystemPersister) GetResourceFilepath(name, namespace string, gvk schema.GroupVersionKind, subPath string, includeRootPath bool) (string, string) {
-	prefixedNamespace := ""
-	if namespace != "" {
-		prefixedNamespace = fmt.Sprintf("%s%s", p.NamespacePrefix, namespace)
-	}
-	prefixedFileExtension := p.FileExtension
-	if p.FileExtension != "" && !strings.HasPrefix(p.FileExtension, ".") {
-		prefixedFileExtension = fmt.Sprintf(".%s", p.FileExtension)
-	}
+// For the 'argocd' layout, the returned namespace dir is the directory below 'applications'.
+// If long names are configured, the name part of the file name is shortened if the file name would be too long.
+// The third return value is true in this case, the file may then contain another resource with the same shortened name,
+// which has to be checked via CheckNameCollision before the file is used.
+// The computed path is logged with the logger from the given context.
+func (p *FileSystemPersister) GetResourceFilepath(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string, includeRootPath bool) (string, string, bool) {
+	var filepath, prefixedNamespace string
+	// names, namespaces, and subPaths are escaped and cleaned, so that the resulting path cannot point outside of the root path
+	name, namespace, subPath = escapePathSegment(name), escapePathSegment(namespace), p.cleanSubPath(subPath)
 	gvkString := utils.GVKToString(gvk, true)
-	filename := fmt.Sprintf("%s%s%s%s", gvkString, p.GVKNameSeparator, name, prefixedFileExtension)
-	filepath := vfs.Join(p.Fs, subPath, prefixedNamespace, filename)
+	nsPrefix, separator, extension := p.fileNaming(gvkString)
+	var shortened bool
+	if p.Layout == config.FILESYSTEM_LAYOUT_ARGOCD {
+		prefixedNamespace = argoCDNamespaceDir(namespace)
+		filePrefix := fmt.Sprintf("%s-", strings.ToLower(gvk.Kind))
+		name, shortened = p.shortenName(filePrefix, name, addFileExtension("", extension))
+		filename := addFileExtension(filePrefix+name, extension)
+		filepath = vfs.Join(p.Fs, subPath, argoCDApplicationsDir, prefixedNamespace, filename)
+	} else {
+		if namespace != "" {
+			prefixedNamespace = fmt.Sprintf("%s%s", nsPrefix, namespace)
+		}
+		filePrefix := fmt.Sprintf("%s%s", gvkString, separator)
+		name, shortened = p.shortenName(filePrefix, name, addFileExtension("", extension))
+		filename := addFileExtension(filePrefix+name, extension)
+		filepath = vfs.Join(p.Fs, subPath, prefixedNamespace, filename)
+	}
 	if includeRootPath {
 		filepath = vfs.Join(p.Fs, p.RootPath, filepath)
 	}
-	p.injectedLogger.Debug("Computed resource filepath", constants.Logging.KEY_PATH, filepath)
-	return filepath, prefixedNamespace
+	logging.FromContextOrDiscard(ctx).Debug("Computed resource filepath", constants.Logging.KEY_PATH, filepath)
+	return filepath, prefixedNamespace, shortened
+}
+
+// fileNaming returns the namespace prefix, the separator, and the file extension which are used for resources with the given GVK string.
+func (p *FileSystemPersister) fileNaming(gvkString string) (string, string, string) {
+	nsPrefix, separator, extension := p.NamespacePrefix, p.GVKNameSeparator, p.FileExtension
+	if override := p.KindOverrides[gvkString]; override != nil {
+		if override.NamespacePrefix != nil {
+			nsPrefix = *override.NamespacePrefix
+		}
+		if override.GVKNameSeparator != nil {
+			separator = *override.GVKNameSeparator
+		}
+		if override.FileExtension != nil {
+			extension = *override.FileExtension
+		}
+	}
+	return nsPrefix, separator, extension
+}
+
+// namespacePrefixes returns all namespace prefixes which are used, including the ones from the kind overrides.
+func (p *FileSystemPersister) namespacePrefixes() []string {
+	res := sets.New[string](p.NamespacePrefix)
+	for _, override := range p.KindOverrides {
+		if override != nil && override.NamespacePrefix != nil {
+			res.Insert(*override.NamespacePrefix)
+		}
+	}
+	return sets.List(res)
+}
+
+// fileExtensions returns all file extensions which are used, including the ones from the kind overrides.
+func (p *FileSystemPersister) fileExtensions() []string {
+	res := sets.New[string](p.FileExtension)
+	for _, override := range p.KindOverrides {
+		if override != nil && override.FileExtension != nil {
+			res.Insert(*override.FileExtension)
+		}
+	}
+	return sets.List(res)
+}
+
+// withFileExtension appends the configured file extension to the given file name.
+func (p *FileSystemPersister) withFileExtension(filename string) string {
+	return addFileExtension(filename, p.FileExtension)
+}
+
+// addFileExtension appends the given file extension to the given file name.
+func addFileExtension(filename, extension string) string {
+	if extension == "" {
+		return filename
+	}
+	if strings.HasPrefix(extension, ".") {
+		return filename + extension
+	}
+	return fmt.Sprintf("%s.%s", filename, extension)
 }
 
 // TryGetInternalFileSystemPersister tries to get the internal FileSystemPersister of the given Persister.
@@ -260,6 +490,21 @@ func ConvertToPersistence(obj *unstructured.Unstructured, t persist.Transformer)
 	return data, nil
 }
 
+// convertToPersistence serializes the given resource like ConvertToPersistence, but respects the configured serialization.
+func (p *FileSystemPersister) convertToPersistence(obj *unstructured.Unstructured, t persist.Transformer) ([]byte, error) {
+	if p.serializer == nil {
+		return ConvertToPersistence(obj, t)
+	}
+	if t != nil {
+		var err error
+		obj, err = t.Transform(obj)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p.serializer.serialize(obj)
+}
+
 // ConvertFromPersistence is the counterpart of ConvertToPersistence and converts a byte array back to a resource.
 // It basically calls yaml.Unmarshal on the given data.
 func ConvertFromPersistence(data []byte) (*unstructured.Unstructured, error) {
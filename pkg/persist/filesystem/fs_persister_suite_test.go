@@ -5,22 +5,33 @@
 package filesystem
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/gardener/landscaper/controller-utils/pkg/logging"
 	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
 	"github.com/gardener/k8syncer/pkg/persist/transformers"
 	"github.com/gardener/k8syncer/pkg/utils"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
 )
 
 func TestConfig(t *testing.T) {
@@ -72,8 +83,13 @@ var _ = Describe("Filesystem Persister Tests", func() {
 		fsp, err := New(fs, cfg, true)
 		Expect(err).ToNot(HaveOccurred())
 
+		By("getting a resource which has not been persisted")
+		missing, err := fsp.Get(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(missing).To(BeNil())
+
 		By("persisting a new resource")
-		persisted, changed, err := fsp.Persist(ctx, dummy, basicTransformer, subPath)
+		persisted, changed, err := fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
 		Expect(err).ToNot(HaveOccurred())
 		Expect(changed).To(BeTrue())
 
@@ -82,7 +98,7 @@ var _ = Describe("Filesystem Persister Tests", func() {
 
 		Expect(persisted).To(Equal(transformed))
 
-		dummyFile, _ := fsp.GetResourceFilepath(dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath, true)
+		dummyFile, _, _ := fsp.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath, true)
 
 		storedRaw, err := vfs.ReadFile(fs, dummyFile)
 		Expect(err).ToNot(HaveOccurred())
@@ -93,7 +109,7 @@ var _ = Describe("Filesystem Persister Tests", func() {
 		Expect(stored).To(Equal(transformed))
 
 		By("update an existing resource without any changes")
-		_, changed, err = fsp.Persist(ctx, dummy, basicTransformer, subPath)
+		_, changed, err = fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
 		Expect(err).ToNot(HaveOccurred())
 		Expect(changed).To(BeFalse())
 
@@ -107,7 +123,7 @@ var _ = Describe("Filesystem Persister Tests", func() {
 		transformed, err = basicTransformer.Transform(dummy)
 		Expect(err).ToNot(HaveOccurred())
 
-		persisted, changed, err = fsp.Persist(ctx, dummy, basicTransformer, subPath)
+		persisted, changed, err = fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
 		Expect(err).ToNot(HaveOccurred())
 		Expect(changed).To(BeTrue())
 
@@ -149,6 +165,10 @@ var _ = Describe("Filesystem Persister Tests", func() {
 		exists, err = fsp.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
 		Expect(err).ToNot(HaveOccurred())
 		Expect(exists).To(BeFalse())
+
+		missing, err = fsp.Get(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(missing).To(BeNil())
 	})
 
 	It("should correctly compute resource filepaths", func() {
@@ -160,29 +180,29 @@ var _ = Describe("Filesystem Persister Tests", func() {
 		gvk := dummy.GroupVersionKind()
 
 		By("default values, namespaced resource, empty subPath")
-		file, dir := fsp.GetResourceFilepath(name, namespace, gvk, subPath, true)
+		file, dir, _ := fsp.GetResourceFilepath(ctx, name, namespace, gvk, subPath, true)
 		Expect(dir).To(Equal(fmt.Sprintf("%s%s", *cfg.NamespacePrefix, namespace)))
 		Expect(file).To(Equal(vfs.Join(fs, cfg.RootPath, subPath, dir, fmt.Sprintf("%s%s%s.%s", utils.GVKToString(gvk, true), *cfg.GVKNameSeparator, name, *cfg.FileExtension))))
 
 		By("default values, non-namespaced, empty subPath")
-		file, dir = fsp.GetResourceFilepath(name, "", gvk, subPath, true)
+		file, dir, _ = fsp.GetResourceFilepath(ctx, name, "", gvk, subPath, true)
 		Expect(dir).To(BeEmpty())
 		Expect(vfs.Dir(fs, file)).To(Equal(vfs.Join(fs, cfg.RootPath, subPath)))
 
 		By("default values, namespaced resource, non-empty subPath")
 		subPath = "subPath"
-		file, dir = fsp.GetResourceFilepath(name, namespace, gvk, subPath, true)
+		file, dir, _ = fsp.GetResourceFilepath(ctx, name, namespace, gvk, subPath, true)
 		Expect(dir).To(Equal(fmt.Sprintf("%s%s", *cfg.NamespacePrefix, namespace)))
 		Expect(vfs.Dir(fs, file)).To(Equal(vfs.Join(fs, cfg.RootPath, subPath, dir)))
 
 		By("default values, namespaced resource, non-empty subPath, without root path")
 		subPath = "subPath"
-		file, dir = fsp.GetResourceFilepath(name, namespace, gvk, subPath, false)
+		file, dir, _ = fsp.GetResourceFilepath(ctx, name, namespace, gvk, subPath, false)
 		Expect(dir).To(Equal(fmt.Sprintf("%s%s", *cfg.NamespacePrefix, namespace)))
 		Expect(vfs.Dir(fs, file)).To(Equal(vfs.Join(fs, subPath, dir)))
 
 		By("default values, non-namespaced, non-empty subPath")
-		file, dir = fsp.GetResourceFilepath(name, "", gvk, subPath, true)
+		file, dir, _ = fsp.GetResourceFilepath(ctx, name, "", gvk, subPath, true)
 		Expect(dir).To(BeEmpty())
 		Expect(file).To(Equal(vfs.Join(fs, cfg.RootPath, subPath, fmt.Sprintf("%s%s%s.%s", utils.GVKToString(gvk, true), *cfg.GVKNameSeparator, name, *cfg.FileExtension))))
 
@@ -195,9 +215,884 @@ var _ = Describe("Filesystem Persister Tests", func() {
 			InMemory:         utils.Ptr(true),
 		}, true)
 		Expect(err).ToNot(HaveOccurred())
-		file, dir = fsp.GetResourceFilepath(name, namespace, gvk, subPath, true)
+		file, dir, _ = fsp.GetResourceFilepath(ctx, name, namespace, gvk, subPath, true)
 		Expect(dir).To(Equal(fmt.Sprintf("&%s", namespace)))
 		Expect(file).To(Equal(fmt.Sprintf("/my/root/path/%s/&%s/%s#%s.txt", subPath, namespace, utils.GVKToString(gvk, true), name)))
 	})
 
+	It("should not compute resource filepaths outside of the root path", func() {
+		fsp, err := New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		gvk := dummy.GroupVersionKind()
+		gvkString := utils.GVKToString(gvk, true)
+
+		file, dir, _ := fsp.GetResourceFilepath(ctx, "../../etc/passwd", "..", gvk, "../../other", true)
+		Expect(dir).To(Equal("ns_%2E%2E"))
+		Expect(file).To(Equal(fmt.Sprintf("/tmp/other/ns_%%2E%%2E/%s_..%%2F..%%2Fetc%%2Fpasswd.yaml", gvkString)))
+
+		file, dir, _ = fsp.GetResourceFilepath(ctx, "a\\b%2F", "", gvk, "/sub/./../sub", true)
+		Expect(dir).To(BeEmpty())
+		Expect(file).To(Equal(fmt.Sprintf("/tmp/sub/%s_a%%5Cb%%252F.yaml", gvkString)))
+
+		dummy.SetName("..")
+		_, _, err = fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "../..")
+		Expect(err).ToNot(HaveOccurred())
+		data, err := fsp.ReadTree(ctx, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(HaveKey(fmt.Sprintf("ns_bar/%s_%%2E%%2E.yaml", gvkString)))
+	})
+
+	It("should resolve the root path and refuse to follow symbolic links out of it", func() {
+		osFs := osfs.New()
+		tmp, err := vfs.Canonical(osFs, GinkgoT().TempDir(), true)
+		Expect(err).ToNot(HaveOccurred())
+		root := vfs.Join(osFs, tmp, "root")
+		outside := vfs.Join(osFs, tmp, "outside")
+		Expect(osFs.MkdirAll(vfs.Join(osFs, root, "data"), os.ModePerm)).To(Succeed())
+		Expect(osFs.MkdirAll(outside, os.ModePerm)).To(Succeed())
+		Expect(osFs.Symlink(vfs.Join(osFs, tmp, "root"), vfs.Join(osFs, tmp, "link"))).To(Succeed())
+		Expect(osFs.Symlink(outside, vfs.Join(osFs, root, "data", "escape"))).To(Succeed())
+		Expect(osFs.Symlink("data", vfs.Join(osFs, root, "inside"))).To(Succeed())
+
+		cfg.InMemory = utils.Ptr(false)
+		cfg.RootPath = vfs.Join(osFs, tmp, "link")
+		fsp, err := NewForOS(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fsp.RootPath).To(Equal(root))
+
+		_, _, err = fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "inside")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.FileExists(osFs, vfs.Join(osFs, root, "data", "ns_bar", fmt.Sprintf("%s_foo.yaml", utils.GVKToString(dummy.GroupVersionKind(), true))))).To(BeTrue())
+
+		_, _, err = fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "data/escape")
+		Expect(err).To(MatchError(ContainSubstring("outside of the root path")))
+		Expect(fsp.Delete(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "data/escape")).To(MatchError(ContainSubstring("outside of the root path")))
+		entries, err := vfs.ReadDir(osFs, outside)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	It("should retry operations which fail with transient errors", func() {
+		flaky := &flakyFileSystem{FileSystem: fs}
+		cfg.IO = &config.FileSystemIOConfiguration{
+			Retries:       2,
+			RetryInterval: &metav1.Duration{Duration: time.Millisecond},
+		}
+		fsp, err := New(flaky, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+
+		flaky.failures = 2
+		_, changed, err := fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+
+		By("failing if the retries are exhausted")
+		flaky.failures = 3
+		_, err = fsp.Get(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).To(MatchError(syscall.ESTALE))
+
+		By("not retrying if no retries are configured")
+		cfg.IO.Retries = 0
+		fsp, err = New(flaky, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		flaky.failures = 1
+		_, err = fsp.Get(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).To(MatchError(syscall.ESTALE))
+	})
+
+	It("should lock files while reading and writing them if configured", func() {
+		if !fileLockingSupported {
+			Skip("file locking is not supported on this operating system")
+		}
+		osFs := osfs.New()
+		cfg.InMemory = utils.Ptr(false)
+		cfg.RootPath = GinkgoT().TempDir()
+		cfg.IO = &config.FileSystemIOConfiguration{
+			Lock: true,
+		}
+		fsp, err := NewForOS(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		_, _, err = fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		filepath, _, _ := fsp.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath, true)
+		f, err := osFs.OpenFile(filepath, os.O_RDWR, os.ModePerm)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lockFile(f, true)).To(Succeed())
+
+		done := make(chan error)
+		go func() {
+			_, err := fsp.Get(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+			done <- err
+		}()
+		Consistently(done, 200*time.Millisecond).ShouldNot(Receive())
+		Expect(f.Close()).To(Succeed())
+		Eventually(done).Should(Receive(BeNil()))
+	})
+
+	It("should apply kind overrides to the file naming", func() {
+		cfg.KindOverrides = map[string]*config.FileNamingOverride{
+			"dummy.v1.k8syncer.gardener.cloud": {
+				NamespacePrefix: utils.Ptr(""),
+				FileExtension:   utils.Ptr(".json"),
+			},
+		}
+		fsp, err := New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		cm := &unstructured.Unstructured{}
+		cm.SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"})
+		cm.SetName("foo")
+		cm.SetNamespace(dummy.GetNamespace())
+
+		file, dir, _ := fsp.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath, true)
+		Expect(dir).To(Equal("bar"))
+		Expect(file).To(Equal("/tmp/bar/dummy.v1.k8syncer.gardener.cloud_foo.json"))
+		file, dir, _ = fsp.GetResourceFilepath(ctx, cm.GetName(), cm.GetNamespace(), cm.GroupVersionKind(), subPath, true)
+		Expect(dir).To(Equal("ns_bar"))
+		Expect(file).To(Equal("/tmp/ns_bar/configmap.v1_foo.yaml"))
+
+		for _, obj := range []*unstructured.Unstructured{dummy, cm} {
+			_, _, err := fsp.Persist(ctx, obj, basicTransformer, obj.GetName(), subPath)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		tree, err := fsp.ReadTree(ctx, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tree).To(HaveKey("bar/dummy.v1.k8syncer.gardener.cloud_foo.json"))
+		Expect(tree).To(HaveKey("ns_bar/configmap.v1_foo.yaml"))
+
+		By("pruning all namespace directories of the namespace")
+		pruned, err := fsp.PruneNamespace(ctx, dummy.GetNamespace(), subPath, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pruned).To(BeTrue())
+		tree, err = fsp.ReadTree(ctx, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tree).To(BeEmpty())
+	})
+
+	It("should use the argocd layout and maintain the application index", func() {
+		cfg.Layout = config.FILESYSTEM_LAYOUT_ARGOCD
+		cfg.ArgoCD = &config.ArgoCDLayoutConfiguration{
+			RepoURL:             "https://example.com/archive.git",
+			TargetRevision:      "main",
+			RootApplicationName: "k8syncer",
+			Namespace:           "argocd",
+			Project:             "default",
+			DestinationServer:   "https://kubernetes.default.svc",
+		}
+		fsp, err := New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		subPath = "archive"
+
+		file, dir, _ := fsp.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath, true)
+		Expect(dir).To(Equal(dummy.GetNamespace()))
+		Expect(file).To(Equal("/tmp/archive/applications/bar/dummy-foo.yaml"))
+		_, dir, _ = fsp.GetResourceFilepath(ctx, dummy.GetName(), "", dummy.GroupVersionKind(), subPath, true)
+		Expect(dir).To(Equal("_cluster"))
+
+		_, changed, err := fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+
+		rootRaw, err := vfs.ReadFile(fs, "/tmp/archive/k8syncer.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		root, err := ConvertFromPersistence(rootRaw)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(root.GetKind()).To(Equal("Application"))
+		Expect(nestedString(root, "spec", "source", "path")).To(Equal("archive/apps"))
+
+		appRaw, err := vfs.ReadFile(fs, "/tmp/archive/apps/bar.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		app, err := ConvertFromPersistence(appRaw)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(app.GetName()).To(Equal("k8syncer-ns-bar"))
+		Expect(nestedString(app, "spec", "source", "path")).To(Equal("archive/applications/bar"))
+		Expect(nestedString(app, "spec", "source", "repoURL")).To(Equal("https://example.com/archive.git"))
+		Expect(nestedString(app, "spec", "destination", "namespace")).To(Equal("bar"))
+
+		// deleting the last resource in the namespace removes the namespace's application
+		Expect(fsp.Delete(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)).To(Succeed())
+		exists, err := vfs.Exists(fs, "/tmp/archive/apps/bar.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+	})
+
+	It("should list and delete all persisted resources of a kind", func() {
+		fsp, err := New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		subPath = "data"
+		gvk := dummy.GroupVersionKind()
+
+		persistAs := func(name, namespace string, gvk schema.GroupVersionKind) {
+			obj := dummy.DeepCopy()
+			obj.SetName(name)
+			obj.SetNamespace(namespace)
+			obj.SetGroupVersionKind(gvk)
+			_, _, err := fsp.Persist(ctx, obj, basicTransformer, name, subPath)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		persistAs("foo", "bar", gvk)
+		persistAs("a/b", "baz", gvk)
+		persistAs("cluster", "", gvk)
+		otherGVK := schema.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: "Other"}
+		persistAs("foo", "bar", otherGVK)
+
+		resources, err := persist.List(ctx, fsp, gvk, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(ConsistOf(
+			persist.PersistedResource{Name: "foo", Namespace: "bar"},
+			persist.PersistedResource{Name: "a/b", Namespace: "baz"},
+			persist.PersistedResource{Name: "cluster"},
+		))
+		resources, err = persist.List(ctx, fsp, gvk, "other")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(BeEmpty())
+
+		By("deleting the resources of a single namespace")
+		Expect(persist.DeleteAll(ctx, fsp, "bar", gvk, subPath)).To(Succeed())
+		resources, err = persist.List(ctx, fsp, gvk, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(ConsistOf(
+			persist.PersistedResource{Name: "a/b", Namespace: "baz"},
+			persist.PersistedResource{Name: "cluster"},
+		))
+		exists, err := fsp.Exists(ctx, "foo", "bar", otherGVK, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+
+		By("deleting the resources of all namespaces")
+		Expect(persist.DeleteAll(ctx, fsp, "", gvk, subPath)).To(Succeed())
+		resources, err = persist.List(ctx, fsp, gvk, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(BeEmpty())
+		exists, err = vfs.DirExists(fs, "/tmp/data/ns_baz")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+
+		By("listing resources with the argocd layout")
+		cfg.Layout = config.FILESYSTEM_LAYOUT_ARGOCD
+		cfg.ArgoCD = &config.ArgoCDLayoutConfiguration{
+			RootApplicationName: "k8syncer",
+		}
+		fsp, err = New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		persistAs("foo", "bar", gvk)
+		persistAs("cluster", "", gvk)
+		resources, err = persist.List(ctx, fsp, gvk, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(ConsistOf(
+			persist.PersistedResource{Name: "foo", Namespace: "bar"},
+			persist.PersistedResource{Name: "cluster"},
+		))
+	})
+
+	It("should stamp persisted resources with the writer identity and count overwrites of foreign writers", func() {
+		fsp, err := New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		metric := persist.WriterIdentityMetrics()
+
+		p := persist.AddWriterIdentityLayer(fsp, "test", persist.WriterIdentity{Instance: "k8syncer-0", ConfigHash: "abc"})
+		_, changed, err := p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		persisted, err := fsp.Get(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persisted.GetAnnotations()).To(HaveKeyWithValue(constants.ANNOTATION_WRITER_INSTANCE, "k8syncer-0"))
+		Expect(persisted.GetAnnotations()).To(HaveKeyWithValue(constants.ANNOTATION_WRITER_CONFIG_HASH, "abc"))
+		Expect(dummy.GetAnnotations()).ToNot(HaveKey(constants.ANNOTATION_WRITER_INSTANCE))
+
+		By("persisting the resource again with the same identity")
+		_, changed, err = p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeFalse())
+		Expect(testutil.CollectAndCount(metric)).To(Equal(0))
+
+		By("persisting the resource with another identity")
+		p = persist.AddWriterIdentityLayer(fsp, "test", persist.WriterIdentity{Instance: "k8syncer-1", ConfigHash: "abc"})
+		_, changed, err = p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		Expect(testutil.ToFloat64(metric)).To(Equal(float64(1)))
+		persisted, err = fsp.Get(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persisted.GetAnnotations()).To(HaveKeyWithValue(constants.ANNOTATION_WRITER_INSTANCE, "k8syncer-1"))
+	})
+
+	It("should prune the data of a namespace", func() {
+		fsp, err := New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		subPath = "data"
+
+		_, _, err = fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		By("pruning a namespace without any data")
+		pruned, err := fsp.PruneNamespace(ctx, "other", subPath, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pruned).To(BeFalse())
+
+		By("archiving the namespace directory")
+		pruned, err = fsp.PruneNamespace(ctx, dummy.GetNamespace(), subPath, "archive")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pruned).To(BeTrue())
+		exists, err := vfs.DirExists(fs, "/tmp/data/ns_bar")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+		archived, err := vfs.ReadDir(fs, "/tmp/archive/data")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(archived).To(HaveLen(1))
+		Expect(archived[0].Name()).To(HavePrefix("ns_bar_"))
+		dummyFile, _, _ := fsp.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "", false)
+		exists, err = vfs.FileExists(fs, vfs.Join(fs, "/tmp/archive/data", archived[0].Name(), vfs.Base(fs, dummyFile)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+
+		By("deleting the namespace directory")
+		_, _, err = fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		pruned, err = fsp.PruneNamespace(ctx, dummy.GetNamespace(), subPath, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pruned).To(BeTrue())
+		exists, err = vfs.DirExists(fs, "/tmp/data/ns_bar")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+	})
+
+	It("should prune the data of a subPath", func() {
+		fsp, err := New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, _, err = fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "removed/data")
+		Expect(err).ToNot(HaveOccurred())
+		_, _, err = fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "kept")
+		Expect(err).ToNot(HaveOccurred())
+
+		By("pruning a subPath without any data")
+		pruned, err := fsp.PruneSubPath(ctx, "other")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pruned).To(BeFalse())
+
+		By("pruning a subPath with data")
+		pruned, err = fsp.PruneSubPath(ctx, "removed")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pruned).To(BeTrue())
+		exists, err := vfs.DirExists(fs, "/tmp/removed")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+		stored, err := fsp.Get(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "kept")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stored).ToNot(BeNil())
+
+		By("refusing to prune the root directory")
+		_, err = fsp.PruneSubPath(ctx, "")
+		Expect(err).To(HaveOccurred())
+		_, err = fsp.PruneSubPath(ctx, "kept/..")
+		Expect(err).To(HaveOccurred())
+		_, err = fsp.PruneSubPath(ctx, "/")
+		Expect(err).To(HaveOccurred())
+		exists, err = vfs.DirExists(fs, "/tmp/kept")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+	})
+
+	It("should answer from the cache until the namespace is pruned", func() {
+		fsp, err := New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		p := persist.AddCachingLayer(fsp, 0)
+		dummyFile, _, _ := fsp.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath, true)
+
+		_, changed, err := p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+
+		// changes which bypass the persister are not noticed
+		Expect(fs.Remove(dummyFile)).To(Succeed())
+		exists, err := p.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+		_, changed, err = p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeFalse())
+
+		// changed resources are persisted
+		modified := dummy.DeepCopy()
+		Expect(unstructured.SetNestedField(modified.Object, "modified", "spec", "value")).To(Succeed())
+		_, changed, err = p.Persist(ctx, modified, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		Expect(vfs.FileExists(fs, dummyFile)).To(BeTrue())
+
+		// pruning the namespace flushes the cache
+		Expect(fs.Remove(dummyFile)).To(Succeed())
+		_, err = fsp.PruneNamespace(ctx, dummy.GetNamespace(), subPath, "")
+		Expect(err).ToNot(HaveOccurred())
+		_, changed, err = p.Persist(ctx, modified, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+
+		// deleted resources are known not to exist
+		Expect(p.Delete(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)).To(Succeed())
+		Expect(fs.MkdirAll(vfs.Dir(fs, dummyFile), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(fs, dummyFile, []byte("foo"), os.ModePerm)).To(Succeed())
+		exists, err = p.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+	})
+
+	It("should handle corrupt data according to the configured policy", func() {
+		By("failing for policy 'error'")
+		cfg.OnCorruptData = config.CORRUPT_DATA_POLICY_ERROR
+		fsp, err := New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		corruptData := []byte("foo: [bar")
+		filepath, _, _ := fsp.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath, true)
+		writeCorruptData := func() {
+			Expect(fs.MkdirAll(vfs.Dir(fs, filepath), os.ModePerm)).To(Succeed())
+			Expect(vfs.WriteFile(fs, filepath, corruptData, os.ModePerm)).To(Succeed())
+		}
+		writeCorruptData()
+		_, err = fsp.Get(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		var corruptErr *CorruptDataError
+		Expect(errors.As(err, &corruptErr)).To(BeTrue())
+		Expect(corruptErr.Path).To(Equal(filepath))
+		_, _, err = fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(errors.As(err, &corruptErr)).To(BeTrue())
+		Expect(vfs.ReadFile(fs, filepath)).To(Equal(corruptData))
+
+		By("overwriting the data for policy 'overwrite'")
+		cfg.OnCorruptData = config.CORRUPT_DATA_POLICY_OVERWRITE
+		fsp, err = New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		res, err := fsp.Get(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).To(BeNil())
+		_, changed, err := fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		res, err = fsp.Get(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nestedString(res, "spec", "value")).To(Equal(nestedString(dummy, "spec", "value")))
+		Expect(vfs.DirExists(fs, vfs.Join(fs, cfg.RootPath, quarantineDir))).To(BeFalse())
+
+		By("moving the data to quarantine for policy 'quarantine'")
+		cfg.OnCorruptData = config.CORRUPT_DATA_POLICY_QUARANTINE
+		fsp, err = New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		writeCorruptData()
+		_, changed, err = fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		res, err = fsp.Get(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nestedString(res, "spec", "value")).To(Equal(nestedString(dummy, "spec", "value")))
+		quarantinedDir := vfs.Join(fs, cfg.RootPath, quarantineDir, vfs.Base(fs, vfs.Dir(fs, filepath)))
+		quarantined, err := vfs.ReadDir(fs, quarantinedDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(quarantined).To(HaveLen(1))
+		Expect(quarantined[0].Name()).To(HavePrefix(vfs.Base(fs, filepath) + "."))
+		Expect(vfs.ReadFile(fs, vfs.Join(fs, quarantinedDir, quarantined[0].Name()))).To(Equal(corruptData))
+	})
+
+	It("should persist and delete sidecar documents", func() {
+		fsp, err := New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		gvk := dummy.GroupVersionKind()
+		_, _, err = fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		filepath, _, _ := fsp.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), gvk, subPath, true)
+
+		By("persisting a new sidecar document")
+		changed, err := fsp.PersistSidecar(ctx, []byte("owners: []\n"), "owners", dummy.GetName(), dummy.GetNamespace(), gvk, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		data, err := vfs.ReadFile(fs, filepath+".owners")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("owners: []\n"))
+
+		By("persisting an unchanged sidecar document")
+		changed, err = fsp.PersistSidecar(ctx, []byte("owners: []\n"), "owners", dummy.GetName(), dummy.GetNamespace(), gvk, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeFalse())
+
+		By("verifying that the sidecar document is not read as part of the tree")
+		tree, err := fsp.ReadTree(ctx, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tree).To(HaveLen(1))
+
+		By("deleting the sidecar document")
+		Expect(fsp.DeleteSidecar(ctx, "owners", dummy.GetName(), dummy.GetNamespace(), gvk, subPath)).To(Succeed())
+		exists, err := vfs.FileExists(fs, filepath+".owners")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+		Expect(fsp.DeleteSidecar(ctx, "owners", dummy.GetName(), dummy.GetNamespace(), gvk, subPath)).To(Succeed())
+	})
+
+	It("should append to artifacts without including them in the tree", func() {
+		fsp, err := New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		subPath = "foo/bar"
+
+		Expect(fsp.AppendArtifact(ctx, []byte("{\"a\":1}\n"), "changes.ndjson", subPath)).To(Succeed())
+		Expect(fsp.AppendArtifact(ctx, []byte("{\"a\":2}\n"), "changes.ndjson", subPath)).To(Succeed())
+		data, err := vfs.ReadFile(fs, vfs.Join(fs, cfg.RootPath, subPath, "changes.ndjson"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("{\"a\":1}\n{\"a\":2}\n"))
+
+		tree, err := fsp.ReadTree(ctx, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tree).To(BeEmpty())
+
+		By("verifying that the file cannot be written outside of the root path")
+		Expect(fsp.AppendArtifact(ctx, []byte("x"), "../../../changes.ndjson", subPath)).To(Succeed())
+		exists, err := vfs.FileExists(fs, vfs.Join(fs, cfg.RootPath, "changes.ndjson"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+	})
+
+	It("should read and write the data of resources as streams", func() {
+		fsp, err := New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		sp, ok := persist.FindStreamPersister(persist.AddLoggingLayer(fsp, logging.DEBUG))
+		Expect(ok).To(BeTrue())
+
+		By("getting the stream of a missing resource")
+		r, err := sp.GetStream(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(r).To(BeNil())
+
+		By("persisting a resource from a stream")
+		data, err := ConvertToPersistence(dummy, basicTransformer)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sp.PersistStream(ctx, bytes.NewReader(data), dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)).To(Succeed())
+		stored, err := fsp.Get(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		transformed, err := basicTransformer.Transform(dummy)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stored).To(Equal(transformed))
+
+		By("getting the stream of the persisted resource")
+		r, err = sp.GetStream(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		streamed, err := io.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(r.Close()).To(Succeed())
+		Expect(streamed).To(Equal(data))
+
+		By("replacing the data with a shorter stream")
+		Expect(sp.PersistStream(ctx, strings.NewReader("{}\n"), dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)).To(Succeed())
+		dummyFile, _, _ := fsp.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath, true)
+		storedRaw, err := vfs.ReadFile(fs, dummyFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(storedRaw)).To(Equal("{}\n"))
+	})
+
+	It("should persist the namespace next to its resources", func() {
+		fsp, err := New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		_, _, err = fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		ns := &unstructured.Unstructured{}
+		ns.SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"})
+		ns.SetName(dummy.GetNamespace())
+		ns.SetLabels(map[string]string{"policy": "restricted"})
+		ns.SetResourceVersion("1")
+
+		By("persisting the namespace")
+		changed, err := fsp.PersistNamespaceMetadata(ctx, ns, basicTransformer, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		data, err := vfs.ReadFile(fs, "/tmp/ns_bar/namespace.v1_bar.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		persisted, err := ConvertFromPersistence(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persisted.GetLabels()).To(HaveKeyWithValue("policy", "restricted"))
+		Expect(persisted.GetResourceVersion()).To(BeEmpty())
+
+		By("persisting an unchanged namespace")
+		changed, err = fsp.PersistNamespaceMetadata(ctx, ns, basicTransformer, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeFalse())
+
+		By("verifying that the namespace is read as part of the tree")
+		tree, err := fsp.ReadTree(ctx, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tree).To(HaveLen(2))
+		Expect(tree).To(HaveKey("ns_bar/namespace.v1_bar.yaml"))
+
+		By("deleting the last resource of the namespace")
+		Expect(fsp.Delete(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)).To(Succeed())
+		exists, err := vfs.DirExists(fs, "/tmp/ns_bar")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+
+		By("rejecting objects which are not namespaces")
+		_, err = fsp.PersistNamespaceMetadata(ctx, dummy, basicTransformer, subPath)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should maintain a list document per kind and namespace", func() {
+		cfg.ListDocuments = true
+		fsp, err := New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		listPath := "/tmp/ns_bar/_list.dummy.v1.k8syncer.gardener.cloud.yaml"
+		readList := func() []string {
+			data, err := vfs.ReadFile(fs, listPath)
+			Expect(err).ToNot(HaveOccurred())
+			list, err := ConvertFromPersistence(data)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(list.GetAPIVersion()).To(Equal("v1"))
+			Expect(list.GetKind()).To(Equal("List"))
+			items, _, err := unstructured.NestedSlice(list.Object, "items")
+			Expect(err).ToNot(HaveOccurred())
+			names := []string{}
+			for _, item := range items {
+				names = append(names, (&unstructured.Unstructured{Object: item.(map[string]any)}).GetName())
+			}
+			return names
+		}
+
+		By("persisting resources of the same kind")
+		other := dummy.DeepCopy()
+		other.SetName("baz")
+		_, _, err = fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		_, _, err = fsp.Persist(ctx, other, basicTransformer, other.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readList()).To(Equal([]string{"baz", "foo"}))
+
+		By("updating the list document when a resource changes")
+		Expect(unstructured.SetNestedField(dummy.Object, "changed", "spec", "value")).To(Succeed())
+		_, _, err = fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		data, err := vfs.ReadFile(fs, listPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("value: changed"))
+
+		By("verifying that the list document is neither read as part of the tree nor listed")
+		tree, err := fsp.ReadTree(ctx, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tree).To(HaveLen(2))
+		resources, err := fsp.List(ctx, dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(HaveLen(2))
+
+		By("deleting the resources")
+		Expect(fsp.Delete(ctx, other.GetName(), other.GetNamespace(), other.GroupVersionKind(), subPath)).To(Succeed())
+		Expect(readList()).To(Equal([]string{"foo"}))
+		Expect(fsp.Delete(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)).To(Succeed())
+		exists, err := vfs.DirExists(fs, "/tmp/ns_bar")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+	})
+
+	It("should prepend the front matter to persisted files without rewriting them for metadata changes only", func() {
+		cfg.FrontMatter = true
+		fsp, err := New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		t := transformers.NewBasic()
+		t.InjectedAnnotations = map[string]string{constants.ANNOTATION_CLUSTER_NAME: "my-cluster"}
+		dummy.SetResourceVersion("42")
+		dummy.SetGeneration(3)
+		filepath, _, _ := fsp.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath, true)
+
+		By("persisting the resource with front matter")
+		persisted, changed, err := fsp.Persist(ctx, dummy, t, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		data, err := vfs.ReadFile(fs, filepath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(HavePrefix("# k8syncer.syncTime: "))
+		Expect(string(data)).To(ContainSubstring("# k8syncer.sourceCluster: my-cluster\n"))
+		Expect(string(data)).To(ContainSubstring("# k8syncer.version: "))
+		Expect(string(data)).To(ContainSubstring("# k8syncer.resourceVersion: 42\n# k8syncer.generation: 3\napiVersion: "))
+		res, err := fsp.Get(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).To(Equal(persisted))
+
+		By("not rewriting the file if only the sync metadata changed")
+		dummy.SetResourceVersion("43")
+		_, changed, err = fsp.Persist(ctx, dummy, t, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeFalse())
+		differs, err := fsp.Differs(ctx, dummy, t, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(differs).To(BeFalse())
+		unchanged, err := vfs.ReadFile(fs, filepath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(unchanged).To(Equal(data))
+
+		By("updating the front matter when the content changes")
+		Expect(unstructured.SetNestedField(dummy.Object, "changed", "spec", "value")).To(Succeed())
+		_, changed, err = fsp.Persist(ctx, dummy, t, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		data, err = vfs.ReadFile(fs, filepath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("# k8syncer.resourceVersion: 43\n"))
+
+		By("removing the front matter when it is disabled")
+		cfg.FrontMatter = false
+		fsp, err = New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		_, changed, err = fsp.Persist(ctx, dummy, t, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		data, err = vfs.ReadFile(fs, filepath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(HavePrefix("apiVersion: "))
+	})
+
+	It("should serialize resources canonically and in the configured field order", func() {
+		Expect(unstructured.SetNestedField(dummy.Object, "2024-03-01T10:15:30.123456+01:00", "spec", "startTime")).To(Succeed())
+		Expect(unstructured.SetNestedStringMap(dummy.Object, map[string]string{"cpu": "1000m", "memory": "1024Mi"}, "spec", "resources", "requests")).To(Succeed())
+
+		By("serializing resources as-is if no serialization is configured")
+		fsp, err := New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		expected, err := ConvertToPersistence(dummy, nil)
+		Expect(err).ToNot(HaveOccurred())
+		data, err := fsp.convertToPersistence(dummy, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(expected))
+
+		By("keeping the output identical if the serialization doesn't change anything")
+		cfg.Serialization = &config.SerializationConfiguration{}
+		fsp, err = New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		data, err = fsp.convertToPersistence(dummy, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(expected))
+
+		By("normalizing values and ordering fields")
+		cfg.Serialization = &config.SerializationConfiguration{
+			Canonical:  true,
+			FieldOrder: []string{"apiVersion", "kind", "metadata", "name", "namespace"},
+		}
+		fsp, err = New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		_, _, err = fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		filepath, _, _ := fsp.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath, true)
+		data, err = vfs.ReadFile(fs, filepath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(HavePrefix("apiVersion: k8syncer.gardener.cloud/v1\nkind: Dummy\nmetadata:\n  name: foo\n  namespace: bar\n"))
+		persisted, err := ConvertFromPersistence(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nestedString(persisted, "spec", "startTime")).To(Equal("2024-03-01T09:15:30Z"))
+		Expect(nestedString(persisted, "spec", "resources", "requests", "cpu")).To(Equal("1"))
+		Expect(nestedString(persisted, "spec", "resources", "requests", "memory")).To(Equal("1Gi"))
+		Expect(nestedString(persisted, "spec", "value")).To(Equal(nestedString(dummy, "spec", "value")))
+
+		By("not changing the file for semantically equal resources")
+		Expect(unstructured.SetNestedField(dummy.Object, "2024-03-01T09:15:30Z", "spec", "startTime")).To(Succeed())
+		Expect(unstructured.SetNestedStringMap(dummy.Object, map[string]string{"cpu": "1", "memory": "1Gi"}, "spec", "resources", "requests")).To(Succeed())
+		_, changed, err := fsp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeFalse())
+
+		By("ordering the top-level fields separately")
+		cfg.Serialization = &config.SerializationConfiguration{
+			FieldOrder:         []string{"name", "kind"},
+			TopLevelFieldOrder: []string{"apiVersion", "kind", "metadata", "spec"},
+		}
+		fsp, err = New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		obj := dummy.DeepCopy()
+		Expect(unstructured.SetNestedField(obj.Object, "value", "data", "foo")).To(Succeed())
+		Expect(unstructured.SetNestedField(obj.Object, "Nested", "spec", "dependency", "kind")).To(Succeed())
+		Expect(unstructured.SetNestedField(obj.Object, "nested", "spec", "dependency", "name")).To(Succeed())
+		data, err = fsp.convertToPersistence(obj, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(HavePrefix("apiVersion: k8syncer.gardener.cloud/v1\nkind: Dummy\nmetadata:\n  name: foo\n"))
+		Expect(string(data)).To(ContainSubstring("\n  dependency:\n    name: nested\n    kind: Nested\n"))
+		Expect(strings.Index(string(data), "\nspec:")).To(BeNumerically("<", strings.Index(string(data), "\ndata:")))
+	})
+
+	It("should shorten long file names and detect collisions", func() {
+		cfg.LongNames = &config.LongNamesConfiguration{MaxLength: 100, Strategy: config.LONG_NAME_STRATEGY_TRUNCATE}
+		fsp, err := New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		gvk := dummy.GroupVersionKind()
+		longName := strings.Repeat("a", 120)
+
+		By("keeping short names")
+		path, _, shortened := fsp.GetResourceFilepath(ctx, "foo", "bar", gvk, subPath, false)
+		Expect(path).To(Equal("ns_bar/dummy.v1.k8syncer.gardener.cloud_foo.yaml"))
+		Expect(shortened).To(BeFalse())
+
+		By("truncating long names")
+		path, _, shortened = fsp.GetResourceFilepath(ctx, longName, "bar", gvk, subPath, false)
+		Expect(shortened).To(BeTrue())
+		filename := vfs.Base(fs, path)
+		Expect(filename).To(HaveLen(100))
+		Expect(filename).To(MatchRegexp(`^dummy\.v1\.k8syncer\.gardener\.cloud_a+~[0-9a-f]{8}\.yaml$`))
+		otherPath, _, _ := fsp.GetResourceFilepath(ctx, longName+"b", "bar", gvk, subPath, false)
+		Expect(otherPath).ToNot(Equal(path))
+
+		By("not splitting escape sequences")
+		escapedName := strings.Repeat("a", 52) + "/" + strings.Repeat("a", 60)
+		path, _, _ = fsp.GetResourceFilepath(ctx, escapedName, "bar", gvk, subPath, false)
+		Expect(vfs.Base(fs, path)).To(MatchRegexp(`_a{52}~[0-9a-f]{8}\.yaml$`))
+
+		By("persisting, reading, and listing resources with long names")
+		obj := dummy.DeepCopy()
+		obj.SetName(longName)
+		_, changed, err := fsp.Persist(ctx, obj, basicTransformer, longName, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		persisted, err := fsp.Get(ctx, longName, "bar", gvk, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persisted.GetName()).To(Equal(longName))
+		resources, err := persist.List(ctx, fsp, gvk, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(ConsistOf(persist.PersistedResource{Name: longName, Namespace: "bar"}))
+
+		By("detecting collisions of shortened names")
+		path, _, _ = fsp.GetResourceFilepath(ctx, longName, "bar", gvk, subPath, true)
+		data, err := vfs.ReadFile(fs, path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(fs, path, bytes.Replace(data, []byte(longName), []byte(longName[1:]+"c"), 1), os.ModePerm)).To(Succeed())
+		_, _, err = fsp.Persist(ctx, obj, basicTransformer, longName, subPath)
+		var collisionErr *NameCollisionError
+		Expect(errors.As(err, &collisionErr)).To(BeTrue())
+		_, err = fsp.Get(ctx, longName, "bar", gvk, subPath)
+		Expect(errors.As(err, &collisionErr)).To(BeTrue())
+		Expect(errors.As(fsp.Delete(ctx, longName, "bar", gvk, subPath), &collisionErr)).To(BeTrue())
+		_, err = fsp.PersistSidecar(ctx, []byte("owners"), "owners", longName, "bar", gvk, subPath)
+		Expect(errors.As(err, &collisionErr)).To(BeTrue())
+		Expect(errors.As(fsp.DeleteSidecar(ctx, "owners", longName, "bar", gvk, subPath), &collisionErr)).To(BeTrue())
+		exists, err := vfs.FileExists(fs, path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+
+		By("replacing long names with a hash")
+		cfg.LongNames.Strategy = config.LONG_NAME_STRATEGY_HASH
+		fsp, err = New(fs, cfg, true)
+		Expect(err).ToNot(HaveOccurred())
+		path, _, _ = fsp.GetResourceFilepath(ctx, longName, "bar", gvk, subPath, false)
+		Expect(vfs.Base(fs, path)).To(MatchRegexp(`^dummy\.v1\.k8syncer\.gardener\.cloud_~[0-9a-f]{32}\.yaml$`))
+	})
 })
+
+func nestedString(obj *unstructured.Unstructured, fields ...string) string {
+	val, _, err := unstructured.NestedString(obj.Object, fields...)
+	Expect(err).ToNot(HaveOccurred())
+	return val
+}
+
+// flakyFileSystem fails opening files with ESTALE as long as failures is greater than zero, decrementing it with each failure.
+type flakyFileSystem struct {
+	vfs.FileSystem
+	failures int
+}
+
+func (f *flakyFileSystem) OpenFile(name string, flags int, perm vfs.FileMode) (vfs.File, error) {
+	if f.failures > 0 {
+		f.failures--
+		return nil, &os.PathError{Op: "open", Path: name, Err: syscall.ESTALE}
+	}
+	return f.FileSystem.OpenFile(name, flags, perm)
+}
+
+func (f *flakyFileSystem) Open(name string) (vfs.File, error) {
+	return f.OpenFile(name, os.O_RDONLY, 0)
+}
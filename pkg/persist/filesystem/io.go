@@ -0,0 +1,211 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+var _ vfs.FileSystem = &retryingFileSystem{}
+
+// retryingFileSystem is a wrapper for a vfs.FileSystem which retries operations that fail with a transient error.
+// Only operations which are identified by a path are retried, reading from and writing to opened files is not.
+type retryingFileSystem struct {
+	vfs.FileSystem
+	retries  int
+	interval time.Duration
+}
+
+// withRetries wraps the given filesystem, so that operations are retried up to the given number of times, with exponential backoff starting at the given interval.
+func withRetries(fs vfs.FileSystem, retries int, interval time.Duration) vfs.FileSystem {
+	return &retryingFileSystem{
+		FileSystem: fs,
+		retries:    retries,
+		interval:   interval,
+	}
+}
+
+// isTransientIOError returns true for errors which network filesystems return temporarily, e.g. while a file is in use on the server or after a failover.
+func isTransientIOError(err error) bool {
+	return errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.ESTALE) || errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR)
+}
+
+// retryIO calls f until it doesn't return a transient error anymore or the retries of the filesystem are exhausted.
+func retryIO[T any](rfs *retryingFileSystem, f func() (T, error)) (T, error) {
+	res, err := f()
+	backoff := rfs.interval
+	for i := 0; i < rfs.retries && isTransientIOError(err); i++ {
+		time.Sleep(backoff)
+		backoff *= 2
+		res, err = f()
+	}
+	return res, err
+}
+
+// retryIOErr is retryIO for functions which only return an error.
+func retryIOErr(rfs *retryingFileSystem, f func() error) error {
+	_, err := retryIO(rfs, func() (struct{}, error) {
+		return struct{}{}, f()
+	})
+	return err
+}
+
+func (rfs *retryingFileSystem) Create(name string) (vfs.File, error) {
+	return retryIO(rfs, func() (vfs.File, error) { return rfs.FileSystem.Create(name) })
+}
+
+func (rfs *retryingFileSystem) Mkdir(name string, perm vfs.FileMode) error {
+	return retryIOErr(rfs, func() error { return rfs.FileSystem.Mkdir(name, perm) })
+}
+
+func (rfs *retryingFileSystem) MkdirAll(path string, perm vfs.FileMode) error {
+	return retryIOErr(rfs, func() error { return rfs.FileSystem.MkdirAll(path, perm) })
+}
+
+func (rfs *retryingFileSystem) Open(name string) (vfs.File, error) {
+	return retryIO(rfs, func() (vfs.File, error) { return rfs.FileSystem.Open(name) })
+}
+
+func (rfs *retryingFileSystem) OpenFile(name string, flags int, perm vfs.FileMode) (vfs.File, error) {
+	return retryIO(rfs, func() (vfs.File, error) { return rfs.FileSystem.OpenFile(name, flags, perm) })
+}
+
+func (rfs *retryingFileSystem) Remove(name string) error {
+	return retryIOErr(rfs, func() error { return rfs.FileSystem.Remove(name) })
+}
+
+func (rfs *retryingFileSystem) RemoveAll(path string) error {
+	return retryIOErr(rfs, func() error { return rfs.FileSystem.RemoveAll(path) })
+}
+
+func (rfs *retryingFileSystem) Rename(oldname, newname string) error {
+	return retryIOErr(rfs, func() error { return rfs.FileSystem.Rename(oldname, newname) })
+}
+
+func (rfs *retryingFileSystem) Stat(name string) (vfs.FileInfo, error) {
+	return retryIO(rfs, func() (vfs.FileInfo, error) { return rfs.FileSystem.Stat(name) })
+}
+
+func (rfs *retryingFileSystem) Lstat(name string) (vfs.FileInfo, error) {
+	return retryIO(rfs, func() (vfs.FileInfo, error) { return rfs.FileSystem.Lstat(name) })
+}
+
+func (rfs *retryingFileSystem) Readlink(name string) (string, error) {
+	return retryIO(rfs, func() (string, error) { return rfs.FileSystem.Readlink(name) })
+}
+
+func (rfs *retryingFileSystem) Chmod(name string, mode vfs.FileMode) error {
+	return retryIOErr(rfs, func() error { return rfs.FileSystem.Chmod(name, mode) })
+}
+
+func (rfs *retryingFileSystem) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return retryIOErr(rfs, func() error { return rfs.FileSystem.Chtimes(name, atime, mtime) })
+}
+
+// readFile returns the content of the given file.
+// If locking is enabled, a shared lock is held on the file while reading it.
+func (p *FileSystemPersister) readFile(path string) ([]byte, error) {
+	if !p.lockFiles {
+		return vfs.ReadFile(p.Fs, path)
+	}
+	f, err := p.Fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := lockFile(f, false); err != nil {
+		return nil, fmt.Errorf("error locking file '%s': %w", path, err)
+	}
+	return io.ReadAll(f)
+}
+
+// writeFile replaces the content of the given file with the given data, creating the file if it doesn't exist.
+// If locking is enabled, an exclusive lock is held on the file while writing it.
+// The file is only truncated after the lock has been acquired, so that readers which respect the lock never see an empty file.
+func (p *FileSystemPersister) writeFile(path string, data []byte) error {
+	if !p.lockFiles {
+		return vfs.WriteFile(p.Fs, path, data, os.ModePerm)
+	}
+	f, err := p.Fs.OpenFile(path, os.O_WRONLY|os.O_CREATE, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	if err := lockFile(f, true); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("error locking file '%s': %w", path, err)
+	}
+	if err := f.Truncate(0); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	// closing the file releases the lock
+	return f.Close()
+}
+
+// writeFileFrom replaces the content of the given file with the data read from the given reader, creating the file if it doesn't exist.
+// Locking works like for writeFile.
+func (p *FileSystemPersister) writeFileFrom(path string, data io.Reader) error {
+	flags := os.O_WRONLY | os.O_CREATE
+	if !p.lockFiles {
+		flags |= os.O_TRUNC
+	}
+	f, err := p.Fs.OpenFile(path, flags, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	if p.lockFiles {
+		if err := lockFile(f, true); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("error locking file '%s': %w", path, err)
+		}
+		if err := f.Truncate(0); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	if _, err := io.Copy(f, data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// appendFile appends the given data to the given file, creating the file if it doesn't exist.
+// If locking is enabled, an exclusive lock is held on the file while writing to it.
+// The end of the file is only determined after the lock has been acquired, as the file might have grown in the meantime.
+func (p *FileSystemPersister) appendFile(path string, data []byte) error {
+	f, err := p.Fs.OpenFile(path, os.O_WRONLY|os.O_CREATE, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	if p.lockFiles {
+		if err := lockFile(f, true); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("error locking file '%s': %w", path, err)
+		}
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	// the in-memory filesystem doesn't support seeking to the end of a file, so the data is written at the current size instead
+	if _, err := f.WriteAt(data, info.Size()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+	"github.com/gardener/k8syncer/pkg/utils"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+var _ persist.ResourceLister = &FileSystemPersister{}
+
+// List returns all resources of the given kind which are persisted below the given subPath.
+// The resources are derived from the file names, the files themselves are not read,
+// apart from files whose names may have been shortened, which contain the only copy of the full name.
+// For the default layout, these are the files in the subPath directory for cluster-scoped resources and the files in the namespace directories below it.
+// For the 'argocd' layout, these are the files in the namespace directories below 'applications'.
+func (p *FileSystemPersister) List(ctx context.Context, gvk schema.GroupVersionKind, subPath string) ([]persist.PersistedResource, error) {
+	gvkString := utils.GVKToString(gvk, true)
+	nsPrefix, separator, extension := p.fileNaming(gvkString)
+	parentDir := p.joinRoot(subPath)
+	filePrefix := fmt.Sprintf("%s%s", gvkString, separator)
+	if p.Layout == config.FILESYSTEM_LAYOUT_ARGOCD {
+		parentDir = p.joinRoot(subPath, argoCDApplicationsDir)
+		filePrefix = fmt.Sprintf("%s-", strings.ToLower(gvk.Kind))
+	}
+	fileSuffix := addFileExtension("", extension)
+
+	res := []persist.PersistedResource{}
+	entries, err := p.readDirIfExists(parentDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		// hidden files and directories, e.g. '.git', never contain persisted resources
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if !entry.IsDir() {
+			// cluster-scoped resources are stored in namespace directories too for the 'argocd' layout
+			if p.Layout == config.FILESYSTEM_LAYOUT_ARGOCD {
+				continue
+			}
+			if name, ok := p.nameFromFile(ctx, vfs.Join(p.Fs, parentDir, entry.Name()), filePrefix, fileSuffix); ok {
+				res = append(res, persist.PersistedResource{Name: name})
+			}
+			continue
+		}
+		namespace, ok := p.namespaceFromDir(entry.Name(), nsPrefix)
+		if !ok {
+			continue
+		}
+		files, err := p.readDirIfExists(vfs.Join(p.Fs, parentDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+			if name, ok := p.nameFromFile(ctx, vfs.Join(p.Fs, parentDir, entry.Name(), file.Name()), filePrefix, fileSuffix); ok {
+				res = append(res, persist.PersistedResource{Name: name, Namespace: namespace})
+			}
+		}
+	}
+	return res, nil
+}
+
+// readDirIfExists returns the contents of the given directory, or nothing if it doesn't exist.
+func (p *FileSystemPersister) readDirIfExists(dir string) ([]os.FileInfo, error) {
+	exists, err := vfs.DirExists(p.Fs, dir)
+	if err != nil || !exists {
+		return nil, err
+	}
+	entries, err := vfs.ReadDir(p.Fs, dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading directory '%s': %w", dir, err)
+	}
+	return entries, nil
+}
+
+// namespaceFromDir returns the namespace whose resources are stored in the directory with the given name.
+// The second return value is false if the directory is no namespace directory for the given namespace prefix.
+func (p *FileSystemPersister) namespaceFromDir(dir, nsPrefix string) (string, bool) {
+	if p.Layout == config.FILESYSTEM_LAYOUT_ARGOCD {
+		if dir == argoCDClusterScopedDir {
+			return "", true
+		}
+		return unescapePathSegment(dir)
+	}
+	if !strings.HasPrefix(dir, nsPrefix) || dir == nsPrefix {
+		return "", false
+	}
+	return unescapePathSegment(strings.TrimPrefix(dir, nsPrefix))
+}
+
+// nameFromFilename returns the name under which the resource in the file with the given name has been persisted.
+// The second return value is false if the file name doesn't have the given prefix and suffix.
+func nameFromFilename(filename, prefix, suffix string) (string, bool) {
+	if !strings.HasPrefix(filename, prefix) || !strings.HasSuffix(filename, suffix) || len(filename) <= len(prefix)+len(suffix) {
+		return "", false
+	}
+	return unescapePathSegment(filename[len(prefix) : len(filename)-len(suffix)])
+}
+
+// nameFromFile returns the name of the resource in the file at the given path, derived from the file name like nameFromFilename.
+// If the name may have been shortened, it is read from the file instead. Files which cannot be parsed are skipped then,
+// as their name is unknown.
+func (p *FileSystemPersister) nameFromFile(ctx context.Context, path, prefix, suffix string) (string, bool) {
+	name, ok := nameFromFilename(vfs.Base(p.Fs, path), prefix, suffix)
+	if !ok || !p.mayBeShortened(name) {
+		return name, ok
+	}
+	log := logging.FromContextOrDiscard(ctx)
+	data, err := p.readFile(path)
+	if err != nil {
+		log.Debug("Unable to read file with shortened name, skipping it", constants.Logging.KEY_PATH, path, constants.Logging.KEY_ERROR, err.Error())
+		return "", false
+	}
+	obj, err := ConvertFromPersistence(data)
+	if err != nil || obj.GetName() == "" {
+		log.Debug("Unable to determine the resource name of file with shortened name, skipping it", constants.Logging.KEY_PATH, path)
+		return "", false
+	}
+	return obj.GetName(), true
+}
+
+// unescapePathSegment reverts escapePathSegment.
+// The second return value is false if the given value cannot be the result of escapePathSegment.
+func unescapePathSegment(value string) (string, bool) {
+	res, err := url.PathUnescape(value)
+	if err != nil {
+		return "", false
+	}
+	return res, true
+}
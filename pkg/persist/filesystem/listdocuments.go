@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/utils"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// listDocumentPrefix is the prefix of the file names of list documents.
+// Resource file names always start with the GVK string, which cannot start with '_', so list documents are never mistaken for resource files.
+const listDocumentPrefix = "_list."
+
+// listDocumentFilepath returns the path of the list document for the given GVK string in the given directory.
+func (p *FileSystemPersister) listDocumentFilepath(dir, gvkString string) string {
+	_, _, extension := p.fileNaming(gvkString)
+	return vfs.Join(p.Fs, dir, addFileExtension(listDocumentPrefix+gvkString, extension))
+}
+
+// isListDocument returns true if the file with the given name is a list document.
+func isListDocument(filename string) bool {
+	return strings.HasPrefix(filename, listDocumentPrefix)
+}
+
+// updateListDocument rewrites the list document of the given kind in the given directory, which contains the persisted resources of this kind
+// as items of a 'v1/List', sorted by their file names. If the directory doesn't contain any resources of this kind anymore, the list document is removed.
+// Files which cannot be parsed are left out.
+func (p *FileSystemPersister) updateListDocument(ctx context.Context, dir string, gvk schema.GroupVersionKind) error {
+	log := logging.FromContextOrDiscard(ctx)
+	gvkString := utils.GVKToString(gvk, true)
+	_, separator, extension := p.fileNaming(gvkString)
+	filePrefix := fmt.Sprintf("%s%s", gvkString, separator)
+	fileSuffix := addFileExtension("", extension)
+	listPath := p.listDocumentFilepath(dir, gvkString)
+
+	files, err := p.readDirIfExists(dir)
+	if err != nil {
+		return err
+	}
+	items := []any{}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if _, ok := nameFromFilename(file.Name(), filePrefix, fileSuffix); !ok {
+			continue
+		}
+		path := vfs.Join(p.Fs, dir, file.Name())
+		data, err := p.readFile(path)
+		if err != nil {
+			return err
+		}
+		obj, err := ConvertFromPersistence(data)
+		if err != nil || obj.Object == nil {
+			log.Debug("Unable to parse persisted file, leaving it out of the list document", constants.Logging.KEY_PATH, path)
+			continue
+		}
+		items = append(items, obj.Object)
+	}
+
+	if len(items) == 0 {
+		if err := p.Fs.Remove(listPath); err != nil && !vfs.IsErrNotExist(err) {
+			return fmt.Errorf("error removing list document '%s': %w", listPath, err)
+		}
+		return nil
+	}
+	list := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      items,
+	}}
+	data, err := p.convertToPersistence(list, nil)
+	if err != nil {
+		return err
+	}
+	existingData, err := p.getRaw(ctx, listPath)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(data, existingData) {
+		return nil
+	}
+	return p.persistRaw(ctx, data, listPath)
+}
@@ -0,0 +1,21 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !unix
+
+package filesystem
+
+import (
+	"fmt"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// fileLockingSupported is true if lockFile is implemented for the current operating system.
+const fileLockingSupported = false
+
+// lockFile is not supported on this operating system.
+func lockFile(f vfs.File, exclusive bool) error {
+	return fmt.Errorf("file locking is not supported on this operating system")
+}
@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unix
+
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+
+	vfsutils "github.com/mandelsoft/vfs/pkg/utils"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// fileLockingSupported is true if lockFile is implemented for the current operating system.
+const fileLockingSupported = true
+
+// lockFile acquires an advisory lock on the given file, which is released when the file is closed.
+// The lock is shared, unless exclusive is true. The function blocks until the lock has been acquired.
+// The file has to be a file of the operating system's filesystem.
+func lockFile(f vfs.File, exclusive bool) error {
+	// the OS filesystem of vfs wraps the files to report their absolute paths as name
+	if rf, ok := f.(*vfsutils.RenamedFile); ok {
+		f = rf.File
+	}
+	osFile, ok := f.(interface{ Fd() uintptr })
+	if !ok {
+		return fmt.Errorf("file '%s' does not belong to the operating system's filesystem", f.Name())
+	}
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	for {
+		err := syscall.Flock(int(osFile.Fd()), how)
+		if !errors.Is(err, syscall.EINTR) {
+			return err
+		}
+	}
+}
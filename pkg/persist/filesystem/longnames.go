@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+)
+
+const (
+	// shortenedNameMarker separates the hash from the rest of a shortened name.
+	// Names of most kinds cannot contain it, which makes shortened file names easy to recognize.
+	shortenedNameMarker = "~"
+	// truncatedHashLength is the number of hex characters of the hash which is appended to truncated names.
+	truncatedHashLength = 8
+	// hashedNameLength is the number of hex characters of the hash which replaces the name for the 'hash' strategy.
+	hashedNameLength = 32
+)
+
+// shortenedNameRegex matches the names which may be the result of shortening.
+var shortenedNameRegex = regexp.MustCompile(fmt.Sprintf(`%s([0-9a-f]{%d}|[0-9a-f]{%d})$`, regexp.QuoteMeta(shortenedNameMarker), truncatedHashLength, hashedNameLength))
+
+// nameShortener shortens the given escaped resource name to at most max bytes.
+// The result must be deterministic and match shortenedNameRegex.
+type nameShortener func(name string, max int) string
+
+// nameShorteners contains the shortener for each strategy.
+var nameShorteners = map[config.LongNameStrategy]nameShortener{
+	config.LONG_NAME_STRATEGY_TRUNCATE: truncateName,
+	config.LONG_NAME_STRATEGY_HASH:     hashName,
+}
+
+var _ persist.ResourceError = &NameCollisionError{}
+
+// NameCollisionError is returned if the file for a resource with a shortened name contains another resource,
+// because the shortened names of both resources are equal.
+type NameCollisionError struct {
+	// Path is the path of the file.
+	Path string
+	// Resource is the resource which should be stored in the file, as '<namespace>/<name>'.
+	Resource string
+	// ExistingResource is the resource which is stored in the file, as '<namespace>/<name>'.
+	ExistingResource string
+}
+
+func (e *NameCollisionError) Error() string {
+	return fmt.Sprintf("shortened file name collision: file '%s' for resource '%s' already contains resource '%s'", e.Path, e.Resource, e.ExistingResource)
+}
+
+func (e *NameCollisionError) ResourceError() {}
+
+// nameHash returns the first length hex characters of the SHA256 hash of the given name.
+func nameHash(name string, length int) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:])[:length]
+}
+
+// hashName replaces the name with a hash of it.
+func hashName(name string, _ int) string {
+	return shortenedNameMarker + nameHash(name, hashedNameLength)
+}
+
+// truncateName keeps the longest possible beginning of the name, followed by a short hash of the full name.
+// Escape sequences and multi-byte characters are not split, so that the result can still be unescaped.
+func truncateName(name string, max int) string {
+	suffix := shortenedNameMarker + nameHash(name, truncatedHashLength)
+	cut := max - len(suffix)
+	if cut >= len(name) {
+		return name
+	}
+	if cut <= 0 {
+		return suffix
+	}
+	for cut > 0 && !utf8.RuneStart(name[cut]) {
+		cut--
+	}
+	if idx := strings.LastIndex(name[:cut], "%"); idx >= 0 && idx+3 > cut {
+		cut = idx
+	}
+	return name[:cut] + suffix
+}
+
+// shortenName returns the given escaped name, shortened if a file name consisting of it and the given prefix and suffix would exceed the maximum length.
+// The second return value is true if the name has been shortened.
+// If no long names configuration is set, names are never shortened.
+func (p *FileSystemPersister) shortenName(prefix, name, suffix string) (string, bool) {
+	if p.LongNames == nil {
+		return name, false
+	}
+	available := p.LongNames.MaxLength - len(prefix) - len(suffix)
+	if len(name) <= available {
+		return name, false
+	}
+	shortener, ok := nameShorteners[p.LongNames.Strategy]
+	if !ok {
+		// should not happen, as the strategy is validated
+		shortener = truncateName
+	}
+	return shortener(name, available), true
+}
+
+// mayBeShortened returns true if the given name, which has been derived from a file name, may be the result of shortening a longer name.
+func (p *FileSystemPersister) mayBeShortened(name string) bool {
+	return p.LongNames != nil && shortenedNameRegex.MatchString(name)
+}
+
+// CheckNameCollision returns a NameCollisionError if the given data, which has been read from a file with a shortened name,
+// contains a resource other than the specified one. Data which cannot be parsed is not checked.
+func CheckNameCollision(filepath string, data []byte, name, namespace string) error {
+	if data == nil {
+		return nil
+	}
+	existing, err := ConvertFromPersistence(data)
+	if err != nil || existing == nil || existing.GetName() == "" {
+		return nil
+	}
+	// transformers may remove the namespace, so it is only compared if it is contained
+	if existing.GetName() != name || (existing.GetNamespace() != "" && existing.GetNamespace() != namespace) {
+		return &NameCollisionError{
+			Path:             filepath,
+			Resource:         fmt.Sprintf("%s/%s", namespace, name),
+			ExistingResource: fmt.Sprintf("%s/%s", existing.GetNamespace(), existing.GetName()),
+		}
+	}
+	return nil
+}
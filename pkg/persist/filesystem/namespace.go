@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/persist"
+)
+
+var _ persist.NamespaceMetadataPersister = &FileSystemPersister{}
+
+// namespaceGVK is the GroupVersionKind of Namespaces.
+var namespaceGVK = schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+
+// PersistNamespaceMetadata writes the given Namespace object into the namespace directory of the namespace.
+// The file is named like the file of any other resource in this directory, so it is returned by ReadTree and part of snapshots.
+// It doesn't prevent the namespace directory from being removed when the last resource in it is deleted.
+func (p *FileSystemPersister) PersistNamespaceMetadata(ctx context.Context, namespace *unstructured.Unstructured, t persist.Transformer, subPath string) (bool, error) {
+	if namespace.GroupVersionKind() != namespaceGVK {
+		return false, fmt.Errorf("expected a namespace, got '%s'", namespace.GroupVersionKind().String())
+	}
+	filepath := p.namespaceMetadataFilepath(ctx, namespace.GetName(), subPath)
+	existingData, err := p.getRaw(ctx, filepath)
+	if err != nil {
+		return false, err
+	}
+	transformed := namespace
+	if t != nil {
+		transformed, err = t.Transform(namespace)
+		if err != nil {
+			return false, err
+		}
+	}
+	newData, err := p.convertToPersistence(transformed, nil)
+	if err != nil {
+		return false, err
+	}
+	if p.unchanged(newData, existingData) {
+		return false, nil
+	}
+	if p.FrontMatter {
+		newData = append(frontMatter(namespace, transformed, time.Now()), newData...)
+	}
+	if err := p.persistRaw(ctx, newData, filepath); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// namespaceMetadataFilepath returns the path of the file containing the Namespace object of the given namespace, including the root path.
+func (p *FileSystemPersister) namespaceMetadataFilepath(ctx context.Context, namespace, subPath string) string {
+	// the namespace directory contains only a single Namespace object, so a shortened name cannot collide
+	filepath, _, _ := p.GetResourceFilepath(ctx, namespace, namespace, namespaceGVK, subPath, true)
+	return filepath
+}
+
+// containsOnlyNamespaceMetadata returns true if the given directory contents consist of nothing but the Namespace object of the given namespace.
+func (p *FileSystemPersister) containsOnlyNamespaceMetadata(ctx context.Context, contents []os.FileInfo, namespace, subPath string) bool {
+	return len(contents) == 1 && !contents[0].IsDir() && contents[0].Name() == vfs.Base(p.Fs, p.namespaceMetadataFilepath(ctx, namespace, subPath))
+}
@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// pathSegmentEscaper escapes the characters which must not occur in a single path segment.
+// '%' is escaped too, so that escaped and unescaped segments cannot collide.
+var pathSegmentEscaper = strings.NewReplacer("%", "%25", "/", "%2F", "\\", "%5C")
+
+// escapePathSegment returns the given value in a form which can be used as a single segment of a path.
+// Path separators are escaped and the special segments '.' and '..' are replaced, so that the value cannot refer to another directory.
+// Values which are valid names of k8s resources are returned unchanged, except for the rare case of them containing '%'.
+func escapePathSegment(value string) string {
+	if value == "." || value == ".." {
+		return strings.ReplaceAll(value, ".", "%2E")
+	}
+	return pathSegmentEscaper.Replace(value)
+}
+
+// cleanSubPath returns the lexically cleaned form of the given subPath, relative to the root path.
+// '..' elements cannot climb above the root path, e.g. '../foo' and '/foo' both result in 'foo'.
+func (p *FileSystemPersister) cleanSubPath(subPath string) string {
+	return strings.TrimPrefix(vfs.Clean(p.Fs, vfs.PathSeparatorString+subPath), vfs.PathSeparatorString)
+}
+
+// joinRoot joins the given path elements and returns the resulting path below the root path.
+// The joined path is cleaned with cleanSubPath, so it cannot point outside of the root path.
+func (p *FileSystemPersister) joinRoot(elems ...string) string {
+	return vfs.Join(p.Fs, p.RootPath, p.cleanSubPath(vfs.Join(p.Fs, elems...)))
+}
+
+// ensureWithinRoot returns an error if the given path resolves to a location outside of the root path,
+// which can happen if a directory below the root path is a symbolic link.
+// The path itself doesn't need to exist, only its existing parts are resolved.
+func (p *FileSystemPersister) ensureWithinRoot(path string) error {
+	resolved, err := vfs.Canonical(p.Fs, path, false)
+	if err != nil {
+		return fmt.Errorf("error resolving path '%s': %w", path, err)
+	}
+	if p.RootPath != vfs.PathSeparatorString && resolved != p.RootPath && !strings.HasPrefix(resolved, p.RootPath+vfs.PathSeparatorString) {
+		return fmt.Errorf("path '%s' resolves to '%s', which is outside of the root path '%s'", path, resolved, p.RootPath)
+	}
+	return nil
+}
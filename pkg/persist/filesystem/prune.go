@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// archiveTimestampFormat is the format of the timestamp which is appended to archived namespace directories.
+const archiveTimestampFormat = "20060102T150405Z"
+
+var _ persist.NamespacePruner = &FileSystemPersister{}
+var _ persist.SubPathPruner = &FileSystemPersister{}
+
+// PruneNamespace removes the namespace directory of the given namespace below the given subPath.
+// If archiveSubPath is not empty, the directory is moved to '<archiveSubPath>/<subPath>' instead, with the current timestamp appended to its name.
+// For the 'argocd' layout, the application for the namespace is removed from the index in both cases.
+func (p *FileSystemPersister) PruneNamespace(ctx context.Context, namespace, subPath, archiveSubPath string) (bool, error) {
+	if namespace == "" {
+		return false, fmt.Errorf("namespace must not be empty")
+	}
+	namespace = escapePathSegment(namespace)
+	// the data of many resources is removed at once
+	defer p.NotifyChange()
+	if p.Layout == config.FILESYSTEM_LAYOUT_ARGOCD {
+		nsDir := argoCDNamespaceDir(namespace)
+		pruned, err := p.pruneNamespaceDir(ctx, nsDir, p.joinRoot(subPath, argoCDApplicationsDir), subPath, archiveSubPath)
+		if err != nil || !pruned {
+			return pruned, err
+		}
+		appPath := p.joinRoot(subPath, argoCDIndexDir, p.withFileExtension(nsDir))
+		if err := p.Fs.Remove(appPath); err != nil && !vfs.IsErrNotExist(err) {
+			return true, fmt.Errorf("error removing argocd application for namespace directory '%s': %w", nsDir, err)
+		}
+		return true, nil
+	}
+
+	// kind overrides might store the resources of the namespace in multiple namespace directories
+	pruned := false
+	for _, prefix := range p.namespacePrefixes() {
+		nsPruned, err := p.pruneNamespaceDir(ctx, fmt.Sprintf("%s%s", prefix, namespace), p.joinRoot(subPath), subPath, archiveSubPath)
+		pruned = pruned || nsPruned
+		if err != nil {
+			return pruned, err
+		}
+	}
+	return pruned, nil
+}
+
+// pruneNamespaceDir deletes or archives the namespace directory with the given name below the given parent directory.
+// The first return value is false if the directory doesn't exist.
+func (p *FileSystemPersister) pruneNamespaceDir(ctx context.Context, nsDir, parentDir, subPath, archiveSubPath string) (bool, error) {
+	nsPath := vfs.Join(p.Fs, parentDir, nsDir)
+	exists, err := vfs.DirExists(p.Fs, nsPath)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, nil
+	}
+
+	if archiveSubPath == "" {
+		logging.FromContextOrDiscard(ctx).Debug("Deleting namespace directory", constants.Logging.KEY_PATH, nsPath)
+		if err := p.Fs.RemoveAll(nsPath); err != nil {
+			return true, fmt.Errorf("error deleting namespace directory '%s': %w", nsPath, err)
+		}
+		return true, nil
+	}
+	// keep the subPath below the archive path to avoid conflicts between namespace directories from different subPaths
+	archiveDir := p.joinRoot(archiveSubPath, subPath)
+	if err := p.Fs.MkdirAll(archiveDir, os.ModeDir|os.ModePerm); err != nil {
+		return true, fmt.Errorf("error creating archive directory '%s': %w", archiveDir, err)
+	}
+	archivePath := vfs.Join(p.Fs, archiveDir, fmt.Sprintf("%s_%s", nsDir, time.Now().UTC().Format(archiveTimestampFormat)))
+	logging.FromContextOrDiscard(ctx).Debug("Archiving namespace directory", constants.Logging.KEY_PATH, nsPath, constants.Logging.KEY_ARCHIVE_PATH, archivePath)
+	if err := p.Fs.Rename(nsPath, archivePath); err != nil {
+		return true, fmt.Errorf("error moving namespace directory '%s' to '%s': %w", nsPath, archivePath, err)
+	}
+	return true, nil
+}
+
+// PruneSubPath removes the directory of the given subPath with all its content.
+// It refuses to remove the root directory of the storage.
+func (p *FileSystemPersister) PruneSubPath(ctx context.Context, subPath string) (bool, error) {
+	dir := p.joinRoot(subPath)
+	if dir == p.joinRoot() {
+		return false, fmt.Errorf("subPath '%s' resolves to the root directory of the storage, which is never pruned", subPath)
+	}
+	if err := p.ensureWithinRoot(dir); err != nil {
+		return false, err
+	}
+	exists, err := vfs.DirExists(p.Fs, dir)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, nil
+	}
+	// the data of many resources is removed at once
+	defer p.NotifyChange()
+	logging.FromContextOrDiscard(ctx).Debug("Deleting subPath directory", constants.Logging.KEY_PATH, dir)
+	if err := p.Fs.RemoveAll(dir); err != nil {
+		return true, fmt.Errorf("error deleting subPath directory '%s': %w", dir, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,179 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	goyaml "sigs.k8s.io/yaml/goyaml.v2"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+// quantityMapKeys contains the names of fields which usually contain a resource list, meaning a map from resource names to quantities.
+// As the schema of the serialized resources is unknown, only the values of maps with these names are treated as quantities.
+var quantityMapKeys = sets.New[string]("requests", "limits", "capacity", "allocatable", "hard", "used", "overhead")
+
+// serializer converts resources into the data which is written to the files, according to a serialization configuration.
+type serializer struct {
+	// canonical is true if values with multiple equivalent representations should be normalized.
+	canonical bool
+	// fieldOrder maps the field names which should be written first to their position.
+	fieldOrder map[string]int
+	// topLevelFieldOrder is like fieldOrder, but only for the top-level fields.
+	// If nil, the top-level fields are ordered according to fieldOrder too.
+	topLevelFieldOrder map[string]int
+}
+
+// newSerializer creates a new serializer from the given configuration.
+// It returns nil if the configuration is nil, in which case ConvertToPersistence should be used.
+func newSerializer(cfg *config.SerializationConfiguration) *serializer {
+	if cfg == nil {
+		return nil
+	}
+	s := &serializer{
+		canonical:  cfg.Canonical,
+		fieldOrder: fieldPositions(cfg.FieldOrder),
+	}
+	if len(cfg.TopLevelFieldOrder) > 0 {
+		s.topLevelFieldOrder = fieldPositions(cfg.TopLevelFieldOrder)
+	}
+	return s
+}
+
+// fieldPositions maps the given field names to their position, duplicates keep their first position.
+func fieldPositions(fieldNames []string) map[string]int {
+	res := make(map[string]int, len(fieldNames))
+	for idx, fieldName := range fieldNames {
+		if _, ok := res[fieldName]; !ok {
+			res[fieldName] = idx
+		}
+	}
+	return res
+}
+
+// serialize converts the given resource into YAML.
+// The output is identical to the one of ConvertToPersistence, apart from the normalized values and the field order.
+func (s *serializer) serialize(obj *unstructured.Unstructured) ([]byte, error) {
+	content := obj.UnstructuredContent()
+	if s.canonical {
+		content = canonicalize(runtime.DeepCopyJSON(content), "").(map[string]interface{})
+	}
+	// the JSON encoding has the fields sorted alphabetically, decoding it into a MapSlice preserves that order
+	jsonData, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("error while marshalling object to json: %w", err)
+	}
+	ordered := goyaml.MapSlice{}
+	if err := goyaml.Unmarshal(jsonData, &ordered); err != nil {
+		return nil, fmt.Errorf("error while converting object to yaml: %w", err)
+	}
+	if s.topLevelFieldOrder != nil {
+		// the top-level fields have their own order, the nested maps are ordered according to the common one
+		sortFields(ordered, s.topLevelFieldOrder)
+		for _, item := range ordered {
+			s.order(item.Value)
+		}
+	} else {
+		s.order(ordered)
+	}
+	data, err := goyaml.Marshal(ordered)
+	if err != nil {
+		return nil, fmt.Errorf("error while marshalling object to yaml: %w", err)
+	}
+	return data, nil
+}
+
+// order moves the fields which are contained in the field order to the front of the given map and all maps nested in it.
+// Fields which are not contained in the field order keep their relative order.
+func (s *serializer) order(value interface{}) {
+	switch typed := value.(type) {
+	case goyaml.MapSlice:
+		sortFields(typed, s.fieldOrder)
+		for _, item := range typed {
+			s.order(item.Value)
+		}
+	case []interface{}:
+		for _, elem := range typed {
+			s.order(elem)
+		}
+	}
+}
+
+// sortFields moves the fields which are contained in the given field order to the front of the given map, in that order.
+// Fields which are not contained in the field order keep their relative order.
+func sortFields(fields goyaml.MapSlice, fieldOrder map[string]int) {
+	if len(fieldOrder) == 0 {
+		return
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		return rank(fields[i].Key, fieldOrder) < rank(fields[j].Key, fieldOrder)
+	})
+}
+
+// rank returns the position of the given field in the given field order.
+// Fields which are not contained in the field order are ranked behind all contained ones.
+func rank(key interface{}, fieldOrder map[string]int) int {
+	if str, ok := key.(string); ok {
+		if pos, ok := fieldOrder[str]; ok {
+			return pos
+		}
+	}
+	return len(fieldOrder)
+}
+
+// canonicalize normalizes all values in the given value recursively and returns the result.
+// The given key is the name of the field which contains the value, it is empty for the root and for list elements.
+// The value is modified in-place, so it must not be shared with the persisted resource.
+func canonicalize(value interface{}, key string) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		isQuantityMap := quantityMapKeys.Has(key)
+		for k, v := range typed {
+			if str, ok := v.(string); ok && isQuantityMap {
+				typed[k] = canonicalQuantity(str)
+				continue
+			}
+			typed[k] = canonicalize(v, k)
+		}
+		return typed
+	case []interface{}:
+		for idx, elem := range typed {
+			typed[idx] = canonicalize(elem, "")
+		}
+		return typed
+	case string:
+		return canonicalTimestamp(typed)
+	default:
+		return value
+	}
+}
+
+// canonicalQuantity returns the canonical form of the given quantity, e.g. '1' for '1000m'.
+// Values which are not quantities are returned unchanged.
+func canonicalQuantity(value string) string {
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return value
+	}
+	return q.String()
+}
+
+// canonicalTimestamp converts the given RFC 3339 timestamp to UTC and trims the fractional seconds.
+// Values which are not timestamps are returned unchanged.
+func canonicalTimestamp(value string) string {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return value
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/persist"
+)
+
+var _ persist.SidecarPersister = &FileSystemPersister{}
+
+// PersistSidecar writes the given data into the sidecar file of the given kind of the specified resource.
+// The file is stored next to the resource's file and named like it, with '.<kind>' appended. As it doesn't end with the
+// configured file extension, it is neither read by ReadTree nor deployed by Argo CD for the 'argocd' layout.
+func (p *FileSystemPersister) PersistSidecar(ctx context.Context, data []byte, kind, name, namespace string, gvk schema.GroupVersionKind, subPath string) (bool, error) {
+	filepath, err := p.sidecarFilepath(ctx, kind, name, namespace, gvk, subPath)
+	if err != nil {
+		return false, err
+	}
+	existingData, err := p.getRaw(ctx, filepath)
+	if err != nil {
+		return false, err
+	}
+	if existingData != nil && bytes.Equal(data, existingData) {
+		return false, nil
+	}
+	if err := p.persistRaw(ctx, data, filepath); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// DeleteSidecar removes the sidecar file of the given kind of the specified resource, if it exists.
+func (p *FileSystemPersister) DeleteSidecar(ctx context.Context, kind, name, namespace string, gvk schema.GroupVersionKind, subPath string) error {
+	filepath, err := p.sidecarFilepath(ctx, kind, name, namespace, gvk, subPath)
+	if err != nil {
+		return err
+	}
+	if err := p.Fs.Remove(filepath); err != nil && !vfs.IsErrNotExist(err) {
+		return fmt.Errorf("error removing sidecar file '%s': %w", filepath, err)
+	}
+	return nil
+}
+
+// sidecarFilepath returns the path of the sidecar file of the given kind of the specified resource.
+// If the name of the resource's file has been shortened, it returns a NameCollisionError if that file contains another resource,
+// as the sidecar file belongs to that resource then.
+func (p *FileSystemPersister) sidecarFilepath(ctx context.Context, kind, name, namespace string, gvk schema.GroupVersionKind, subPath string) (string, error) {
+	filepath, _, shortened := p.GetResourceFilepath(ctx, name, namespace, gvk, subPath, true)
+	if shortened {
+		data, err := p.getRaw(ctx, filepath)
+		if err != nil {
+			return "", err
+		}
+		if err := CheckNameCollision(filepath, data, name, namespace); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%s.%s", filepath, kind), nil
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// StagingPersister is an in-memory FileSystemPersister for storages which don't store files, like the kubernetes, wiki, and kv storages.
+// They stage the stored data of a resource in it for the duration of an operation, so that the FileSystemPersister takes care of
+// the naming and serialization of the resource, and read the result back from it afterwards.
+// As the staging filesystem is shared, the operations have to hold the lock while using it.
+type StagingPersister struct {
+	*FileSystemPersister
+	sync.Mutex
+}
+
+// NewStagingPersister returns a new StagingPersister for the given filesystem configuration.
+func NewStagingPersister(cfg *config.FileSystemConfiguration) (*StagingPersister, error) {
+	fsp, err := NewForMemory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating internal FileSystemPersister: %w", err)
+	}
+	return &StagingPersister{FileSystemPersister: fsp}, nil
+}
+
+// Stage writes the given data to the given path in the staging filesystem.
+func (p *StagingPersister) Stage(filepath string, data []byte) error {
+	if err := p.Fs.MkdirAll(vfs.Dir(p.Fs, filepath), os.ModeDir|os.ModePerm); err != nil {
+		return fmt.Errorf("error staging data: %w", err)
+	}
+	if err := vfs.WriteFile(p.Fs, filepath, data, os.ModePerm); err != nil {
+		return fmt.Errorf("error staging data: %w", err)
+	}
+	return nil
+}
+
+// ReadStaged returns the data at the given path in the staging filesystem.
+func (p *StagingPersister) ReadStaged(filepath string) ([]byte, error) {
+	data, err := vfs.ReadFile(p.Fs, filepath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading staged data: %w", err)
+	}
+	return data, nil
+}
+
+// Unstage removes the given path from the staging filesystem, so that it only holds the data of the current operation.
+func (p *StagingPersister) Unstage(ctx context.Context, filepath string) {
+	if err := p.Fs.Remove(filepath); err != nil && !vfs.IsErrNotExist(err) {
+		logging.FromContextOrDiscard(ctx).Error(err, "Unable to remove staged data", constants.Logging.KEY_PATH, filepath)
+	}
+}
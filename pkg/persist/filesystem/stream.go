@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+)
+
+var _ persist.StreamPersister = &FileSystemPersister{}
+
+// GetStream returns the opened file of the specified resource.
+// If locking is enabled, a shared lock is held on the file until the returned reader is closed.
+// If the name of the file has been shortened, the file is read completely to check for a name collision, so it is not streamed.
+func (p *FileSystemPersister) GetStream(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (io.ReadCloser, error) {
+	filepath, _, shortened := p.GetResourceFilepath(ctx, name, namespace, gvk, subPath, true)
+	if shortened {
+		data, err := p.getRaw(ctx, filepath)
+		if err != nil || data == nil {
+			return nil, err
+		}
+		if err := CheckNameCollision(filepath, data, name, namespace); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	f, err := p.Fs.Open(filepath)
+	if err != nil {
+		if vfs.IsErrNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if p.lockFiles {
+		if err := lockFile(f, false); err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("error locking file '%s': %w", filepath, err)
+		}
+	}
+	return f, nil
+}
+
+// PersistStream writes the data read from the given reader into the file of the specified resource.
+// List documents and the Argo CD application index are updated afterwards, if configured.
+func (p *FileSystemPersister) PersistStream(ctx context.Context, data io.Reader, name, namespace string, gvk schema.GroupVersionKind, subPath string) error {
+	filepath, _, shortened := p.GetResourceFilepath(ctx, name, namespace, gvk, subPath, true)
+	if shortened {
+		existingData, err := p.getRaw(ctx, filepath)
+		if err != nil {
+			return err
+		}
+		if err := CheckNameCollision(filepath, existingData, name, namespace); err != nil {
+			return err
+		}
+	}
+	dirpath := vfs.Dir(p.Fs, filepath)
+	if err := p.ensureWithinRoot(dirpath); err != nil {
+		return err
+	}
+	if err := p.Fs.MkdirAll(dirpath, os.ModeDir|os.ModePerm); err != nil {
+		return err
+	}
+	if err := p.writeFileFrom(filepath, data); err != nil {
+		return err
+	}
+	if p.ListDocuments {
+		if err := p.updateListDocument(ctx, dirpath, gvk); err != nil {
+			return fmt.Errorf("error updating list document: %w", err)
+		}
+	}
+	if p.Layout == config.FILESYSTEM_LAYOUT_ARGOCD {
+		if err := p.updateArgoCDIndex(ctx, namespace, subPath); err != nil {
+			return fmt.Errorf("error updating argocd application index: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/gardener/k8syncer/pkg/persist"
+)
+
+var _ persist.TreeReader = &FileSystemPersister{}
+var _ persist.ArtifactPersister = &FileSystemPersister{}
+var _ persist.ArtifactAppender = &FileSystemPersister{}
+
+// ReadTree returns the contents of all files with the configured file extensions below the given subPath.
+// Hidden files and directories (starting with '.') are ignored, so that e.g. the '.git' directory of a repository is not read.
+// List documents are ignored too, as they only duplicate the resource files.
+func (p *FileSystemPersister) ReadTree(ctx context.Context, subPath string) (map[string][]byte, error) {
+	root := p.joinRoot(subPath)
+	res := map[string][]byte{}
+	exists, err := vfs.DirExists(p.Fs, root)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return res, nil
+	}
+	suffixes := []string{}
+	for _, extension := range p.fileExtensions() {
+		suffixes = append(suffixes, addFileExtension("", extension))
+	}
+	err = vfs.Walk(p.Fs, root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != root && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return vfs.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || !hasAnySuffix(info.Name(), suffixes) || isListDocument(info.Name()) {
+			return nil
+		}
+		data, err := p.readFile(path)
+		if err != nil {
+			return err
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(path, root), vfs.PathSeparatorString)
+		res[rel] = data
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading files below '%s': %w", root, err)
+	}
+	return res, nil
+}
+
+// PersistArtifact writes the given data into a file with the given name below the given subPath.
+func (p *FileSystemPersister) PersistArtifact(ctx context.Context, data []byte, filename, subPath string) error {
+	return p.persistRaw(ctx, data, p.joinRoot(subPath, filename))
+}
+
+// AppendArtifact appends the given data to the file with the given name below the given subPath, creating the file and its directory if required.
+func (p *FileSystemPersister) AppendArtifact(ctx context.Context, data []byte, filename, subPath string) error {
+	filepath := p.joinRoot(subPath, filename)
+	dirpath := vfs.Dir(p.Fs, filepath)
+	if err := p.ensureWithinRoot(dirpath); err != nil {
+		return err
+	}
+	if err := p.Fs.MkdirAll(dirpath, os.ModeDir|os.ModePerm); err != nil {
+		return err
+	}
+	return p.appendFile(filepath, data)
+}
+
+func hasAnySuffix(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"time"
+
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// HasBackgroundPull returns true if the repository should be pulled in the background, see StartBackgroundPulls.
+func (p *GitPersister) HasBackgroundPull() bool {
+	return p.backgroundPull != nil
+}
+
+// StartBackgroundPulls pulls all checkouts of the repository whenever the configured interval has passed, until the context is cancelled.
+// Failed pulls are logged and retried with the next interval, operations pull themselves once the last successful pull exceeds the freshness window.
+// It returns immediately if background pulls are not configured.
+func (p *GitPersister) StartBackgroundPulls(ctx context.Context) error {
+	if p.backgroundPull == nil {
+		return nil
+	}
+	interval := p.backgroundPull.Interval.Duration
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			for _, co := range p.checkouts() {
+				if co.pulledWithin(interval) {
+					// an operation has pulled recently
+					continue
+				}
+				if err := co.repo.Pull(p.log); err != nil {
+					p.log.Error(err, "error pulling git repository in the background", constants.Logging.KEY_BRANCH, co.repo.Branch)
+					continue
+				}
+				co.markPulled()
+				p.NotifyChange()
+			}
+		}
+	}
+}
+
+// checkouts returns the checkout of the configured branch and all namespace branch checkouts which have been used so far.
+func (p *GitPersister) checkouts() []*checkout {
+	p.checkoutsLock.Lock()
+	defer p.checkoutsLock.Unlock()
+	res := make([]*checkout, 0, len(p.namespaceCheckouts)+1)
+	res = append(res, p.base)
+	for _, co := range p.namespaceCheckouts {
+		res = append(res, co)
+	}
+	return res
+}
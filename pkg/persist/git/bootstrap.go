@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// repositoryProvider manages repositories and branches via the REST API of a git provider.
+type repositoryProvider interface {
+	// getRepository returns the default branch of the repository and whether the repository exists.
+	// The default branch may be empty if the repository doesn't contain any commits.
+	getRepository(ctx context.Context) (string, bool, error)
+	// createRepository creates the repository with an initial commit.
+	// The given branch is used as default branch, if the provider supports choosing it.
+	createRepository(ctx context.Context, branch string, private bool) error
+	// branchExists returns whether the given branch exists in the repository.
+	branchExists(ctx context.Context, branch string) (bool, error)
+	// createBranch creates the given branch, pointing to the head of the branch 'from'.
+	createBranch(ctx context.Context, branch, from string) error
+	// protectBranch protects the given branch against force pushes and deletion.
+	// It does not return an error if the branch is protected already.
+	protectBranch(ctx context.Context, branch string) error
+}
+
+// newRepositoryProvider returns the repository provider for the given configuration.
+// The configuration is expected to be completed and validated.
+func newRepositoryProvider(cfg *config.GitProviderConfiguration) (repositoryProvider, error) {
+	switch cfg.Type {
+	case config.GIT_PROVIDER_GITHUB:
+		return newGitHubProvider(cfg), nil
+	case config.GIT_PROVIDER_GITLAB:
+		return newGitLabProvider(cfg), nil
+	case config.GIT_PROVIDER_GITEA:
+		return newGiteaProvider(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown git provider '%s'", string(cfg.Type))
+	}
+}
+
+// bootstrapRepository creates the configured repository and branch via the API of the configured provider, if they don't exist.
+// A branch which is created by it is protected, if configured. The branch can't be created if the repository exists but is empty,
+// it is then created by the first push, without protection.
+func bootstrapRepository(ctx context.Context, gitCfg *config.GitConfiguration) error {
+	log := logging.FromContextOrDiscard(ctx).WithValues(constants.Logging.KEY_REPOSITORY, gitCfg.Provider.Repository, constants.Logging.KEY_BRANCH, gitCfg.Branch)
+	rp, err := newRepositoryProvider(gitCfg.Provider)
+	if err != nil {
+		return err
+	}
+	defaultBranch, exists, err := rp.getRepository(ctx)
+	if err != nil {
+		return err
+	}
+	created := false
+	if !exists {
+		log.Info("Creating git repository")
+		if err := rp.createRepository(ctx, gitCfg.Branch, *gitCfg.Provider.Private); err != nil {
+			return err
+		}
+		defaultBranch, exists, err = rp.getRepository(ctx)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("repository '%s' does not exist after creating it", gitCfg.Provider.Repository)
+		}
+		created = true
+	}
+	if defaultBranch != "" {
+		hasCommits, err := rp.branchExists(ctx, defaultBranch)
+		if err != nil {
+			return err
+		}
+		if !hasCommits {
+			defaultBranch = ""
+		}
+	}
+	if defaultBranch == "" {
+		log.Info("Git repository is empty, the branch will be created by the first push")
+		return nil
+	}
+	if defaultBranch != gitCfg.Branch {
+		branchExists, err := rp.branchExists(ctx, gitCfg.Branch)
+		if err != nil {
+			return err
+		}
+		created = !branchExists
+		if created {
+			log.Info("Creating git branch from the default branch")
+			if err := rp.createBranch(ctx, gitCfg.Branch, defaultBranch); err != nil {
+				return err
+			}
+		}
+	}
+	if created && gitCfg.Provider.ProtectBranch {
+		log.Info("Protecting git branch")
+		if err := rp.protectBranch(ctx, gitCfg.Branch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils/providerapi"
+)
+
+var _ repositoryProvider = &githubProvider{}
+var _ repositoryProvider = &gitlabProvider{}
+var _ repositoryProvider = &giteaProvider{}
+
+// githubProvider manages repositories on GitHub or GitHub Enterprise Server.
+type githubProvider struct {
+	api   *providerapi.Client
+	owner string
+	name  string
+}
+
+func newGitHubProvider(cfg *config.GitProviderConfiguration) *githubProvider {
+	apiURL := strings.TrimSuffix(cfg.URL, "/") + "/api/v3"
+	if u, err := url.Parse(cfg.URL); err == nil && u.Host == "github.com" {
+		apiURL = "https://api.github.com"
+	}
+	owner, name, _ := strings.Cut(cfg.Repository, "/")
+	return &githubProvider{
+		api:   providerapi.NewClient(apiURL, cfg.Token, cfg.TokenFile, providerapi.GitHubAuth),
+		owner: owner,
+		name:  name,
+	}
+}
+
+func (g *githubProvider) repoPath() string {
+	return fmt.Sprintf("/repos/%s/%s", url.PathEscape(g.owner), url.PathEscape(g.name))
+}
+
+func (g *githubProvider) getRepository(ctx context.Context) (string, bool, error) {
+	repo := &struct {
+		DefaultBranch string `json:"default_branch"`
+	}{}
+	if _, err := g.api.DoJSON(ctx, http.MethodGet, g.repoPath(), nil, repo); err != nil {
+		if providerapi.IsNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("error reading repository '%s/%s': %w", g.owner, g.name, err)
+	}
+	return repo.DefaultBranch, true, nil
+}
+
+func (g *githubProvider) createRepository(ctx context.Context, _ string, private bool) error {
+	user := &struct {
+		Login string `json:"login"`
+	}{}
+	if _, err := g.api.DoJSON(ctx, http.MethodGet, "/user", nil, user); err != nil {
+		return fmt.Errorf("error reading authenticated user: %w", err)
+	}
+	// GitHub doesn't allow choosing the default branch on creation, the one configured for the owner is used
+	reqPath := fmt.Sprintf("/orgs/%s/repos", url.PathEscape(g.owner))
+	if strings.EqualFold(user.Login, g.owner) {
+		reqPath = "/user/repos"
+	}
+	body := map[string]any{
+		"name":      g.name,
+		"private":   private,
+		"auto_init": true,
+	}
+	if _, _, err := g.api.Do(ctx, http.MethodPost, reqPath, body); err != nil {
+		return fmt.Errorf("error creating repository '%s/%s': %w", g.owner, g.name, err)
+	}
+	return nil
+}
+
+// branchHead returns the commit the given branch points to, or the empty string if the branch doesn't exist.
+func (g *githubProvider) branchHead(ctx context.Context, branch string) (string, error) {
+	ref := &struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}{}
+	// branch names may contain slashes, which are part of the reference path
+	if _, err := g.api.DoJSON(ctx, http.MethodGet, fmt.Sprintf("%s/git/ref/heads/%s", g.repoPath(), branch), nil, ref); err != nil {
+		if providerapi.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("error reading branch '%s': %w", branch, err)
+	}
+	return ref.Object.SHA, nil
+}
+
+func (g *githubProvider) branchExists(ctx context.Context, branch string) (bool, error) {
+	head, err := g.branchHead(ctx, branch)
+	return head != "", err
+}
+
+func (g *githubProvider) createBranch(ctx context.Context, branch, from string) error {
+	head, err := g.branchHead(ctx, from)
+	if err != nil {
+		return err
+	}
+	if head == "" {
+		return fmt.Errorf("error creating branch '%s': branch '%s' does not exist", branch, from)
+	}
+	body := map[string]any{
+		"ref": "refs/heads/" + branch,
+		"sha": head,
+	}
+	if _, _, err := g.api.Do(ctx, http.MethodPost, g.repoPath()+"/git/refs", body); err != nil {
+		return fmt.Errorf("error creating branch '%s': %w", branch, err)
+	}
+	return nil
+}
+
+func (g *githubProvider) protectBranch(ctx context.Context, branch string) error {
+	// the null values are required by the API, they disable the respective protection
+	body := map[string]any{
+		"required_status_checks":        nil,
+		"enforce_admins":                nil,
+		"required_pull_request_reviews": nil,
+		"restrictions":                  nil,
+		"allow_force_pushes":            false,
+		"allow_deletions":               false,
+	}
+	if _, _, err := g.api.Do(ctx, http.MethodPut, fmt.Sprintf("%s/branches/%s/protection", g.repoPath(), url.PathEscape(branch)), body); err != nil {
+		return fmt.Errorf("error protecting branch '%s': %w", branch, err)
+	}
+	return nil
+}
+
+// gitlabProvider manages projects on GitLab.
+type gitlabProvider struct {
+	api *providerapi.Client
+	// project is the full path of the project.
+	project string
+}
+
+func newGitLabProvider(cfg *config.GitProviderConfiguration) *gitlabProvider {
+	return &gitlabProvider{
+		api:     providerapi.NewClient(cfg.URL, cfg.Token, cfg.TokenFile, providerapi.GitLabAuth),
+		project: cfg.Repository,
+	}
+}
+
+func (g *gitlabProvider) projectPath() string {
+	return fmt.Sprintf("/api/v4/projects/%s", url.PathEscape(g.project))
+}
+
+func (g *gitlabProvider) getRepository(ctx context.Context) (string, bool, error) {
+	project := &struct {
+		DefaultBranch string `json:"default_branch"`
+	}{}
+	if _, err := g.api.DoJSON(ctx, http.MethodGet, g.projectPath(), nil, project); err != nil {
+		if providerapi.IsNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("error reading project '%s': %w", g.project, err)
+	}
+	return project.DefaultBranch, true, nil
+}
+
+func (g *gitlabProvider) createRepository(ctx context.Context, branch string, private bool) error {
+	namespacePath, name := path.Split(g.project)
+	namespace := &struct {
+		ID int `json:"id"`
+	}{}
+	if _, err := g.api.DoJSON(ctx, http.MethodGet, fmt.Sprintf("/api/v4/namespaces/%s", url.PathEscape(strings.TrimSuffix(namespacePath, "/"))), nil, namespace); err != nil {
+		return fmt.Errorf("error reading namespace of project '%s': %w", g.project, err)
+	}
+	visibility := "public"
+	if private {
+		visibility = "private"
+	}
+	body := map[string]any{
+		"name":                   name,
+		"path":                   name,
+		"namespace_id":           namespace.ID,
+		"visibility":             visibility,
+		"initialize_with_readme": true,
+		"default_branch":         branch,
+	}
+	if _, _, err := g.api.Do(ctx, http.MethodPost, "/api/v4/projects", body); err != nil {
+		return fmt.Errorf("error creating project '%s': %w", g.project, err)
+	}
+	return nil
+}
+
+func (g *gitlabProvider) branchExists(ctx context.Context, branch string) (bool, error) {
+	if _, _, err := g.api.Do(ctx, http.MethodGet, fmt.Sprintf("%s/repository/branches/%s", g.projectPath(), url.PathEscape(branch)), nil); err != nil {
+		if providerapi.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error reading branch '%s': %w", branch, err)
+	}
+	return true, nil
+}
+
+func (g *gitlabProvider) createBranch(ctx context.Context, branch, from string) error {
+	body := map[string]any{
+		"branch": branch,
+		"ref":    from,
+	}
+	if _, _, err := g.api.Do(ctx, http.MethodPost, g.projectPath()+"/repository/branches", body); err != nil {
+		return fmt.Errorf("error creating branch '%s': %w", branch, err)
+	}
+	return nil
+}
+
+func (g *gitlabProvider) protectBranch(ctx context.Context, branch string) error {
+	// 40 is the access level of maintainers
+	body := map[string]any{
+		"name":               branch,
+		"push_access_level":  40,
+		"merge_access_level": 40,
+		"allow_force_push":   false,
+	}
+	// GitLab protects the default branch of new projects by default
+	if _, _, err := g.api.Do(ctx, http.MethodPost, g.projectPath()+"/protected_branches", body); err != nil && !providerapi.IsConflict(err) {
+		return fmt.Errorf("error protecting branch '%s': %w", branch, err)
+	}
+	return nil
+}
+
+// giteaProvider manages repositories on Gitea (and Forgejo).
+type giteaProvider struct {
+	api   *providerapi.Client
+	owner string
+	name  string
+}
+
+func newGiteaProvider(cfg *config.GitProviderConfiguration) *giteaProvider {
+	owner, name, _ := strings.Cut(cfg.Repository, "/")
+	return &giteaProvider{
+		api:   providerapi.NewClient(cfg.URL, cfg.Token, cfg.TokenFile, providerapi.GiteaAuth),
+		owner: owner,
+		name:  name,
+	}
+}
+
+func (g *giteaProvider) repoPath() string {
+	return fmt.Sprintf("/api/v1/repos/%s/%s", url.PathEscape(g.owner), url.PathEscape(g.name))
+}
+
+func (g *giteaProvider) getRepository(ctx context.Context) (string, bool, error) {
+	repo := &struct {
+		DefaultBranch string `json:"default_branch"`
+		Empty         bool   `json:"empty"`
+	}{}
+	if _, err := g.api.DoJSON(ctx, http.MethodGet, g.repoPath(), nil, repo); err != nil {
+		if providerapi.IsNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("error reading repository '%s/%s': %w", g.owner, g.name, err)
+	}
+	if repo.Empty {
+		return "", true, nil
+	}
+	return repo.DefaultBranch, true, nil
+}
+
+func (g *giteaProvider) createRepository(ctx context.Context, branch string, private bool) error {
+	user := &struct {
+		Login string `json:"login"`
+	}{}
+	if _, err := g.api.DoJSON(ctx, http.MethodGet, "/api/v1/user", nil, user); err != nil {
+		return fmt.Errorf("error reading authenticated user: %w", err)
+	}
+	reqPath := fmt.Sprintf("/api/v1/orgs/%s/repos", url.PathEscape(g.owner))
+	if strings.EqualFold(user.Login, g.owner) {
+		reqPath = "/api/v1/user/repos"
+	}
+	body := map[string]any{
+		"name":           g.name,
+		"private":        private,
+		"auto_init":      true,
+		"readme":         "Default",
+		"default_branch": branch,
+	}
+	if _, _, err := g.api.Do(ctx, http.MethodPost, reqPath, body); err != nil {
+		return fmt.Errorf("error creating repository '%s/%s': %w", g.owner, g.name, err)
+	}
+	return nil
+}
+
+func (g *giteaProvider) branchExists(ctx context.Context, branch string) (bool, error) {
+	if _, _, err := g.api.Do(ctx, http.MethodGet, fmt.Sprintf("%s/branches/%s", g.repoPath(), url.PathEscape(branch)), nil); err != nil {
+		if providerapi.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error reading branch '%s': %w", branch, err)
+	}
+	return true, nil
+}
+
+func (g *giteaProvider) createBranch(ctx context.Context, branch, from string) error {
+	body := map[string]any{
+		"new_branch_name": branch,
+		"old_branch_name": from,
+	}
+	if _, _, err := g.api.Do(ctx, http.MethodPost, g.repoPath()+"/branches", body); err != nil {
+		return fmt.Errorf("error creating branch '%s': %w", branch, err)
+	}
+	return nil
+}
+
+func (g *giteaProvider) protectBranch(ctx context.Context, branch string) error {
+	// pushing stays allowed for everyone with write access, force pushes and deletion are forbidden for protected branches
+	body := map[string]any{
+		"branch_name": branch,
+		"rule_name":   branch,
+		"enable_push": true,
+	}
+	if _, _, err := g.api.Do(ctx, http.MethodPost, g.repoPath()+"/branch_protections", body); err != nil && !providerapi.IsConflict(err) {
+		return fmt.Errorf("error protecting branch '%s': %w", branch, err)
+	}
+	return nil
+}
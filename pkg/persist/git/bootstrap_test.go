@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils"
+)
+
+// fakeGitea implements the parts of the Gitea API which are used for bootstrapping the repository 'owner/repo'.
+type fakeGitea struct {
+	// defaultBranch is the default branch of the repository, the repository doesn't exist if it is empty
+	defaultBranch string
+	branches      map[string]bool
+	protected     []string
+	// writes contains the method and path of all requests which modify the repository
+	writes []string
+}
+
+func (f *fakeGitea) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "token s3cr3t" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	body := map[string]any{}
+	if r.Method != http.MethodGet {
+		f.writes = append(f.writes, r.Method+" "+r.URL.Path)
+		Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+	}
+	respond := func(status int, res any) {
+		w.WriteHeader(status)
+		Expect(json.NewEncoder(w).Encode(res)).To(Succeed())
+	}
+	const repoPath = "/api/v1/repos/owner/repo"
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/user":
+		respond(http.StatusOK, map[string]any{"login": "owner"})
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/user/repos":
+		Expect(body).To(HaveKeyWithValue("name", "repo"))
+		Expect(body).To(HaveKeyWithValue("private", true))
+		f.defaultBranch = body["default_branch"].(string)
+		f.branches = map[string]bool{f.defaultBranch: true}
+		respond(http.StatusCreated, map[string]any{})
+	case f.defaultBranch == "":
+		respond(http.StatusNotFound, map[string]any{})
+	case r.Method == http.MethodGet && r.URL.Path == repoPath:
+		respond(http.StatusOK, map[string]any{"default_branch": f.defaultBranch})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, repoPath+"/branches/"):
+		if !f.branches[strings.TrimPrefix(r.URL.Path, repoPath+"/branches/")] {
+			respond(http.StatusNotFound, map[string]any{})
+			return
+		}
+		respond(http.StatusOK, map[string]any{})
+	case r.Method == http.MethodPost && r.URL.Path == repoPath+"/branches":
+		Expect(body).To(HaveKeyWithValue("old_branch_name", f.defaultBranch))
+		f.branches[body["new_branch_name"].(string)] = true
+		respond(http.StatusCreated, map[string]any{})
+	case r.Method == http.MethodPost && r.URL.Path == repoPath+"/branch_protections":
+		f.protected = append(f.protected, body["branch_name"].(string))
+		respond(http.StatusCreated, map[string]any{})
+	default:
+		respond(http.StatusNotFound, map[string]any{})
+	}
+}
+
+var _ = Describe("Repository Bootstrap", func() {
+
+	var (
+		fake   *fakeGitea
+		server *httptest.Server
+		gitCfg *config.GitConfiguration
+	)
+
+	BeforeEach(func() {
+		fake = &fakeGitea{}
+		server = httptest.NewServer(fake)
+		gitCfg = &config.GitConfiguration{
+			URL:       server.URL + "/owner/repo.git",
+			Branch:    "k8syncer",
+			Bootstrap: true,
+			Provider: &config.GitProviderConfiguration{
+				Type:          config.GIT_PROVIDER_GITEA,
+				URL:           server.URL,
+				Repository:    "owner/repo",
+				Token:         "s3cr3t",
+				Private:       utils.Ptr(true),
+				ProtectBranch: true,
+			},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should create and protect a missing repository", func() {
+		Expect(bootstrapRepository(context.Background(), gitCfg)).To(Succeed())
+		Expect(fake.defaultBranch).To(Equal("k8syncer"))
+		Expect(fake.protected).To(ConsistOf("k8syncer"))
+		Expect(fake.writes).To(Equal([]string{"POST /api/v1/user/repos", "POST /api/v1/repos/owner/repo/branch_protections"}))
+
+		// bootstrapping an existing repository doesn't change it
+		fake.writes = nil
+		Expect(bootstrapRepository(context.Background(), gitCfg)).To(Succeed())
+		Expect(fake.writes).To(BeEmpty())
+	})
+
+	It("should create a missing branch from the default branch", func() {
+		fake.defaultBranch = "main"
+		fake.branches = map[string]bool{"main": true}
+		Expect(bootstrapRepository(context.Background(), gitCfg)).To(Succeed())
+		Expect(fake.branches).To(HaveKey("k8syncer"))
+		Expect(fake.protected).To(ConsistOf("k8syncer"))
+
+		// existing branches are not protected
+		fake.protected = nil
+		gitCfg.Branch = "main"
+		Expect(bootstrapRepository(context.Background(), gitCfg)).To(Succeed())
+		Expect(fake.protected).To(BeEmpty())
+	})
+
+	It("should return errors from the API", func() {
+		gitCfg.Provider.Token = "wrong"
+		err := bootstrapRepository(context.Background(), gitCfg)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("401"))
+	})
+
+})
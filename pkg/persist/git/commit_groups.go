@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+// commitGroupFunc returns a function which determines the commit group of a changed file by rendering the given template.
+func commitGroupFunc(tmpl *template.Template) func(path string, data []byte) (string, error) {
+	return func(path string, data []byte) (string, error) {
+		td := &config.CommitGroupTemplateData{
+			Path: path,
+		}
+		obj := &unstructured.Unstructured{}
+		// files which don't contain a resource, e.g. artifacts, only provide their path
+		if err := yaml.Unmarshal(data, &obj.Object); err == nil && obj.Object != nil {
+			td.Name = obj.GetName()
+			td.Namespace = obj.GetNamespace()
+			td.Kind = obj.GetKind()
+			td.Labels = obj.GetLabels()
+			td.Annotations = obj.GetAnnotations()
+		}
+		return config.RenderCommitGroup(tmpl, td)
+	}
+}
@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+	fspersist "github.com/gardener/k8syncer/pkg/persist/filesystem"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+	"github.com/gardener/k8syncer/pkg/utils/git"
+)
+
+// EVENT_REASON_GIT_CONFLICT is the reason of the events which are emitted for conflicts.
+const EVENT_REASON_GIT_CONFLICT = "GitConflict"
+
+// conflicts only affect the conflicting files and must not open the circuit breaker of the storage
+var _ persist.ResourceError = &git.ConflictError{}
+
+var conflictCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "k8syncer",
+	Name:      "git_conflicts_total",
+	Help:      "Number of files which have been modified both by K8Syncer and on the remote, by storage and conflict policy.",
+}, []string{"storage", "policy"})
+
+// ConflictMetrics returns the prometheus collector for the conflict metrics of all GitPersisters.
+// It has to be registered at a prometheus registry in order to be exposed.
+func ConflictMetrics() prometheus.Collector {
+	return conflictCounter
+}
+
+// SetEventRecorder sets the recorder which is used to emit events for conflicts.
+// The events are emitted for the resources stored in the conflicting files. If no recorder is set, conflicts are only logged and counted.
+// It must be called before the persister is used.
+func (p *GitPersister) SetEventRecorder(recorder record.EventRecorder) {
+	p.recorder = recorder
+}
+
+// conflictHandler returns the function which is called by the repository of the given checkout for conflicts.
+func (p *GitPersister) conflictHandler(co *checkout) func(conflicts []*git.Conflict) {
+	policy := p.storageDef.GitConfig.ConflictPolicy
+	return func(conflicts []*git.Conflict) {
+		for _, c := range conflicts {
+			conflictCounter.WithLabelValues(p.storageDef.Name, string(policy)).Inc()
+			p.log.Info("File has been modified on the remote, resolving conflict", constants.Logging.KEY_PATH, c.Path, constants.Logging.KEY_BRANCH, co.repo.Branch, constants.Logging.KEY_CONFLICT_POLICY, string(policy))
+			if policy == config.GIT_CONFLICT_POLICY_FAIL_AND_ALERT {
+				co.block(c.Path)
+			}
+			if p.recorder != nil {
+				p.emitConflictEvent(c, policy)
+			}
+		}
+	}
+}
+
+// emitConflictEvent emits an event for the given conflict on the resource which is stored in the conflicting file.
+// Files which don't contain a resource, e.g. sidecar documents, are skipped.
+func (p *GitPersister) emitConflictEvent(c *git.Conflict, policy config.GitConflictPolicy) {
+	data := c.Local
+	if data == nil {
+		data = c.Remote
+	}
+	obj, err := fspersist.ConvertFromPersistence(data)
+	if err != nil || obj.GetKind() == "" || obj.GetName() == "" {
+		return
+	}
+	var msg string
+	switch policy {
+	case config.GIT_CONFLICT_POLICY_PREFER_REMOTE:
+		msg = "the remote changes have been kept"
+	case config.GIT_CONFLICT_POLICY_FAIL_AND_ALERT:
+		msg = "the remote changes have been kept, syncing the resource fails until the file matches the resource again"
+	default:
+		msg = "the remote changes have been overwritten"
+	}
+	p.recorder.Eventf(obj, corev1.EventTypeWarning, EVENT_REASON_GIT_CONFLICT, "File '%s' in storage '%s' has been modified on the remote, %s", c.Path, p.storageDef.Name, msg)
+}
+
+// checkBlocked returns a ConflictError if the given resource's file is blocked due to an unresolved conflict.
+// The conflict is considered resolved once the file matches the given resource, in which case the file is unblocked.
+// A nil resource represents a deleted resource, whose conflict is resolved if the file doesn't exist anymore.
+func (p *GitPersister) checkBlocked(ctx context.Context, co *checkout, resource *unstructured.Unstructured, t persist.Transformer, name, namespace string, gvk schema.GroupVersionKind, subPath string) error {
+	// Exists and Differs below return a NameCollisionError if the file belongs to another resource with the same shortened name
+	relPath, _, _ := co.fsp.GetResourceFilepath(ctx, name, namespace, gvk, subPath, false)
+	relPath = strings.TrimPrefix(relPath, "/")
+	if !co.isBlocked(relPath) {
+		return nil
+	}
+	// the conflict might have been resolved on the remote, so the checkout is pulled independently of the pull configuration
+	if err := co.repo.Pull(logging.FromContextOrDiscard(ctx)); err != nil {
+		return err
+	}
+	co.markPulled()
+	var differs bool
+	var err error
+	if resource == nil {
+		differs, err = co.fsp.Exists(ctx, name, namespace, gvk, subPath)
+	} else {
+		differs, err = co.fsp.Differs(ctx, resource, t, name, subPath)
+	}
+	if err != nil {
+		return err
+	}
+	if differs {
+		return fmt.Errorf("unresolved conflict: %w", &git.ConflictError{Paths: []string{relPath}})
+	}
+	co.unblock(relPath)
+	return nil
+}
@@ -7,7 +7,13 @@ package git
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gardener/landscaper/controller-utils/pkg/logging"
 	"github.com/go-git/go-git/v5/plumbing/transport"
@@ -23,23 +29,67 @@ import (
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var _ persist.Persister = &GitPersister{}
-var _ persist.LoggerInjectable = &GitPersister{}
+var _ persist.TreeReader = &GitPersister{}
+var _ persist.ArtifactPersister = &GitPersister{}
+var _ persist.ArtifactAppender = &GitPersister{}
+var _ persist.NamespacePruner = &GitPersister{}
+var _ persist.SubPathPruner = &GitPersister{}
+var _ persist.SidecarPersister = &GitPersister{}
+var _ persist.NamespaceMetadataPersister = &GitPersister{}
+var _ persist.ChangeNotifier = &GitPersister{}
+var _ persist.HealthChecker = &GitPersister{}
+var _ persist.ResourceLister = &GitPersister{}
+var _ persist.StreamPersister = &GitPersister{}
 
 // GitPersister persists data by pushing changes to a git repository.
+// If namespace branches are configured, the resources of each namespace are pushed to a separate branch.
 type GitPersister struct {
 	persist.Persister
-	injectedLogger          *logging.Logger
-	repo                    *git.GitRepo
 	expectChangesFromRemote bool
+	// base is the checkout of the configured branch.
+	base *checkout
+	// log is used for operations which are not triggered by a persister call, e.g. pulls triggered by webhooks.
+	log logging.Logger
+	// webhook is the handler for push events, if configured.
+	// If it is set, the repository is only pulled if pullRequired is true.
+	webhook      *WebhookHandler
+	pullRequired atomic.Bool
+	// backgroundPull is the configuration for pulling in the background, if configured.
+	// If it is set, operations only pull if the last successful pull of the checkout is older than its freshness window.
+	backgroundPull *config.GitBackgroundPullConfiguration
+	// pullMinInterval is the minimum time between two pulls of a checkout which are triggered by operations.
+	// If it is 0, every operation pulls.
+	pullMinInterval time.Duration
+
+	storageDef *config.StorageDefinition
+	// namespaceBranchPrefix is the prefix of the namespace branches.
+	// It is empty if namespace branches are not configured.
+	namespaceBranchPrefix string
+	// namespaceCheckouts contains the checkouts of the namespace branches which have been used so far, mapped by namespace.
+	namespaceCheckouts map[string]*checkout
+	checkoutsLock      sync.Mutex
+	// provenance writes the provenance document before each commit, if configured.
+	provenance *provenanceWriter
+	// index writes the index document before each commit, if configured.
+	index *indexWriter
+	// ChangeListeners are notified after each pull and when a namespace has been pruned.
+	persist.ChangeListeners
+	// recorder is used to emit events for conflicts, it may be nil.
+	recorder record.EventRecorder
+	// secretReader is used to read the credentials of namespace branches from Secrets, it may be nil.
+	secretReader client.Reader
 }
 
 // New creates a new GitPersister.
 // If expectChangesFromRemote is false, the Persister assumes that it is the only one pushing to the repository and pulls only in case of an error during push.
 // If it is true, every action causes it to pull first.
-func New(ctx context.Context, stDef *config.StorageDefinition) (*GitPersister, error) {
+// The cluster name is only used for the provenance documents and may be empty.
+func New(ctx context.Context, stDef *config.StorageDefinition, clusterName string) (*GitPersister, error) {
 	log := logging.FromContextOrDiscard(ctx)
 	rootPath := stDef.FileSystemConfig.RootPath
 	var fs vfs.FileSystem
@@ -57,19 +107,22 @@ func New(ctx context.Context, stDef *config.StorageDefinition) (*GitPersister, e
 	if err != nil {
 		return nil, err
 	}
+	// the persister resolves relative paths and symbolic links in the root path
+	rootPath = fsp.RootPath
 	err = prepareFilesystem(fsp.Fs, rootPath, gitRepoName)
 	if err != nil {
 		return nil, fmt.Errorf("error while preparing git repository: %w", err)
 	}
 
 	gitCfg := stDef.GitConfig
-	gitAuth, err := git.AuthFromConfig(gitCfg.Auth)
+	authFromConfig := authFromConfigFunc(gitCfg.GitBackend)
+	gitAuth, err := authFromConfig(gitCfg.Auth)
 	if err != nil {
 		return nil, fmt.Errorf("error creating auth method from config: %w", err)
 	}
 	var gitSecondaryAuth transport.AuthMethod
 	if gitCfg.SecondaryAuth != nil {
-		gitSecondaryAuth, err = git.AuthFromConfig(gitCfg.SecondaryAuth)
+		gitSecondaryAuth, err = authFromConfig(gitCfg.SecondaryAuth)
 		if err != nil {
 			return nil, fmt.Errorf("error creating secondary auth method from config: %w", err)
 		}
@@ -79,6 +132,55 @@ func New(ctx context.Context, stDef *config.StorageDefinition) (*GitPersister, e
 	if err != nil {
 		return nil, fmt.Errorf("error during git repo creation: %w", err)
 	}
+	if gitCfg.RemoteName != "" {
+		gitRepo.RemoteName = gitCfg.RemoteName
+	}
+	gitRepo.CommitChunkSize = gitCfg.CommitChunkSize
+	gitRepo.Backend = gitCfg.GitBackend
+	gitRepo.ConflictPolicy = gitCfg.ConflictPolicy
+	if ct := gitCfg.CommitTimestamps; ct != nil {
+		if ct.TimeZone != "" {
+			gitRepo.CommitTimeZone, err = time.LoadLocation(ct.TimeZone)
+			if err != nil {
+				// should not happen, as this check is already part of the config validation
+				return nil, fmt.Errorf("invalid commit time zone: %w", err)
+			}
+		}
+		if ct.Truncate != nil {
+			gitRepo.CommitTimePrecision = ct.Truncate.Duration
+		}
+	}
+	var pw *provenanceWriter
+	if gitCfg.Provenance != nil {
+		pw, err = newProvenanceWriter(gitCfg.Provenance, clusterName)
+		if err != nil {
+			return nil, err
+		}
+		// the provenance document describes the local changes, the remote one is outdated anyway
+		gitRepo.ConflictExcludes = append(gitRepo.ConflictExcludes, ProvenanceFileName)
+		gitRepo.CommitGroupExcludes = append(gitRepo.CommitGroupExcludes, ProvenanceFileName)
+	}
+	var iw *indexWriter
+	if gitCfg.Index != nil {
+		iw = newIndexWriter(stDef, clusterName)
+		// the index document is regenerated with every commit
+		gitRepo.ConflictExcludes = append(gitRepo.ConflictExcludes, iw.fileName)
+		gitRepo.CommitGroupExcludes = append(gitRepo.CommitGroupExcludes, iw.fileName)
+	}
+	if gitCfg.CommitGroups != nil {
+		tmpl, err := config.ParseCommitGroupTemplate(gitCfg.CommitGroups.Template)
+		if err != nil {
+			// should not happen, as this check is already part of the config validation
+			return nil, fmt.Errorf("invalid commit group template: %w", err)
+		}
+		gitRepo.CommitGroup = commitGroupFunc(tmpl)
+	}
+	gitRepo.PreCommitHook = preCommitHook(gitRepo.Fs, fsp, gitRepo.Branch, pw, iw)
+	if gitCfg.Bootstrap {
+		if err := bootstrapRepository(ctx, gitCfg); err != nil {
+			return nil, fmt.Errorf("error bootstrapping git repository: %w", err)
+		}
+	}
 	err = gitRepo.Initialize(log)
 	if err != nil {
 		return nil, fmt.Errorf("error initializing git repo: %w", err)
@@ -86,71 +188,398 @@ func New(ctx context.Context, stDef *config.StorageDefinition) (*GitPersister, e
 
 	gp := &GitPersister{
 		Persister:               fsp,
-		injectedLogger:          &persist.StaticDiscardLogger,
-		repo:                    gitRepo,
 		expectChangesFromRemote: !gitCfg.Exclusive,
+		base:                    newCheckout(fsp, gitRepo),
+		log:                     log,
+		storageDef:              stDef,
+		namespaceCheckouts:      map[string]*checkout{},
+		provenance:              pw,
+		index:                   iw,
+	}
+	gitRepo.OnConflict = gp.conflictHandler(gp.base)
+	if gitCfg.NamespaceBranches != nil {
+		gp.namespaceBranchPrefix = gitCfg.NamespaceBranches.Prefix
+	}
+	if gitCfg.BackgroundPull != nil && gp.expectChangesFromRemote {
+		gp.backgroundPull = gitCfg.BackgroundPull
+	}
+	if gitCfg.PullMinInterval != nil && gp.expectChangesFromRemote {
+		gp.pullMinInterval = gitCfg.PullMinInterval.Duration
+	}
+	if gitCfg.Webhook != nil && gp.expectChangesFromRemote {
+		gp.webhook, err = NewWebhookHandler(gitCfg.Webhook, gitCfg.Branch, gp.triggerPull)
+		if err != nil {
+			return nil, fmt.Errorf("error creating webhook handler: %w", err)
+		}
 	}
 
 	return gp, nil
 }
 
-func (p *GitPersister) InjectLogger(il *logging.Logger) {
-	p.injectedLogger = il
-	// pass down injected logger to wrapped persister
-	if li, ok := p.Persister.(persist.LoggerInjectable); ok {
-		li.InjectLogger(il)
+// SetSyncConfigs sets the sync configs which are listed in the index document, if it is configured.
+// Sync configs which don't reference the storage of the persister are ignored.
+// It must be called before the persister is used.
+func (p *GitPersister) SetSyncConfigs(syncConfigs []*config.SyncConfig) {
+	if p.index != nil {
+		p.index.setSyncConfigs(syncConfigs)
 	}
 }
 
-func (p *GitPersister) Exists(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (bool, error) {
-	if p.expectChangesFromRemote {
-		err := p.repo.Pull(*p.injectedLogger)
-		if err != nil {
-			return false, err
+// preCommitHook returns the pre-commit hook which writes the configured index and provenance documents into the given repository filesystem.
+// The index document is written first, so that it is covered by the provenance document.
+// It returns nil if neither is configured.
+func preCommitHook(fs vfs.FileSystem, fsp *fspersist.FileSystemPersister, branch string, pw *provenanceWriter, iw *indexWriter) func(changed, deleted []string) error {
+	if pw == nil && iw == nil {
+		return nil
+	}
+	var provenanceHook func(changed, deleted []string) error
+	if pw != nil {
+		provenanceHook = pw.hookFor(fs)
+	}
+	return func(changed, deleted []string) error {
+		if iw != nil {
+			written, err := iw.write(fs, fsp, branch, changed)
+			if err != nil {
+				return err
+			}
+			if written && !slices.Contains(changed, iw.fileName) {
+				changed = append(changed, iw.fileName)
+			}
 		}
+		if provenanceHook != nil {
+			return provenanceHook(changed, deleted)
+		}
+		return nil
 	}
-	exists, err := p.Persister.Exists(ctx, name, namespace, gvk, subPath)
+}
+
+func (p *GitPersister) Exists(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (bool, error) {
+	co, err := p.checkoutFor(ctx, namespace)
+	if err != nil {
+		return false, err
+	}
+	if err := p.pull(logging.FromContextOrDiscard(ctx), co); err != nil {
+		return false, err
+	}
+	exists, err := co.fsp.Exists(ctx, name, namespace, gvk, subPath)
 	return exists, err
 }
 
 func (p *GitPersister) Get(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (*unstructured.Unstructured, error) {
-	if p.expectChangesFromRemote {
-		err := p.repo.Pull(*p.injectedLogger)
-		if err != nil {
-			return nil, err
-		}
+	co, err := p.checkoutFor(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.pull(logging.FromContextOrDiscard(ctx), co); err != nil {
+		return nil, err
 	}
-	data, err := p.Persister.Get(ctx, name, namespace, gvk, subPath)
+	data, err := co.fsp.Get(ctx, name, namespace, gvk, subPath)
 	return data, err
 }
 
-func (p *GitPersister) commitAndPush(resource *unstructured.Unstructured) error {
-	return p.repo.CommitAndPush(*p.injectedLogger, p.expectChangesFromRemote, fmt.Sprintf("update %s %s", utils.GVKToString(resource.GroupVersionKind(), true), getNamespacedName(resource.GetName(), resource.GetNamespace())))
+func (p *GitPersister) commitAndPush(ctx context.Context, co *checkout, msg string) error {
+	return co.repo.CommitAndPush(logging.FromContextOrDiscard(ctx), p.expectChangesFromRemote, msg)
 }
 
-func (p *GitPersister) Persist(ctx context.Context, resource *unstructured.Unstructured, t persist.Transformer, subPath string) (*unstructured.Unstructured, bool, error) {
-	if p.expectChangesFromRemote {
-		err := p.repo.Pull(*p.injectedLogger)
-		if err != nil {
-			return nil, false, err
-		}
+func (p *GitPersister) Persist(ctx context.Context, resource *unstructured.Unstructured, t persist.Transformer, name, subPath string) (*unstructured.Unstructured, bool, error) {
+	co, err := p.checkoutFor(ctx, resource.GetNamespace())
+	if err != nil {
+		return nil, false, err
 	}
-	persisted, changed, err := p.Persister.Persist(ctx, resource, t, subPath)
+	if err := p.pull(logging.FromContextOrDiscard(ctx), co); err != nil {
+		return nil, false, err
+	}
+	if err := p.checkBlocked(ctx, co, resource, t, name, resource.GetNamespace(), resource.GroupVersionKind(), subPath); err != nil {
+		return nil, false, err
+	}
+	persisted, changed, err := co.fsp.Persist(ctx, resource, t, name, subPath)
 	if err != nil {
 		return nil, false, err
 	}
 	if changed {
-		err = p.commitAndPush(persisted)
+		err = p.commitAndPush(ctx, co, fmt.Sprintf("update %s %s", utils.GVKToString(persisted.GroupVersionKind(), true), getNamespacedName(persisted.GetName(), persisted.GetNamespace())))
 	}
 	return persisted, changed, err
 }
 
 func (p *GitPersister) Delete(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) error {
-	err := p.Persister.Delete(ctx, name, namespace, gvk, subPath)
+	co, err := p.checkoutFor(ctx, namespace)
 	if err != nil {
 		return err
 	}
-	err = p.repo.CommitAndPush(*p.injectedLogger, p.expectChangesFromRemote, fmt.Sprintf("delete %s %s", utils.GVKToString(gvk, true), getNamespacedName(name, namespace)))
+	if err := p.checkBlocked(ctx, co, nil, nil, name, namespace, gvk, subPath); err != nil {
+		return err
+	}
+	err = co.fsp.Delete(ctx, name, namespace, gvk, subPath)
+	if err != nil {
+		return err
+	}
+	err = p.commitAndPush(ctx, co, fmt.Sprintf("delete %s %s", utils.GVKToString(gvk, true), getNamespacedName(name, namespace)))
+	return err
+}
+
+// ReadTree returns the contents of all persisted files below the given subPath.
+// If namespace branches are configured, the trees of all namespace branches in the remote repository are merged into the one of the configured branch.
+func (p *GitPersister) ReadTree(ctx context.Context, subPath string) (map[string][]byte, error) {
+	if err := p.pull(logging.FromContextOrDiscard(ctx), p.base); err != nil {
+		return nil, err
+	}
+	tree, err := p.base.fsp.ReadTree(ctx, subPath)
+	if err != nil || p.namespaceBranchPrefix == "" {
+		return tree, err
+	}
+	namespaces, err := p.namespaceBranchNamespaces(logging.FromContextOrDiscard(ctx))
+	if err != nil {
+		return nil, err
+	}
+	for _, namespace := range namespaces {
+		co, err := p.checkoutFor(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.pull(logging.FromContextOrDiscard(ctx), co); err != nil {
+			return nil, err
+		}
+		nsTree, err := co.fsp.ReadTree(ctx, subPath)
+		if err != nil {
+			return nil, err
+		}
+		for path, data := range nsTree {
+			tree[path] = data
+		}
+	}
+	return tree, nil
+}
+
+// List returns all resources of the given kind which are persisted below the given subPath.
+// If namespace branches are configured, the resources on all namespace branches in the remote repository are returned too.
+func (p *GitPersister) List(ctx context.Context, gvk schema.GroupVersionKind, subPath string) ([]persist.PersistedResource, error) {
+	if err := p.pull(logging.FromContextOrDiscard(ctx), p.base); err != nil {
+		return nil, err
+	}
+	res, err := p.base.fsp.List(ctx, gvk, subPath)
+	if err != nil || p.namespaceBranchPrefix == "" {
+		return res, err
+	}
+	namespaces, err := p.namespaceBranchNamespaces(logging.FromContextOrDiscard(ctx))
+	if err != nil {
+		return nil, err
+	}
+	for _, namespace := range namespaces {
+		co, err := p.checkoutFor(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.pull(logging.FromContextOrDiscard(ctx), co); err != nil {
+			return nil, err
+		}
+		nsRes, err := co.fsp.List(ctx, gvk, subPath)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, nsRes...)
+	}
+	return res, nil
+}
+
+func (p *GitPersister) PersistArtifact(ctx context.Context, data []byte, filename, subPath string) error {
+	if err := p.pull(logging.FromContextOrDiscard(ctx), p.base); err != nil {
+		return err
+	}
+	err := p.base.fsp.PersistArtifact(ctx, data, filename, subPath)
+	if err != nil {
+		return err
+	}
+	return p.commitAndPush(ctx, p.base, fmt.Sprintf("add %s", vfs.Join(p.base.repo.Fs, subPath, filename)))
+}
+
+func (p *GitPersister) AppendArtifact(ctx context.Context, data []byte, filename, subPath string) error {
+	if err := p.pull(logging.FromContextOrDiscard(ctx), p.base); err != nil {
+		return err
+	}
+	err := p.base.fsp.AppendArtifact(ctx, data, filename, subPath)
+	if err != nil {
+		return err
+	}
+	return p.commitAndPush(ctx, p.base, fmt.Sprintf("append to %s", vfs.Join(p.base.repo.Fs, subPath, filename)))
+}
+
+func (p *GitPersister) GetStream(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (io.ReadCloser, error) {
+	co, err := p.checkoutFor(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.pull(logging.FromContextOrDiscard(ctx), co); err != nil {
+		return nil, err
+	}
+	return co.fsp.GetStream(ctx, name, namespace, gvk, subPath)
+}
+
+// PersistStream writes the data read from the given reader into the file of the specified resource and commits it.
+// As the data can't be compared to the remote version of a conflicting file, files with unresolved conflicts stay blocked
+// until they have been deleted on the remote.
+func (p *GitPersister) PersistStream(ctx context.Context, data io.Reader, name, namespace string, gvk schema.GroupVersionKind, subPath string) error {
+	co, err := p.checkoutFor(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	if err := p.pull(logging.FromContextOrDiscard(ctx), co); err != nil {
+		return err
+	}
+	if err := p.checkBlocked(ctx, co, nil, nil, name, namespace, gvk, subPath); err != nil {
+		return err
+	}
+	if err := co.fsp.PersistStream(ctx, data, name, namespace, gvk, subPath); err != nil {
+		return err
+	}
+	return p.commitAndPush(ctx, co, fmt.Sprintf("update %s %s", utils.GVKToString(gvk, true), getNamespacedName(name, namespace)))
+}
+
+// PruneNamespace removes the data of the given namespace below the given subPath.
+// If namespace branches are configured, the data is removed from the namespace branch
+// and the branch itself is deleted if it doesn't contain any data afterwards.
+func (p *GitPersister) PruneNamespace(ctx context.Context, namespace, subPath, archiveSubPath string) (bool, error) {
+	// the data of many resources is removed at once, possibly on a namespace branch
+	defer p.NotifyChange()
+	co, err := p.checkoutFor(ctx, namespace)
+	if err != nil {
+		return false, err
+	}
+	if err := p.pull(logging.FromContextOrDiscard(ctx), co); err != nil {
+		return false, err
+	}
+	pruned, err := co.fsp.PruneNamespace(ctx, namespace, subPath, archiveSubPath)
+	if err != nil {
+		return pruned, err
+	}
+	if co != p.base {
+		empty, err := co.isEmpty()
+		if err != nil {
+			return pruned, fmt.Errorf("error checking whether branch '%s' is empty: %w", co.repo.Branch, err)
+		}
+		if empty {
+			// the branch is removed completely, so there is no need to commit the removal of the data first
+			return pruned, p.removeNamespaceBranch(logging.FromContextOrDiscard(ctx), namespace, co)
+		}
+	}
+	if !pruned {
+		return false, nil
+	}
+	msg := fmt.Sprintf("prune namespace %s", namespace)
+	if archiveSubPath != "" {
+		msg = fmt.Sprintf("archive namespace %s", namespace)
+	}
+	return true, p.commitAndPush(ctx, co, msg)
+}
+
+// PruneSubPath removes all data below the given subPath from the branch of the storage.
+// If namespace branches are configured, the namespace branches are not changed.
+func (p *GitPersister) PruneSubPath(ctx context.Context, subPath string) (bool, error) {
+	defer p.NotifyChange()
+	if err := p.pull(logging.FromContextOrDiscard(ctx), p.base); err != nil {
+		return false, err
+	}
+	pruned, err := p.base.fsp.PruneSubPath(ctx, subPath)
+	if err != nil || !pruned {
+		return pruned, err
+	}
+	return true, p.commitAndPush(ctx, p.base, fmt.Sprintf("prune %s", subPath))
+}
+
+func (p *GitPersister) PersistSidecar(ctx context.Context, data []byte, kind, name, namespace string, gvk schema.GroupVersionKind, subPath string) (bool, error) {
+	co, err := p.checkoutFor(ctx, namespace)
+	if err != nil {
+		return false, err
+	}
+	if err := p.pull(logging.FromContextOrDiscard(ctx), co); err != nil {
+		return false, err
+	}
+	changed, err := co.fsp.PersistSidecar(ctx, data, kind, name, namespace, gvk, subPath)
+	if err != nil || !changed {
+		return changed, err
+	}
+	return true, p.commitAndPush(ctx, co, fmt.Sprintf("update %s of %s %s", kind, utils.GVKToString(gvk, true), getNamespacedName(name, namespace)))
+}
+
+func (p *GitPersister) DeleteSidecar(ctx context.Context, kind, name, namespace string, gvk schema.GroupVersionKind, subPath string) error {
+	co, err := p.checkoutFor(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	if err := co.fsp.DeleteSidecar(ctx, kind, name, namespace, gvk, subPath); err != nil {
+		return err
+	}
+	return p.commitAndPush(ctx, co, fmt.Sprintf("delete %s of %s %s", kind, utils.GVKToString(gvk, true), getNamespacedName(name, namespace)))
+}
+
+func (p *GitPersister) PersistNamespaceMetadata(ctx context.Context, namespace *unstructured.Unstructured, t persist.Transformer, subPath string) (bool, error) {
+	co, err := p.checkoutFor(ctx, namespace.GetName())
+	if err != nil {
+		return false, err
+	}
+	if err := p.pull(logging.FromContextOrDiscard(ctx), co); err != nil {
+		return false, err
+	}
+	changed, err := co.fsp.PersistNamespaceMetadata(ctx, namespace, t, subPath)
+	if err != nil || !changed {
+		return changed, err
+	}
+	return true, p.commitAndPush(ctx, co, fmt.Sprintf("update namespace %s", namespace.GetName()))
+}
+
+// WebhookHandler returns the handler for push events from the git provider.
+// It returns nil if no webhook is configured.
+func (p *GitPersister) WebhookHandler() http.Handler {
+	if p.webhook == nil {
+		return nil
+	}
+	return p.webhook
+}
+
+// pull pulls the given checkout from the remote repository, if changes from the remote are expected.
+// If a webhook is configured, it only pulls if a push event has been received since the last pull.
+// If background pulls are configured, it only pulls if the last successful pull is older than the freshness window.
+// If a minimum pull interval is configured, it only pulls if the last successful pull is older than this interval.
+func (p *GitPersister) pull(log logging.Logger, co *checkout) error {
+	if !p.expectChangesFromRemote {
+		return nil
+	}
+	if p.webhook != nil && !p.pullRequired.CompareAndSwap(true, false) {
+		return nil
+	}
+	if p.backgroundPull != nil && co.pulledWithin(p.backgroundPull.FreshnessWindow.Duration) {
+		return nil
+	}
+	if p.pullMinInterval > 0 && co.pulledWithin(p.pullMinInterval) {
+		return nil
+	}
+	err := co.repo.Pull(log)
+	if err != nil && p.webhook != nil {
+		// retry with the next operation
+		p.pullRequired.Store(true)
+	}
+	if err == nil {
+		co.markPulled()
+		// the pull might have brought in changes to any persisted data
+		p.NotifyChange()
+	}
+	return err
+}
+
+// triggerPull is called by the webhook handler when a push event has been received.
+// It marks the repository as outdated and pulls in the background.
+func (p *GitPersister) triggerPull() {
+	p.pullRequired.Store(true)
+	go func() {
+		if err := p.pull(p.log, p.base); err != nil {
+			p.log.Error(err, "error pulling git repository after receiving push event")
+		}
+	}()
+}
+
+// CheckHealth lists the branches of the remote repository, which fails if the remote is not reachable.
+func (p *GitPersister) CheckHealth(ctx context.Context) error {
+	_, err := p.base.repo.RemoteBranches(logging.FromContextOrDiscard(ctx), p.base.repo.Branch)
 	return err
 }
 
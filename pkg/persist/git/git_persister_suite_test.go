@@ -5,21 +5,35 @@
 package git
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/mandelsoft/vfs/pkg/osfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
 	fspersist "github.com/gardener/k8syncer/pkg/persist/filesystem"
 	"github.com/gardener/k8syncer/pkg/persist/transformers"
 	"github.com/gardener/k8syncer/pkg/utils"
@@ -92,11 +106,11 @@ var _ = Describe("Git Persister Tests", func() {
 		Expect(vfs.WriteFile(testRepo.Fs, "preventEmpty", []byte{}, os.ModePerm)).To(Succeed())
 		Expect(testRepo.CommitAndPush(staticDiscardLogger, false, "add dummy file so repo won't be empty"))
 
-		gp, err := New(ctx, stDef)
+		gp, err := New(ctx, stDef, "")
 		Expect(err).ToNot(HaveOccurred())
 
 		By("persisting a new resource")
-		persisted, changed, err := gp.Persist(ctx, dummy, basicTransformer, subPath)
+		persisted, changed, err := gp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
 		Expect(err).ToNot(HaveOccurred())
 		Expect(changed).To(BeTrue())
 
@@ -109,7 +123,7 @@ var _ = Describe("Git Persister Tests", func() {
 
 		internalFsp, ok := gp.InternalPersister().(*fspersist.FileSystemPersister)
 		Expect(ok).To(BeTrue())
-		dummyFile, _ := internalFsp.GetResourceFilepath(dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath, false)
+		dummyFile, _, _ := internalFsp.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath, false)
 
 		storedRaw, err := vfs.ReadFile(testRepo.Fs, dummyFile)
 		Expect(err).ToNot(HaveOccurred())
@@ -120,11 +134,11 @@ var _ = Describe("Git Persister Tests", func() {
 		Expect(stored).To(Equal(transformed))
 
 		By("open an existing repository")
-		gp, err = New(ctx, stDef)
+		gp, err = New(ctx, stDef, "")
 		Expect(err).ToNot(HaveOccurred())
 
 		By("update an existing resource without any changes")
-		_, changed, err = gp.Persist(ctx, dummy, basicTransformer, subPath)
+		_, changed, err = gp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
 		Expect(err).ToNot(HaveOccurred())
 		Expect(changed).To(BeFalse())
 
@@ -138,7 +152,7 @@ var _ = Describe("Git Persister Tests", func() {
 		transformed, err = basicTransformer.Transform(dummy)
 		Expect(err).ToNot(HaveOccurred())
 
-		persisted, changed, err = gp.Persist(ctx, dummy, basicTransformer, subPath)
+		persisted, changed, err = gp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
 		Expect(err).ToNot(HaveOccurred())
 		Expect(changed).To(BeTrue())
 
@@ -179,4 +193,376 @@ var _ = Describe("Git Persister Tests", func() {
 		Expect(exists).To(BeFalse())
 	})
 
+	It("should commit and push resources which are persisted from streams", func() {
+		testRepo, err := dr.NewRepo()
+		Expect(err).ToNot(HaveOccurred())
+		gp, err := New(ctx, stDef, "")
+		Expect(err).ToNot(HaveOccurred())
+		sp, ok := persist.FindStreamPersister(gp)
+		Expect(ok).To(BeTrue())
+		Expect(sp).To(BeIdenticalTo(gp), "streams must not bypass the git persister")
+
+		data, err := fspersist.ConvertToPersistence(dummy, basicTransformer)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sp.PersistStream(ctx, bytes.NewReader(data), dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)).To(Succeed())
+
+		Expect(testRepo.Pull(staticDiscardLogger)).To(Succeed())
+		dummyFile, _, _ := gp.base.fsp.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath, false)
+		storedRaw, err := vfs.ReadFile(testRepo.Fs, dummyFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(storedRaw).To(Equal(data))
+
+		r, err := sp.GetStream(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		streamed, err := io.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(r.Close()).To(Succeed())
+		Expect(streamed).To(Equal(data))
+	})
+
+	It("should store namespaced resources in namespace branches", func() {
+		// workaround: go-git currently cannot delete the last file in a repository, see https://github.com/go-git/go-git/issues/723
+		testRepo, err := dr.NewRepo()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(testRepo.Fs, "preventEmpty", []byte{}, os.ModePerm)).To(Succeed())
+		Expect(testRepo.CommitAndPush(staticDiscardLogger, false, "add dummy file so repo won't be empty"))
+
+		stDef.GitConfig.NamespaceBranches = &config.GitNamespaceBranchesConfiguration{
+			Prefix: "namespaces/",
+		}
+		gp, err := New(ctx, stDef, "")
+		Expect(err).ToNot(HaveOccurred())
+
+		By("persisting a namespaced and a cluster-scoped resource")
+		_, changed, err := gp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		clusterDummy := dummy.DeepCopy()
+		clusterDummy.SetNamespace("")
+		_, changed, err = gp.Persist(ctx, clusterDummy, basicTransformer, clusterDummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+
+		internalFsp, ok := gp.InternalPersister().(*fspersist.FileSystemPersister)
+		Expect(ok).To(BeTrue())
+		dummyFile, _, _ := internalFsp.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath, false)
+		clusterDummyFile, _, _ := internalFsp.GetResourceFilepath(ctx, clusterDummy.GetName(), "", clusterDummy.GroupVersionKind(), subPath, false)
+
+		Expect(testRepo.Pull(staticDiscardLogger)).To(Succeed())
+		Expect(vfs.FileExists(testRepo.Fs, clusterDummyFile)).To(BeTrue())
+		Expect(vfs.FileExists(testRepo.Fs, dummyFile)).To(BeFalse())
+
+		nsRepoPath, err := vfs.TempDir(dr.Fs, "", "repo-")
+		Expect(err).ToNot(HaveOccurred())
+		nsRepo, err := git.NewRepo(dr.Fs, dr.RootPath, "namespaces/bar", nsRepoPath, nil, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nsRepo.Initialize(staticDiscardLogger)).To(Succeed())
+		Expect(vfs.FileExists(nsRepo.Fs, dummyFile)).To(BeTrue())
+		Expect(vfs.FileExists(nsRepo.Fs, clusterDummyFile)).To(BeFalse())
+
+		By("reading the tree of all branches")
+		tree, err := gp.ReadTree(ctx, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tree).To(HaveLen(2))
+		Expect(tree).To(HaveKey("ns_bar/dummy.v1.k8syncer.gardener.cloud_foo.yaml"))
+		Expect(tree).To(HaveKey("dummy.v1.k8syncer.gardener.cloud_foo.yaml"))
+
+		By("listing the resources of all branches")
+		resources, err := gp.List(ctx, dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(ConsistOf(
+			persist.PersistedResource{Name: dummy.GetName(), Namespace: dummy.GetNamespace()},
+			persist.PersistedResource{Name: clusterDummy.GetName()},
+		))
+
+		By("reading the resource with a new persister")
+		gp, err = New(ctx, stDef, "")
+		Expect(err).ToNot(HaveOccurred())
+		exists, err := gp.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+
+		By("pruning the namespace")
+		pruned, err := gp.PruneNamespace(ctx, dummy.GetNamespace(), subPath, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pruned).To(BeTrue())
+		_, err = dr.Repo.Reference(plumbing.NewBranchReferenceName("namespaces/bar"), false)
+		Expect(err).To(MatchError(plumbing.ErrReferenceNotFound))
+		exists, err = gp.Exists(ctx, clusterDummy.GetName(), "", clusterDummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+
+		pruned, err = gp.PruneNamespace(ctx, "missing", subPath, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pruned).To(BeFalse())
+	})
+
+	It("should pull in the background and skip pulls within the freshness window", func() {
+		testRepo, err := dr.NewRepo()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(testRepo.Fs, "preventEmpty", []byte{}, os.ModePerm)).To(Succeed())
+		Expect(testRepo.CommitAndPush(staticDiscardLogger, false, "add dummy file so repo won't be empty"))
+
+		stDef.GitConfig.Exclusive = false
+		stDef.GitConfig.BackgroundPull = &config.GitBackgroundPullConfiguration{
+			Interval:        &metav1.Duration{Duration: 50 * time.Millisecond},
+			FreshnessWindow: &metav1.Duration{Duration: time.Hour},
+		}
+		gp, err := New(ctx, stDef, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gp.HasBackgroundPull()).To(BeTrue())
+
+		By("pushing a resource from another checkout")
+		internalFsp, ok := gp.InternalPersister().(*fspersist.FileSystemPersister)
+		Expect(ok).To(BeTrue())
+		dummyFile, dummyDir, _ := internalFsp.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath, false)
+		Expect(testRepo.Fs.MkdirAll(dummyDir, os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testRepo.Fs, dummyFile, []byte("kind: Dummy"), os.ModePerm)).To(Succeed())
+		Expect(testRepo.CommitAndPush(staticDiscardLogger, false, "add dummy")).To(Succeed())
+
+		By("using the local checkout within the freshness window")
+		exists, err := gp.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+
+		By("pulling the change in the background")
+		pullCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		done := make(chan error)
+		go func() {
+			done <- gp.StartBackgroundPulls(pullCtx)
+		}()
+		Eventually(func() (bool, error) {
+			return gp.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		}).Should(BeTrue())
+		cancel()
+		Eventually(done).Should(Receive(BeNil()))
+	})
+
+	It("should skip pulls within the minimum pull interval", func() {
+		testRepo, err := dr.NewRepo()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(testRepo.Fs, "preventEmpty", []byte{}, os.ModePerm)).To(Succeed())
+		Expect(testRepo.CommitAndPush(staticDiscardLogger, false, "add dummy file so repo won't be empty"))
+
+		stDef.GitConfig.Exclusive = false
+		stDef.GitConfig.PullMinInterval = &metav1.Duration{Duration: time.Second}
+		gp, err := New(ctx, stDef, "")
+		Expect(err).ToNot(HaveOccurred())
+
+		By("pulling with the first operation")
+		exists, err := gp.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+
+		By("pushing a resource from another checkout")
+		internalFsp, ok := gp.InternalPersister().(*fspersist.FileSystemPersister)
+		Expect(ok).To(BeTrue())
+		dummyFile, dummyDir, _ := internalFsp.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath, false)
+		Expect(testRepo.Fs.MkdirAll(dummyDir, os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testRepo.Fs, dummyFile, []byte("kind: Dummy"), os.ModePerm)).To(Succeed())
+		Expect(testRepo.CommitAndPush(staticDiscardLogger, false, "add dummy")).To(Succeed())
+
+		By("using the local checkout within the minimum pull interval")
+		exists, err = gp.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+
+		By("pulling again after the minimum pull interval")
+		Eventually(func() (bool, error) {
+			return gp.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)
+		}).WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(BeTrue())
+	})
+
+	It("should keep remote changes and fail until conflicts are resolved if configured", func() {
+		// workaround: go-git currently cannot delete the last file in a repository, see https://github.com/go-git/go-git/issues/723
+		testRepo, err := dr.NewRepo()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(testRepo.Fs, "preventEmpty", []byte{}, os.ModePerm)).To(Succeed())
+		Expect(testRepo.CommitAndPush(staticDiscardLogger, false, "add dummy file so repo won't be empty"))
+
+		stDef.GitConfig.ConflictPolicy = config.GIT_CONFLICT_POLICY_FAIL_AND_ALERT
+		gp, err := New(ctx, stDef, "")
+		Expect(err).ToNot(HaveOccurred())
+		recorder := record.NewFakeRecorder(10)
+		gp.SetEventRecorder(recorder)
+		metric := conflictCounter.WithLabelValues(stDef.Name, string(config.GIT_CONFLICT_POLICY_FAIL_AND_ALERT))
+		conflictsBefore := testutil.ToFloat64(metric)
+
+		_, _, err = gp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		By("modifying the resource on the remote")
+		internalFsp, ok := gp.InternalPersister().(*fspersist.FileSystemPersister)
+		Expect(ok).To(BeTrue())
+		dummyFile, _, _ := internalFsp.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath, false)
+		Expect(testRepo.Pull(staticDiscardLogger)).To(Succeed())
+		remote := dummy.DeepCopy()
+		Expect(unstructured.SetNestedField(remote.Object, "remote", "spec", "value")).To(Succeed())
+		remoteData, err := fspersist.ConvertToPersistence(remote, basicTransformer)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(testRepo.Fs, dummyFile, remoteData, os.ModePerm)).To(Succeed())
+		Expect(testRepo.CommitAndPush(staticDiscardLogger, false, "modify dummy")).To(Succeed())
+
+		By("failing to persist a conflicting change")
+		Expect(unstructured.SetNestedField(dummy.Object, "cluster", "spec", "value")).To(Succeed())
+		_, _, err = gp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(git.IsConflictError(err)).To(BeTrue(), "expected conflict error, got %v", err)
+		Expect(testutil.ToFloat64(metric)).To(Equal(conflictsBefore + 1))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EVENT_REASON_GIT_CONFLICT)))
+		Expect(testRepo.Pull(staticDiscardLogger)).To(Succeed())
+		stored, err := vfs.ReadFile(testRepo.Fs, dummyFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stored).To(Equal(remoteData))
+
+		By("failing until the conflict has been resolved")
+		_, _, err = gp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(git.IsConflictError(err)).To(BeTrue(), "expected conflict error, got %v", err)
+		resolvedData, err := fspersist.ConvertToPersistence(dummy, basicTransformer)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(testRepo.Fs, dummyFile, resolvedData, os.ModePerm)).To(Succeed())
+		Expect(testRepo.CommitAndPush(staticDiscardLogger, false, "resolve conflict")).To(Succeed())
+		_, changed, err := gp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeFalse())
+
+		By("persisting further changes")
+		Expect(unstructured.SetNestedField(dummy.Object, "updated", "spec", "value")).To(Succeed())
+		_, changed, err = gp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+	})
+
+	It("should add a signed provenance document to each commit", func() {
+		// workaround: go-git currently cannot delete the last file in a repository, see https://github.com/go-git/go-git/issues/723
+		testRepo, err := dr.NewRepo()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(testRepo.Fs, "preventEmpty", []byte{}, os.ModePerm)).To(Succeed())
+		Expect(testRepo.CommitAndPush(staticDiscardLogger, false, "add dummy file so repo won't be empty"))
+
+		pub, priv, err := ed25519.GenerateKey(nil)
+		Expect(err).ToNot(HaveOccurred())
+		keyData, err := x509.MarshalPKCS8PrivateKey(priv)
+		Expect(err).ToNot(HaveOccurred())
+		stDef.GitConfig.Provenance = &config.GitProvenanceConfiguration{
+			SigningKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyData})),
+		}
+		gp, err := New(ctx, stDef, "my-cluster")
+		Expect(err).ToNot(HaveOccurred())
+
+		By("persisting a resource")
+		_, changed, err := gp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+
+		internalFsp, ok := gp.InternalPersister().(*fspersist.FileSystemPersister)
+		Expect(ok).To(BeTrue())
+		dummyFile, _, _ := internalFsp.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath, false)
+		dummyPath := strings.TrimPrefix(dummyFile, "/")
+
+		Expect(testRepo.Pull(staticDiscardLogger)).To(Succeed())
+		storedRaw, err := vfs.ReadFile(testRepo.Fs, dummyFile)
+		Expect(err).ToNot(HaveOccurred())
+		sum := sha256.Sum256(storedRaw)
+		provRaw, err := vfs.ReadFile(testRepo.Fs, ProvenanceFileName)
+		Expect(err).ToNot(HaveOccurred())
+		prov, err := VerifyProvenance(provRaw, pub)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(prov.ClusterName).To(Equal("my-cluster"))
+		Expect(prov.K8SyncerVersion).ToNot(BeEmpty())
+		Expect(prov.Files).To(ConsistOf(ProvenanceFile{
+			Path:   dummyPath,
+			Digest: "sha256:" + hex.EncodeToString(sum[:]),
+		}))
+
+		By("verifying against a different key")
+		otherPub, _, err := ed25519.GenerateKey(nil)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = VerifyProvenance(provRaw, otherPub)
+		Expect(err).To(HaveOccurred())
+
+		By("deleting the resource")
+		Expect(gp.Delete(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), subPath)).To(Succeed())
+		Expect(testRepo.Pull(staticDiscardLogger)).To(Succeed())
+		provRaw, err = vfs.ReadFile(testRepo.Fs, ProvenanceFileName)
+		Expect(err).ToNot(HaveOccurred())
+		prov, err = VerifyProvenance(provRaw, pub)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(prov.Files).To(ConsistOf(ProvenanceFile{
+			Path:    dummyPath,
+			Deleted: true,
+		}))
+
+		By("not reading the provenance document as part of the tree")
+		tree, err := gp.ReadTree(ctx, subPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tree).To(BeEmpty())
+	})
+
+	It("should maintain an index document in the repository root", func() {
+		testRepo, err := dr.NewRepo()
+		Expect(err).ToNot(HaveOccurred())
+
+		stDef.GitConfig.Index = &config.GitIndexConfiguration{
+			FileName: "INDEX.md",
+		}
+		stDef.GitConfig.Provenance = &config.GitProvenanceConfiguration{}
+		gp, err := New(ctx, stDef, "my-cluster")
+		Expect(err).ToNot(HaveOccurred())
+		gp.SetSyncConfigs([]*config.SyncConfig{
+			{
+				ID: "dummies",
+				Resource: &config.ResourceSyncConfig{
+					Group:   "k8syncer.gardener.cloud",
+					Version: "v1",
+					Kind:    "Dummy",
+				},
+				StorageRefs: []*config.StorageReference{{Name: stDef.Name, SubPath: "sub"}},
+			},
+			{
+				ID: "other",
+				Resource: &config.ResourceSyncConfig{
+					Version: "v1",
+					Kind:    "ConfigMap",
+				},
+				StorageRefs: []*config.StorageReference{{Name: "otherStorage"}},
+			},
+		})
+
+		By("persisting a resource")
+		_, changed, err := gp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "sub")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+
+		Expect(testRepo.Pull(staticDiscardLogger)).To(Succeed())
+		indexRaw, err := vfs.ReadFile(testRepo.Fs, "INDEX.md")
+		Expect(err).ToNot(HaveOccurred())
+		index := string(indexRaw)
+		Expect(index).To(ContainSubstring("- Source cluster: `my-cluster`"))
+		Expect(index).To(ContainSubstring("| `dummies` | `dummy.v1.k8syncer.gardener.cloud` | all | `sub/ns_<namespace>/dummy.v1.k8syncer.gardener.cloud_<name>.yaml` |"))
+		Expect(index).ToNot(ContainSubstring("`other`"))
+		Expect(index).To(ContainSubstring("| `Dummy.k8syncer.gardener.cloud` |"))
+		data := parseIndexData(indexRaw)
+		Expect(data.LastSynced).To(HaveKey("Dummy.k8syncer.gardener.cloud"))
+
+		By("covering the index document with the provenance document")
+		provRaw, err := vfs.ReadFile(testRepo.Fs, ProvenanceFileName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(provRaw)).To(ContainSubstring("path: INDEX.md"))
+
+		By("keeping the last sync times of other kinds")
+		data.LastSynced["Other.example.org"] = "2024-01-01T00:00:00Z"
+		dataRaw, err := json.Marshal(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(gp.base.repo.Fs, "INDEX.md", []byte(indexDataPrefix+string(dataRaw)+indexDataSuffix), os.ModePerm)).To(Succeed())
+		Expect(unstructured.SetNestedField(dummy.Object, "updated", "spec", "value")).To(Succeed())
+		_, _, err = gp.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "sub")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(testRepo.Pull(staticDiscardLogger)).To(Succeed())
+		indexRaw, err = vfs.ReadFile(testRepo.Fs, "INDEX.md")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(indexRaw)).To(ContainSubstring("| `Other.example.org` | 2024-01-01T00:00:00Z |"))
+		Expect(string(indexRaw)).To(ContainSubstring("# myStorage"))
+	})
+
 })
@@ -0,0 +1,198 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	fspersist "github.com/gardener/k8syncer/pkg/persist/filesystem"
+	"github.com/gardener/k8syncer/pkg/utils"
+)
+
+const (
+	// indexDataPrefix and indexDataSuffix enclose the machine-readable part of the index document.
+	// It is hidden in an HTML comment, so that it is not rendered.
+	indexDataPrefix = "<!-- k8syncer-index-data: "
+	indexDataSuffix = " -->"
+)
+
+// indexData is the part of the index document which has to be kept between commits.
+type indexData struct {
+	// LastSynced maps group kinds to the time at which a resource of the kind has been written last, in RFC 3339 format.
+	LastSynced map[string]string `json:"lastSynced"`
+}
+
+// indexWriter writes the index document into the worktree of a repository before changes are committed.
+type indexWriter struct {
+	fileName    string
+	storageName string
+	clusterName string
+	// namespaceBranchPrefix is the prefix of the namespace branches, it is empty if namespace branches are not configured.
+	namespaceBranchPrefix string
+
+	lock        sync.RWMutex
+	syncConfigs []*config.SyncConfig
+}
+
+// newIndexWriter creates a new indexWriter for the given storage definition.
+func newIndexWriter(stDef *config.StorageDefinition, clusterName string) *indexWriter {
+	iw := &indexWriter{
+		fileName:    stDef.GitConfig.Index.FileName,
+		storageName: stDef.Name,
+		clusterName: clusterName,
+	}
+	if stDef.GitConfig.NamespaceBranches != nil {
+		iw.namespaceBranchPrefix = stDef.GitConfig.NamespaceBranches.Prefix
+	}
+	return iw
+}
+
+// setSyncConfigs sets the sync configs which are listed in the index document.
+// Sync configs which don't reference the storage are ignored.
+func (iw *indexWriter) setSyncConfigs(syncConfigs []*config.SyncConfig) {
+	iw.lock.Lock()
+	defer iw.lock.Unlock()
+	iw.syncConfigs = syncConfigs
+}
+
+// write updates the index document in the given repository filesystem for the given changed files.
+// It returns true if the document has been changed.
+func (iw *indexWriter) write(fs vfs.FileSystem, fsp *fspersist.FileSystemPersister, branch string, changed []string) (bool, error) {
+	old, err := vfs.ReadFile(fs, iw.fileName)
+	if err != nil && !vfs.IsErrNotExist(err) {
+		return false, fmt.Errorf("error reading index document: %w", err)
+	}
+	// a missing or unparsable document is regenerated from scratch
+	data := parseIndexData(old)
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, path := range changed {
+		if path == iw.fileName || path == ProvenanceFileName {
+			continue
+		}
+		raw, err := vfs.ReadFile(fs, path)
+		if err != nil {
+			return false, fmt.Errorf("error reading changed file '%s': %w", path, err)
+		}
+		obj, err := fspersist.ConvertFromPersistence(raw)
+		if err != nil || obj.GetKind() == "" {
+			// not a resource, e.g. a sidecar document
+			continue
+		}
+		data.LastSynced[obj.GroupVersionKind().GroupKind().String()] = now
+	}
+
+	doc, err := iw.render(fsp, branch, data)
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(doc, old) {
+		return false, nil
+	}
+	if err := vfs.WriteFile(fs, iw.fileName, doc, os.ModePerm); err != nil {
+		return false, fmt.Errorf("error writing index document: %w", err)
+	}
+	return true, nil
+}
+
+// render returns the index document for the given data.
+func (iw *indexWriter) render(fsp *fspersist.FileSystemPersister, branch string, data *indexData) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "# %s\n\n", iw.storageName)
+	buf.WriteString("The resources in this repository are synced from a Kubernetes cluster by [K8Syncer](https://github.com/gardener/k8syncer).\n")
+	buf.WriteString("This file is generated by K8Syncer with every commit, manual changes to it are overwritten.\n\n")
+	if iw.clusterName != "" {
+		fmt.Fprintf(buf, "- Source cluster: `%s`\n", iw.clusterName)
+	}
+	fmt.Fprintf(buf, "- Branch: `%s`\n", branch)
+	if iw.namespaceBranchPrefix != "" {
+		fmt.Fprintf(buf, "- Namespaced resources are stored in the branch `%s<namespace>` of their namespace.\n", iw.namespaceBranchPrefix)
+	}
+
+	buf.WriteString("\n## Sync Configurations\n\n")
+	buf.WriteString("| Sync Config | Resource | Namespace | Path |\n")
+	buf.WriteString("| --- | --- | --- | --- |\n")
+	iw.lock.RLock()
+	for _, sc := range iw.syncConfigs {
+		if sc.Resource == nil {
+			continue
+		}
+		gvk := schema.GroupVersionKind{Group: sc.Resource.Group, Version: sc.Resource.Version, Kind: sc.Resource.Kind}
+		if sc.Resource.PersistVersion != "" {
+			gvk.Version = sc.Resource.PersistVersion
+		}
+		namespace := "all"
+		if sc.Resource.Namespace != "" {
+			namespace = fmt.Sprintf("`%s`", sc.Resource.Namespace)
+		}
+		for _, ref := range sc.StorageRefs {
+			if ref.Name != iw.storageName {
+				continue
+			}
+			// the index is rendered by the pre-commit hook, which has no request context, and only shows the path pattern,
+			// so it doesn't matter whether the placeholder has been shortened
+			path, _, _ := fsp.GetResourceFilepath(context.Background(), indexNamePlaceholder(ref.FileNaming), "<namespace>", gvk, ref.SubPath, false)
+			fmt.Fprintf(buf, "| `%s` | `%s` | %s | `%s` |\n", sc.ID, utils.GVKToString(gvk, true), namespace, strings.TrimPrefix(path, "/"))
+		}
+	}
+	iw.lock.RUnlock()
+	buf.WriteString("\nCluster-scoped resources are stored without a namespace directory, or in a dedicated directory for the `argocd` layout.\n")
+
+	buf.WriteString("\n## Last Sync\n\n")
+	buf.WriteString("| Kind | Last Written |\n")
+	buf.WriteString("| --- | --- |\n")
+	kinds := make([]string, 0, len(data.LastSynced))
+	for kind := range data.LastSynced {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		fmt.Fprintf(buf, "| `%s` | %s |\n", kind, data.LastSynced[kind])
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling index data: %w", err)
+	}
+	fmt.Fprintf(buf, "\n%s%s%s\n", indexDataPrefix, string(raw), indexDataSuffix)
+	return buf.Bytes(), nil
+}
+
+// parseIndexData extracts the machine-readable data from the given index document.
+// It returns empty data if the document doesn't contain any.
+func parseIndexData(doc []byte) *indexData {
+	data := &indexData{}
+	if idx := bytes.LastIndex(doc, []byte(indexDataPrefix)); idx >= 0 {
+		raw, _, _ := bytes.Cut(doc[idx+len(indexDataPrefix):], []byte(indexDataSuffix))
+		_ = json.Unmarshal(raw, data)
+	}
+	if data.LastSynced == nil {
+		data.LastSynced = map[string]string{}
+	}
+	return data
+}
+
+// indexNamePlaceholder returns the placeholder for the part of the file name which is derived from the resource, depending on the file naming.
+func indexNamePlaceholder(naming config.FileNaming) string {
+	switch naming {
+	case config.FILE_NAMING_UID:
+		return "<uid>"
+	case config.FILE_NAMING_NAME_AND_UID:
+		return "<name>_<uid>"
+	}
+	return "<name>"
+}
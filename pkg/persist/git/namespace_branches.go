@@ -0,0 +1,251 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	fspersist "github.com/gardener/k8syncer/pkg/persist/filesystem"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+	"github.com/gardener/k8syncer/pkg/utils/credentials"
+	"github.com/gardener/k8syncer/pkg/utils/git"
+)
+
+// errNotEmpty is used to abort walking a checkout as soon as a file has been found.
+var errNotEmpty = errors.New("checkout is not empty")
+
+// checkout is a local checkout of a single branch of the repository, together with the persister working on it.
+type checkout struct {
+	fsp  *fspersist.FileSystemPersister
+	repo *git.GitRepo
+	// lastPull is the time of the last successful pull in unix nanoseconds.
+	// As the checkout is up-to-date after cloning or opening the repository, it is initialized with the creation time.
+	lastPull atomic.Int64
+	// blocked contains the paths of the files, relative to the repository root, whose conflicts have not been resolved yet.
+	// It is only used for the 'failAndAlert' conflict policy.
+	blocked     map[string]struct{}
+	blockedLock sync.Mutex
+}
+
+func newCheckout(fsp *fspersist.FileSystemPersister, repo *git.GitRepo) *checkout {
+	co := &checkout{
+		fsp:     fsp,
+		repo:    repo,
+		blocked: map[string]struct{}{},
+	}
+	co.markPulled()
+	return co
+}
+
+// checkoutFor returns the checkout of the branch which contains the resources of the given namespace.
+// If namespace branches are not configured or the namespace is empty, the checkout of the configured branch is returned.
+// Namespace branches are checked out on first use and created if they don't exist yet.
+func (p *GitPersister) checkoutFor(ctx context.Context, namespace string) (*checkout, error) {
+	if p.namespaceBranchPrefix == "" || namespace == "" {
+		return p.base, nil
+	}
+	p.checkoutsLock.Lock()
+	defer p.checkoutsLock.Unlock()
+	if co, ok := p.namespaceCheckouts[namespace]; ok {
+		return co, nil
+	}
+	co, err := p.newNamespaceCheckout(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error checking out branch for namespace '%s': %w", namespace, err)
+	}
+	p.namespaceCheckouts[namespace] = co
+	return co, nil
+}
+
+// newNamespaceCheckout checks out the branch for the given namespace.
+// For in-memory filesystems, each branch uses its own filesystem, otherwise the branches are checked out
+// to '<rootPath>.branches/<namespace>', as they must not be contained in the checkout of the configured branch.
+func (p *GitPersister) newNamespaceCheckout(ctx context.Context, namespace string) (*checkout, error) {
+	branch := p.namespaceBranchPrefix + namespace
+	fsCfg := p.storageDef.FileSystemConfig.DeepCopy()
+	var fs vfs.FileSystem
+	if *fsCfg.InMemory {
+		fs = memoryfs.New()
+	} else {
+		fs = osfs.New()
+		fsCfg.RootPath = namespaceCheckoutPath(fsCfg.RootPath, namespace)
+	}
+	if err := fs.MkdirAll(fsCfg.RootPath, os.ModeDir|os.ModePerm); err != nil {
+		return nil, fmt.Errorf("error creating directory for branch '%s': %w", branch, err)
+	}
+	fsp, err := fspersist.New(fs, fsCfg, false)
+	if err != nil {
+		return nil, err
+	}
+	gitCfg := p.storageDef.GitConfig
+	auth, secondaryAuth, err := p.namespaceAuth(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := git.NewRepo(fs, gitCfg.URL, branch, fsCfg.RootPath, auth, secondaryAuth)
+	if err != nil {
+		return nil, fmt.Errorf("error during git repo creation: %w", err)
+	}
+	repo.RemoteName = p.base.repo.RemoteName
+	repo.CommitChunkSize = p.base.repo.CommitChunkSize
+	repo.Backend = p.base.repo.Backend
+	repo.ConflictPolicy = p.base.repo.ConflictPolicy
+	repo.CommitTimeZone = p.base.repo.CommitTimeZone
+	repo.CommitTimePrecision = p.base.repo.CommitTimePrecision
+	repo.PreCommitHook = preCommitHook(repo.Fs, fsp, branch, p.provenance, p.index)
+	repo.ConflictExcludes = p.base.repo.ConflictExcludes
+	repo.CommitGroup = p.base.repo.CommitGroup
+	repo.CommitGroupExcludes = p.base.repo.CommitGroupExcludes
+	log := logging.FromContextOrDiscard(ctx)
+	log.Debug("Checking out namespace branch", constants.Logging.KEY_BRANCH, branch)
+	if err := repo.Initialize(log); err != nil {
+		return nil, fmt.Errorf("error initializing git repo: %w", err)
+	}
+	co := newCheckout(fsp, repo)
+	repo.OnConflict = p.conflictHandler(co)
+	return co, nil
+}
+
+// SetSecretReader sets the reader which is used to read the credentials of namespace branches from the configured auth secret.
+// It must be called before the persister is used, if an auth secret is configured.
+func (p *GitPersister) SetSecretReader(reader client.Reader) {
+	p.secretReader = reader
+}
+
+// namespaceAuth returns the auth methods for the branch of the given namespace.
+// Namespaces which are mapped to own credentials or covered by the auth secret never fall back to the storage's auth,
+// so their resources are not pushed at all if the credentials cannot be determined.
+func (p *GitPersister) namespaceAuth(ctx context.Context, namespace string) (transport.AuthMethod, transport.AuthMethod, error) {
+	nbCfg := p.storageDef.GitConfig.NamespaceBranches
+	for _, m := range nbCfg.Namespaces {
+		if m.Namespace != namespace {
+			continue
+		}
+		auth, err := authFromConfigFunc(p.storageDef.GitConfig.GitBackend)(m.Auth)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error creating auth method for namespace '%s': %w", namespace, err)
+		}
+		return auth, nil, nil
+	}
+	if as := nbCfg.AuthSecret; as != nil {
+		if p.secretReader == nil {
+			return nil, nil, fmt.Errorf("unable to read auth secret for namespace '%s', no secret reader has been set", namespace)
+		}
+		provider := credentials.NewSecretProvider(p.secretReader, namespace, as.Name, as.RefreshInterval.Duration)
+		// fail early, instead of trying to push without credentials
+		if _, err := provider.Credentials(ctx); err != nil {
+			return nil, nil, fmt.Errorf("error reading auth secret for namespace '%s': %w", namespace, err)
+		}
+		return git.NewProviderAuth(as.Type, provider), nil, nil
+	}
+	return p.base.repo.Auth, p.base.repo.SecondaryAuth, nil
+}
+
+// authFromConfigFunc returns the function which creates auth methods for the given git backend.
+func authFromConfigFunc(backend config.GitBackend) func(*config.GitRepoAuth) (transport.AuthMethod, error) {
+	if backend == config.GIT_BACKEND_CLI {
+		return git.CLIAuthFromConfig
+	}
+	return git.AuthFromConfig
+}
+
+// removeNamespaceBranch deletes the branch of the given namespace from the remote repository and removes its local checkout.
+func (p *GitPersister) removeNamespaceBranch(log logging.Logger, namespace string, co *checkout) error {
+	log.Debug("Deleting namespace branch", constants.Logging.KEY_BRANCH, co.repo.Branch)
+	if err := co.repo.DeleteRemoteBranch(log); err != nil {
+		return err
+	}
+	p.checkoutsLock.Lock()
+	defer p.checkoutsLock.Unlock()
+	delete(p.namespaceCheckouts, namespace)
+	if !*p.storageDef.FileSystemConfig.InMemory {
+		if err := co.fsp.Fs.RemoveAll(co.fsp.RootPath); err != nil {
+			return fmt.Errorf("error removing local checkout of branch '%s': %w", co.repo.Branch, err)
+		}
+	}
+	return nil
+}
+
+// namespaceBranchNamespaces returns the namespaces for which a namespace branch exists in the remote repository.
+func (p *GitPersister) namespaceBranchNamespaces(log logging.Logger) ([]string, error) {
+	branches, err := p.base.repo.RemoteBranches(log, p.namespaceBranchPrefix)
+	if err != nil {
+		return nil, err
+	}
+	namespaces := make([]string, 0, len(branches))
+	for _, branch := range branches {
+		namespaces = append(namespaces, strings.TrimPrefix(branch, p.namespaceBranchPrefix))
+	}
+	return namespaces, nil
+}
+
+// markPulled records that the checkout has been pulled successfully.
+func (co *checkout) markPulled() {
+	co.lastPull.Store(time.Now().UnixNano())
+}
+
+// pulledWithin returns true if the last successful pull of the checkout happened within the given duration.
+func (co *checkout) block(path string) {
+	co.blockedLock.Lock()
+	defer co.blockedLock.Unlock()
+	co.blocked[path] = struct{}{}
+}
+
+func (co *checkout) unblock(path string) {
+	co.blockedLock.Lock()
+	defer co.blockedLock.Unlock()
+	delete(co.blocked, path)
+}
+
+func (co *checkout) isBlocked(path string) bool {
+	co.blockedLock.Lock()
+	defer co.blockedLock.Unlock()
+	_, ok := co.blocked[path]
+	return ok
+}
+
+func (co *checkout) pulledWithin(d time.Duration) bool {
+	return time.Since(time.Unix(0, co.lastPull.Load())) < d
+}
+
+// isEmpty returns true if the checkout doesn't contain any files, apart from the git metadata.
+func (co *checkout) isEmpty() (bool, error) {
+	err := vfs.Walk(co.repo.Fs, vfs.PathSeparatorString, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return vfs.SkipDir
+			}
+			return nil
+		}
+		return errNotEmpty
+	})
+	if errors.Is(err, errNotEmpty) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func namespaceCheckoutPath(rootPath, namespace string) string {
+	return filepath.Join(fmt.Sprintf("%s.branches", filepath.Clean(rootPath)), namespace)
+}
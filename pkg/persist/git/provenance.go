@@ -0,0 +1,212 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/version"
+)
+
+const (
+	// ProvenanceFileName is the name of the file in the root of the repository which contains the provenance document of the last commit.
+	ProvenanceFileName = ".k8syncer-provenance.yaml"
+	// ProvenanceSignatureAlgorithm is the algorithm used for signing provenance documents.
+	ProvenanceSignatureAlgorithm = "ed25519"
+)
+
+// Provenance describes the origin of the changes of a commit.
+type Provenance struct {
+	// K8SyncerVersion is the version of K8Syncer which created the commit.
+	K8SyncerVersion string `json:"k8syncerVersion"`
+	// ClusterName is the name of the cluster the resources have been synced from, if configured.
+	ClusterName string `json:"clusterName,omitempty"`
+	// Timestamp is the time at which the document has been created, in RFC 3339 format.
+	Timestamp string `json:"timestamp"`
+	// Files contains the files which are changed by the commit.
+	Files []ProvenanceFile `json:"files"`
+	// Signature is the signature of the document, if a signing key is configured.
+	// It is computed over the JSON encoding of the document without the signature.
+	Signature *ProvenanceSignature `json:"signature,omitempty"`
+}
+
+// ProvenanceFile describes a file which is changed by a commit.
+type ProvenanceFile struct {
+	// Path is the path of the file, relative to the repository root.
+	Path string `json:"path"`
+	// Digest is the digest of the file's content in the format 'sha256:<hex>'.
+	// It is empty for deleted files.
+	Digest string `json:"digest,omitempty"`
+	// Deleted is true if the file is deleted by the commit.
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// ProvenanceSignature is the signature of a provenance document.
+type ProvenanceSignature struct {
+	// Algorithm is the signature algorithm, currently always 'ed25519'.
+	Algorithm string `json:"algorithm"`
+	// PublicKey is the base64-encoded public key belonging to the signing key.
+	// It is informational only, consumers must verify the signature against a trusted key.
+	PublicKey string `json:"publicKey"`
+	// Value is the base64-encoded signature.
+	Value string `json:"value"`
+}
+
+// provenanceWriter writes provenance documents into the worktree of a repository before changes are committed.
+type provenanceWriter struct {
+	clusterName string
+	// key is the signing key, documents are not signed if it is nil.
+	key ed25519.PrivateKey
+}
+
+// newProvenanceWriter creates a new provenanceWriter from the given configuration.
+func newProvenanceWriter(cfg *config.GitProvenanceConfiguration, clusterName string) (*provenanceWriter, error) {
+	pw := &provenanceWriter{
+		clusterName: clusterName,
+	}
+	keyData := []byte(cfg.SigningKey)
+	if cfg.SigningKeyFile != "" {
+		data, err := os.ReadFile(cfg.SigningKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading provenance signing key file: %w", err)
+		}
+		keyData = data
+	}
+	if len(keyData) > 0 {
+		key, err := parseSigningKey(keyData)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing provenance signing key: %w", err)
+		}
+		pw.key = key
+	}
+	return pw, nil
+}
+
+// hookFor returns a pre-commit hook which writes the provenance document for the changes into the given repository filesystem.
+func (pw *provenanceWriter) hookFor(fs vfs.FileSystem) func(changed, deleted []string) error {
+	return func(changed, deleted []string) error {
+		prov := &Provenance{
+			K8SyncerVersion: version.Get().GitVersion,
+			ClusterName:     pw.clusterName,
+			Timestamp:       time.Now().UTC().Format(time.RFC3339),
+			Files:           make([]ProvenanceFile, 0, len(changed)+len(deleted)),
+		}
+		for _, path := range changed {
+			if path == ProvenanceFileName {
+				continue
+			}
+			data, err := vfs.ReadFile(fs, path)
+			if err != nil {
+				return fmt.Errorf("error reading changed file '%s': %w", path, err)
+			}
+			sum := sha256.Sum256(data)
+			prov.Files = append(prov.Files, ProvenanceFile{
+				Path:   path,
+				Digest: "sha256:" + hex.EncodeToString(sum[:]),
+			})
+		}
+		for _, path := range deleted {
+			if path == ProvenanceFileName {
+				continue
+			}
+			prov.Files = append(prov.Files, ProvenanceFile{
+				Path:    path,
+				Deleted: true,
+			})
+		}
+		if pw.key != nil {
+			if err := prov.sign(pw.key); err != nil {
+				return err
+			}
+		}
+		data, err := yaml.Marshal(prov)
+		if err != nil {
+			return fmt.Errorf("error marshalling provenance document: %w", err)
+		}
+		return vfs.WriteFile(fs, ProvenanceFileName, data, os.ModePerm)
+	}
+}
+
+// sign signs the provenance document with the given key and sets its signature.
+func (prov *Provenance) sign(key ed25519.PrivateKey) error {
+	payload, err := prov.signaturePayload()
+	if err != nil {
+		return err
+	}
+	prov.Signature = &ProvenanceSignature{
+		Algorithm: ProvenanceSignatureAlgorithm,
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+		Value:     base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload)),
+	}
+	return nil
+}
+
+// signaturePayload returns the data the signature is computed over.
+func (prov *Provenance) signaturePayload() ([]byte, error) {
+	unsigned := *prov
+	unsigned.Signature = nil
+	payload, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling provenance document: %w", err)
+	}
+	return payload, nil
+}
+
+// VerifyProvenance parses the given provenance document and verifies its signature against the given public key.
+// It returns an error if the document is not signed or the signature is invalid.
+func VerifyProvenance(data []byte, publicKey ed25519.PublicKey) (*Provenance, error) {
+	prov := &Provenance{}
+	if err := yaml.Unmarshal(data, prov); err != nil {
+		return nil, fmt.Errorf("error parsing provenance document: %w", err)
+	}
+	if prov.Signature == nil {
+		return nil, fmt.Errorf("provenance document is not signed")
+	}
+	if prov.Signature.Algorithm != ProvenanceSignatureAlgorithm {
+		return nil, fmt.Errorf("unsupported signature algorithm '%s'", prov.Signature.Algorithm)
+	}
+	sig, err := base64.StdEncoding.DecodeString(prov.Signature.Value)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding signature: %w", err)
+	}
+	payload, err := prov.signaturePayload()
+	if err != nil {
+		return nil, err
+	}
+	if !ed25519.Verify(publicKey, payload, sig) {
+		return nil, fmt.Errorf("invalid signature")
+	}
+	return prov, nil
+}
+
+// parseSigningKey parses a PEM-encoded ed25519 private key in PKCS #8 format.
+func parseSigningKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("expected an ed25519 private key, got %T", key)
+	}
+	return edKey, nil
+}
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+const (
+	// WebhookPathPrefix is the prefix of the paths under which the webhook handlers of the git storages are served.
+	// The full path is the prefix followed by the name of the storage definition.
+	WebhookPathPrefix = "/webhooks/git/"
+
+	// maxWebhookPayloadSize limits the size of accepted push event payloads.
+	maxWebhookPayloadSize = 25 * 1024 * 1024
+
+	headerGitHubEvent     = "X-GitHub-Event"
+	headerGitHubSignature = "X-Hub-Signature-256"
+	headerGitLabEvent     = "X-Gitlab-Event"
+	headerGitLabToken     = "X-Gitlab-Token"
+)
+
+// WebhookPath returns the path under which the webhook handler for the given storage definition is served.
+func WebhookPath(storageName string) string {
+	return WebhookPathPrefix + storageName
+}
+
+// WebhookHandler receives push events from GitHub or GitLab and calls a trigger function
+// whenever a verified push event for the configured branch is received.
+type WebhookHandler struct {
+	branchRef string
+	secret    []byte
+	trigger   func()
+}
+
+// NewWebhookHandler creates a new WebhookHandler.
+// The trigger function is called for each verified push event for the given branch and must not block.
+func NewWebhookHandler(cfg *config.GitWebhookConfiguration, branch string, trigger func()) (*WebhookHandler, error) {
+	secret := []byte(cfg.Secret)
+	if cfg.SecretFile != "" {
+		data, err := os.ReadFile(cfg.SecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading webhook secret file: %w", err)
+		}
+		secret = bytes.TrimSpace(data)
+	}
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("webhook secret must not be empty")
+	}
+	return &WebhookHandler{
+		branchRef: fmt.Sprintf("refs/heads/%s", branch),
+		secret:    secret,
+		trigger:   trigger,
+	}, nil
+}
+
+func (wh *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST requests are supported", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookPayloadSize))
+	if err != nil {
+		http.Error(w, "unable to read request body", http.StatusBadRequest)
+		return
+	}
+
+	var isPush bool
+	switch {
+	case r.Header.Get(headerGitHubEvent) != "":
+		if !wh.verifyGitHubSignature(r.Header.Get(headerGitHubSignature), body) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		isPush = r.Header.Get(headerGitHubEvent) == "push"
+	case r.Header.Get(headerGitLabEvent) != "":
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(headerGitLabToken)), wh.secret) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		isPush = r.Header.Get(headerGitLabEvent) == "Push Hook"
+	default:
+		http.Error(w, "unknown event format, only GitHub and GitLab push events are supported", http.StatusBadRequest)
+		return
+	}
+	if !isPush {
+		// e.g. GitHub's 'ping' event, which is sent when the webhook is created
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	event := &pushEvent{}
+	if err := json.Unmarshal(body, event); err != nil {
+		http.Error(w, "unable to parse push event", http.StatusBadRequest)
+		return
+	}
+	if event.Ref != wh.branchRef {
+		// push to a different branch, nothing to do
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	wh.trigger()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// verifyGitHubSignature verifies the HMAC-SHA256 signature GitHub sends with each event.
+func (wh *WebhookHandler) verifyGitHubSignature(signature string, body []byte) bool {
+	sig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	actual, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, wh.secret)
+	mac.Write(body)
+	return hmac.Equal(actual, mac.Sum(nil))
+}
+
+// pushEvent contains the fields of GitHub and GitLab push events which are relevant for K8Syncer.
+type pushEvent struct {
+	Ref string `json:"ref"`
+}
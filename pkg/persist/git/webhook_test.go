@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+var _ = Describe("Webhook Handler", func() {
+
+	var (
+		triggered int
+		wh        *WebhookHandler
+	)
+
+	BeforeEach(func() {
+		triggered = 0
+		var err error
+		wh, err = NewWebhookHandler(&config.GitWebhookConfiguration{Secret: "s3cr3t"}, "main", func() { triggered++ })
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	send := func(body string, headers map[string]string) int {
+		req := httptest.NewRequest(http.MethodPost, WebhookPath("myStorage"), bytes.NewBufferString(body))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		wh.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	githubSignature := func(body string) string {
+		mac := hmac.New(sha256.New, []byte("s3cr3t"))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	It("should trigger for verified GitHub push events for the configured branch", func() {
+		body := `{"ref": "refs/heads/main"}`
+		Expect(send(body, map[string]string{headerGitHubEvent: "push", headerGitHubSignature: githubSignature(body)})).To(Equal(http.StatusAccepted))
+		Expect(triggered).To(Equal(1))
+
+		// wrong signature
+		Expect(send(body, map[string]string{headerGitHubEvent: "push", headerGitHubSignature: githubSignature("foo")})).To(Equal(http.StatusUnauthorized))
+		// other branch
+		other := `{"ref": "refs/heads/feature"}`
+		Expect(send(other, map[string]string{headerGitHubEvent: "push", headerGitHubSignature: githubSignature(other)})).To(Equal(http.StatusOK))
+		// other event
+		Expect(send(body, map[string]string{headerGitHubEvent: "ping", headerGitHubSignature: githubSignature(body)})).To(Equal(http.StatusOK))
+		Expect(triggered).To(Equal(1))
+	})
+
+	It("should trigger for verified GitLab push events for the configured branch", func() {
+		body := `{"object_kind": "push", "ref": "refs/heads/main"}`
+		Expect(send(body, map[string]string{headerGitLabEvent: "Push Hook", headerGitLabToken: "s3cr3t"})).To(Equal(http.StatusAccepted))
+		Expect(triggered).To(Equal(1))
+
+		Expect(send(body, map[string]string{headerGitLabEvent: "Push Hook", headerGitLabToken: "wrong"})).To(Equal(http.StatusUnauthorized))
+		Expect(send(body, map[string]string{})).To(Equal(http.StatusBadRequest))
+		Expect(triggered).To(Equal(1))
+	})
+
+})
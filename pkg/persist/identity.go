@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package persist
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+var _ Persister = &writerIdentityPersister{}
+
+var foreignWriterCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "k8syncer",
+	Name:      "storage_foreign_writer_overwrites_total",
+	Help:      "Number of resources which have been overwritten in a storage after they had been written by another K8Syncer instance or with another configuration, by storage.",
+}, []string{"storage"})
+
+// WriterIdentityMetrics returns the prometheus collector for the foreign writer metrics of all storages.
+// It has to be registered at a prometheus registry in order to be exposed.
+func WriterIdentityMetrics() prometheus.Collector {
+	return foreignWriterCounter
+}
+
+// WriterIdentity identifies the K8Syncer instance which writes into a storage.
+type WriterIdentity struct {
+	// Instance is the name of the K8Syncer instance, usually its pod name.
+	Instance string
+	// ConfigHash is a hash over the configuration of the K8Syncer instance.
+	ConfigHash string
+}
+
+func (wi WriterIdentity) String() string {
+	return fmt.Sprintf("%s (config %s)", wi.Instance, wi.ConfigHash)
+}
+
+// writerIdentityFromResource returns the writer identity which is stamped on the given persisted resource.
+// The second return value is false if the resource doesn't carry a writer identity.
+func writerIdentityFromResource(obj *unstructured.Unstructured) (WriterIdentity, bool) {
+	ann := obj.GetAnnotations()
+	instance, ok := ann[constants.ANNOTATION_WRITER_INSTANCE]
+	if !ok {
+		return WriterIdentity{}, false
+	}
+	return WriterIdentity{Instance: instance, ConfigHash: ann[constants.ANNOTATION_WRITER_CONFIG_HASH]}, true
+}
+
+// writerIdentityPersister is a wrapper for a Persister which stamps all persisted resources with the identity of the writing K8Syncer instance.
+// Overwriting a resource which carries the identity of another writer is reported, as it indicates that multiple instances write into the same storage.
+type writerIdentityPersister struct {
+	Persister
+	storage  string
+	identity WriterIdentity
+
+	lock sync.Mutex
+	// reported contains the foreign writers which have already been reported on info level.
+	reported sets.Set[WriterIdentity]
+}
+
+// AddWriterIdentityLayer wraps the given Persister with a layer which adds the given identity as annotations to all persisted resources.
+// Before a resource is persisted, the persisted version is fetched, and if it carries the identity of another writer, this is logged and counted in the WriterIdentityMetrics.
+// Only the first overwrite per foreign writer is logged on info level, to not flood the logs after a restart with a new instance name.
+// Resources without an identity, e.g. because they have been persisted before the identity was configured, are overwritten silently.
+func AddWriterIdentityLayer(p Persister, storage string, identity WriterIdentity) Persister {
+	res := &writerIdentityPersister{
+		Persister: p,
+		storage:   storage,
+		identity:  identity,
+		reported:  sets.New[WriterIdentity](),
+	}
+	return res
+}
+
+func (wp *writerIdentityPersister) Persist(ctx context.Context, resource *unstructured.Unstructured, t Transformer, name, subPath string) (*unstructured.Unstructured, bool, error) {
+	log := logging.FromContextOrDiscard(ctx)
+	existing, err := wp.Persister.Get(ctx, name, resource.GetNamespace(), resource.GroupVersionKind(), subPath)
+	if err != nil {
+		// the check is best effort, it must not prevent the resource from being persisted
+		log.Debug("Unable to fetch persisted resource to check its writer identity", constants.Logging.KEY_RESOURCE_STORAGE, wp.storage, constants.Logging.KEY_ERROR, err.Error())
+	} else if existing != nil {
+		wp.check(ctx, existing)
+	}
+	return wp.Persister.Persist(ctx, resource, &writerIdentityTransformer{Transformer: t, identity: wp.identity}, name, subPath)
+}
+
+func (wp *writerIdentityPersister) InternalPersister() Persister {
+	return wp.Persister
+}
+
+// check reports if the given persisted resource has been written by another writer.
+func (wp *writerIdentityPersister) check(ctx context.Context, existing *unstructured.Unstructured) {
+	previous, ok := writerIdentityFromResource(existing)
+	if !ok || previous == wp.identity {
+		return
+	}
+	foreignWriterCounter.WithLabelValues(wp.storage).Inc()
+	wp.lock.Lock()
+	first := !wp.reported.Has(previous)
+	wp.reported.Insert(previous)
+	wp.lock.Unlock()
+
+	log := logging.FromContextOrDiscard(ctx)
+	keysAndValues := []interface{}{
+		constants.Logging.KEY_RESOURCE_STORAGE, wp.storage,
+		constants.Logging.KEY_WRITER, wp.identity.String(),
+		constants.Logging.KEY_PREVIOUS_WRITER, previous.String(),
+	}
+	if first {
+		log.Info("Overwriting resource which has been written by another K8Syncer instance or with another configuration, multiple instances might be writing into the same storage (further overwrites of this writer's resources are logged on debug level)", keysAndValues...)
+		return
+	}
+	log.Debug("Overwriting resource which has been written by another K8Syncer instance or with another configuration", keysAndValues...)
+}
+
+// writerIdentityTransformer adds the writer identity to the annotations of the resources transformed by the wrapped Transformer.
+type writerIdentityTransformer struct {
+	Transformer
+	identity WriterIdentity
+}
+
+func (wt *writerIdentityTransformer) Transform(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	res, err := wt.Transformer.Transform(obj)
+	if err != nil {
+		return nil, err
+	}
+	res = res.DeepCopy()
+	ann := res.GetAnnotations()
+	if ann == nil {
+		ann = map[string]string{}
+	}
+	ann[constants.ANNOTATION_WRITER_INSTANCE] = wt.identity.Instance
+	ann[constants.ANNOTATION_WRITER_CONFIG_HASH] = wt.identity.ConfigHash
+	res.SetAnnotations(ann)
+	return res, nil
+}
@@ -0,0 +1,230 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+	fspersist "github.com/gardener/k8syncer/pkg/persist/filesystem"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+var _ persist.Persister = &KubernetesPersister{}
+
+const (
+	// maxObjectNameLength is the maximum length of the name of a ConfigMap or Secret.
+	maxObjectNameLength = 253
+	// nameHashLength is the length of the hash suffix of the object names.
+	nameHashLength = 8
+)
+
+var (
+	invalidNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+	invalidKeyChars  = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+)
+
+// KubernetesPersister persists resources in ConfigMaps or Secrets of a cluster.
+// Depending on the mode, each resource is stored in its own object, or the resources which would be in the same directory
+// of a filesystem storage share an object, with one key per resource. The object names are derived from the paths
+// in a filesystem storage, the keys are the file names.
+// The data of a resource is staged in an in-memory FileSystemPersister, which takes care of the naming and serialization of the resources.
+type KubernetesPersister struct {
+	staging *fspersist.StagingPersister
+	store   objectStore
+	// namePrefix is prepended to all object names.
+	namePrefix string
+	// keyPerResource is true if the resources of a directory share an object.
+	keyPerResource bool
+}
+
+// New creates a new KubernetesPersister from the given storage definition.
+// The objects are stored in the cluster specified by the given rest config.
+// The storage definition is expected to be completed and validated.
+func New(stDef *config.StorageDefinition, restCfg *rest.Config) (*KubernetesPersister, error) {
+	if stDef.KubernetesConfig == nil {
+		return nil, fmt.Errorf("kubernetes config must not be nil")
+	}
+	c, err := client.New(restCfg, client.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("error creating client for kubernetes storage '%s': %w", stDef.Name, err)
+	}
+	return newWithStore(stDef, &clusterStore{
+		client:      c,
+		namespace:   stDef.KubernetesConfig.Namespace,
+		secrets:     stDef.KubernetesConfig.ObjectType == config.KUBERNETES_OBJECT_TYPE_SECRET,
+		storageName: stDef.Name,
+	})
+}
+
+// newWithStore creates a new KubernetesPersister which stores the data in the given object store.
+func newWithStore(stDef *config.StorageDefinition, store objectStore) (*KubernetesPersister, error) {
+	staging, err := fspersist.NewStagingPersister(stDef.FileSystemConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &KubernetesPersister{
+		staging:        staging,
+		store:          store,
+		namePrefix:     stDef.KubernetesConfig.NamePrefix,
+		keyPerResource: stDef.KubernetesConfig.Mode == config.KUBERNETES_MODE_KEY_PER_RESOURCE,
+	}, nil
+}
+
+func (p *KubernetesPersister) Exists(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (bool, error) {
+	loc := p.locate(ctx, name, namespace, gvk, subPath)
+	data, err := p.store.get(ctx, loc.objectName)
+	if err != nil {
+		return false, err
+	}
+	content, ok := data[loc.key]
+	if ok && loc.shortened {
+		if err := fspersist.CheckNameCollision(loc.filepath, content, name, namespace); err != nil {
+			return false, err
+		}
+	}
+	return ok, nil
+}
+
+func (p *KubernetesPersister) Get(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (*unstructured.Unstructured, error) {
+	p.staging.Lock()
+	defer p.staging.Unlock()
+	loc := p.locate(ctx, name, namespace, gvk, subPath)
+	data, err := p.store.get(ctx, loc.objectName)
+	if err != nil {
+		return nil, err
+	}
+	content, ok := data[loc.key]
+	if !ok {
+		return nil, nil
+	}
+	defer p.staging.Unstage(ctx, loc.filepath)
+	if err := p.staging.Stage(loc.filepath, content); err != nil {
+		return nil, err
+	}
+	return p.staging.Get(ctx, name, namespace, gvk, subPath)
+}
+
+func (p *KubernetesPersister) Persist(ctx context.Context, resource *unstructured.Unstructured, t persist.Transformer, name, subPath string) (*unstructured.Unstructured, bool, error) {
+	p.staging.Lock()
+	defer p.staging.Unlock()
+	loc := p.locate(ctx, name, resource.GetNamespace(), resource.GroupVersionKind(), subPath)
+	data, err := p.store.get(ctx, loc.objectName)
+	if err != nil {
+		return nil, false, err
+	}
+	defer p.staging.Unstage(ctx, loc.filepath)
+	if content, ok := data[loc.key]; ok {
+		if err := p.staging.Stage(loc.filepath, content); err != nil {
+			return nil, false, err
+		}
+	}
+	persisted, changed, err := p.staging.Persist(ctx, resource, t, name, subPath)
+	if err != nil || !changed {
+		return persisted, changed, err
+	}
+	content, err := p.staging.ReadStaged(loc.filepath)
+	if err != nil {
+		return persisted, true, err
+	}
+	logging.FromContextOrDiscard(ctx).Debug("Writing object", constants.Logging.KEY_RESOURCE_NAME, loc.objectName, constants.Logging.KEY_PATH, loc.key)
+	if err := p.store.update(ctx, loc.objectName, loc.path, func(data map[string][]byte) map[string][]byte {
+		if data == nil {
+			data = map[string][]byte{}
+		}
+		data[loc.key] = content
+		return data
+	}); err != nil {
+		return persisted, true, err
+	}
+	return persisted, true, nil
+}
+
+func (p *KubernetesPersister) Delete(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) error {
+	loc := p.locate(ctx, name, namespace, gvk, subPath)
+	if loc.shortened {
+		// the data of another resource with the same shortened name must not be removed
+		data, err := p.store.get(ctx, loc.objectName)
+		if err != nil {
+			return err
+		}
+		if err := fspersist.CheckNameCollision(loc.filepath, data[loc.key], name, namespace); err != nil {
+			return err
+		}
+	}
+	logging.FromContextOrDiscard(ctx).Debug("Deleting object data", constants.Logging.KEY_RESOURCE_NAME, loc.objectName, constants.Logging.KEY_PATH, loc.key)
+	return p.store.update(ctx, loc.objectName, loc.path, func(data map[string][]byte) map[string][]byte {
+		delete(data, loc.key)
+		return data
+	})
+}
+
+func (p *KubernetesPersister) InternalPersister() persist.Persister {
+	return nil
+}
+
+// location describes where the data of a resource is stored.
+type location struct {
+	// filepath is the path of the resource in the staging filesystem.
+	filepath string
+	// path is the path of the object's data in a filesystem storage, the file for one object per resource or the directory otherwise.
+	path string
+	// objectName is the name of the object which contains the resource.
+	objectName string
+	// key is the key of the resource within the object's data.
+	key string
+	// shortened is true if the name part of the key has been shortened, so that the data may belong to another resource.
+	// Get and Persist don't need to check this, as the internal FileSystemPersister checks the staged data for collisions.
+	shortened bool
+}
+
+// locate returns the location of the given resource.
+func (p *KubernetesPersister) locate(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) location {
+	filepath, _, shortened := p.staging.GetResourceFilepath(ctx, name, namespace, gvk, subPath, true)
+	relPath, _, _ := p.staging.GetResourceFilepath(ctx, name, namespace, gvk, subPath, false)
+	relPath = strings.TrimPrefix(relPath, "/")
+	res := location{
+		filepath:  filepath,
+		path:      relPath,
+		key:       invalidKeyChars.ReplaceAllString(path.Base(relPath), "_"),
+		shortened: shortened,
+	}
+	if p.keyPerResource {
+		res.path = path.Dir(relPath)
+		if res.path == "." {
+			res.path = ""
+		}
+	}
+	res.objectName = objectName(p.namePrefix, res.path)
+	return res
+}
+
+// objectName derives a valid object name from the given prefix and path.
+// As the sanitization of the path is lossy, a hash of the path is appended to avoid collisions.
+func objectName(prefix, p string) string {
+	hash := sha256.Sum256([]byte(p))
+	suffix := hex.EncodeToString(hash[:])[:nameHashLength]
+	sanitized := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(p), "-"), "-")
+	if maxLen := maxObjectNameLength - len(prefix) - len(suffix) - 1; len(sanitized) > maxLen {
+		sanitized = strings.TrimRight(sanitized[:maxLen], "-")
+	}
+	if sanitized == "" {
+		return prefix + suffix
+	}
+	return fmt.Sprintf("%s%s-%s", prefix, sanitized, suffix)
+}
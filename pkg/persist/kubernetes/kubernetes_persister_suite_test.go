@@ -0,0 +1,199 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	fspersist "github.com/gardener/k8syncer/pkg/persist/filesystem"
+	"github.com/gardener/k8syncer/pkg/persist/transformers"
+	testutils "github.com/gardener/k8syncer/test/utils"
+)
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Kubernetes Persister Test Suite")
+}
+
+var _ = Describe("Kubernetes Persister Tests", func() {
+
+	var (
+		dummy            *unstructured.Unstructured
+		basicTransformer = transformers.NewBasic()
+		ctx              context.Context
+		store            *memStore
+	)
+
+	BeforeEach(func() {
+		dummy = &unstructured.Unstructured{}
+		dummy.SetName("foo")
+		dummy.SetNamespace("bar")
+		dummy.SetGroupVersionKind(schema.GroupVersionKind{
+			Group:   "k8syncer.gardener.cloud",
+			Version: "v1",
+			Kind:    "Dummy",
+		})
+		Expect(unstructured.SetNestedField(dummy.Object, fmt.Sprint(time.Now().Unix()), "spec", "value")).To(Succeed())
+
+		ctx = logging.NewContext(context.Background(), logging.Discard())
+		store = &memStore{objects: map[string]map[string][]byte{}, paths: map[string]string{}}
+	})
+
+	newPersister := func(kubeCfg *config.KubernetesConfiguration) *KubernetesPersister {
+		cfg := &config.K8SyncerConfiguration{
+			StorageDefinitions: []*config.StorageDefinition{
+				{
+					Name:             "kubernetes",
+					Type:             config.STORAGE_TYPE_KUBERNETES,
+					KubernetesConfig: kubeCfg,
+				},
+			},
+		}
+		Expect(cfg.Complete()).To(Succeed())
+		p, err := newWithStore(cfg.StorageDefinitions[0], store)
+		Expect(err).ToNot(HaveOccurred())
+		return p
+	}
+
+	It("should store each resource in its own object", func() {
+		p := newPersister(&config.KubernetesConfiguration{Namespace: "mirror"})
+
+		_, _, err := p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.objects).To(HaveLen(1))
+		for name, data := range store.objects {
+			Expect(name).To(HavePrefix("k8syncer-ns-bar-dummy-v1-k8syncer-gardener-cloud-foo-yaml-"))
+			Expect(data).To(HaveKeyWithValue("dummy.v1.k8syncer.gardener.cloud_foo.yaml", ContainSubstring("name: foo")))
+			Expect(store.paths).To(HaveKeyWithValue(name, "ns_bar/dummy.v1.k8syncer.gardener.cloud_foo.yaml"))
+		}
+
+		other := dummy.DeepCopy()
+		other.SetName("baz")
+		_, _, err = p.Persist(ctx, other, basicTransformer, other.GetName(), "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.objects).To(HaveLen(2))
+		Expect(p.Delete(ctx, other.GetName(), other.GetNamespace(), other.GroupVersionKind(), "")).To(Succeed())
+		Expect(p.Delete(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "")).To(Succeed())
+
+		testutils.PersisterRoundTrip(ctx, p, dummy, nil)
+		Expect(store.objects).To(BeEmpty())
+	})
+
+	It("should store the resources of a directory in one object", func() {
+		p := newPersister(&config.KubernetesConfiguration{
+			Namespace:  "mirror",
+			Mode:       config.KUBERNETES_MODE_KEY_PER_RESOURCE,
+			NamePrefix: "mirror.",
+		})
+
+		other := dummy.DeepCopy()
+		other.SetName("baz")
+		for _, obj := range []*unstructured.Unstructured{dummy, other} {
+			_, _, err := p.Persist(ctx, obj, basicTransformer, obj.GetName(), "")
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(store.objects).To(HaveLen(1))
+		for name, data := range store.objects {
+			Expect(name).To(HavePrefix("mirror.ns-bar-"))
+			Expect(data).To(HaveKey("dummy.v1.k8syncer.gardener.cloud_foo.yaml"))
+			Expect(data).To(HaveKey("dummy.v1.k8syncer.gardener.cloud_baz.yaml"))
+			Expect(store.paths).To(HaveKeyWithValue(name, "ns_bar"))
+		}
+
+		By("keeping the object while it contains other resources")
+		Expect(p.Delete(ctx, other.GetName(), other.GetNamespace(), other.GroupVersionKind(), "")).To(Succeed())
+		Expect(store.objects).To(HaveLen(1))
+		Expect(p.Delete(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "")).To(Succeed())
+		Expect(store.objects).To(BeEmpty())
+
+		testutils.PersisterRoundTrip(ctx, p, dummy, nil)
+		Expect(store.objects).To(BeEmpty())
+	})
+
+	It("should detect collisions of shortened keys", func() {
+		p := newPersister(&config.KubernetesConfiguration{Namespace: "mirror"})
+		p.staging.LongNames = &config.LongNamesConfiguration{MaxLength: 100, Strategy: config.LONG_NAME_STRATEGY_TRUNCATE}
+		gvk := dummy.GroupVersionKind()
+		longName := strings.Repeat("a", 120)
+		dummy.SetName(longName)
+		_, _, err := p.Persist(ctx, dummy, basicTransformer, longName, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.objects).To(HaveLen(1))
+
+		By("not treating the data of another resource with the same shortened key as existing or deleting it")
+		for _, data := range store.objects {
+			for key, content := range data {
+				data[key] = bytes.Replace(content, []byte(longName), []byte(longName[1:]+"b"), 1)
+			}
+		}
+		var collisionErr *fspersist.NameCollisionError
+		_, err = p.Exists(ctx, longName, dummy.GetNamespace(), gvk, "")
+		Expect(errors.As(err, &collisionErr)).To(BeTrue())
+		Expect(errors.As(p.Delete(ctx, longName, dummy.GetNamespace(), gvk, ""), &collisionErr)).To(BeTrue())
+		Expect(store.objects).To(HaveLen(1))
+	})
+
+	It("should derive valid and distinct object names", func() {
+		long := strings.Repeat("a", 300)
+		names := map[string]string{}
+		for _, path := range []string{"", "ns_bar/Foo.yaml", "ns_bar/foo.yaml", "ns-bar/foo-yaml", long, long + "b"} {
+			name := objectName("k8syncer-", path)
+			Expect(validation.IsDNS1123Subdomain(name)).To(BeEmpty(), "invalid name '%s' for path '%s'", name, path)
+			Expect(names).ToNot(HaveKey(name), "paths '%s' and '%s' result in the same name", names[name], path)
+			names[name] = path
+		}
+	})
+
+})
+
+// memStore is an in-memory objectStore.
+type memStore struct {
+	objects map[string]map[string][]byte
+	// paths contains the path of each object, as passed on creation.
+	paths map[string]string
+}
+
+var _ objectStore = &memStore{}
+
+func (s *memStore) get(_ context.Context, name string) (map[string][]byte, error) {
+	data, ok := s.objects[name]
+	if !ok {
+		return nil, nil
+	}
+	res := map[string][]byte{}
+	for k, v := range data {
+		res[k] = v
+	}
+	return res, nil
+}
+
+func (s *memStore) update(ctx context.Context, name, path string, f func(data map[string][]byte) map[string][]byte) error {
+	data, _ := s.get(ctx, name)
+	data = f(data)
+	if len(data) == 0 {
+		delete(s.objects, name)
+		delete(s.paths, name)
+		return nil
+	}
+	if _, ok := s.objects[name]; !ok {
+		s.paths[name] = path
+	}
+	s.objects[name] = data
+	return nil
+}
@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// objectStore reads and writes the data of the objects which contain the persisted resources.
+type objectStore interface {
+	// get returns the data of the object with the given name.
+	// If the object doesn't exist, it returns (nil, nil).
+	get(ctx context.Context, name string) (map[string][]byte, error)
+	// update passes the data of the object with the given name to f and writes the returned data.
+	// f receives nil if the object doesn't exist, it may modify and return the passed map.
+	// The object is created if it doesn't exist and deleted if the returned data is empty.
+	// path describes the data of the object and is only used for new objects.
+	// f may be called multiple times, if the object is modified concurrently.
+	update(ctx context.Context, name, path string, f func(data map[string][]byte) map[string][]byte) error
+}
+
+// clusterStore is an objectStore for ConfigMaps or Secrets in a namespace of a cluster.
+// It only modifies objects which belong to its storage, as indicated by their storage label.
+type clusterStore struct {
+	client    client.Client
+	namespace string
+	// secrets is true if the data is stored in Secrets instead of ConfigMaps.
+	secrets     bool
+	storageName string
+}
+
+var _ objectStore = &clusterStore{}
+
+func (s *clusterStore) get(ctx context.Context, name string) (map[string][]byte, error) {
+	_, data, err := s.fetch(ctx, name)
+	return data, err
+}
+
+func (s *clusterStore) update(ctx context.Context, name, path string, f func(data map[string][]byte) map[string][]byte) error {
+	return retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}, func() error {
+		obj, data, err := s.fetch(ctx, name)
+		if err != nil {
+			return err
+		}
+		data = f(data)
+		if len(data) == 0 {
+			if obj == nil {
+				return nil
+			}
+			// the preconditions ensure that data which has been added concurrently is not lost
+			uid, rv := obj.GetUID(), obj.GetResourceVersion()
+			if err := s.client.Delete(ctx, obj, client.Preconditions{UID: &uid, ResourceVersion: &rv}); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("error deleting %s: %w", s.describe(name), err)
+			}
+			return nil
+		}
+		if obj == nil {
+			obj = s.newObject(name)
+			obj.SetLabels(map[string]string{constants.LABEL_STORAGE: s.storageName})
+			obj.SetAnnotations(map[string]string{constants.ANNOTATION_STORAGE_PATH: path})
+			s.setData(obj, data)
+			if err := s.client.Create(ctx, obj); err != nil {
+				return fmt.Errorf("error creating %s: %w", s.describe(name), err)
+			}
+			return nil
+		}
+		s.setData(obj, data)
+		if err := s.client.Update(ctx, obj); err != nil {
+			return fmt.Errorf("error updating %s: %w", s.describe(name), err)
+		}
+		return nil
+	})
+}
+
+// fetch returns the object with the given name and its data.
+// If the object doesn't exist, it returns (nil, nil, nil).
+// It returns an error if the object doesn't belong to the storage.
+func (s *clusterStore) fetch(ctx context.Context, name string) (client.Object, map[string][]byte, error) {
+	obj := s.newObject(name)
+	if err := s.client.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("error fetching %s: %w", s.describe(name), err)
+	}
+	if owner := obj.GetLabels()[constants.LABEL_STORAGE]; owner != s.storageName {
+		return nil, nil, fmt.Errorf("%s does not belong to storage '%s', its label '%s' is '%s'", s.describe(name), s.storageName, constants.LABEL_STORAGE, owner)
+	}
+	data := map[string][]byte{}
+	switch o := obj.(type) {
+	case *corev1.Secret:
+		for k, v := range o.Data {
+			data[k] = v
+		}
+	case *corev1.ConfigMap:
+		for k, v := range o.Data {
+			data[k] = []byte(v)
+		}
+	}
+	return obj, data, nil
+}
+
+// newObject returns an empty ConfigMap or Secret with the given name in the store's namespace.
+func (s *clusterStore) newObject(name string) client.Object {
+	var obj client.Object = &corev1.ConfigMap{}
+	if s.secrets {
+		obj = &corev1.Secret{}
+	}
+	obj.SetName(name)
+	obj.SetNamespace(s.namespace)
+	return obj
+}
+
+// setData replaces the data of the given ConfigMap or Secret.
+func (s *clusterStore) setData(obj client.Object, data map[string][]byte) {
+	switch o := obj.(type) {
+	case *corev1.Secret:
+		o.Data = data
+	case *corev1.ConfigMap:
+		o.Data = make(map[string]string, len(data))
+		for k, v := range data {
+			o.Data[k] = string(v)
+		}
+	}
+}
+
+// describe returns a description of the object with the given name for error messages.
+func (s *clusterStore) describe(name string) string {
+	kind := "configmap"
+	if s.secrets {
+		kind = "secret"
+	}
+	return fmt.Sprintf("%s '%s/%s'", kind, s.namespace, name)
+}
@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kv
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+// kvClient reads and writes the keys which contain the persisted resources.
+type kvClient interface {
+	// get returns the value of the given key.
+	// If the key doesn't exist, it returns (nil, nil).
+	get(ctx context.Context, key string) ([]byte, error)
+	// put sets the value of the given key.
+	// If ttl is positive, the key expires after it, otherwise it doesn't expire.
+	put(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// delete removes the given key.
+	// It does not return an error if the key doesn't exist.
+	delete(ctx context.Context, key string) error
+	// scan returns all keys with the given prefix.
+	scan(ctx context.Context, prefix string) ([]string, error)
+}
+
+// newKVClient returns the client for the configured backend.
+// The configuration is expected to be completed and validated.
+func newKVClient(cfg *config.KVConfiguration) (kvClient, error) {
+	creds := &credentials{username: cfg.Username, password: cfg.Password, passwordFile: cfg.PasswordFile}
+	switch cfg.Backend {
+	case config.KV_BACKEND_ETCD:
+		return newEtcdClient(cfg.Endpoint, creds, cfg.Timeout.Duration), nil
+	case config.KV_BACKEND_REDIS:
+		return newRedisClient(cfg.Endpoint, creds, cfg.Timeout.Duration)
+	default:
+		return nil, fmt.Errorf("unknown kv backend '%s'", string(cfg.Backend))
+	}
+}
+
+// credentials are used for authenticating against the key-value store.
+type credentials struct {
+	username     string
+	password     string
+	passwordFile string
+}
+
+// empty returns true if no password is configured.
+func (c *credentials) empty() bool {
+	return c.password == "" && c.passwordFile == ""
+}
+
+// currentPassword returns the configured password.
+// If a password file is configured, it is read on every call, so that rotated passwords are picked up.
+func (c *credentials) currentPassword() (string, error) {
+	if c.passwordFile == "" {
+		return c.password, nil
+	}
+	data, err := os.ReadFile(c.passwordFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading password file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// etcdClient is a kvClient for etcd, which uses the etcd v3 client.
+// The client is created on first use and re-created after authentication errors, so that a rotated password is picked up.
+// Keys with a TTL are attached to a new lease per write, which expires with the TTL.
+type etcdClient struct {
+	endpoint string
+	creds    *credentials
+	timeout  time.Duration
+
+	// lock guards the client.
+	lock sync.Mutex
+	// client is nil if it has not been created yet or has been discarded after an authentication error.
+	client *clientv3.Client
+}
+
+var _ kvClient = &etcdClient{}
+
+// newEtcdClient returns a client for the etcd v3 API at the given endpoint, e.g. 'https://etcd.example.com:2379'.
+// If a username is given, the client authenticates with it and the configured password.
+func newEtcdClient(endpoint string, creds *credentials, timeout time.Duration) *etcdClient {
+	return &etcdClient{
+		endpoint: endpoint,
+		creds:    creds,
+		timeout:  timeout,
+	}
+}
+
+func (c *etcdClient) get(ctx context.Context, key string) ([]byte, error) {
+	var res []byte
+	err := c.do(ctx, func(ctx context.Context, client *clientv3.Client) error {
+		resp, err := client.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if len(resp.Kvs) == 0 {
+			return nil
+		}
+		res = resp.Kvs[0].Value
+		if res == nil {
+			res = []byte{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading key '%s': %w", key, err)
+	}
+	return res, nil
+}
+
+func (c *etcdClient) put(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.do(ctx, func(ctx context.Context, client *clientv3.Client) error {
+		var opts []clientv3.OpOption
+		if ttl > 0 {
+			lease, err := client.Grant(ctx, int64(math.Ceil(ttl.Seconds())))
+			if err != nil {
+				return fmt.Errorf("error granting lease for key '%s': %w", key, err)
+			}
+			opts = append(opts, clientv3.WithLease(lease.ID))
+		}
+		if _, err := client.Put(ctx, key, string(value), opts...); err != nil {
+			return fmt.Errorf("error writing key '%s': %w", key, err)
+		}
+		return nil
+	})
+}
+
+func (c *etcdClient) delete(ctx context.Context, key string) error {
+	err := c.do(ctx, func(ctx context.Context, client *clientv3.Client) error {
+		_, err := client.Delete(ctx, key)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting key '%s': %w", key, err)
+	}
+	return nil
+}
+
+func (c *etcdClient) scan(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := c.do(ctx, func(ctx context.Context, client *clientv3.Client) error {
+		resp, err := client.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+		if err != nil {
+			return err
+		}
+		keys = make([]string, 0, len(resp.Kvs))
+		for _, kv := range resp.Kvs {
+			keys = append(keys, string(kv.Key))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing keys with prefix '%s': %w", prefix, err)
+	}
+	return keys, nil
+}
+
+// do calls f with the client and a context which is limited by the configured timeout.
+// If the call fails because the authentication failed, the client is discarded,
+// so that the next call authenticates with the current password.
+func (c *etcdClient) do(ctx context.Context, f func(ctx context.Context, client *clientv3.Client) error) error {
+	client, err := c.getClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	err = f(ctx, client)
+	if errors.Is(err, rpctypes.ErrAuthFailed) {
+		c.resetClient(client)
+	}
+	return err
+}
+
+// getClient returns the client, creating it first if necessary.
+// Creating the client authenticates, if a username is configured.
+func (c *etcdClient) getClient() (*clientv3.Client, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.client != nil {
+		return c.client, nil
+	}
+	cfg := clientv3.Config{
+		Endpoints:   []string{c.endpoint},
+		DialTimeout: c.timeout,
+		Logger:      zap.NewNop(),
+	}
+	if c.creds.username != "" {
+		password, err := c.creds.currentPassword()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Username = c.creds.username
+		cfg.Password = password
+	}
+	client, err := clientv3.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating etcd client: %w", err)
+	}
+	c.client = client
+	return c.client, nil
+}
+
+// resetClient closes the given client and discards it, unless it has been replaced already.
+func (c *etcdClient) resetClient(client *clientv3.Client) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.client == client {
+		_ = c.client.Close()
+		c.client = nil
+	}
+}
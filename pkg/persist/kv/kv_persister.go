@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kv
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+	fspersist "github.com/gardener/k8syncer/pkg/persist/filesystem"
+	"github.com/gardener/k8syncer/pkg/utils"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+var (
+	_ persist.Persister      = &KVPersister{}
+	_ persist.ResourceLister = &KVPersister{}
+)
+
+// clusterScopedNamespace is used as namespace segment of the keys of cluster-scoped resources.
+// It cannot collide with a namespace, as namespace names must not contain underscores.
+const clusterScopedNamespace = "_cluster"
+
+// KVPersister persists resources in a key-value store.
+// Each resource is stored under the key '<keyPrefix><subPath>/<gvk>/<namespace>/<name>'.
+// The data of a resource is staged in an in-memory FileSystemPersister, which takes care of the serialization of the resources.
+type KVPersister struct {
+	staging *fspersist.StagingPersister
+	client  kvClient
+	// keyPrefix is prepended to all keys.
+	keyPrefix string
+	// ttl is the time after which persisted resources expire, zero if they don't expire.
+	ttl time.Duration
+}
+
+// New creates a new KVPersister from the given storage definition.
+// The storage definition is expected to be completed and validated.
+func New(stDef *config.StorageDefinition) (*KVPersister, error) {
+	if stDef.KVConfig == nil {
+		return nil, fmt.Errorf("kv config must not be nil")
+	}
+	client, err := newKVClient(stDef.KVConfig)
+	if err != nil {
+		return nil, err
+	}
+	return newWithClient(stDef, client)
+}
+
+// newWithClient creates a new KVPersister which stores the data via the given client.
+func newWithClient(stDef *config.StorageDefinition, client kvClient) (*KVPersister, error) {
+	staging, err := fspersist.NewStagingPersister(stDef.FileSystemConfig)
+	if err != nil {
+		return nil, err
+	}
+	res := &KVPersister{
+		staging:   staging,
+		client:    client,
+		keyPrefix: stDef.KVConfig.KeyPrefix,
+	}
+	if stDef.KVConfig.TTL != nil {
+		res.ttl = stDef.KVConfig.TTL.Duration
+	}
+	return res, nil
+}
+
+func (p *KVPersister) Exists(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (bool, error) {
+	data, err := p.client.get(ctx, p.key(name, namespace, gvk, subPath))
+	if err != nil {
+		return false, err
+	}
+	return data != nil, nil
+}
+
+func (p *KVPersister) Get(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (*unstructured.Unstructured, error) {
+	p.staging.Lock()
+	defer p.staging.Unlock()
+	data, err := p.client.get(ctx, p.key(name, namespace, gvk, subPath))
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, nil
+	}
+	// keys contain the full name, so the staged data always belongs to the resource, even if the staging path has been shortened
+	filepath, _, _ := p.staging.GetResourceFilepath(ctx, name, namespace, gvk, subPath, true)
+	defer p.staging.Unstage(ctx, filepath)
+	if err := p.staging.Stage(filepath, data); err != nil {
+		return nil, err
+	}
+	return p.staging.Get(ctx, name, namespace, gvk, subPath)
+}
+
+func (p *KVPersister) Persist(ctx context.Context, resource *unstructured.Unstructured, t persist.Transformer, name, subPath string) (*unstructured.Unstructured, bool, error) {
+	p.staging.Lock()
+	defer p.staging.Unlock()
+	log := logging.FromContextOrDiscard(ctx)
+	key := p.key(name, resource.GetNamespace(), resource.GroupVersionKind(), subPath)
+	data, err := p.client.get(ctx, key)
+	if err != nil {
+		return nil, false, err
+	}
+	filepath, _, _ := p.staging.GetResourceFilepath(ctx, name, resource.GetNamespace(), resource.GroupVersionKind(), subPath, true)
+	defer p.staging.Unstage(ctx, filepath)
+	if data != nil {
+		if err := p.staging.Stage(filepath, data); err != nil {
+			return nil, false, err
+		}
+	}
+	persisted, changed, err := p.staging.Persist(ctx, resource, t, name, subPath)
+	if err != nil {
+		return persisted, changed, err
+	}
+	if !changed {
+		if p.ttl > 0 && data != nil {
+			// the data is written again to refresh its TTL
+			log.Debug("Refreshing key", constants.Logging.KEY_PATH, key)
+			if err := p.client.put(ctx, key, data, p.ttl); err != nil {
+				return persisted, false, err
+			}
+		}
+		return persisted, false, nil
+	}
+	content, err := p.staging.ReadStaged(filepath)
+	if err != nil {
+		return persisted, true, err
+	}
+	log.Debug("Writing key", constants.Logging.KEY_PATH, key)
+	if err := p.client.put(ctx, key, content, p.ttl); err != nil {
+		return persisted, true, err
+	}
+	return persisted, true, nil
+}
+
+func (p *KVPersister) Delete(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) error {
+	key := p.key(name, namespace, gvk, subPath)
+	logging.FromContextOrDiscard(ctx).Debug("Deleting key", constants.Logging.KEY_PATH, key)
+	return p.client.delete(ctx, key)
+}
+
+func (p *KVPersister) InternalPersister() persist.Persister {
+	return nil
+}
+
+// List returns the resources of the given kind below the given subPath, by scanning the keys with the corresponding prefix.
+func (p *KVPersister) List(ctx context.Context, gvk schema.GroupVersionKind, subPath string) ([]persist.PersistedResource, error) {
+	prefix := p.keyPrefix + path.Join(cleanSubPath(subPath), utils.GVKToString(gvk, true)) + "/"
+	keys, err := p.client.scan(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	res := []persist.PersistedResource{}
+	for _, key := range keys {
+		namespace, name, ok := strings.Cut(strings.TrimPrefix(key, prefix), "/")
+		if !ok || name == "" || strings.Contains(name, "/") {
+			// not a resource of this kind, e.g. a key below a nested subPath
+			continue
+		}
+		if namespace == clusterScopedNamespace {
+			namespace = ""
+		}
+		res = append(res, persist.PersistedResource{Name: name, Namespace: namespace})
+	}
+	return res, nil
+}
+
+// key returns the key under which the given resource is stored.
+func (p *KVPersister) key(name, namespace string, gvk schema.GroupVersionKind, subPath string) string {
+	if namespace == "" {
+		namespace = clusterScopedNamespace
+	}
+	return p.keyPrefix + path.Join(cleanSubPath(subPath), utils.GVKToString(gvk, true), namespace, name)
+}
+
+// cleanSubPath returns the given subPath without leading or trailing slashes, or an empty string for the root.
+func cleanSubPath(subPath string) string {
+	return strings.Trim(path.Clean("/"+subPath), "/")
+}
@@ -0,0 +1,485 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kv
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+	"github.com/gardener/k8syncer/pkg/persist/transformers"
+	testutils "github.com/gardener/k8syncer/test/utils"
+)
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "KV Persister Test Suite")
+}
+
+const testPassword = "secret"
+
+var _ = Describe("KV Persister Tests", func() {
+
+	var (
+		dummy            *unstructured.Unstructured
+		basicTransformer = transformers.NewBasic()
+		ctx              context.Context
+	)
+
+	BeforeEach(func() {
+		dummy = &unstructured.Unstructured{}
+		dummy.SetName("foo")
+		dummy.SetNamespace("bar")
+		dummy.SetGroupVersionKind(schema.GroupVersionKind{
+			Group:   "k8syncer.gardener.cloud",
+			Version: "v1",
+			Kind:    "Dummy",
+		})
+		Expect(unstructured.SetNestedField(dummy.Object, fmt.Sprint(time.Now().Unix()), "spec", "value")).To(Succeed())
+
+		ctx = logging.NewContext(context.Background(), logging.Discard())
+	})
+
+	newPersister := func(kvCfg *config.KVConfiguration) *KVPersister {
+		cfg := &config.K8SyncerConfiguration{
+			StorageDefinitions: []*config.StorageDefinition{
+				{
+					Name:     "kv",
+					Type:     config.STORAGE_TYPE_KV,
+					KVConfig: kvCfg,
+				},
+			},
+		}
+		Expect(cfg.Complete()).To(Succeed())
+		p, err := New(cfg.StorageDefinitions[0])
+		Expect(err).ToNot(HaveOccurred())
+		return p
+	}
+
+	// testRoundTrip runs the persister round trip for the dummy resource next to a cluster-scoped one and verifies the keys in the store.
+	testRoundTrip := func(p *KVPersister, store testStore) {
+		gvk := dummy.GroupVersionKind()
+		clusterScoped := dummy.DeepCopy()
+		clusterScoped.SetNamespace("")
+
+		testutils.PersisterRoundTrip(ctx, p, dummy, &testutils.RoundTripOptions{
+			SubPath: "archive",
+			AfterPersist: func() {
+				_, changed, err := p.Persist(ctx, clusterScoped, basicTransformer, clusterScoped.GetName(), "archive")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(changed).To(BeTrue())
+				Expect(store.keys()).To(ConsistOf(
+					"k8syncer/archive/dummy.v1.k8syncer.gardener.cloud/bar/foo",
+					"k8syncer/archive/dummy.v1.k8syncer.gardener.cloud/_cluster/foo",
+				))
+				Expect(string(store.value("k8syncer/archive/dummy.v1.k8syncer.gardener.cloud/bar/foo"))).To(ContainSubstring("name: foo"))
+
+				By("listing the persisted resources")
+				_, _, err = p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "archive/nested")
+				Expect(err).ToNot(HaveOccurred())
+				resources, err := p.List(ctx, gvk, "archive")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resources).To(ConsistOf(
+					persist.PersistedResource{Name: "foo", Namespace: "bar"},
+					persist.PersistedResource{Name: "foo"},
+				))
+				Expect(p.Delete(ctx, dummy.GetName(), dummy.GetNamespace(), gvk, "archive/nested")).To(Succeed())
+			},
+			AfterDelete: func() {
+				Expect(p.Delete(ctx, clusterScoped.GetName(), "", gvk, "archive")).To(Succeed())
+				Expect(store.keys()).To(BeEmpty())
+			},
+		})
+	}
+
+	Context("etcd", func() {
+
+		var (
+			store  *memKV
+			server *fakeEtcd
+		)
+
+		BeforeEach(func() {
+			store = newMemKV()
+			server = newFakeEtcd(store)
+			DeferCleanup(server.close)
+		})
+
+		It("should store resources in etcd", func() {
+			p := newPersister(&config.KVConfiguration{
+				Backend:   config.KV_BACKEND_ETCD,
+				Endpoint:  server.endpoint(),
+				KeyPrefix: "k8syncer/",
+				Username:  "k8syncer",
+				Password:  testPassword,
+			})
+			testRoundTrip(p, store)
+		})
+
+		It("should attach keys to leases if a TTL is configured", func() {
+			p := newPersister(&config.KVConfiguration{
+				Backend:   config.KV_BACKEND_ETCD,
+				Endpoint:  server.endpoint(),
+				KeyPrefix: "k8syncer/",
+				TTL:       &metav1.Duration{Duration: 1500 * time.Millisecond},
+			})
+			_, _, err := p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "")
+			Expect(err).ToNot(HaveOccurred())
+			key := "k8syncer/dummy.v1.k8syncer.gardener.cloud/bar/foo"
+			Expect(store.ttl(key)).To(Equal(2 * time.Second))
+
+			By("refreshing the TTL of unchanged resources")
+			store.setTTL(key, 0)
+			_, changed, err := p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeFalse())
+			Expect(store.ttl(key)).To(Equal(2 * time.Second))
+		})
+
+		It("should authenticate again if the token has expired", func() {
+			p := newPersister(&config.KVConfiguration{
+				Backend:  config.KV_BACKEND_ETCD,
+				Endpoint: server.endpoint(),
+				Username: "k8syncer",
+				Password: testPassword,
+			})
+			_, _, err := p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "")
+			Expect(err).ToNot(HaveOccurred())
+			server.expireTokens()
+			exists, err := p.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+			Expect(server.authentications()).To(Equal(2))
+		})
+
+		It("should fail for wrong credentials and pick up a rotated password", func() {
+			passwordFile := filepath.Join(GinkgoT().TempDir(), "password")
+			Expect(os.WriteFile(passwordFile, []byte("wrong\n"), 0600)).To(Succeed())
+			p := newPersister(&config.KVConfiguration{
+				Backend:      config.KV_BACKEND_ETCD,
+				Endpoint:     server.endpoint(),
+				Username:     "k8syncer",
+				PasswordFile: passwordFile,
+			})
+			_, err := p.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "")
+			Expect(err).To(MatchError(ContainSubstring("authentication failed")))
+
+			Expect(os.WriteFile(passwordFile, []byte(testPassword+"\n"), 0600)).To(Succeed())
+			_, err = p.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+	})
+
+	Context("redis", func() {
+
+		var server *miniredis.Miniredis
+
+		BeforeEach(func() {
+			var err error
+			server, err = miniredis.Run()
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(server.Close)
+		})
+
+		It("should store resources in redis", func() {
+			server.RequireAuth(testPassword)
+			p := newPersister(&config.KVConfiguration{
+				Backend:   config.KV_BACKEND_REDIS,
+				Endpoint:  fmt.Sprintf("redis://%s/2", server.Addr()),
+				KeyPrefix: "k8syncer/",
+				Password:  testPassword,
+			})
+			testRoundTrip(p, &redisStore{db: server.DB(2)})
+		})
+
+		It("should set the TTL of keys if configured", func() {
+			p := newPersister(&config.KVConfiguration{
+				Backend:  config.KV_BACKEND_REDIS,
+				Endpoint: "redis://" + server.Addr(),
+				TTL:      &metav1.Duration{Duration: 1500 * time.Millisecond},
+			})
+			_, _, err := p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "")
+			Expect(err).ToNot(HaveOccurred())
+			key := "dummy.v1.k8syncer.gardener.cloud/bar/foo"
+			Expect(server.TTL(key)).To(Equal(1500 * time.Millisecond))
+
+			By("refreshing the TTL of unchanged resources")
+			server.FastForward(500 * time.Millisecond)
+			Expect(server.TTL(key)).To(Equal(time.Second))
+			_, changed, err := p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeFalse())
+			Expect(server.TTL(key)).To(Equal(1500 * time.Millisecond))
+		})
+
+		It("should reconnect after the connection has been closed", func() {
+			p := newPersister(&config.KVConfiguration{
+				Backend:  config.KV_BACKEND_REDIS,
+				Endpoint: "redis://" + server.Addr(),
+			})
+			_, _, err := p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "")
+			Expect(err).ToNot(HaveOccurred())
+			server.Close()
+			Expect(server.Restart()).To(Succeed())
+			exists, err := p.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+		})
+
+		It("should fail for wrong credentials", func() {
+			server.RequireAuth(testPassword)
+			p := newPersister(&config.KVConfiguration{
+				Backend:  config.KV_BACKEND_REDIS,
+				Endpoint: "redis://" + server.Addr(),
+				Password: "wrong",
+			})
+			_, err := p.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "")
+			Expect(err).To(MatchError(ContainSubstring("WRONGPASS")))
+		})
+
+	})
+
+})
+
+// testStore gives access to the keys which have been written to the key-value store.
+type testStore interface {
+	keys() []string
+	value(key string) []byte
+}
+
+// memKV is an in-memory key-value store which records the TTLs of the keys.
+type memKV struct {
+	lock   sync.Mutex
+	values map[string][]byte
+	ttls   map[string]time.Duration
+}
+
+func newMemKV() *memKV {
+	return &memKV{values: map[string][]byte{}, ttls: map[string]time.Duration{}}
+}
+
+func (s *memKV) put(key string, value []byte, ttl time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.values[key] = value
+	s.ttls[key] = ttl
+}
+
+func (s *memKV) delete(key string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.values, key)
+	delete(s.ttls, key)
+}
+
+func (s *memKV) value(key string) []byte {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.values[key]
+}
+
+func (s *memKV) ttl(key string) time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.ttls[key]
+}
+
+func (s *memKV) setTTL(key string, ttl time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.ttls[key] = ttl
+}
+
+func (s *memKV) keys() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res := []string{}
+	for k := range s.values {
+		res = append(res, k)
+	}
+	return res
+}
+
+// redisStore gives access to the keys of a database of the miniredis server.
+type redisStore struct {
+	db *miniredis.RedisDB
+}
+
+func (s *redisStore) keys() []string {
+	return s.db.Keys()
+}
+
+func (s *redisStore) value(key string) []byte {
+	value, err := s.db.Get(key)
+	if err != nil {
+		return nil
+	}
+	return []byte(value)
+}
+
+// fakeEtcd is a gRPC server which implements the parts of the etcd v3 API that are used by the etcd client.
+// If a user authenticates, a valid token is required for all further requests.
+type fakeEtcd struct {
+	etcdserverpb.UnimplementedKVServer
+	etcdserverpb.UnimplementedLeaseServer
+	etcdserverpb.UnimplementedAuthServer
+
+	store    *memKV
+	listener net.Listener
+	server   *grpc.Server
+
+	lock   sync.Mutex
+	tokens map[string]bool
+	// authCount is the number of successful authentications.
+	authCount int
+	leases    map[int64]time.Duration
+}
+
+func newFakeEtcd(store *memKV) *fakeEtcd {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+	f := &fakeEtcd{
+		store:    store,
+		listener: listener,
+		server:   grpc.NewServer(),
+		tokens:   map[string]bool{},
+		leases:   map[int64]time.Duration{},
+	}
+	etcdserverpb.RegisterKVServer(f.server, f)
+	etcdserverpb.RegisterLeaseServer(f.server, f)
+	etcdserverpb.RegisterAuthServer(f.server, f)
+	go func() {
+		_ = f.server.Serve(listener)
+	}()
+	return f
+}
+
+func (f *fakeEtcd) endpoint() string {
+	return "http://" + f.listener.Addr().String()
+}
+
+func (f *fakeEtcd) close() {
+	f.server.Stop()
+}
+
+// expireTokens invalidates all tokens which have been issued so far.
+func (f *fakeEtcd) expireTokens() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.tokens = map[string]bool{}
+}
+
+func (f *fakeEtcd) authentications() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.authCount
+}
+
+// checkToken returns an error if tokens have been issued and the request doesn't contain a valid one.
+// The lock must be held.
+func (f *fakeEtcd) checkToken(ctx context.Context) error {
+	if f.authCount == 0 {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, token := range md.Get(rpctypes.TokenFieldNameGRPC) {
+		if f.tokens[token] {
+			return nil
+		}
+	}
+	return rpctypes.ErrGRPCInvalidAuthToken
+}
+
+func (f *fakeEtcd) Authenticate(_ context.Context, req *etcdserverpb.AuthenticateRequest) (*etcdserverpb.AuthenticateResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if req.Password != testPassword {
+		return nil, rpctypes.ErrGRPCAuthFailed
+	}
+	f.authCount++
+	token := fmt.Sprintf("token-%s-%d", req.Name, f.authCount)
+	f.tokens[token] = true
+	return &etcdserverpb.AuthenticateResponse{Header: &etcdserverpb.ResponseHeader{}, Token: token}, nil
+}
+
+func (f *fakeEtcd) Range(ctx context.Context, req *etcdserverpb.RangeRequest) (*etcdserverpb.RangeResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.checkToken(ctx); err != nil {
+		return nil, err
+	}
+	res := &etcdserverpb.RangeResponse{Header: &etcdserverpb.ResponseHeader{}}
+	keys := f.store.keys()
+	sort.Strings(keys)
+	for _, k := range keys {
+		inRange := k == string(req.Key)
+		if req.RangeEnd != nil {
+			inRange = k >= string(req.Key) && k < string(req.RangeEnd)
+		}
+		if !inRange {
+			continue
+		}
+		kv := &mvccpb.KeyValue{Key: []byte(k)}
+		if !req.KeysOnly {
+			kv.Value = f.store.value(k)
+		}
+		res.Kvs = append(res.Kvs, kv)
+	}
+	res.Count = int64(len(res.Kvs))
+	return res, nil
+}
+
+func (f *fakeEtcd) Put(ctx context.Context, req *etcdserverpb.PutRequest) (*etcdserverpb.PutResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.checkToken(ctx); err != nil {
+		return nil, err
+	}
+	f.store.put(string(req.Key), req.Value, f.leases[req.Lease])
+	return &etcdserverpb.PutResponse{Header: &etcdserverpb.ResponseHeader{}}, nil
+}
+
+func (f *fakeEtcd) DeleteRange(ctx context.Context, req *etcdserverpb.DeleteRangeRequest) (*etcdserverpb.DeleteRangeResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.checkToken(ctx); err != nil {
+		return nil, err
+	}
+	f.store.delete(string(req.Key))
+	return &etcdserverpb.DeleteRangeResponse{Header: &etcdserverpb.ResponseHeader{}}, nil
+}
+
+func (f *fakeEtcd) LeaseGrant(ctx context.Context, req *etcdserverpb.LeaseGrantRequest) (*etcdserverpb.LeaseGrantResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.checkToken(ctx); err != nil {
+		return nil, err
+	}
+	id := int64(len(f.leases) + 1)
+	f.leases[id] = time.Duration(req.TTL) * time.Second
+	return &etcdserverpb.LeaseGrantResponse{Header: &etcdserverpb.ResponseHeader{}, ID: id, TTL: req.TTL}, nil
+}
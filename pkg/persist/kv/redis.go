@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// redisClient is a kvClient for redis.
+// The connections are pooled by the go-redis client, which re-establishes them after errors.
+type redisClient struct {
+	client *redis.Client
+}
+
+var _ kvClient = &redisClient{}
+
+// newRedisClient returns a client for the redis server at the given endpoint, e.g. 'redis://redis.example.com:6379/0'.
+// The password is read for every new connection, so that rotated passwords are picked up.
+func newRedisClient(endpoint string, creds *credentials, timeout time.Duration) (*redisClient, error) {
+	opts, err := redis.ParseURL(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis endpoint: %w", err)
+	}
+	if !creds.empty() {
+		opts.CredentialsProviderContext = func(_ context.Context) (string, string, error) {
+			password, err := creds.currentPassword()
+			return creds.username, password, err
+		}
+	}
+	opts.DialTimeout = timeout
+	opts.ReadTimeout = timeout
+	opts.WriteTimeout = timeout
+	opts.ContextTimeoutEnabled = true
+	opts.DisableIdentity = true
+	return &redisClient{
+		client: redis.NewClient(opts),
+	}, nil
+}
+
+func (c *redisClient) get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading key '%s': %w", key, err)
+	}
+	return value, nil
+}
+
+func (c *redisClient) put(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	var expiration time.Duration
+	if ttl > 0 {
+		expiration = ttl
+	}
+	if err := c.client.Set(ctx, key, value, expiration).Err(); err != nil {
+		return fmt.Errorf("error writing key '%s': %w", key, err)
+	}
+	return nil
+}
+
+func (c *redisClient) delete(ctx context.Context, key string) error {
+	if err := c.client.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("error deleting key '%s': %w", key, err)
+	}
+	return nil
+}
+
+func (c *redisClient) scan(ctx context.Context, prefix string) ([]string, error) {
+	// SCAN might return keys multiple times
+	keys := sets.New[string]()
+	it := c.client.Scan(ctx, 0, escapeGlob(prefix)+"*", 1000).Iterator()
+	for it.Next(ctx) {
+		keys.Insert(it.Val())
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("error listing keys with prefix '%s': %w", prefix, err)
+	}
+	return sets.List(keys), nil
+}
+
+// escapeGlob escapes the characters which have a special meaning in the patterns of the SCAN command.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package persist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/utils"
+)
+
+// ErrListNotSupported is returned by List and DeleteAll if no Persister in the chain of internal Persisters implements ResourceLister.
+var ErrListNotSupported = errors.New("storage does not support listing the persisted resources")
+
+// List returns all resources of the given kind which are persisted in the given Persister below the given subPath.
+// It uses the outermost ResourceLister in the chain of internal Persisters and returns ErrListNotSupported if there is none.
+func List(ctx context.Context, p Persister, gvk schema.GroupVersionKind, subPath string) ([]PersistedResource, error) {
+	rl, ok := FindResourceLister(p)
+	if !ok {
+		return nil, ErrListNotSupported
+	}
+	return rl.List(ctx, gvk, subPath)
+}
+
+// DeleteAll deletes all resources of the given kind in the given namespace which are persisted in the given Persister below the given subPath.
+// If namespace is empty, the resources are deleted across all namespaces, including cluster-scoped ones.
+// The resources are enumerated via List, but deleted one by one via the given Persister, so that all wrapping layers, e.g. caches, take note of the deletions.
+// A failed deletion doesn't prevent the remaining resources from being deleted, the errors are aggregated.
+func DeleteAll(ctx context.Context, p Persister, namespace string, gvk schema.GroupVersionKind, subPath string) error {
+	resources, err := List(ctx, p, gvk, subPath)
+	if err != nil {
+		return err
+	}
+	errs := utils.NewErrorList()
+	for _, res := range resources {
+		if namespace != "" && res.Namespace != namespace {
+			continue
+		}
+		if err := p.Delete(ctx, res.Name, res.Namespace, gvk, subPath); err != nil {
+			errs.Append(fmt.Errorf("error deleting '%s': %w", res.String(), err))
+		}
+	}
+	return errs.Aggregate()
+}
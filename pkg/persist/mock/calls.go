@@ -304,11 +304,12 @@ func MockedGetReturn(resource *unstructured.Unstructured, err error) *MockedRetu
 	}
 }
 
-func MockedPersistCall(resource *unstructured.Unstructured, t persist.Transformer, subPath string) *MockedCall {
+func MockedPersistCall(resource *unstructured.Unstructured, t persist.Transformer, name, subPath string) *MockedCall {
 	return &MockedCall{
 		callType: callName_Persist,
 		resource: resource,
 		t:        t,
+		name:     &name,
 		subPath:  &subPath,
 	}
 }
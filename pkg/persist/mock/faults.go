@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package mock
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// defaultFaultMessage is the error message of injected failures, if the fault doesn't specify one.
+const defaultFaultMessage = "injected fault"
+
+// InjectedFaultError is returned by a MockPersister for calls which fail due to a configured fault.
+type InjectedFaultError struct {
+	Operation config.MockOperation
+	// Call is the number of the failed call among the calls matching the fault.
+	Call    int
+	Message string
+}
+
+func (e *InjectedFaultError) Error() string {
+	return fmt.Sprintf("%s (%s, call %d)", e.Message, string(e.Operation), e.Call)
+}
+
+// fault is the runtime state of a configured fault.
+type fault struct {
+	cfg         *config.MockFault
+	operations  sets.Set[config.MockOperation]
+	failOnCalls sets.Set[int]
+
+	lock  sync.Mutex
+	calls int
+	rand  *rand.Rand
+}
+
+func newFault(cfg *config.MockFault) *fault {
+	seed := time.Now().UnixNano()
+	if cfg.Seed != nil {
+		seed = *cfg.Seed
+	}
+	return &fault{
+		cfg:         cfg,
+		operations:  sets.New[config.MockOperation](cfg.Operations...),
+		failOnCalls: sets.New[int](cfg.FailOnCalls...),
+		rand:        rand.New(rand.NewSource(seed)),
+	}
+}
+
+// register counts a call of the given operation and determines whether it fails.
+// The returned call number is 0 if the fault doesn't apply to the operation.
+func (f *fault) register(op config.MockOperation) (int, bool) {
+	if f.operations.Len() > 0 && !f.operations.Has(op) {
+		return 0, false
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.calls++
+	fail := f.failOnCalls.Has(f.calls) || (f.cfg.FailureRate > 0 && f.rand.Intn(100) < f.cfg.FailureRate)
+	return f.calls, fail
+}
+
+// SetFaults replaces the faults which are injected into the calls of the persister.
+// The call numbers of all faults start at 1 again.
+func (p *MockPersister) SetFaults(faults []*config.MockFault) {
+	res := make([]*fault, 0, len(faults))
+	for _, f := range faults {
+		if f != nil {
+			res = append(res, newFault(f))
+		}
+	}
+	p.faultsLock.Lock()
+	defer p.faultsLock.Unlock()
+	p.faults = res
+}
+
+// injectFaults applies all faults which match the given operation.
+// It waits for the configured latencies and returns an error if any of the faults causes the call to fail.
+func (p *MockPersister) injectFaults(ctx context.Context, op config.MockOperation) error {
+	p.faultsLock.Lock()
+	faults := p.faults
+	p.faultsLock.Unlock()
+
+	var latency time.Duration
+	var err error
+	for _, f := range faults {
+		call, fail := f.register(op)
+		if call == 0 {
+			continue
+		}
+		if f.cfg.Latency != nil {
+			latency += f.cfg.Latency.Duration
+		}
+		if fail && err == nil {
+			msg := f.cfg.Message
+			if msg == "" {
+				msg = defaultFaultMessage
+			}
+			err = &InjectedFaultError{
+				Operation: op,
+				Call:      call,
+				Message:   msg,
+			}
+		}
+	}
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if err != nil {
+		logging.FromContextOrDiscard(ctx).Info("Injecting fault", constants.Logging.KEY_ERROR, err.Error())
+	}
+	return err
+}
@@ -7,6 +7,7 @@ package mock
 import (
 	"context"
 	"reflect"
+	"sync"
 
 	"github.com/gardener/landscaper/controller-utils/pkg/logging"
 	"sigs.k8s.io/yaml"
@@ -21,14 +22,17 @@ import (
 )
 
 var _ persist.Persister = &MockPersister{}
-var _ persist.LoggerInjectable = &MockPersister{}
+var _ persist.ResourceLister = &MockPersister{}
 
 // MockPersister stores resources in memory and logs operations on it.
 // It does not actually persist anything.
+// Failures and latencies can be injected into its calls, see SetFaults.
 type MockPersister struct {
-	Storage        map[resourceIdentifier]*unstructured.Unstructured
-	injectedLogger *logging.Logger
-	expectedCalls  utils.Queue[*MockedCall]
+	Storage       map[resourceIdentifier]*unstructured.Unstructured
+	expectedCalls utils.Queue[*MockedCall]
+
+	faultsLock sync.Mutex
+	faults     []*fault
 }
 
 type resourceIdentifier struct {
@@ -56,8 +60,11 @@ func New(mockCfg *config.MockConfiguration, testMode bool) (persist.Persister, e
 	}
 
 	mp := &MockPersister{
-		Storage:        map[resourceIdentifier]*unstructured.Unstructured{},
-		injectedLogger: &persist.StaticDiscardLogger,
+		Storage: map[resourceIdentifier]*unstructured.Unstructured{},
+	}
+
+	if mockCfg != nil {
+		mp.SetFaults(mockCfg.Faults)
 	}
 
 	if testMode {
@@ -68,10 +75,6 @@ func New(mockCfg *config.MockConfiguration, testMode bool) (persist.Persister, e
 	return persist.AddLoggingLayer(mp, logLevel), nil
 }
 
-func (p *MockPersister) InjectLogger(il *logging.Logger) {
-	p.injectedLogger = il
-}
-
 func (p *MockPersister) Exists(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (bool, error) {
 	var expectedReturn *MockedReturn
 	if p.expectedCalls != nil {
@@ -83,8 +86,11 @@ func (p *MockPersister) Exists(ctx context.Context, name, namespace string, gvk
 			return false, err
 		}
 	}
+	if err := p.injectFaults(ctx, config.MOCK_OPERATION_EXISTS); err != nil {
+		return false, err
+	}
 	_, exists := p.Storage[Identify(name, namespace, gvk, subPath)]
-	p.injectedLogger.Info("Checking if data exists", constants.Logging.KEY_DATA_EXISTS, exists)
+	logging.FromContextOrDiscard(ctx).Info("Checking if data exists", constants.Logging.KEY_DATA_EXISTS, exists)
 	if expectedReturn != nil {
 		if err := compareReturns(expectedReturn, MockedExistsReturn(exists, nil)); err != nil {
 			return false, err
@@ -104,6 +110,9 @@ func (p *MockPersister) Get(ctx context.Context, name, namespace string, gvk sch
 			return nil, err
 		}
 	}
+	if err := p.injectFaults(ctx, config.MOCK_OPERATION_GET); err != nil {
+		return nil, err
+	}
 	data, exists := p.Storage[Identify(name, namespace, gvk, subPath)]
 	logFields := []interface{}{
 		constants.Logging.KEY_DATA_EXISTS, exists,
@@ -114,7 +123,7 @@ func (p *MockPersister) Get(ctx context.Context, name, namespace string, gvk sch
 			logFields = append(logFields, constants.Logging.KEY_DATA, string(rawData))
 		}
 	}
-	p.injectedLogger.Info("Getting data", logFields...)
+	logging.FromContextOrDiscard(ctx).Info("Getting data", logFields...)
 	if expectedReturn != nil {
 		if err := compareReturns(expectedReturn, MockedGetReturn(data, nil)); err != nil {
 			return nil, err
@@ -123,22 +132,25 @@ func (p *MockPersister) Get(ctx context.Context, name, namespace string, gvk sch
 	return data, nil
 }
 
-func (p *MockPersister) Persist(ctx context.Context, resource *unstructured.Unstructured, t persist.Transformer, subPath string) (*unstructured.Unstructured, bool, error) {
+func (p *MockPersister) Persist(ctx context.Context, resource *unstructured.Unstructured, t persist.Transformer, name, subPath string) (*unstructured.Unstructured, bool, error) {
 	var expectedReturn *MockedReturn
 	if p.expectedCalls != nil {
 		expectedCall, err := p.expectedCalls.Peek()
 		if err == nil {
 			expectedReturn = expectedCall.expectedReturn
 		}
-		if err := p.compareExpectedVsActualCall(MockedPersistCall(resource, t, subPath)); err != nil {
+		if err := p.compareExpectedVsActualCall(MockedPersistCall(resource, t, name, subPath)); err != nil {
 			return nil, false, err
 		}
 	}
+	if err := p.injectFaults(ctx, config.MOCK_OPERATION_PERSIST); err != nil {
+		return nil, false, err
+	}
 	transformed, err := t.Transform(resource)
 	if err != nil {
 		return nil, false, err
 	}
-	id := Identify(resource.GetName(), resource.GetNamespace(), resource.GroupVersionKind(), subPath)
+	id := Identify(name, resource.GetNamespace(), resource.GroupVersionKind(), subPath)
 	data, exists := p.Storage[id]
 	changed := true
 	if exists {
@@ -147,7 +159,7 @@ func (p *MockPersister) Persist(ctx context.Context, resource *unstructured.Unst
 	if changed {
 		p.Storage[id] = transformed
 	}
-	p.injectedLogger.Info("Persisting resource if changed", constants.Logging.KEY_RESOURCE_IN_STORAGE_CHANGED, changed)
+	logging.FromContextOrDiscard(ctx).Info("Persisting resource if changed", constants.Logging.KEY_RESOURCE_IN_STORAGE_CHANGED, changed)
 	if expectedReturn != nil {
 		if err := compareReturns(expectedReturn, MockedPersistReturn(transformed, changed, nil)); err != nil {
 			return transformed, changed, err
@@ -167,8 +179,11 @@ func (p *MockPersister) Delete(ctx context.Context, name, namespace string, gvk
 			return err
 		}
 	}
+	if err := p.injectFaults(ctx, config.MOCK_OPERATION_DELETE); err != nil {
+		return err
+	}
 	delete(p.Storage, Identify(name, namespace, gvk, subPath))
-	p.injectedLogger.Info("Deleting resource")
+	logging.FromContextOrDiscard(ctx).Info("Deleting resource")
 	if expectedReturn != nil {
 		if err := compareReturns(expectedReturn, MockedDeleteReturn(nil)); err != nil {
 			return err
@@ -177,6 +192,19 @@ func (p *MockPersister) Delete(ctx context.Context, name, namespace string, gvk
 	return nil
 }
 
+// List returns all resources of the given kind which are stored in memory for the given subPath.
+// Opposed to the Persister methods, calls to List are not compared to the expected calls and no faults are injected.
+func (p *MockPersister) List(ctx context.Context, gvk schema.GroupVersionKind, subPath string) ([]persist.PersistedResource, error) {
+	res := []persist.PersistedResource{}
+	for id := range p.Storage {
+		if id.gvk == gvk && id.subPath == subPath {
+			res = append(res, persist.PersistedResource{Name: id.name, Namespace: id.namespace})
+		}
+	}
+	logging.FromContextOrDiscard(ctx).Info("Listing resources", constants.Logging.KEY_RESOURCE_KIND, gvk.Kind)
+	return res, nil
+}
+
 func (p *MockPersister) InternalPersister() persist.Persister {
 	return nil
 }
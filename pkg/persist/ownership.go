@@ -0,0 +1,180 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package persist
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+var _ Persister = &ownershipGuardedPersister{}
+
+var ownershipConflictCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "k8syncer",
+	Name:      "storage_ownership_conflicts_total",
+	Help:      "Number of writes and deletions of resources which have been persisted by another sync config, by storage and sync config.",
+}, []string{"storage", "owner", "conflicting_owner"})
+
+// OwnershipConflictMetrics returns the prometheus collector for the ownership conflict metrics of all storages.
+// It has to be registered at a prometheus registry in order to be exposed.
+func OwnershipConflictMetrics() prometheus.Collector {
+	return ownershipConflictCounter
+}
+
+type ownerContextKey struct{}
+
+// WithOwner returns a context which marks all Persister calls done with it as being done on behalf of the given owner, usually a sync config ID.
+// Only calls with an owner are checked for ownership conflicts, see AddOwnershipLayer.
+func WithOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, ownerContextKey{}, owner)
+}
+
+// OwnerFromContext returns the owner which has been set via WithOwner, or an empty string if none has been set.
+func OwnerFromContext(ctx context.Context) string {
+	owner, _ := ctx.Value(ownerContextKey{}).(string)
+	return owner
+}
+
+// OwnershipConflictError is returned if a resource is written or deleted on behalf of an owner, while it has been persisted by another owner.
+type OwnershipConflictError struct {
+	Storage      string
+	Owner        string
+	CurrentOwner string
+	Name         string
+	Namespace    string
+	GroupKind    schema.GroupKind
+	SubPath      string
+}
+
+func (e *OwnershipConflictError) Error() string {
+	return fmt.Sprintf("resource '%s' of kind '%s' in namespace '%s' and subPath '%s' of storage '%s' is already persisted by sync config '%s', refusing to modify it for sync config '%s' (subPaths of sync configs which watch the same resources must not overlap)",
+		e.Name, e.GroupKind.String(), e.Namespace, e.SubPath, e.Storage, e.CurrentOwner, e.Owner)
+}
+
+// ownershipKey identifies a resource in the storage.
+// The version is not part of it, as different versions of a resource are stored in the same place.
+type ownershipKey struct {
+	name      string
+	namespace string
+	gk        schema.GroupKind
+	subPath   string
+}
+
+// ownershipGuardedPersister is a wrapper for a Persister which keeps an index of the owners of all resources which have been persisted through it.
+// Writes and deletions of a resource on behalf of another owner than the one which has persisted it first are detected as conflicts.
+type ownershipGuardedPersister struct {
+	Persister
+	storage string
+	policy  config.OwnershipConflictPolicy
+
+	lock   sync.Mutex
+	owners map[ownershipKey]string
+}
+
+// AddOwnershipLayer wraps the given Persister with a guard which detects if multiple owners write the same resource.
+// Depending on the policy, conflicting calls fail with an OwnershipConflictError or are only logged. All conflicts are counted in the OwnershipConflictMetrics.
+// Calls without an owner in their context are passed through, without being checked or changing the ownership.
+// Ownership is only tracked in memory, so the first owner which persists a resource after a restart becomes its owner.
+// It should be the outermost layer, so that conflicts are detected before any other layer can answer a call.
+func AddOwnershipLayer(p Persister, storage string, policy config.OwnershipConflictPolicy) Persister {
+	res := &ownershipGuardedPersister{
+		Persister: p,
+		storage:   storage,
+		policy:    policy,
+		owners:    map[ownershipKey]string{},
+	}
+	return res
+}
+
+func (op *ownershipGuardedPersister) Persist(ctx context.Context, resource *unstructured.Unstructured, t Transformer, name, subPath string) (*unstructured.Unstructured, bool, error) {
+	owner := OwnerFromContext(ctx)
+	if owner == "" {
+		return op.Persister.Persist(ctx, resource, t, name, subPath)
+	}
+	key := newOwnershipKey(name, resource.GetNamespace(), resource.GroupVersionKind(), subPath)
+	if err := op.check(ctx, key, owner, true); err != nil {
+		return nil, false, err
+	}
+	return op.Persister.Persist(ctx, resource, t, name, subPath)
+}
+
+func (op *ownershipGuardedPersister) Delete(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) error {
+	owner := OwnerFromContext(ctx)
+	if owner == "" {
+		return op.Persister.Delete(ctx, name, namespace, gvk, subPath)
+	}
+	key := newOwnershipKey(name, namespace, gvk, subPath)
+	if err := op.check(ctx, key, owner, false); err != nil {
+		return err
+	}
+	if err := op.Persister.Delete(ctx, name, namespace, gvk, subPath); err != nil {
+		return err
+	}
+	op.release(key, owner)
+	return nil
+}
+
+func (op *ownershipGuardedPersister) InternalPersister() Persister {
+	return op.Persister
+}
+
+// check verifies that the resource with the given key is not owned by another owner.
+// If claim is true and the resource has no owner yet, the given owner becomes its owner.
+// It returns an OwnershipConflictError for conflicts, unless the policy only asks for a warning.
+func (op *ownershipGuardedPersister) check(ctx context.Context, key ownershipKey, owner string, claim bool) error {
+	op.lock.Lock()
+	current, ok := op.owners[key]
+	if !ok && claim {
+		op.owners[key] = owner
+	}
+	op.lock.Unlock()
+	if !ok || current == owner {
+		return nil
+	}
+
+	ownershipConflictCounter.WithLabelValues(op.storage, current, owner).Inc()
+	if op.policy == config.OWNERSHIP_CONFLICT_POLICY_WARN {
+		logging.FromContextOrDiscard(ctx).Info("Resource is already persisted by another sync config, overlapping writes will interleave", constants.Logging.KEY_RESOURCE_STORAGE, op.storage, constants.Logging.KEY_SYNC_CONFIG_ID, owner, constants.Logging.KEY_CURRENT_OWNER, current)
+		return nil
+	}
+	return &OwnershipConflictError{
+		Storage:      op.storage,
+		Owner:        owner,
+		CurrentOwner: current,
+		Name:         key.name,
+		Namespace:    key.namespace,
+		GroupKind:    key.gk,
+		SubPath:      key.subPath,
+	}
+}
+
+// release removes the ownership of the given owner for the resource with the given key, if it owns it.
+func (op *ownershipGuardedPersister) release(key ownershipKey, owner string) {
+	op.lock.Lock()
+	defer op.lock.Unlock()
+	if op.owners[key] == owner {
+		delete(op.owners, key)
+	}
+}
+
+func newOwnershipKey(name, namespace string, gvk schema.GroupVersionKind, subPath string) ownershipKey {
+	return ownershipKey{
+		name:      name,
+		namespace: namespace,
+		gk:        gvk.GroupKind(),
+		subPath:   strings.TrimPrefix(path.Clean("/"+subPath), "/"),
+	}
+}
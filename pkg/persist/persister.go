@@ -6,6 +6,8 @@ package persist
 
 import (
 	"context"
+	"fmt"
+	"io"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -20,11 +22,12 @@ type Persister interface {
 	// Get returns the currently persisted data for the specified resource.
 	// If no data for the resource exists, it is expected to return (nil, nil) and not an error.
 	Get(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (*unstructured.Unstructured, error)
-	// Persist persists the given resource.
+	// Persist persists the given resource under the given name.
+	// The name is usually the resource's name, but it can differ, e.g. if the storage items should be named after the resource's UID.
 	// Depending on the implementation, it might check for its existence in the storage first and only update it if it differs.
 	// It returns the transformed version of the given resource, which should be the one that is persisted after this command.
 	// The second return value is 'true' if the resource in the storage has changed (meaning the given resource differed from the one in the storage when this method was called).
-	Persist(ctx context.Context, resource *unstructured.Unstructured, t Transformer, subPath string) (*unstructured.Unstructured, bool, error)
+	Persist(ctx context.Context, resource *unstructured.Unstructured, t Transformer, name, subPath string) (*unstructured.Unstructured, bool, error)
 	// Delete deletes the resource from persistence.
 	// If the resource does not exist, Delete will not return an error.
 	Delete(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) error
@@ -38,3 +41,199 @@ type Transformer interface {
 	// Transform prepares the resource for persistence by removing (volatile) fields which should not be persisted.
 	Transform(*unstructured.Unstructured) (*unstructured.Unstructured, error)
 }
+
+// TreeReader is implemented by persisters which can return all data persisted below a subPath at once.
+type TreeReader interface {
+	// ReadTree returns the contents of all persisted files below the given subPath.
+	// The keys of the returned map are the file paths relative to the subPath.
+	ReadTree(ctx context.Context, subPath string) (map[string][]byte, error)
+}
+
+// ArtifactPersister is implemented by persisters which can store arbitrary files next to the persisted resources, e.g. snapshots.
+type ArtifactPersister interface {
+	// PersistArtifact stores the given data in a file with the given name below the given subPath.
+	// Existing files are overwritten.
+	PersistArtifact(ctx context.Context, data []byte, filename, subPath string) error
+}
+
+// ArtifactAppender is implemented by persisters which can append data to arbitrary files next to the persisted resources, e.g. change logs.
+type ArtifactAppender interface {
+	// AppendArtifact appends the given data to the file with the given name below the given subPath.
+	// The file is created if it doesn't exist.
+	AppendArtifact(ctx context.Context, data []byte, filename, subPath string) error
+}
+
+// NamespacePruner is implemented by persisters which can remove all data persisted for a namespace at once.
+type NamespacePruner interface {
+	// PruneNamespace removes the data of all resources of the given namespace below the given subPath.
+	// If archiveSubPath is not empty, the data is moved below archiveSubPath instead of being deleted.
+	// The first return value is true if there was any data for the namespace.
+	PruneNamespace(ctx context.Context, namespace, subPath, archiveSubPath string) (bool, error)
+}
+
+// SubPathPruner is implemented by persisters which can remove all data below a subPath at once.
+type SubPathPruner interface {
+	// PruneSubPath removes all data below the given subPath.
+	// The subPath must not resolve to the root of the storage.
+	// The first return value is true if there was any data below the subPath.
+	PruneSubPath(ctx context.Context, subPath string) (bool, error)
+}
+
+// SidecarPersister is implemented by persisters which can store additional documents next to the data of a resource.
+// Sidecar documents are not returned by TreeReader.ReadTree, as they don't contain resources.
+type SidecarPersister interface {
+	// PersistSidecar stores the given data as sidecar document of the given kind (e.g. 'owners') next to the data of the specified resource.
+	// The returned bool is 'true' if the sidecar document in the storage has changed.
+	PersistSidecar(ctx context.Context, data []byte, kind, name, namespace string, gvk schema.GroupVersionKind, subPath string) (bool, error)
+	// DeleteSidecar removes the sidecar document of the given kind of the specified resource.
+	// If the sidecar document does not exist, DeleteSidecar will not return an error.
+	DeleteSidecar(ctx context.Context, kind, name, namespace string, gvk schema.GroupVersionKind, subPath string) error
+}
+
+// NamespaceMetadataPersister is implemented by persisters which can store the Namespace object of a namespace together with the namespace's resources.
+// This allows restoring the labels and annotations of a namespace, which are e.g. used by policies.
+type NamespaceMetadataPersister interface {
+	// PersistNamespaceMetadata stores the given Namespace object next to the data of the resources in this namespace.
+	// The returned bool is 'true' if the Namespace object in the storage has changed.
+	PersistNamespaceMetadata(ctx context.Context, namespace *unstructured.Unstructured, t Transformer, subPath string) (bool, error)
+}
+
+// HealthChecker is implemented by persisters which can check whether their storage is available, without reading or writing any resources.
+type HealthChecker interface {
+	// CheckHealth returns an error if the storage is currently not available.
+	CheckHealth(ctx context.Context) error
+}
+
+// StreamPersister is implemented by persisters which can read and write the serialized data of a resource as a stream,
+// so that very large resources don't have to be held in memory as a whole.
+type StreamPersister interface {
+	// GetStream returns a reader for the persisted data of the specified resource, as it is stored in the storage.
+	// The caller has to close the reader. If no data for the resource exists, it returns (nil, nil).
+	GetStream(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (io.ReadCloser, error)
+	// PersistStream replaces the persisted data of the specified resource with the data read from the given reader.
+	// The data has to be serialized in the storage's format already, it is neither transformed nor compared to the existing data.
+	PersistStream(ctx context.Context, data io.Reader, name, namespace string, gvk schema.GroupVersionKind, subPath string) error
+}
+
+// PersistedResource identifies the data of a resource in a storage.
+type PersistedResource struct {
+	// Name is the name under which the resource is persisted, which is not necessarily the resource's name, see Persister.Persist.
+	Name string
+	// Namespace is the namespace of the resource, it is empty for cluster-scoped resources.
+	Namespace string
+}
+
+func (r PersistedResource) String() string {
+	if r.Namespace == "" {
+		return r.Name
+	}
+	return fmt.Sprintf("%s/%s", r.Namespace, r.Name)
+}
+
+// ResourceLister is implemented by persisters which can enumerate the persisted resources, without having to know them from the cluster.
+// See List and DeleteAll for using it on any Persister.
+type ResourceLister interface {
+	// List returns all resources of the given kind which are persisted below the given subPath, across all namespaces.
+	// The version of the kind has to match, as it is part of the persisted data's identity.
+	List(ctx context.Context, gvk schema.GroupVersionKind, subPath string) ([]PersistedResource, error)
+}
+
+// FindTreeReader returns the outermost Persister in the chain of internal Persisters which implements TreeReader.
+func FindTreeReader(p Persister) (TreeReader, bool) {
+	for cur := p; cur != nil; cur = cur.InternalPersister() {
+		if tr, ok := cur.(TreeReader); ok {
+			return tr, true
+		}
+	}
+	return nil, false
+}
+
+// FindArtifactPersister returns the outermost Persister in the chain of internal Persisters which implements ArtifactPersister.
+func FindArtifactPersister(p Persister) (ArtifactPersister, bool) {
+	for cur := p; cur != nil; cur = cur.InternalPersister() {
+		if ap, ok := cur.(ArtifactPersister); ok {
+			return ap, true
+		}
+	}
+	return nil, false
+}
+
+// FindArtifactAppender returns the outermost Persister in the chain of internal Persisters which implements ArtifactAppender.
+func FindArtifactAppender(p Persister) (ArtifactAppender, bool) {
+	for cur := p; cur != nil; cur = cur.InternalPersister() {
+		if aa, ok := cur.(ArtifactAppender); ok {
+			return aa, true
+		}
+	}
+	return nil, false
+}
+
+// FindNamespacePruner returns the outermost Persister in the chain of internal Persisters which implements NamespacePruner.
+func FindNamespacePruner(p Persister) (NamespacePruner, bool) {
+	for cur := p; cur != nil; cur = cur.InternalPersister() {
+		if np, ok := cur.(NamespacePruner); ok {
+			return np, true
+		}
+	}
+	return nil, false
+}
+
+// FindSubPathPruner returns the outermost Persister in the chain of internal Persisters which implements SubPathPruner.
+func FindSubPathPruner(p Persister) (SubPathPruner, bool) {
+	for cur := p; cur != nil; cur = cur.InternalPersister() {
+		if spp, ok := cur.(SubPathPruner); ok {
+			return spp, true
+		}
+	}
+	return nil, false
+}
+
+// FindSidecarPersister returns the outermost Persister in the chain of internal Persisters which implements SidecarPersister.
+func FindSidecarPersister(p Persister) (SidecarPersister, bool) {
+	for cur := p; cur != nil; cur = cur.InternalPersister() {
+		if sp, ok := cur.(SidecarPersister); ok {
+			return sp, true
+		}
+	}
+	return nil, false
+}
+
+// FindNamespaceMetadataPersister returns the outermost Persister in the chain of internal Persisters which implements NamespaceMetadataPersister.
+func FindNamespaceMetadataPersister(p Persister) (NamespaceMetadataPersister, bool) {
+	for cur := p; cur != nil; cur = cur.InternalPersister() {
+		if nmp, ok := cur.(NamespaceMetadataPersister); ok {
+			return nmp, true
+		}
+	}
+	return nil, false
+}
+
+// FindHealthChecker returns the outermost Persister in the chain of internal Persisters which implements HealthChecker.
+func FindHealthChecker(p Persister) (HealthChecker, bool) {
+	for cur := p; cur != nil; cur = cur.InternalPersister() {
+		if hc, ok := cur.(HealthChecker); ok {
+			return hc, true
+		}
+	}
+	return nil, false
+}
+
+// FindResourceLister returns the outermost Persister in the chain of internal Persisters which implements ResourceLister.
+func FindResourceLister(p Persister) (ResourceLister, bool) {
+	for cur := p; cur != nil; cur = cur.InternalPersister() {
+		if rl, ok := cur.(ResourceLister); ok {
+			return rl, true
+		}
+	}
+	return nil, false
+}
+
+// FindStreamPersister returns the outermost Persister in the chain of internal Persisters which implements StreamPersister.
+func FindStreamPersister(p Persister) (StreamPersister, bool) {
+	for cur := p; cur != nil; cur = cur.InternalPersister() {
+		if sp, ok := cur.(StreamPersister); ok {
+			return sp, true
+		}
+	}
+	return nil, false
+}
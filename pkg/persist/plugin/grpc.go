@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+// newClientConn creates a gRPC connection to the plugin at the configured address.
+// The address is either 'unix://<path>', which is natively supported by gRPC, or '<host>:<port>'.
+// The connection is established lazily and re-established automatically if it breaks.
+func newClientConn(cfg *config.PluginConfiguration) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if cfg.TLS != nil {
+		tlsCfg, err := newTLSConfig(cfg.TLS, cfg.Address)
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsCfg)
+	}
+	conn, err := grpc.Dial(cfg.Address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("error creating connection to plugin at '%s': %w", cfg.Address, err)
+	}
+	return conn, nil
+}
+
+// newTLSConfig creates the TLS configuration for the connection to the plugin at the given address.
+// The client certificate is read for every handshake, so that it can be rotated without a restart.
+func newTLSConfig(cfg *config.PluginTLSConfiguration, address string) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.ServerName,
+	}
+	if tlsCfg.ServerName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid plugin address '%s': %w", address, err)
+		}
+		tlsCfg.ServerName = host
+	}
+	if cfg.CAFile != "" {
+		data, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA file '%s': %w", cfg.CAFile, err)
+		}
+		tlsCfg.RootCAs = x509.NewCertPool()
+		if !tlsCfg.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("CA file '%s' does not contain any PEM encoded certificates", cfg.CAFile)
+		}
+	}
+	if cfg.CertFile != "" {
+		tlsCfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("error loading client certificate: %w", err)
+			}
+			return &cert, nil
+		}
+	}
+	return tlsCfg, nil
+}
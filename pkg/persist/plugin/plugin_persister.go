@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+var _ persist.Persister = &PluginPersister{}
+
+// PluginPersister delegates all operations to an external persister, which implements the gRPC service defined in persister.proto.
+// The resources are transformed by K8Syncer before they are sent to the plugin, which only has to store and return them.
+type PluginPersister struct {
+	client      PersisterClient
+	timeout     time.Duration
+	storageName string
+}
+
+// New creates a new PluginPersister from the given storage definition.
+// The storage definition is expected to be completed and validated.
+// The connection to the plugin is established lazily, so the plugin doesn't have to be running yet.
+func New(stDef *config.StorageDefinition) (*PluginPersister, error) {
+	if stDef.PluginConfig == nil {
+		return nil, fmt.Errorf("plugin config must not be nil")
+	}
+	conn, err := newClientConn(stDef.PluginConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &PluginPersister{
+		client:      NewPersisterClient(conn),
+		timeout:     stDef.PluginConfig.Timeout.Duration,
+		storageName: stDef.Name,
+	}, nil
+}
+
+func (p *PluginPersister) Exists(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	resp, err := p.client.Exists(ctx, &ExistsRequest{Key: p.key(name, namespace, gvk, subPath)})
+	if err != nil {
+		return false, callError("Exists", err)
+	}
+	return resp.Exists, nil
+}
+
+func (p *PluginPersister) Get(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (*unstructured.Unstructured, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	resp, err := p.client.Get(ctx, &GetRequest{Key: p.key(name, namespace, gvk, subPath)})
+	if err != nil {
+		return nil, callError("Get", err)
+	}
+	if !resp.Found {
+		return nil, nil
+	}
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(resp.Resource); err != nil {
+		return nil, fmt.Errorf("error parsing resource returned by plugin: %w", err)
+	}
+	return obj, nil
+}
+
+func (p *PluginPersister) Persist(ctx context.Context, resource *unstructured.Unstructured, t persist.Transformer, name, subPath string) (*unstructured.Unstructured, bool, error) {
+	transformed, err := t.Transform(resource)
+	if err != nil {
+		return nil, false, fmt.Errorf("error transforming resource: %w", err)
+	}
+	data, err := transformed.MarshalJSON()
+	if err != nil {
+		return nil, false, fmt.Errorf("error serializing resource: %w", err)
+	}
+	callCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	resp, err := p.client.Persist(callCtx, &PersistRequest{
+		Key:      p.key(name, resource.GetNamespace(), resource.GroupVersionKind(), subPath),
+		Resource: data,
+	})
+	if err != nil {
+		return nil, false, callError("Persist", err)
+	}
+	if resp.Changed {
+		logging.FromContextOrDiscard(ctx).Debug("Resource persisted by plugin", constants.Logging.KEY_RESOURCE_NAME, name, constants.Logging.KEY_RESOURCE_NAMESPACE, resource.GetNamespace())
+	}
+	return transformed, resp.Changed, nil
+}
+
+func (p *PluginPersister) Delete(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	if _, err := p.client.Delete(ctx, &DeleteRequest{Key: p.key(name, namespace, gvk, subPath)}); err != nil {
+		return callError("Delete", err)
+	}
+	return nil
+}
+
+func (p *PluginPersister) InternalPersister() persist.Persister {
+	return nil
+}
+
+// key returns the key which identifies the given resource in calls to the plugin.
+func (p *PluginPersister) key(name, namespace string, gvk schema.GroupVersionKind, subPath string) *ResourceKey {
+	return &ResourceKey{
+		Name:      name,
+		Namespace: namespace,
+		Group:     gvk.Group,
+		Version:   gvk.Version,
+		Kind:      gvk.Kind,
+		SubPath:   subPath,
+		Storage:   p.storageName,
+	}
+}
+
+// callError wraps an error returned by a call of the given plugin method.
+func callError(method string, err error) error {
+	return fmt.Errorf("error calling plugin method '%s': %w", method, err)
+}
@@ -0,0 +1,344 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist/transformers"
+	testutils "github.com/gardener/k8syncer/test/utils"
+)
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Plugin Persister Test Suite")
+}
+
+var _ = Describe("Plugin Persister Tests", func() {
+
+	var (
+		dummy            *unstructured.Unstructured
+		basicTransformer = transformers.NewBasic()
+		ctx              context.Context
+		plugin           *fakePlugin
+	)
+
+	BeforeEach(func() {
+		dummy = &unstructured.Unstructured{}
+		dummy.SetName("foo")
+		dummy.SetNamespace("bar")
+		dummy.SetGroupVersionKind(schema.GroupVersionKind{
+			Group:   "k8syncer.gardener.cloud",
+			Version: "v1",
+			Kind:    "Dummy",
+		})
+		dummy.SetResourceVersion("1")
+		Expect(unstructured.SetNestedField(dummy.Object, fmt.Sprint(time.Now().Unix()), "spec", "value")).To(Succeed())
+
+		ctx = logging.NewContext(context.Background(), logging.Discard())
+		plugin = newFakePlugin()
+	})
+
+	// serve starts a gRPC server for the fake plugin on the given listener, which is stopped after the test.
+	serve := func(listener net.Listener, opts ...grpc.ServerOption) {
+		srv := grpc.NewServer(opts...)
+		RegisterPersisterServer(srv, plugin)
+		go func() {
+			defer GinkgoRecover()
+			Expect(srv.Serve(listener)).To(Succeed())
+		}()
+		DeferCleanup(srv.Stop)
+	}
+
+	// serveTCP starts a gRPC server for the fake plugin on a random local port and returns its address.
+	serveTCP := func(opts ...grpc.ServerOption) string {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		serve(listener, opts...)
+		return listener.Addr().String()
+	}
+
+	newPersister := func(address string, tlsCfg ...*config.PluginTLSConfiguration) *PluginPersister {
+		cfg := &config.K8SyncerConfiguration{
+			StorageDefinitions: []*config.StorageDefinition{
+				{
+					Name: "plugin",
+					Type: config.STORAGE_TYPE_PLUGIN,
+					PluginConfig: &config.PluginConfiguration{
+						Address: address,
+					},
+				},
+			},
+		}
+		if len(tlsCfg) > 0 {
+			cfg.StorageDefinitions[0].PluginConfig.TLS = tlsCfg[0]
+		}
+		Expect(cfg.Complete()).To(Succeed())
+		p, err := New(cfg.StorageDefinitions[0])
+		Expect(err).ToNot(HaveOccurred())
+		return p
+	}
+
+	// testRoundTrip runs the persister round trip below a sub path, which has to be passed on to the plugin.
+	testRoundTrip := func(p *PluginPersister) {
+		testutils.PersisterRoundTrip(ctx, p, dummy, &testutils.RoundTripOptions{
+			SubPath: "sub",
+			AfterPersist: func() {
+				exists, err := p.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(exists).To(BeFalse())
+			},
+		})
+	}
+
+	It("should delegate all operations to a plugin listening on a TCP port", func() {
+		p := newPersister(serveTCP())
+		testRoundTrip(p)
+		Expect(plugin.keys).ToNot(BeEmpty())
+		Expect(proto.Equal(plugin.keys[0], &ResourceKey{
+			Name:      "foo",
+			Namespace: "bar",
+			Group:     "k8syncer.gardener.cloud",
+			Version:   "v1",
+			Kind:      "Dummy",
+			SubPath:   "sub",
+			Storage:   "plugin",
+		})).To(BeTrue(), "unexpected key %v", plugin.keys[0])
+	})
+
+	It("should delegate all operations to a plugin listening on a unix socket", func() {
+		socket := filepath.Join(GinkgoT().TempDir(), "plugin.sock")
+		listener, err := net.Listen("unix", socket)
+		Expect(err).ToNot(HaveOccurred())
+		serve(listener)
+
+		testRoundTrip(newPersister("unix://" + socket))
+	})
+
+	It("should delegate all operations to a plugin which requires mutual TLS", func() {
+		dir := GinkgoT().TempDir()
+		ca := newTestCA()
+		serverCert := ca.issue("plugin.example.com")
+		Expect(os.WriteFile(filepath.Join(dir, "ca.crt"), ca.certPEM, 0o600)).To(Succeed())
+		clientCert := ca.issue("k8syncer")
+		Expect(os.WriteFile(filepath.Join(dir, "tls.crt"), clientCert.certPEM, 0o600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "tls.key"), clientCert.keyPEM, 0o600)).To(Succeed())
+		serverTLS, err := tls.X509KeyPair(serverCert.certPEM, serverCert.keyPEM)
+		Expect(err).ToNot(HaveOccurred())
+		clientCAs := x509.NewCertPool()
+		Expect(clientCAs.AppendCertsFromPEM(ca.certPEM)).To(BeTrue())
+		address := serveTCP(grpc.Creds(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{serverTLS},
+			ClientCAs:    clientCAs,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		})))
+
+		testRoundTrip(newPersister(address, &config.PluginTLSConfiguration{
+			CAFile:     filepath.Join(dir, "ca.crt"),
+			CertFile:   filepath.Join(dir, "tls.crt"),
+			KeyFile:    filepath.Join(dir, "tls.key"),
+			ServerName: "plugin.example.com",
+		}))
+
+		By("verifying the serving certificate")
+		p := newPersister(address, &config.PluginTLSConfiguration{
+			CAFile:   filepath.Join(dir, "ca.crt"),
+			CertFile: filepath.Join(dir, "tls.crt"),
+			KeyFile:  filepath.Join(dir, "tls.key"),
+		})
+		_, err = p.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "")
+		Expect(status.Code(err)).To(Equal(codes.Unavailable))
+
+		By("requiring a client certificate")
+		p = newPersister(address, &config.PluginTLSConfiguration{
+			CAFile:     filepath.Join(dir, "ca.crt"),
+			ServerName: "plugin.example.com",
+		})
+		_, err = p.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return the errors reported by the plugin", func() {
+		p := newPersister(serveTCP())
+		plugin.fail = "Persist"
+		_, _, err := p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Persist"))
+		Expect(status.Code(err)).To(Equal(codes.Unavailable))
+		Expect(err.Error()).To(ContainSubstring("desc = storage unavailable"))
+	})
+
+	It("should fail if the plugin does not answer in time", func() {
+		p := newPersister(serveTCP())
+		p.timeout = 100 * time.Millisecond
+		plugin.delay = time.Second
+		_, err := p.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "")
+		Expect(status.Code(err)).To(Equal(codes.DeadlineExceeded))
+	})
+
+	It("should fail if the plugin is not reachable", func() {
+		p := newPersister("unix://" + filepath.Join(GinkgoT().TempDir(), "missing.sock"))
+		_, err := p.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "")
+		Expect(status.Code(err)).To(Equal(codes.Unavailable))
+	})
+
+})
+
+// fakePlugin is a minimal implementation of the plugin service, which stores all resources in memory.
+type fakePlugin struct {
+	UnimplementedPersisterServer
+	lock sync.Mutex
+	data map[string][]byte
+	// keys contains the keys of all received requests.
+	keys []*ResourceKey
+	// fail is the method which fails with status 'UNAVAILABLE'.
+	fail string
+	// delay delays all responses.
+	delay time.Duration
+}
+
+func newFakePlugin() *fakePlugin {
+	return &fakePlugin{
+		data: map[string][]byte{},
+	}
+}
+
+// handle records the key of a request and returns the key under which the resource is stored,
+// or an error if the given method is configured to fail.
+func (f *fakePlugin) handle(method string, key *ResourceKey) (string, error) {
+	time.Sleep(f.delay)
+	f.keys = append(f.keys, key)
+	if method == f.fail {
+		return "", status.Error(codes.Unavailable, "storage unavailable")
+	}
+	return key.String(), nil
+}
+
+func (f *fakePlugin) Exists(_ context.Context, req *ExistsRequest) (*ExistsResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	key, err := f.handle("Exists", req.Key)
+	if err != nil {
+		return nil, err
+	}
+	_, exists := f.data[key]
+	return &ExistsResponse{Exists: exists}, nil
+}
+
+func (f *fakePlugin) Get(_ context.Context, req *GetRequest) (*GetResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	key, err := f.handle("Get", req.Key)
+	if err != nil {
+		return nil, err
+	}
+	data, found := f.data[key]
+	return &GetResponse{Found: found, Resource: data}, nil
+}
+
+func (f *fakePlugin) Persist(_ context.Context, req *PersistRequest) (*PersistResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	key, err := f.handle("Persist", req.Key)
+	if err != nil {
+		return nil, err
+	}
+	changed := !bytes.Equal(f.data[key], req.Resource)
+	f.data[key] = req.Resource
+	return &PersistResponse{Changed: changed}, nil
+}
+
+func (f *fakePlugin) Delete(_ context.Context, req *DeleteRequest) (*DeleteResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	key, err := f.handle("Delete", req.Key)
+	if err != nil {
+		return nil, err
+	}
+	delete(f.data, key)
+	return &DeleteResponse{}, nil
+}
+
+// testCert is a PEM encoded certificate with its key.
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCA creates a self-signed CA certificate.
+func newTestCA() *testCert {
+	return newTestCert(&x509.Certificate{
+		Subject:               pkix.Name{CommonName: "k8syncer-test-ca"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil)
+}
+
+// issue creates a certificate for the given name, which is signed by the CA and valid for server and client authentication.
+func (ca *testCert) issue(name string) *testCert {
+	return newTestCert(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: name},
+		DNSNames:    []string{name},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}, ca)
+}
+
+func newTestCert(tmpl *x509.Certificate, ca *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	Expect(err).ToNot(HaveOccurred())
+	tmpl.SerialNumber = serial
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	parent, parentKey := tmpl, key
+	if ca != nil {
+		parent, parentKey = ca.cert, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	Expect(err).ToNot(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).ToNot(HaveOccurred())
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).ToNot(HaveOccurred())
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
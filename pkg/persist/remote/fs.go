@@ -0,0 +1,531 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"syscall"
+	"time"
+
+	vfsutils "github.com/mandelsoft/vfs/pkg/utils"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// client provides access to the files on a remote server.
+// All paths are slash-separated paths on the server. Errors for files which don't exist match fs.ErrNotExist.
+type client interface {
+	// stat returns information about the file or directory at the given path, following symbolic links.
+	stat(path string) (fs.FileInfo, error)
+	// readDir returns the entries of the given directory.
+	readDir(path string) ([]fs.FileInfo, error)
+	// readFile returns the content of the given file.
+	readFile(path string) ([]byte, error)
+	// writeFile replaces the content of the given file, creating it if it doesn't exist.
+	writeFile(path string, data []byte) error
+	// mkdir creates the given directory, its parent directory has to exist.
+	mkdir(path string) error
+	// remove deletes the given file or empty directory.
+	remove(path string, isDir bool) error
+	// rename moves the given file or directory, replacing an existing file at the new path.
+	rename(oldPath, newPath string) error
+}
+
+var _ vfs.FileSystem = &fileSystem{}
+
+// fileSystem is a vfs.FileSystem for a directory on a remote server.
+// Its root is the remote directory, the paths are mapped to paths below it.
+// Opened files are buffered in memory: their content is downloaded when they are opened and uploaded when they are synced or closed.
+// Symbolic links on the server are resolved by the server, the filesystem itself doesn't support them.
+type fileSystem struct {
+	vfsutils.FileSystemBase
+	name   string
+	root   string
+	client client
+}
+
+// newFileSystem returns a filesystem whose root is the given directory on the server.
+// The name is used to identify the filesystem in messages, it must not contain credentials.
+func newFileSystem(name, root string, c client) *fileSystem {
+	return &fileSystem{
+		name:   name,
+		root:   root,
+		client: c,
+	}
+}
+
+func (f *fileSystem) Name() string {
+	return f.name
+}
+
+// remotePath returns the path on the server for the given path of the filesystem.
+func (f *fileSystem) remotePath(name string) string {
+	return path.Join(f.root, path.Clean("/"+name))
+}
+
+// pathError returns a PathError for an error of the client.
+func pathError(op, name string, err error) error {
+	return &fs.PathError{Op: op, Path: name, Err: clientError(err)}
+}
+
+// clientError replaces errors of the client for missing files with fs.ErrNotExist,
+// as os.IsNotExist, which is used by the vfs utilities and the FileSystemPersister, doesn't evaluate the Is methods of errors.
+func clientError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fs.ErrNotExist
+	}
+	return err
+}
+
+func (f *fileSystem) Create(name string) (vfs.File, error) {
+	return f.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+func (f *fileSystem) Open(name string) (vfs.File, error) {
+	return f.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (f *fileSystem) OpenFile(name string, flags int, perm vfs.FileMode) (vfs.File, error) {
+	p := f.remotePath(name)
+	info, err := f.client.stat(p)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, pathError("open", name, err)
+	}
+	res := &file{
+		fs:    f,
+		name:  name,
+		path:  p,
+		flags: flags,
+	}
+	switch {
+	case err != nil:
+		if flags&os.O_CREATE == 0 {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		// the file is created on the server when it is synced
+		res.mode = perm & fs.ModePerm
+		res.modTime = time.Now()
+		res.dirty = true
+	case flags&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	case info.IsDir():
+		if res.writable() {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: syscall.EISDIR}
+		}
+		res.mode = info.Mode()
+		res.modTime = info.ModTime()
+	case res.writable() && flags&os.O_TRUNC != 0:
+		res.mode = info.Mode()
+		res.modTime = time.Now()
+		res.dirty = true
+	default:
+		data, err := f.client.readFile(p)
+		if err != nil {
+			return nil, pathError("open", name, err)
+		}
+		res.data = data
+		res.mode = info.Mode()
+		res.modTime = info.ModTime()
+	}
+	return res, nil
+}
+
+func (f *fileSystem) Mkdir(name string, perm vfs.FileMode) error {
+	p := f.remotePath(name)
+	if _, err := f.client.stat(p); err == nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return pathError("mkdir", name, err)
+	}
+	if err := f.client.mkdir(p); err != nil {
+		return pathError("mkdir", name, err)
+	}
+	return nil
+}
+
+func (f *fileSystem) MkdirAll(name string, perm vfs.FileMode) error {
+	p := f.remotePath(name)
+	info, err := f.client.stat(p)
+	if err == nil {
+		if info.IsDir() {
+			return nil
+		}
+		return &fs.PathError{Op: "mkdir", Path: name, Err: syscall.ENOTDIR}
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return pathError("mkdir", name, err)
+	}
+	clean := path.Clean("/" + name)
+	if parent := path.Dir(clean); parent != clean {
+		if err := f.MkdirAll(parent, perm); err != nil {
+			return err
+		}
+	}
+	if err := f.client.mkdir(p); err != nil {
+		// the directory might have been created concurrently
+		if info, statErr := f.client.stat(p); statErr == nil && info.IsDir() {
+			return nil
+		}
+		return pathError("mkdir", name, err)
+	}
+	return nil
+}
+
+func (f *fileSystem) Remove(name string) error {
+	p := f.remotePath(name)
+	info, err := f.client.stat(p)
+	if err != nil {
+		return pathError("remove", name, err)
+	}
+	if info.IsDir() {
+		entries, err := f.client.readDir(p)
+		if err != nil {
+			return pathError("remove", name, err)
+		}
+		if len(entries) > 0 {
+			return &fs.PathError{Op: "remove", Path: name, Err: syscall.ENOTEMPTY}
+		}
+	}
+	if err := f.client.remove(p, info.IsDir()); err != nil {
+		return pathError("remove", name, err)
+	}
+	return nil
+}
+
+func (f *fileSystem) RemoveAll(name string) error {
+	p := f.remotePath(name)
+	info, err := f.client.stat(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return pathError("remove", name, err)
+	}
+	if info.IsDir() {
+		entries, err := f.client.readDir(p)
+		if err != nil {
+			return pathError("remove", name, err)
+		}
+		for _, entry := range entries {
+			if err := f.RemoveAll(path.Join(name, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	if err := f.client.remove(p, info.IsDir()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return pathError("remove", name, err)
+	}
+	return nil
+}
+
+func (f *fileSystem) Rename(oldname, newname string) error {
+	if err := f.client.rename(f.remotePath(oldname), f.remotePath(newname)); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: clientError(err)}
+	}
+	return nil
+}
+
+func (f *fileSystem) Stat(name string) (vfs.FileInfo, error) {
+	info, err := f.client.stat(f.remotePath(name))
+	if err != nil {
+		return nil, pathError("stat", name, err)
+	}
+	return &fileInfo{
+		name:    path.Base(path.Clean("/" + name)),
+		size:    info.Size(),
+		mode:    info.Mode(),
+		modTime: info.ModTime(),
+	}, nil
+}
+
+// Lstat is the same as Stat, as symbolic links are resolved by the server.
+func (f *fileSystem) Lstat(name string) (vfs.FileInfo, error) {
+	return f.Stat(name)
+}
+
+func (f *fileSystem) Symlink(oldname, newname string) error {
+	return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: errors.ErrUnsupported}
+}
+
+func (f *fileSystem) Readlink(name string) (string, error) {
+	return "", &fs.PathError{Op: "readlink", Path: name, Err: errors.ErrUnsupported}
+}
+
+func (f *fileSystem) Chmod(name string, mode vfs.FileMode) error {
+	return &fs.PathError{Op: "chmod", Path: name, Err: errors.ErrUnsupported}
+}
+
+func (f *fileSystem) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return &fs.PathError{Op: "chtimes", Path: name, Err: errors.ErrUnsupported}
+}
+
+var _ vfs.File = &file{}
+
+// file is an opened file or directory of a fileSystem.
+// The content of a file is kept in memory, changes are written to the server by Sync and Close.
+type file struct {
+	fs    *fileSystem
+	name  string
+	path  string
+	flags int
+
+	mode    fs.FileMode
+	modTime time.Time
+	data    []byte
+	offset  int64
+	// dirty is true if the content has been modified since it has been read or written last.
+	dirty  bool
+	closed bool
+
+	// entries are the entries of a directory, they are read on first access.
+	entries []fs.FileInfo
+	dirPos  int
+}
+
+func (f *file) writable() bool {
+	return f.flags&(os.O_WRONLY|os.O_RDWR) != 0
+}
+
+func (f *file) readable() bool {
+	return f.flags&os.O_WRONLY == 0
+}
+
+// check returns an error if the file is closed or doesn't support the given operation.
+func (f *file) check(op string, write bool) error {
+	switch {
+	case f.closed:
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
+	case f.mode.IsDir():
+		return &fs.PathError{Op: op, Path: f.name, Err: syscall.EISDIR}
+	case write && !f.writable(), !write && !f.readable():
+		return &fs.PathError{Op: op, Path: f.name, Err: syscall.EBADF}
+	}
+	return nil
+}
+
+func (f *file) Name() string {
+	return f.name
+}
+
+func (f *file) Read(b []byte) (int, error) {
+	if err := f.check("read", false); err != nil {
+		return 0, err
+	}
+	if f.offset >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	n := copy(b, f.data[f.offset:])
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *file) ReadAt(b []byte, off int64) (int, error) {
+	if err := f.check("read", false); err != nil {
+		return 0, err
+	}
+	if off < 0 {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: syscall.EINVAL}
+	}
+	if off >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	n := copy(b, f.data[off:])
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrClosed}
+	}
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(len(f.data))
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: syscall.EINVAL}
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *file) Write(b []byte) (int, error) {
+	if f.flags&os.O_APPEND != 0 {
+		f.offset = int64(len(f.data))
+	}
+	n, err := f.WriteAt(b, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *file) WriteAt(b []byte, off int64) (int, error) {
+	if err := f.check("write", true); err != nil {
+		return 0, err
+	}
+	if off < 0 {
+		return 0, &fs.PathError{Op: "write", Path: f.name, Err: syscall.EINVAL}
+	}
+	if end := off + int64(len(b)); end > int64(len(f.data)) {
+		f.data = append(f.data, make([]byte, end-int64(len(f.data)))...)
+	}
+	copy(f.data[off:], b)
+	f.dirty = true
+	return len(b), nil
+}
+
+func (f *file) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+func (f *file) Truncate(size int64) error {
+	if err := f.check("truncate", true); err != nil {
+		return err
+	}
+	if size < 0 {
+		return &fs.PathError{Op: "truncate", Path: f.name, Err: syscall.EINVAL}
+	}
+	if size <= int64(len(f.data)) {
+		f.data = f.data[:size]
+	} else {
+		f.data = append(f.data, make([]byte, size-int64(len(f.data)))...)
+	}
+	f.dirty = true
+	return nil
+}
+
+// Sync uploads the content of the file, if it has been modified.
+func (f *file) Sync() error {
+	if f.closed {
+		return &fs.PathError{Op: "sync", Path: f.name, Err: fs.ErrClosed}
+	}
+	if !f.dirty {
+		return nil
+	}
+	if err := f.fs.client.writeFile(f.path, f.data); err != nil {
+		return pathError("sync", f.name, err)
+	}
+	f.dirty = false
+	f.modTime = time.Now()
+	return nil
+}
+
+func (f *file) Close() error {
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.name, Err: fs.ErrClosed}
+	}
+	err := f.Sync()
+	f.closed = true
+	f.data = nil
+	f.entries = nil
+	return err
+}
+
+func (f *file) Stat() (vfs.FileInfo, error) {
+	if f.closed {
+		return nil, &fs.PathError{Op: "stat", Path: f.name, Err: fs.ErrClosed}
+	}
+	return &fileInfo{
+		name:    path.Base(path.Clean("/" + f.name)),
+		size:    int64(len(f.data)),
+		mode:    f.mode,
+		modTime: f.modTime,
+	}, nil
+}
+
+func (f *file) Readdir(count int) ([]vfs.FileInfo, error) {
+	if f.closed {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: fs.ErrClosed}
+	}
+	if !f.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: syscall.ENOTDIR}
+	}
+	if f.entries == nil {
+		entries, err := f.fs.client.readDir(f.path)
+		if err != nil {
+			return nil, pathError("readdir", f.name, err)
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+		f.entries = entries
+	}
+	remaining := f.entries[f.dirPos:]
+	if count <= 0 {
+		f.dirPos = len(f.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(remaining) {
+		count = len(remaining)
+	}
+	f.dirPos += count
+	return remaining[:count], nil
+}
+
+func (f *file) ReadDir(count int) ([]vfs.DirEntry, error) {
+	infos, err := f.Readdir(count)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]vfs.DirEntry, 0, len(infos))
+	for _, info := range infos {
+		res = append(res, fs.FileInfoToDirEntry(info))
+	}
+	return res, nil
+}
+
+func (f *file) Readdirnames(n int) ([]string, error) {
+	infos, err := f.Readdir(n)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, 0, len(infos))
+	for _, info := range infos {
+		res = append(res, info.Name())
+	}
+	return res, nil
+}
+
+var _ fs.FileInfo = &fileInfo{}
+
+// fileInfo describes a file or directory on the server.
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (fi *fileInfo) Name() string {
+	return fi.name
+}
+
+func (fi *fileInfo) Size() int64 {
+	return fi.size
+}
+
+func (fi *fileInfo) Mode() fs.FileMode {
+	return fi.mode
+}
+
+func (fi *fileInfo) ModTime() time.Time {
+	return fi.modTime
+}
+
+func (fi *fileInfo) IsDir() bool {
+	return fi.mode.IsDir()
+}
+
+func (fi *fileInfo) Sys() any {
+	return nil
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	fspersist "github.com/gardener/k8syncer/pkg/persist/filesystem"
+)
+
+// New creates a new FileSystemPersister which stores the resources on the remote file share of the given storage definition.
+// The storage definition is expected to be completed and validated.
+func New(stDef *config.StorageDefinition) (*fspersist.FileSystemPersister, error) {
+	var rfs *fileSystem
+	switch stDef.Type {
+	case config.STORAGE_TYPE_SFTP:
+		if stDef.SFTPConfig == nil {
+			return nil, fmt.Errorf("sftp config must not be nil")
+		}
+		root := stDef.SFTPConfig.Path
+		if root == "" {
+			root = "."
+		}
+		rfs = newFileSystem(fmt.Sprintf("sftp://%s/%s", stDef.SFTPConfig.Host, strings.TrimPrefix(root, "/")), root, newSFTPClient(stDef.SFTPConfig))
+	case config.STORAGE_TYPE_WEBDAV:
+		if stDef.WebDAVConfig == nil {
+			return nil, fmt.Errorf("webdav config must not be nil")
+		}
+		c, err := newWebDAVClient(stDef.WebDAVConfig)
+		if err != nil {
+			return nil, err
+		}
+		root := path.Join("/", stDef.WebDAVConfig.Path)
+		rfs = newFileSystem(c.url(root), root, c)
+	default:
+		return nil, fmt.Errorf("unsupported remote storage type '%s'", string(stDef.Type))
+	}
+	return newForFileSystem(rfs, stDef.FileSystemConfig)
+}
+
+// newForFileSystem creates a new FileSystemPersister whose root path is the root of the given remote filesystem.
+func newForFileSystem(rfs vfs.FileSystem, fsCfg *config.FileSystemConfiguration) (*fspersist.FileSystemPersister, error) {
+	cfg := fsCfg.DeepCopy()
+	if cfg == nil {
+		cfg = &config.FileSystemConfiguration{}
+	}
+	cfg.RootPath = "/"
+	exists, err := vfs.DirExists(rfs, cfg.RootPath)
+	if err != nil {
+		return nil, fmt.Errorf("error accessing remote directory '%s': %w", rfs.Name(), err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("remote directory '%s' does not exist or is not a directory", rfs.Name())
+	}
+	return fspersist.New(rfs, cfg, false)
+}
+
+// readPassword returns the given password, or the content of the given password file, if it is set.
+// The file is read on every call, so that rotated passwords are picked up.
+func readPassword(password, passwordFile string) (string, error) {
+	if passwordFile == "" {
+		return password, nil
+	}
+	data, err := os.ReadFile(passwordFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading password file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
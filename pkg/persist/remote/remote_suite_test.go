@@ -0,0 +1,428 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/webdav"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+	fspersist "github.com/gardener/k8syncer/pkg/persist/filesystem"
+	testutils "github.com/gardener/k8syncer/test/utils"
+)
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Remote Persister Test Suite")
+}
+
+const (
+	testUsername = "k8syncer"
+	testPassword = "secret"
+)
+
+var _ = Describe("Remote Persister Tests", func() {
+
+	var (
+		dummy *unstructured.Unstructured
+		ctx   context.Context
+	)
+
+	BeforeEach(func() {
+		dummy = &unstructured.Unstructured{}
+		dummy.SetName("foo")
+		dummy.SetNamespace("bar")
+		dummy.SetGroupVersionKind(schema.GroupVersionKind{
+			Group:   "k8syncer.gardener.cloud",
+			Version: "v1",
+			Kind:    "Dummy",
+		})
+		Expect(unstructured.SetNestedField(dummy.Object, fmt.Sprint(time.Now().Unix()), "spec", "value")).To(Succeed())
+
+		ctx = logging.NewContext(context.Background(), logging.Discard())
+	})
+
+	newPersister := func(stDef *config.StorageDefinition) (*fspersist.FileSystemPersister, error) {
+		cfg := &config.K8SyncerConfiguration{
+			StorageDefinitions: []*config.StorageDefinition{stDef},
+		}
+		Expect(cfg.Complete()).To(Succeed())
+		return New(cfg.StorageDefinitions[0])
+	}
+
+	// testRoundTrip runs the persister round trip below a sub path and verifies the files on the remote side.
+	// exists is called with the path of the resource file relative to the remote directory.
+	testRoundTrip := func(p *fspersist.FileSystemPersister, exists func(relPath string) bool) {
+		gvk := dummy.GroupVersionKind()
+		file, _, _ := p.GetResourceFilepath(ctx, dummy.GetName(), dummy.GetNamespace(), gvk, "archive", false)
+
+		testutils.PersisterRoundTrip(ctx, p, dummy, &testutils.RoundTripOptions{
+			SubPath: "archive",
+			AfterPersist: func() {
+				Expect(exists(file)).To(BeTrue())
+
+				By("listing and reading the persisted resources")
+				resources, err := p.List(ctx, gvk, "archive")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resources).To(ConsistOf(persist.PersistedResource{Name: "foo", Namespace: "bar"}))
+				tree, err := p.ReadTree(ctx, "archive")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(tree).To(HaveLen(1))
+				Expect(tree).To(HaveKey(path.Join("ns_bar", path.Base(file))))
+			},
+			AfterDelete: func() {
+				Expect(exists(file)).To(BeFalse())
+			},
+		})
+	}
+
+	Context("sftp", func() {
+
+		var (
+			server    *fakeSFTPServer
+			root      string
+			clientKey ed25519.PrivateKey
+		)
+
+		BeforeEach(func() {
+			root = GinkgoT().TempDir()
+			Expect(os.Mkdir(filepath.Join(root, "data"), 0755)).To(Succeed())
+			var err error
+			_, clientKey, err = ed25519.GenerateKey(rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+			authorizedKey, err := ssh.NewPublicKey(clientKey.Public())
+			Expect(err).ToNot(HaveOccurred())
+			server = newFakeSFTPServer(root, authorizedKey)
+			DeferCleanup(server.close)
+		})
+
+		sftpDefinition := func(sftpCfg *config.SFTPConfiguration) *config.StorageDefinition {
+			return &config.StorageDefinition{
+				Name:       "sftp",
+				Type:       config.STORAGE_TYPE_SFTP,
+				SFTPConfig: sftpCfg,
+			}
+		}
+
+		existsLocally := func(dir string) func(string) bool {
+			return func(relPath string) bool {
+				_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(relPath)))
+				return err == nil
+			}
+		}
+
+		It("should store resources on an sftp server using password authentication", func() {
+			p, err := newPersister(sftpDefinition(&config.SFTPConfiguration{
+				Host:     server.address(),
+				Path:     "data",
+				Username: testUsername,
+				Password: testPassword,
+				HostKey:  server.authorizedHostKey(),
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			testRoundTrip(p, existsLocally(filepath.Join(root, "data")))
+		})
+
+		It("should store resources in the login directory using public key authentication and a known hosts file", func() {
+			block, err := ssh.MarshalPrivateKey(clientKey, "")
+			Expect(err).ToNot(HaveOccurred())
+			knownHostsFile := filepath.Join(GinkgoT().TempDir(), "known_hosts")
+			Expect(os.WriteFile(knownHostsFile, []byte(fmt.Sprintf("%s %s", server.address(), server.authorizedHostKey())), 0600)).To(Succeed())
+			p, err := newPersister(sftpDefinition(&config.SFTPConfiguration{
+				Host:           server.address(),
+				Username:       testUsername,
+				PrivateKey:     string(pem.EncodeToMemory(block)),
+				KnownHostsFile: knownHostsFile,
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			testRoundTrip(p, existsLocally(root))
+		})
+
+		It("should rename files if the server doesn't support the posix-rename extension", func() {
+			// the extensions are global, so they are restored after the test
+			Expect(sftp.SetSFTPExtensions("hardlink@openssh.com", "statvfs@openssh.com")).To(Succeed())
+			DeferCleanup(sftp.SetSFTPExtensions, "hardlink@openssh.com", posixRenameExtension, "statvfs@openssh.com")
+			p, err := newPersister(sftpDefinition(&config.SFTPConfiguration{
+				Host:     server.address(),
+				Path:     "data",
+				Username: testUsername,
+				Password: testPassword,
+				HostKey:  server.authorizedHostKey(),
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			testRoundTrip(p, existsLocally(filepath.Join(root, "data")))
+		})
+
+		It("should reconnect after the connection has been closed", func() {
+			p, err := newPersister(sftpDefinition(&config.SFTPConfiguration{
+				Host:     server.address(),
+				Path:     "data",
+				Username: testUsername,
+				Password: testPassword,
+				HostKey:  server.authorizedHostKey(),
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			server.closeConnections()
+			// the first request after the connection has been closed fails, the next one reconnects
+			Eventually(func() error {
+				_, err := p.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "archive")
+				return err
+			}).Should(Succeed())
+			testRoundTrip(p, existsLocally(filepath.Join(root, "data")))
+		})
+
+		It("should fail for wrong credentials or an unknown host key", func() {
+			_, err := newPersister(sftpDefinition(&config.SFTPConfiguration{
+				Host:     server.address(),
+				Username: testUsername,
+				Password: "wrong",
+				HostKey:  server.authorizedHostKey(),
+			}))
+			Expect(err).To(HaveOccurred())
+
+			otherKey, _, err := ed25519.GenerateKey(rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+			otherPublicKey, err := ssh.NewPublicKey(otherKey)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = newPersister(sftpDefinition(&config.SFTPConfiguration{
+				Host:     server.address(),
+				Username: testUsername,
+				Password: testPassword,
+				HostKey:  string(ssh.MarshalAuthorizedKey(otherPublicKey)),
+			}))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail if the directory doesn't exist", func() {
+			_, err := newPersister(sftpDefinition(&config.SFTPConfiguration{
+				Host:     server.address(),
+				Path:     "missing",
+				Username: testUsername,
+				Password: testPassword,
+				HostKey:  server.authorizedHostKey(),
+			}))
+			Expect(err).To(MatchError(ContainSubstring("does not exist")))
+		})
+
+	})
+
+	Context("webdav", func() {
+
+		var (
+			server *httptest.Server
+			davFS  webdav.FileSystem
+		)
+
+		BeforeEach(func() {
+			davFS = webdav.NewMemFS()
+			Expect(davFS.Mkdir(context.Background(), "/k8syncer", 0755)).To(Succeed())
+			handler := &webdav.Handler{
+				Prefix:     "/dav",
+				FileSystem: davFS,
+				LockSystem: webdav.NewMemLS(),
+			}
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if username, password, ok := r.BasicAuth(); !ok || username != testUsername || password != testPassword {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				handler.ServeHTTP(w, r)
+			}))
+			DeferCleanup(server.Close)
+		})
+
+		webdavDefinition := func(webdavCfg *config.WebDAVConfiguration) *config.StorageDefinition {
+			return &config.StorageDefinition{
+				Name:         "webdav",
+				Type:         config.STORAGE_TYPE_WEBDAV,
+				WebDAVConfig: webdavCfg,
+			}
+		}
+
+		It("should store resources on a webdav server", func() {
+			passwordFile := filepath.Join(GinkgoT().TempDir(), "password")
+			Expect(os.WriteFile(passwordFile, []byte(testPassword+"\n"), 0600)).To(Succeed())
+			p, err := newPersister(webdavDefinition(&config.WebDAVConfiguration{
+				URL:          server.URL + "/dav/",
+				Path:         "k8syncer",
+				Username:     testUsername,
+				PasswordFile: passwordFile,
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			testRoundTrip(p, func(relPath string) bool {
+				_, err := davFS.Stat(context.Background(), path.Join("/k8syncer", relPath))
+				return err == nil
+			})
+		})
+
+		It("should fail for wrong credentials", func() {
+			_, err := newPersister(webdavDefinition(&config.WebDAVConfiguration{
+				URL:      server.URL + "/dav",
+				Path:     "k8syncer",
+				Username: testUsername,
+				Password: "wrong",
+			}))
+			Expect(err).To(HaveOccurred())
+			Expect(errors.Is(err, fs.ErrPermission)).To(BeTrue())
+		})
+
+		It("should fail if the collection doesn't exist", func() {
+			_, err := newPersister(webdavDefinition(&config.WebDAVConfiguration{
+				URL:      server.URL + "/dav",
+				Path:     "missing",
+				Username: testUsername,
+				Password: testPassword,
+				Timeout:  &metav1.Duration{Duration: 5 * time.Second},
+			}))
+			Expect(err).To(MatchError(ContainSubstring("does not exist")))
+		})
+
+	})
+
+})
+
+// fakeSFTPServer is an SSH server which serves the SFTP subsystem for a local directory, which is also the login directory.
+type fakeSFTPServer struct {
+	listener net.Listener
+	config   *ssh.ServerConfig
+	hostKey  ssh.Signer
+	root     string
+
+	lock  sync.Mutex
+	conns []net.Conn
+}
+
+func newFakeSFTPServer(root string, authorizedKey ssh.PublicKey) *fakeSFTPServer {
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	Expect(err).ToNot(HaveOccurred())
+	s := &fakeSFTPServer{
+		hostKey: signer,
+		root:    root,
+		config: &ssh.ServerConfig{
+			PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+				if meta.User() == testUsername && string(password) == testPassword {
+					return nil, nil
+				}
+				return nil, fmt.Errorf("invalid credentials")
+			},
+			PublicKeyCallback: func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+				if meta.User() == testUsername && bytes.Equal(key.Marshal(), authorizedKey.Marshal()) {
+					return nil, nil
+				}
+				return nil, fmt.Errorf("unknown key")
+			},
+		},
+	}
+	s.config.AddHostKey(signer)
+	s.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+	go s.serve()
+	return s
+}
+
+func (s *fakeSFTPServer) address() string {
+	return s.listener.Addr().String()
+}
+
+// authorizedHostKey returns the public host key in the authorized_keys format.
+func (s *fakeSFTPServer) authorizedHostKey() string {
+	return string(ssh.MarshalAuthorizedKey(s.hostKey.PublicKey()))
+}
+
+func (s *fakeSFTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.lock.Lock()
+		s.conns = append(s.conns, conn)
+		s.lock.Unlock()
+		go s.handleConn(conn)
+	}
+}
+
+// closeConnections closes all established connections.
+func (s *fakeSFTPServer) closeConnections() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *fakeSFTPServer) close() {
+	_ = s.listener.Close()
+	s.closeConnections()
+}
+
+func (s *fakeSFTPServer) handleConn(conn net.Conn) {
+	defer conn.Close()
+	_, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChan := range chans {
+		if newChan.ChannelType() != "session" {
+			_ = newChan.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		ch, chReqs, err := newChan.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range chReqs {
+				// the payload of a subsystem request is the name of the subsystem
+				subsystem := struct{ Name string }{}
+				ok := req.Type == "subsystem" && ssh.Unmarshal(req.Payload, &subsystem) == nil && subsystem.Name == "sftp"
+				_ = req.Reply(ok, nil)
+				if ok {
+					go s.serveSFTP(ch)
+				}
+			}
+		}()
+	}
+}
+
+func (s *fakeSFTPServer) serveSFTP(ch ssh.Channel) {
+	defer ch.Close()
+	server, err := sftp.NewServer(ch, sftp.WithServerWorkingDirectory(s.root))
+	if err != nil {
+		return
+	}
+	defer server.Close()
+	_ = server.Serve()
+}
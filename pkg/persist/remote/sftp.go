@@ -0,0 +1,291 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+// posixRenameExtension is the OpenSSH extension for renaming files with replacing an existing target.
+const posixRenameExtension = "posix-rename@openssh.com"
+
+var _ client = &sftpClient{}
+
+// sftpClient is a client for SFTP servers.
+// Requests are sent one at a time over a single connection, which is established on first use and re-established after connection errors.
+type sftpClient struct {
+	address string
+	cfg     *config.SFTPConfiguration
+	timeout time.Duration
+
+	// lock serializes the requests, as the deadline of the connection applies to all of them.
+	lock sync.Mutex
+	conn *sftpConn
+}
+
+// sftpConn is an established SFTP session.
+type sftpConn struct {
+	netConn   net.Conn
+	sshClient *ssh.Client
+	client    *sftp.Client
+}
+
+// newSFTPClient returns a client for the server of the given configuration.
+func newSFTPClient(cfg *config.SFTPConfiguration) *sftpClient {
+	address := cfg.Host
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "22")
+	}
+	return &sftpClient{
+		address: address,
+		cfg:     cfg,
+		timeout: cfg.Timeout.Duration,
+	}
+}
+
+func (c *sftpClient) stat(p string) (fs.FileInfo, error) {
+	var res fs.FileInfo
+	err := c.do(func(sc *sftp.Client) error {
+		info, err := sc.Stat(p)
+		res = info
+		return err
+	})
+	return res, err
+}
+
+func (c *sftpClient) readDir(p string) ([]fs.FileInfo, error) {
+	var res []fs.FileInfo
+	err := c.do(func(sc *sftp.Client) error {
+		entries, err := sc.ReadDir(p)
+		if err != nil {
+			return err
+		}
+		res = make([]fs.FileInfo, 0, len(entries))
+		for _, info := range entries {
+			if info.Mode()&fs.ModeSymlink != 0 {
+				// listings contain the attributes of the links, so they are resolved like stat does
+				if info, err = sc.Stat(path.Join(p, info.Name())); err != nil {
+					if errors.Is(err, fs.ErrNotExist) {
+						// dangling link
+						continue
+					}
+					return err
+				}
+			}
+			res = append(res, info)
+		}
+		return nil
+	})
+	return res, err
+}
+
+func (c *sftpClient) readFile(p string) ([]byte, error) {
+	var res []byte
+	err := c.do(func(sc *sftp.Client) error {
+		f, err := sc.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		res, err = io.ReadAll(f)
+		return err
+	})
+	return res, err
+}
+
+func (c *sftpClient) writeFile(p string, data []byte) error {
+	return c.do(func(sc *sftp.Client) error {
+		f, err := sc.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			_ = f.Close()
+			return err
+		}
+		// errors of the close request are returned, as the server might only write the data when the file is closed
+		return f.Close()
+	})
+}
+
+func (c *sftpClient) mkdir(p string) error {
+	return c.do(func(sc *sftp.Client) error {
+		return sc.Mkdir(p)
+	})
+}
+
+func (c *sftpClient) remove(p string, isDir bool) error {
+	return c.do(func(sc *sftp.Client) error {
+		if isDir {
+			return sc.RemoveDirectory(p)
+		}
+		return sc.Remove(p)
+	})
+}
+
+func (c *sftpClient) rename(oldPath, newPath string) error {
+	return c.do(func(sc *sftp.Client) error {
+		if _, ok := sc.HasExtension(posixRenameExtension); ok {
+			return sc.PosixRename(oldPath, newPath)
+		}
+		// the plain rename request fails if the target exists, so an existing file is removed first
+		if info, err := sc.Stat(newPath); err == nil && !info.IsDir() {
+			if err := sc.Remove(newPath); err != nil {
+				return err
+			}
+		}
+		return sc.Rename(oldPath, newPath)
+	})
+}
+
+// do calls f with the SFTP client, establishing the connection first if necessary.
+// If f fails with another error than an error status of the server, the connection is closed, as its state is unknown.
+func (c *sftpClient) do(f func(sc *sftp.Client) error) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.conn == nil {
+		conn, err := c.connect()
+		if err != nil {
+			return err
+		}
+		c.conn = conn
+	}
+	if err := c.conn.netConn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		c.closeConn()
+		return err
+	}
+	err := f(c.conn.client)
+	if err != nil && !isSFTPStatusError(err) {
+		c.closeConn()
+		return err
+	}
+	// the connection is idle until the next request, which must not run into the deadline
+	if deadlineErr := c.conn.netConn.SetDeadline(time.Time{}); deadlineErr != nil {
+		c.closeConn()
+	}
+	return err
+}
+
+// isSFTPStatusError returns true if the given error is an error status returned by the server.
+// The SFTP client replaces the statuses for missing files and denied permissions with the corresponding errors of the os package.
+func isSFTPStatusError(err error) bool {
+	var statusErr *sftp.StatusError
+	return errors.As(err, &statusErr) || errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission)
+}
+
+// connect establishes the SSH connection and starts the SFTP subsystem.
+func (c *sftpClient) connect() (*sftpConn, error) {
+	sshConfig, err := c.sshClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	netConn, err := net.DialTimeout("tcp", c.address, c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to '%s': %w", c.address, err)
+	}
+	conn := &sftpConn{netConn: netConn}
+	if err := netConn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		conn.close()
+		return nil, err
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, c.address, sshConfig)
+	if err != nil {
+		conn.close()
+		return nil, fmt.Errorf("error establishing ssh connection to '%s': %w", c.address, err)
+	}
+	conn.sshClient = ssh.NewClient(sshConn, chans, reqs)
+	conn.client, err = sftp.NewClient(conn.sshClient)
+	if err != nil {
+		conn.close()
+		return nil, fmt.Errorf("error starting sftp session: %w", err)
+	}
+	if err := netConn.SetDeadline(time.Time{}); err != nil {
+		conn.close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// sshClientConfig returns the configuration for the SSH connection.
+// The password and private key files are read every time, so that rotated credentials are picked up with the next connection.
+func (c *sftpClient) sshClientConfig() (*ssh.ClientConfig, error) {
+	res := &ssh.ClientConfig{
+		User:    c.cfg.Username,
+		Timeout: c.timeout,
+	}
+	switch {
+	case c.cfg.HostKey != "":
+		hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(c.cfg.HostKey))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing host key: %w", err)
+		}
+		res.HostKeyCallback = ssh.FixedHostKey(hostKey)
+		res.HostKeyAlgorithms = []string{hostKey.Type()}
+	case c.cfg.KnownHostsFile != "":
+		callback, err := knownhosts.New(c.cfg.KnownHostsFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading known hosts file: %w", err)
+		}
+		res.HostKeyCallback = callback
+	default:
+		// should not happen, as this is checked by the config validation
+		return nil, fmt.Errorf("neither known hosts file nor host key is configured")
+	}
+	privateKey := []byte(c.cfg.PrivateKey)
+	if c.cfg.PrivateKeyFile != "" {
+		var err error
+		privateKey, err = os.ReadFile(c.cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading private key file: %w", err)
+		}
+	}
+	if len(privateKey) > 0 {
+		signer, err := ssh.ParsePrivateKey(privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing private key: %w", err)
+		}
+		res.Auth = append(res.Auth, ssh.PublicKeys(signer))
+	}
+	if c.cfg.Password != "" || c.cfg.PasswordFile != "" {
+		password, err := readPassword(c.cfg.Password, c.cfg.PasswordFile)
+		if err != nil {
+			return nil, err
+		}
+		res.Auth = append(res.Auth, ssh.Password(password))
+	}
+	return res, nil
+}
+
+// closeConn closes the connection, if any.
+func (c *sftpClient) closeConn() {
+	if c.conn != nil {
+		c.conn.close()
+	}
+	c.conn = nil
+}
+
+func (c *sftpConn) close() {
+	if c.client != nil {
+		_ = c.client.Close()
+	}
+	if c.sshClient != nil {
+		_ = c.sshClient.Close()
+	}
+	_ = c.netConn.Close()
+}
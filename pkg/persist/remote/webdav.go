@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/studio-b12/gowebdav"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+// webdavStatusError is an unexpected response status of the server.
+type webdavStatusError struct {
+	err    error
+	status int
+}
+
+func (e *webdavStatusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *webdavStatusError) Unwrap() error {
+	return e.err
+}
+
+func (e *webdavStatusError) Is(target error) bool {
+	switch e.status {
+	case http.StatusNotFound:
+		return target == fs.ErrNotExist
+	case http.StatusConflict:
+		// WebDAV servers answer with 409 if the parent collection doesn't exist
+		return target == fs.ErrNotExist
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return target == fs.ErrPermission
+	}
+	return false
+}
+
+var _ client = &webdavClient{}
+
+// webdavClient is a client for WebDAV servers, as specified in RFC 4918.
+// Directories are mapped to collections, files to non-collection resources. Locks are not used.
+type webdavClient struct {
+	baseURL string
+	client  *gowebdav.Client
+}
+
+// newWebDAVClient returns a client for the server of the given configuration.
+// Paths are resolved against the path of the configured URL.
+func newWebDAVClient(cfg *config.WebDAVConfiguration) (*webdavClient, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webdav url: %w", err)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+	auth := gowebdav.NewEmptyAuth()
+	if cfg.Username != "" {
+		auth = gowebdav.NewPreemptiveAuth(&webdavBasicAuth{cfg: cfg})
+	}
+	c := gowebdav.NewAuthClient(u.String(), auth)
+	c.SetTimeout(cfg.Timeout.Duration)
+	return &webdavClient{
+		baseURL: u.String(),
+		client:  c,
+	}, nil
+}
+
+func (c *webdavClient) stat(p string) (fs.FileInfo, error) {
+	info, err := c.client.Stat(p)
+	return info, webdavError(err)
+}
+
+func (c *webdavClient) readDir(p string) ([]fs.FileInfo, error) {
+	entries, err := c.client.ReadDir(p)
+	return entries, webdavError(err)
+}
+
+func (c *webdavClient) readFile(p string) ([]byte, error) {
+	data, err := c.client.Read(p)
+	return data, webdavError(err)
+}
+
+func (c *webdavClient) writeFile(p string, data []byte) error {
+	return webdavError(c.client.Write(p, data, 0644))
+}
+
+func (c *webdavClient) mkdir(p string) error {
+	err := c.client.Mkdir(p, 0755)
+	if gowebdav.IsErrCode(err, http.StatusMethodNotAllowed) {
+		// MKCOL is not allowed for existing resources
+		return fs.ErrExist
+	}
+	return webdavError(err)
+}
+
+func (c *webdavClient) remove(p string, isDir bool) error {
+	if isDir {
+		// collections are addressed with a trailing slash, as some servers require it
+		p = gowebdav.FixSlash(p)
+	}
+	return webdavError(c.client.Remove(p))
+}
+
+func (c *webdavClient) rename(oldPath, newPath string) error {
+	return webdavError(c.client.Rename(oldPath, newPath, true))
+}
+
+// url returns the URL of the given path, which identifies the filesystem in messages.
+func (c *webdavClient) url(p string) string {
+	return gowebdav.FixSlash(c.baseURL + path.Join("/", p))
+}
+
+// webdavError converts errors which contain an unexpected response status into a webdavStatusError,
+// so that they can be matched against the errors of the fs package.
+func webdavError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var statusErr gowebdav.StatusError
+	if errors.As(err, &statusErr) {
+		return &webdavStatusError{err: err, status: statusErr.Status}
+	}
+	return err
+}
+
+var _ gowebdav.Authenticator = &webdavBasicAuth{}
+
+// webdavBasicAuth authenticates all requests via basic authentication.
+// The password file is read for every request, so that rotated passwords are picked up without a restart.
+type webdavBasicAuth struct {
+	cfg *config.WebDAVConfiguration
+}
+
+func (a *webdavBasicAuth) Authorize(_ *http.Client, rq *http.Request, _ string) error {
+	password, err := readPassword(a.cfg.Password, a.cfg.PasswordFile)
+	if err != nil {
+		return err
+	}
+	rq.SetBasicAuth(a.cfg.Username, password)
+	return nil
+}
+
+func (a *webdavBasicAuth) Verify(_ *http.Client, rs *http.Response, p string) (bool, error) {
+	if rs.StatusCode == http.StatusUnauthorized {
+		return false, gowebdav.NewPathError("Authorize", p, rs.StatusCode)
+	}
+	return false, nil
+}
+
+func (a *webdavBasicAuth) Close() error {
+	return nil
+}
+
+func (a *webdavBasicAuth) Clone() gowebdav.Authenticator {
+	return a
+}
+
+func (a *webdavBasicAuth) String() string {
+	return fmt.Sprintf("BasicAuth login: %s", a.cfg.Username)
+}
@@ -5,11 +5,15 @@
 package transformers
 
 import (
+	"encoding/base64"
 	"fmt"
+	"unicode/utf8"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
+	"github.com/gardener/k8syncer/pkg/config"
 	"github.com/gardener/k8syncer/pkg/persist"
+	"github.com/gardener/k8syncer/pkg/utils"
 )
 
 var _ persist.Transformer = &Basic{}
@@ -19,6 +23,33 @@ var _ persist.Transformer = &Basic{}
 // It serializes to YAML.
 type Basic struct {
 	MetadataCopyFields []string
+	// InjectedAnnotations are added to the annotations of the transformed resource.
+	InjectedAnnotations map[string]string
+	// KeepAnnotations retains the annotations of the resource, except for the state annotations written by K8Syncer.
+	KeepAnnotations bool
+	// KeepFinalizers retains the finalizers of the resource.
+	KeepFinalizers bool
+	// KeepStatus retains the status of the resource.
+	KeepStatus bool
+	// KeepManagedFields retains the managed fields of the resource.
+	KeepManagedFields bool
+	// SecretData specifies how the data of Secrets is transformed.
+	// If empty, it is kept as it is.
+	SecretData config.SecretDataMode
+	// MetadataOnly reduces the resource to its apiVersion, kind, and a summary of its metadata, see MetadataSummaryFields.
+	// The MetadataCopyFields and the Keep* options are ignored in this case, the InjectedAnnotations are added nevertheless.
+	MetadataOnly bool
+}
+
+// MetadataSummaryFields are the metadata fields which are retained if the transformer is configured to persist only the metadata.
+var MetadataSummaryFields = []string{
+	"name",
+	"namespace",
+	"labels",
+	"uid",
+	"generation",
+	"creationTimestamp",
+	"deletionTimestamp",
 }
 
 // NewBasic constructs a new basic transformer.
@@ -41,6 +72,9 @@ func NewBasic(metadataFields ...string) *Basic {
 }
 
 func (b *Basic) Transform(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if b.MetadataOnly {
+		return b.transformMetadataOnly(obj)
+	}
 	res := obj.DeepCopy()
 	oldMeta, found, err := unstructured.NestedMap(obj.UnstructuredContent(), "metadata")
 	if err != nil {
@@ -55,11 +89,139 @@ func (b *Basic) Transform(obj *unstructured.Unstructured) (*unstructured.Unstruc
 			newMeta[field] = oldMeta[field]
 		}
 	}
+	if b.KeepFinalizers && oldMeta["finalizers"] != nil {
+		newMeta["finalizers"] = oldMeta["finalizers"]
+	}
+	if b.KeepManagedFields && oldMeta["managedFields"] != nil {
+		newMeta["managedFields"] = oldMeta["managedFields"]
+	}
+	if b.KeepAnnotations {
+		if oldAnn, ok := oldMeta["annotations"].(map[string]interface{}); ok {
+			ann := map[string]interface{}{}
+			for k, v := range oldAnn {
+				if !utils.IsStateAnnotation(k) {
+					ann[k] = v
+				}
+			}
+			if len(ann) > 0 {
+				newMeta["annotations"] = ann
+			}
+		}
+	}
+	b.injectAnnotations(newMeta)
 	err = unstructured.SetNestedMap(res.Object, newMeta, "metadata")
 	if err != nil {
 		return nil, fmt.Errorf("error setting new metadata: %w", err)
 	}
-	delete(res.Object, "status")
+	if !b.KeepStatus {
+		delete(res.Object, "status")
+	}
+	if isSecret(res) {
+		if err := transformSecretData(res, b.SecretData); err != nil {
+			return nil, err
+		}
+	}
 
 	return res, nil
 }
+
+// transformMetadataOnly returns a new resource which contains only the apiVersion, kind, and the MetadataSummaryFields of the given one.
+func (b *Basic) transformMetadataOnly(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	oldMeta, found, err := unstructured.NestedMap(obj.UnstructuredContent(), "metadata")
+	if err != nil {
+		return nil, fmt.Errorf("object metadata is not a map: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("object does not have metadata")
+	}
+	newMeta := map[string]interface{}{}
+	for _, field := range MetadataSummaryFields {
+		if oldMeta[field] != nil {
+			newMeta[field] = oldMeta[field]
+		}
+	}
+	b.injectAnnotations(newMeta)
+	res := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	res.SetAPIVersion(obj.GetAPIVersion())
+	res.SetKind(obj.GetKind())
+	res.Object["metadata"] = newMeta
+	return res, nil
+}
+
+// injectAnnotations adds the InjectedAnnotations to the given metadata map.
+func (b *Basic) injectAnnotations(meta map[string]interface{}) {
+	if len(b.InjectedAnnotations) == 0 {
+		return
+	}
+	ann, ok := meta["annotations"].(map[string]interface{})
+	if !ok {
+		ann = map[string]interface{}{}
+	}
+	for k, v := range b.InjectedAnnotations {
+		ann[k] = v
+	}
+	meta["annotations"] = ann
+}
+
+// isSecret returns true if the given resource is a Secret of the core group.
+func isSecret(obj *unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	return gvk.Group == "" && gvk.Kind == "Secret"
+}
+
+// transformSecretData modifies the 'data' and 'stringData' fields of the given Secret according to the given mode.
+func transformSecretData(secret *unstructured.Unstructured, mode config.SecretDataMode) error {
+	switch mode {
+	case "", config.SECRET_DATA_MODE_DATA:
+		return nil
+	case config.SECRET_DATA_MODE_STRIP:
+		delete(secret.Object, "data")
+		delete(secret.Object, "stringData")
+		return nil
+	case config.SECRET_DATA_MODE_STRING_DATA:
+		data, found, err := unstructured.NestedMap(secret.Object, "data")
+		if err != nil {
+			return fmt.Errorf("secret data is not a map: %w", err)
+		}
+		if !found {
+			return nil
+		}
+		stringData, _, err := unstructured.NestedMap(secret.Object, "stringData")
+		if err != nil {
+			return fmt.Errorf("secret stringData is not a map: %w", err)
+		}
+		if stringData == nil {
+			stringData = map[string]interface{}{}
+		}
+		for k, v := range data {
+			encoded, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("value of key '%s' in secret data is not a string", k)
+			}
+			decoded, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return fmt.Errorf("value of key '%s' in secret data is not base64-encoded: %w", k, err)
+			}
+			// binary values stay encoded, as they cannot be represented as string
+			if !utf8.Valid(decoded) {
+				continue
+			}
+			// stringData takes precedence over data when a Secret is applied, so it must not be overwritten
+			if _, exists := stringData[k]; !exists {
+				stringData[k] = string(decoded)
+			}
+			delete(data, k)
+		}
+		if len(data) > 0 {
+			secret.Object["data"] = data
+		} else {
+			delete(secret.Object, "data")
+		}
+		if len(stringData) > 0 {
+			secret.Object["stringData"] = stringData
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown secret data mode '%s'", string(mode))
+	}
+}
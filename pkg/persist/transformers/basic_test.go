@@ -5,12 +5,17 @@
 package transformers
 
 import (
+	"encoding/base64"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
 )
 
 var _ = Describe("Basic Transformer", func() {
@@ -105,6 +110,140 @@ var _ = Describe("Basic Transformer", func() {
 			Expect(transformedSpec).To(Equal(defaultSpec))
 		})
 
+		It("should add the injected annotations", func() {
+			original := &unstructured.Unstructured{
+				Object: map[string]interface{}{},
+			}
+			Expect(unstructured.SetNestedMap(original.Object, originalMetadata, "metadata")).To(Succeed())
+			basic.InjectedAnnotations = map[string]string{
+				"foo.bar.baz/cluster": "foo",
+			}
+
+			transformed, err := basic.Transform(original)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(transformed.GetAnnotations()).To(Equal(basic.InjectedAnnotations))
+		})
+
+		It("should keep the optional fields if configured", func() {
+			original := &unstructured.Unstructured{
+				Object: map[string]interface{}{},
+			}
+			Expect(unstructured.SetNestedMap(original.Object, originalMetadata, "metadata")).To(Succeed())
+			Expect(unstructured.SetNestedMap(original.Object, defaultSpec, "status")).To(Succeed())
+			original.SetFinalizers([]string{"foo.bar.baz/finalizer"})
+			original.SetManagedFields([]metav1.ManagedFieldsEntry{
+				{
+					Manager:   "foo",
+					Operation: metav1.ManagedFieldsOperationApply,
+				},
+			})
+			ann := original.GetAnnotations()
+			ann[constants.ANNOTATION_PHASE] = "Finished"
+			ann["instance-a.state.k8syncer.gardener.cloud/phase"] = "Finished"
+			original.SetAnnotations(ann)
+
+			transformed, err := basic.Transform(original)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(transformed.GetAnnotations()).To(BeEmpty())
+			Expect(transformed.GetFinalizers()).To(BeEmpty())
+			Expect(transformed.GetManagedFields()).To(BeEmpty())
+			Expect(transformed.Object).ToNot(HaveKey("status"))
+
+			basic.KeepAnnotations = true
+			basic.KeepFinalizers = true
+			basic.KeepStatus = true
+			basic.KeepManagedFields = true
+			basic.InjectedAnnotations = map[string]string{
+				"foo.bar.baz/cluster": "foo",
+			}
+			transformed, err = basic.Transform(original)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(transformed.GetAnnotations()).To(Equal(map[string]string{
+				"foo.bar.baz":         "foobar",
+				"foo.bar.baz/cluster": "foo",
+			}))
+			Expect(transformed.GetFinalizers()).To(Equal(original.GetFinalizers()))
+			Expect(transformed.GetManagedFields()).To(Equal(original.GetManagedFields()))
+			Expect(transformed.Object).To(HaveKeyWithValue("status", defaultSpec))
+		})
+
+		It("should transform the data of secrets according to the secret data mode", func() {
+			original := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "Secret",
+					"data": map[string]interface{}{
+						"text":   base64.StdEncoding.EncodeToString([]byte("foo")),
+						"binary": base64.StdEncoding.EncodeToString([]byte{0xff, 0xfe}),
+					},
+				},
+			}
+			Expect(unstructured.SetNestedMap(original.Object, originalMetadata, "metadata")).To(Succeed())
+
+			transformed, err := basic.Transform(original)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(transformed.Object).To(HaveKeyWithValue("data", original.Object["data"]))
+			Expect(transformed.Object).ToNot(HaveKey("stringData"))
+
+			basic.SecretData = config.SECRET_DATA_MODE_STRING_DATA
+			transformed, err = basic.Transform(original)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(transformed.Object).To(HaveKeyWithValue("data", map[string]interface{}{
+				"binary": base64.StdEncoding.EncodeToString([]byte{0xff, 0xfe}),
+			}))
+			Expect(transformed.Object).To(HaveKeyWithValue("stringData", map[string]interface{}{
+				"text": "foo",
+			}))
+
+			basic.SecretData = config.SECRET_DATA_MODE_STRIP
+			transformed, err = basic.Transform(original)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(transformed.Object).ToNot(HaveKey("data"))
+			Expect(transformed.Object).ToNot(HaveKey("stringData"))
+
+			// other resources are not affected
+			original.SetKind("ConfigMap")
+			transformed, err = basic.Transform(original)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(transformed.Object).To(HaveKeyWithValue("data", original.Object["data"]))
+		})
+
+		It("should only keep the metadata summary if configured", func() {
+			original := &unstructured.Unstructured{
+				Object: map[string]interface{}{},
+			}
+			Expect(unstructured.SetNestedMap(original.Object, originalMetadata, "metadata")).To(Succeed())
+			Expect(unstructured.SetNestedMap(original.Object, defaultSpec, "spec")).To(Succeed())
+			Expect(unstructured.SetNestedMap(original.Object, defaultSpec, "status")).To(Succeed())
+			original.SetAPIVersion("apps/v1")
+			original.SetKind("Deployment")
+			original.SetFinalizers([]string{"foo.bar.baz/finalizer"})
+			basic.MetadataOnly = true
+			basic.KeepStatus = true
+			basic.KeepFinalizers = true
+			basic.InjectedAnnotations = map[string]string{
+				"foo.bar.baz/cluster": "foo",
+			}
+
+			transformed, err := basic.Transform(original)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(transformed.Object).To(HaveLen(3))
+			Expect(transformed.GetAPIVersion()).To(Equal("apps/v1"))
+			Expect(transformed.GetKind()).To(Equal("Deployment"))
+
+			transformedMetadata, found, err := unstructured.NestedMap(transformed.UnstructuredContent(), "metadata")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			// the resource has no deletionTimestamp, but the injected annotations
+			Expect(transformedMetadata).To(HaveLen(len(MetadataSummaryFields)))
+			Expect(transformedMetadata).ToNot(HaveKey("deletionTimestamp"))
+			for _, field := range []string{"name", "namespace", "labels", "uid", "generation", "creationTimestamp"} {
+				Expect(transformedMetadata).To(HaveKeyWithValue(field, originalMetadata[field]))
+			}
+			Expect(transformed.GetAnnotations()).To(Equal(basic.InjectedAnnotations))
+			Expect(original.Object).To(HaveKey("spec"), "original object should not have changed")
+		})
+
 	})
 
 })
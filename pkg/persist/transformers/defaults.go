@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package transformers
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/persist"
+)
+
+var _ persist.Transformer = &DefaultsPruner{}
+
+// schemaRefPrefix is the prefix of references to other schemas in an OpenAPI v3 document.
+const schemaRefPrefix = "#/components/schemas/"
+
+// DefaultsPruner is a transformer which wraps another transformer.
+// It removes all fields from the transformed resources which are set to the default value of their OpenAPI v3 schema,
+// so that the persisted resources are close to what a user would have written.
+// Fields with a null value are removed too, as well as objects which are empty only because all of their fields have been removed.
+// Empty objects and lists which are empty in the resource itself are kept, as they might be meaningful, e.g. 'emptyDir: {}'.
+// apiVersion, kind, and metadata are never pruned.
+type DefaultsPruner struct {
+	persist.Transformer
+	// GVK is the kind the schema belongs to. Resources of other kinds are only pruned of null values.
+	GVK schema.GroupVersionKind
+	// Schema is the OpenAPI v3 schema of the kind, e.g. the openAPIV3Schema of a CRD version.
+	// If nil, only null values are pruned.
+	Schema map[string]interface{}
+	// Definitions contains the schemas which are referenced via '$ref' in Schema, by name.
+	// These are the 'components.schemas' of an OpenAPI v3 document.
+	Definitions map[string]interface{}
+}
+
+// NewDefaultsPruner constructs a new DefaultsPruner which prunes the results of the given transformer.
+func NewDefaultsPruner(t persist.Transformer, gvk schema.GroupVersionKind, schema, definitions map[string]interface{}) *DefaultsPruner {
+	return &DefaultsPruner{
+		Transformer: t,
+		GVK:         gvk,
+		Schema:      schema,
+		Definitions: definitions,
+	}
+}
+
+func (dp *DefaultsPruner) Transform(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	res, err := dp.Transformer.Transform(obj)
+	if err != nil {
+		return nil, err
+	}
+	res = res.DeepCopy()
+	var properties map[string]interface{}
+	if res.GroupVersionKind() == dp.GVK {
+		properties, _ = dp.resolve(dp.Schema)["properties"].(map[string]interface{})
+	}
+	for k, v := range res.Object {
+		if k == "apiVersion" || k == "kind" || k == "metadata" {
+			continue
+		}
+		var fieldSchema map[string]interface{}
+		if properties != nil {
+			fieldSchema, _ = properties[k].(map[string]interface{})
+		}
+		pruned, keep := dp.prune(v, fieldSchema)
+		if !keep {
+			delete(res.Object, k)
+			continue
+		}
+		res.Object[k] = pruned
+	}
+	return res, nil
+}
+
+// prune returns the given value without its defaulted fields, according to the given schema.
+// The second return value is false if the value itself should be removed.
+// A nil schema means that the schema of the value is unknown.
+func (dp *DefaultsPruner) prune(value interface{}, s map[string]interface{}) (interface{}, bool) {
+	if value == nil {
+		return nil, false
+	}
+	s = dp.resolve(s)
+	if def, ok := s["default"]; ok && equalJSON(value, def) {
+		return nil, false
+	}
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if len(typed) == 0 {
+			return typed, true
+		}
+		properties, _ := s["properties"].(map[string]interface{})
+		additionalProperties, _ := s["additionalProperties"].(map[string]interface{})
+		res := make(map[string]interface{}, len(typed))
+		for k, v := range typed {
+			fieldSchema, ok := properties[k].(map[string]interface{})
+			if !ok {
+				fieldSchema = additionalProperties
+			}
+			if pruned, keep := dp.prune(v, fieldSchema); keep {
+				res[k] = pruned
+			}
+		}
+		return res, len(res) > 0
+	case []interface{}:
+		items, _ := s["items"].(map[string]interface{})
+		res := make([]interface{}, 0, len(typed))
+		for _, v := range typed {
+			pruned, keep := dp.prune(v, items)
+			if !keep {
+				// removing list elements would change the meaning of the list
+				pruned = v
+				if m, ok := v.(map[string]interface{}); ok && len(m) > 0 {
+					pruned = map[string]interface{}{}
+				}
+			}
+			res = append(res, pruned)
+		}
+		return res, true
+	}
+	return value, true
+}
+
+// resolve follows references to other schemas.
+// Kubernetes uses 'allOf' with a single reference to add a default to a referenced schema, this is resolved too.
+// The default of the referencing schema takes precedence over the one of the referenced schema.
+func (dp *DefaultsPruner) resolve(s map[string]interface{}) map[string]interface{} {
+	for i := 0; s != nil && i < 100; i++ {
+		var next map[string]interface{}
+		if ref, ok := s["$ref"].(string); ok {
+			next, _ = dp.Definitions[strings.TrimPrefix(ref, schemaRefPrefix)].(map[string]interface{})
+		} else if allOf, ok := s["allOf"].([]interface{}); ok && len(allOf) == 1 && s["properties"] == nil {
+			next, _ = allOf[0].(map[string]interface{})
+		} else {
+			return s
+		}
+		if def, ok := s["default"]; ok && next != nil {
+			merged := make(map[string]interface{}, len(next)+1)
+			for k, v := range next {
+				merged[k] = v
+			}
+			merged["default"] = def
+			next = merged
+		}
+		s = next
+	}
+	// reference loops and unknown references are treated as unknown schema
+	return nil
+}
+
+// equalJSON returns whether the given values have the same JSON representation.
+// This is required because the numbers of the resources and the ones of the schema can have different types.
+func equalJSON(a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	aData, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bData, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(aData) == string(bData)
+}
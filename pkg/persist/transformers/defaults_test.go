@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package transformers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var _ = Describe("DefaultsPruner", func() {
+
+	gvk := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+
+	// schemas as they are parsed from JSON, numbers are float64
+	definitions := map[string]interface{}{
+		"io.k8s.api.core.v1.ContainerPort": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"containerPort": map[string]interface{}{"type": "integer"},
+				"protocol":      map[string]interface{}{"type": "string", "default": "TCP"},
+			},
+		},
+		"io.k8s.api.apps.v1.DeploymentSpec": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"replicas":             map[string]interface{}{"type": "integer", "default": float64(1)},
+				"revisionHistoryLimit": map[string]interface{}{"type": "integer"},
+				"strategy": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"type": map[string]interface{}{"type": "string", "default": "RollingUpdate"},
+					},
+				},
+				"ports": map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"allOf": []interface{}{
+							map[string]interface{}{"$ref": "#/components/schemas/io.k8s.api.core.v1.ContainerPort"},
+						},
+					},
+				},
+				"volume": map[string]interface{}{
+					"type": "object",
+				},
+			},
+		},
+	}
+	deploymentSchema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"allOf": []interface{}{
+					map[string]interface{}{"$ref": "#/components/schemas/io.k8s.api.apps.v1.DeploymentSpec"},
+				},
+			},
+		},
+	}
+
+	newDeployment := func() *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name": "foo",
+			},
+			"spec": map[string]interface{}{
+				"replicas":             int64(1),
+				"revisionHistoryLimit": int64(10),
+				"strategy": map[string]interface{}{
+					"type": "RollingUpdate",
+				},
+				"ports": []interface{}{
+					map[string]interface{}{"containerPort": int64(80), "protocol": "TCP"},
+					map[string]interface{}{"containerPort": int64(53), "protocol": "UDP"},
+				},
+				"volume": map[string]interface{}{
+					"emptyDir": map[string]interface{}{},
+				},
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"creationTimestamp": nil,
+					},
+				},
+			},
+		}}
+		obj.SetGroupVersionKind(gvk)
+		return obj
+	}
+
+	It("should remove defaulted, null, and emptied fields", func() {
+		pruner := NewDefaultsPruner(NewBasic(), gvk, deploymentSchema, definitions)
+		original := newDeployment()
+
+		transformed, err := pruner.Transform(original)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(transformed.GetName()).To(Equal("foo"))
+		Expect(transformed.Object).To(HaveKeyWithValue("spec", map[string]interface{}{
+			"revisionHistoryLimit": int64(10),
+			"ports": []interface{}{
+				map[string]interface{}{"containerPort": int64(80)},
+				map[string]interface{}{"containerPort": int64(53), "protocol": "UDP"},
+			},
+			"volume": map[string]interface{}{
+				"emptyDir": map[string]interface{}{},
+			},
+		}))
+		Expect(original.Object).To(Equal(newDeployment().Object), "original object should not have changed")
+	})
+
+	It("should only remove null values if the schema doesn't belong to the kind", func() {
+		pruner := NewDefaultsPruner(NewBasic(), gvk, deploymentSchema, definitions)
+		original := newDeployment()
+		original.SetKind("StatefulSet")
+
+		transformed, err := pruner.Transform(original)
+		Expect(err).ToNot(HaveOccurred())
+		spec, found, err := unstructured.NestedMap(transformed.Object, "spec")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(spec).To(HaveKeyWithValue("replicas", int64(1)))
+		Expect(spec).To(HaveKeyWithValue("strategy", map[string]interface{}{"type": "RollingUpdate"}))
+		Expect(spec).ToNot(HaveKey("template"))
+	})
+
+})
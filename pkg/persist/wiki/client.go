@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package wiki
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils/providerapi"
+)
+
+// pageClient reads and writes the pages which contain the persisted resources.
+// Depending on the provider and mode, a page is a wiki page or a snippet.
+type pageClient interface {
+	// getPage returns the content of the page with the given title.
+	// If the page doesn't exist, it returns (nil, nil).
+	getPage(ctx context.Context, title string) ([]byte, error)
+	// putPage creates the page with the given title or updates its content.
+	// exists is the result of the preceding getPage call.
+	putPage(ctx context.Context, title string, content []byte, exists bool) error
+	// deletePage deletes the page with the given title.
+	// It does not return an error if the page doesn't exist.
+	deletePage(ctx context.Context, title string) error
+}
+
+// newPageClient returns the page client for the given provider and mode.
+// The configuration is expected to be completed and validated.
+func newPageClient(cfg *config.WikiConfiguration) (pageClient, error) {
+	switch cfg.Provider {
+	case config.WIKI_PROVIDER_GITEA:
+		if cfg.Mode == config.WIKI_MODE_SNIPPETS {
+			return nil, fmt.Errorf("mode '%s' is not supported by provider '%s'", string(cfg.Mode), string(cfg.Provider))
+		}
+		api := providerapi.NewClient(cfg.URL, cfg.Token, cfg.TokenFile, providerapi.GiteaAuth)
+		return &giteaWiki{api: api, repoPath: cfg.Project}, nil
+	case config.WIKI_PROVIDER_GITLAB:
+		api := providerapi.NewClient(cfg.URL, cfg.Token, cfg.TokenFile, providerapi.GitLabAuth)
+		if cfg.Mode == config.WIKI_MODE_SNIPPETS {
+			return &gitlabSnippets{api: api, project: cfg.Project, visibility: cfg.SnippetVisibility}, nil
+		}
+		return &gitlabWiki{api: api, project: cfg.Project}, nil
+	default:
+		return nil, fmt.Errorf("unknown wiki provider '%s'", string(cfg.Provider))
+	}
+}
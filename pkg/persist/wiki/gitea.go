@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package wiki
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gardener/k8syncer/pkg/utils/providerapi"
+)
+
+var _ pageClient = &giteaWiki{}
+
+// giteaWiki stores pages in the wiki of a Gitea repository.
+type giteaWiki struct {
+	api *providerapi.Client
+	// repoPath is '<owner>/<repository>'.
+	repoPath string
+}
+
+type giteaWikiPage struct {
+	Title         string `json:"title"`
+	ContentBase64 string `json:"content_base64"`
+	Message       string `json:"message,omitempty"`
+}
+
+func (g *giteaWiki) pagePath(title string) string {
+	return fmt.Sprintf("/api/v1/repos/%s/wiki/page/%s", g.repoPath, url.PathEscape(title))
+}
+
+func (g *giteaWiki) getPage(ctx context.Context, title string) ([]byte, error) {
+	page := &giteaWikiPage{}
+	if _, err := g.api.DoJSON(ctx, http.MethodGet, g.pagePath(title), nil, page); err != nil {
+		if providerapi.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading wiki page '%s': %w", title, err)
+	}
+	content, err := base64.StdEncoding.DecodeString(page.ContentBase64)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding content of wiki page '%s': %w", title, err)
+	}
+	return content, nil
+}
+
+func (g *giteaWiki) putPage(ctx context.Context, title string, content []byte, exists bool) error {
+	page := &giteaWikiPage{
+		Title:         title,
+		ContentBase64: base64.StdEncoding.EncodeToString(content),
+		Message:       fmt.Sprintf("update %s", title),
+	}
+	method, path := http.MethodPatch, g.pagePath(title)
+	if !exists {
+		method, path = http.MethodPost, fmt.Sprintf("/api/v1/repos/%s/wiki/new", g.repoPath)
+	}
+	if _, _, err := g.api.Do(ctx, method, path, page); err != nil {
+		return fmt.Errorf("error writing wiki page '%s': %w", title, err)
+	}
+	return nil
+}
+
+func (g *giteaWiki) deletePage(ctx context.Context, title string) error {
+	if _, _, err := g.api.Do(ctx, http.MethodDelete, g.pagePath(title), nil); err != nil && !providerapi.IsNotFound(err) {
+		return fmt.Errorf("error deleting wiki page '%s': %w", title, err)
+	}
+	return nil
+}
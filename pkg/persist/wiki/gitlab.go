@@ -0,0 +1,198 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package wiki
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/gardener/k8syncer/pkg/utils/providerapi"
+)
+
+var _ pageClient = &gitlabWiki{}
+var _ pageClient = &gitlabSnippets{}
+
+// gitlabWiki stores pages in the wiki of a GitLab project.
+type gitlabWiki struct {
+	api *providerapi.Client
+	// project is the ID or the full path of the project.
+	project string
+}
+
+type gitlabWikiPage struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	Format  string `json:"format,omitempty"`
+}
+
+// gitlabSlug returns the slug GitLab derives from the given title.
+func gitlabSlug(title string) string {
+	return strings.ReplaceAll(title, " ", "-")
+}
+
+func (g *gitlabWiki) wikisPath() string {
+	return fmt.Sprintf("/api/v4/projects/%s/wikis", url.PathEscape(g.project))
+}
+
+func (g *gitlabWiki) pagePath(title string) string {
+	return fmt.Sprintf("%s/%s", g.wikisPath(), url.PathEscape(gitlabSlug(title)))
+}
+
+func (g *gitlabWiki) getPage(ctx context.Context, title string) ([]byte, error) {
+	page := &gitlabWikiPage{}
+	if _, err := g.api.DoJSON(ctx, http.MethodGet, g.pagePath(title), nil, page); err != nil {
+		if providerapi.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading wiki page '%s': %w", title, err)
+	}
+	return []byte(page.Content), nil
+}
+
+func (g *gitlabWiki) putPage(ctx context.Context, title string, content []byte, exists bool) error {
+	page := &gitlabWikiPage{
+		Title:   title,
+		Content: string(content),
+		Format:  "markdown",
+	}
+	method, path := http.MethodPut, g.pagePath(title)
+	if !exists {
+		method, path = http.MethodPost, g.wikisPath()
+	}
+	if _, _, err := g.api.Do(ctx, method, path, page); err != nil {
+		return fmt.Errorf("error writing wiki page '%s': %w", title, err)
+	}
+	return nil
+}
+
+func (g *gitlabWiki) deletePage(ctx context.Context, title string) error {
+	if _, _, err := g.api.Do(ctx, http.MethodDelete, g.pagePath(title), nil); err != nil && !providerapi.IsNotFound(err) {
+		return fmt.Errorf("error deleting wiki page '%s': %w", title, err)
+	}
+	return nil
+}
+
+// gitlabSnippets stores pages as snippets of a GitLab project.
+// As snippets are identified by their ID, the IDs of the snippets are looked up by their titles once and then kept up-to-date.
+// Snippets which are created by others while the persister is running are therefore not detected.
+type gitlabSnippets struct {
+	api *providerapi.Client
+	// project is the ID or the full path of the project.
+	project    string
+	visibility string
+
+	lock sync.Mutex
+	// ids maps snippet titles to snippet IDs, it is nil until the snippets have been listed.
+	ids map[string]int
+}
+
+type gitlabSnippet struct {
+	ID         int    `json:"id,omitempty"`
+	Title      string `json:"title,omitempty"`
+	FileName   string `json:"file_name,omitempty"`
+	Content    string `json:"content,omitempty"`
+	Visibility string `json:"visibility,omitempty"`
+}
+
+func (g *gitlabSnippets) snippetsPath() string {
+	return fmt.Sprintf("/api/v4/projects/%s/snippets", url.PathEscape(g.project))
+}
+
+// snippetID returns the ID of the snippet with the given title, or 0 if there is none.
+func (g *gitlabSnippets) snippetID(ctx context.Context, title string) (int, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.ids == nil {
+		ids := map[string]int{}
+		for page := "1"; page != ""; {
+			snippets := []*gitlabSnippet{}
+			header, err := g.api.DoJSON(ctx, http.MethodGet, fmt.Sprintf("%s?per_page=100&page=%s", g.snippetsPath(), page), nil, &snippets)
+			if err != nil {
+				return 0, fmt.Errorf("error listing snippets: %w", err)
+			}
+			for _, s := range snippets {
+				if _, ok := ids[s.Title]; !ok {
+					ids[s.Title] = s.ID
+				}
+			}
+			page = header.Get("X-Next-Page")
+		}
+		g.ids = ids
+	}
+	return g.ids[title], nil
+}
+
+// setSnippetID updates the ID of the snippet with the given title, an ID of 0 removes the snippet.
+func (g *gitlabSnippets) setSnippetID(title string, id int) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.ids == nil {
+		return
+	}
+	if id == 0 {
+		delete(g.ids, title)
+		return
+	}
+	g.ids[title] = id
+}
+
+func (g *gitlabSnippets) getPage(ctx context.Context, title string) ([]byte, error) {
+	id, err := g.snippetID(ctx, title)
+	if err != nil || id == 0 {
+		return nil, err
+	}
+	data, _, err := g.api.Do(ctx, http.MethodGet, fmt.Sprintf("%s/%d/raw", g.snippetsPath(), id), nil)
+	if err != nil {
+		if providerapi.IsNotFound(err) {
+			// the snippet has been deleted by someone else
+			g.setSnippetID(title, 0)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading snippet '%s': %w", title, err)
+	}
+	return data, nil
+}
+
+func (g *gitlabSnippets) putPage(ctx context.Context, title string, content []byte, exists bool) error {
+	id, err := g.snippetID(ctx, title)
+	if err != nil {
+		return err
+	}
+	snippet := &gitlabSnippet{
+		Title:    title,
+		FileName: path.Base(title),
+		Content:  string(content),
+	}
+	if id != 0 && exists {
+		if _, _, err := g.api.Do(ctx, http.MethodPut, fmt.Sprintf("%s/%d", g.snippetsPath(), id), snippet); err != nil {
+			return fmt.Errorf("error updating snippet '%s': %w", title, err)
+		}
+		return nil
+	}
+	snippet.Visibility = g.visibility
+	created := &gitlabSnippet{}
+	if _, err := g.api.DoJSON(ctx, http.MethodPost, g.snippetsPath(), snippet, created); err != nil {
+		return fmt.Errorf("error creating snippet '%s': %w", title, err)
+	}
+	g.setSnippetID(title, created.ID)
+	return nil
+}
+
+func (g *gitlabSnippets) deletePage(ctx context.Context, title string) error {
+	id, err := g.snippetID(ctx, title)
+	if err != nil || id == 0 {
+		return err
+	}
+	if _, _, err := g.api.Do(ctx, http.MethodDelete, fmt.Sprintf("%s/%d", g.snippetsPath(), id), nil); err != nil && !providerapi.IsNotFound(err) {
+		return fmt.Errorf("error deleting snippet '%s': %w", title, err)
+	}
+	g.setSnippetID(title, 0)
+	return nil
+}
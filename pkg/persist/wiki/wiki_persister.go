@@ -0,0 +1,195 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package wiki
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+	fspersist "github.com/gardener/k8syncer/pkg/persist/filesystem"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+var _ persist.Persister = &WikiPersister{}
+
+// WikiPersister persists resources as wiki pages or snippets, using the API of a git provider.
+// Each resource is stored in its own page, the page's title is derived from the path the resource would have in a filesystem storage.
+// The data of a page is staged in an in-memory FileSystemPersister, which takes care of the naming and serialization of the resources.
+type WikiPersister struct {
+	staging *fspersist.StagingPersister
+	client  pageClient
+	// titlePrefix is prepended to all page titles.
+	titlePrefix string
+	// fencedContent is true if the serialized resources are wrapped in a markdown code block, which is the case for wiki pages.
+	fencedContent bool
+}
+
+// New creates a new WikiPersister from the given storage definition.
+// The storage definition is expected to be completed and validated.
+func New(stDef *config.StorageDefinition) (*WikiPersister, error) {
+	if stDef.WikiConfig == nil {
+		return nil, fmt.Errorf("wiki config must not be nil")
+	}
+	staging, err := fspersist.NewStagingPersister(stDef.FileSystemConfig)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newPageClient(stDef.WikiConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &WikiPersister{
+		staging:       staging,
+		client:        client,
+		titlePrefix:   stDef.WikiConfig.TitlePrefix,
+		fencedContent: stDef.WikiConfig.Mode != config.WIKI_MODE_SNIPPETS,
+	}, nil
+}
+
+func (p *WikiPersister) Exists(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (bool, error) {
+	filepath, title, shortened := p.locate(ctx, name, namespace, gvk, subPath)
+	content, err := p.client.getPage(ctx, title)
+	if err != nil {
+		return false, err
+	}
+	if shortened && content != nil {
+		if err := fspersist.CheckNameCollision(filepath, p.fromPage(content), name, namespace); err != nil {
+			return false, err
+		}
+	}
+	return content != nil, nil
+}
+
+func (p *WikiPersister) Get(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (*unstructured.Unstructured, error) {
+	p.staging.Lock()
+	defer p.staging.Unlock()
+	filepath, title, _ := p.locate(ctx, name, namespace, gvk, subPath)
+	content, err := p.client.getPage(ctx, title)
+	if err != nil {
+		return nil, err
+	}
+	if content == nil {
+		return nil, nil
+	}
+	defer p.staging.Unstage(ctx, filepath)
+	if err := p.staging.Stage(filepath, p.fromPage(content)); err != nil {
+		return nil, err
+	}
+	return p.staging.Get(ctx, name, namespace, gvk, subPath)
+}
+
+func (p *WikiPersister) Persist(ctx context.Context, resource *unstructured.Unstructured, t persist.Transformer, name, subPath string) (*unstructured.Unstructured, bool, error) {
+	p.staging.Lock()
+	defer p.staging.Unlock()
+	filepath, title, _ := p.locate(ctx, name, resource.GetNamespace(), resource.GroupVersionKind(), subPath)
+	content, err := p.client.getPage(ctx, title)
+	if err != nil {
+		return nil, false, err
+	}
+	defer p.staging.Unstage(ctx, filepath)
+	if content != nil {
+		if err := p.staging.Stage(filepath, p.fromPage(content)); err != nil {
+			return nil, false, err
+		}
+	}
+	persisted, changed, err := p.staging.Persist(ctx, resource, t, name, subPath)
+	if err != nil || !changed {
+		return persisted, changed, err
+	}
+	data, err := p.staging.ReadStaged(filepath)
+	if err != nil {
+		return persisted, true, err
+	}
+	logging.FromContextOrDiscard(ctx).Debug("Writing page", constants.Logging.KEY_PATH, title)
+	if err := p.client.putPage(ctx, title, p.toPage(data), content != nil); err != nil {
+		return persisted, true, err
+	}
+	return persisted, true, nil
+}
+
+func (p *WikiPersister) Delete(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) error {
+	filepath, title, shortened := p.locate(ctx, name, namespace, gvk, subPath)
+	if shortened {
+		// the page of another resource with the same shortened name must not be removed
+		content, err := p.client.getPage(ctx, title)
+		if err != nil {
+			return err
+		}
+		if content != nil {
+			if err := fspersist.CheckNameCollision(filepath, p.fromPage(content), name, namespace); err != nil {
+				return err
+			}
+		}
+	}
+	logging.FromContextOrDiscard(ctx).Debug("Deleting page", constants.Logging.KEY_PATH, title)
+	return p.client.deletePage(ctx, title)
+}
+
+func (p *WikiPersister) InternalPersister() persist.Persister {
+	return nil
+}
+
+// locate returns the path of the given resource in the staging filesystem and the title of its page.
+// For wiki pages, the file extension is not part of the title.
+// The third return value is true if the name part of the title has been shortened, see FileSystemPersister.GetResourceFilepath.
+// Get and Persist don't need to check this, as the internal FileSystemPersister checks the staged page content for collisions.
+func (p *WikiPersister) locate(ctx context.Context, name, namespace string, gvk schema.GroupVersionKind, subPath string) (string, string, bool) {
+	filepath, _, shortened := p.staging.GetResourceFilepath(ctx, name, namespace, gvk, subPath, true)
+	relPath, _, _ := p.staging.GetResourceFilepath(ctx, name, namespace, gvk, subPath, false)
+	relPath = strings.TrimPrefix(relPath, "/")
+	if p.fencedContent {
+		relPath = strings.TrimSuffix(relPath, path.Ext(relPath))
+	}
+	return filepath, p.titlePrefix + relPath, shortened
+}
+
+// toPage converts the serialized resource into the content of its page.
+// For wiki pages, the data is wrapped in a markdown code block, so that it is rendered as-is.
+func (p *WikiPersister) toPage(data []byte) []byte {
+	if !p.fencedContent {
+		return data
+	}
+	fence := "```"
+	for bytes.Contains(data, []byte(fence)) {
+		fence += "`"
+	}
+	buf := &bytes.Buffer{}
+	buf.WriteString(fence + "yaml\n")
+	buf.Write(data)
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		buf.WriteString("\n")
+	}
+	buf.WriteString(fence + "\n")
+	return buf.Bytes()
+}
+
+// fromPage is the counterpart of toPage and extracts the serialized resource from the content of its page.
+// Content which is not a single code block is returned unchanged.
+func (p *WikiPersister) fromPage(content []byte) []byte {
+	if !p.fencedContent {
+		return content
+	}
+	// wikis might have normalized the line endings
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	firstLine, rest, ok := strings.Cut(text, "\n")
+	if !ok || !strings.HasPrefix(firstLine, "```") {
+		return content
+	}
+	fence := firstLine[:len(firstLine)-len(strings.TrimLeft(firstLine, "`"))]
+	rest = strings.TrimRight(rest, "\n")
+	if !strings.HasSuffix(rest, fence) {
+		return content
+	}
+	return []byte(strings.TrimSuffix(rest, fence))
+}
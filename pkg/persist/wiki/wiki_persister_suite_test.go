@@ -0,0 +1,337 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package wiki
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	fspersist "github.com/gardener/k8syncer/pkg/persist/filesystem"
+	"github.com/gardener/k8syncer/pkg/persist/transformers"
+	testutils "github.com/gardener/k8syncer/test/utils"
+)
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Wiki Persister Test Suite")
+}
+
+const testToken = "secret"
+
+var _ = Describe("Wiki Persister Tests", func() {
+
+	var (
+		dummy            *unstructured.Unstructured
+		basicTransformer = transformers.NewBasic()
+		ctx              context.Context
+		provider         *fakeProvider
+		server           *httptest.Server
+	)
+
+	BeforeEach(func() {
+		dummy = &unstructured.Unstructured{}
+		dummy.SetName("foo")
+		dummy.SetNamespace("bar")
+		dummy.SetGroupVersionKind(schema.GroupVersionKind{
+			Group:   "k8syncer.gardener.cloud",
+			Version: "v1",
+			Kind:    "Dummy",
+		})
+		Expect(unstructured.SetNestedField(dummy.Object, fmt.Sprint(time.Now().Unix()), "spec", "value")).To(Succeed())
+
+		ctx = logging.NewContext(context.Background(), logging.Discard())
+		provider = newFakeProvider()
+		server = httptest.NewServer(provider)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	newPersister := func(wikiCfg *config.WikiConfiguration) *WikiPersister {
+		cfg := &config.K8SyncerConfiguration{
+			StorageDefinitions: []*config.StorageDefinition{
+				{
+					Name:       "wiki",
+					Type:       config.STORAGE_TYPE_WIKI,
+					WikiConfig: wikiCfg,
+				},
+			},
+		}
+		Expect(cfg.Complete()).To(Succeed())
+		p, err := New(cfg.StorageDefinitions[0])
+		Expect(err).ToNot(HaveOccurred())
+		return p
+	}
+
+	It("should store resources as pages of a gitea wiki", func() {
+		p := newPersister(&config.WikiConfiguration{
+			Provider: config.WIKI_PROVIDER_GITEA,
+			URL:      server.URL,
+			Project:  "owner/repo",
+			Token:    testToken,
+		})
+
+		_, _, err := p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(provider.pages).To(HaveKey("ns_bar/dummy.v1.k8syncer.gardener.cloud_foo"))
+		content := provider.pages["ns_bar/dummy.v1.k8syncer.gardener.cloud_foo"]
+		Expect(content).To(HavePrefix("```yaml\n"))
+		Expect(content).To(HaveSuffix("\n```\n"))
+		Expect(content).To(ContainSubstring("name: foo"))
+		Expect(p.Delete(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "")).To(Succeed())
+
+		testutils.PersisterRoundTrip(ctx, p, dummy, nil)
+		Expect(provider.pages).To(BeEmpty())
+	})
+
+	It("should store resources as pages of a gitlab wiki", func() {
+		p := newPersister(&config.WikiConfiguration{
+			Provider:    config.WIKI_PROVIDER_GITLAB,
+			URL:         server.URL,
+			Project:     "group/project",
+			Token:       testToken,
+			TitlePrefix: "k8syncer/",
+		})
+
+		_, _, err := p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(provider.pages).To(HaveKey("k8syncer/ns_bar/dummy.v1.k8syncer.gardener.cloud_foo"))
+		Expect(p.Delete(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "")).To(Succeed())
+
+		testutils.PersisterRoundTrip(ctx, p, dummy, nil)
+		Expect(provider.pages).To(BeEmpty())
+	})
+
+	It("should store resources as gitlab snippets", func() {
+		wikiCfg := &config.WikiConfiguration{
+			Provider: config.WIKI_PROVIDER_GITLAB,
+			Mode:     config.WIKI_MODE_SNIPPETS,
+			URL:      server.URL,
+			Project:  "42",
+			Token:    testToken,
+		}
+		p := newPersister(wikiCfg)
+
+		_, _, err := p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(provider.snippets).To(HaveLen(1))
+		for _, s := range provider.snippets {
+			Expect(s.Title).To(Equal("ns_bar/dummy.v1.k8syncer.gardener.cloud_foo.yaml"))
+			Expect(s.FileName).To(Equal("dummy.v1.k8syncer.gardener.cloud_foo.yaml"))
+			Expect(s.Visibility).To(Equal("private"))
+			Expect(s.Content).To(HavePrefix("apiVersion: "))
+		}
+
+		By("finding existing snippets after a restart")
+		p = newPersister(wikiCfg.DeepCopy())
+		_, changed, err := p.Persist(ctx, dummy, basicTransformer, dummy.GetName(), "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeFalse())
+		Expect(p.Delete(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "")).To(Succeed())
+
+		testutils.PersisterRoundTrip(ctx, p, dummy, nil)
+		Expect(provider.snippets).To(BeEmpty())
+	})
+
+	It("should detect collisions of shortened page titles", func() {
+		p := newPersister(&config.WikiConfiguration{
+			Provider: config.WIKI_PROVIDER_GITEA,
+			URL:      server.URL,
+			Project:  "owner/repo",
+			Token:    testToken,
+		})
+		p.staging.LongNames = &config.LongNamesConfiguration{MaxLength: 100, Strategy: config.LONG_NAME_STRATEGY_TRUNCATE}
+		gvk := dummy.GroupVersionKind()
+		longName := strings.Repeat("a", 120)
+		dummy.SetName(longName)
+		_, _, err := p.Persist(ctx, dummy, basicTransformer, longName, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(provider.pages).To(HaveLen(1))
+
+		By("not treating the page of another resource with the same shortened title as existing or deleting it")
+		for title, content := range provider.pages {
+			provider.pages[title] = strings.Replace(content, longName, longName[1:]+"b", 1)
+		}
+		var collisionErr *fspersist.NameCollisionError
+		_, err = p.Exists(ctx, longName, dummy.GetNamespace(), gvk, "")
+		Expect(errors.As(err, &collisionErr)).To(BeTrue())
+		Expect(errors.As(p.Delete(ctx, longName, dummy.GetNamespace(), gvk, ""), &collisionErr)).To(BeTrue())
+		Expect(provider.pages).To(HaveLen(1))
+	})
+
+	It("should fail for unauthorized requests", func() {
+		p := newPersister(&config.WikiConfiguration{
+			Provider: config.WIKI_PROVIDER_GITEA,
+			URL:      server.URL,
+			Project:  "owner/repo",
+			Token:    "wrong",
+		})
+		_, err := p.Exists(ctx, dummy.GetName(), dummy.GetNamespace(), dummy.GroupVersionKind(), "")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("401"))
+	})
+
+})
+
+// fakeProvider implements the parts of the Gitea and GitLab APIs which are used by the page clients.
+// Wiki pages are shared between both providers.
+type fakeProvider struct {
+	lock     sync.Mutex
+	pages    map[string]string
+	snippets map[int]*gitlabSnippet
+	nextID   int
+}
+
+func newFakeProvider() *fakeProvider {
+	return &fakeProvider{
+		pages:    map[string]string{},
+		snippets: map[int]*gitlabSnippet{},
+		nextID:   1,
+	}
+}
+
+func (fp *fakeProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fp.lock.Lock()
+	defer fp.lock.Unlock()
+	if r.Header.Get("Authorization") != "token "+testToken && r.Header.Get("PRIVATE-TOKEN") != testToken {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	segments := strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/"), "/")
+	for i := range segments {
+		segments[i], _ = url.PathUnescape(segments[i])
+	}
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case len(segments) >= 6 && segments[0] == "api" && segments[1] == "v1" && segments[5] == "wiki":
+		fp.serveGitea(w, r.Method, segments[6:], body)
+	case len(segments) >= 5 && segments[0] == "api" && segments[1] == "v4" && segments[4] == "wikis":
+		fp.serveGitLabWiki(w, r.Method, segments[5:], body)
+	case len(segments) >= 5 && segments[0] == "api" && segments[1] == "v4" && segments[4] == "snippets":
+		fp.serveGitLabSnippets(w, r.Method, segments[5:], body)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (fp *fakeProvider) serveGitea(w http.ResponseWriter, method string, segments []string, body []byte) {
+	page := &giteaWikiPage{}
+	_ = json.Unmarshal(body, page)
+	content, _ := base64.StdEncoding.DecodeString(page.ContentBase64)
+	switch {
+	case method == http.MethodPost && len(segments) == 1 && segments[0] == "new":
+		fp.pages[page.Title] = string(content)
+		w.WriteHeader(http.StatusCreated)
+	case len(segments) == 2 && segments[0] == "page":
+		existing, ok := fp.pages[segments[1]]
+		if !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		switch method {
+		case http.MethodGet:
+			writeJSON(w, &giteaWikiPage{Title: segments[1], ContentBase64: base64.StdEncoding.EncodeToString([]byte(existing))})
+		case http.MethodPatch:
+			fp.pages[segments[1]] = string(content)
+		case http.MethodDelete:
+			delete(fp.pages, segments[1])
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (fp *fakeProvider) serveGitLabWiki(w http.ResponseWriter, method string, segments []string, body []byte) {
+	page := &gitlabWikiPage{}
+	_ = json.Unmarshal(body, page)
+	switch {
+	case method == http.MethodPost && len(segments) == 0:
+		fp.pages[page.Title] = page.Content
+		w.WriteHeader(http.StatusCreated)
+	case len(segments) == 1:
+		existing, ok := fp.pages[segments[0]]
+		if !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		switch method {
+		case http.MethodGet:
+			writeJSON(w, &gitlabWikiPage{Title: segments[0], Content: existing})
+		case http.MethodPut:
+			fp.pages[segments[0]] = page.Content
+		case http.MethodDelete:
+			delete(fp.pages, segments[0])
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (fp *fakeProvider) serveGitLabSnippets(w http.ResponseWriter, method string, segments []string, body []byte) {
+	snippet := &gitlabSnippet{}
+	_ = json.Unmarshal(body, snippet)
+	if len(segments) == 0 {
+		switch method {
+		case http.MethodGet:
+			res := []*gitlabSnippet{}
+			for _, s := range fp.snippets {
+				res = append(res, &gitlabSnippet{ID: s.ID, Title: s.Title, FileName: s.FileName})
+			}
+			writeJSON(w, res)
+		case http.MethodPost:
+			snippet.ID = fp.nextID
+			fp.nextID++
+			fp.snippets[snippet.ID] = snippet
+			w.WriteHeader(http.StatusCreated)
+			writeJSON(w, snippet)
+		}
+		return
+	}
+	id, _ := strconv.Atoi(segments[0])
+	existing, ok := fp.snippets[id]
+	if !ok {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	switch {
+	case method == http.MethodGet && len(segments) == 2 && segments[1] == "raw":
+		_, _ = w.Write([]byte(existing.Content))
+	case method == http.MethodPut && len(segments) == 1:
+		existing.Content = snippet.Content
+		writeJSON(w, existing)
+	case method == http.MethodDelete && len(segments) == 1:
+		delete(fp.snippets, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, obj any) {
+	data, _ := json.Marshal(obj)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,209 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pruning
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+	"github.com/gardener/k8syncer/pkg/utils"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// NamespacePruner watches namespaces and prunes the data of deleted namespaces in the storages of all sync configs.
+type NamespacePruner struct {
+	Client client.Client
+	Config *config.K8SyncerConfiguration
+	// targets contains one entry per storage reference of all sync configs.
+	targets []*pruneTarget
+	// archiveSubPathTemplate is the parsed archive subPath, it is nil if the data is deleted instead of archived
+	// or if the archive subPath does not contain any template actions.
+	archiveSubPathTemplate *template.Template
+}
+
+// pruneTarget bundles a storage reference with the persister and the sync config it belongs to.
+type pruneTarget struct {
+	syncConfig      *config.SyncConfig
+	storageRef      *config.StorageReference
+	pruner          persist.NamespacePruner
+	subPathTemplate *template.Template
+}
+
+// NewNamespacePruner creates a new NamespacePruner.
+// Storage references whose persisters don't support pruning namespaces are ignored.
+// Sync configs with their own kubeconfig or a shoot are ignored too, as their resources are not contained in the watched cluster.
+func NewNamespacePruner(c client.Client, cfg *config.K8SyncerConfiguration, persisters map[string]persist.Persister) (*NamespacePruner, error) {
+	if cfg.NamespacePruning == nil {
+		// should not happen, as the pruner is only created if pruning is configured
+		return nil, fmt.Errorf("namespace pruning is not configured")
+	}
+	np := &NamespacePruner{
+		Client: c,
+		Config: cfg,
+	}
+	if cfg.NamespacePruning.Mode == config.NAMESPACE_PRUNING_MODE_ARCHIVE {
+		tmpl, err := config.ParseSubPathTemplate(cfg.NamespacePruning.ArchiveSubPath)
+		if err != nil {
+			// should not happen, as this check is already part of the config validation
+			return nil, fmt.Errorf("invalid archive subPath template: %w", err)
+		}
+		np.archiveSubPathTemplate = tmpl
+	}
+	for _, sc := range cfg.SyncConfigs {
+		if sc.ClusterKey() != "" {
+			continue
+		}
+		for idx, ref := range sc.StorageRefs {
+			pruner, ok := persist.FindNamespacePruner(persisters[ref.Name])
+			if !ok {
+				continue
+			}
+			tmpl, err := config.ParseSubPathTemplate(ref.SubPath)
+			if err != nil {
+				// should not happen, as this check is already part of the config validation
+				return nil, fmt.Errorf("invalid subPath template in storage reference at index %d in sync configuration with id %s: %w", idx, sc.ID, err)
+			}
+			np.targets = append(np.targets, &pruneTarget{
+				syncConfig:      sc,
+				storageRef:      ref,
+				pruner:          pruner,
+				subPathTemplate: tmpl,
+			})
+		}
+	}
+	return np, nil
+}
+
+// AddNamespacePrunerToManager registers a NamespacePruner in the manager.
+// It is a no-op if namespace pruning is not configured.
+func AddNamespacePrunerToManager(baseLogger logging.Logger, mgr manager.Manager, cfg *config.K8SyncerConfiguration, persisters map[string]persist.Persister) error {
+	if cfg.NamespacePruning == nil {
+		return nil
+	}
+	log := baseLogger.WithName("namespace-pruner")
+	np, err := NewNamespacePruner(mgr.GetClient(), cfg, persisters)
+	if err != nil {
+		return err
+	}
+	storageNames := sets.New[string]()
+	for _, t := range np.targets {
+		storageNames.Insert(t.storageRef.Name)
+	}
+	log.Info(fmt.Sprintf("namespace pruning configured with mode '%s'", string(cfg.NamespacePruning.Mode)), constants.Logging.KEY_CONFIGURED_STORAGES, fmt.Sprintf("[%s]", strings.Join(sets.List(storageNames), ", ")))
+
+	// only the deletion of namespaces is relevant, so their content is neither watched nor cached
+	return builder.ControllerManagedBy(mgr).
+		For(&corev1.Namespace{}, builder.OnlyMetadata).
+		Named("namespace-pruner").
+		WithEventFilter(predicate.Funcs{
+			// only deletions are relevant, the namespace is checked again during reconciliation
+			CreateFunc:  func(event.CreateEvent) bool { return false },
+			UpdateFunc:  func(event.UpdateEvent) bool { return false },
+			DeleteFunc:  func(event.DeleteEvent) bool { return true },
+			GenericFunc: func(event.GenericEvent) bool { return false },
+		}).
+		WithLogConstructor(func(r *reconcile.Request) logr.Logger { return log.Logr() }).
+		Complete(np)
+}
+
+func (np *NamespacePruner) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := logging.FromContextOrDiscard(ctx).WithValues(constants.Logging.KEY_RESOURCE_NAMESPACE, req.Name)
+	ctx = logging.NewContext(ctx, log)
+
+	// the metadata is read from the same cache as the watch, see AddNamespacePrunerToManager
+	ns := &metav1.PartialObjectMetadata{}
+	ns.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+	err := np.Client.Get(ctx, req.NamespacedName, ns)
+	if err == nil {
+		// namespace has been recreated in the meantime
+		log.Debug("Namespace exists, nothing to prune")
+		return reconcile.Result{}, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return reconcile.Result{}, fmt.Errorf("error fetching namespace from cluster: %w", err)
+	}
+
+	log.Info("Pruning data of deleted namespace")
+	return reconcile.Result{}, np.Prune(ctx, req.Name)
+}
+
+// Prune removes the data of the given namespace from the storages of all sync configs.
+// Each combination of storage and resolved subPath is pruned only once.
+func (np *NamespacePruner) Prune(ctx context.Context, namespace string) error {
+	log := logging.FromContextOrDiscard(ctx)
+
+	archiveSubPath := ""
+	if np.Config.NamespacePruning.Mode == config.NAMESPACE_PRUNING_MODE_ARCHIVE {
+		var err error
+		archiveSubPath, err = config.RenderSubPath(np.archiveSubPathTemplate, np.Config.NamespacePruning.ArchiveSubPath, np.templateData(namespace, ""))
+		if err != nil {
+			return fmt.Errorf("error resolving archive subPath: %w", err)
+		}
+	}
+
+	errs := utils.NewErrorList()
+	done := map[string]bool{}
+	for _, t := range np.targets {
+		if t.syncConfig.Resource != nil && t.syncConfig.Resource.Namespace != "" && t.syncConfig.Resource.Namespace != namespace {
+			// resources from this namespace are not synced by this sync config
+			continue
+		}
+		curLog := log.WithValues(constants.Logging.KEY_ID, t.syncConfig.ID, constants.Logging.KEY_RESOURCE_STORAGE_ID, t.storageRef.Name)
+		kind := ""
+		if t.syncConfig.Resource != nil {
+			kind = t.syncConfig.Resource.Kind
+		}
+		subPath, err := config.RenderSubPath(t.subPathTemplate, t.storageRef.SubPath, np.templateData(namespace, kind))
+		if err != nil {
+			errMsg := "error while resolving subPath"
+			curLog.Error(err, errMsg)
+			errs.Append(fmt.Errorf("[%s] %s: %w", t.storageRef.Name, errMsg, err))
+			continue
+		}
+		// subPaths which point to the same directory are pruned only once
+		key := fmt.Sprintf("%s:%s", t.storageRef.Name, cleanSubPath(subPath))
+		if done[key] {
+			continue
+		}
+		done[key] = true
+
+		pruned, err := t.pruner.PruneNamespace(logging.NewContext(ctx, curLog), namespace, subPath, archiveSubPath)
+		if err != nil {
+			errMsg := "error while pruning namespace"
+			curLog.Error(err, errMsg)
+			errs.Append(fmt.Errorf("[%s] %s: %w", t.storageRef.Name, errMsg, err))
+			continue
+		}
+		if pruned {
+			curLog.Info("Pruned namespace data", constants.Logging.KEY_PATH, subPath)
+		}
+	}
+	return errs.Aggregate()
+}
+
+func (np *NamespacePruner) templateData(namespace, kind string) *config.SubPathTemplateData {
+	return &config.SubPathTemplateData{
+		ClusterName: np.Config.ClusterName,
+		Namespace:   namespace,
+		Kind:        kind,
+	}
+}
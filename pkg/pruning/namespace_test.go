@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pruning
+
+import (
+	"context"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+)
+
+var _ = Describe("NamespacePruner", func() {
+
+	var (
+		ctx context.Context
+		c   client.Client
+		p   *recordingPruner
+		cfg *config.K8SyncerConfiguration
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		c = fake.NewClientBuilder().Build()
+		p = newRecordingPruner()
+		cfg = &config.K8SyncerConfiguration{
+			NamespacePruning: &config.NamespacePruningConfiguration{
+				Mode: config.NAMESPACE_PRUNING_MODE_DELETE,
+			},
+		}
+	})
+
+	newPruner := func() *NamespacePruner {
+		np, err := NewNamespacePruner(c, cfg, map[string]persist.Persister{testStorage: p})
+		Expect(err).ToNot(HaveOccurred())
+		return np
+	}
+
+	It("should prune each combination of storage and subPath only once", func() {
+		p.persistDummy(ctx, "foo", "default", "data")
+		p.persistDummy(ctx, "foo", "default", "other")
+		cfg.SyncConfigs = []*config.SyncConfig{
+			syncConfig("a", "", "data", "other"),
+			syncConfig("b", "", "./data/"),
+			syncConfig("c", "default", "data"),
+		}
+
+		Expect(newPruner().Prune(ctx, "default")).To(Succeed())
+		Expect(p.namespaceCalls).To(ConsistOf("default:data:", "default:other:"))
+		Expect(p.exists(ctx, "foo", "default", "data")).To(BeFalse())
+		Expect(p.exists(ctx, "foo", "default", "other")).To(BeFalse())
+	})
+
+	It("should deduplicate subPaths which resolve to the same path", func() {
+		cfg.SyncConfigs = []*config.SyncConfig{
+			syncConfig("a", "", "tenants/{{ .Namespace }}"),
+			syncConfig("b", "default", "tenants/default"),
+		}
+
+		Expect(newPruner().Prune(ctx, "default")).To(Succeed())
+		Expect(p.namespaceCalls).To(ConsistOf("default:tenants/default:"))
+	})
+
+	It("should skip sync configs which are restricted to another namespace", func() {
+		p.persistDummy(ctx, "foo", "default", "data")
+		p.persistDummy(ctx, "foo", "default", "restricted")
+		cfg.SyncConfigs = []*config.SyncConfig{
+			syncConfig("a", "", "data"),
+			syncConfig("b", "other", "restricted"),
+		}
+
+		Expect(newPruner().Prune(ctx, "default")).To(Succeed())
+		Expect(p.namespaceCalls).To(ConsistOf("default:data:"))
+		Expect(p.exists(ctx, "foo", "default", "data")).To(BeFalse())
+		Expect(p.exists(ctx, "foo", "default", "restricted")).To(BeTrue())
+	})
+
+	It("should skip sync configs for other clusters", func() {
+		sc := syncConfig("a", "", "data")
+		sc.Kubeconfig = "/path/to/kubeconfig"
+		cfg.SyncConfigs = []*config.SyncConfig{sc}
+
+		Expect(newPruner().Prune(ctx, "default")).To(Succeed())
+		Expect(p.namespaceCalls).To(BeEmpty())
+	})
+
+	It("should render the subPath for the pruned namespace", func() {
+		p.persistDummy(ctx, "foo", "default", "tenants/default")
+		p.persistDummy(ctx, "foo", "other", "tenants/other")
+		cfg.ClusterName = "c1"
+		cfg.SyncConfigs = []*config.SyncConfig{syncConfig("a", "", "tenants/{{ .Namespace }}"), syncConfig("b", "", "{{ .ClusterName }}/{{ .Kind }}")}
+
+		Expect(newPruner().Prune(ctx, "default")).To(Succeed())
+		Expect(p.namespaceCalls).To(ConsistOf("default:tenants/default:", "default:c1/Dummy:"))
+		Expect(p.exists(ctx, "foo", "default", "tenants/default")).To(BeFalse())
+		Expect(p.exists(ctx, "foo", "other", "tenants/other")).To(BeTrue())
+	})
+
+	It("should not touch the data of other namespaces", func() {
+		p.persistDummy(ctx, "foo", "default", "data")
+		p.persistDummy(ctx, "foo", "other", "data")
+		cfg.SyncConfigs = []*config.SyncConfig{syncConfig("a", "", "data")}
+
+		Expect(newPruner().Prune(ctx, "default")).To(Succeed())
+		Expect(p.exists(ctx, "foo", "default", "data")).To(BeFalse())
+		Expect(p.exists(ctx, "foo", "other", "data")).To(BeTrue())
+	})
+
+	It("should move the data below the archive subPath in archive mode", func() {
+		p.persistDummy(ctx, "foo", "default", "data")
+		p.persistDummy(ctx, "foo", "other", "data")
+		cfg.ClusterName = "c1"
+		cfg.NamespacePruning = &config.NamespacePruningConfiguration{
+			Mode:           config.NAMESPACE_PRUNING_MODE_ARCHIVE,
+			ArchiveSubPath: "archive/{{ .ClusterName }}/{{ .Namespace }}",
+		}
+		cfg.SyncConfigs = []*config.SyncConfig{syncConfig("a", "", "data")}
+
+		Expect(newPruner().Prune(ctx, "default")).To(Succeed())
+		Expect(p.namespaceCalls).To(ConsistOf("default:data:archive/c1/default"))
+		Expect(p.exists(ctx, "foo", "default", "data")).To(BeFalse())
+		Expect(p.exists(ctx, "foo", "other", "data")).To(BeTrue())
+		archived, err := vfs.ReadDir(p.Fs, "/data/archive/c1/default/data")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(archived).To(HaveLen(1))
+		Expect(archived[0].IsDir()).To(BeTrue())
+		Expect(archived[0].Name()).To(HavePrefix(p.NamespacePrefix + "default_"))
+	})
+
+	It("should continue with the other storage references if pruning fails", func() {
+		p.persistDummy(ctx, "foo", "default", "other")
+		p.fail.Insert("data")
+		cfg.SyncConfigs = []*config.SyncConfig{syncConfig("a", "", "data", "other")}
+
+		Expect(newPruner().Prune(ctx, "default")).To(MatchError(ContainSubstring("pruning namespace 'default' below subPath 'data' failed")))
+		Expect(p.namespaceCalls).To(ConsistOf("default:data:", "default:other:"))
+		Expect(p.exists(ctx, "foo", "default", "other")).To(BeFalse())
+	})
+
+	It("should only prune namespaces which don't exist anymore", func() {
+		p.persistDummy(ctx, "foo", "default", "data")
+		cfg.SyncConfigs = []*config.SyncConfig{syncConfig("a", "", "data")}
+		np := newPruner()
+		req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "default"}}
+
+		Expect(c.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})).To(Succeed())
+		_, err := np.Reconcile(ctx, req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p.namespaceCalls).To(BeEmpty())
+		Expect(p.exists(ctx, "foo", "default", "data")).To(BeTrue())
+
+		Expect(c.Delete(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})).To(Succeed())
+		_, err = np.Reconcile(ctx, req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p.namespaceCalls).To(ConsistOf("default:data:"))
+		Expect(p.exists(ctx, "foo", "default", "data")).To(BeFalse())
+	})
+
+})
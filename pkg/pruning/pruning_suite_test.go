@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pruning
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	fspersist "github.com/gardener/k8syncer/pkg/persist/filesystem"
+	"github.com/gardener/k8syncer/pkg/persist/transformers"
+)
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Pruning Test Suite")
+}
+
+const testStorage = "myStorage"
+
+var (
+	testGVK = schema.GroupVersionKind{
+		Group:   "k8syncer.gardener.cloud",
+		Version: "v1",
+		Kind:    "Dummy",
+	}
+	basicTransformer = transformers.NewBasic()
+)
+
+// recordingPruner is an in-memory FileSystemPersister which records the calls of its pruning methods.
+// Pruning the subPaths contained in fail returns an error.
+type recordingPruner struct {
+	*fspersist.FileSystemPersister
+	subPathCalls   []string
+	namespaceCalls []string
+	fail           sets.Set[string]
+}
+
+func newRecordingPruner() *recordingPruner {
+	fsp, err := fspersist.NewForMemory(&config.FileSystemConfiguration{RootPath: "/data"})
+	Expect(err).ToNot(HaveOccurred())
+	return &recordingPruner{
+		FileSystemPersister: fsp,
+		fail:                sets.New[string](),
+	}
+}
+
+func (p *recordingPruner) PruneSubPath(ctx context.Context, subPath string) (bool, error) {
+	p.subPathCalls = append(p.subPathCalls, subPath)
+	if p.fail.Has(subPath) {
+		return false, fmt.Errorf("pruning subPath '%s' failed", subPath)
+	}
+	return p.FileSystemPersister.PruneSubPath(ctx, subPath)
+}
+
+// PruneNamespace records the calls in the format '<namespace>:<subPath>:<archiveSubPath>'.
+func (p *recordingPruner) PruneNamespace(ctx context.Context, namespace, subPath, archiveSubPath string) (bool, error) {
+	p.namespaceCalls = append(p.namespaceCalls, fmt.Sprintf("%s:%s:%s", namespace, subPath, archiveSubPath))
+	if p.fail.Has(subPath) {
+		return false, fmt.Errorf("pruning namespace '%s' below subPath '%s' failed", namespace, subPath)
+	}
+	return p.FileSystemPersister.PruneNamespace(ctx, namespace, subPath, archiveSubPath)
+}
+
+// persistDummy persists a dummy resource with the given name and namespace below the given subPath.
+func (p *recordingPruner) persistDummy(ctx context.Context, name, namespace, subPath string) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(testGVK)
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	_, _, err := p.Persist(ctx, obj, basicTransformer, name, subPath)
+	Expect(err).ToNot(HaveOccurred())
+}
+
+// exists returns whether the dummy resource with the given name and namespace exists below the given subPath.
+func (p *recordingPruner) exists(ctx context.Context, name, namespace, subPath string) bool {
+	exists, err := p.Exists(ctx, name, namespace, testGVK, subPath)
+	Expect(err).ToNot(HaveOccurred())
+	return exists
+}
+
+// syncConfig returns a sync config for the dummy resources in the given namespace with storage references to the given subPaths of the test storage.
+func syncConfig(id, namespace string, subPaths ...string) *config.SyncConfig {
+	sc := &config.SyncConfig{
+		ID: id,
+		Resource: &config.ResourceSyncConfig{
+			Namespace: namespace,
+			Group:     testGVK.Group,
+			Version:   testGVK.Version,
+			Kind:      testGVK.Kind,
+		},
+	}
+	for _, subPath := range subPaths {
+		sc.StorageRefs = append(sc.StorageRefs, &config.StorageReference{Name: testStorage, SubPath: subPath})
+	}
+	return sc
+}
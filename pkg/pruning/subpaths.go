@@ -0,0 +1,327 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pruning
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+	"github.com/gardener/k8syncer/pkg/utils"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+const (
+	// managedSubPathsKey is the key in the ConfigMap under which the recorded subPaths are stored.
+	managedSubPathsKey = "subPaths"
+
+	// probeNamespace is used to find out whether a subPath depends on the namespace of the resources.
+	probeNamespace = "k8syncer-subpath-pruning"
+)
+
+// SubPathPruner removes the data below the subPaths of storage references which have been removed from the configuration.
+// The subPaths of all storage references are recorded in a ConfigMap, so that removed storage references can be detected after a restart.
+type SubPathPruner struct {
+	Client client.Client
+	Config *config.K8SyncerConfiguration
+	key    types.NamespacedName
+	// pruners contains the persisters which support pruning subPaths, mapped by the name of their storage definition.
+	pruners map[string]persist.SubPathPruner
+}
+
+// subPaths contains the subPaths of the storage references of a storage.
+type subPaths struct {
+	// recorded are the subPaths which are recorded and pruned once they are not referenced anymore.
+	recorded sets.Set[string]
+	// protected are the subPaths whose data must not be pruned, including everything below and above them.
+	// Besides the recorded subPaths, it contains the static prefixes of subPaths which depend on the namespace of the resources,
+	// and the subPaths of snapshots and archived namespaces.
+	protected sets.Set[string]
+}
+
+// NewSubPathPruner creates a new SubPathPruner.
+// Storages whose persisters don't support pruning subPaths are ignored.
+func NewSubPathPruner(c client.Client, cfg *config.K8SyncerConfiguration, persisters map[string]persist.Persister) (*SubPathPruner, error) {
+	if cfg.SubPathPruning == nil {
+		// should not happen, as the pruner is only created if pruning is configured
+		return nil, fmt.Errorf("subPath pruning is not configured")
+	}
+	spp := &SubPathPruner{
+		Client: c,
+		Config: cfg,
+		key: types.NamespacedName{
+			Namespace: cfg.SubPathPruning.ConfigMapNamespace,
+			Name:      cfg.SubPathPruning.ConfigMapName,
+		},
+		pruners: map[string]persist.SubPathPruner{},
+	}
+	for name, p := range persisters {
+		if pruner, ok := persist.FindSubPathPruner(p); ok {
+			spp.pruners[name] = pruner
+		}
+	}
+	return spp, nil
+}
+
+// Run prunes the data below all recorded subPaths which are not referenced by any storage reference anymore and records the current subPaths afterwards.
+// Recorded subPaths which overlap with a current one are not pruned. If pruning a subPath fails, it stays recorded, so that the pruning is retried on the next start.
+// If no subPaths have been recorded yet, the current subPaths are recorded without pruning anything.
+func (spp *SubPathPruner) Run(ctx context.Context) error {
+	log := logging.FromContextOrDiscard(ctx).WithName("subpath-pruner")
+
+	current, err := spp.currentSubPaths()
+	if err != nil {
+		return err
+	}
+	recorded, err := spp.load(ctx)
+	if err != nil {
+		return err
+	}
+	if recorded == nil {
+		log.Info("No subPaths recorded yet, nothing to prune", constants.Logging.KEY_CONFIGMAP, spp.key.String())
+	}
+
+	errs := utils.NewErrorList()
+	keep := map[string]sets.Set[string]{}
+	for _, storage := range sortedKeys(recorded) {
+		pruner, ok := spp.pruners[storage]
+		for _, subPath := range sets.List(recorded[storage]) {
+			if cur, exists := current[storage]; exists && overlapsAny(subPath, cur.protected) {
+				continue
+			}
+			curLog := log.WithValues(constants.Logging.KEY_RESOURCE_STORAGE_ID, storage, constants.Logging.KEY_PATH, subPath)
+			if !ok {
+				// the storage definition has been removed too, so there is no way to prune its data
+				curLog.Info("Storage of removed storage reference is not configured anymore, its data is not pruned")
+				continue
+			}
+			if subPath == "" {
+				curLog.Info("Removed storage reference pointed to the root of the storage, its data is not pruned")
+				continue
+			}
+			if spp.Config.SubPathPruning.DryRun {
+				curLog.Info("Dry run, not pruning data of removed storage reference")
+				addSubPath(keep, storage, subPath)
+				continue
+			}
+			pruned, err := pruner.PruneSubPath(logging.NewContext(ctx, curLog), subPath)
+			if err != nil {
+				errMsg := "error while pruning data of removed storage reference"
+				curLog.Error(err, errMsg)
+				errs.Append(fmt.Errorf("[%s] %s: %w", storage, errMsg, err))
+				addSubPath(keep, storage, subPath)
+				continue
+			}
+			if pruned {
+				curLog.Info("Pruned data of removed storage reference")
+			} else {
+				curLog.Debug("No data found for removed storage reference")
+			}
+		}
+	}
+
+	for storage, sp := range current {
+		for subPath := range sp.recorded {
+			addSubPath(keep, storage, subPath)
+		}
+	}
+	errs.Append(spp.save(ctx, keep))
+	return errs.Aggregate()
+}
+
+// currentSubPaths returns the subPaths of all storage references in the configuration, mapped by storage name.
+// Only storages which support pruning subPaths are contained.
+func (spp *SubPathPruner) currentSubPaths() (map[string]*subPaths, error) {
+	res := map[string]*subPaths{}
+	get := func(storage string) *subPaths {
+		sp, ok := res[storage]
+		if !ok {
+			sp = &subPaths{recorded: sets.New[string](), protected: sets.New[string]()}
+			res[storage] = sp
+		}
+		return sp
+	}
+	for _, sc := range spp.Config.SyncConfigs {
+		data := &config.SubPathTemplateData{ClusterName: sc.ClusterNameOrDefault(spp.Config.ClusterName)}
+		if sc.Resource != nil {
+			data.Namespace = sc.Resource.Namespace
+			data.Kind = sc.Resource.Kind
+		}
+		for idx, ref := range sc.StorageRefs {
+			if _, ok := spp.pruners[ref.Name]; !ok {
+				continue
+			}
+			subPath, nsDependent, err := resolveStaticSubPath(ref.SubPath, data)
+			if err != nil {
+				return nil, fmt.Errorf("error resolving subPath of storage reference at index %d in sync configuration with id %s: %w", idx, sc.ID, err)
+			}
+			sp := get(ref.Name)
+			sp.protected.Insert(subPath)
+			if !nsDependent {
+				sp.recorded.Insert(subPath)
+			}
+		}
+		if sc.Snapshot != nil && sc.Snapshot.Target != nil {
+			if _, ok := spp.pruners[sc.Snapshot.Target.Name]; ok {
+				subPath, _, err := resolveStaticSubPath(sc.Snapshot.Target.SubPath, data)
+				if err != nil {
+					return nil, fmt.Errorf("error resolving snapshot subPath in sync configuration with id %s: %w", sc.ID, err)
+				}
+				get(sc.Snapshot.Target.Name).protected.Insert(subPath)
+			}
+		}
+	}
+	if np := spp.Config.NamespacePruning; np != nil && np.Mode == config.NAMESPACE_PRUNING_MODE_ARCHIVE {
+		subPath, _, err := resolveStaticSubPath(np.ArchiveSubPath, &config.SubPathTemplateData{ClusterName: spp.Config.ClusterName})
+		if err != nil {
+			return nil, fmt.Errorf("error resolving archive subPath: %w", err)
+		}
+		// the archive subPath applies to all storages
+		for storage := range spp.pruners {
+			get(storage).protected.Insert(subPath)
+		}
+	}
+	return res, nil
+}
+
+// resolveStaticSubPath renders the given subPath template.
+// If the subPath depends on the namespace and the template data doesn't contain a namespace, the static prefix of the subPath is returned,
+// which consists of the path segments which don't depend on the namespace, and the second return value is true.
+func resolveStaticSubPath(subPath string, data *config.SubPathTemplateData) (string, bool, error) {
+	tmpl, err := config.ParseSubPathTemplate(subPath)
+	if err != nil {
+		return "", false, err
+	}
+	res, err := config.RenderSubPath(tmpl, subPath, data)
+	if err != nil {
+		return "", false, err
+	}
+	res = cleanSubPath(res)
+	if tmpl == nil || data.Namespace != "" {
+		return res, false, nil
+	}
+	probeData := *data
+	probeData.Namespace = probeNamespace
+	probe, err := config.RenderSubPath(tmpl, subPath, &probeData)
+	if err != nil {
+		return "", false, err
+	}
+	probe = cleanSubPath(probe)
+	if probe == res {
+		return res, false, nil
+	}
+	segments := strings.Split(res, "/")
+	probeSegments := strings.Split(probe, "/")
+	prefix := []string{}
+	for i := 0; i < len(segments) && i < len(probeSegments) && segments[i] == probeSegments[i]; i++ {
+		prefix = append(prefix, segments[i])
+	}
+	return strings.Join(prefix, "/"), true, nil
+}
+
+// cleanSubPath normalizes the given subPath, so that subPaths pointing to the same directory are equal.
+// The root of the storage is represented by the empty string.
+func cleanSubPath(subPath string) string {
+	res := strings.Trim(path.Clean("/"+subPath), "/")
+	if res == "." {
+		return ""
+	}
+	return res
+}
+
+// overlapsAny returns true if the given subPath is equal to, below, or above any of the given subPaths.
+func overlapsAny(subPath string, others sets.Set[string]) bool {
+	for other := range others {
+		if subPath == other || subPath == "" || other == "" || strings.HasPrefix(subPath, other+"/") || strings.HasPrefix(other, subPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// load returns the recorded subPaths, mapped by storage name.
+// Returns nil if the ConfigMap doesn't exist.
+func (spp *SubPathPruner) load(ctx context.Context) (map[string]sets.Set[string], error) {
+	cm := &corev1.ConfigMap{}
+	if err := spp.Client.Get(ctx, spp.key, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching configmap '%s': %w", spp.key.String(), err)
+	}
+	raw := map[string][]string{}
+	if data, ok := cm.Data[managedSubPathsKey]; ok {
+		if err := json.Unmarshal([]byte(data), &raw); err != nil {
+			return nil, fmt.Errorf("error parsing key '%s' of configmap '%s': %w", managedSubPathsKey, spp.key.String(), err)
+		}
+	}
+	res := map[string]sets.Set[string]{}
+	for storage, sps := range raw {
+		res[storage] = sets.New(sps...)
+	}
+	return res, nil
+}
+
+// save records the given subPaths, creating the ConfigMap if it doesn't exist.
+func (spp *SubPathPruner) save(ctx context.Context, recorded map[string]sets.Set[string]) error {
+	raw := map[string][]string{}
+	for storage, sps := range recorded {
+		raw[storage] = sets.List(sps)
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}, func() error {
+		cm := &corev1.ConfigMap{}
+		if err := spp.Client.Get(ctx, spp.key, cm); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return fmt.Errorf("error fetching configmap '%s': %w", spp.key.String(), err)
+			}
+			cm = &corev1.ConfigMap{}
+			cm.SetName(spp.key.Name)
+			cm.SetNamespace(spp.key.Namespace)
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[managedSubPathsKey] = string(data)
+		if cm.ResourceVersion == "" {
+			return spp.Client.Create(ctx, cm)
+		}
+		return spp.Client.Update(ctx, cm)
+	})
+}
+
+func addSubPath(subPaths map[string]sets.Set[string], storage, subPath string) {
+	if _, ok := subPaths[storage]; !ok {
+		subPaths[storage] = sets.New[string]()
+	}
+	subPaths[storage].Insert(subPath)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,225 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pruning
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+)
+
+var _ = Describe("SubPathPruner", func() {
+
+	var (
+		ctx   context.Context
+		c     client.Client
+		p     *recordingPruner
+		cfg   *config.K8SyncerConfiguration
+		cmKey = types.NamespacedName{Name: "subpaths", Namespace: "default"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		c = fake.NewClientBuilder().Build()
+		p = newRecordingPruner()
+		cfg = &config.K8SyncerConfiguration{
+			SubPathPruning: &config.SubPathPruningConfiguration{
+				ConfigMapName:      cmKey.Name,
+				ConfigMapNamespace: cmKey.Namespace,
+			},
+		}
+	})
+
+	run := func() error {
+		spp, err := NewSubPathPruner(c, cfg, map[string]persist.Persister{testStorage: p})
+		Expect(err).ToNot(HaveOccurred())
+		return spp.Run(ctx)
+	}
+
+	recorded := func() map[string][]string {
+		cm := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, cmKey, cm)).To(Succeed())
+		res := map[string][]string{}
+		Expect(json.Unmarshal([]byte(cm.Data[managedSubPathsKey]), &res)).To(Succeed())
+		return res
+	}
+
+	It("should only record the subPaths on the first run", func() {
+		p.persistDummy(ctx, "foo", "default", "old")
+		p.persistDummy(ctx, "foo", "default", "current")
+		cfg.SyncConfigs = []*config.SyncConfig{syncConfig("a", "", "current")}
+
+		Expect(run()).To(Succeed())
+		Expect(p.subPathCalls).To(BeEmpty())
+		Expect(p.exists(ctx, "foo", "default", "old")).To(BeTrue())
+		Expect(recorded()).To(Equal(map[string][]string{testStorage: {"current"}}))
+	})
+
+	It("should prune the data of removed storage references", func() {
+		cfg.SyncConfigs = []*config.SyncConfig{syncConfig("a", "", "old", "current")}
+		Expect(run()).To(Succeed())
+		Expect(recorded()).To(Equal(map[string][]string{testStorage: {"current", "old"}}))
+
+		p.persistDummy(ctx, "foo", "default", "old")
+		p.persistDummy(ctx, "foo", "default", "current")
+		cfg.SyncConfigs = []*config.SyncConfig{syncConfig("a", "", "current")}
+		Expect(run()).To(Succeed())
+		Expect(p.subPathCalls).To(ConsistOf("old"))
+		Expect(p.exists(ctx, "foo", "default", "old")).To(BeFalse())
+		Expect(p.exists(ctx, "foo", "default", "current")).To(BeTrue())
+		Expect(recorded()).To(Equal(map[string][]string{testStorage: {"current"}}))
+	})
+
+	It("should not prune subPaths which overlap with a current or protected subPath", func() {
+		cfg.SyncConfigs = []*config.SyncConfig{syncConfig("a", "", "current/below", "above", "same", "snapshots/below", "archive/below")}
+		Expect(run()).To(Succeed())
+
+		p.persistDummy(ctx, "foo", "default", "current/below")
+		p.persistDummy(ctx, "foo", "default", "above")
+		p.persistDummy(ctx, "foo", "default", "same")
+		p.persistDummy(ctx, "foo", "default", "snapshots/below")
+		p.persistDummy(ctx, "foo", "default", "archive/below")
+		sc := syncConfig("b", "", "current", "above/below", "./same/")
+		sc.Snapshot = &config.SnapshotConfiguration{Target: &config.SnapshotTarget{Name: testStorage, SubPath: "snapshots"}}
+		cfg.SyncConfigs = []*config.SyncConfig{sc}
+		cfg.NamespacePruning = &config.NamespacePruningConfiguration{
+			Mode:           config.NAMESPACE_PRUNING_MODE_ARCHIVE,
+			ArchiveSubPath: "archive",
+		}
+		Expect(run()).To(Succeed())
+		Expect(p.subPathCalls).To(BeEmpty())
+		for _, subPath := range []string{"current/below", "above", "same", "snapshots/below", "archive/below"} {
+			Expect(p.exists(ctx, "foo", "default", subPath)).To(BeTrue(), "data below subPath %q should not have been pruned", subPath)
+		}
+		Expect(recorded()).To(Equal(map[string][]string{testStorage: {"above/below", "current", "same"}}))
+	})
+
+	It("should protect the static prefix of subPaths which depend on the namespace without recording them", func() {
+		cfg.SyncConfigs = []*config.SyncConfig{syncConfig("a", "", "tenants/old")}
+		Expect(run()).To(Succeed())
+
+		p.persistDummy(ctx, "foo", "default", "tenants/old")
+		cfg.SyncConfigs = []*config.SyncConfig{syncConfig("a", "", "tenants/{{ .Namespace }}")}
+		Expect(run()).To(Succeed())
+		Expect(p.subPathCalls).To(BeEmpty())
+		Expect(p.exists(ctx, "foo", "default", "tenants/old")).To(BeTrue())
+		Expect(recorded()).To(BeEmpty())
+	})
+
+	It("should record subPaths which contain a fixed namespace", func() {
+		cfg.SyncConfigs = []*config.SyncConfig{syncConfig("a", "foo", "tenants/{{ .Namespace }}")}
+		Expect(run()).To(Succeed())
+		Expect(recorded()).To(Equal(map[string][]string{testStorage: {"tenants/foo"}}))
+	})
+
+	It("should not prune anything in dry run mode", func() {
+		cfg.SyncConfigs = []*config.SyncConfig{syncConfig("a", "", "old")}
+		Expect(run()).To(Succeed())
+
+		p.persistDummy(ctx, "foo", "default", "old")
+		cfg.SyncConfigs = nil
+		cfg.SubPathPruning.DryRun = true
+		Expect(run()).To(Succeed())
+		Expect(p.subPathCalls).To(BeEmpty())
+		Expect(p.exists(ctx, "foo", "default", "old")).To(BeTrue())
+		Expect(recorded()).To(Equal(map[string][]string{testStorage: {"old"}}))
+	})
+
+	It("should keep subPaths recorded if pruning them fails", func() {
+		cfg.SyncConfigs = []*config.SyncConfig{syncConfig("a", "", "old", "other")}
+		Expect(run()).To(Succeed())
+
+		p.persistDummy(ctx, "foo", "default", "old")
+		p.persistDummy(ctx, "foo", "default", "other")
+		p.fail.Insert("old")
+		cfg.SyncConfigs = nil
+		Expect(run()).To(MatchError(ContainSubstring("pruning subPath 'old' failed")))
+		Expect(p.subPathCalls).To(ConsistOf("old", "other"))
+		Expect(p.exists(ctx, "foo", "default", "old")).To(BeTrue())
+		Expect(p.exists(ctx, "foo", "default", "other")).To(BeFalse())
+		Expect(recorded()).To(Equal(map[string][]string{testStorage: {"old"}}))
+
+		// the pruning is retried on the next run
+		p.fail.Delete("old")
+		p.subPathCalls = nil
+		Expect(run()).To(Succeed())
+		Expect(p.subPathCalls).To(ConsistOf("old"))
+		Expect(p.exists(ctx, "foo", "default", "old")).To(BeFalse())
+		Expect(recorded()).To(BeEmpty())
+	})
+
+	It("should never prune the root of a storage", func() {
+		cfg.SyncConfigs = []*config.SyncConfig{syncConfig("a", "", "")}
+		Expect(run()).To(Succeed())
+
+		p.persistDummy(ctx, "foo", "default", "")
+		cfg.SyncConfigs = nil
+		Expect(run()).To(Succeed())
+		Expect(p.subPathCalls).To(BeEmpty())
+		Expect(p.exists(ctx, "foo", "default", "")).To(BeTrue())
+	})
+
+	It("should ignore subPaths of storages which are not configured anymore", func() {
+		Expect(c.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: cmKey.Name, Namespace: cmKey.Namespace},
+			Data:       map[string]string{managedSubPathsKey: `{"removedStorage":["old"]}`},
+		})).To(Succeed())
+
+		Expect(run()).To(Succeed())
+		Expect(p.subPathCalls).To(BeEmpty())
+		Expect(recorded()).To(BeEmpty())
+	})
+
+	Context("resolveStaticSubPath", func() {
+
+		DescribeTable("should resolve the static prefix of subPaths",
+			func(subPath string, data *config.SubPathTemplateData, expected string, expectedNsDependent bool) {
+				res, nsDependent, err := resolveStaticSubPath(subPath, data)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res).To(Equal(expected))
+				Expect(nsDependent).To(Equal(expectedNsDependent))
+			},
+			Entry("static subPath", "/foo/bar/", &config.SubPathTemplateData{}, "foo/bar", false),
+			Entry("root", "", &config.SubPathTemplateData{}, "", false),
+			Entry("cluster name", "{{ .ClusterName }}/foo", &config.SubPathTemplateData{ClusterName: "c1"}, "c1/foo", false),
+			Entry("kind", "{{ .Kind }}", &config.SubPathTemplateData{Kind: "Dummy"}, "Dummy", false),
+			Entry("fixed namespace", "tenants/{{ .Namespace }}", &config.SubPathTemplateData{Namespace: "foo"}, "tenants/foo", false),
+			Entry("namespace dependent", "tenants/{{ .Namespace }}/data", &config.SubPathTemplateData{}, "tenants", true),
+			Entry("namespace dependent segment", "tenants/ns-{{ .Namespace }}", &config.SubPathTemplateData{}, "tenants", true),
+			Entry("namespace dependent at the root", "{{ .Namespace }}", &config.SubPathTemplateData{}, "", true),
+		)
+
+	})
+
+	Context("overlapsAny", func() {
+
+		DescribeTable("should detect overlapping subPaths",
+			func(subPath string, others []string, expected bool) {
+				Expect(overlapsAny(subPath, sets.New(others...))).To(Equal(expected))
+			},
+			Entry("equal", "foo", []string{"foo"}, true),
+			Entry("below", "foo/bar", []string{"foo"}, true),
+			Entry("above", "foo", []string{"foo/bar"}, true),
+			Entry("root", "", []string{"foo"}, true),
+			Entry("other is root", "foo", []string{""}, true),
+			Entry("common prefix", "foo", []string{"foobar"}, false),
+			Entry("siblings", "foo/bar", []string{"foo/baz"}, false),
+			Entry("no others", "foo", nil, false),
+		)
+
+	})
+
+})
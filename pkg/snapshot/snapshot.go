@@ -0,0 +1,233 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// timestampFormat is the format of the timestamp which is part of the snapshot file names.
+const timestampFormat = "20060102T150405Z"
+
+var _ manager.Runnable = &Snapshotter{}
+
+// Snapshotter periodically bundles all resources which have been persisted for a sync config into a single artifact.
+type Snapshotter struct {
+	SyncConfigID string
+	Interval     time.Duration
+	Format       config.SnapshotFormat
+	// Source is used to read the persisted resources.
+	Source        persist.TreeReader
+	SourceSubPath string
+	// Target is used to store the snapshot artifacts.
+	Target        persist.ArtifactPersister
+	TargetSubPath string
+
+	log logging.Logger
+	// now returns the current time, can be overwritten for testing purposes.
+	now func() time.Time
+	// syncConfig is used to skip snapshots while the sync config is suspended.
+	syncConfig *config.SyncConfig
+}
+
+// New creates a new Snapshotter for the given sync config.
+// The sync config is expected to have a completed and validated snapshot configuration.
+func New(log logging.Logger, cfg *config.K8SyncerConfiguration, syncConfig *config.SyncConfig, persisters map[string]persist.Persister) (*Snapshotter, error) {
+	snapCfg := syncConfig.Snapshot
+	if snapCfg == nil || snapCfg.Interval == nil || snapCfg.Target == nil {
+		// should not happen, as this check is already part of the config validation
+		return nil, fmt.Errorf("incomplete snapshot configuration in sync configuration with id %s", syncConfig.ID)
+	}
+	// resources from all namespaces are bundled, unless the sync config is restricted to a single namespace
+	tmplData := &config.SubPathTemplateData{
+		ClusterName: syncConfig.ClusterNameOrDefault(cfg.ClusterName),
+	}
+	if syncConfig.Resource != nil {
+		tmplData.Namespace = syncConfig.Resource.Namespace
+		tmplData.Kind = syncConfig.Resource.Kind
+	}
+
+	var sourceRef *config.StorageReference
+	for _, ref := range syncConfig.StorageRefs {
+		if ref.Name == snapCfg.Source {
+			sourceRef = ref
+			break
+		}
+	}
+	if sourceRef == nil {
+		// should not happen, as this check is already part of the config validation
+		return nil, fmt.Errorf("snapshot source '%s' is not a storage reference of sync configuration with id %s", snapCfg.Source, syncConfig.ID)
+	}
+	source, ok := persist.FindTreeReader(persisters[sourceRef.Name])
+	if !ok {
+		return nil, fmt.Errorf("storage '%s' does not support reading snapshots", sourceRef.Name)
+	}
+	sourceSubPath, err := renderSubPath(sourceRef.SubPath, tmplData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subPath template in snapshot source: %w", err)
+	}
+
+	target, ok := persist.FindArtifactPersister(persisters[snapCfg.Target.Name])
+	if !ok {
+		return nil, fmt.Errorf("storage '%s' does not support storing snapshots", snapCfg.Target.Name)
+	}
+	targetSubPath, err := renderSubPath(snapCfg.Target.SubPath, tmplData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subPath template in snapshot target: %w", err)
+	}
+
+	return &Snapshotter{
+		SyncConfigID:  syncConfig.ID,
+		Interval:      snapCfg.Interval.Duration,
+		Format:        snapCfg.Format,
+		Source:        source,
+		SourceSubPath: sourceSubPath,
+		Target:        target,
+		TargetSubPath: targetSubPath,
+		log:           log,
+		now:           time.Now,
+		syncConfig:    syncConfig,
+	}, nil
+}
+
+// AddSnapshotterToManager registers a Snapshotter for the given sync config in the manager.
+// It is a no-op if no snapshots are configured for the sync config.
+func AddSnapshotterToManager(baseLogger logging.Logger, mgr manager.Manager, cfg *config.K8SyncerConfiguration, syncConfig *config.SyncConfig, persisters map[string]persist.Persister) error {
+	if syncConfig.Snapshot == nil {
+		return nil
+	}
+	log := baseLogger.WithName(syncConfig.ID).WithName("snapshot").WithValues(constants.Logging.KEY_ID, syncConfig.ID)
+	s, err := New(log, cfg, syncConfig, persisters)
+	if err != nil {
+		return err
+	}
+	log.Info("snapshots configured", constants.Logging.KEY_RESOURCE_STORAGE_ID, syncConfig.Snapshot.Target.Name, constants.Logging.KEY_PATH, s.TargetSubPath)
+	return mgr.Add(s)
+}
+
+// Start takes a snapshot whenever the configured interval has passed, until the context is cancelled.
+// Failed snapshots are logged and retried with the next interval.
+func (s *Snapshotter) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if s.syncConfig != nil {
+				if _, suspended := s.syncConfig.SuspendedUntil(s.now()); suspended {
+					s.log.Info("Sync config is suspended, skipping snapshot")
+					continue
+				}
+			}
+			filename, err := s.TakeSnapshot(ctx)
+			if err != nil {
+				s.log.Error(err, "error creating snapshot")
+				continue
+			}
+			s.log.Info("Created snapshot", constants.Logging.KEY_PATH, filename)
+		}
+	}
+}
+
+// TakeSnapshot bundles all persisted resources into a single artifact and stores it.
+// It returns the file name of the created snapshot.
+func (s *Snapshotter) TakeSnapshot(ctx context.Context) (string, error) {
+	files, err := s.Source.ReadTree(ctx, s.SourceSubPath)
+	if err != nil {
+		return "", fmt.Errorf("error reading persisted resources: %w", err)
+	}
+	now := s.now().UTC()
+	var data []byte
+	switch s.Format {
+	case config.SNAPSHOT_FORMAT_TARGZ:
+		data, err = renderTarGz(files, now)
+	default:
+		data = renderYAML(files)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error rendering snapshot: %w", err)
+	}
+	filename := fmt.Sprintf("%s-%s.%s", s.SyncConfigID, now.Format(timestampFormat), string(s.Format))
+	if err := s.Target.PersistArtifact(ctx, data, filename, s.TargetSubPath); err != nil {
+		return "", fmt.Errorf("error storing snapshot: %w", err)
+	}
+	return filename, nil
+}
+
+// renderYAML concatenates the given files into a multi-document YAML stream, sorted by path.
+// Each document is preceded by a comment containing its path.
+func renderYAML(files map[string][]byte) []byte {
+	buf := &bytes.Buffer{}
+	for _, path := range sortedPaths(files) {
+		fmt.Fprintf(buf, "---\n# Source: %s\n", path)
+		buf.Write(files[path])
+		if data := files[path]; len(data) > 0 && data[len(data)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
+
+// renderTarGz packs the given files into a gzipped tarball.
+func renderTarGz(files map[string][]byte, modTime time.Time) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for _, path := range sortedPaths(files) {
+		data := files[path]
+		err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     path,
+			Mode:     0644,
+			Size:     int64(len(data)),
+			ModTime:  modTime,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func sortedPaths(files map[string][]byte) []string {
+	res := make([]string, 0, len(files))
+	for path := range files {
+		res = append(res, path)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func renderSubPath(subPath string, data *config.SubPathTemplateData) (string, error) {
+	tmpl, err := config.ParseSubPathTemplate(subPath)
+	if err != nil {
+		return "", err
+	}
+	return config.RenderSubPath(tmpl, subPath, data)
+}
@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/persist"
+	fspersist "github.com/gardener/k8syncer/pkg/persist/filesystem"
+	"github.com/gardener/k8syncer/pkg/persist/transformers"
+	"github.com/gardener/k8syncer/pkg/utils"
+)
+
+var _ = Describe("Snapshotter", func() {
+
+	var (
+		ctx        context.Context
+		fs         vfs.FileSystem
+		cfg        *config.K8SyncerConfiguration
+		persisters map[string]persist.Persister
+		now        time.Time
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fs = memoryfs.New()
+		fsp, err := fspersist.New(fs, &config.FileSystemConfiguration{
+			RootPath: "/data",
+			InMemory: utils.Ptr(true),
+		}, true)
+		Expect(err).ToNot(HaveOccurred())
+		persisters = map[string]persist.Persister{
+			"myStorage": persist.AddLoggingLayer(fsp, logging.DEBUG),
+		}
+		cfg = &config.K8SyncerConfiguration{
+			SyncConfigs: []*config.SyncConfig{
+				{
+					ID: "dummyWatcher",
+					StorageRefs: []*config.StorageReference{
+						{
+							Name:    "myStorage",
+							SubPath: "resources",
+						},
+					},
+					Snapshot: &config.SnapshotConfiguration{
+						Interval: &metav1.Duration{Duration: time.Hour},
+						Format:   config.SNAPSHOT_FORMAT_YAML,
+						Source:   "myStorage",
+						Target: &config.SnapshotTarget{
+							Name:    "myStorage",
+							SubPath: "snapshots",
+						},
+					},
+				},
+			},
+		}
+		now = time.Date(2023, 6, 1, 12, 30, 0, 0, time.UTC)
+
+		for _, name := range []string{"foo", "bar"} {
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(schema.GroupVersionKind{Group: "k8syncer.gardener.cloud", Version: "v1", Kind: "Dummy"})
+			obj.SetName(name)
+			obj.SetNamespace("default")
+			_, _, err := fsp.Persist(ctx, obj, transformers.NewBasic(), name, "resources")
+			Expect(err).ToNot(HaveOccurred())
+		}
+	})
+
+	newSnapshotter := func() *Snapshotter {
+		s, err := New(logging.Discard(), cfg, cfg.SyncConfigs[0], persisters)
+		Expect(err).ToNot(HaveOccurred())
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	It("should bundle all persisted resources into a multi-document yaml file", func() {
+		s := newSnapshotter()
+		filename, err := s.TakeSnapshot(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filename).To(Equal("dummyWatcher-20230601T123000Z.yaml"))
+
+		data, err := vfs.ReadFile(fs, "/data/snapshots/dummyWatcher-20230601T123000Z.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		docs := strings.Split(strings.TrimPrefix(string(data), "---\n"), "---\n")
+		Expect(docs).To(HaveLen(2))
+		Expect(docs[0]).To(HavePrefix("# Source: ns_default/dummy.v1.k8syncer.gardener.cloud_bar.yaml\n"))
+		Expect(docs[0]).To(ContainSubstring("name: bar"))
+		Expect(docs[1]).To(HavePrefix("# Source: ns_default/dummy.v1.k8syncer.gardener.cloud_foo.yaml\n"))
+		Expect(docs[1]).To(ContainSubstring("name: foo"))
+	})
+
+	It("should bundle all persisted resources into a tarball", func() {
+		cfg.SyncConfigs[0].Snapshot.Format = config.SNAPSHOT_FORMAT_TARGZ
+		s := newSnapshotter()
+		filename, err := s.TakeSnapshot(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filename).To(Equal("dummyWatcher-20230601T123000Z.tar.gz"))
+
+		data, err := vfs.ReadFile(fs, "/data/snapshots/"+filename)
+		Expect(err).ToNot(HaveOccurred())
+		gr, err := gzip.NewReader(bytes.NewReader(data))
+		Expect(err).ToNot(HaveOccurred())
+		tr := tar.NewReader(gr)
+		names := []string{}
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			Expect(err).ToNot(HaveOccurred())
+			names = append(names, hdr.Name)
+			content, err := io.ReadAll(tr)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring("kind: Dummy"))
+		}
+		Expect(names).To(Equal([]string{"ns_default/dummy.v1.k8syncer.gardener.cloud_bar.yaml", "ns_default/dummy.v1.k8syncer.gardener.cloud_foo.yaml"}))
+	})
+
+})
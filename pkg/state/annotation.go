@@ -6,6 +6,7 @@ package state
 
 import (
 	"fmt"
+	"strconv"
 
 	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -21,12 +22,23 @@ type AnnotationStateDisplay struct {
 	verbosity StateVerbosity
 }
 
-func NewAnnotationStateDisplay(v StateVerbosity) *AnnotationStateDisplay {
+// NewAnnotationStateDisplay creates a state display which writes the state fields into the annotations with the given keys.
+// Empty keys are replaced with the default annotation keys.
+func NewAnnotationStateDisplay(generationKey, phaseKey, detailKey string, v StateVerbosity) *AnnotationStateDisplay {
+	if generationKey == "" {
+		generationKey = constants.ANNOTATION_LAST_SYNCED_GENERATION
+	}
+	if phaseKey == "" {
+		phaseKey = constants.ANNOTATION_PHASE
+	}
+	if detailKey == "" {
+		detailKey = constants.ANNOTATION_DETAIL
+	}
 	return &AnnotationStateDisplay{
 		fieldAnnotations: map[string]string{
-			STATE_FIELD_LAST_SYNCED_GENERATION.name: constants.ANNOTATION_LAST_SYNCED_GENERATION,
-			STATE_FIELD_PHASE.name:                  constants.ANNOTATION_PHASE,
-			STATE_FIELD_DETAIL.name:                 constants.ANNOTATION_DETAIL,
+			STATE_FIELD_LAST_SYNCED_GENERATION.name: generationKey,
+			STATE_FIELD_PHASE.name:                  phaseKey,
+			STATE_FIELD_DETAIL.name:                 detailKey,
 		},
 		verbosity: v,
 	}
@@ -52,10 +64,22 @@ func (asd *AnnotationStateDisplay) Read(obj client.Object) (*SyncState, StateErr
 		if !asd.verbosity.Includes(field) {
 			continue
 		}
-		value, exists := ann[asd.fieldAnnotations[field.Name()]]
+		raw, exists := ann[asd.fieldAnnotations[field.Name()]]
 		if !exists {
 			return nil, DefaultMissingStateError(asd.verbosity, field)
 		}
+		// annotation values are always strings, so they have to be converted into the type of the field
+		var value any = raw
+		switch field {
+		case STATE_FIELD_LAST_SYNCED_GENERATION:
+			gen, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return nil, DefaultInvalidStateError(field, raw, err)
+			}
+			value = gen
+		case STATE_FIELD_PHASE:
+			value = PhaseFromString(raw)
+		}
 		err := state.SetField(field, value)
 		if err != nil {
 			return nil, err
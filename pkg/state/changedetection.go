@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils"
+)
+
+// ObservedVersion returns the value which represents the current version of the object for the given change detection mode.
+// This value is stored as last synced generation in the state.
+//   - 'generation' returns the object's generation
+//   - 'resourceVersion' returns the object's resourceVersion, which is expected to be an integer
+//   - 'contentHash' returns a non-negative integer derived from the object's content hash, see ContentHash
+func ObservedVersion(obj client.Object, mode config.ChangeDetectionMode) (int64, error) {
+	if obj == nil {
+		return 0, fmt.Errorf("object must not be nil")
+	}
+	switch mode {
+	case config.CHANGE_DETECTION_GENERATION, "":
+		return obj.GetGeneration(), nil
+	case config.CHANGE_DETECTION_RESOURCE_VERSION:
+		rv, err := strconv.ParseInt(obj.GetResourceVersion(), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unable to parse resourceVersion '%s' of object: %w", obj.GetResourceVersion(), err)
+		}
+		return rv, nil
+	case config.CHANGE_DETECTION_CONTENT_HASH:
+		hash, err := ContentHash(obj)
+		if err != nil {
+			return 0, err
+		}
+		return int64(binary.BigEndian.Uint64(hash[:8]) & math.MaxInt64), nil
+	default:
+		return 0, fmt.Errorf("unknown change detection mode '%s'", string(mode))
+	}
+}
+
+// ContentHash returns a SHA256 hash over the object's content.
+// The status, as well as all metadata fields except for name, namespace, labels, annotations, and owner references, are ignored.
+// Annotations written by the annotation state display are ignored too, so that writing the state does not change the hash.
+func ContentHash(obj client.Object) ([]byte, error) {
+	u, serr := ObjectToUnstructured(obj)
+	if serr != nil {
+		return nil, serr
+	}
+	content := map[string]any{}
+	for k, v := range u.UnstructuredContent() {
+		if k == "metadata" || k == "status" {
+			continue
+		}
+		content[k] = v
+	}
+	annotations := map[string]string{}
+	for k, v := range u.GetAnnotations() {
+		if !utils.IsStateAnnotation(k) {
+			annotations[k] = v
+		}
+	}
+	content["metadata"] = map[string]any{
+		"name":            u.GetName(),
+		"namespace":       u.GetNamespace(),
+		"labels":          u.GetLabels(),
+		"annotations":     annotations,
+		"ownerReferences": u.GetOwnerReferences(),
+	}
+	// json.Marshal sorts map keys, so the result is deterministic
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling object for content hash: %w", err)
+	}
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
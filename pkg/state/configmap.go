@@ -0,0 +1,255 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package state
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ StateDisplay = &ConfigMapStateDisplay{}
+var _ StateRemover = &ConfigMapStateDisplay{}
+
+// ConfigMapStateDisplay writes the state of all objects of a namespace into a single ConfigMap in that namespace,
+// with one key per object. The objects themselves are not modified.
+// The state of cluster-scoped objects is written into a ConfigMap in a configurable namespace.
+type ConfigMapStateDisplay struct {
+	client                 client.Client
+	name                   string
+	clusterScopedNamespace string
+
+	verbosity StateVerbosity
+}
+
+func NewConfigMapStateDisplay(c client.Client, name, clusterScopedNamespace string, v StateVerbosity) *ConfigMapStateDisplay {
+	return &ConfigMapStateDisplay{
+		client:                 c,
+		name:                   name,
+		clusterScopedNamespace: clusterScopedNamespace,
+		verbosity:              v,
+	}
+}
+
+func (*ConfigMapStateDisplay) Type() string {
+	return "configmap"
+}
+
+func (csd *ConfigMapStateDisplay) Read(obj client.Object) (*SyncState, StateError) {
+	if csd.verbosity == STATE_VERBOSITY_UNDEFINED || csd.verbosity == StateVerbosity("") {
+		return nil, NewInternalStateError("invalid desired verbosity: %s", string(csd.verbosity))
+	}
+	if obj == nil {
+		return nil, NewInternalStateError("object must not be nil")
+	}
+	cm := &corev1.ConfigMap{}
+	if err := csd.client.Get(context.Background(), csd.configMapKey(obj), cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, DefaultMissingStateError(csd.verbosity)
+		}
+		return nil, DefaultReadStateError(err)
+	}
+	if _, exists := cm.Data[ConfigMapStateKey(obj)]; !exists {
+		return nil, DefaultMissingStateError(csd.verbosity)
+	}
+	entry, serr := readConfigMapStateEntry(cm, ConfigMapStateKey(obj))
+	if serr != nil {
+		return nil, serr
+	}
+	state := &SyncState{}
+	for _, field := range ALL_STATE_FIELDS {
+		if !csd.verbosity.Includes(field) {
+			continue
+		}
+		value, exists := entry[field.Name()]
+		if !exists {
+			return nil, DefaultMissingStateError(csd.verbosity, field)
+		}
+		if field == STATE_FIELD_PHASE {
+			value = PhaseFromString(fmt.Sprint(value))
+		}
+		err := state.SetField(field, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// Write writes the state into the ConfigMap, creating it if it doesn't exist.
+// As the object itself is never modified, the returned set of changed fields is always empty.
+func (csd *ConfigMapStateDisplay) Write(obj client.Object, state *SyncState, fields ...*StateField) (sets.Set[string], error) {
+	if state == nil || state.Verbosity == STATE_VERBOSITY_UNDEFINED || state.Verbosity == STATE_VERBOSITY_ANY || state.Verbosity == StateVerbosity("") {
+		return nil, NewInternalStateError("invalid SyncState object, either nil or with invalid verbosity")
+	}
+	if obj == nil {
+		return nil, NewInternalStateError("object must not be nil")
+	}
+	key := ConfigMapStateKey(obj)
+	err := csd.updateConfigMap(obj, func(cm *corev1.ConfigMap) (bool, error) {
+		entry, serr := readConfigMapStateEntry(cm, key)
+		if serr != nil {
+			return false, serr
+		}
+		changed := false
+		for _, field := range fields {
+			if !state.Verbosity.Includes(field) {
+				continue
+			}
+			newValue := field.serialize(state.GetField(field))
+			if oldValue, found := entry[field.Name()]; found && reflect.DeepEqual(newValue, oldValue) {
+				// value currently stored in the configmap is the same as we would write, no need to try it
+				continue
+			}
+			changed = true
+			entry[field.Name()] = newValue
+		}
+		if !changed {
+			return false, nil
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return false, err
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[key] = string(data)
+		return true, nil
+	})
+	if err != nil {
+		return nil, DefaultWriteStateError(err)
+	}
+	return nil, nil
+}
+
+// Remove removes the state of the given object from the ConfigMap.
+func (csd *ConfigMapStateDisplay) Remove(obj client.Object) error {
+	if obj == nil {
+		return NewInternalStateError("object must not be nil")
+	}
+	key := ConfigMapStateKey(obj)
+	err := csd.updateConfigMap(obj, func(cm *corev1.ConfigMap) (bool, error) {
+		if cm.ResourceVersion == "" {
+			// configmap doesn't exist, there is nothing to remove
+			return false, nil
+		}
+		if _, exists := cm.Data[key]; !exists {
+			return false, nil
+		}
+		delete(cm.Data, key)
+		return true, nil
+	})
+	if err != nil {
+		return DefaultWriteStateError(err)
+	}
+	return nil
+}
+
+func (csd *ConfigMapStateDisplay) Verbosity() StateVerbosity {
+	return csd.verbosity
+}
+
+// configMapKey returns the key of the ConfigMap which contains the state of the given object.
+func (csd *ConfigMapStateDisplay) configMapKey(obj client.Object) types.NamespacedName {
+	namespace := obj.GetNamespace()
+	if namespace == "" {
+		namespace = csd.clusterScopedNamespace
+	}
+	return types.NamespacedName{
+		Namespace: namespace,
+		Name:      csd.name,
+	}
+}
+
+// updateConfigMap fetches the ConfigMap for the given object and applies the change function to it.
+// If the change function returns true, the ConfigMap is created or updated, with retrying in case of a conflict.
+// Writes and retried conflicts are counted in the state write metrics.
+// The change function is called with an empty ConfigMap if it doesn't exist yet.
+func (csd *ConfigMapStateDisplay) updateConfigMap(obj client.Object, changeFunc func(cm *corev1.ConfigMap) (bool, error)) error {
+	ctx := context.Background()
+	cmKey := csd.configMapKey(obj)
+	backoff := retry.DefaultRetry
+	tries := 0
+	return retry.OnError(backoff, func(err error) bool {
+		// multiple objects of the same namespace share the configmap
+		if !apierrors.IsConflict(err) && !apierrors.IsAlreadyExists(err) {
+			return false
+		}
+		// the function is called at most backoff.Steps times, so the conflict of the last try is returned instead of being retried
+		if tries < backoff.Steps {
+			RecordWriteConflict(csd.Type())
+		}
+		return true
+	}, func() error {
+		tries++
+		cm := &corev1.ConfigMap{}
+		if err := csd.client.Get(ctx, cmKey, cm); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return fmt.Errorf("error fetching state configmap: %w", err)
+			}
+			cm = &corev1.ConfigMap{}
+			cm.SetName(cmKey.Name)
+			cm.SetNamespace(cmKey.Namespace)
+		}
+		changed, err := changeFunc(cm)
+		if err != nil || !changed {
+			return err
+		}
+		RecordWriteAttempt(csd.Type())
+		if cm.ResourceVersion == "" {
+			return csd.client.Create(ctx, cm)
+		}
+		return csd.client.Update(ctx, cm)
+	})
+}
+
+// ConfigMapStateKey returns the key under which the state of the given object is stored in the state ConfigMap.
+// It has the format '<kind>.<group>_<name>', with '.<group>' being omitted for the core group.
+// As '_' is neither allowed in kinds, nor in groups or names, the key is unique within a namespace.
+func ConfigMapStateKey(obj client.Object) string {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if gvk.Group == "" {
+		return fmt.Sprintf("%s_%s", gvk.Kind, obj.GetName())
+	}
+	return fmt.Sprintf("%s.%s_%s", gvk.Kind, gvk.Group, obj.GetName())
+}
+
+// readConfigMapStateEntry parses the state stored under the given key in the ConfigMap.
+// The values are converted into the types returned by the serialize functions of the state fields.
+// Returns an empty entry if there is no such key.
+func readConfigMapStateEntry(cm *corev1.ConfigMap, key string) (map[string]any, StateError) {
+	entry := map[string]any{}
+	raw, exists := cm.Data[key]
+	if !exists {
+		return entry, nil
+	}
+	dec := json.NewDecoder(bytes.NewBufferString(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&entry); err != nil {
+		return nil, DefaultReadStateError(fmt.Errorf("error parsing state under key '%s' in configmap: %w", key, err))
+	}
+	if value, ok := entry[STATE_FIELD_LAST_SYNCED_GENERATION.Name()]; ok {
+		num, ok := value.(json.Number)
+		if !ok {
+			return nil, DefaultInvalidStateError(STATE_FIELD_LAST_SYNCED_GENERATION, value, nil)
+		}
+		gen, err := num.Int64()
+		if err != nil {
+			return nil, DefaultInvalidStateError(STATE_FIELD_LAST_SYNCED_GENERATION, value, err)
+		}
+		entry[STATE_FIELD_LAST_SYNCED_GENERATION.Name()] = gen
+	}
+	return entry, nil
+}
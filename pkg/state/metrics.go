@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package state
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	writeCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "k8syncer",
+		Name:      "state_writes_total",
+		Help:      "Number of requests to the cluster which write state, by state display type.",
+	}, []string{"state_display"})
+	writeConflictCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "k8syncer",
+		Name:      "state_write_conflicts_total",
+		Help:      "Number of state writes which failed due to a conflict and have been retried, by state display type.",
+	}, []string{"state_display"})
+	writeFailureCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "k8syncer",
+		Name:      "state_write_failures_total",
+		Help:      "Number of state updates which failed permanently, including the ones which ran out of retries, by state display type.",
+	}, []string{"state_display"})
+)
+
+// WriteMetrics returns the prometheus collectors for the state write metrics of all state displays.
+// They have to be registered at a prometheus registry in order to be exposed.
+func WriteMetrics() []prometheus.Collector {
+	return []prometheus.Collector{writeCounter, writeConflictCounter, writeFailureCounter}
+}
+
+// RecordWriteAttempt counts a request to the cluster which writes state of the given state display type.
+func RecordWriteAttempt(stateType string) {
+	writeCounter.WithLabelValues(stateType).Inc()
+}
+
+// RecordWriteConflict counts a state write of the given state display type which is retried because of a conflict.
+func RecordWriteConflict(stateType string) {
+	writeConflictCounter.WithLabelValues(stateType).Inc()
+}
+
+// RecordWriteFailure counts a state update of the given state display type which failed and won't be retried.
+func RecordWriteFailure(stateType string) {
+	writeFailureCounter.WithLabelValues(stateType).Inc()
+}
@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package state
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("State Write Metrics", func() {
+
+	var (
+		csd *ConfigMapStateDisplay
+		obj *unstructured.Unstructured
+		// conflicts is the number of ConfigMap updates which fail with a conflict before the next one succeeds
+		conflicts int
+		// failure, if not nil, is returned for all ConfigMap updates
+		failure error
+	)
+
+	// metrics returns the current values of the write and conflict counters of the ConfigMap state display
+	metrics := func() [2]float64 {
+		return [2]float64{testutil.ToFloat64(writeCounter.WithLabelValues(csd.Type())), testutil.ToFloat64(writeConflictCounter.WithLabelValues(csd.Type()))}
+	}
+
+	BeforeEach(func() {
+		conflicts = 0
+		failure = nil
+		cl := interceptor.NewClient(fake.NewClientBuilder().Build(), interceptor.Funcs{
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				if failure != nil {
+					return failure
+				}
+				if conflicts > 0 {
+					conflicts--
+					return apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, obj.GetName(), fmt.Errorf("injected conflict"))
+				}
+				return c.Update(ctx, obj, opts...)
+			},
+		})
+		csd = NewConfigMapStateDisplay(cl, "k8syncer-state", "", STATE_VERBOSITY_PHASE)
+		obj = &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "Secret"})
+		obj.SetName("foo")
+		obj.SetNamespace("bar")
+	})
+
+	write := func(phase Phase) error {
+		_, err := csd.Write(obj, &SyncState{Verbosity: STATE_VERBOSITY_PHASE, Phase: phase}, STATE_FIELD_PHASE)
+		return err
+	}
+
+	It("should count the writes of the ConfigMap state display", func() {
+		before := metrics()
+		Expect(write(PHASE_PROGRESSING)).To(Succeed())
+		Expect(metrics()).To(Equal([2]float64{before[0] + 1, before[1]}))
+
+		By("not writing unchanged state")
+		Expect(write(PHASE_PROGRESSING)).To(Succeed())
+		Expect(metrics()).To(Equal([2]float64{before[0] + 1, before[1]}))
+
+		cm := &corev1.ConfigMap{}
+		Expect(csd.client.Get(context.Background(), client.ObjectKey{Namespace: "bar", Name: "k8syncer-state"}, cm)).To(Succeed())
+		Expect(cm.Data).To(HaveKey(ConfigMapStateKey(obj)))
+	})
+
+	It("should count retried conflicts", func() {
+		Expect(write(PHASE_PROGRESSING)).To(Succeed())
+		before := metrics()
+		conflicts = 2
+		Expect(write(PHASE_FINISHED)).To(Succeed())
+		Expect(metrics()).To(Equal([2]float64{before[0] + 3, before[1] + 2}))
+	})
+
+	It("should not count the last conflict, which is not retried", func() {
+		Expect(write(PHASE_PROGRESSING)).To(Succeed())
+		before := metrics()
+		conflicts = retry.DefaultRetry.Steps
+		Expect(write(PHASE_FINISHED)).To(MatchError(ContainSubstring("injected conflict")))
+		Expect(metrics()).To(Equal([2]float64{before[0] + float64(retry.DefaultRetry.Steps), before[1] + float64(retry.DefaultRetry.Steps-1)}))
+	})
+
+	It("should not retry other errors", func() {
+		Expect(write(PHASE_PROGRESSING)).To(Succeed())
+		before := metrics()
+		failure = apierrors.NewInternalError(fmt.Errorf("injected failure"))
+		Expect(write(PHASE_FINISHED)).To(MatchError(ContainSubstring("injected failure")))
+		Expect(metrics()).To(Equal([2]float64{before[0] + 1, before[1]}))
+	})
+
+})
@@ -5,7 +5,9 @@
 package state
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -104,13 +106,27 @@ type StateDisplay interface {
 	Verbosity() StateVerbosity
 }
 
+// StateRemover can optionally be implemented by state displays which store the state outside of the object.
+// It is used to clean up the state of objects which have been deleted.
+type StateRemover interface {
+	// Remove removes the state of the given object.
+	Remove(obj client.Object) error
+}
+
 // IsSynced returns whether the last version of the object has been synced.
 // If the object's state has already been read, it can be passed as argument. Otherwise (if the given SyncState is nil),
 // the given StateDisplay's Read method will be used to read the state from the object.
 // It then returns state.LastSyncedGeneration == obj.Generation().
+// Use IsSyncedWithChangeDetection for sync configs which use a change detection mode other than 'generation'.
 //
 // Either the StateDisplay or the SyncState must be non-nil.
 func IsSynced(obj client.Object, sd StateDisplay, state *SyncState) (bool, error) {
+	return IsSyncedWithChangeDetection(obj, sd, state, config.CHANGE_DETECTION_GENERATION)
+}
+
+// IsSyncedWithChangeDetection works like IsSynced, but compares the last synced generation from the state
+// to the object's observed version for the given change detection mode, see ObservedVersion.
+func IsSyncedWithChangeDetection(obj client.Object, sd StateDisplay, state *SyncState, mode config.ChangeDetectionMode) (bool, error) {
 	if obj == nil {
 		return false, fmt.Errorf("object must not be nil")
 	}
@@ -121,7 +137,11 @@ func IsSynced(obj client.Object, sd StateDisplay, state *SyncState) (bool, error
 			return false, err
 		}
 	}
-	synced := state.LastSyncedGeneration == obj.GetGeneration()
+	observed, err := ObservedVersion(obj, mode)
+	if err != nil {
+		return false, err
+	}
+	synced := state.LastSyncedGeneration == observed
 	return synced, nil
 }
 
@@ -154,6 +174,10 @@ type StateField struct {
 	get func(state *SyncState) any
 	// serialize returns the value transformed into one of [bool, int64, float64, string]
 	serialize func(value any) any
+	// deserialize converts a value read from an object into the field's type, it is the inverse of serialize
+	// as numbers may have a different type after decoding, e.g. float64 instead of int64, all integral numbers are accepted
+	// it returns false if the value cannot be converted
+	deserialize func(value any) (any, bool)
 	// hasCorrectType returns true if the given value's type matches the field's type
 	hasCorrectType func(value any) bool
 	// name returns the name of the field as string
@@ -178,6 +202,9 @@ var (
 		serialize: func(value any) any {
 			return value
 		},
+		deserialize: func(value any) (any, bool) {
+			return toInt64(value)
+		},
 		hasCorrectType: func(value any) bool {
 			_, ok := value.(int64)
 			return ok
@@ -196,6 +223,15 @@ var (
 		serialize: func(value any) any {
 			return string(value.(Phase))
 		},
+		deserialize: func(value any) (any, bool) {
+			switch v := value.(type) {
+			case Phase:
+				return v, true
+			case string:
+				return PhaseFromString(v), true
+			}
+			return nil, false
+		},
 		hasCorrectType: func(value any) bool {
 			_, ok := value.(Phase)
 			return ok
@@ -214,6 +250,10 @@ var (
 		serialize: func(value any) any {
 			return value
 		},
+		deserialize: func(value any) (any, bool) {
+			v, ok := value.(string)
+			return v, ok
+		},
 		hasCorrectType: func(value any) bool {
 			_, ok := value.(string)
 			return ok
@@ -225,6 +265,30 @@ var (
 	ALL_STATE_FIELDS = []*StateField{STATE_FIELD_LAST_SYNCED_GENERATION, STATE_FIELD_PHASE, STATE_FIELD_DETAIL}
 )
 
+// toInt64 converts the given number into an int64.
+// Depending on how an object has been decoded, integers are represented as int64, float64, or json.Number.
+// It returns false if the value is not a number or not integral.
+func toInt64(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case float64:
+		// float64(math.MaxInt64) is rounded up to 2^63, which doesn't fit into an int64
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	}
+	return 0, false
+}
+
 // ObjectToUnstructured converts a client.Object into an *unstructured.Unstructured.
 func ObjectToUnstructured(obj client.Object) (*unstructured.Unstructured, StateError) {
 	if converted, ok := obj.(*unstructured.Unstructured); ok {
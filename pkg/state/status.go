@@ -67,7 +67,11 @@ func (ssd *StatusStateDisplay) Read(rawObj client.Object) (*SyncState, StateErro
 		if !exists {
 			return nil, DefaultMissingStateError(ssd.verbosity, field)
 		}
-		err2 := state.SetField(field, value)
+		converted, ok := field.deserialize(value)
+		if !ok {
+			return nil, DefaultInvalidStateError(field, value, nil)
+		}
+		err2 := state.SetField(field, converted)
 		if err2 != nil {
 			return nil, err2
 		}
@@ -105,6 +109,10 @@ func (ssd *StatusStateDisplay) Write(rawObj client.Object, state *SyncState, fie
 		newValue := field.serialize(state.GetField(field))
 		if found {
 			// the object already has a value for this state field
+			// it is normalized before comparing, as numbers read from the cluster may be float64 instead of int64
+			if converted, ok := field.deserialize(oldValue); ok {
+				oldValue = field.serialize(converted)
+			}
 			if reflect.DeepEqual(newValue, oldValue) {
 				// value currently stored in the object is the same as we would write, no need to try it
 				continue
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package state
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Status State Display", func() {
+
+	var ssd *StatusStateDisplay
+
+	BeforeEach(func() {
+		ssd = NewStatusStateDisplay("syncStatus.lastSyncedGeneration", "syncStatus.phase", "syncStatus.detail", STATE_VERBOSITY_DETAIL)
+	})
+
+	// objectWithStatus returns an object with the given status, as if it had been decoded from the given JSON by the encoding/json package
+	objectWithStatus := func(status string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]any{}}
+		Expect(json.Unmarshal([]byte(`{"status": `+status+`}`), &obj.Object)).To(Succeed())
+		return obj
+	}
+
+	generation := func(obj *unstructured.Unstructured) int64 {
+		gen, found, err := unstructured.NestedInt64(obj.Object, "status", "syncStatus", "lastSyncedGeneration")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		return gen
+	}
+
+	It("should read and write the state", func() {
+		obj := &unstructured.Unstructured{Object: map[string]any{}}
+		s := &SyncState{Verbosity: STATE_VERBOSITY_DETAIL, LastSyncedGeneration: 3, Phase: PHASE_FINISHED, Detail: "synced"}
+		changed, err := ssd.Write(obj, s, ALL_STATE_FIELDS...)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(HaveKey("status"))
+		Expect(generation(obj)).To(Equal(int64(3)))
+
+		read, serr := ssd.Read(obj)
+		Expect(serr).ToNot(HaveOccurred())
+		Expect(read.LastSyncedGeneration).To(Equal(int64(3)))
+		Expect(read.Phase).To(Equal(PHASE_FINISHED))
+		Expect(read.Detail).To(Equal("synced"))
+	})
+
+	It("should treat numbers which have been decoded as float64 like int64", func() {
+		obj := objectWithStatus(`{"syncStatus": {"lastSyncedGeneration": 5, "phase": "Finished", "detail": ""}}`)
+		Expect(obj.Object["status"].(map[string]any)["syncStatus"].(map[string]any)["lastSyncedGeneration"]).To(BeAssignableToTypeOf(float64(0)))
+
+		read, serr := ssd.Read(obj)
+		Expect(serr).ToNot(HaveOccurred())
+		Expect(read.LastSyncedGeneration).To(Equal(int64(5)))
+
+		By("not detecting a change if the value is equal")
+		s := &SyncState{Verbosity: STATE_VERBOSITY_DETAIL, LastSyncedGeneration: 5, Phase: PHASE_FINISHED}
+		changed, err := ssd.Write(obj, s, ALL_STATE_FIELDS...)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeEmpty())
+
+		By("detecting a change if the value differs")
+		s.LastSyncedGeneration = 6
+		changed, err = ssd.Write(obj, s, STATE_FIELD_LAST_SYNCED_GENERATION)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(HaveKey("status"))
+		Expect(generation(obj)).To(Equal(int64(6)))
+	})
+
+	It("should reject non-integral generations", func() {
+		obj := objectWithStatus(`{"syncStatus": {"lastSyncedGeneration": 5.5, "phase": "Finished", "detail": ""}}`)
+		_, serr := ssd.Read(obj)
+		Expect(serr).To(HaveOccurred())
+
+		By("overwriting them")
+		s := &SyncState{Verbosity: STATE_VERBOSITY_DETAIL, LastSyncedGeneration: 5, Phase: PHASE_FINISHED}
+		changed, err := ssd.Write(obj, s, STATE_FIELD_LAST_SYNCED_GENERATION)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(HaveKey("status"))
+	})
+
+	It("should convert integral numbers into int64", func() {
+		for _, v := range []any{int64(7), 7, int32(7), float64(7), json.Number("7")} {
+			i, ok := toInt64(v)
+			Expect(ok).To(BeTrue(), "%v", v)
+			Expect(i).To(Equal(int64(7)))
+		}
+		for _, v := range []any{7.1, float64(1 << 63), json.Number("7.1"), "7", nil} {
+			_, ok := toInt64(v)
+			Expect(ok).To(BeFalse(), "%v", v)
+		}
+	})
+
+})
@@ -0,0 +1,278 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package syncerrors
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils"
+)
+
+const (
+	// DebugEndpointPath is the path under which the error cache is served, if registered at the metrics server.
+	DebugEndpointPath = "/debug/sync-errors"
+
+	// OtherNamespacesLabelValue is the value of the namespace label of the sync error metric for objects in namespaces which exceed the maximum number of namespaces.
+	OtherNamespacesLabelValue = "_other"
+)
+
+// ErrorEntry describes the last error which occurred while syncing a specific object.
+type ErrorEntry struct {
+	// SyncConfigID is the ID of the sync config which the failed reconciliation belongs to.
+	SyncConfigID string `json:"syncConfigID"`
+	// GVK is the GroupVersionKind of the object, in the format '<kind>.<version>.<group>' (lowercase kind).
+	GVK string `json:"gvk"`
+	// Namespace is the namespace of the object, empty for cluster-scoped objects.
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the object.
+	Name string `json:"name"`
+	// Error is the message of the last error.
+	Error string `json:"error"`
+	// Count is the number of consecutive failed reconciliations.
+	Count int `json:"count"`
+	// LastOccurrence is the time of the last failed reconciliation.
+	LastOccurrence time.Time `json:"lastOccurrence"`
+}
+
+type entryKey struct {
+	syncConfigID string
+	gvk          string
+	namespace    string
+	name         string
+}
+
+// Cache keeps track of the last error per object.
+// Entries are removed as soon as the object has been synced successfully.
+// All methods are safe for concurrent use and can be called on a nil Cache, which does nothing.
+type Cache struct {
+	lock    sync.RWMutex
+	entries map[entryKey]*ErrorEntry
+
+	// metric configuration, see config.SyncErrorMetricsConfiguration
+	desc          *prometheus.Desc
+	labels        []config.SyncErrorMetricLabel
+	aggregation   config.MetricAggregation
+	maxNamespaces int
+}
+
+// NewCache creates a new, empty Cache.
+// The metric configuration is expected to be completed. If it is nil, the metric has one series per object.
+func NewCache(metricsCfg *config.SyncErrorMetricsConfiguration) *Cache {
+	c := &Cache{
+		entries:     map[entryKey]*ErrorEntry{},
+		labels:      config.ALL_SYNC_ERROR_METRIC_LABELS,
+		aggregation: config.METRIC_AGGREGATION_MAX,
+	}
+	if metricsCfg != nil {
+		c.labels = metricsCfg.Labels
+		c.aggregation = metricsCfg.Aggregation
+		c.maxNamespaces = metricsCfg.MaxNamespaces
+	}
+	labelNames := make([]string, len(c.labels))
+	for i, l := range c.labels {
+		labelNames[i] = string(l)
+	}
+	c.desc = prometheus.NewDesc("k8syncer_sync_errors", "Number of consecutive failed sync attempts per object, only contains objects whose last sync failed. "+
+		"Depending on the configuration, objects which don't differ in the exposed labels are aggregated into one series.", labelNames, nil)
+	return c
+}
+
+// Collector returns the prometheus collector for the metric which represents the cache content.
+// It has to be registered at a prometheus registry in order to be exposed.
+func (c *Cache) Collector() prometheus.Collector {
+	return &cacheCollector{cache: c}
+}
+
+// Record stores the given error for the specified object.
+// If err is nil, the entry for the object is removed instead.
+func (c *Cache) Record(syncConfigID string, gvk schema.GroupVersionKind, namespace, name string, err error) {
+	if c == nil {
+		return
+	}
+	if err == nil {
+		c.Clear(syncConfigID, gvk, namespace, name)
+		return
+	}
+	key := newEntryKey(syncConfigID, gvk, namespace, name)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		e = &ErrorEntry{
+			SyncConfigID: key.syncConfigID,
+			GVK:          key.gvk,
+			Namespace:    key.namespace,
+			Name:         key.name,
+		}
+		c.entries[key] = e
+	}
+	e.Error = err.Error()
+	e.Count++
+	e.LastOccurrence = time.Now()
+}
+
+// Clear removes the entry for the specified object, if any.
+func (c *Cache) Clear(syncConfigID string, gvk schema.GroupVersionKind, namespace, name string) {
+	if c == nil {
+		return
+	}
+	key := newEntryKey(syncConfigID, gvk, namespace, name)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.entries, key)
+}
+
+// List returns copies of all entries, sorted by sync config ID, GVK, namespace, and name.
+func (c *Cache) List() []ErrorEntry {
+	if c == nil {
+		return nil
+	}
+	c.lock.RLock()
+	res := make([]ErrorEntry, 0, len(c.entries))
+	for _, e := range c.entries {
+		res = append(res, *e)
+	}
+	c.lock.RUnlock()
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.SyncConfigID != b.SyncConfigID {
+			return a.SyncConfigID < b.SyncConfigID
+		}
+		if a.GVK != b.GVK {
+			return a.GVK < b.GVK
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return res
+}
+
+// ServeHTTP returns the list of entries as JSON.
+func (c *Cache) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	data, err := json.Marshal(c.List())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+func newEntryKey(syncConfigID string, gvk schema.GroupVersionKind, namespace, name string) entryKey {
+	return entryKey{
+		syncConfigID: syncConfigID,
+		gvk:          utils.GVKToString(gvk, true),
+		namespace:    namespace,
+		name:         name,
+	}
+}
+
+// labelValue returns the value of the given metric label for the entry.
+func (k entryKey) labelValue(label config.SyncErrorMetricLabel) string {
+	switch label {
+	case config.SYNC_ERROR_METRIC_LABEL_SYNC_CONFIG:
+		return k.syncConfigID
+	case config.SYNC_ERROR_METRIC_LABEL_GVK:
+		return k.gvk
+	case config.SYNC_ERROR_METRIC_LABEL_NAMESPACE:
+		return k.namespace
+	case config.SYNC_ERROR_METRIC_LABEL_NAME:
+		return k.name
+	}
+	return ""
+}
+
+// cacheCollector exposes the cache content as metric.
+// The series are computed from the entries on each scrape, so that the aggregation always reflects the current content.
+type cacheCollector struct {
+	cache *Cache
+}
+
+var _ prometheus.Collector = &cacheCollector{}
+
+func (cc *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.cache.desc
+}
+
+func (cc *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	c := cc.cache
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	type series struct {
+		labelValues []string
+		value       float64
+	}
+	aggregated := c.aggregatedNamespaces()
+	allSeries := map[string]*series{}
+	for key, e := range c.entries {
+		if aggregated.Has(key.namespace) {
+			key.namespace = OtherNamespacesLabelValue
+		}
+		labelValues := make([]string, len(c.labels))
+		for i, l := range c.labels {
+			labelValues[i] = key.labelValue(l)
+		}
+		id := strings.Join(labelValues, "\x00")
+		s, ok := allSeries[id]
+		if !ok {
+			s = &series{labelValues: labelValues}
+			allSeries[id] = s
+		}
+		switch c.aggregation {
+		case config.METRIC_AGGREGATION_SUM:
+			s.value += float64(e.Count)
+		case config.METRIC_AGGREGATION_COUNT:
+			s.value++
+		default:
+			s.value = max(s.value, float64(e.Count))
+		}
+	}
+	for _, s := range allSeries {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, s.value, s.labelValues...)
+	}
+}
+
+// aggregatedNamespaces returns the namespaces which don't get their own value for the namespace label, because the maximum number of namespaces is exceeded.
+// The namespaces with the most entries get their own value, ties are resolved alphabetically. Cluster-scoped objects don't count as a namespace.
+// The lock has to be held by the caller.
+func (c *Cache) aggregatedNamespaces() sets.Set[string] {
+	res := sets.New[string]()
+	if c.maxNamespaces <= 0 {
+		return res
+	}
+	counts := map[string]int{}
+	for key := range c.entries {
+		if key.namespace != "" {
+			counts[key.namespace]++
+		}
+	}
+	if len(counts) <= c.maxNamespaces {
+		return res
+	}
+	namespaces := make([]string, 0, len(counts))
+	for ns := range counts {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Slice(namespaces, func(i, j int) bool {
+		if counts[namespaces[i]] != counts[namespaces[j]] {
+			return counts[namespaces[i]] > counts[namespaces[j]]
+		}
+		return namespaces[i] < namespaces[j]
+	})
+	return res.Insert(namespaces[c.maxNamespaces:]...)
+}
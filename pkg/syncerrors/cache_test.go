@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package syncerrors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+var _ = Describe("Error Cache", func() {
+
+	gvk := schema.GroupVersionKind{Group: "", Version: "v1", Kind: "ConfigMap"}
+
+	It("should record, count, and clear errors per object", func() {
+		c := NewCache(nil)
+		c.Record("foo", gvk, "default", "a", fmt.Errorf("first"))
+		c.Record("foo", gvk, "default", "a", fmt.Errorf("second"))
+		c.Record("foo", gvk, "default", "b", fmt.Errorf("other"))
+		c.Record("foo", gvk, "default", "c", nil)
+
+		entries := c.List()
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Name).To(Equal("a"))
+		Expect(entries[0].GVK).To(Equal("configmap.v1"))
+		Expect(entries[0].Error).To(Equal("second"))
+		Expect(entries[0].Count).To(Equal(2))
+		Expect(entries[1].Name).To(Equal("b"))
+		Expect(entries[1].Count).To(Equal(1))
+		Expect(testutil.CollectAndCount(c.Collector())).To(Equal(2))
+
+		// successful sync removes the entry
+		c.Record("foo", gvk, "default", "a", nil)
+		entries = c.List()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Name).To(Equal("b"))
+		Expect(testutil.CollectAndCount(c.Collector())).To(Equal(1))
+	})
+
+	It("should serve the entries as JSON", func() {
+		c := NewCache(nil)
+		c.Record("foo", gvk, "default", "a", fmt.Errorf("error"))
+
+		rec := httptest.NewRecorder()
+		c.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DebugEndpointPath, nil))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		entries := []ErrorEntry{}
+		Expect(json.Unmarshal(rec.Body.Bytes(), &entries)).To(Succeed())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].SyncConfigID).To(Equal("foo"))
+		Expect(entries[0].Namespace).To(Equal("default"))
+		Expect(entries[0].Error).To(Equal("error"))
+	})
+
+	It("should aggregate the objects which don't differ in the configured labels", func() {
+		c := NewCache(&config.SyncErrorMetricsConfiguration{
+			Labels:      []config.SyncErrorMetricLabel{config.SYNC_ERROR_METRIC_LABEL_SYNC_CONFIG, config.SYNC_ERROR_METRIC_LABEL_NAMESPACE},
+			Aggregation: config.METRIC_AGGREGATION_SUM,
+		})
+		c.Record("foo", gvk, "default", "a", fmt.Errorf("first"))
+		c.Record("foo", gvk, "default", "a", fmt.Errorf("second"))
+		c.Record("foo", gvk, "default", "b", fmt.Errorf("other"))
+		c.Record("foo", gvk, "kube-system", "c", fmt.Errorf("other"))
+
+		Expect(testutil.CollectAndCompare(c.Collector(), strings.NewReader(`
+# HELP k8syncer_sync_errors Number of consecutive failed sync attempts per object, only contains objects whose last sync failed. Depending on the configuration, objects which don't differ in the exposed labels are aggregated into one series.
+# TYPE k8syncer_sync_errors gauge
+k8syncer_sync_errors{namespace="default",sync_config="foo"} 3
+k8syncer_sync_errors{namespace="kube-system",sync_config="foo"} 1
+`))).To(Succeed())
+	})
+
+	It("should aggregate the namespaces which exceed the maximum number of namespaces", func() {
+		c := NewCache(&config.SyncErrorMetricsConfiguration{
+			Labels:        []config.SyncErrorMetricLabel{config.SYNC_ERROR_METRIC_LABEL_NAMESPACE},
+			Aggregation:   config.METRIC_AGGREGATION_COUNT,
+			MaxNamespaces: 2,
+		})
+		c.Record("foo", gvk, "a", "a1", fmt.Errorf("error"))
+		c.Record("foo", gvk, "b", "b1", fmt.Errorf("error"))
+		c.Record("foo", gvk, "b", "b2", fmt.Errorf("error"))
+		c.Record("foo", gvk, "c", "c1", fmt.Errorf("error"))
+		c.Record("foo", gvk, "d", "d1", fmt.Errorf("error"))
+		c.Record("foo", gvk, "", "cluster-scoped", fmt.Errorf("error"))
+
+		Expect(testutil.CollectAndCompare(c.Collector(), strings.NewReader(`
+# HELP k8syncer_sync_errors Number of consecutive failed sync attempts per object, only contains objects whose last sync failed. Depending on the configuration, objects which don't differ in the exposed labels are aggregated into one series.
+# TYPE k8syncer_sync_errors gauge
+k8syncer_sync_errors{namespace=""} 1
+k8syncer_sync_errors{namespace="a"} 1
+k8syncer_sync_errors{namespace="b"} 2
+k8syncer_sync_errors{namespace="_other"} 2
+`))).To(Succeed())
+
+		// once the errors in a namespace are gone, the next namespace gets its own label value
+		c.Record("foo", gvk, "a", "a1", nil)
+		Expect(testutil.CollectAndCompare(c.Collector(), strings.NewReader(`
+# HELP k8syncer_sync_errors Number of consecutive failed sync attempts per object, only contains objects whose last sync failed. Depending on the configuration, objects which don't differ in the exposed labels are aggregated into one series.
+# TYPE k8syncer_sync_errors gauge
+k8syncer_sync_errors{namespace=""} 1
+k8syncer_sync_errors{namespace="b"} 2
+k8syncer_sync_errors{namespace="c"} 1
+k8syncer_sync_errors{namespace="_other"} 1
+`))).To(Succeed())
+	})
+
+	It("should not fail on a nil cache", func() {
+		var c *Cache
+		c.Record("foo", gvk, "default", "a", fmt.Errorf("error"))
+		Expect(c.List()).To(BeEmpty())
+	})
+
+})
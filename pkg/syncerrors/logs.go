@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package syncerrors
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// LogDeduplicator collapses error logs which are repeated for the same object, e.g. because a storage is down.
+// An error is logged when it occurs for the first time. Identical errors for the same object within the repeat interval
+// are only counted and reported by the first log entry for the error after the interval has passed.
+// When the object has been synced successfully again, a summary of the failed attempts is logged.
+// All methods are safe for concurrent use and can be called on a nil LogDeduplicator, which doesn't deduplicate anything.
+type LogDeduplicator struct {
+	interval time.Duration
+	// now returns the current time, can be overwritten for testing purposes.
+	now func() time.Time
+
+	lock    sync.Mutex
+	objects map[types.NamespacedName]*objectErrors
+}
+
+// objectErrors tracks the failed syncs of an object since its last successful sync.
+type objectErrors struct {
+	failedAttempts int
+	firstFailure   time.Time
+	suppressed     int
+	// errors maps the message and error of each logged error to its repetitions.
+	errors map[string]*repeatedError
+}
+
+type repeatedError struct {
+	// lastLogged is the time at which the error has been logged the last time.
+	lastLogged time.Time
+	// repetitions is the number of occurrences which have not been logged since then.
+	repetitions int
+}
+
+// NewLogDeduplicator creates a new LogDeduplicator with the given repeat interval.
+// If the interval is not positive, nil is returned, so that every error is logged.
+func NewLogDeduplicator(interval time.Duration) *LogDeduplicator {
+	if interval <= 0 {
+		return nil
+	}
+	return &LogDeduplicator{
+		interval: interval,
+		now:      time.Now,
+		objects:  map[types.NamespacedName]*objectErrors{},
+	}
+}
+
+// Logger returns a logger for syncing the specified object, which deduplicates the errors logged via it.
+func (d *LogDeduplicator) Logger(log logging.Logger, namespace, name string) logging.Logger {
+	if d == nil {
+		return log
+	}
+	// the call depth accounts for the additional frame of the deduplicating sink
+	base := log.Logr().WithCallDepth(1)
+	return logging.Wrap(logr.New(&deduplicatingSink{
+		LogSink: base.GetSink(),
+		d:       d,
+		key:     types.NamespacedName{Namespace: namespace, Name: name},
+	}))
+}
+
+// Done must be called after each sync of the specified object, with the error returned by the sync.
+// If the sync succeeded after failed attempts, a summary is logged and the tracked errors of the object are forgotten.
+func (d *LogDeduplicator) Done(log logging.Logger, namespace, name string, err error) {
+	if d == nil {
+		return
+	}
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	oe, ok := d.objects[key]
+	if err != nil {
+		if !ok {
+			oe = &objectErrors{
+				firstFailure: d.now(),
+				errors:       map[string]*repeatedError{},
+			}
+			d.objects[key] = oe
+		}
+		oe.failedAttempts++
+		return
+	}
+	if !ok {
+		return
+	}
+	delete(d.objects, key)
+	if oe.failedAttempts == 0 {
+		return
+	}
+	log.Info(fmt.Sprintf("Resource synced successfully after %d failed attempts in %s", oe.failedAttempts, d.now().Sub(oe.firstFailure).Round(time.Second).String()),
+		constants.Logging.KEY_FAILED_COUNT, oe.failedAttempts, constants.Logging.KEY_SUPPRESSED_COUNT, oe.suppressed)
+}
+
+// allow returns whether the given error for the specified object should be logged.
+// If so, the returned key-value pairs describe the repetitions of the error which have not been logged.
+func (d *LogDeduplicator) allow(key types.NamespacedName, msg string, err error) (bool, []any) {
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+	errKey := msg + "\x00" + errMsg
+	now := d.now()
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	oe, ok := d.objects[key]
+	if !ok {
+		oe = &objectErrors{
+			firstFailure: now,
+			errors:       map[string]*repeatedError{},
+		}
+		d.objects[key] = oe
+	}
+	re, ok := oe.errors[errKey]
+	if !ok {
+		oe.errors[errKey] = &repeatedError{lastLogged: now}
+		return true, nil
+	}
+	if now.Sub(re.lastLogged) < d.interval {
+		re.repetitions++
+		oe.suppressed++
+		return false, nil
+	}
+	var kv []any
+	if re.repetitions > 0 {
+		kv = []any{constants.Logging.KEY_REPEATED, fmt.Sprintf("error repeated %d times in last %s", re.repetitions, now.Sub(re.lastLogged).Round(time.Second).String())}
+	}
+	re.lastLogged = now
+	re.repetitions = 0
+	return true, kv
+}
+
+// deduplicatingSink drops error logs which are suppressed by the LogDeduplicator.
+type deduplicatingSink struct {
+	logr.LogSink
+	d   *LogDeduplicator
+	key types.NamespacedName
+}
+
+// Init does nothing, as the wrapped sink has been initialized already.
+func (s *deduplicatingSink) Init(logr.RuntimeInfo) {}
+
+func (s *deduplicatingSink) Info(level int, msg string, keysAndValues ...any) {
+	s.LogSink.Info(level, msg, keysAndValues...)
+}
+
+func (s *deduplicatingSink) Error(err error, msg string, keysAndValues ...any) {
+	ok, kv := s.d.allow(s.key, msg, err)
+	if !ok {
+		return
+	}
+	s.LogSink.Error(err, msg, append(keysAndValues, kv...)...)
+}
+
+func (s *deduplicatingSink) WithValues(keysAndValues ...any) logr.LogSink {
+	return &deduplicatingSink{
+		LogSink: s.LogSink.WithValues(keysAndValues...),
+		d:       s.d,
+		key:     s.key,
+	}
+}
+
+func (s *deduplicatingSink) WithName(name string) logr.LogSink {
+	return &deduplicatingSink{
+		LogSink: s.LogSink.WithName(name),
+		d:       s.d,
+		key:     s.key,
+	}
+}
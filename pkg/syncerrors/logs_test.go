@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package syncerrors
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Log Deduplicator", func() {
+
+	var (
+		lines []string
+		log   logging.Logger
+		now   time.Time
+		d     *LogDeduplicator
+	)
+
+	BeforeEach(func() {
+		lines = nil
+		log = logging.Wrap(funcr.New(func(_, args string) {
+			lines = append(lines, args)
+		}, funcr.Options{}))
+		now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		d = NewLogDeduplicator(10 * time.Minute)
+		d.now = func() time.Time { return now }
+	})
+
+	It("should collapse repeated errors and summarize them on recovery", func() {
+		objLog := d.Logger(log, "default", "a").WithValues("reconcileID", "1")
+		storageDown := fmt.Errorf("storage is down")
+		for i := 0; i < 58; i++ {
+			objLog.Error(storageDown, "error while persisting resource")
+			d.Done(objLog, "default", "a", storageDown)
+			now = now.Add(5 * time.Second)
+		}
+		Expect(lines).To(HaveLen(1))
+		Expect(lines[0]).To(ContainSubstring(`"msg"="error while persisting resource"`))
+
+		// other errors and other objects are logged independently
+		objLog.Error(fmt.Errorf("other"), "error while persisting resource")
+		d.Logger(log, "default", "b").Error(storageDown, "error while persisting resource")
+		Expect(lines).To(HaveLen(3))
+
+		// the next occurrence after the interval reports the repetitions
+		now = now.Add(6 * time.Minute)
+		objLog.Error(storageDown, "error while persisting resource")
+		Expect(lines).To(HaveLen(4))
+		Expect(lines[3]).To(ContainSubstring(`"repeated"="error repeated 57 times in last 10m`))
+
+		// info logs are not affected
+		objLog.Info("Starting reconcile")
+		objLog.Info("Starting reconcile")
+		Expect(lines).To(HaveLen(6))
+
+		d.Done(objLog, "default", "a", nil)
+		Expect(lines).To(HaveLen(7))
+		Expect(lines[6]).To(ContainSubstring("Resource synced successfully after 58 failed attempts in 10m"))
+		Expect(lines[6]).To(ContainSubstring(`"suppressedCount"=57`))
+
+		// the history is forgotten after a successful sync
+		objLog.Error(storageDown, "error while persisting resource")
+		Expect(lines).To(HaveLen(8))
+		d.Done(objLog, "default", "a", nil)
+		Expect(lines).To(HaveLen(8))
+	})
+
+	It("should log every error if the interval is not positive", func() {
+		d := NewLogDeduplicator(0)
+		Expect(d).To(BeNil())
+		objLog := d.Logger(log, "default", "a")
+		objLog.Error(fmt.Errorf("error"), "error while persisting resource")
+		objLog.Error(fmt.Errorf("error"), "error while persisting resource")
+		d.Done(objLog, "default", "a", nil)
+		Expect(lines).To(HaveLen(2))
+	})
+
+})
@@ -44,6 +44,41 @@ var Logging = struct {
 	KEY_STATE_VERBOSITY             string
 	KEY_CONFIGURED_STORAGES         string
 	KEY_RESOURCE_IN_STORAGE_CHANGED string
+	KEY_IMPERSONATED_USER           string
+	KEY_FILE_NAMING                 string
+	KEY_RECHECK_INTERVAL            string
+	KEY_ARCHIVE_PATH                string
+	KEY_SYNCED_COUNT                string
+	KEY_FAILED_COUNT                string
+	KEY_LAST_PROCESSED              string
+	KEY_CHECKPOINT                  string
+	KEY_PERMISSION                  string
+	KEY_ALLOWED                     string
+	KEY_CLUSTER                     string
+	KEY_OWNER                       string
+	KEY_BRANCH                      string
+	KEY_DELETED_COUNT               string
+	KEY_READ_ONLY_SOURCE            string
+	KEY_FILE_COUNT                  string
+	KEY_CHUNK                       string
+	KEY_QUARANTINE_PATH             string
+	KEY_INCLUDED_RESOURCE           string
+	KEY_CONFLICT_POLICY             string
+	KEY_RESUME_AT                   string
+	KEY_SYNC_CONFIG_ID              string
+	KEY_CURRENT_OWNER               string
+	KEY_WRITER                      string
+	KEY_PREVIOUS_WRITER             string
+	KEY_COMMIT_GROUP                string
+	KEY_REPOSITORY                  string
+	KEY_EXPIRES_AT                  string
+	KEY_REPEATED                    string
+	KEY_SUPPRESSED_COUNT            string
+	KEY_CONFIGMAP                   string
+	KEY_USER                        string
+	KEY_UID                         string
+	KEY_PERSISTED_UID               string
+	KEY_SUMMARY                     string
 }{
 	CALL_EXISTS_MSG:                "Call to Exists",
 	CALL_GET_MSG:                   "Call to Get",
@@ -81,6 +116,41 @@ var Logging = struct {
 	KEY_STATE_VERBOSITY:             "stateVerbosity",
 	KEY_CONFIGURED_STORAGES:         "configuredStorages",
 	KEY_RESOURCE_IN_STORAGE_CHANGED: "storageChanged",
+	KEY_IMPERSONATED_USER:           "impersonatedUser",
+	KEY_FILE_NAMING:                 "fileNaming",
+	KEY_RECHECK_INTERVAL:            "recheckInterval",
+	KEY_ARCHIVE_PATH:                "archivePath",
+	KEY_SYNCED_COUNT:                "synced",
+	KEY_FAILED_COUNT:                "failed",
+	KEY_LAST_PROCESSED:              "lastProcessed",
+	KEY_CHECKPOINT:                  "checkpoint",
+	KEY_PERMISSION:                  "permission",
+	KEY_ALLOWED:                     "allowed",
+	KEY_CLUSTER:                     "cluster",
+	KEY_OWNER:                       "owner",
+	KEY_BRANCH:                      "branch",
+	KEY_DELETED_COUNT:               "deletedCount",
+	KEY_READ_ONLY_SOURCE:            "readOnlySource",
+	KEY_FILE_COUNT:                  "fileCount",
+	KEY_CHUNK:                       "chunk",
+	KEY_QUARANTINE_PATH:             "quarantinePath",
+	KEY_INCLUDED_RESOURCE:           "includedResource",
+	KEY_CONFLICT_POLICY:             "conflictPolicy",
+	KEY_RESUME_AT:                   "resumeAt",
+	KEY_SYNC_CONFIG_ID:              "syncConfigID",
+	KEY_CURRENT_OWNER:               "currentOwner",
+	KEY_WRITER:                      "writer",
+	KEY_PREVIOUS_WRITER:             "previousWriter",
+	KEY_COMMIT_GROUP:                "commitGroup",
+	KEY_REPOSITORY:                  "repository",
+	KEY_EXPIRES_AT:                  "expiresAt",
+	KEY_REPEATED:                    "repeated",
+	KEY_SUPPRESSED_COUNT:            "suppressedCount",
+	KEY_CONFIGMAP:                   "configMap",
+	KEY_USER:                        "user",
+	KEY_UID:                         "uid",
+	KEY_PERSISTED_UID:               "persistedUID",
+	KEY_SUMMARY:                     "summary",
 }
 
 type k8syncerContextKey string
@@ -90,7 +160,29 @@ const (
 	ANNOTATION_LAST_SYNCED_GENERATION = "state." + K8SYNCER_GROUP + "/lastSyncedGeneration"
 	ANNOTATION_PHASE                  = "state." + K8SYNCER_GROUP + "/phase"
 	ANNOTATION_DETAIL                 = "state." + K8SYNCER_GROUP + "/detail"
+	ANNOTATION_CONTENT_HASH           = "state." + K8SYNCER_GROUP + "/contentHash"
 	K8SYNCER_FINALIZER                = "finalizer." + K8SYNCER_GROUP
+	ANNOTATION_CLUSTER_NAME           = K8SYNCER_GROUP + "/clusterName"
+	// ANNOTATION_WRITER_INSTANCE contains the name of the K8Syncer instance which has written a persisted resource.
+	ANNOTATION_WRITER_INSTANCE = K8SYNCER_GROUP + "/writerInstance"
+	// ANNOTATION_WRITER_CONFIG_HASH contains a hash over the configuration of the K8Syncer instance which has written a persisted resource.
+	ANNOTATION_WRITER_CONFIG_HASH = K8SYNCER_GROUP + "/writerConfigHash"
+	// ANNOTATION_INCLUDE contains references to resources which should be persisted together with the annotated resource.
+	ANNOTATION_INCLUDE = K8SYNCER_GROUP + "/include"
+	// ANNOTATION_SUSPEND suspends syncing the annotated resource if set to 'true'.
+	ANNOTATION_SUSPEND = K8SYNCER_GROUP + "/suspend"
+	// LABEL_STORAGE contains the name of the storage which the labeled object belongs to,
+	// for the ConfigMaps or Secrets of kubernetes storages and the replicated resources of cluster storages.
+	LABEL_STORAGE = K8SYNCER_GROUP + "/storage"
+	// ANNOTATION_SOURCE_UID contains the UID of the resource which the annotated resource has been replicated from, for cluster storages.
+	ANNOTATION_SOURCE_UID = K8SYNCER_GROUP + "/sourceUID"
+	// ANNOTATION_STORAGE_PATH contains the path which the data of the annotated ConfigMap or Secret would have in a filesystem storage, for kubernetes storages.
+	ANNOTATION_STORAGE_PATH = K8SYNCER_GROUP + "/storagePath"
+	// STATE_ANNOTATION_PREFIX is the prefix of all annotations which K8Syncer writes on the synced resources by default.
+	STATE_ANNOTATION_PREFIX = STATE_ANNOTATION_DOMAIN + "/"
+	// STATE_ANNOTATION_DOMAIN is the prefix of the state annotation keys, without the trailing '/'.
+	// Custom prefixes for the annotation state display have to be subdomains of it.
+	STATE_ANNOTATION_DOMAIN = "state." + K8SYNCER_GROUP
 
 	CONTEXT_KEY_LOGGING_DATA k8syncerContextKey = "logging_data"
 )
@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+// awsSecretsManagerFetcher reads secret values via the GetSecretValue action of AWS Secrets Manager.
+// The AWS credentials are resolved by the default credential chain of the AWS SDK, which caches and renews temporary credentials.
+type awsSecretsManagerFetcher struct {
+	cfg *config.AWSSecretsManagerSource
+
+	// client is created on first use, it is nil before.
+	client *secretsmanager.Client
+}
+
+func newAWSSecretsManagerFetcher(cfg *config.AWSSecretsManagerSource) *awsSecretsManagerFetcher {
+	return &awsSecretsManagerFetcher{
+		cfg: cfg,
+	}
+}
+
+func (f *awsSecretsManagerFetcher) fetchSecretValue(ctx context.Context, httpClient *http.Client) (string, error) {
+	if f.client == nil {
+		awsCfg, err := awsconfig.LoadDefaultConfig(ctx,
+			awsconfig.WithRegion(f.cfg.Region),
+			// the SDK's client is used with the same timeout, as it supports custom CA bundles via 'AWS_CA_BUNDLE'
+			awsconfig.WithHTTPClient(awshttp.NewBuildableClient().WithTimeout(httpClient.Timeout)),
+			// the SecretValueProvider falls back to the cached password and fetches it again on the next use anyway
+			awsconfig.WithRetryMaxAttempts(1),
+		)
+		if err != nil {
+			return "", fmt.Errorf("error loading AWS configuration: %w", err)
+		}
+		f.client = secretsmanager.NewFromConfig(awsCfg, func(o *secretsmanager.Options) {
+			if f.cfg.Endpoint != "" {
+				o.BaseEndpoint = aws.String(f.cfg.Endpoint)
+			}
+		})
+	}
+
+	res, err := f.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(f.cfg.SecretID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error reading secret '%s': %w", f.cfg.SecretID, err)
+	}
+	value := string(res.SecretBinary)
+	if res.SecretString != nil {
+		value = *res.SecretString
+	}
+	return secretValueForKey(value, f.cfg.Key, f.cfg.SecretID)
+}
+
+// secretValueForKey returns the value for the given key, if the secret value is a JSON object.
+// If the key is empty, the secret value is returned as it is.
+func secretValueForKey(value, key, secretName string) (string, error) {
+	if key == "" {
+		return value, nil
+	}
+	data := map[string]any{}
+	if err := json.Unmarshal([]byte(value), &data); err != nil {
+		return "", fmt.Errorf("secret '%s' is not a JSON object, which is required for reading key '%s'", secretName, key)
+	}
+	raw, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("secret '%s' does not contain key '%s'", secretName, key)
+	}
+	str, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("value for key '%s' in secret '%s' is not a string", key, secretName)
+	}
+	return str, nil
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/googleapis/gax-go/v2"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+// gcpSecretManagerFetcher reads secret versions via the REST client of the Google Cloud Secret Manager SDK.
+// The access token is obtained via the application default credentials, e.g. from the metadata server.
+type gcpSecretManagerFetcher struct {
+	cfg *config.GCPSecretManagerSource
+
+	// client is created on first use, it caches the access token and requests a new one before it expires.
+	// It is nil if it hasn't been created yet or has been discarded because the token has been rejected.
+	client *secretmanager.Client
+}
+
+func newGCPSecretManagerFetcher(cfg *config.GCPSecretManagerSource) *gcpSecretManagerFetcher {
+	return &gcpSecretManagerFetcher{
+		cfg: cfg,
+	}
+}
+
+func (f *gcpSecretManagerFetcher) fetchSecretValue(ctx context.Context, httpClient *http.Client) (string, error) {
+	if f.client == nil {
+		// the token source keeps the context for requesting tokens later on, so it must not be canceled together with this call
+		creds, err := google.FindDefaultCredentials(context.WithValue(context.WithoutCancel(ctx), oauth2.HTTPClient, httpClient), secretmanager.DefaultAuthScopes()...)
+		if err != nil {
+			return "", fmt.Errorf("error finding GCP credentials: %w", err)
+		}
+		f.client, err = secretmanager.NewRESTClient(context.WithoutCancel(ctx), option.WithEndpoint(f.cfg.Endpoint), option.WithTokenSource(creds.TokenSource))
+		if err != nil {
+			return "", fmt.Errorf("error creating Secret Manager client: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, httpClient.Timeout)
+	defer cancel()
+	name := fmt.Sprintf("projects/%s/secrets/%s/versions/%s", f.cfg.Project, f.cfg.Secret, f.cfg.Version)
+	// the SecretValueProvider falls back to the cached password and fetches it again on the next use anyway
+	res, err := f.client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: name}, gax.WithRetry(nil))
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusUnauthorized {
+			// token might have been revoked, request a new one next time
+			_ = f.client.Close()
+			f.client = nil
+		}
+		return "", fmt.Errorf("error accessing secret version '%s': %w", name, err)
+	}
+	return string(res.GetPayload().GetData()), nil
+}
@@ -0,0 +1,24 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package credentials
+
+import (
+	"context"
+)
+
+// Credentials contains credentials which have been fetched by a Provider.
+// Depending on the usage, only some of the fields are set.
+type Credentials struct {
+	Username   string
+	Password   string
+	PrivateKey string
+}
+
+// Provider provides credentials which are fetched at runtime.
+type Provider interface {
+	// Credentials returns the current credentials.
+	// Implementations are expected to cache the credentials and refresh them when required.
+	Credentials(ctx context.Context) (*Credentials, error)
+}
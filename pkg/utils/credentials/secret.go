@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ Provider = &SecretProvider{}
+
+// SecretProvider reads credentials from a Kubernetes Secret.
+// The Secret is expected to contain the keys 'username', 'password', and 'privateKey', depending on the authentication type.
+// The credentials are cached and read again after the refresh interval.
+type SecretProvider struct {
+	reader          client.Reader
+	key             client.ObjectKey
+	refreshInterval time.Duration
+	// now returns the current time, can be overwritten for testing purposes.
+	now func() time.Time
+
+	lock        sync.Mutex
+	creds       *Credentials
+	credsExpiry time.Time
+}
+
+// NewSecretProvider creates a new SecretProvider for the Secret with the given name in the given namespace.
+func NewSecretProvider(reader client.Reader, namespace, name string, refreshInterval time.Duration) *SecretProvider {
+	return &SecretProvider{
+		reader:          reader,
+		key:             client.ObjectKey{Namespace: namespace, Name: name},
+		refreshInterval: refreshInterval,
+		now:             time.Now,
+	}
+}
+
+// Credentials returns the cached credentials, if they are still valid.
+// Otherwise, the Secret is read again.
+// If reading the Secret fails and there are cached credentials, they are returned together with the error.
+func (sp *SecretProvider) Credentials(ctx context.Context) (*Credentials, error) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+
+	if sp.creds != nil && sp.now().Before(sp.credsExpiry) {
+		return sp.creds, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := sp.reader.Get(ctx, sp.key, secret); err != nil {
+		return sp.creds, fmt.Errorf("error reading secret '%s': %w", sp.key.String(), err)
+	}
+	sp.creds = &Credentials{
+		Username:   string(secret.Data["username"]),
+		Password:   string(secret.Data["password"]),
+		PrivateKey: string(secret.Data["privateKey"]),
+	}
+	sp.credsExpiry = sp.now().Add(sp.refreshInterval)
+	return sp.creds, nil
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package credentials
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// secretReader is a client.Reader which only knows a single secret.
+type secretReader struct {
+	secret *corev1.Secret
+}
+
+var _ client.Reader = &secretReader{}
+
+func (r *secretReader) Get(_ context.Context, key client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	if r.secret == nil || key != client.ObjectKeyFromObject(r.secret) {
+		return apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, key.Name)
+	}
+	r.secret.DeepCopyInto(obj.(*corev1.Secret))
+	return nil
+}
+
+func (r *secretReader) List(_ context.Context, _ client.ObjectList, _ ...client.ListOption) error {
+	return nil
+}
+
+var _ = Describe("Secret Provider", func() {
+
+	var (
+		ctx    context.Context
+		c      *secretReader
+		secret *corev1.Secret
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "git-creds",
+				Namespace: "tenant-a",
+			},
+			Data: map[string][]byte{
+				"username": []byte("user"),
+				"password": []byte("foo"),
+			},
+		}
+		c = &secretReader{secret: secret}
+	})
+
+	It("should read the credentials from the secret", func() {
+		sp := NewSecretProvider(c, "tenant-a", "git-creds", time.Minute)
+		creds, err := sp.Credentials(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(creds.Username).To(Equal("user"))
+		Expect(creds.Password).To(Equal("foo"))
+		Expect(creds.PrivateKey).To(BeEmpty())
+	})
+
+	It("should cache the credentials until the refresh interval has passed", func() {
+		now := time.Now()
+		sp := NewSecretProvider(c, "tenant-a", "git-creds", time.Minute)
+		sp.now = func() time.Time { return now }
+		_, err := sp.Credentials(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		secret.Data["password"] = []byte("bar")
+		creds, err := sp.Credentials(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(creds.Password).To(Equal("foo"))
+
+		now = now.Add(2 * time.Minute)
+		creds, err = sp.Credentials(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(creds.Password).To(Equal("bar"))
+	})
+
+	It("should return the cached credentials together with the error if the secret cannot be read", func() {
+		now := time.Now()
+		sp := NewSecretProvider(c, "tenant-a", "git-creds", time.Minute)
+		sp.now = func() time.Time { return now }
+		_, err := sp.Credentials(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		c.secret = nil
+		now = now.Add(2 * time.Minute)
+		creds, err := sp.Credentials(ctx)
+		Expect(err).To(HaveOccurred())
+		Expect(creds).ToNot(BeNil())
+		Expect(creds.Password).To(Equal("foo"))
+	})
+
+	It("should fail if the secret doesn't exist", func() {
+		sp := NewSecretProvider(c, "tenant-b", "git-creds", time.Minute)
+		creds, err := sp.Credentials(ctx)
+		Expect(err).To(HaveOccurred())
+		Expect(creds).To(BeNil())
+	})
+
+})
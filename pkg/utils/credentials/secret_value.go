@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+var _ Provider = &SecretValueProvider{}
+
+// SecretValueProvider provides the credentials of a git auth configuration whose password is fetched from an external secret manager.
+// The other credentials are taken from the configuration, a private key file is read again whenever the password is refreshed.
+// The credentials are cached and fetched again after the configured refresh interval.
+type SecretValueProvider struct {
+	auth       *config.GitRepoAuth
+	fetcher    secretValueFetcher
+	httpClient *http.Client
+	// now returns the current time, can be overwritten for testing purposes.
+	now func() time.Time
+
+	lock        sync.Mutex
+	creds       *Credentials
+	credsExpiry time.Time
+}
+
+// secretValueFetcher fetches a single value from a secret manager.
+// It is only called while the lock of the SecretValueProvider is held.
+type secretValueFetcher interface {
+	fetchSecretValue(ctx context.Context, httpClient *http.Client) (string, error)
+}
+
+// NewSecretValueProvider creates a new SecretValueProvider for the given git auth configuration, which must have 'passwordFrom' set.
+// The configuration is expected to be completed and validated.
+func NewSecretValueProvider(auth *config.GitRepoAuth) *SecretValueProvider {
+	svp := &SecretValueProvider{
+		auth: auth,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		now: time.Now,
+	}
+	if auth.PasswordFrom.AWSSecretsManager != nil {
+		svp.fetcher = newAWSSecretsManagerFetcher(auth.PasswordFrom.AWSSecretsManager)
+	} else {
+		svp.fetcher = newGCPSecretManagerFetcher(auth.PasswordFrom.GCPSecretManager)
+	}
+	return svp
+}
+
+// Credentials returns the cached credentials, if they are still valid.
+// Otherwise, the password is fetched from the secret manager.
+// If fetching the password fails and there are cached credentials, they are returned together with the error.
+func (svp *SecretValueProvider) Credentials(ctx context.Context) (*Credentials, error) {
+	svp.lock.Lock()
+	defer svp.lock.Unlock()
+
+	if svp.creds != nil && svp.now().Before(svp.credsExpiry) {
+		return svp.creds, nil
+	}
+
+	password, err := svp.fetcher.fetchSecretValue(ctx, svp.httpClient)
+	if err != nil {
+		return svp.creds, fmt.Errorf("error fetching password from secret manager: %w", err)
+	}
+	creds := &Credentials{
+		Username:   svp.auth.Username,
+		Password:   password,
+		PrivateKey: svp.auth.PrivateKey,
+	}
+	if svp.auth.PrivateKeyFile != "" {
+		data, err := os.ReadFile(svp.auth.PrivateKeyFile)
+		if err != nil {
+			return svp.creds, fmt.Errorf("error reading private key file: %w", err)
+		}
+		creds.PrivateKey = string(data)
+	}
+	svp.creds = creds
+	svp.credsExpiry = svp.now().Add(svp.auth.PasswordFrom.RefreshInterval.Duration)
+	return svp.creds, nil
+}
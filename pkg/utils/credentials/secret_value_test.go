@@ -0,0 +1,292 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package credentials
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+var _ = Describe("Secret Value Provider", func() {
+
+	var (
+		server   *httptest.Server
+		mux      *http.ServeMux
+		reads    int
+		password string
+	)
+
+	BeforeEach(func() {
+		reads = 0
+		password = "foo"
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("AWS Secrets Manager", func() {
+
+		var (
+			accessKeyID string
+			auth        *config.GitRepoAuth
+		)
+
+		BeforeEach(func() {
+			accessKeyID = "AKIDEXAMPLE"
+			mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" || !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential="+accessKeyID+"/") {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				body := map[string]string{}
+				Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+				Expect(body["SecretId"]).To(Equal("git-password"))
+				reads++
+				secret, err := json.Marshal(map[string]string{"passphrase": password})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(json.NewEncoder(w).Encode(map[string]string{"SecretString": string(secret)})).To(Succeed())
+			})
+
+			// the configuration of the test environment must not be picked up by the default credential chain
+			empty := filepath.Join(GinkgoT().TempDir(), "empty")
+			GinkgoT().Setenv("AWS_CONFIG_FILE", empty)
+			GinkgoT().Setenv("AWS_SHARED_CREDENTIALS_FILE", empty)
+			GinkgoT().Setenv("AWS_EC2_METADATA_DISABLED", "true")
+			GinkgoT().Setenv("AWS_CA_BUNDLE", "")
+			GinkgoT().Setenv("AWS_ROLE_ARN", "")
+			GinkgoT().Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "")
+			GinkgoT().Setenv("AWS_ACCESS_KEY_ID", accessKeyID)
+			GinkgoT().Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+			auth = &config.GitRepoAuth{
+				Type:       config.GIT_AUTH_SSH,
+				PrivateKey: "myPrivateKey",
+				PasswordFrom: &config.SecretValueSource{
+					AWSSecretsManager: &config.AWSSecretsManagerSource{
+						Region:   "eu-west-1",
+						SecretID: "git-password",
+						Key:      "passphrase",
+						Endpoint: server.URL,
+					},
+					RefreshInterval: &metav1.Duration{Duration: time.Minute},
+				},
+			}
+		})
+
+		It("should fetch, cache, and refresh the password", func() {
+			now := time.Now()
+			svp := NewSecretValueProvider(auth)
+			svp.now = func() time.Time { return now }
+
+			creds, err := svp.Credentials(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(creds).To(Equal(&Credentials{Password: "foo", PrivateKey: "myPrivateKey"}))
+			Expect(reads).To(Equal(1))
+
+			// cached
+			password = "bar"
+			creds, err = svp.Credentials(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(creds.Password).To(Equal("foo"))
+			Expect(reads).To(Equal(1))
+
+			// refreshed after the refresh interval
+			now = now.Add(2 * time.Minute)
+			creds, err = svp.Credentials(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(creds.Password).To(Equal("bar"))
+			Expect(reads).To(Equal(2))
+		})
+
+		It("should exchange a web identity token for temporary credentials", func() {
+			tokenFile := filepath.Join(GinkgoT().TempDir(), "token")
+			Expect(os.WriteFile(tokenFile, []byte("dummy-jwt"), os.ModePerm)).To(Succeed())
+			assumed := 0
+			sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.ParseForm()).To(Succeed())
+				if r.PostForm.Get("Action") != "AssumeRoleWithWebIdentity" || r.PostForm.Get("WebIdentityToken") != "dummy-jwt" || r.PostForm.Get("RoleArn") != "arn:aws:iam::123456789012:role/k8syncer" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				assumed++
+				w.Header().Set("Content-Type", "text/xml")
+				_, _ = w.Write([]byte(`<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>` +
+					`<AccessKeyId>ASIATEMP</AccessKeyId><SecretAccessKey>temp</SecretAccessKey><SessionToken>session</SessionToken>` +
+					`<Expiration>` + time.Now().Add(time.Hour).UTC().Format(time.RFC3339) + `</Expiration>` +
+					`</Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`))
+			}))
+			defer sts.Close()
+
+			accessKeyID = "ASIATEMP"
+			GinkgoT().Setenv("AWS_ACCESS_KEY_ID", "")
+			GinkgoT().Setenv("AWS_SECRET_ACCESS_KEY", "")
+			GinkgoT().Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/k8syncer")
+			GinkgoT().Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", tokenFile)
+			GinkgoT().Setenv("AWS_ENDPOINT_URL_STS", sts.URL)
+			now := time.Now()
+			svp := NewSecretValueProvider(auth)
+			svp.now = func() time.Time { return now }
+
+			creds, err := svp.Credentials(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(creds.Password).To(Equal("foo"))
+			Expect(assumed).To(Equal(1))
+
+			// the temporary credentials are cached until they expire
+			now = now.Add(2 * time.Minute)
+			_, err = svp.Credentials(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reads).To(Equal(2))
+			Expect(assumed).To(Equal(1))
+		})
+
+		It("should return the cached credentials together with the error if the secret manager is not reachable", func() {
+			now := time.Now()
+			svp := NewSecretValueProvider(auth)
+			svp.now = func() time.Time { return now }
+
+			_, err := svp.Credentials(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+
+			server.Close()
+			now = now.Add(2 * time.Minute)
+			creds, err := svp.Credentials(context.Background())
+			Expect(err).To(HaveOccurred())
+			Expect(creds).To(Equal(&Credentials{Password: "foo", PrivateKey: "myPrivateKey"}))
+		})
+
+	})
+
+	Context("GCP Secret Manager", func() {
+
+		var (
+			tokens  int
+			revoked bool
+			auth    *config.GitRepoAuth
+		)
+
+		BeforeEach(func() {
+			tokens = 0
+			revoked = false
+			mux.HandleFunc("/computeMetadata/v1/instance/service-accounts/default/token", func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Metadata-Flavor") != "Google" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				tokens++
+				_, _ = w.Write([]byte(`{"access_token": "dummy-token", "expires_in": 3600, "token_type": "Bearer"}`))
+			})
+			mux.HandleFunc("/v1/projects/my-project/secrets/git-password/versions/latest:access", func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer dummy-token" || revoked {
+					revoked = false
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				reads++
+				_, _ = w.Write([]byte(`{"name": "projects/my-project/secrets/git-password/versions/1", "payload": {"data": "` + base64.StdEncoding.EncodeToString([]byte(password)) + `"}}`))
+			})
+
+			// the application default credentials fall back to the metadata server, if neither a credentials file is configured nor gcloud is logged in
+			GinkgoT().Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+			GinkgoT().Setenv("HOME", GinkgoT().TempDir())
+			GinkgoT().Setenv("GCE_METADATA_HOST", strings.TrimPrefix(server.URL, "http://"))
+
+			keyFile := filepath.Join(GinkgoT().TempDir(), "key")
+			Expect(os.WriteFile(keyFile, []byte("myPrivateKey"), os.ModePerm)).To(Succeed())
+			auth = &config.GitRepoAuth{
+				Type:           config.GIT_AUTH_SSH,
+				PrivateKeyFile: keyFile,
+				PasswordFrom: &config.SecretValueSource{
+					GCPSecretManager: &config.GCPSecretManagerSource{
+						Project:  "my-project",
+						Secret:   "git-password",
+						Version:  "latest",
+						Endpoint: server.URL,
+					},
+					RefreshInterval: &metav1.Duration{Duration: time.Minute},
+				},
+			}
+		})
+
+		It("should fetch, cache, and refresh the password", func() {
+			now := time.Now()
+			svp := NewSecretValueProvider(auth)
+			svp.now = func() time.Time { return now }
+
+			creds, err := svp.Credentials(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(creds).To(Equal(&Credentials{Password: "foo", PrivateKey: "myPrivateKey"}))
+			Expect(tokens).To(Equal(1))
+			Expect(reads).To(Equal(1))
+
+			// cached
+			password = "bar"
+			creds, err = svp.Credentials(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(creds.Password).To(Equal("foo"))
+			Expect(reads).To(Equal(1))
+
+			// refreshed after the refresh interval, without requesting a new token
+			now = now.Add(2 * time.Minute)
+			creds, err = svp.Credentials(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(creds.Password).To(Equal("bar"))
+			Expect(tokens).To(Equal(1))
+			Expect(reads).To(Equal(2))
+
+			// requests a new token after the current one has been rejected
+			revoked = true
+			now = now.Add(2 * time.Minute)
+			_, err = svp.Credentials(context.Background())
+			Expect(err).To(HaveOccurred())
+			_, err = svp.Credentials(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tokens).To(Equal(2))
+			Expect(reads).To(Equal(3))
+		})
+
+		It("should return errors of the secret manager without retrying or requesting a new token", func() {
+			status := http.StatusServiceUnavailable
+			failures := 0
+			mux.HandleFunc("/v1/projects/my-project/secrets/missing/versions/latest:access", func(w http.ResponseWriter, r *http.Request) {
+				failures++
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(`{"error": {"code": ` + strconv.Itoa(status) + `, "message": "secret version is not available", "status": "UNAVAILABLE"}}`))
+			})
+			auth.PasswordFrom.GCPSecretManager.Secret = "missing"
+			svp := NewSecretValueProvider(auth)
+
+			_, err := svp.Credentials(context.Background())
+			Expect(err).To(MatchError(ContainSubstring("error accessing secret version 'projects/my-project/secrets/missing/versions/latest'")))
+			Expect(err).To(MatchError(ContainSubstring("secret version is not available")))
+			Expect(failures).To(Equal(1))
+
+			status = http.StatusNotFound
+			_, err = svp.Credentials(context.Background())
+			Expect(err).To(MatchError(ContainSubstring("secret version is not available")))
+			Expect(failures).To(Equal(2))
+			Expect(tokens).To(Equal(1))
+		})
+
+	})
+
+})
@@ -0,0 +1,199 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	vaultk8s "github.com/hashicorp/vault/api/auth/kubernetes"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+var _ Provider = &VaultProvider{}
+
+// VaultProvider fetches credentials from a KV secrets engine in HashiCorp Vault.
+// It logs in via the Kubernetes auth method, using a service account token.
+// The credentials are cached and fetched again after the configured refresh interval.
+// The Vault token is renewed by a LifetimeWatcher for as long as possible, afterwards, the provider logs in again.
+type VaultProvider struct {
+	cfg *config.VaultConfiguration
+	// now returns the current time, can be overwritten for testing purposes.
+	now func() time.Time
+
+	lock          sync.Mutex
+	client        *vaultapi.Client
+	watcher       *vaultapi.LifetimeWatcher
+	loginRequired bool
+	creds         *Credentials
+	credsExpiry   time.Time
+}
+
+// NewVaultProvider creates a new VaultProvider.
+// The configuration is expected to be completed and validated.
+func NewVaultProvider(cfg *config.VaultConfiguration) *VaultProvider {
+	return &VaultProvider{
+		cfg:           cfg,
+		now:           time.Now,
+		loginRequired: true,
+	}
+}
+
+// Credentials returns the cached credentials, if they are still valid.
+// Otherwise, they are fetched from Vault.
+// If fetching the credentials fails and there are cached ones, they are returned together with the error.
+func (vp *VaultProvider) Credentials(ctx context.Context) (*Credentials, error) {
+	vp.lock.Lock()
+	defer vp.lock.Unlock()
+
+	if vp.creds != nil && vp.now().Before(vp.credsExpiry) {
+		return vp.creds, nil
+	}
+
+	creds, err := vp.fetchCredentials(ctx)
+	if err != nil {
+		return vp.creds, fmt.Errorf("error fetching credentials from vault: %w", err)
+	}
+	vp.creds = creds
+	vp.credsExpiry = vp.now().Add(vp.cfg.RefreshInterval.Duration)
+	return vp.creds, nil
+}
+
+func (vp *VaultProvider) fetchCredentials(ctx context.Context) (*Credentials, error) {
+	if vp.client == nil {
+		client, err := newVaultClient(vp.cfg)
+		if err != nil {
+			return nil, err
+		}
+		vp.client = client
+	}
+	if vp.loginRequired {
+		if err := vp.login(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	engine := strings.Trim(vp.cfg.Engine, "/")
+	path := strings.Trim(vp.cfg.Path, "/")
+	var secret *vaultapi.KVSecret
+	var err error
+	if vp.cfg.EngineVersion == 2 {
+		secret, err = vp.client.KVv2(engine).Get(ctx, path)
+	} else {
+		secret, err = vp.client.KVv1(engine).Get(ctx, path)
+	}
+	if err != nil {
+		respErr := &vaultapi.ResponseError{}
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusForbidden {
+			// token might have been revoked, log in again next time
+			vp.loginRequired = true
+		}
+		return nil, fmt.Errorf("error reading secret '%s' from engine '%s': %w", path, engine, err)
+	}
+
+	creds := &Credentials{}
+	var ok bool
+	for key, target := range map[string]*string{"username": &creds.Username, "password": &creds.Password, "privateKey": &creds.PrivateKey} {
+		raw, exists := secret.Data[key]
+		if !exists {
+			continue
+		}
+		if *target, ok = raw.(string); !ok {
+			return nil, fmt.Errorf("value for key '%s' in secret '%s' is not a string", key, path)
+		}
+	}
+	return creds, nil
+}
+
+// newVaultClient creates a client for the configured Vault server.
+func newVaultClient(cfg *config.VaultConfiguration) (*vaultapi.Client, error) {
+	clientCfg := vaultapi.DefaultConfig()
+	if clientCfg.Error != nil {
+		return nil, fmt.Errorf("error creating vault client configuration: %w", clientCfg.Error)
+	}
+	clientCfg.Address = cfg.Address
+	clientCfg.Timeout = 30 * time.Second
+	if cfg.CACert != "" || cfg.CACertFile != "" {
+		if err := clientCfg.ConfigureTLS(&vaultapi.TLSConfig{
+			CACert:      cfg.CACertFile,
+			CACertBytes: []byte(cfg.CACert),
+		}); err != nil {
+			return nil, fmt.Errorf("error configuring vault CA certificate: %w", err)
+		}
+	}
+	client, err := vaultapi.NewClient(clientCfg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating vault client: %w", err)
+	}
+	if cfg.Namespace != "" {
+		client.SetNamespace(cfg.Namespace)
+	}
+	return client, nil
+}
+
+// login logs in via the Kubernetes auth method, which sets the token of the client.
+// It starts a LifetimeWatcher which renews the token and requires a new login once the token cannot be renewed anymore.
+func (vp *VaultProvider) login(ctx context.Context) error {
+	// the token file is read on each login, as projected service account tokens are rotated
+	jwt, err := os.ReadFile(vp.cfg.ServiceAccountTokenFile)
+	if err != nil {
+		return fmt.Errorf("error reading service account token: %w", err)
+	}
+	auth, err := vaultk8s.NewKubernetesAuth(vp.cfg.Role, vaultk8s.WithServiceAccountToken(strings.TrimSpace(string(jwt))), vaultk8s.WithMountPath(strings.Trim(vp.cfg.AuthMountPath, "/")))
+	if err != nil {
+		return fmt.Errorf("error configuring kubernetes auth method: %w", err)
+	}
+	if vp.watcher != nil {
+		vp.watcher.Stop()
+		vp.watcher = nil
+	}
+	secret, err := vp.client.Auth().Login(ctx, auth)
+	if err != nil {
+		return fmt.Errorf("error logging in to vault: %w", err)
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return fmt.Errorf("error logging in to vault: response does not contain a token")
+	}
+	vp.loginRequired = false
+	if secret.Auth.LeaseDuration <= 0 {
+		// the token doesn't expire
+		return nil
+	}
+	watcher, err := vp.client.NewLifetimeWatcher(&vaultapi.LifetimeWatcherInput{Secret: secret})
+	if err != nil {
+		return fmt.Errorf("error creating lifetime watcher for vault token: %w", err)
+	}
+	vp.watcher = watcher
+	go watcher.Start()
+	go vp.watchToken(watcher)
+	return nil
+}
+
+// watchToken waits until the given watcher is done, which happens if the token cannot be renewed anymore,
+// or if the watcher has been stopped because of a new login.
+// In the first case, the next fetch logs in again.
+func (vp *VaultProvider) watchToken(watcher *vaultapi.LifetimeWatcher) {
+	for {
+		select {
+		case <-watcher.RenewCh():
+		case <-watcher.DoneCh():
+			vp.lock.Lock()
+			defer vp.lock.Unlock()
+			if vp.watcher == watcher {
+				vp.watcher = nil
+				vp.loginRequired = true
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+var _ = Describe("Vault Provider", func() {
+
+	var (
+		server     *httptest.Server
+		lock       sync.Mutex
+		logins     int
+		renewals   int
+		reads      int
+		namespaces []string
+		password   string
+		cfg        *config.VaultConfiguration
+	)
+
+	BeforeEach(func() {
+		logins = 0
+		renewals = 0
+		reads = 0
+		namespaces = nil
+		password = "foo"
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/v1/auth/kubernetes/login", func(w http.ResponseWriter, r *http.Request) {
+			body := map[string]string{}
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			if body["role"] != "k8syncer" || body["jwt"] != "dummy-jwt" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			logins++
+			namespaces = append(namespaces, r.Header.Get("X-Vault-Namespace"))
+			// the token can be renewed twice, for one second each
+			_, _ = w.Write([]byte(`{"auth": {"client_token": "dummy-token", "lease_duration": 1, "renewable": true}}`))
+		})
+		mux.HandleFunc("/v1/auth/token/renew-self", func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+			renewals++
+			if renewals > 2 {
+				// the maximum lifetime of the token has been reached
+				_, _ = w.Write([]byte(`{"auth": {"client_token": "dummy-token", "lease_duration": 0, "renewable": true}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"auth": {"client_token": "dummy-token", "lease_duration": 1, "renewable": true}}`))
+		})
+		mux.HandleFunc("/v1/secret/data/git/creds", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Vault-Token") != "dummy-token" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			reads++
+			namespaces = append(namespaces, r.Header.Get("X-Vault-Namespace"))
+			_, _ = w.Write([]byte(`{"data": {"data": {"username": "user", "password": "` + password + `"}, "metadata": {}}}`))
+		})
+		server = httptest.NewServer(mux)
+
+		tokenFile := filepath.Join(GinkgoT().TempDir(), "token")
+		Expect(os.WriteFile(tokenFile, []byte("dummy-jwt\n"), os.ModePerm)).To(Succeed())
+
+		cfg = &config.VaultConfiguration{
+			Address:                 server.URL,
+			Role:                    "k8syncer",
+			AuthMountPath:           "kubernetes",
+			ServiceAccountTokenFile: tokenFile,
+			Engine:                  "secret",
+			EngineVersion:           2,
+			Path:                    "git/creds",
+			RefreshInterval:         &metav1.Duration{Duration: time.Minute},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should fetch, cache, and refresh credentials", func() {
+		now := time.Now()
+		vp := NewVaultProvider(cfg)
+		vp.now = func() time.Time { return now }
+
+		creds, err := vp.Credentials(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(creds).To(Equal(&Credentials{Username: "user", Password: "foo"}))
+		Expect(logins).To(Equal(1))
+		Expect(reads).To(Equal(1))
+
+		// cached
+		password = "bar"
+		creds, err = vp.Credentials(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(creds.Password).To(Equal("foo"))
+		Expect(reads).To(Equal(1))
+
+		// refreshed after the refresh interval, without logging in again
+		now = now.Add(2 * time.Minute)
+		creds, err = vp.Credentials(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(creds.Password).To(Equal("bar"))
+		Expect(logins).To(Equal(1))
+		Expect(reads).To(Equal(2))
+
+	})
+
+	It("should renew the token and log in again once it cannot be renewed anymore", func() {
+		now := time.Now()
+		vp := NewVaultProvider(cfg)
+		vp.now = func() time.Time { return now }
+
+		_, err := vp.Credentials(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(logins).To(Equal(1))
+
+		// the token is renewed until its maximum lifetime has been reached
+		Eventually(func() int {
+			lock.Lock()
+			defer lock.Unlock()
+			return renewals
+		}, 10*time.Second, 100*time.Millisecond).Should(BeNumerically(">=", 2))
+		Eventually(func() int {
+			now = now.Add(2 * time.Minute)
+			_, err := vp.Credentials(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			lock.Lock()
+			defer lock.Unlock()
+			return logins
+		}, 10*time.Second, 100*time.Millisecond).Should(Equal(2))
+	})
+
+	It("should use the configured namespace and CA certificate", func() {
+		tlsServer := httptest.NewTLSServer(server.Config.Handler)
+		defer tlsServer.Close()
+		cfg.Address = tlsServer.URL
+		cfg.Namespace = "team-a"
+
+		By("rejecting the server certificate without the CA certificate")
+		_, err := NewVaultProvider(cfg).Credentials(context.Background())
+		Expect(err).To(HaveOccurred())
+
+		By("verifying the server certificate with the CA certificate")
+		cfg.CACert = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw}))
+		creds, err := NewVaultProvider(cfg).Credentials(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(creds.Password).To(Equal("foo"))
+		Expect(namespaces).To(HaveEach("team-a"))
+
+		By("reading the CA certificate from a file")
+		caFile := filepath.Join(GinkgoT().TempDir(), "ca.crt")
+		Expect(os.WriteFile(caFile, []byte(cfg.CACert), os.ModePerm)).To(Succeed())
+		cfg.CACert = ""
+		cfg.CACertFile = caFile
+		_, err = NewVaultProvider(cfg).Credentials(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should return the cached credentials together with the error if vault is not reachable", func() {
+		now := time.Now()
+		vp := NewVaultProvider(cfg)
+		vp.now = func() time.Time { return now }
+
+		_, err := vp.Credentials(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+
+		server.Close()
+		now = now.Add(2 * time.Minute)
+		creds, err := vp.Credentials(context.Background())
+		Expect(err).To(HaveOccurred())
+		Expect(creds).To(Equal(&Credentials{Username: "user", Password: "foo"}))
+	})
+
+})
@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gardener
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+const (
+	// adminKubeconfigSubresource is the subresource of shoots which issues admin kubeconfigs.
+	adminKubeconfigSubresource = "adminkubeconfig"
+	// refreshRetryInterval is the time after which a failed refresh of the kubeconfig is retried.
+	refreshRetryInterval = 30 * time.Second
+)
+
+// shootsResource is the resource of Gardener shoots in the garden cluster.
+var shootsResource = schema.GroupVersionResource{Group: "core.gardener.cloud", Version: "v1beta1", Resource: "shoots"}
+
+// ShootAccess provides access to a shoot cluster with admin kubeconfigs, which are requested from the garden cluster.
+// A new kubeconfig is requested after 80% of the validity of the current one have passed. If this fails,
+// the current kubeconfig is used until it expires and the request is retried periodically.
+// Use NewShootAccess to instantiate it.
+type ShootAccess struct {
+	gardenClient dynamic.Interface
+	ref          *config.ShootReference
+	log          logging.Logger
+	// now returns the current time, can be overwritten for testing purposes.
+	now func() time.Time
+
+	lock      sync.Mutex
+	transport *http.Transport
+	// rt is the transport wrapped with the authentication of the current kubeconfig.
+	rt        http.RoundTripper
+	refreshAt time.Time
+	expiresAt time.Time
+}
+
+// NewShootAccess creates a new ShootAccess for the referenced shoot, which requests the kubeconfigs with the given garden cluster configuration.
+// The reference is expected to be completed and validated.
+func NewShootAccess(log logging.Logger, gardenCfg *rest.Config, ref *config.ShootReference) (*ShootAccess, error) {
+	gardenClient, err := dynamic.NewForConfig(gardenCfg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating garden cluster client: %w", err)
+	}
+	return &ShootAccess{
+		gardenClient: gardenClient,
+		ref:          ref,
+		log:          log.WithValues(constants.Logging.KEY_CLUSTER, ref.ClusterKey()),
+		now:          time.Now,
+	}, nil
+}
+
+// RESTConfig requests a kubeconfig for the shoot and returns a configuration for the shoot cluster.
+// The transport of the returned configuration switches to new credentials whenever the kubeconfig has been refreshed.
+func (sa *ShootAccess) RESTConfig(ctx context.Context) (*rest.Config, error) {
+	sa.lock.Lock()
+	defer sa.lock.Unlock()
+	shootCfg, err := sa.refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rest.Config{
+		Host:      shootCfg.Host,
+		APIPath:   shootCfg.APIPath,
+		Transport: sa,
+	}, nil
+}
+
+// RoundTrip sends the request with the credentials of the current kubeconfig, which is refreshed first if required.
+func (sa *ShootAccess) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt, err := sa.currentRoundTripper(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	return rt.RoundTrip(req)
+}
+
+// currentRoundTripper returns the round tripper for the current kubeconfig, after refreshing it if it is due.
+// An error is only returned if the refresh fails and the current kubeconfig has expired.
+func (sa *ShootAccess) currentRoundTripper(ctx context.Context) (http.RoundTripper, error) {
+	sa.lock.Lock()
+	defer sa.lock.Unlock()
+	now := sa.now()
+	if now.Before(sa.refreshAt) {
+		return sa.rt, nil
+	}
+	if _, err := sa.refresh(ctx); err != nil {
+		sa.refreshAt = now.Add(refreshRetryInterval)
+		if now.Before(sa.expiresAt) {
+			sa.log.Error(err, "Unable to refresh shoot kubeconfig, using the current one until it expires", constants.Logging.KEY_EXPIRES_AT, sa.expiresAt.Format(time.RFC3339))
+			return sa.rt, nil
+		}
+		return nil, fmt.Errorf("kubeconfig of shoot '%s/%s' has expired: %w", sa.ref.Namespace, sa.ref.Name, err)
+	}
+	return sa.rt, nil
+}
+
+// refresh requests a new kubeconfig and replaces the round tripper with one for its credentials.
+// The caller must hold the lock.
+func (sa *ShootAccess) refresh(ctx context.Context) (*rest.Config, error) {
+	shootCfg, expiresAt, err := sa.requestKubeconfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tlsCfg, err := rest.TLSConfigFor(shootCfg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating TLS configuration for shoot '%s/%s': %w", sa.ref.Namespace, sa.ref.Name, err)
+	}
+	transport := utilnet.SetTransportDefaults(&http.Transport{TLSClientConfig: tlsCfg})
+	rt, err := rest.HTTPWrappersForConfig(shootCfg, transport)
+	if err != nil {
+		return nil, fmt.Errorf("error creating transport for shoot '%s/%s': %w", sa.ref.Namespace, sa.ref.Name, err)
+	}
+
+	// running requests, e.g. watches, finish with the old credentials, new ones use the new transport
+	if sa.transport != nil {
+		sa.transport.CloseIdleConnections()
+	}
+	now := sa.now()
+	sa.transport = transport
+	sa.rt = rt
+	sa.expiresAt = expiresAt
+	sa.refreshAt = now.Add(expiresAt.Sub(now) * 4 / 5)
+	sa.log.Debug("Refreshed shoot kubeconfig", constants.Logging.KEY_EXPIRES_AT, expiresAt.Format(time.RFC3339))
+	return shootCfg, nil
+}
+
+// requestKubeconfig requests an admin kubeconfig for the shoot via the 'shoots/adminkubeconfig' subresource.
+// It returns the configuration from the kubeconfig's current context and the time at which the kubeconfig expires.
+func (sa *ShootAccess) requestKubeconfig(ctx context.Context) (*rest.Config, time.Time, error) {
+	req := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "authentication.gardener.cloud/v1alpha1",
+		"kind":       "AdminKubeconfigRequest",
+		"metadata": map[string]any{
+			"namespace": sa.ref.Namespace,
+			"name":      sa.ref.Name,
+		},
+		"spec": map[string]any{
+			"expirationSeconds": int64(sa.ref.Expiration.Duration.Seconds()),
+		},
+	}}
+	res, err := sa.gardenClient.Resource(shootsResource).Namespace(sa.ref.Namespace).Create(ctx, req, metav1.CreateOptions{}, adminKubeconfigSubresource)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error requesting admin kubeconfig for shoot '%s/%s': %w", sa.ref.Namespace, sa.ref.Name, err)
+	}
+
+	encoded, _, err := unstructured.NestedString(res.Object, "status", "kubeconfig")
+	if err != nil || encoded == "" {
+		return nil, time.Time{}, fmt.Errorf("admin kubeconfig request for shoot '%s/%s' did not return a kubeconfig", sa.ref.Namespace, sa.ref.Name)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error decoding admin kubeconfig of shoot '%s/%s': %w", sa.ref.Namespace, sa.ref.Name, err)
+	}
+	shootCfg, err := clientcmd.RESTConfigFromKubeConfig(data)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error parsing admin kubeconfig of shoot '%s/%s': %w", sa.ref.Namespace, sa.ref.Name, err)
+	}
+
+	// fall back to the requested expiration if the response doesn't contain a valid timestamp
+	expiresAt := sa.now().Add(sa.ref.Expiration.Duration)
+	if rawExpiration, _, _ := unstructured.NestedString(res.Object, "status", "expirationTimestamp"); rawExpiration != "" {
+		if parsed, err := time.Parse(time.RFC3339, rawExpiration); err == nil {
+			expiresAt = parsed
+		}
+	}
+	return shootCfg, expiresAt, nil
+}
@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gardener
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+var _ = Describe("Shoot Access", func() {
+
+	var (
+		garden *httptest.Server
+		shoot  *httptest.Server
+		now    time.Time
+		// issued is the number of kubeconfigs which have been issued by the garden cluster, the token of each kubeconfig contains its number
+		issued int
+		// gardenDown makes the garden cluster reject all requests
+		gardenDown bool
+		// tokens contains the tokens of the requests which have been received by the shoot cluster
+		tokens []string
+		ref    *config.ShootReference
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		issued = 0
+		gardenDown = false
+		tokens = nil
+
+		shoot = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokens = append(tokens, r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		garden = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if gardenDown || r.Method != http.MethodPost || r.URL.Path != "/apis/core.gardener.cloud/v1beta1/namespaces/garden-dev/shoots/dev/adminkubeconfig" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			req := map[string]any{}
+			Expect(json.NewDecoder(r.Body).Decode(&req)).To(Succeed())
+			Expect(req).To(HaveKeyWithValue("kind", "AdminKubeconfigRequest"))
+			Expect(req).To(HaveKeyWithValue("spec", HaveKeyWithValue("expirationSeconds", BeNumerically("==", 3600))))
+
+			issued++
+			kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: shoot
+  cluster:
+    server: %s
+    certificate-authority-data: %s
+contexts:
+- name: shoot
+  context:
+    cluster: shoot
+    user: admin
+current-context: shoot
+users:
+- name: admin
+  user:
+    token: token-%d
+`, shoot.URL, base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: shoot.Certificate().Raw})), issued)
+			req["status"] = map[string]any{
+				"kubeconfig":          base64.StdEncoding.EncodeToString([]byte(kubeconfig)),
+				"expirationTimestamp": now.Add(time.Hour).Format(time.RFC3339),
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			Expect(json.NewEncoder(w).Encode(req)).To(Succeed())
+		}))
+
+		ref = &config.ShootReference{
+			Namespace:  "garden-dev",
+			Name:       "dev",
+			Expiration: &metav1.Duration{Duration: time.Hour},
+		}
+	})
+
+	AfterEach(func() {
+		garden.Close()
+		shoot.Close()
+	})
+
+	newShootClient := func() (*ShootAccess, *http.Client) {
+		sa, err := NewShootAccess(logging.Discard(), &rest.Config{Host: garden.URL}, ref)
+		Expect(err).ToNot(HaveOccurred())
+		sa.now = func() time.Time { return now }
+		restCfg, err := sa.RESTConfig(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restCfg.Host).To(Equal(shoot.URL))
+		httpClient, err := rest.HTTPClientFor(restCfg)
+		Expect(err).ToNot(HaveOccurred())
+		return sa, httpClient
+	}
+
+	get := func(httpClient *http.Client) error {
+		resp, err := httpClient.Get(shoot.URL + "/api")
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	It("should refresh the kubeconfig after 80% of its validity", func() {
+		_, httpClient := newShootClient()
+		Expect(get(httpClient)).To(Succeed())
+		Expect(issued).To(Equal(1))
+
+		now = now.Add(47 * time.Minute)
+		Expect(get(httpClient)).To(Succeed())
+		Expect(issued).To(Equal(1))
+
+		now = now.Add(2 * time.Minute)
+		Expect(get(httpClient)).To(Succeed())
+		Expect(issued).To(Equal(2))
+		Expect(tokens).To(Equal([]string{"Bearer token-1", "Bearer token-1", "Bearer token-2"}))
+	})
+
+	It("should use the current kubeconfig until it expires if it cannot be refreshed", func() {
+		_, httpClient := newShootClient()
+		gardenDown = true
+
+		now = now.Add(50 * time.Minute)
+		Expect(get(httpClient)).To(Succeed())
+		Expect(tokens).To(Equal([]string{"Bearer token-1"}))
+
+		now = now.Add(time.Hour)
+		err := get(httpClient)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("kubeconfig of shoot 'garden-dev/dev' has expired"))
+		Expect(tokens).To(HaveLen(1))
+
+		// the kubeconfig is requested again once the garden cluster is available
+		gardenDown = false
+		now = now.Add(time.Minute)
+		Expect(get(httpClient)).To(Succeed())
+		Expect(tokens).To(Equal([]string{"Bearer token-1", "Bearer token-2"}))
+	})
+
+	It("should return an error if the shoot doesn't exist", func() {
+		ref.Name = "missing"
+		sa, err := NewShootAccess(logging.Discard(), &rest.Config{Host: garden.URL}, ref)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = sa.RESTConfig(context.Background())
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("error requesting admin kubeconfig for shoot 'garden-dev/missing'"))
+	})
+
+})
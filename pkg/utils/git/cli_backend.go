@@ -0,0 +1,523 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	gohttp "net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// emptyTreeHash is the hash of the empty tree, which is used as merge base for branches without a common history.
+const emptyTreeHash = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// authFailureMarkers are parts of the output of the git binary which indicate that the authentication against the remote failed.
+var authFailureMarkers = []string{
+	"Authentication failed",
+	"Permission denied",
+	"could not read Username",
+	"could not read Password",
+	"The requested URL returned error: 401",
+	"The requested URL returned error: 403",
+}
+
+var _ transport.AuthMethod = &SSHKeyFileAuth{}
+
+// SSHKeyFileAuth is an auth method for the 'cli' git backend, which passes an unencrypted SSH private key file to the git binary.
+type SSHKeyFileAuth struct {
+	PrivateKeyFile string
+}
+
+func (a *SSHKeyFileAuth) Name() string {
+	return "ssh-key-file"
+}
+
+func (a *SSHKeyFileAuth) String() string {
+	return fmt.Sprintf("%s - %s", a.Name(), a.PrivateKeyFile)
+}
+
+// CLIAuthFromConfig is like AuthFromConfig, but returns auth methods which can be used with the 'cli' git backend.
+// SSH authentication is converted into an SSHKeyFileAuth, all other auth methods are the same as for go-git.
+func CLIAuthFromConfig(authCfg *config.GitRepoAuth) (transport.AuthMethod, error) {
+	if authCfg == nil || authCfg.Type != config.GIT_AUTH_SSH {
+		return AuthFromConfig(authCfg)
+	}
+	if authCfg.PrivateKeyFile == "" {
+		// should not happen as already part of the config validation
+		return nil, fmt.Errorf("git backend '%s' requires privateKeyFile for git ssh config", string(config.GIT_BACKEND_CLI))
+	}
+	return &SSHKeyFileAuth{PrivateKeyFile: authCfg.PrivateKeyFile}, nil
+}
+
+// usesCLI returns true if the repository is handled by the git binary instead of go-git.
+func (r *GitRepo) usesCLI() bool {
+	return r.Backend == config.GIT_BACKEND_CLI
+}
+
+// cliRun executes the git binary with the given arguments in the repository directory and returns its output.
+// The given auth method is used for commands which communicate with the remote, stdin may be nil.
+func (r *GitRepo) cliRun(auth transport.AuthMethod, stdin io.Reader, args ...string) (string, error) {
+	env, err := r.cliAuthEnv(auth)
+	if err != nil {
+		return "", err
+	}
+	author := r.author()
+	// git's internal date format, which keeps the time zone of the timestamp
+	date := fmt.Sprintf("@%d %s", author.When.Unix(), author.When.Format("-0700"))
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.LocalPath
+	cmd.Stdin = stdin
+	cmd.Env = append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_AUTHOR_NAME="+author.Name,
+		"GIT_AUTHOR_EMAIL="+author.Email,
+		"GIT_COMMITTER_NAME="+author.Name,
+		"GIT_COMMITTER_EMAIL="+author.Email,
+		"GIT_AUTHOR_DATE="+date,
+		"GIT_COMMITTER_DATE="+date,
+	)
+	cmd.Env = append(cmd.Env, env...)
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return stdout.String(), &cliError{
+			command: args[0],
+			output:  strings.TrimSpace(stderr.String()),
+			err:     err,
+		}
+	}
+	return stdout.String(), nil
+}
+
+// cliRunRemote is like cliRun, but retries with the secondary auth method if the authentication with the primary one failed.
+func (r *GitRepo) cliRunRemote(args ...string) (string, error) {
+	out, err := r.cliRun(r.Auth, nil, args...)
+	if err != nil && errors.Is(err, transport.ErrAuthorizationFailed) && r.SecondaryAuth != nil {
+		out, err = r.cliRun(r.SecondaryAuth, nil, args...)
+		if err != nil {
+			return out, fmt.Errorf("secondary auth: %w", err)
+		}
+	}
+	return out, err
+}
+
+// cliAuthEnv returns the environment variables which pass the given auth method to the git binary.
+func (r *GitRepo) cliAuthEnv(auth transport.AuthMethod) ([]string, error) {
+	switch typed := auth.(type) {
+	case nil:
+		return nil, nil
+	case *SSHKeyFileAuth:
+		return []string{fmt.Sprintf("GIT_SSH_COMMAND=ssh -i '%s' -o IdentitiesOnly=yes -o BatchMode=yes", strings.ReplaceAll(typed.PrivateKeyFile, "'", `'\''`))}, nil
+	case http.AuthMethod:
+		if err := checkAuth(auth); err != nil {
+			return nil, err
+		}
+		// the credentials are passed as header, so that they neither end up in the command line nor in a credentials file
+		req, err := gohttp.NewRequest(gohttp.MethodGet, r.URL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error computing authorization header: %w", err)
+		}
+		typed.SetAuth(req)
+		header := req.Header.Get("Authorization")
+		if header == "" {
+			return nil, nil
+		}
+		return []string{
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: " + header,
+		}, nil
+	default:
+		return nil, fmt.Errorf("auth method '%s' is not supported by git backend '%s'", auth.Name(), string(config.GIT_BACKEND_CLI))
+	}
+}
+
+// cliError is returned if the git binary failed.
+// It matches transport.ErrAuthorizationFailed if the output indicates that the authentication against the remote failed.
+type cliError struct {
+	command string
+	output  string
+	err     error
+}
+
+func (e *cliError) Error() string {
+	if e.output == "" {
+		return fmt.Sprintf("error during 'git %s': %s", e.command, e.err.Error())
+	}
+	return fmt.Sprintf("error during 'git %s': %s: %s", e.command, e.err.Error(), e.output)
+}
+
+func (e *cliError) Unwrap() error {
+	return e.err
+}
+
+func (e *cliError) Is(target error) bool {
+	if target != transport.ErrAuthorizationFailed {
+		return false
+	}
+	for _, marker := range authFailureMarkers {
+		if strings.Contains(e.output, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// outputContains returns true if the given error is a cliError whose output contains the given string.
+func outputContains(err error, s string) bool {
+	cliErr := &cliError{}
+	return errors.As(err, &cliErr) && strings.Contains(cliErr.output, s)
+}
+
+// cliInitialize opens the repository if it exists and clones it otherwise, using the git binary.
+func (r *GitRepo) cliInitialize() error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("git backend '%s' requires the git binary: %w", string(config.GIT_BACKEND_CLI), err)
+	}
+	gitExists, err := vfs.DirExists(r.Fs, ".git")
+	if err != nil {
+		return fmt.Errorf("error trying to check for repo existence: %w", err)
+	}
+	if !gitExists {
+		if _, err := r.cliRun(nil, nil, "init", "-q"); err != nil {
+			return err
+		}
+	}
+	if err := r.cliReconcileRemote(); err != nil {
+		return err
+	}
+	if err := r.cliCheckout(); err != nil {
+		return err
+	}
+	r.cliInitialized = true
+	return nil
+}
+
+// cliReconcileRemote ensures that the remote with the configured name exists and points to the configured URL.
+func (r *GitRepo) cliReconcileRemote() error {
+	url, err := r.cliRun(nil, nil, "remote", "get-url", r.RemoteName)
+	if err != nil {
+		_, err = r.cliRun(nil, nil, "remote", "add", r.RemoteName, r.URL)
+		return err
+	}
+	if strings.TrimSpace(url) == r.URL {
+		return nil
+	}
+	_, err = r.cliRun(nil, nil, "remote", "set-url", r.RemoteName, r.URL)
+	return err
+}
+
+// cliCheckout checks out the configured branch.
+// If it exists locally, unpushed local changes are re-applied on top of the remote branch.
+// Otherwise, it is created from the remote branch, or as an orphan branch if it doesn't exist on the remote either.
+func (r *GitRepo) cliCheckout() error {
+	fetched, err := r.cliFetch()
+	if err != nil {
+		return err
+	}
+	if _, err := r.cliRun(nil, nil, "rev-parse", "--verify", "-q", "refs/heads/"+r.Branch); err == nil {
+		if _, err := r.cliRun(nil, nil, "checkout", "-q", "-f", r.Branch); err != nil {
+			return err
+		}
+		if fetched {
+			return r.cliIntegrateRemote()
+		}
+		return nil
+	}
+	if fetched {
+		_, err = r.cliRun(nil, nil, "checkout", "-q", "-f", "--no-track", "-b", r.Branch, r.remoteRef())
+		return err
+	}
+	if _, err := r.cliRun(nil, nil, "rev-parse", "--verify", "-q", "HEAD"); err == nil {
+		_, err = r.cliRun(nil, nil, "checkout", "-q", "-f", "-b", r.Branch)
+		return err
+	}
+	// there is no commit to create the branch from, the first commit will create it
+	_, err = r.cliRun(nil, nil, "symbolic-ref", "HEAD", "refs/heads/"+r.Branch)
+	return err
+}
+
+// remoteRef returns the name of the reference the remote branch is fetched into.
+func (r *GitRepo) remoteRef() string {
+	return fmt.Sprintf("refs/remotes/%s/%s", r.RemoteName, r.Branch)
+}
+
+// cliFetch fetches the remote branch.
+// It returns false if the branch doesn't exist on the remote.
+func (r *GitRepo) cliFetch() (bool, error) {
+	_, err := r.cliRunRemote("fetch", "-q", "--no-tags", r.RemoteName, fmt.Sprintf("+refs/heads/%s:%s", r.Branch, r.remoteRef()))
+	if err != nil {
+		if outputContains(err, "couldn't find remote ref") {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// cliPull fetches the remote branch and fast-forwards the local branch to it.
+func (r *GitRepo) cliPull() error {
+	fetched, err := r.cliFetch()
+	if err != nil || !fetched {
+		return err
+	}
+	if _, err := r.cliRun(nil, nil, "rev-parse", "--verify", "-q", "HEAD"); err != nil {
+		// there are no local commits yet
+		_, err = r.cliRun(nil, nil, "reset", "-q", "--hard", r.remoteRef())
+		return err
+	}
+	_, err = r.cliRun(nil, nil, "merge", "-q", "--ff-only", r.remoteRef())
+	return err
+}
+
+// cliRebaseOnRemote is the equivalent of gitRebaseOnRemote for the git binary.
+func (r *GitRepo) cliRebaseOnRemote() error {
+	fetched, err := r.cliFetch()
+	if err != nil || !fetched {
+		return err
+	}
+	return r.cliIntegrateRemote()
+}
+
+// cliIntegrateRemote re-applies the changes of all unpushed local commits on top of the fetched remote branch, see gitRebaseOnRemote.
+func (r *GitRepo) cliIntegrateRemote() error {
+	out, err := r.cliRun(nil, nil, "rev-parse", "--verify", "-q", "HEAD")
+	if err != nil {
+		// there are no local commits, just use the remote state
+		return r.cliResetKeepingWorktree(r.remoteRef())
+	}
+	localCommit := strings.TrimSpace(out)
+	out, err = r.cliRun(nil, nil, "rev-parse", "--verify", "-q", r.remoteRef())
+	if err != nil {
+		return err
+	}
+	remoteCommit := strings.TrimSpace(out)
+	out, err = r.cliRun(nil, nil, "merge-base", localCommit, remoteCommit)
+	exitErr := &exec.ExitError{}
+	if err != nil && (!errors.As(err, &exitErr) || exitErr.ExitCode() != 1) {
+		// exit code 1 means that there is no merge base
+		return err
+	}
+	base := strings.TrimSpace(out)
+	if base == remoteCommit {
+		// local branch already contains the remote head, nothing to do
+		return nil
+	}
+	if base == localCommit {
+		// there are no unpushed local changes, just fast-forward to the remote head
+		return r.cliResetKeepingWorktree(remoteCommit)
+	}
+
+	// collect the local changes
+	diffBase := base
+	logRange := localCommit
+	if base == "" {
+		diffBase = emptyTreeHash
+	} else {
+		logRange = base + ".." + localCommit
+	}
+	out, err = r.cliRun(nil, nil, "diff", "--name-status", "-z", "--no-renames", diffBase, localCommit)
+	if err != nil {
+		return err
+	}
+	changed := []string{}
+	deleted := []string{}
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for idx := 0; idx+1 < len(fields); idx += 2 {
+		if fields[idx] == "D" {
+			deleted = append(deleted, fields[idx+1])
+		} else {
+			changed = append(changed, fields[idx+1])
+		}
+	}
+	conflicts, err := r.cliDetectConflicts(diffBase, localCommit, remoteCommit, changed, deleted)
+	if err != nil {
+		return err
+	}
+	keep := r.resolveConflicts(conflicts)
+	changed = withoutPaths(changed, keep)
+	deleted = withoutPaths(deleted, keep)
+	out, err = r.cliRun(nil, nil, "log", "-z", "--reverse", "--format=%B", logRange)
+	if err != nil {
+		return err
+	}
+	msgs := []string{}
+	for _, msg := range strings.Split(strings.TrimSuffix(out, "\x00"), "\x00") {
+		msgs = append(msgs, strings.TrimRight(msg, "\n"))
+	}
+
+	// reset to the remote state and re-apply the changes, uncommitted changes are restored afterwards
+	snapshot, err := r.snapshotWorktree()
+	if err != nil {
+		return err
+	}
+	if _, err := r.cliRun(nil, nil, "reset", "-q", "--hard", remoteCommit); err != nil {
+		return err
+	}
+	if len(deleted) > 0 {
+		if _, err := r.cliRun(nil, pathspecs(deleted), "rm", "-q", "--ignore-unmatch", "--pathspec-from-file=-", "--pathspec-file-nul"); err != nil {
+			return err
+		}
+	}
+	if len(changed) > 0 {
+		if _, err := r.cliRun(nil, pathspecs(changed), "checkout", "--pathspec-from-file=-", "--pathspec-file-nul", localCommit); err != nil {
+			return err
+		}
+	}
+	if _, err := r.cliCommit(strings.Join(msgs, "\n")); err != nil {
+		return err
+	}
+	return r.restoreWorktree(snapshot)
+}
+
+// cliResetKeepingWorktree hard-resets the local branch to the given commit and restores the uncommitted changes afterwards.
+func (r *GitRepo) cliResetKeepingWorktree(commit string) error {
+	snapshot, err := r.snapshotWorktree()
+	if err != nil {
+		return err
+	}
+	if _, err := r.cliRun(nil, nil, "reset", "-q", "--hard", commit); err != nil {
+		return err
+	}
+	return r.restoreWorktree(snapshot)
+}
+
+// cliCommit is the equivalent of gitCommit for the git binary.
+// It returns false if there are no staged changes after adding the specified paths.
+func (r *GitRepo) cliCommit(msg string, paths ...string) (bool, error) {
+	if len(paths) > 0 {
+		if _, err := r.cliRun(nil, pathspecs(paths), "add", "-A", "--pathspec-from-file=-", "--pathspec-file-nul"); err != nil {
+			return false, err
+		}
+	} else if _, err := r.cliRun(nil, nil, "add", "-A"); err != nil {
+		return false, err
+	}
+	if _, err := r.cliRun(nil, nil, "diff", "--cached", "--quiet"); err == nil {
+		return false, nil
+	}
+	if _, err := r.cliRun(nil, nil, "commit", "-q", "--no-verify", "-m", defaultCommitMessage(msg, paths)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// cliStatus returns the paths of all changed and all deleted files in the worktree, relative to the repository root.
+func (r *GitRepo) cliStatus() ([]string, []string, error) {
+	out, err := r.cliRun(nil, nil, "status", "--porcelain", "-z", "--untracked-files=all", "--no-renames")
+	if err != nil {
+		return nil, nil, err
+	}
+	changed := []string{}
+	deleted := []string{}
+	for _, entry := range strings.Split(strings.TrimSuffix(out, "\x00"), "\x00") {
+		if len(entry) < 4 {
+			continue
+		}
+		// entries have the format 'XY path', where X is the status in the index and Y the one in the worktree
+		if entry[1] == 'D' || (entry[1] == ' ' && entry[0] == 'D') {
+			deleted = append(deleted, entry[3:])
+		} else {
+			changed = append(changed, entry[3:])
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(deleted)
+	return changed, deleted, nil
+}
+
+// cliCommitAndPushChunked is the equivalent of commitAndPushChunked for the git binary.
+func (r *GitRepo) cliCommitAndPushChunked(log logging.Logger, pullBefore bool, msg string) (bool, error) {
+	changed, deleted, err := r.cliStatus()
+	if err != nil {
+		return false, err
+	}
+	changed = append(changed, deleted...)
+	if len(changed) <= r.CommitChunkSize {
+		return false, nil
+	}
+	sort.Strings(changed)
+	if msg == "" {
+		msg = "updated files"
+	}
+	chunkCount := (len(changed) + r.CommitChunkSize - 1) / r.CommitChunkSize
+	log.Info("Committing changes in chunks", constants.Logging.KEY_FILE_COUNT, len(changed), constants.Logging.KEY_CHUNK, chunkCount)
+	for idx := 0; idx < chunkCount; idx++ {
+		chunk := changed[idx*r.CommitChunkSize : min((idx+1)*r.CommitChunkSize, len(changed))]
+		if _, err := r.cliCommit(fmt.Sprintf("%s (%d/%d)", msg, idx+1, chunkCount), chunk...); err != nil {
+			return true, err
+		}
+		r.hasUnpushedCommits = true
+		if err := r.pushWithoutLocking(pullBefore); err != nil {
+			return true, fmt.Errorf("error pushing chunk %d of %d: %w", idx+1, chunkCount, err)
+		}
+		log.Info("Pushed chunk of changes", constants.Logging.KEY_CHUNK, fmt.Sprintf("%d/%d", idx+1, chunkCount))
+	}
+	return true, nil
+}
+
+// cliPushOnce is the equivalent of gitPushOnce for the git binary.
+func (r *GitRepo) cliPushOnce() error {
+	_, err := r.cliRunRemote("push", "-q", "--no-verify", r.RemoteName, "HEAD:refs/heads/"+r.Branch)
+	return err
+}
+
+// cliDeleteRemoteBranch is the equivalent of DeleteRemoteBranch for the git binary.
+func (r *GitRepo) cliDeleteRemoteBranch() error {
+	_, err := r.cliRunRemote("push", "-q", "--no-verify", r.RemoteName, ":refs/heads/"+r.Branch)
+	if err != nil && !outputContains(err, "remote ref does not exist") {
+		return fmt.Errorf("error deleting remote branch '%s': %w", r.Branch, err)
+	}
+	return nil
+}
+
+// cliRemoteBranches is the equivalent of RemoteBranches for the git binary.
+func (r *GitRepo) cliRemoteBranches(prefix string) ([]string, error) {
+	out, err := r.cliRunRemote("ls-remote", "--heads", r.RemoteName)
+	if err != nil {
+		return nil, fmt.Errorf("error listing remote references: %w", err)
+	}
+	branches := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		_, ref, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok {
+			continue
+		}
+		branch := strings.TrimPrefix(ref, "refs/heads/")
+		if strings.HasPrefix(branch, prefix) {
+			branches = append(branches, branch)
+		}
+	}
+	sort.Strings(branches)
+	return branches, nil
+}
+
+// pathspecs returns the given paths as NUL-separated pathspecs for the '--pathspec-from-file' option.
+// The paths are passed via stdin, as the command line length is limited.
+func pathspecs(paths []string) io.Reader {
+	buf := &bytes.Buffer{}
+	for _, p := range paths {
+		// the paths are literal, not patterns
+		buf.WriteString(":(literal)")
+		buf.WriteString(p)
+		buf.WriteByte(0)
+	}
+	return buf
+}
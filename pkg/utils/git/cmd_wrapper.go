@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -22,9 +23,16 @@ import (
 	gitfs "github.com/go-git/go-git/v5/storage/filesystem"
 	"github.com/mandelsoft/vfs/pkg/projectionfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils/constants"
 )
 
-const defaultRemoteName = "origin"
+const (
+	defaultRemoteName = "origin"
+	// defaultMaxPushRetries is the default for how often a failed push is retried after re-applying the local changes on top of the remote branch.
+	defaultMaxPushRetries = 3
+)
 
 var ErrNotInitialized = fmt.Errorf("git repo is not initialized, call repo.Initialize first")
 
@@ -35,6 +43,8 @@ type GitRepo struct {
 	URL string
 	// Branch is the branch of the repo which should be used.
 	Branch string
+	// RemoteName is the name of the remote which refers to URL.
+	RemoteName string
 	// LocalPath is the filesystem path where the repo should be checked out to.
 	LocalPath string
 	// Auth is the authentification information for the git repository.
@@ -43,10 +53,53 @@ type GitRepo struct {
 	SecondaryAuth transport.AuthMethod
 	// Fs is the filesystem used for the repository.
 	Fs vfs.FileSystem
+	// MaxPushRetries specifies how often a failed push is retried.
+	// Before each retry, the remote branch is fetched and the unpushed local changes are re-applied on top of it.
+	MaxPushRetries int
+	// CommitChunkSize is the maximum number of changed files per commit when all changes are committed via CommitAndPush.
+	// If more files have changed, the changes are split into multiple commits, each of which is pushed on its own.
+	// 0 means that all changes are committed at once.
+	CommitChunkSize int
+	// CommitGroup returns the group of a changed file, given its path relative to the repository root and its content.
+	// For deleted files, the content of the last commit is passed.
+	// If it is set and the changes which are committed via CommitAndPush without explicit paths belong to more than one group,
+	// one commit is created per group, in the order of the group names. Each group is split into chunks of CommitChunkSize files.
+	CommitGroup func(path string, data []byte) (string, error)
+	// CommitGroupExcludes contains paths, relative to the repository root, which don't belong to any group.
+	// They are committed together with the last group, e.g. because they describe all of the changes.
+	CommitGroupExcludes []string
+	// PreCommitHook is called with the paths of all changed and all deleted files before the changes are committed via CommitAndPush without explicit paths.
+	// Paths are relative to the repository root. The hook may modify files in the worktree, e.g. to add metadata about the changes,
+	// these modifications are committed together with the changes. It is not called if there are no changes.
+	PreCommitHook func(changed, deleted []string) error
+	// Backend is the implementation which is used for the git commands.
+	// If it is config.GIT_BACKEND_CLI, the git binary is used, which works directly on LocalPath instead of Fs, so Fs must be a projection of the local filesystem.
+	// Auth and SecondaryAuth must then be created via CLIAuthFromConfig. Defaults to go-git.
+	Backend config.GitBackend
+	// ConflictPolicy specifies how files are handled which have been modified both locally and on the remote,
+	// when the unpushed local changes are re-applied on top of the remote branch. Defaults to config.GIT_CONFLICT_POLICY_PREFER_CLUSTER.
+	// For config.GIT_CONFLICT_POLICY_FAIL_AND_ALERT, CommitAndPush and Push return a ConflictError after pushing the remaining changes.
+	ConflictPolicy config.GitConflictPolicy
+	// OnConflict is called with all conflicts after they have been resolved according to ConflictPolicy. It may be nil.
+	// It is called while the repository is locked, so it must not call any methods of the GitRepo.
+	OnConflict func(conflicts []*Conflict)
+	// ConflictExcludes contains paths, relative to the repository root, for which conflicts are ignored and the local version always wins.
+	ConflictExcludes []string
+	// CommitTimeZone is the time zone in which the timestamps of the commits are recorded.
+	// If nil, the local time zone is used.
+	CommitTimeZone *time.Location
+	// CommitTimePrecision is the duration to which the timestamps of the commits are truncated, e.g. time.Minute.
+	// 0 means that the timestamps are not truncated, apart from git's precision of one second.
+	CommitTimePrecision time.Duration
+	// Now returns the current time, which is used for the timestamps of the commits. If nil, time.Now is used.
+	Now func() time.Time
 
 	repo               *git.Repository
+	cliInitialized     bool
 	hasUnpushedCommits bool
-	lock               *sync.Mutex
+	// unresolvedConflicts contains the paths of the conflicting files which have to be reported via a ConflictError.
+	unresolvedConflicts []string
+	lock                *sync.Mutex
 }
 
 // NewRepo creates a new GitRepo instance, which can be used to interact with a git repository.
@@ -60,10 +113,12 @@ func NewRepo(baseFs vfs.FileSystem, url, branch, localPath string, auth, seconda
 	return &GitRepo{
 		URL:                url,
 		Branch:             branch,
+		RemoteName:         defaultRemoteName,
 		LocalPath:          localPath,
 		Auth:               auth,
 		SecondaryAuth:      secondaryAuth,
 		Fs:                 fs,
+		MaxPushRetries:     defaultMaxPushRetries,
 		hasUnpushedCommits: false,
 		lock:               &sync.Mutex{},
 	}, nil
@@ -73,6 +128,9 @@ func NewRepo(baseFs vfs.FileSystem, url, branch, localPath string, auth, seconda
 func (r *GitRepo) Initialize(log logging.Logger) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
+	if r.usesCLI() {
+		return r.cliInitialize()
+	}
 	gitExists, err := vfs.DirExists(r.Fs, ".git")
 	if err != nil {
 		return fmt.Errorf("error trying to check for repo existence: %w", err)
@@ -113,19 +171,24 @@ func (r *GitRepo) commitWithoutLocking(msg string, paths ...string) (bool, error
 }
 
 // Push pushes all unpushed commits to the remote repository.
-// If pullBefore is true, it pulls before pushing to avoid conflicts.
-// If an error occurs during the push, it tries to pull and then retries the push.
+// If pullBefore is true, it integrates the remote changes before pushing to avoid conflicts.
+// If an error occurs during the push, it fetches the remote branch, re-applies the unpushed local changes on top of it,
+// and retries the push, for up to r.MaxPushRetries times.
 func (r *GitRepo) Push(log logging.Logger, pullBefore bool) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 	if !r.IsInitialized() {
 		return ErrNotInitialized
 	}
-	return r.pushWithoutLocking(pullBefore)
+	r.unresolvedConflicts = nil
+	if err := r.pushWithoutLocking(pullBefore); err != nil {
+		return err
+	}
+	return r.conflictError()
 }
 
 func (r *GitRepo) pushWithoutLocking(pullBefore bool) error {
-	if err := r.gitPush(pullBefore, false); err != nil {
+	if err := r.gitPush(pullBefore); err != nil {
 		return err
 	}
 	r.hasUnpushedCommits = false
@@ -141,6 +204,31 @@ func (r *GitRepo) CommitAndPush(log logging.Logger, pullBefore bool, msg string,
 	if !r.IsInitialized() {
 		return ErrNotInitialized
 	}
+	r.unresolvedConflicts = nil
+	if err := r.commitAndPushWithoutLocking(log, pullBefore, msg, paths...); err != nil {
+		return err
+	}
+	return r.conflictError()
+}
+
+func (r *GitRepo) commitAndPushWithoutLocking(log logging.Logger, pullBefore bool, msg string, paths ...string) error {
+	if len(paths) == 0 && r.PreCommitHook != nil {
+		if err := r.runPreCommitHook(); err != nil {
+			return err
+		}
+	}
+	if len(paths) == 0 && r.CommitGroup != nil {
+		grouped, err := r.commitAndPushGrouped(log, pullBefore, msg)
+		if err != nil || grouped {
+			return err
+		}
+	}
+	if len(paths) == 0 && r.CommitChunkSize > 0 {
+		chunked, err := r.commitAndPushChunked(log, pullBefore, msg)
+		if err != nil || chunked {
+			return err
+		}
+	}
 	pushRequired, err := r.commitWithoutLocking(msg, paths...)
 	if err != nil {
 		return err
@@ -151,6 +239,106 @@ func (r *GitRepo) CommitAndPush(log logging.Logger, pullBefore bool, msg string,
 	return nil
 }
 
+// runPreCommitHook calls r.PreCommitHook with the changes of the worktree.
+func (r *GitRepo) runPreCommitHook() error {
+	changed, deleted, err := r.worktreeStatus()
+	if err != nil {
+		return err
+	}
+	if len(changed) == 0 && len(deleted) == 0 {
+		return nil
+	}
+	if err := r.PreCommitHook(changed, deleted); err != nil {
+		return fmt.Errorf("error running pre-commit hook: %w", err)
+	}
+	return nil
+}
+
+// worktreeStatus returns the paths of all changed and all deleted files in the worktree, relative to the repository root.
+func (r *GitRepo) worktreeStatus() ([]string, []string, error) {
+	if r.usesCLI() {
+		return r.cliStatus()
+	}
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting worktree: %w", err)
+	}
+	status, err := w.Status()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error during 'git status': %w", err)
+	}
+	changed := []string{}
+	deleted := []string{}
+	for path, fileStatus := range status {
+		if fileStatus.Worktree == git.Deleted || (fileStatus.Worktree == git.Unmodified && fileStatus.Staging == git.Deleted) {
+			deleted = append(deleted, path)
+		} else if fileStatus.Worktree != git.Unmodified || fileStatus.Staging != git.Unmodified {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(deleted)
+	return changed, deleted, nil
+}
+
+// commitAndPushChunked commits and pushes all changes in chunks of r.CommitChunkSize files.
+// The chunks are pushed one after another, so that a failed push only requires the current chunk to be re-applied.
+// It does nothing and returns false if the number of changed files does not exceed the chunk size.
+func (r *GitRepo) commitAndPushChunked(log logging.Logger, pullBefore bool, msg string) (bool, error) {
+	if r.usesCLI() {
+		return r.cliCommitAndPushChunked(log, pullBefore, msg)
+	}
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return false, fmt.Errorf("error getting worktree: %w", err)
+	}
+	status, err := w.Status()
+	if err != nil {
+		return false, fmt.Errorf("error during 'git status': %w", err)
+	}
+	changed := []string{}
+	for path, fileStatus := range status {
+		if fileStatus.Worktree != git.Unmodified || fileStatus.Staging != git.Unmodified {
+			changed = append(changed, path)
+		}
+	}
+	if len(changed) <= r.CommitChunkSize {
+		return false, nil
+	}
+	sort.Strings(changed)
+	if msg == "" {
+		msg = "updated files"
+	}
+	chunkCount := (len(changed) + r.CommitChunkSize - 1) / r.CommitChunkSize
+	log.Info("Committing changes in chunks", constants.Logging.KEY_FILE_COUNT, len(changed), constants.Logging.KEY_CHUNK, chunkCount)
+	for idx := 0; idx < chunkCount; idx++ {
+		chunk := changed[idx*r.CommitChunkSize : min((idx+1)*r.CommitChunkSize, len(changed))]
+		for _, path := range chunk {
+			if status.File(path).Worktree == git.Deleted {
+				_, err = w.Remove(path)
+			} else {
+				// the status is already known, computing it again for each file would be very slow for large change sets
+				err = w.AddWithOptions(&git.AddOptions{Path: path, SkipStatus: true})
+			}
+			if err != nil {
+				return true, fmt.Errorf("error during 'git add': %w", err)
+			}
+		}
+		_, err = w.Commit(fmt.Sprintf("%s (%d/%d)", msg, idx+1, chunkCount), &git.CommitOptions{
+			Author: r.author(),
+		})
+		if err != nil {
+			return true, fmt.Errorf("error during 'git commit': %w", err)
+		}
+		r.hasUnpushedCommits = true
+		if err := r.pushWithoutLocking(pullBefore); err != nil {
+			return true, fmt.Errorf("error pushing chunk %d of %d: %w", idx+1, chunkCount, err)
+		}
+		log.Info("Pushed chunk of changes", constants.Logging.KEY_CHUNK, fmt.Sprintf("%d/%d", idx+1, chunkCount))
+	}
+	return true, nil
+}
+
 // Pull pulls from the remote repository.
 func (r *GitRepo) Pull(log logging.Logger) error {
 	r.lock.Lock()
@@ -164,6 +352,67 @@ func (r *GitRepo) Pull(log logging.Logger) error {
 	return nil
 }
 
+// DeleteRemoteBranch deletes the branch from the remote repository.
+// It does not return an error if the branch doesn't exist on the remote.
+func (r *GitRepo) DeleteRemoteBranch(log logging.Logger) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.IsInitialized() {
+		return ErrNotInitialized
+	}
+	if r.usesCLI() {
+		return r.cliDeleteRemoteBranch()
+	}
+	pushOptions := &git.PushOptions{
+		RemoteName: r.RemoteName,
+		Auth:       r.Auth,
+		RefSpecs:   []gitcfg.RefSpec{gitcfg.RefSpec(":" + plumbing.NewBranchReferenceName(r.Branch).String())},
+	}
+	err := r.repo.Push(pushOptions)
+	if err != nil && errors.Is(err, transport.ErrAuthorizationFailed) && r.SecondaryAuth != nil {
+		pushOptions.Auth = r.SecondaryAuth
+		err = r.repo.Push(pushOptions)
+	}
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) && !errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return fmt.Errorf("error deleting remote branch '%s': %w", r.Branch, err)
+	}
+	return nil
+}
+
+// RemoteBranches returns the sorted names of all branches of the remote repository which start with the given prefix.
+func (r *GitRepo) RemoteBranches(log logging.Logger, prefix string) ([]string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.IsInitialized() {
+		return nil, ErrNotInitialized
+	}
+	if r.usesCLI() {
+		return r.cliRemoteBranches(prefix)
+	}
+	remote, err := r.repo.Remote(r.RemoteName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading remote '%s': %w", r.RemoteName, err)
+	}
+	refs, err := remote.List(&git.ListOptions{Auth: r.Auth})
+	if err != nil && errors.Is(err, transport.ErrAuthorizationFailed) && r.SecondaryAuth != nil {
+		refs, err = remote.List(&git.ListOptions{Auth: r.SecondaryAuth})
+	}
+	if err != nil {
+		if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error listing remote references: %w", err)
+	}
+	branches := []string{}
+	for _, ref := range refs {
+		if ref.Name().IsBranch() && strings.HasPrefix(ref.Name().Short(), prefix) {
+			branches = append(branches, ref.Name().Short())
+		}
+	}
+	sort.Strings(branches)
+	return branches, nil
+}
+
 func (r *GitRepo) gitInit() error {
 	// folder is required to create a projectionfs
 	gitDirPath := vfs.Join(r.Fs, ".git")
@@ -184,7 +433,7 @@ func (r *GitRepo) gitInit() error {
 	}
 
 	_, err = r.repo.CreateRemote(&gitcfg.RemoteConfig{
-		Name:  defaultRemoteName,
+		Name:  r.RemoteName,
 		URLs:  []string{r.URL},
 		Fetch: []gitcfg.RefSpec{refspecFromBranch(r.Branch)},
 	})
@@ -205,6 +454,9 @@ func (r *GitRepo) gitClone() error {
 }
 
 func (r *GitRepo) gitCommit(msg string, paths ...string) (bool, error) {
+	if r.usesCLI() {
+		return r.cliCommit(msg, paths...)
+	}
 	w, err := r.repo.Worktree()
 	if err != nil {
 		return false, fmt.Errorf("error getting worktree: %w", err)
@@ -226,27 +478,8 @@ func (r *GitRepo) gitCommit(msg string, paths ...string) (bool, error) {
 		}
 	}
 
-	if msg == "" {
-		sb := strings.Builder{}
-		sb.WriteString("updated file")
-		if len(paths) == 0 || len(paths) > 1 {
-			sb.WriteString("s")
-
-			if len(paths) > 1 && len(paths) <= 10 {
-				// if there were 10 or less files updated, list their paths
-				sb.WriteString("\n\n\n")
-				for _, p := range paths {
-					sb.WriteString(fmt.Sprintf("%s\n", p))
-				}
-			}
-		} else {
-			sb.WriteString(fmt.Sprintf(" %s", paths[0]))
-		}
-		msg = sb.String()
-	}
-
-	_, err = w.Commit(msg, &git.CommitOptions{
-		Author: K8SyncerAuthor(),
+	_, err = w.Commit(defaultCommitMessage(msg, paths), &git.CommitOptions{
+		Author: r.author(),
 	})
 	if err != nil {
 		return false, fmt.Errorf("error during 'git commit': %w", err)
@@ -255,49 +488,267 @@ func (r *GitRepo) gitCommit(msg string, paths ...string) (bool, error) {
 	return true, nil
 }
 
-func (r *GitRepo) gitPush(pullBefore, isRetry bool) error {
+func (r *GitRepo) gitPush(pullBefore bool) error {
 	if pullBefore {
-		// pull first to avoid conflicts
-		err := r.gitPull(false)
+		// integrate remote changes first to avoid conflicts
+		err := r.gitRebaseOnRemote()
 		if err != nil {
 			return err
 		}
 	}
 
+	var err error
+	for tries := 0; tries <= r.MaxPushRetries; tries++ {
+		if tries > 0 {
+			// the remote branch has probably been updated in the meantime
+			// re-apply the local changes on top of it before retrying
+			if err2 := r.gitRebaseOnRemote(); err2 != nil {
+				return fmt.Errorf("error during 'git push' (%s), re-applying local changes on top of the remote branch failed: %w", err.Error(), err2)
+			}
+		}
+		err = r.gitPushOnce()
+		if err == nil || errors.Is(err, transport.ErrAuthorizationFailed) {
+			// retrying doesn't help with authorization problems
+			return err
+		}
+	}
+
+	return fmt.Errorf("giving up after %d retries: %w", r.MaxPushRetries, err)
+}
+
+// defaultCommitMessage returns the given commit message or, if it is empty, a generic one for the given paths.
+func defaultCommitMessage(msg string, paths []string) string {
+	if msg != "" {
+		return msg
+	}
+	sb := strings.Builder{}
+	sb.WriteString("updated file")
+	if len(paths) == 0 || len(paths) > 1 {
+		sb.WriteString("s")
+
+		if len(paths) > 1 && len(paths) <= 10 {
+			// if there were 10 or less files updated, list their paths
+			sb.WriteString("\n\n\n")
+			for _, p := range paths {
+				sb.WriteString(fmt.Sprintf("%s\n", p))
+			}
+		}
+	} else {
+		sb.WriteString(fmt.Sprintf(" %s", paths[0]))
+	}
+	return sb.String()
+}
+
+func (r *GitRepo) gitPushOnce() error {
+	if r.usesCLI() {
+		return r.cliPushOnce()
+	}
 	pushOptions := &git.PushOptions{
-		RemoteName: defaultRemoteName,
+		RemoteName: r.RemoteName,
 		Auth:       r.Auth,
 		RefSpecs:   []gitcfg.RefSpec{refspecFromBranch(r.Branch)},
 	}
-	err := r.repo.Push(pushOptions)
-	if err != nil {
+	err := checkAuth(pushOptions.Auth)
+	if err == nil {
+		err = r.repo.Push(pushOptions)
+	}
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
 		if errors.Is(err, transport.ErrAuthorizationFailed) && r.SecondaryAuth != nil {
 			// try with secondary auth information
 			pushOptions.Auth = r.SecondaryAuth
-			err2 := r.repo.Push(pushOptions)
+			err2 := checkAuth(pushOptions.Auth)
 			if err2 == nil {
+				err2 = r.repo.Push(pushOptions)
+			}
+			if err2 == nil || errors.Is(err2, git.NoErrAlreadyUpToDate) {
 				// successful with second auth, ignore error from primary auth try
 				return nil
 			}
 			return fmt.Errorf("error during 'git push' (secondary auth): %w", err2)
 		}
-		if isRetry {
-			return fmt.Errorf("error during 'git push': %w", err)
-		}
-		return r.gitPush(true, true)
+		return fmt.Errorf("error during 'git push': %w", err)
 	}
 
 	return nil
 }
 
+// gitRebaseOnRemote fetches the remote branch and re-applies the changes of all unpushed local commits on top of it.
+// The changes are re-applied file-wise, meaning that for each file which was changed locally, the local version wins.
+// All unpushed local commits are squashed into a single commit, which keeps the original commit messages.
+func (r *GitRepo) gitRebaseOnRemote() error {
+	if r.usesCLI() {
+		return r.cliRebaseOnRemote()
+	}
+	remoteRefName := plumbing.NewRemoteReferenceName(r.RemoteName, r.Branch)
+	fetchOptions := &git.FetchOptions{
+		RemoteName: r.RemoteName,
+		RefSpecs:   []gitcfg.RefSpec{gitcfg.RefSpec(fmt.Sprintf("+refs/heads/%s:%s", r.Branch, remoteRefName.String()))},
+		Auth:       r.Auth,
+		Force:      true,
+	}
+	err := r.repo.Fetch(fetchOptions)
+	if err != nil && errors.Is(err, transport.ErrAuthorizationFailed) && r.SecondaryAuth != nil {
+		fetchOptions.Auth = r.SecondaryAuth
+		err = r.repo.Fetch(fetchOptions)
+	}
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		if errors.Is(err, git.NoMatchingRefSpecError{}) || errors.Is(err, transport.ErrEmptyRemoteRepository) {
+			// the branch doesn't exist on the remote yet, there is nothing to integrate
+			return nil
+		}
+		return fmt.Errorf("error during 'git fetch': %w", err)
+	}
+
+	remoteRef, err := r.repo.Reference(remoteRefName, true)
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return nil
+		}
+		return fmt.Errorf("error resolving remote reference: %w", err)
+	}
+	remoteCommit, err := r.repo.CommitObject(remoteRef.Hash())
+	if err != nil {
+		return fmt.Errorf("error getting remote head commit: %w", err)
+	}
+	localRef, err := r.repo.Head()
+	if err != nil {
+		return fmt.Errorf("error getting local head: %w", err)
+	}
+	localCommit, err := r.repo.CommitObject(localRef.Hash())
+	if err != nil {
+		return fmt.Errorf("error getting local head commit: %w", err)
+	}
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("error getting worktree: %w", err)
+	}
+
+	bases, err := localCommit.MergeBase(remoteCommit)
+	if err != nil {
+		return fmt.Errorf("error computing merge base: %w", err)
+	}
+	var base *object.Commit
+	if len(bases) > 0 {
+		base = bases[0]
+	}
+	if base != nil && base.Hash == remoteCommit.Hash {
+		// local branch already contains the remote head, nothing to do
+		return nil
+	}
+	if base != nil && base.Hash == localCommit.Hash {
+		// there are no unpushed local changes, just fast-forward to the remote head
+		snapshot, err := r.snapshotWorktree()
+		if err != nil {
+			return err
+		}
+		if err := w.Reset(&git.ResetOptions{Commit: remoteCommit.Hash, Mode: git.HardReset}); err != nil {
+			return fmt.Errorf("error during 'git reset': %w", err)
+		}
+		return r.restoreWorktree(snapshot)
+	}
+
+	// collect the local changes
+	localTree, err := localCommit.Tree()
+	if err != nil {
+		return fmt.Errorf("error getting local tree: %w", err)
+	}
+	baseTree := &object.Tree{}
+	if base != nil {
+		baseTree, err = base.Tree()
+		if err != nil {
+			return fmt.Errorf("error getting merge base tree: %w", err)
+		}
+	}
+	changes, err := object.DiffTree(baseTree, localTree)
+	if err != nil {
+		return fmt.Errorf("error computing local changes: %w", err)
+	}
+	changedFiles := map[string][]byte{}
+	deletedFiles := []string{}
+	for _, change := range changes {
+		from, to, err := change.Files()
+		if err != nil {
+			return fmt.Errorf("error reading changed files: %w", err)
+		}
+		// the names of the files only contain the base name, the full paths are in the change entries
+		if from != nil && (to == nil || change.From.Name != change.To.Name) {
+			deletedFiles = append(deletedFiles, change.From.Name)
+		}
+		if to != nil {
+			content, err := to.Contents()
+			if err != nil {
+				return fmt.Errorf("error reading content of file '%s': %w", change.To.Name, err)
+			}
+			changedFiles[change.To.Name] = []byte(content)
+		}
+	}
+	conflicts, err := detectConflicts(baseTree, remoteCommit, changedFiles, deletedFiles)
+	if err != nil {
+		return err
+	}
+	keep := r.resolveConflicts(conflicts)
+	deletedFiles = withoutPaths(deletedFiles, keep)
+	for path := range keep {
+		delete(changedFiles, path)
+	}
+	msgs := []string{}
+	commitIter := localCommit
+	for commitIter != nil && (base == nil || commitIter.Hash != base.Hash) {
+		msgs = append(msgs, commitIter.Message)
+		if commitIter.NumParents() == 0 {
+			break
+		}
+		commitIter, err = commitIter.Parent(0)
+		if err != nil {
+			return fmt.Errorf("error iterating over unpushed commits: %w", err)
+		}
+	}
+
+	// reset to the remote state and re-apply the changes, uncommitted changes are restored afterwards
+	snapshot, err := r.snapshotWorktree()
+	if err != nil {
+		return err
+	}
+	if err := w.Reset(&git.ResetOptions{Commit: remoteCommit.Hash, Mode: git.HardReset}); err != nil {
+		return fmt.Errorf("error during 'git reset': %w", err)
+	}
+	for _, path := range deletedFiles {
+		if _, ok := changedFiles[path]; ok {
+			continue
+		}
+		if err := r.Fs.Remove(path); err != nil && !vfs.IsErrNotExist(err) {
+			return fmt.Errorf("error removing file '%s': %w", path, err)
+		}
+	}
+	for path, content := range changedFiles {
+		if err := r.Fs.MkdirAll(vfs.Dir(r.Fs, path), os.ModeDir|os.ModePerm); err != nil {
+			return fmt.Errorf("error creating parent directories for file '%s': %w", path, err)
+		}
+		if err := vfs.WriteFile(r.Fs, path, content, os.ModePerm); err != nil {
+			return fmt.Errorf("error writing file '%s': %w", path, err)
+		}
+	}
+	// messages have been collected from newest to oldest
+	for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+		msgs[i], msgs[j] = msgs[j], msgs[i]
+	}
+	if _, err := r.gitCommit(strings.Join(msgs, "\n")); err != nil {
+		return err
+	}
+	return r.restoreWorktree(snapshot)
+}
+
 func (r *GitRepo) gitPull(force bool) error {
+	if r.usesCLI() {
+		return r.cliPull()
+	}
 	w, err := r.repo.Worktree()
 	if err != nil {
 		return fmt.Errorf("error getting worktree: %w", err)
 	}
 
 	pullOptions := &git.PullOptions{
-		RemoteName:    defaultRemoteName,
+		RemoteName:    r.RemoteName,
 		SingleBranch:  true,
 		ReferenceName: plumbing.NewBranchReferenceName(r.Branch),
 		Auth:          r.Auth,
@@ -336,7 +787,13 @@ func (r *GitRepo) gitOpen() error {
 		}
 	}
 
-	err := r.gitCheckout()
+	err := r.gitReconcileRemote()
+	if err != nil {
+		r.repo = nil
+		return err
+	}
+
+	err = r.gitCheckout()
 	if err != nil {
 		r.repo = nil
 		return err
@@ -351,6 +808,40 @@ func (r *GitRepo) gitOpen() error {
 	return nil
 }
 
+// gitReconcileRemote ensures that the remote with the configured name exists and points to the configured URL.
+// This is required for repositories which have not been cloned by this code, e.g. by an init container.
+// Other remotes as well as the refspecs of an existing remote are kept.
+func (r *GitRepo) gitReconcileRemote() error {
+	remote, err := r.repo.Remote(r.RemoteName)
+	if err != nil && !errors.Is(err, git.ErrRemoteNotFound) {
+		return fmt.Errorf("error reading remote '%s': %w", r.RemoteName, err)
+	}
+	fetch := []gitcfg.RefSpec{refspecFromBranch(r.Branch)}
+	if remote != nil {
+		remoteCfg := remote.Config()
+		if len(remoteCfg.URLs) == 1 && remoteCfg.URLs[0] == r.URL {
+			return nil
+		}
+		if len(remoteCfg.Fetch) > 0 {
+			fetch = remoteCfg.Fetch
+		}
+		// the URLs of an existing remote cannot be updated reliably, so the remote is re-created instead
+		if err := r.repo.DeleteRemote(r.RemoteName); err != nil {
+			return fmt.Errorf("error during 'git remote remove': %w", err)
+		}
+	}
+
+	_, err = r.repo.CreateRemote(&gitcfg.RemoteConfig{
+		Name:  r.RemoteName,
+		URLs:  []string{r.URL},
+		Fetch: fetch,
+	})
+	if err != nil {
+		return fmt.Errorf("error during 'git remote add': %w", err)
+	}
+	return nil
+}
+
 func (r *GitRepo) gitCheckout() error {
 	w, err := r.repo.Worktree()
 	if err != nil {
@@ -360,7 +851,7 @@ func (r *GitRepo) gitCheckout() error {
 	branchRef := plumbing.NewBranchReferenceName(r.Branch)
 	// try to fetch branch from remote
 	fetchOptions := &git.FetchOptions{
-		RemoteName: defaultRemoteName,
+		RemoteName: r.RemoteName,
 		RefSpecs:   []gitcfg.RefSpec{refspecFromBranch(r.Branch)},
 		Auth:       r.Auth,
 	}
@@ -381,31 +872,22 @@ func (r *GitRepo) gitCheckout() error {
 	_, err = r.repo.Storer.Reference(branchRef)
 	branchExists := err == nil
 
-	hash := plumbing.ZeroHash
 	if !branchExists {
 		// branch has to be created
 		// check if there is a commit hash for HEAD
-		_, err := r.repo.Head()
-		if err != nil {
-			// go-git currently cannot create new branches on 'empty' repositories (no head commit in current branch), see
+		if _, err := r.repo.Head(); err != nil {
+			if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+				return fmt.Errorf("error resolving HEAD: %w", err)
+			}
+			// there is no commit to create the branch from (e.g. because the remote repository is empty)
+			// go-git cannot create branches on 'empty' repositories via checkout, see
 			// https://github.com/go-git/go-git/issues/481
 			// https://github.com/go-git/go-git/issues/587
-			// this is a workaround which creates an empty dummy commit in order to have a hash to create the branch from
-			hash, err = w.Commit("dummy initial commit", &git.CommitOptions{
-				AllowEmptyCommits: true,
-				Author:            K8SyncerAuthor(),
-			})
-			if err != nil {
-				return fmt.Errorf("error creating dummy initial commit: %w", err)
-			}
-
-			// re-evaluate branch existence, as the commit could have created the branch
-			_, err = r.repo.Storer.Reference(branchRef)
-			branchExists = err == nil
-			if branchExists {
-				// if the 'Create' option is false, 'Branch' and 'Hash' both specify what to checkout and are mutually exclusive
-				hash = plumbing.ZeroHash
+			// instead, point HEAD to the new branch, which will then be created by the first commit (orphan branch)
+			if err := r.repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, branchRef)); err != nil {
+				return fmt.Errorf("error creating orphan branch '%s': %w", r.Branch, err)
 			}
+			return nil
 		}
 	}
 
@@ -413,7 +895,6 @@ func (r *GitRepo) gitCheckout() error {
 		Branch: branchRef,
 		Force:  true,
 		Create: !branchExists,
-		Hash:   hash,
 	})
 	if err != nil {
 		return fmt.Errorf("error during 'git checkout': %s", err)
@@ -423,7 +904,7 @@ func (r *GitRepo) gitCheckout() error {
 }
 
 func (r *GitRepo) IsInitialized() bool {
-	return r.repo != nil
+	return r.repo != nil || r.cliInitialized
 }
 
 func refspecFromBranch(branch string) gitcfg.RefSpec {
@@ -513,3 +994,26 @@ func K8SyncerAuthor() *object.Signature {
 		When:  time.Now(),
 	}
 }
+
+// author returns the signature which is used for commits, with the commit timestamp configured for the repository.
+func (r *GitRepo) author() *object.Signature {
+	res := K8SyncerAuthor()
+	res.When = r.commitTime()
+	return res
+}
+
+// commitTime returns the timestamp for the next commit, according to CommitTimeZone and CommitTimePrecision.
+func (r *GitRepo) commitTime() time.Time {
+	now := time.Now
+	if r.Now != nil {
+		now = r.Now
+	}
+	res := now()
+	if r.CommitTimePrecision > 0 {
+		res = res.Truncate(r.CommitTimePrecision)
+	}
+	if r.CommitTimeZone != nil {
+		res = res.In(r.CommitTimeZone)
+	}
+	return res
+}
@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/gardener/k8syncer/pkg/utils/constants"
+)
+
+// commitAndPushGrouped commits and pushes all changes with one commit per group, as returned by r.CommitGroup.
+// The groups are pushed one after another, in the order of their names. The name of the group is appended to the commit message.
+// It does nothing and returns false if all changes belong to the same group.
+func (r *GitRepo) commitAndPushGrouped(log logging.Logger, pullBefore bool, msg string) (bool, error) {
+	changed, deleted, err := r.worktreeStatus()
+	if err != nil {
+		return false, err
+	}
+	isDeleted := make(map[string]bool, len(deleted))
+	for _, path := range deleted {
+		isDeleted[path] = true
+	}
+	groups := map[string][]string{}
+	excluded := []string{}
+	for _, path := range append(slices.Clone(changed), deleted...) {
+		if slices.Contains(r.CommitGroupExcludes, path) {
+			excluded = append(excluded, path)
+			continue
+		}
+		var data []byte
+		if isDeleted[path] {
+			data, err = r.committedFile(path)
+		} else {
+			data, err = vfs.ReadFile(r.Fs, path)
+		}
+		if err != nil {
+			return false, fmt.Errorf("error reading changed file '%s': %w", path, err)
+		}
+		group, err := r.CommitGroup(path, data)
+		if err != nil {
+			return false, fmt.Errorf("error determining commit group of file '%s': %w", path, err)
+		}
+		groups[group] = append(groups[group], path)
+	}
+	if len(groups) <= 1 {
+		return false, nil
+	}
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	last := names[len(names)-1]
+	groups[last] = append(groups[last], excluded...)
+	if msg == "" {
+		msg = "updated files"
+	}
+	log.Info("Committing changes in groups", constants.Logging.KEY_FILE_COUNT, len(changed)+len(deleted), constants.Logging.KEY_COMMIT_GROUP, len(names))
+	for _, name := range names {
+		paths := groups[name]
+		sort.Strings(paths)
+		groupMsg := fmt.Sprintf("%s [%s]", msg, name)
+		if name == "" {
+			groupMsg = msg
+		}
+		chunkSize := len(paths)
+		if r.CommitChunkSize > 0 {
+			chunkSize = r.CommitChunkSize
+		}
+		chunkCount := (len(paths) + chunkSize - 1) / chunkSize
+		for idx := 0; idx < chunkCount; idx++ {
+			chunk := paths[idx*chunkSize : min((idx+1)*chunkSize, len(paths))]
+			chunkMsg := groupMsg
+			if chunkCount > 1 {
+				chunkMsg = fmt.Sprintf("%s (%d/%d)", groupMsg, idx+1, chunkCount)
+			}
+			if err := r.commitFiles(chunkMsg, chunk, isDeleted); err != nil {
+				return true, err
+			}
+			r.hasUnpushedCommits = true
+			// the changes of the remaining groups are not committed yet, they are restored after integrating remote changes
+			if err := r.pushWithoutLocking(pullBefore); err != nil {
+				return true, fmt.Errorf("error pushing commit group '%s': %w", name, err)
+			}
+		}
+		log.Info("Pushed commit group", constants.Logging.KEY_COMMIT_GROUP, name, constants.Logging.KEY_FILE_COUNT, len(paths))
+	}
+	return true, nil
+}
+
+// committedFile returns the content of the given file in the last commit.
+// It returns nil if the file doesn't exist in the last commit.
+func (r *GitRepo) committedFile(path string) ([]byte, error) {
+	if r.usesCLI() {
+		out, err := r.cliRun(nil, nil, "cat-file", "blob", "HEAD:"+path)
+		if err != nil {
+			if outputContains(err, "does not exist") || outputContains(err, "not in 'HEAD'") || outputContains(err, "invalid object name") {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return []byte(out), nil
+	}
+	head, err := r.repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("error getting local head: %w", err)
+	}
+	commit, err := r.repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("error getting local head commit: %w", err)
+	}
+	file, err := commit.File(path)
+	if err != nil {
+		if errors.Is(err, object.ErrFileNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading file from local head commit: %w", err)
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("error reading file from local head commit: %w", err)
+	}
+	return []byte(content), nil
+}
+
+// commitFiles commits the changes of the given files, the given set contains the ones which have been deleted.
+func (r *GitRepo) commitFiles(msg string, paths []string, isDeleted map[string]bool) error {
+	if r.usesCLI() {
+		_, err := r.cliCommit(msg, paths...)
+		return err
+	}
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("error getting worktree: %w", err)
+	}
+	for _, path := range paths {
+		if isDeleted[path] {
+			_, err = w.Remove(path)
+		} else {
+			// the status is already known, computing it again for each file would be very slow for large change sets
+			err = w.AddWithOptions(&git.AddOptions{Path: path, SkipStatus: true})
+		}
+		if err != nil {
+			return fmt.Errorf("error during 'git add': %w", err)
+		}
+	}
+	_, err = w.Commit(msg, &git.CommitOptions{
+		Author: r.author(),
+	})
+	if err != nil {
+		return fmt.Errorf("error during 'git commit': %w", err)
+	}
+	return nil
+}
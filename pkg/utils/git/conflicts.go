@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/gardener/k8syncer/pkg/config"
+)
+
+// Conflict describes a file which has been modified both locally and on the remote.
+type Conflict struct {
+	// Path is the path of the file, relative to the repository root.
+	Path string
+	// Local is the content of the local version of the file, it is nil if the file has been deleted locally.
+	Local []byte
+	// Remote is the content of the remote version of the file, it is nil if the file has been deleted on the remote.
+	Remote []byte
+}
+
+// ConflictError is returned by CommitAndPush and Push if conflicts occurred and the conflict policy is 'failAndAlert'.
+// The remote versions of the conflicting files have been kept, all other changes have been pushed.
+type ConflictError struct {
+	// Paths contains the paths of the conflicting files, relative to the repository root.
+	Paths []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("conflicting remote changes to %s", strings.Join(e.Paths, ", "))
+}
+
+// ResourceError marks conflicts as caused by the data of single resources, see persist.ResourceError.
+func (e *ConflictError) ResourceError() {}
+
+// IsConflictError returns true if the given error is or wraps a ConflictError.
+func IsConflictError(err error) bool {
+	ce := &ConflictError{}
+	return errors.As(err, &ce)
+}
+
+// keepsRemote returns true if the remote version of conflicting files is kept.
+func (r *GitRepo) keepsRemote() bool {
+	return r.ConflictPolicy == config.GIT_CONFLICT_POLICY_PREFER_REMOTE || r.ConflictPolicy == config.GIT_CONFLICT_POLICY_FAIL_AND_ALERT
+}
+
+// resolveConflicts reports the given conflicts via OnConflict and remembers them for the ConflictError, if required.
+// It returns the paths of the files whose local changes must not be re-applied, because the remote versions should be kept.
+func (r *GitRepo) resolveConflicts(conflicts []*Conflict) map[string]struct{} {
+	keep := map[string]struct{}{}
+	if len(r.ConflictExcludes) > 0 {
+		filtered := make([]*Conflict, 0, len(conflicts))
+		for _, c := range conflicts {
+			if !slices.Contains(r.ConflictExcludes, c.Path) {
+				filtered = append(filtered, c)
+			}
+		}
+		conflicts = filtered
+	}
+	if len(conflicts) == 0 {
+		return keep
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Path < conflicts[j].Path })
+	for _, c := range conflicts {
+		if r.keepsRemote() {
+			keep[c.Path] = struct{}{}
+		}
+		if r.ConflictPolicy == config.GIT_CONFLICT_POLICY_FAIL_AND_ALERT {
+			r.unresolvedConflicts = append(r.unresolvedConflicts, c.Path)
+		}
+	}
+	if r.OnConflict != nil {
+		r.OnConflict(conflicts)
+	}
+	return keep
+}
+
+// withoutPaths returns the given paths without the ones contained in the given set.
+func withoutPaths(paths []string, remove map[string]struct{}) []string {
+	if len(remove) == 0 {
+		return paths
+	}
+	res := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if _, ok := remove[path]; !ok {
+			res = append(res, path)
+		}
+	}
+	return res
+}
+
+// conflictError returns a ConflictError for the conflicts which have been kept unresolved since the last call, if any.
+func (r *GitRepo) conflictError() error {
+	if len(r.unresolvedConflicts) == 0 {
+		return nil
+	}
+	paths := r.unresolvedConflicts
+	r.unresolvedConflicts = nil
+	sort.Strings(paths)
+	return &ConflictError{Paths: paths}
+}
+
+// cliDetectConflicts is the equivalent of detectConflicts for the git binary.
+// base may be the empty tree hash if the local and the remote branch don't have a common history.
+func (r *GitRepo) cliDetectConflicts(base, localCommit, remoteCommit string, changed, deleted []string) ([]*Conflict, error) {
+	if len(changed) == 0 && len(deleted) == 0 {
+		return nil, nil
+	}
+	remoteChanged, err := r.cliChangedPaths(base, remoteCommit)
+	if err != nil {
+		return nil, err
+	}
+	// files which have the same content locally and on the remote are not conflicting
+	differing, err := r.cliChangedPaths(localCommit, remoteCommit)
+	if err != nil {
+		return nil, err
+	}
+	conflicts := []*Conflict{}
+	for _, path := range append(append([]string{}, changed...), deleted...) {
+		_, remotelyChanged := remoteChanged[path]
+		_, differs := differing[path]
+		if !remotelyChanged || !differs {
+			continue
+		}
+		c := &Conflict{Path: path}
+		if c.Local, err = r.cliFileContent(localCommit, path); err != nil {
+			return nil, err
+		}
+		if c.Remote, err = r.cliFileContent(remoteCommit, path); err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, c)
+	}
+	return conflicts, nil
+}
+
+// cliChangedPaths returns the paths of all files which differ between the given commits.
+func (r *GitRepo) cliChangedPaths(from, to string) (map[string]struct{}, error) {
+	out, err := r.cliRun(nil, nil, "diff", "--name-only", "-z", "--no-renames", from, to)
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]struct{}{}
+	for _, path := range strings.Split(strings.TrimSuffix(out, "\x00"), "\x00") {
+		if path != "" {
+			res[path] = struct{}{}
+		}
+	}
+	return res, nil
+}
+
+// cliFileContent returns the content of the given file in the given commit, or nil if the file doesn't exist there.
+func (r *GitRepo) cliFileContent(commit, path string) ([]byte, error) {
+	out, err := r.cliRun(nil, nil, "ls-tree", "-z", "--name-only", commit, "--", path)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	out, err = r.cliRun(nil, nil, "cat-file", "blob", commit+":"+path)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+// detectConflicts returns the locally changed or deleted files which have been modified on the remote too, in a different way.
+func detectConflicts(baseTree *object.Tree, remoteCommit *object.Commit, changedFiles map[string][]byte, deletedFiles []string) ([]*Conflict, error) {
+	remoteTree, err := remoteCommit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("error getting remote tree: %w", err)
+	}
+	remoteChanges, err := object.DiffTree(baseTree, remoteTree)
+	if err != nil {
+		return nil, fmt.Errorf("error computing remote changes: %w", err)
+	}
+	// maps the paths of all files which have been changed on the remote to their remote content, nil for deleted files
+	remoteFiles := map[string][]byte{}
+	for _, change := range remoteChanges {
+		_, to, err := change.Files()
+		if err != nil {
+			return nil, fmt.Errorf("error reading remotely changed files: %w", err)
+		}
+		if to == nil {
+			remoteFiles[change.From.Name] = nil
+			continue
+		}
+		if change.From.Name != "" && change.From.Name != change.To.Name {
+			remoteFiles[change.From.Name] = nil
+		}
+		content, err := to.Contents()
+		if err != nil {
+			return nil, fmt.Errorf("error reading remote content of file '%s': %w", change.To.Name, err)
+		}
+		remoteFiles[change.To.Name] = []byte(content)
+	}
+	conflicts := []*Conflict{}
+	for path, local := range changedFiles {
+		if remote, ok := remoteFiles[path]; ok && (remote == nil || string(remote) != string(local)) {
+			conflicts = append(conflicts, &Conflict{Path: path, Local: local, Remote: remote})
+		}
+	}
+	for _, path := range deletedFiles {
+		if _, ok := changedFiles[path]; ok {
+			continue
+		}
+		if remote, ok := remoteFiles[path]; ok && remote != nil {
+			conflicts = append(conflicts, &Conflict{Path: path, Remote: remote})
+		}
+	}
+	return conflicts, nil
+}
@@ -5,14 +5,28 @@
 package git
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gardener/landscaper/controller-utils/pkg/logging"
+	"github.com/go-git/go-git/v5"
+	gitcfg "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/mandelsoft/vfs/pkg/osfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils/credentials"
 )
 
 func TestConfig(t *testing.T) {
@@ -22,6 +36,13 @@ func TestConfig(t *testing.T) {
 
 var staticDiscardLogger = logging.Discard()
 
+// failingProvider is a credentials.Provider which never returns credentials.
+type failingProvider struct{}
+
+func (failingProvider) Credentials(_ context.Context) (*credentials.Credentials, error) {
+	return nil, errors.New("vault is sealed")
+}
+
 var _ = Describe("Git Wrapper Tests", func() {
 
 	var dr *DummyRemote
@@ -62,6 +83,466 @@ var _ = Describe("Git Wrapper Tests", func() {
 		Expect(dstData).To(Equal(srcData))
 	})
 
+	It("should re-apply local changes on top of the remote branch if the push is rejected", func() {
+		repo1, err := dr.NewRepo()
+		Expect(err).ToNot(HaveOccurred())
+
+		repo2, err := dr.NewRepo()
+		Expect(err).ToNot(HaveOccurred())
+
+		// both repos commit different changes, repo1 pushes first
+		file1 := "file1"
+		data1 := []byte("foo")
+		Expect(vfs.WriteFile(repo1.Fs, file1, data1, os.ModePerm)).To(Succeed())
+		Expect(repo1.CommitAndPush(staticDiscardLogger, false, "")).To(Succeed())
+
+		file2 := "dir/file2"
+		data2 := []byte("bar")
+		Expect(repo2.Fs.MkdirAll("dir", os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(repo2.Fs, file2, data2, os.ModePerm)).To(Succeed())
+		// the push from repo2 is rejected at first and has to be retried on top of the new remote head
+		Expect(repo2.CommitAndPush(staticDiscardLogger, false, "")).To(Succeed())
+
+		// repo2 should contain both files
+		data, err := vfs.ReadFile(repo2.Fs, file1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(data1))
+		data, err = vfs.ReadFile(repo2.Fs, file2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(data2))
+
+		// the remote should contain both files
+		Expect(repo1.Pull(staticDiscardLogger)).To(Succeed())
+		data, err = vfs.ReadFile(repo1.Fs, file2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(data2))
+	})
+
+	It("should not create any commits when initializing a repository for an empty remote", func() {
+		repo, err := dr.NewRepo()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(vfs.WriteFile(repo.Fs, "foofile", []byte("testvalue"), os.ModePerm)).To(Succeed())
+		Expect(repo.CommitAndPush(staticDiscardLogger, false, "first")).To(Succeed())
+
+		ref, err := dr.Repo.Reference(plumbing.NewBranchReferenceName(dr.Branch), true)
+		Expect(err).ToNot(HaveOccurred())
+		commit, err := dr.Repo.CommitObject(ref.Hash())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(commit.Message).To(Equal("first"))
+		Expect(commit.NumParents()).To(BeZero())
+	})
+
+	It("should split large change sets into chunks", func() {
+		repo, err := dr.NewRepo()
+		Expect(err).ToNot(HaveOccurred())
+		repo.CommitChunkSize = 2
+
+		remoteMessages := func() []string {
+			ref, err := dr.Repo.Reference(plumbing.NewBranchReferenceName(dr.Branch), true)
+			Expect(err).ToNot(HaveOccurred())
+			commits, err := dr.Repo.Log(&git.LogOptions{From: ref.Hash()})
+			Expect(err).ToNot(HaveOccurred())
+			res := []string{}
+			Expect(commits.ForEach(func(c *object.Commit) error {
+				res = append(res, c.Message)
+				return nil
+			})).To(Succeed())
+			return res
+		}
+
+		// go-git cannot delete the last file of a repository, so one file is kept
+		for _, name := range []string{"a", "b", "c", "d", "preventEmpty"} {
+			Expect(vfs.WriteFile(repo.Fs, name, []byte(name), os.ModePerm)).To(Succeed())
+		}
+		Expect(repo.CommitAndPush(staticDiscardLogger, false, "add")).To(Succeed())
+		Expect(remoteMessages()).To(Equal([]string{"add (3/3)", "add (2/3)", "add (1/3)"}))
+
+		for _, name := range []string{"a", "b", "c"} {
+			Expect(repo.Fs.Remove(name)).To(Succeed())
+		}
+		Expect(vfs.WriteFile(repo.Fs, "d", []byte("changed"), os.ModePerm)).To(Succeed())
+		Expect(repo.CommitAndPush(staticDiscardLogger, false, "remove")).To(Succeed())
+		Expect(remoteMessages()).To(HaveLen(5))
+
+		// small change sets are committed at once
+		Expect(vfs.WriteFile(repo.Fs, "e", []byte("e"), os.ModePerm)).To(Succeed())
+		Expect(repo.CommitAndPush(staticDiscardLogger, false, "small")).To(Succeed())
+		Expect(remoteMessages()[0]).To(Equal("small"))
+
+		// the remote should contain the final state
+		clone, err := dr.NewRepo()
+		Expect(err).ToNot(HaveOccurred())
+		files, err := vfs.ReadDir(clone.Fs, ".")
+		Expect(err).ToNot(HaveOccurred())
+		names := []string{}
+		for _, f := range files {
+			names = append(names, f.Name())
+		}
+		Expect(names).To(ConsistOf(".git", "d", "e", "preventEmpty"))
+		data, err := vfs.ReadFile(clone.Fs, "d")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("changed")))
+	})
+
+	It("should not lose the changes of later chunks when integrating remote changes", func() {
+		for _, backend := range []config.GitBackend{config.GIT_BACKEND_GO_GIT, config.GIT_BACKEND_CLI} {
+			if _, err := exec.LookPath("git"); err != nil && backend == config.GIT_BACKEND_CLI {
+				continue
+			}
+			remote, err := NewDummyRemote(osfs.OsFs, "foo")
+			Expect(err).ToNot(HaveOccurred())
+			defer remote.Close()
+			tmpdir, err := vfs.TempDir(remote.Fs, "", "repo-")
+			Expect(err).ToNot(HaveOccurred())
+			repo, err := NewRepo(remote.Fs, remote.RootPath, remote.Branch, tmpdir, nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+			repo.Backend = backend
+			repo.CommitChunkSize = 2
+			Expect(repo.Initialize(staticDiscardLogger)).To(Succeed())
+			Expect(vfs.WriteFile(repo.Fs, "preventEmpty", []byte("x"), os.ModePerm)).To(Succeed())
+			Expect(repo.CommitAndPush(staticDiscardLogger, false, "init")).To(Succeed())
+
+			// the remote is moved by another repository
+			other, err := remote.NewRepo()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vfs.WriteFile(other.Fs, "other", []byte("other"), os.ModePerm)).To(Succeed())
+			Expect(other.CommitAndPush(staticDiscardLogger, false, "other")).To(Succeed())
+
+			for _, name := range []string{"a", "b", "c", "d", "e"} {
+				Expect(vfs.WriteFile(repo.Fs, name, []byte(name), os.ModePerm)).To(Succeed())
+			}
+			Expect(repo.CommitAndPush(staticDiscardLogger, true, "add")).To(Succeed(), "backend %s", backend)
+
+			// the remote should contain all files
+			clone, err := remote.NewRepo()
+			Expect(err).ToNot(HaveOccurred())
+			for _, name := range []string{"a", "b", "c", "d", "e", "other"} {
+				data, err := vfs.ReadFile(clone.Fs, name)
+				Expect(err).ToNot(HaveOccurred(), "backend %s, file %s", backend, name)
+				Expect(string(data)).To(Equal(name))
+			}
+			changed, deleted, err := repo.worktreeStatus()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeEmpty())
+			Expect(deleted).To(BeEmpty())
+		}
+	})
+
+	It("should fail pushes with the cause if a credentials provider has no credentials", func() {
+		repo, err := dr.NewRepo()
+		Expect(err).ToNot(HaveOccurred())
+		repo.Auth = NewProviderAuth(config.GIT_AUTH_USERNAME_PASSWORD, failingProvider{})
+
+		Expect(vfs.WriteFile(repo.Fs, "foofile", []byte("testvalue"), os.ModePerm)).To(Succeed())
+		err = repo.CommitAndPush(staticDiscardLogger, false, "")
+		Expect(err).To(MatchError(transport.ErrAuthorizationFailed))
+		Expect(err.Error()).To(ContainSubstring("vault is sealed"))
+	})
+
+	It("should use the configured commit timestamps", func() {
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		Expect(err).ToNot(HaveOccurred())
+		now := time.Date(2023, 5, 31, 6, 29, 46, 123, time.UTC)
+		expected := time.Date(2023, 5, 31, 8, 29, 0, 0, berlin)
+
+		headCommit := func() *object.Commit {
+			ref, err := dr.Repo.Reference(plumbing.NewBranchReferenceName(dr.Branch), true)
+			Expect(err).ToNot(HaveOccurred())
+			commit, err := dr.Repo.CommitObject(ref.Hash())
+			Expect(err).ToNot(HaveOccurred())
+			return commit
+		}
+
+		repo, err := dr.NewRepo()
+		Expect(err).ToNot(HaveOccurred())
+		repo.CommitTimeZone = berlin
+		repo.CommitTimePrecision = time.Minute
+		repo.Now = func() time.Time { return now }
+		Expect(vfs.WriteFile(repo.Fs, "file1", []byte("foo"), os.ModePerm)).To(Succeed())
+		Expect(repo.CommitAndPush(staticDiscardLogger, false, "go-git")).To(Succeed())
+		commit := headCommit()
+		Expect(commit.Author.When.Equal(expected)).To(BeTrue(), "unexpected author timestamp %s", commit.Author.When.String())
+		_, offset := commit.Author.When.Zone()
+		Expect(offset).To(Equal(2 * 60 * 60))
+
+		// the git binary uses the same timestamps
+		tmpdir, err := vfs.TempDir(dr.Fs, "", "repo-")
+		Expect(err).ToNot(HaveOccurred())
+		cliRepo, err := NewRepo(dr.Fs, dr.RootPath, dr.Branch, tmpdir, nil, nil)
+		Expect(err).ToNot(HaveOccurred())
+		cliRepo.Backend = config.GIT_BACKEND_CLI
+		cliRepo.CommitTimeZone = berlin
+		cliRepo.CommitTimePrecision = time.Minute
+		cliRepo.Now = func() time.Time { return now.Add(10 * time.Second) }
+		Expect(cliRepo.Initialize(staticDiscardLogger)).To(Succeed())
+		Expect(vfs.WriteFile(cliRepo.Fs, "file2", []byte("bar"), os.ModePerm)).To(Succeed())
+		Expect(cliRepo.CommitAndPush(staticDiscardLogger, false, "cli")).To(Succeed())
+		commit = headCommit()
+		Expect(commit.Message).To(HavePrefix("cli"))
+		for _, sig := range []object.Signature{commit.Author, commit.Committer} {
+			Expect(sig.When.Equal(expected)).To(BeTrue(), "unexpected timestamp %s", sig.When.String())
+			_, offset := sig.When.Zone()
+			Expect(offset).To(Equal(2 * 60 * 60))
+		}
+	})
+
+	It("should create one commit per commit group", func() {
+		for _, backend := range []config.GitBackend{config.GIT_BACKEND_GO_GIT, config.GIT_BACKEND_CLI} {
+			if _, err := exec.LookPath("git"); err != nil && backend == config.GIT_BACKEND_CLI {
+				continue
+			}
+			remote, err := NewDummyRemote(osfs.OsFs, "foo")
+			Expect(err).ToNot(HaveOccurred())
+			defer remote.Close()
+			tmpdir, err := vfs.TempDir(remote.Fs, "", "repo-")
+			Expect(err).ToNot(HaveOccurred())
+			repo, err := NewRepo(remote.Fs, remote.RootPath, remote.Branch, tmpdir, nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+			repo.Backend = backend
+			// the group is the part of the content before the colon, deleted files are grouped by their last committed content
+			repo.CommitGroup = func(path string, data []byte) (string, error) {
+				group, _, _ := strings.Cut(string(data), ":")
+				return group, nil
+			}
+			repo.CommitGroupExcludes = []string{"summary"}
+			Expect(repo.Initialize(staticDiscardLogger)).To(Succeed())
+
+			remoteCommits := func() []*object.Commit {
+				ref, err := remote.Repo.Reference(plumbing.NewBranchReferenceName(remote.Branch), true)
+				Expect(err).ToNot(HaveOccurred())
+				commits, err := remote.Repo.Log(&git.LogOptions{From: ref.Hash()})
+				Expect(err).ToNot(HaveOccurred())
+				res := []*object.Commit{}
+				Expect(commits.ForEach(func(c *object.Commit) error {
+					res = append(res, c)
+					return nil
+				})).To(Succeed())
+				return res
+			}
+			changedFiles := func(c *object.Commit) []string {
+				stats, err := c.Stats()
+				Expect(err).ToNot(HaveOccurred())
+				res := []string{}
+				for _, s := range stats {
+					res = append(res, s.Name)
+				}
+				return res
+			}
+
+			for name, content := range map[string]string{"a": "team-a:a", "b": "team-b:b", "c": "team-a:c", "summary": "all", "preventEmpty": ":"} {
+				Expect(vfs.WriteFile(repo.Fs, name, []byte(content), os.ModePerm)).To(Succeed())
+			}
+			Expect(repo.CommitAndPush(staticDiscardLogger, false, "add")).To(Succeed())
+			commits := remoteCommits()
+			Expect(commits).To(HaveLen(3), "backend %s", backend)
+			Expect(strings.TrimSpace(commits[0].Message)).To(Equal("add [team-b]"))
+			Expect(changedFiles(commits[0])).To(ConsistOf("b", "summary"))
+			Expect(strings.TrimSpace(commits[1].Message)).To(Equal("add [team-a]"))
+			Expect(changedFiles(commits[1])).To(ConsistOf("a", "c"))
+			Expect(strings.TrimSpace(commits[2].Message)).To(Equal("add"))
+			Expect(changedFiles(commits[2])).To(ConsistOf("preventEmpty"))
+
+			Expect(repo.Fs.Remove("a")).To(Succeed())
+			Expect(repo.Fs.Remove("b")).To(Succeed())
+			Expect(repo.CommitAndPush(staticDiscardLogger, false, "remove")).To(Succeed())
+			commits = remoteCommits()
+			Expect(commits).To(HaveLen(5), "backend %s", backend)
+			Expect(strings.TrimSpace(commits[0].Message)).To(Equal("remove [team-b]"))
+			Expect(changedFiles(commits[0])).To(ConsistOf("b"))
+			Expect(strings.TrimSpace(commits[1].Message)).To(Equal("remove [team-a]"))
+			Expect(changedFiles(commits[1])).To(ConsistOf("a"))
+
+			// changes which belong to a single group are committed as usual
+			Expect(vfs.WriteFile(repo.Fs, "c", []byte("team-a:changed"), os.ModePerm)).To(Succeed())
+			Expect(repo.CommitAndPush(staticDiscardLogger, false, "single")).To(Succeed())
+			commits = remoteCommits()
+			Expect(commits).To(HaveLen(6), "backend %s", backend)
+			Expect(strings.TrimSpace(commits[0].Message)).To(Equal("single"))
+
+			// the changes of later groups must survive the integration of remote changes for the earlier ones
+			other, err := remote.NewRepo()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vfs.WriteFile(other.Fs, "other", []byte(":other"), os.ModePerm)).To(Succeed())
+			Expect(other.CommitAndPush(staticDiscardLogger, false, "other")).To(Succeed())
+			for name, content := range map[string]string{"a": "team-a:new", "b": "team-b:new", "d": "team-c:new"} {
+				Expect(vfs.WriteFile(repo.Fs, name, []byte(content), os.ModePerm)).To(Succeed())
+			}
+			Expect(repo.CommitAndPush(staticDiscardLogger, true, "moved")).To(Succeed(), "backend %s", backend)
+			clone, err := remote.NewRepo()
+			Expect(err).ToNot(HaveOccurred())
+			for name, content := range map[string]string{"a": "team-a:new", "b": "team-b:new", "d": "team-c:new", "other": ":other"} {
+				data, err := vfs.ReadFile(clone.Fs, name)
+				Expect(err).ToNot(HaveOccurred(), "backend %s, file %s", backend, name)
+				Expect(string(data)).To(Equal(content))
+			}
+		}
+	})
+
+	It("should work with the git binary as backend", func() {
+		if _, err := exec.LookPath("git"); err != nil {
+			Skip("git binary is not available")
+		}
+		newCLIRepo := func(branch string) *GitRepo {
+			tmpdir, err := vfs.TempDir(dr.Fs, "", "repo-")
+			Expect(err).ToNot(HaveOccurred())
+			repo, err := NewRepo(dr.Fs, dr.RootPath, branch, tmpdir, nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+			repo.Backend = config.GIT_BACKEND_CLI
+			Expect(repo.Initialize(staticDiscardLogger)).To(Succeed())
+			return repo
+		}
+
+		cliRepo := newCLIRepo(dr.Branch)
+		goGitRepo, err := dr.NewRepo()
+		Expect(err).ToNot(HaveOccurred())
+
+		// changes pushed via the git binary are visible to go-git
+		Expect(cliRepo.Fs.MkdirAll("dir", os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(cliRepo.Fs, "dir/file1", []byte("foo"), os.ModePerm)).To(Succeed())
+		Expect(cliRepo.CommitAndPush(staticDiscardLogger, false, "first")).To(Succeed())
+		Expect(goGitRepo.Pull(staticDiscardLogger)).To(Succeed())
+		data, err := vfs.ReadFile(goGitRepo.Fs, "dir/file1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("foo")))
+
+		// a rejected push is retried on top of the remote branch
+		Expect(vfs.WriteFile(goGitRepo.Fs, "file2", []byte("bar"), os.ModePerm)).To(Succeed())
+		Expect(goGitRepo.CommitAndPush(staticDiscardLogger, false, "")).To(Succeed())
+		Expect(cliRepo.Fs.Remove("dir/file1")).To(Succeed())
+		Expect(vfs.WriteFile(cliRepo.Fs, "file3", []byte("baz"), os.ModePerm)).To(Succeed())
+		Expect(cliRepo.CommitAndPush(staticDiscardLogger, false, "second")).To(Succeed())
+		Expect(goGitRepo.Pull(staticDiscardLogger)).To(Succeed())
+		for file, exists := range map[string]bool{"dir/file1": false, "file2": true, "file3": true} {
+			fileExists, err := vfs.FileExists(goGitRepo.Fs, file)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileExists).To(Equal(exists), "file '%s' should exist: %v", file, exists)
+		}
+		ref, err := dr.Repo.Reference(plumbing.NewBranchReferenceName(dr.Branch), true)
+		Expect(err).ToNot(HaveOccurred())
+		commit, err := dr.Repo.CommitObject(ref.Hash())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(commit.Message).To(HavePrefix("second"))
+		Expect(commit.Author.Name).To(Equal(K8SyncerAuthor().Name))
+
+		// nothing is committed if there are no changes
+		Expect(cliRepo.CommitAndPush(staticDiscardLogger, false, "nothing")).To(Succeed())
+		ref2, err := dr.Repo.Reference(plumbing.NewBranchReferenceName(dr.Branch), true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ref2.Hash()).To(Equal(ref.Hash()))
+
+		// other branches can be created, listed and deleted
+		branchRepo := newCLIRepo("ns/bar")
+		Expect(vfs.FileExists(branchRepo.Fs, "file1")).To(BeFalse())
+		Expect(vfs.WriteFile(branchRepo.Fs, "file4", []byte("qux"), os.ModePerm)).To(Succeed())
+		Expect(branchRepo.CommitAndPush(staticDiscardLogger, false, "")).To(Succeed())
+		branches, err := cliRepo.RemoteBranches(staticDiscardLogger, "ns/")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(branches).To(Equal([]string{"ns/bar"}))
+		Expect(branchRepo.DeleteRemoteBranch(staticDiscardLogger)).To(Succeed())
+		Expect(branchRepo.DeleteRemoteBranch(staticDiscardLogger)).To(Succeed())
+		branches, err = cliRepo.RemoteBranches(staticDiscardLogger, "ns/")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(branches).To(BeEmpty())
+	})
+
+	It("should resolve conflicts according to the conflict policy", func() {
+		backends := []config.GitBackend{config.GIT_BACKEND_GO_GIT}
+		if _, err := exec.LookPath("git"); err == nil {
+			backends = append(backends, config.GIT_BACKEND_CLI)
+		}
+		for _, backend := range backends {
+			for _, policy := range []config.GitConflictPolicy{config.GIT_CONFLICT_POLICY_PREFER_CLUSTER, config.GIT_CONFLICT_POLICY_PREFER_REMOTE, config.GIT_CONFLICT_POLICY_FAIL_AND_ALERT} {
+				By(fmt.Sprintf("using backend '%s' with policy '%s'", backend, policy))
+				remote, err := dr.NewRepo()
+				Expect(err).ToNot(HaveOccurred())
+				tmpdir, err := vfs.TempDir(dr.Fs, "", "repo-")
+				Expect(err).ToNot(HaveOccurred())
+				repo, err := NewRepo(dr.Fs, dr.RootPath, dr.Branch, tmpdir, nil, nil)
+				Expect(err).ToNot(HaveOccurred())
+				repo.Backend = backend
+				repo.ConflictPolicy = policy
+				var reported []*Conflict
+				repo.OnConflict = func(conflicts []*Conflict) {
+					reported = append(reported, conflicts...)
+				}
+				Expect(repo.Initialize(staticDiscardLogger)).To(Succeed())
+
+				Expect(vfs.WriteFile(remote.Fs, "conflict", []byte("base"), os.ModePerm)).To(Succeed())
+				Expect(vfs.WriteFile(remote.Fs, "same", []byte("base"), os.ModePerm)).To(Succeed())
+				Expect(remote.CommitAndPush(staticDiscardLogger, false, "")).To(Succeed())
+				Expect(repo.Pull(staticDiscardLogger)).To(Succeed())
+
+				Expect(vfs.WriteFile(remote.Fs, "conflict", []byte("remote"), os.ModePerm)).To(Succeed())
+				Expect(vfs.WriteFile(remote.Fs, "same", []byte("changed"), os.ModePerm)).To(Succeed())
+				Expect(remote.CommitAndPush(staticDiscardLogger, false, "")).To(Succeed())
+
+				Expect(vfs.WriteFile(repo.Fs, "conflict", []byte("local"), os.ModePerm)).To(Succeed())
+				Expect(vfs.WriteFile(repo.Fs, "same", []byte("changed"), os.ModePerm)).To(Succeed())
+				Expect(vfs.WriteFile(repo.Fs, "other", []byte("local"), os.ModePerm)).To(Succeed())
+				err = repo.CommitAndPush(staticDiscardLogger, true, "")
+				if policy == config.GIT_CONFLICT_POLICY_FAIL_AND_ALERT {
+					Expect(err).To(MatchError(&ConflictError{Paths: []string{"conflict"}}))
+				} else {
+					Expect(err).ToNot(HaveOccurred())
+				}
+				Expect(reported).To(HaveLen(1))
+				Expect(reported[0]).To(Equal(&Conflict{Path: "conflict", Local: []byte("local"), Remote: []byte("remote")}))
+
+				expected := "local"
+				if policy != config.GIT_CONFLICT_POLICY_PREFER_CLUSTER {
+					expected = "remote"
+				}
+				Expect(remote.Pull(staticDiscardLogger)).To(Succeed())
+				for file, content := range map[string]string{"conflict": expected, "same": "changed", "other": "local"} {
+					data, err := vfs.ReadFile(remote.Fs, file)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(data)).To(Equal(content), "unexpected content of file '%s'", file)
+				}
+				Expect(remote.Fs.Remove("other")).To(Succeed())
+				Expect(remote.CommitAndPush(staticDiscardLogger, false, "")).To(Succeed())
+			}
+		}
+	})
+
+	It("should reconcile the remote configuration of existing repositories", func() {
+		srcRepo, err := dr.NewRepo()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(srcRepo.Fs, "foofile", []byte("testvalue"), os.ModePerm)).To(Succeed())
+		Expect(srcRepo.CommitAndPush(staticDiscardLogger, false, "")).To(Succeed())
+
+		// simulate a clone with an outdated remote URL and a wildcard refspec
+		cfg, err := srcRepo.repo.Config()
+		Expect(err).ToNot(HaveOccurred())
+		cfg.Remotes[defaultRemoteName].URLs = []string{"/does/not/exist"}
+		cfg.Remotes[defaultRemoteName].Fetch = []gitcfg.RefSpec{"+refs/heads/*:refs/remotes/origin/*"}
+		Expect(srcRepo.repo.SetConfig(cfg)).To(Succeed())
+
+		// existing remote with outdated URL
+		repo, err := NewRepo(osfs.OsFs, dr.RootPath, dr.Branch, srcRepo.LocalPath, nil, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(repo.Initialize(staticDiscardLogger)).To(Succeed())
+		cfg, err = repo.repo.Config()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Remotes).To(HaveLen(1))
+		Expect(cfg.Remotes[defaultRemoteName].URLs).To(Equal([]string{dr.RootPath}))
+		Expect(cfg.Remotes[defaultRemoteName].Fetch).To(ConsistOf(gitcfg.RefSpec("+refs/heads/*:refs/remotes/origin/*")))
+
+		// missing remote with custom name
+		repo, err = NewRepo(osfs.OsFs, dr.RootPath, dr.Branch, srcRepo.LocalPath, nil, nil)
+		Expect(err).ToNot(HaveOccurred())
+		repo.RemoteName = "upstream"
+		Expect(repo.Initialize(staticDiscardLogger)).To(Succeed())
+		cfg, err = repo.repo.Config()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Remotes).To(HaveKey(defaultRemoteName))
+		Expect(cfg.Remotes).To(HaveKey("upstream"))
+		Expect(cfg.Remotes["upstream"].URLs).To(Equal([]string{dr.RootPath}))
+
+		Expect(vfs.WriteFile(repo.Fs, "barfile", []byte("testvalue"), os.ModePerm)).To(Succeed())
+		Expect(repo.CommitAndPush(staticDiscardLogger, true, "")).To(Succeed())
+	})
+
 	It("should be able to create and switch between branches on new and existing repositores", func() {
 		tempdir, err := vfs.TempDir(osfs.OsFs, "", "repo-")
 		Expect(err).ToNot(HaveOccurred())
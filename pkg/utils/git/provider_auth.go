@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	gohttp "net/http"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils/credentials"
+)
+
+var _ http.AuthMethod = &ProviderAuth{}
+var _ ssh.AuthMethod = &ProviderAuth{}
+
+// ProviderAuth is an auth method which fetches its credentials from a credentials.Provider whenever it is used.
+// This allows the credentials to change at runtime.
+// It can be used for both HTTP(S) and SSH repository URLs, depending on the configured auth type.
+type ProviderAuth struct {
+	Type     config.GitAuthenticationType
+	Provider credentials.Provider
+}
+
+// NewProviderAuth returns a new ProviderAuth.
+func NewProviderAuth(authType config.GitAuthenticationType, provider credentials.Provider) *ProviderAuth {
+	return &ProviderAuth{
+		Type:     authType,
+		Provider: provider,
+	}
+}
+
+func (a *ProviderAuth) Name() string {
+	return fmt.Sprintf("provider-%s", string(a.Type))
+}
+
+func (a *ProviderAuth) String() string {
+	return fmt.Sprintf("%s - credentials from provider", a.Name())
+}
+
+// Check returns an error if no credentials are available, because they cannot be fetched and there are no cached ones.
+// The error matches transport.ErrAuthorizationFailed and contains the cause.
+func (a *ProviderAuth) Check(ctx context.Context) error {
+	creds, err := a.Provider.Credentials(ctx)
+	if creds != nil {
+		return nil
+	}
+	if err == nil {
+		err = errors.New("provider did not return any credentials")
+	}
+	return fmt.Errorf("%w: %w", transport.ErrAuthorizationFailed, err)
+}
+
+// checkAuth calls Check if the given auth method is a ProviderAuth, so that remote operations fail with the cause
+// instead of being sent without credentials. It returns nil for all other auth methods.
+func checkAuth(auth transport.AuthMethod) error {
+	pa, ok := auth.(*ProviderAuth)
+	if !ok {
+		return nil
+	}
+	return pa.Check(context.Background())
+}
+
+// SetAuth implements http.AuthMethod.
+// If the credentials cannot be fetched and there are no cached ones, the request is sent without authentication,
+// which will cause an authorization error. Pushes call Check before, which returns the cause in this case.
+func (a *ProviderAuth) SetAuth(r *gohttp.Request) {
+	creds, _ := a.Provider.Credentials(r.Context())
+	if creds == nil {
+		return
+	}
+	username := creds.Username
+	if username == "" {
+		// set arbitrary username for access token
+		username = "anonymous"
+	}
+	r.SetBasicAuth(username, creds.Password)
+}
+
+// ClientConfig implements ssh.AuthMethod.
+func (a *ProviderAuth) ClientConfig() (*gossh.ClientConfig, error) {
+	creds, err := a.Provider.Credentials(context.Background())
+	if creds == nil {
+		return nil, err
+	}
+	publicKeys, err := ssh.NewPublicKeys("git", []byte(creds.PrivateKey), creds.Password)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create public key from provided credentials: %w", err)
+	}
+	return publicKeys.ClientConfig()
+}
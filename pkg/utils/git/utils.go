@@ -12,12 +12,19 @@ import (
 	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 
 	"github.com/gardener/k8syncer/pkg/config"
+	"github.com/gardener/k8syncer/pkg/utils/credentials"
 )
 
 func AuthFromConfig(authCfg *config.GitRepoAuth) (transport.AuthMethod, error) {
 	if authCfg == nil {
 		return nil, nil
 	}
+	if authCfg.Vault != nil {
+		return NewProviderAuth(authCfg.Type, credentials.NewVaultProvider(authCfg.Vault)), nil
+	}
+	if authCfg.PasswordFrom != nil {
+		return NewProviderAuth(authCfg.Type, credentials.NewSecretValueProvider(authCfg)), nil
+	}
 	switch authCfg.Type {
 	case config.GIT_AUTH_USERNAME_PASSWORD:
 		return &http.BasicAuth{
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// worktreeSnapshot contains the uncommitted changes of the worktree, see snapshotWorktree.
+type worktreeSnapshot struct {
+	// files maps the paths of all changed files to their content in the worktree, the content of deleted files is nil.
+	files map[string][]byte
+	// committed maps the same paths to their content in the commit the changes are based on, nil if the file didn't exist there.
+	committed map[string][]byte
+}
+
+// snapshotWorktree returns the uncommitted changes of the worktree, so that they can be restored after a hard reset.
+// This is required when pushing in chunks or groups, because the changes of the later ones are not yet committed
+// when the remote changes are integrated for the earlier ones.
+// It returns nil if there are no uncommitted changes.
+func (r *GitRepo) snapshotWorktree() (*worktreeSnapshot, error) {
+	changed, deleted, err := r.worktreeStatus()
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) == 0 && len(deleted) == 0 {
+		return nil, nil
+	}
+	s := &worktreeSnapshot{
+		files:     make(map[string][]byte, len(changed)+len(deleted)),
+		committed: make(map[string][]byte, len(changed)+len(deleted)),
+	}
+	for _, path := range changed {
+		data, err := vfs.ReadFile(r.Fs, path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading uncommitted file '%s': %w", path, err)
+		}
+		s.files[path] = data
+	}
+	for _, path := range deleted {
+		s.files[path] = nil
+	}
+	for path := range s.files {
+		if s.committed[path], err = r.committedFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// restoreWorktree re-applies the uncommitted changes of the given snapshot on top of the current head.
+// Files which have been changed in the meantime in a different way are handled according to the conflict policy.
+func (r *GitRepo) restoreWorktree(s *worktreeSnapshot) error {
+	if s == nil {
+		return nil
+	}
+	conflicts := []*Conflict{}
+	for path, local := range s.files {
+		current, err := r.committedFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(current, s.committed[path]) || bytes.Equal(current, local) {
+			continue
+		}
+		conflicts = append(conflicts, &Conflict{Path: path, Local: local, Remote: current})
+	}
+	keep := r.resolveConflicts(conflicts)
+	for path, local := range s.files {
+		if _, ok := keep[path]; ok {
+			continue
+		}
+		if local == nil {
+			if err := r.Fs.Remove(path); err != nil && !vfs.IsErrNotExist(err) {
+				return fmt.Errorf("error removing file '%s': %w", path, err)
+			}
+			continue
+		}
+		if err := r.Fs.MkdirAll(vfs.Dir(r.Fs, path), os.ModeDir|os.ModePerm); err != nil {
+			return fmt.Errorf("error creating parent directories for file '%s': %w", path, err)
+		}
+		if err := vfs.WriteFile(r.Fs, path, local, os.ModePerm); err != nil {
+			return fmt.Errorf("error writing file '%s': %w", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package providerapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Error is returned if the API responded with an unexpected status code.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("unexpected response status %d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound returns true if the given error is an Error for status 404.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsConflict returns true if the given error is an Error for status 409 or 422,
+// which is what the providers respond with if a resource which should be created exists already.
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict) || hasStatus(err, http.StatusUnprocessableEntity)
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+// AuthFunc adds the given token to the request in the way the provider expects it.
+type AuthFunc func(req *http.Request, token string)
+
+// GiteaAuth sends the token as Gitea (and Forgejo) expect it.
+func GiteaAuth(req *http.Request, token string) {
+	req.Header.Set("Authorization", "token "+token)
+}
+
+// GitLabAuth sends the token as GitLab expects it.
+func GitLabAuth(req *http.Request, token string) {
+	req.Header.Set("PRIVATE-TOKEN", token)
+}
+
+// GitHubAuth sends the token as GitHub expects it.
+func GitHubAuth(req *http.Request, token string) {
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+}
+
+// Client sends authenticated requests to the REST API of a git provider.
+// Use NewClient to instantiate it.
+type Client struct {
+	baseURL    string
+	token      string
+	tokenFile  string
+	httpClient *http.Client
+	setAuth    AuthFunc
+}
+
+// NewClient returns a client for the API with the given base URL, e.g. 'https://gitlab.example.com'.
+// Only one of token and tokenFile has to be set. If tokenFile is set, it is read for every request, so that rotated tokens are picked up.
+func NewClient(baseURL, token, tokenFile string, setAuth AuthFunc) *Client {
+	return &Client{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		token:     token,
+		tokenFile: tokenFile,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		setAuth: setAuth,
+	}
+}
+
+// Do sends a request to the API and returns the response body and headers.
+// If body is not nil, it is sent as JSON. Successful responses have a 2xx status, all others result in an Error.
+func (c *Client) Do(ctx context.Context, method, path string, body any) ([]byte, http.Header, error) {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error marshalling request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	token, err := c.currentToken()
+	if err != nil {
+		return nil, nil, err
+	}
+	c.setAuth(req, token)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, nil, &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+	}
+	return data, resp.Header, nil
+}
+
+// DoJSON sends a request to the API and unmarshals the response into res.
+func (c *Client) DoJSON(ctx context.Context, method, path string, body, res any) (http.Header, error) {
+	data, header, err := c.Do(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return header, nil
+}
+
+// currentToken returns the configured token.
+// If a token file is configured, it is read for every request.
+func (c *Client) currentToken() (string, error) {
+	if c.tokenFile == "" {
+		return c.token, nil
+	}
+	data, err := os.ReadFile(c.tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading token file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -26,6 +26,14 @@ func GVKToString(gvk schema.GroupVersionKind, suppressDotSuffix bool) string {
 }
 
 // Ptr returns a pointer to the given object.
+// IsStateAnnotation returns true if the given annotation key belongs to the state annotations which K8Syncer writes on the synced resources.
+// These are all annotations whose prefix is 'state.k8syncer.gardener.cloud' or a subdomain of it,
+// which also covers the state annotations of other K8Syncer instances with a custom annotation prefix.
+func IsStateAnnotation(key string) bool {
+	prefix, _, found := strings.Cut(key, "/")
+	return found && (prefix == constants.STATE_ANNOTATION_DOMAIN || strings.HasSuffix(prefix, "."+constants.STATE_ANNOTATION_DOMAIN))
+}
+
 func Ptr[T any](value T) *T {
 	return &value
 }
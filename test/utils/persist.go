@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/gardener/k8syncer/pkg/persist"
+	"github.com/gardener/k8syncer/pkg/persist/transformers"
+)
+
+// RoundTripOptions contains the storage specific parts of PersisterRoundTrip.
+type RoundTripOptions struct {
+	// SubPath is the sub path below which the resource is persisted.
+	SubPath string
+	// AfterPersist is called after the resource has been persisted for the first time, e.g. to verify how it is stored.
+	AfterPersist func()
+	// AfterDelete is called after the resource has been deleted, e.g. to verify that nothing is left in the storage.
+	AfterDelete func()
+}
+
+// PersisterRoundTrip persists, reads, updates, and deletes the given resource using the given persister and the basic transformer.
+// The resource must have a 'spec.value' string field, which is modified in between, and must not exist in the storage beforehand.
+func PersisterRoundTrip(ctx context.Context, p persist.Persister, resource *unstructured.Unstructured, opts *RoundTripOptions) {
+	if opts == nil {
+		opts = &RoundTripOptions{}
+	}
+	t := transformers.NewBasic()
+	name, namespace, gvk := resource.GetName(), resource.GetNamespace(), resource.GroupVersionKind()
+
+	By("persisting a new resource")
+	transformed, changed, err := p.Persist(ctx, resource, t, name, opts.SubPath)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(changed).To(BeTrue())
+	Expect(transformed.GetResourceVersion()).To(BeEmpty())
+	exists, err := p.Exists(ctx, name, namespace, gvk, opts.SubPath)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(exists).To(BeTrue())
+	if opts.AfterPersist != nil {
+		opts.AfterPersist()
+	}
+
+	By("persisting an unchanged resource")
+	_, changed, err = p.Persist(ctx, resource, t, name, opts.SubPath)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(changed).To(BeFalse())
+
+	By("persisting a changed resource")
+	modified := resource.DeepCopy()
+	Expect(unstructured.SetNestedField(modified.Object, "modified", "spec", "value")).To(Succeed())
+	_, changed, err = p.Persist(ctx, modified, t, name, opts.SubPath)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(changed).To(BeTrue())
+	res, err := p.Get(ctx, name, namespace, gvk, opts.SubPath)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(res).ToNot(BeNil())
+	Expect(res.GroupVersionKind()).To(Equal(gvk))
+	Expect(res.GetName()).To(Equal(name))
+	value, _, err := unstructured.NestedString(res.Object, "spec", "value")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(value).To(Equal("modified"))
+
+	By("deleting the resource")
+	Expect(p.Delete(ctx, name, namespace, gvk, opts.SubPath)).To(Succeed())
+	exists, err = p.Exists(ctx, name, namespace, gvk, opts.SubPath)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(exists).To(BeFalse())
+	res, err = p.Get(ctx, name, namespace, gvk, opts.SubPath)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(res).To(BeNil())
+	Expect(p.Delete(ctx, name, namespace, gvk, opts.SubPath)).To(Succeed())
+	if opts.AfterDelete != nil {
+		opts.AfterDelete()
+	}
+}